@@ -0,0 +1,119 @@
+package ar
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/miscellaneous"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// ar/ArabicAnalyzer.java
+
+/*
+The default set of Arabic stop words: common prepositions,
+conjunctions and pronouns, including the variants with attached
+conjunctions and hamza.
+*/
+var ARABIC_STOP_WORDS_SET = map[string]bool{
+	"من": true, "ومن": true, "منها": true, "منه": true, "في": true,
+	"وفي": true, "فيها": true, "فيه": true, "و": true, "ف": true,
+	"ثم": true, "او": true, "أو": true, "ب": true, "بها": true,
+	"به": true, "ا": true, "أ": true, "اى": true, "اي": true,
+	"أي": true, "أى": true, "لا": true, "ولا": true, "الا": true,
+	"ألا": true, "إلا": true, "لكن": true, "ما": true, "وما": true,
+	"كما": true, "فما": true, "عن": true, "مع": true, "اذا": true,
+	"إذا": true, "ان": true, "أن": true, "إن": true, "انها": true,
+	"أنها": true, "إنها": true, "انه": true, "أنه": true, "إنه": true,
+	"بان": true, "بأن": true, "فان": true, "فأن": true, "وان": true,
+	"وأن": true, "وإن": true, "التى": true, "التي": true, "الذى": true,
+	"الذي": true, "الذين": true, "الى": true, "الي": true, "إلى": true,
+	"إلي": true, "على": true, "عليها": true, "عليه": true, "اما": true,
+	"أما": true, "إما": true, "ايضا": true, "أيضا": true, "كل": true,
+	"وكل": true, "لم": true, "ولم": true, "لن": true, "ولن": true,
+	"هى": true, "هي": true, "هو": true, "وهى": true, "وهي": true,
+	"وهو": true, "فهى": true, "فهي": true, "فهو": true, "انت": true,
+	"أنت": true, "لك": true, "لها": true, "له": true, "هذه": true,
+	"هذا": true, "تلك": true, "ذلك": true, "هناك": true, "كانت": true,
+	"كان": true, "يكون": true, "تكون": true, "وكانت": true,
+	"وكان": true, "غير": true, "بعض": true, "قد": true, "نحو": true,
+	"بين": true, "بينما": true, "منذ": true, "ضمن": true, "حيث": true,
+	"الان": true, "الآن": true, "خلال": true, "بعد": true, "قبل": true,
+	"حتى": true, "عند": true, "عندما": true, "لدى": true, "جميع": true,
+}
+
+/*
+Analyzer for Arabic.
+
+This analyzer implements light-stemming as specified by: Light
+Stemming for Arabic Information Retrieval
+(http://www.mtholyoke.edu/~lballest/Pubs/arab_stem05.pdf).
+
+The analysis package contains three primary components:
+
+  - ArabicNormalizationFilter: Arabic orthographic normalization.
+  - ArabicStemFilter: Arabic light stemming
+  - Arabic stop words file: a set of default Arabic stop words.
+*/
+type ArabicAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet  map[string]bool
+	exclusionSet map[string]bool
+}
+
+func init() {
+	RegisterAnalyzer("arabic", func() Analyzer {
+		return NewArabicAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (ARABIC_STOP_WORDS_SET). */
+func NewArabicAnalyzer() *ArabicAnalyzer {
+	return NewArabicAnalyzerWithStopWords(ARABIC_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewArabicAnalyzerWithStopWords(stopWords map[string]bool) *ArabicAnalyzer {
+	return NewArabicAnalyzerWithStemExclusion(stopWords, nil)
+}
+
+/*
+Builds an analyzer with the given stop words. If a non-empty stem
+exclusion set is provided this analyzer will add a
+SetKeywordMarkerFilter before ArabicStemFilter.
+*/
+func NewArabicAnalyzerWithStemExclusion(stopWords, stemExclusionSet map[string]bool) *ArabicAnalyzer {
+	ans := &ArabicAnalyzer{
+		stopWordSet:  stopWords,
+		exclusionSet: make(map[string]bool),
+	}
+	for k, v := range stemExclusionSet {
+		ans.exclusionSet[k] = v
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+/*
+Creates TokenStreamComponents used to tokenize all the text in the
+provided reader, built from a StandardTokenizer filtered with
+LowerCaseFilter, DecimalDigitFilter, StopFilter,
+ArabicNormalizationFilter, SetKeywordMarkerFilter if a stem exclusion
+set is provided and ArabicStemFilter.
+*/
+func (a *ArabicAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = NewLowerCaseFilter(version, src)
+	tok = NewDecimalDigitFilter(tok)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	// the order here is important: the stopword list is not normalized!
+	tok = NewArabicNormalizationFilter(tok)
+	if len(a.exclusionSet) > 0 {
+		tok = NewSetKeywordMarkerFilter(tok, a.exclusionSet)
+	}
+	tok = NewArabicStemFilter(tok)
+	return NewTokenStreamComponents(src, tok)
+}
@@ -0,0 +1,21 @@
+package ar
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestArabicAnalyzer(t *testing.T) {
+	a := NewArabicAnalyzer()
+	// prefixes and suffixes are stemmed
+	analysis.AssertAnalyzesTo(t, a, "الكتاب والكتابات كتابه", "كتاب", "كتاب", "كتاب")
+	// stop words are removed, hamza and digits normalized
+	analysis.AssertAnalyzesTo(t, a, "في أحمد ٢٠١٤", "احمد", "2014")
+	// harakat and tatweel are removed
+	analysis.AssertAnalyzesTo(t, a, "كِتَـاب", "كتاب")
+}
+
+func TestArabicStemExclusion(t *testing.T) {
+	a := NewArabicAnalyzerWithStemExclusion(ARABIC_STOP_WORDS_SET, map[string]bool{"الكتاب": true})
+	analysis.AssertAnalyzesTo(t, a, "الكتاب والكتابات", "الكتاب", "كتاب")
+}
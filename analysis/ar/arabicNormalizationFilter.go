@@ -0,0 +1,36 @@
+package ar
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ar/ArabicNormalizationFilter.java
+
+/* A TokenFilter that applies ArabicNormalizer to normalize the orthography. */
+type ArabicNormalizationFilter struct {
+	*TokenFilter
+	input      TokenStream
+	normalizer *ArabicNormalizer
+	termAtt    CharTermAttribute
+}
+
+func NewArabicNormalizationFilter(input TokenStream) *ArabicNormalizationFilter {
+	ans := &ArabicNormalizationFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		normalizer:  new(ArabicNormalizer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *ArabicNormalizationFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	newlen := f.normalizer.Normalize(f.termAtt.Buffer(), f.termAtt.Length())
+	f.termAtt.SetLength(newlen)
+	return true, nil
+}
@@ -0,0 +1,65 @@
+package ar
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+)
+
+// ar/ArabicNormalizer.java
+
+const (
+	ALEF             = 'ا'
+	ALEF_MADDA       = 'آ'
+	ALEF_HAMZA_ABOVE = 'أ'
+	ALEF_HAMZA_BELOW = 'إ'
+
+	YEH         = 'ي'
+	DOTLESS_YEH = 'ى'
+
+	TEH_MARBUTA = 'ة'
+	HEH         = 'ه'
+
+	TATWEEL = 'ـ'
+
+	FATHATAN = 'ً'
+	DAMMATAN = 'ٌ'
+	KASRATAN = 'ٍ'
+	FATHA    = 'َ'
+	DAMMA    = 'ُ'
+	KASRA    = 'ِ'
+	SHADDA   = 'ّ'
+	SUKUN    = 'ْ'
+)
+
+/*
+Normalizer for Arabic.
+
+Normalization is done in-place for efficiency, operating on a
+termbuffer.
+
+Normalization is defined as:
+
+  - Normalization of hamza with alef seat to a bare alef.
+  - Normalization of teh marbuta to heh
+  - Normalization of dotless yeh (alef maksura) to yeh.
+  - Removal of Arabic diacritics (the harakat)
+  - Removal of tatweel (stretching character).
+*/
+type ArabicNormalizer struct{}
+
+/* Normalize an input buffer of Arabic text, returning the length after normalization. */
+func (n *ArabicNormalizer) Normalize(s []rune, length int) int {
+	for i := 0; i < length; i++ {
+		switch s[i] {
+		case ALEF_MADDA, ALEF_HAMZA_ABOVE, ALEF_HAMZA_BELOW:
+			s[i] = ALEF
+		case DOTLESS_YEH:
+			s[i] = YEH
+		case TEH_MARBUTA:
+			s[i] = HEH
+		case TATWEEL, KASRATAN, DAMMATAN, FATHATAN, FATHA, DAMMA, KASRA, SHADDA, SUKUN:
+			length = Delete(s, i, length)
+			i--
+		}
+	}
+	return length
+}
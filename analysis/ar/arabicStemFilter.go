@@ -0,0 +1,46 @@
+package ar
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ar/ArabicStemFilter.java
+
+/*
+A TokenFilter that applies ArabicStemmer to stem Arabic words.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type ArabicStemFilter struct {
+	*TokenFilter
+	input      TokenStream
+	stemmer    *ArabicStemmer
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+func NewArabicStemFilter(input TokenStream) *ArabicStemFilter {
+	ans := &ArabicStemFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		stemmer:     new(ArabicStemmer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *ArabicStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		newlen := f.stemmer.Stem(f.termAtt.Buffer(), f.termAtt.Length())
+		f.termAtt.SetLength(newlen)
+	}
+	return true, nil
+}
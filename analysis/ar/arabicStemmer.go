@@ -0,0 +1,93 @@
+package ar
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+)
+
+// ar/ArabicStemmer.java
+
+var prefixes = []string{
+	"ال",  // ALEF LAM
+	"وال", // WAW ALEF LAM
+	"بال", // BEH ALEF LAM
+	"كال", // KAF ALEF LAM
+	"فال", // FEH ALEF LAM
+	"لل",  // LAM LAM
+	"و",   // WAW
+}
+
+var suffixes = []string{
+	"ها", // HEH ALEF
+	"ان", // ALEF NOON
+	"ات", // ALEF TEH
+	"ون", // WAW NOON
+	"ين", // YEH NOON
+	"يه", // YEH HEH
+	"ية", // YEH TEH_MARBUTA
+	"ه",  // HEH
+	"ة",  // TEH_MARBUTA
+	"ي",  // YEH
+}
+
+/*
+Stemmer for Arabic.
+
+Stemming is done in-place for efficiency, operating on a termbuffer.
+
+Stemming is defined as:
+
+  - Removal of attached definite article, conjunction, and
+    prepositions.
+  - Stemming of common suffixes.
+*/
+type ArabicStemmer struct{}
+
+/* Stem an input buffer of Arabic text, returning the length after stemming. */
+func (s *ArabicStemmer) Stem(buffer []rune, length int) int {
+	length = s.stemPrefix(buffer, length)
+	length = s.stemSuffix(buffer, length)
+	return length
+}
+
+/* Stem a prefix off an Arabic word. */
+func (s *ArabicStemmer) stemPrefix(buffer []rune, length int) int {
+	for _, prefix := range prefixes {
+		if startsWithCheckLength(buffer, length, prefix) {
+			return DeleteN(buffer, 0, length, len([]rune(prefix)))
+		}
+	}
+	return length
+}
+
+/* Stem suffix(es) off an Arabic word. */
+func (s *ArabicStemmer) stemSuffix(buffer []rune, length int) int {
+	for _, suffix := range suffixes {
+		if endsWithCheckLength(buffer, length, suffix) {
+			n := len([]rune(suffix))
+			length = DeleteN(buffer, length-n, length, n)
+		}
+	}
+	return length
+}
+
+/*
+Returns true if the prefix matches and can be stemmed: the wa- prefix
+requires at least 3 characters to remain, other prefixes only 2.
+*/
+func startsWithCheckLength(s []rune, length int, prefix string) bool {
+	n := len([]rune(prefix))
+	if n == 1 && length < 4 { // wa- prefix requires at least 3 characters
+		return false
+	} else if length < n+2 { // other prefixes require only 2.
+		return false
+	}
+	return StartsWith(s, length, prefix)
+}
+
+/* Returns true if the suffix matches and can be stemmed, leaving at least 2 characters. */
+func endsWithCheckLength(s []rune, length int, suffix string) bool {
+	if length < len([]rune(suffix))+2 { // all suffixes require at least 2 characters after stemming
+		return false
+	}
+	return EndsWith(s, length, suffix)
+}
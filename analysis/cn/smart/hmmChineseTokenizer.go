@@ -0,0 +1,148 @@
+package smart
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+	"unicode"
+)
+
+// cn/smart/HMMChineseTokenizer.java
+
+/* Token types emitted by HMMChineseTokenizer */
+const (
+	WORD_TYPE   = "word"
+	LETTER_TYPE = "letter"
+	NUMBER_TYPE = "number"
+)
+
+/*
+Tokenizer for Chinese or mixed Chinese-English text.
+
+Runs of Chinese characters are segmented into words with a Hidden
+Markov Model. Runs of letters or digits are output as single tokens,
+while white space and punctuation are discarded.
+*/
+type HMMChineseTokenizer struct {
+	*Tokenizer
+
+	model *HMMModel
+
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	typeAtt   TypeAttribute
+
+	buffer []rune
+	// start offset, end offset and type of the pending tokens
+	pending []chineseToken
+	parsed  bool
+}
+
+type chineseToken struct {
+	start, end int
+	typ        string
+}
+
+func NewHMMChineseTokenizer(input io.RuneReader, model *HMMModel) *HMMChineseTokenizer {
+	ans := &HMMChineseTokenizer{
+		Tokenizer: NewTokenizer(input),
+		model:     model,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	return ans
+}
+
+func (t *HMMChineseTokenizer) IncrementToken() (bool, error) {
+	if !t.parsed {
+		if err := t.parse(); err != nil {
+			return false, err
+		}
+	}
+	if len(t.pending) == 0 {
+		return false, nil
+	}
+	token := t.pending[0]
+	t.pending = t.pending[1:]
+
+	t.Attributes().Clear()
+	t.termAtt.CopyBuffer(t.buffer[token.start:token.end])
+	t.offsetAtt.SetOffset(t.CorrectOffset(token.start), t.CorrectOffset(token.end))
+	t.typeAtt.SetType(token.typ)
+	return true, nil
+}
+
+func (t *HMMChineseTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := t.CorrectOffset(len(t.buffer))
+	t.offsetAtt.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (t *HMMChineseTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.buffer = t.buffer[:0]
+	t.pending = t.pending[:0]
+	t.parsed = false
+	return nil
+}
+
+const (
+	charOther = iota
+	charHan
+	charLetter
+	charDigit
+)
+
+func charTypeOf(ch rune) int {
+	switch {
+	case unicode.Is(unicode.Han, ch):
+		return charHan
+	case unicode.IsLetter(ch):
+		return charLetter
+	case unicode.IsDigit(ch):
+		return charDigit
+	}
+	return charOther
+}
+
+func (t *HMMChineseTokenizer) parse() error {
+	for {
+		ch, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		t.buffer = append(t.buffer, ch)
+	}
+	t.parsed = true
+
+	for start := 0; start < len(t.buffer); {
+		typ := charTypeOf(t.buffer[start])
+		end := start + 1
+		for end < len(t.buffer) && charTypeOf(t.buffer[end]) == typ {
+			end++
+		}
+		switch typ {
+		case charHan:
+			from := start
+			for _, to := range t.model.Segment(t.buffer[start:end]) {
+				t.pending = append(t.pending, chineseToken{from, start + to, WORD_TYPE})
+				from = start + to
+			}
+		case charLetter:
+			t.pending = append(t.pending, chineseToken{start, end, LETTER_TYPE})
+		case charDigit:
+			t.pending = append(t.pending, chineseToken{start, end, NUMBER_TYPE})
+		}
+		start = end
+	}
+	return nil
+}
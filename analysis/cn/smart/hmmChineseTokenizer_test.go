@@ -0,0 +1,57 @@
+package smart
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCorpus = `我 是 中国 人
+我 爱 北京 天安门
+中国 人民 爱 和平
+他 是 北京 人
+我们 都 是 中国 人民
+北京 是 中国 的 首都
+天安门 在 北京
+我 爱 中国
+`
+
+func segment(t *testing.T, model *HMMModel, text string) []string {
+	var terms []string
+	for _, token := range analysis.ConsumeTokens(t, NewHMMChineseTokenizer(strings.NewReader(text), model)) {
+		terms = append(terms, token.Term)
+	}
+	return terms
+}
+
+func TestHMMChineseTokenizer(t *testing.T) {
+	model, err := TrainHMMModel(strings.NewReader(testCorpus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"他", "爱", "北京", "天安门", "lucene", "4", "我们", "是", "中国", "人民"}
+	if got := segment(t, model, "他爱北京天安门，lucene 4！我们是中国人民"); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %q, but got %q", expected, got)
+	}
+
+	// unseen characters are single character words
+	expected = []string{"中国", "猫"}
+	if got := segment(t, model, "中国猫"); !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %q, but got %q", expected, got)
+	}
+
+	// the model survives a round trip
+	var buf bytes.Buffer
+	if _, err = model.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadHMMModel(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(model, loaded) {
+		t.Error("loaded model differs from the written one")
+	}
+}
@@ -0,0 +1,312 @@
+package smart
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// cn/smart/hhmm/HHMMSegmenter.java
+
+/*
+The hidden states of the character tagging model: each character is
+the Beginning, Middle or End of a word, or a Single character word.
+*/
+const (
+	STATE_B = iota
+	STATE_M
+	STATE_E
+	STATE_S
+	STATE_COUNT
+)
+
+var stateNames = [STATE_COUNT]string{"B", "M", "E", "S"}
+
+/* Log probability used for events never seen while training. */
+const MIN_LOG_PROB = -3.14e+100
+
+/*
+Hidden Markov Model for Chinese word segmentation. All probabilities
+are stored as natural logarithms.
+
+A model can be trained from a segmented corpus with TrainHMMModel(),
+saved with WriteTo() and loaded again with LoadHMMModel().
+*/
+type HMMModel struct {
+	start [STATE_COUNT]float64
+	trans [STATE_COUNT][STATE_COUNT]float64
+	emit  [STATE_COUNT]map[rune]float64
+}
+
+func newHMMModel() *HMMModel {
+	ans := new(HMMModel)
+	for i := 0; i < STATE_COUNT; i++ {
+		ans.start[i] = MIN_LOG_PROB
+		for j := 0; j < STATE_COUNT; j++ {
+			ans.trans[i][j] = MIN_LOG_PROB
+		}
+		ans.emit[i] = make(map[rune]float64)
+	}
+	return ans
+}
+
+/*
+Trains a model from a segmented corpus, where each line is a sentence
+and words are separated by white space. Counts are add-one smoothed.
+*/
+func TrainHMMModel(r io.Reader) (*HMMModel, error) {
+	var start [STATE_COUNT]float64
+	var trans [STATE_COUNT][STATE_COUNT]float64
+	var emit [STATE_COUNT]map[rune]float64
+	for i := range emit {
+		emit[i] = make(map[rune]float64)
+	}
+	vocabulary := make(map[rune]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		prev := -1
+		for _, word := range strings.Fields(scanner.Text()) {
+			chars := []rune(word)
+			for i, ch := range chars {
+				state := STATE_M
+				switch {
+				case len(chars) == 1:
+					state = STATE_S
+				case i == 0:
+					state = STATE_B
+				case i == len(chars)-1:
+					state = STATE_E
+				}
+				if prev < 0 {
+					start[state]++
+				} else {
+					trans[prev][state]++
+				}
+				emit[state][ch]++
+				vocabulary[ch] = true
+				prev = state
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ans := newHMMModel()
+	logNormalize := func(counts []float64, allowed func(int) bool, dst []float64) {
+		total := 0.0
+		n := 0
+		for i := range counts {
+			if allowed(i) {
+				total += counts[i]
+				n++
+			}
+		}
+		for i := range counts {
+			if allowed(i) {
+				dst[i] = math.Log((counts[i] + 1) / (total + float64(n)))
+			}
+		}
+	}
+	logNormalize(start[:], func(s int) bool { return validStart(s) }, ans.start[:])
+	for i := 0; i < STATE_COUNT; i++ {
+		from := i
+		logNormalize(trans[i][:], func(s int) bool { return validTransition(from, s) }, ans.trans[i][:])
+
+		total := 0.0
+		for _, c := range emit[i] {
+			total += c
+		}
+		for ch := range vocabulary {
+			ans.emit[i][ch] = math.Log((emit[i][ch] + 1) / (total + float64(len(vocabulary)) + 1))
+		}
+	}
+	return ans, nil
+}
+
+func validStart(state int) bool {
+	return state == STATE_B || state == STATE_S
+}
+
+func validTransition(from, to int) bool {
+	switch from {
+	case STATE_B, STATE_M:
+		return to == STATE_M || to == STATE_E
+	}
+	return to == STATE_B || to == STATE_S
+}
+
+/*
+Loads a model written by WriteTo(). Each line is one of:
+
+	start <state> <logprob>
+	trans <from> <to> <logprob>
+	emit <state> <char> <logprob>
+*/
+func LoadHMMModel(r io.Reader) (*HMMModel, error) {
+	ans := newHMMModel()
+	stateOf := func(name string) (int, error) {
+		for i, s := range stateNames {
+			if s == name {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown state: %v", name)
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var err error
+		var from, to int
+		var prob float64
+		switch {
+		case fields[0] == "start" && len(fields) == 3:
+			if from, err = stateOf(fields[1]); err == nil {
+				if prob, err = strconv.ParseFloat(fields[2], 64); err == nil {
+					ans.start[from] = prob
+				}
+			}
+		case fields[0] == "trans" && len(fields) == 4:
+			if from, err = stateOf(fields[1]); err == nil {
+				if to, err = stateOf(fields[2]); err == nil {
+					if prob, err = strconv.ParseFloat(fields[3], 64); err == nil {
+						ans.trans[from][to] = prob
+					}
+				}
+			}
+		case fields[0] == "emit" && len(fields) == 4 && utf8.RuneCountInString(fields[2]) == 1:
+			if from, err = stateOf(fields[1]); err == nil {
+				if prob, err = strconv.ParseFloat(fields[3], 64); err == nil {
+					ch, _ := utf8.DecodeRuneInString(fields[2])
+					ans.emit[from][ch] = prob
+				}
+			}
+		default:
+			err = fmt.Errorf("malformed entry")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", lineNo, err)
+		}
+	}
+	return ans, scanner.Err()
+}
+
+/* Writes this model in the format read by LoadHMMModel(). */
+func (m *HMMModel) WriteTo(w io.Writer) (n int64, err error) {
+	bw := bufio.NewWriter(w)
+	write := func(format string, args ...interface{}) {
+		if err == nil {
+			var written int
+			written, err = fmt.Fprintf(bw, format, args...)
+			n += int64(written)
+		}
+	}
+	for i := 0; i < STATE_COUNT; i++ {
+		if validStart(i) {
+			write("start %v %v\n", stateNames[i], m.start[i])
+		}
+	}
+	for i := 0; i < STATE_COUNT; i++ {
+		for j := 0; j < STATE_COUNT; j++ {
+			if validTransition(i, j) {
+				write("trans %v %v %v\n", stateNames[i], stateNames[j], m.trans[i][j])
+			}
+		}
+	}
+	for i := 0; i < STATE_COUNT; i++ {
+		for ch, prob := range m.emit[i] {
+			write("emit %v %c %v\n", stateNames[i], ch, prob)
+		}
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	return
+}
+
+func (m *HMMModel) emitProb(state int, ch rune) float64 {
+	if p, ok := m.emit[state][ch]; ok {
+		return p
+	}
+	return MIN_LOG_PROB
+}
+
+/*
+Segments the sentence into words using the Viterbi algorithm, and
+returns the end offset (exclusive) of each word.
+*/
+func (m *HMMModel) Segment(sentence []rune) []int {
+	n := len(sentence)
+	if n == 0 {
+		return nil
+	}
+	// characters never seen in training would make every path
+	// equally unlikely; treat them as single character words
+	known := func(ch rune) bool {
+		for i := 0; i < STATE_COUNT; i++ {
+			if _, ok := m.emit[i][ch]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	prob := make([][STATE_COUNT]float64, n)
+	back := make([][STATE_COUNT]int, n)
+	for s := 0; s < STATE_COUNT; s++ {
+		prob[0][s] = m.start[s] + m.emitProbOrSingle(s, sentence[0], known)
+	}
+	for i := 1; i < n; i++ {
+		for s := 0; s < STATE_COUNT; s++ {
+			best, bestFrom := math.Inf(-1), 0
+			for from := 0; from < STATE_COUNT; from++ {
+				if !validTransition(from, s) {
+					continue
+				}
+				if p := prob[i-1][from] + m.trans[from][s]; p > best {
+					best, bestFrom = p, from
+				}
+			}
+			prob[i][s] = best + m.emitProbOrSingle(s, sentence[i], known)
+			back[i][s] = bestFrom
+		}
+	}
+
+	// the last character must end a word
+	state := STATE_E
+	if prob[n-1][STATE_S] > prob[n-1][STATE_E] {
+		state = STATE_S
+	}
+	states := make([]int, n)
+	for i := n - 1; i >= 0; i-- {
+		states[i] = state
+		state = back[i][state]
+	}
+
+	var ends []int
+	for i, s := range states {
+		if s == STATE_E || s == STATE_S {
+			ends = append(ends, i+1)
+		}
+	}
+	return ends
+}
+
+func (m *HMMModel) emitProbOrSingle(state int, ch rune, known func(rune) bool) float64 {
+	if known(ch) {
+		return m.emitProb(state, ch)
+	}
+	if state == STATE_S {
+		return 0
+	}
+	return MIN_LOG_PROB
+}
@@ -0,0 +1,51 @@
+package smart
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// cn/smart/SmartChineseAnalyzer.java
+
+/*
+SmartChineseAnalyzer is an analyzer for Chinese or mixed
+Chinese-English text. The analyzer uses a Hidden Markov Model to find
+the most likely segmentation of Chinese sentences into words.
+
+Filters HMMChineseTokenizer with LowerCaseFilter and StopFilter. There
+are no default stop words, as punctuation is already dropped by the
+tokenizer.
+*/
+type SmartChineseAnalyzer struct {
+	*StopwordAnalyzerBase
+	model     *HMMModel
+	stopWords map[string]bool
+}
+
+/* Builds an analyzer segmenting with the given model. */
+func NewSmartChineseAnalyzer(model *HMMModel) *SmartChineseAnalyzer {
+	return NewSmartChineseAnalyzerWithStopWords(model, nil)
+}
+
+/* Builds an analyzer segmenting with the given model and removing the given stop words. */
+func NewSmartChineseAnalyzerWithStopWords(model *HMMModel, stopWords map[string]bool) *SmartChineseAnalyzer {
+	ans := &SmartChineseAnalyzer{
+		model:     model,
+		stopWords: stopWords,
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *SmartChineseAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := NewHMMChineseTokenizer(reader, a.model)
+	var tok TokenStream = NewLowerCaseFilter(version, src)
+	if len(a.stopWords) > 0 {
+		tok = NewStopFilter(version, tok, a.stopWords)
+	}
+	return NewTokenStreamComponents(src, tok)
+}
@@ -0,0 +1,52 @@
+package collation
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/document"
+	"io"
+)
+
+// collation/CollationKeyAnalyzer.java
+
+/*
+Configures KeywordTokenizer with CollationKeyFilter.
+
+Converts the whole field value into its collation key, which is then
+indexed as a single term. This allows sorting and range queries which
+follow the locale's rules, e.g. Swedish 'å', 'ä' and 'ö' sorting after
+'z', or case-insensitive ordering when the collator ignores case.
+
+The same Collator must be used to analyze the query terms.
+*/
+type CollationKeyAnalyzer struct {
+	*AnalyzerImpl
+	collator Collator
+}
+
+func NewCollationKeyAnalyzer(collator Collator) *CollationKeyAnalyzer {
+	ans := &CollationKeyAnalyzer{collator: collator}
+	ans.AnalyzerImpl = NewAnalyzer()
+	ans.Spi = ans
+	return ans
+}
+
+func (a *CollationKeyAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	src := NewKeywordTokenizer(reader)
+	return NewTokenStreamComponents(src, NewCollationKeyFilter(src, a.collator))
+}
+
+// collation/CollationDocValuesField.java
+
+/*
+Returns a single-valued SortedDocValuesField holding the collation key
+of the value, so that the ordinals of the values follow the order of
+the collator.
+
+This is more efficient than CollationKeyAnalyzer if the field only has
+one value: no uninversion is necessary to sort on the field. Store the
+original value in a separate field if it needs to be retrieved.
+*/
+func NewCollationField(name, value string, collator Collator) *document.SortedDocValuesField {
+	return document.NewSortedDocValuesField(name, collator.Key(value))
+}
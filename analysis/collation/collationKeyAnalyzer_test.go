@@ -0,0 +1,139 @@
+package collation
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// case-insensitive collator with the Swedish alphabet, where å, ä and ö follow z
+var swedish = CollatorFunc(func(s string) []byte {
+	const alphabet = "abcdefghijklmnopqrstuvwxyzåäö"
+	var key []byte
+	for _, ch := range strings.ToLower(s) {
+		if i := strings.IndexRune(alphabet, ch); i >= 0 {
+			key = append(key, byte(len([]rune(alphabet[:i]))+1))
+		} else {
+			key = append(key, 0xff)
+		}
+	}
+	return key
+})
+
+func collationTerm(t *testing.T, a *CollationKeyAnalyzer, text string) []byte {
+	ts, err := a.TokenStreamForString("field", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	termAtt := ts.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	if err = ts.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	ok, err := ts.IncrementToken()
+	if err != nil || !ok {
+		t.Fatalf("expected one token, got %v, %v", ok, err)
+	}
+	term := []byte(string(termAtt.Buffer()[:termAtt.Length()]))
+	if ok, err = ts.IncrementToken(); err != nil || ok {
+		t.Fatalf("expected a single token, got %v, %v", ok, err)
+	}
+	if err = ts.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return term
+}
+
+func TestCollationKeyAnalyzer(t *testing.T) {
+	a := NewCollationKeyAnalyzer(swedish)
+	// in Swedish order
+	words := []string{"Apa", "banan", "zebra", "ål", "äng", "Ör"}
+	terms := make(map[string][]byte)
+	for _, w := range words {
+		terms[w] = collationTerm(t, a, w)
+	}
+	for i := 1; i < len(words); i++ {
+		if bytes.Compare(terms[words[i-1]], terms[words[i]]) >= 0 {
+			t.Errorf("expected %v to sort before %v", words[i-1], words[i])
+		}
+	}
+	if !bytes.Equal(collationTerm(t, a, "ÅL"), terms["ål"]) {
+		t.Error("expected case-insensitive keys")
+	}
+	if key := DecodeKey([]rune(string(terms["banan"]))); !bytes.Equal(key, swedish.Key("banan")) {
+		t.Errorf("expected decoded key %v, but got %v", swedish.Key("banan"), key)
+	}
+}
+
+/* Returns the values of the string field "word" in the order of the sorted doc values of field. */
+func wordsByOrd(t *testing.T, r index.DirectoryReader, field string) []string {
+	values, err := index.GetSortedValues(r, field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values == nil {
+		t.Fatalf("expected sorted doc values for field %v", field)
+	}
+	ans := make([]string, values.ValueCount())
+	for doc := 0; doc < r.MaxDoc(); doc++ {
+		d, err := r.Document(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ans[values.Ord(doc)] = d.Get("word")
+	}
+	return ans
+}
+
+func TestCollationField(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+	dir := store.NewRAMDirectory()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, NewCollationKeyAnalyzer(swedish))
+	w, err := index.NewIndexWriter(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// in reverse Swedish order, in two segments
+	words := []string{"Ör", "äng", "ål", "zebra", "banan", "Apa"}
+	for i, word := range words {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("word", word, docu.STORE_YES))
+		d.Add(NewCollationField("collated", word, swedish))
+		if err = w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			if err = w.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if n := len(r.Leaves()); n != 2 {
+		t.Fatalf("expected 2 segments, but got %v", n)
+	}
+	expected := []string{"Apa", "banan", "zebra", "ål", "äng", "Ör"}
+	if actual := wordsByOrd(t, r, "collated"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,44 @@
+package collation
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// collation/CollationKeyFilter.java
+
+/*
+Converts each token into its collation key, encoded with EncodeKey(),
+so that the indexed terms can be used for locale-aware sorting and
+range queries.
+
+WARNING: make sure you use exactly the same Collator at index and
+query time -- collation keys are only comparable when produced by the
+same Collator, with the same locale and options.
+*/
+type CollationKeyFilter struct {
+	*TokenFilter
+	input    TokenStream
+	collator Collator
+	termAtt  CharTermAttribute
+}
+
+func NewCollationKeyFilter(input TokenStream, collator Collator) *CollationKeyFilter {
+	ans := &CollationKeyFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		collator:    collator,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *CollationKeyFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	term := string(f.termAtt.Buffer()[:f.termAtt.Length()])
+	f.termAtt.CopyBuffer(EncodeKey(f.collator.Key(term)))
+	return true, nil
+}
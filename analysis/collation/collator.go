@@ -0,0 +1,39 @@
+package collation
+
+/*
+Collator produces sort keys for strings, so that comparing the keys
+byte-wise gives the locale specific order of the strings. See
+NewTextCollator() for a Collator based on golang.org/x/text/collate.
+*/
+type Collator interface {
+	// Returns the collation key for the given string. The key must not
+	// be modified by later calls.
+	Key(s string) []byte
+}
+
+/* CollatorFunc adapts an ordinary function to the Collator interface. */
+type CollatorFunc func(s string) []byte
+
+func (f CollatorFunc) Key(s string) []byte { return f(s) }
+
+/*
+Encodes a collation key as indexable term text, one rune per byte.
+Runes in [0,255] are encoded to UTF-8 in the same order as their
+values, so the indexed terms sort in the same order as the keys.
+*/
+func EncodeKey(key []byte) []rune {
+	ans := make([]rune, len(key))
+	for i, b := range key {
+		ans[i] = rune(b)
+	}
+	return ans
+}
+
+/* Decodes term text produced by EncodeKey() back to the collation key. */
+func DecodeKey(text []rune) []byte {
+	ans := make([]byte, len(text))
+	for i, ch := range text {
+		ans[i] = byte(ch)
+	}
+	return ans
+}
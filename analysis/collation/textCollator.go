@@ -0,0 +1,38 @@
+//go:build xtext
+// +build xtext
+
+package collation
+
+import (
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"sync"
+)
+
+/*
+Collator backed by golang.org/x/text/collate, implementing the Unicode
+Collation Algorithm with the CLDR tailorings of the given language.
+Only built with the "xtext" build tag, to avoid the dependency; test.sh
+and CI also run the tests of the package with it.
+*/
+type TextCollator struct {
+	sync.Mutex
+	collator *collate.Collator
+	buf      collate.Buffer
+}
+
+/*
+Returns a Collator for the given language, e.g.
+NewTextCollator(language.Swedish, collate.IgnoreCase).
+*/
+func NewTextCollator(tag language.Tag, options ...collate.Option) *TextCollator {
+	return &TextCollator{collator: collate.New(tag, options...)}
+}
+
+func (c *TextCollator) Key(s string) []byte {
+	c.Lock()
+	defer c.Unlock()
+	c.buf.Reset()
+	key := c.collator.KeyFromString(&c.buf, s)
+	return append([]byte(nil), key...)
+}
@@ -0,0 +1,45 @@
+//go:build xtext
+// +build xtext
+
+package collation
+
+import (
+	"bytes"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"testing"
+)
+
+func TestTextCollator(t *testing.T) {
+	c := NewTextCollator(language.Swedish, collate.IgnoreCase)
+	// in Swedish order, å, ä and ö follow z
+	words := []string{"Apa", "banan", "zebra", "ål", "äng", "Ör"}
+	for i := 1; i < len(words); i++ {
+		if bytes.Compare(c.Key(words[i-1]), c.Key(words[i])) >= 0 {
+			t.Errorf("expected %v to sort before %v", words[i-1], words[i])
+		}
+	}
+	if !bytes.Equal(c.Key("ÅL"), c.Key("ål")) {
+		t.Error("expected case-insensitive keys")
+	}
+	// keys are not overwritten by later calls
+	key := c.Key("banan")
+	expected := append([]byte(nil), key...)
+	c.Key("zebra")
+	if !bytes.Equal(key, expected) {
+		t.Errorf("expected key %v, but got %v", expected, key)
+	}
+
+	// in German, ä sorts with a
+	de := NewTextCollator(language.German)
+	if bytes.Compare(de.Key("äng"), de.Key("banan")) >= 0 {
+		t.Error("expected äng to sort before banan in German")
+	}
+}
+
+func TestTextCollatorAnalyzer(t *testing.T) {
+	a := NewCollationKeyAnalyzer(NewTextCollator(language.Swedish))
+	if bytes.Compare(collationTerm(t, a, "zebra"), collationTerm(t, a, "ål")) >= 0 {
+		t.Error("expected zebra to sort before ål")
+	}
+}
@@ -0,0 +1,142 @@
+package commongrams
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// commongrams/CommonGramsFilter.java
+
+/* Token type of the bigrams produced by CommonGramsFilter. */
+const GRAM_TYPE = "gram"
+
+const SEPARATOR = '_'
+
+/*
+Construct bigrams for frequently occurring terms while indexing.
+Single terms are still indexed too, with bigrams overlaid. This is
+achieved through the use of PositionIncrementAttribute.SetPositionIncrement().
+Bigrams have a type of GRAM_TYPE. Example:
+
+  - input: "the quick brown fox"
+  - output: |"the","the_quick"|"quick"|"brown"|"fox"|
+  - "the_quick" has a position increment of 0 so it is in the same
+    position as "the"; "the_quick" has a type of "gram"
+
+Phrase queries containing common words can then use the bigrams,
+whose postings are much shorter than the ones of the common words
+themselves, instead of dropping the common words as stop words.
+*/
+type CommonGramsFilter struct {
+	*TokenFilter
+	input TokenStream
+
+	commonWords map[string]bool
+	buffer      []rune
+
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	typeAtt   TypeAttribute
+	posIncAtt PositionIncrementAttribute
+	posLenAtt PositionLengthAttribute
+
+	lastStartOffset int
+	lastWasCommon   bool
+	savedState      *util.AttributeState
+}
+
+/*
+Construct a token stream filtering the given input using a Set of
+common words to create bigrams. Outputs both unigrams with position
+increment and bigrams with position increment 0 type=gram where one
+or both of the words in a potential bigram are in the set of common
+words.
+*/
+func NewCommonGramsFilter(matchVersion util.Version, input TokenStream, commonWords map[string]bool) *CommonGramsFilter {
+	ans := &CommonGramsFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		commonWords: commonWords,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	return ans
+}
+
+/*
+Inserts bigrams for common words into a token stream. For each input
+token, output the token. If the token and/or the following token are
+in the list of common words also output a bigram with position
+increment 0 and type="gram"
+*/
+func (f *CommonGramsFilter) IncrementToken() (bool, error) {
+	// get the next piece of input
+	if f.savedState != nil {
+		f.Attributes().RestoreState(f.savedState)
+		f.savedState = nil
+		f.saveTermBuffer()
+		return true, nil
+	}
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+
+	// We build n-grams before and after stopwords. When valid, the
+	// buffer always contains at least the separator. If its empty,
+	// there is nothing before this stopword.
+	if f.lastWasCommon || (f.isCommon() && len(f.buffer) > 0) {
+		f.savedState = f.Attributes().CaptureState()
+		f.gramToken()
+		return true, nil
+	}
+
+	f.saveTermBuffer()
+	return true, nil
+}
+
+func (f *CommonGramsFilter) Reset() error {
+	if err := f.TokenFilter.Reset(); err != nil {
+		return err
+	}
+	f.lastWasCommon = false
+	f.savedState = nil
+	f.buffer = f.buffer[:0]
+	return nil
+}
+
+/* Determines if the current token is a common term */
+func (f *CommonGramsFilter) isCommon() bool {
+	if f.commonWords == nil {
+		return false
+	}
+	_, ok := f.commonWords[string(f.termAtt.Buffer()[:f.termAtt.Length()])]
+	return ok
+}
+
+/* Saves this information to form the left part of a gram */
+func (f *CommonGramsFilter) saveTermBuffer() {
+	f.buffer = append(f.buffer[:0], f.termAtt.Buffer()[:f.termAtt.Length()]...)
+	f.buffer = append(f.buffer, SEPARATOR)
+	f.lastStartOffset = f.offsetAtt.StartOffset()
+	f.lastWasCommon = f.isCommon()
+}
+
+/* Constructs a compound token. */
+func (f *CommonGramsFilter) gramToken() {
+	f.buffer = append(f.buffer, f.termAtt.Buffer()[:f.termAtt.Length()]...)
+	endOffset := f.offsetAtt.EndOffset()
+
+	f.Attributes().Clear()
+
+	f.termAtt.CopyBuffer(f.buffer)
+	f.posIncAtt.SetPositionIncrement(0)
+	f.posLenAtt.SetPositionLength(2) // bigram
+	f.offsetAtt.SetOffset(f.lastStartOffset, endOffset)
+	f.typeAtt.SetType(GRAM_TYPE)
+	f.buffer = f.buffer[:0]
+}
@@ -0,0 +1,77 @@
+package commongrams
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var commonWords = map[string]bool{"the": true, "in": true, "of": true}
+
+/* Checks the tokens of ts, as term/posInc/posLen, grams marked by a '*'. */
+func assertGrams(t *testing.T, ts TokenStream, expected ...string) {
+	t.Helper()
+	var got []string
+	for _, token := range analysis.ConsumeTokens(t, ts) {
+		s := fmt.Sprintf("%v/%v/%v", token.Term, token.PosInc, token.PosLen)
+		if token.Type == GRAM_TYPE {
+			s += "*"
+		}
+		got = append(got, s)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, but got %v", expected, got)
+	}
+}
+
+func newCommonGramsFilter(input string) *CommonGramsFilter {
+	src := std.NewStandardTokenizer(util.VERSION_LATEST, strings.NewReader(input))
+	return NewCommonGramsFilter(util.VERSION_LATEST, src, commonWords)
+}
+
+func TestCommonGramsFilter(t *testing.T) {
+	for _, test := range []struct {
+		query    bool
+		input    string
+		expected []string
+	}{
+		{false, "the rain in spain falls mainly", []string{
+			"the/1/1", "the_rain/0/2*", "rain/1/1", "rain_in/0/2*", "in/1/1",
+			"in_spain/0/2*", "spain/1/1", "falls/1/1", "mainly/1/1"}},
+		// consecutive common words
+		{false, "out of the way", []string{
+			"out/1/1", "out_of/0/2*", "of/1/1", "of_the/0/2*", "the/1/1", "the_way/0/2*", "way/1/1"}},
+		{false, "the", []string{"the/1/1"}},
+		{false, "quick fox", []string{"quick/1/1", "fox/1/1"}},
+		// the query filter only keeps the words which are not part of a bigram
+		{true, "the rain in spain falls mainly", []string{
+			"the_rain/1/1*", "rain_in/1/1*", "in_spain/1/1*", "spain/1/1", "falls/1/1", "mainly/1/1"}},
+		{true, "rain in", []string{"rain_in/1/1*"}},
+		{true, "the", []string{"the/1/1"}},
+		{true, "quick fox", []string{"quick/1/1", "fox/1/1"}},
+	} {
+		filter := newCommonGramsFilter(test.input)
+		var f TokenStream = filter
+		if test.query {
+			f = NewCommonGramsQueryFilter(filter)
+		}
+		assertGrams(t, f, test.expected...)
+	}
+}
+
+func TestCommonGramsQueryFilterReset(t *testing.T) {
+	src := std.NewStandardTokenizer(util.VERSION_LATEST, strings.NewReader("the wifi network"))
+	f := NewCommonGramsQueryFilter(NewCommonGramsFilter(util.VERSION_LATEST, src, commonWords))
+	expected := []string{"the_wifi/1/1*", "wifi/1/1", "network/1/1"}
+	assertGrams(t, f, expected...)
+	// the filter can be reused on a new input, once closed
+	if err := src.SetReader(strings.NewReader("the wifi network")); err != nil {
+		t.Fatal(err)
+	}
+	assertGrams(t, f, expected...)
+}
@@ -0,0 +1,113 @@
+package commongrams
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// commongrams/CommonGramsQueryFilter.java
+
+/*
+Wrap a CommonGramsFilter optimizing phrase queries by only returning
+single words when they are not a member of a bigram.
+
+Example:
+
+  - query input to CommonGramsFilter: "the rain in spain falls mainly"
+  - output of CommonGramsFilter/input to CommonGramsQueryFilter:
+    |"the","the_rain"|"rain","rain_in"|"in","in_spain"|"spain"|"falls"|"mainly"|
+  - output of CommonGramsQueryFilter: "the_rain", "rain_in", "in_spain",
+    "spain", "falls", "mainly"
+*/
+type CommonGramsQueryFilter struct {
+	*TokenFilter
+	input TokenStream
+
+	typeAtt   TypeAttribute
+	posIncAtt PositionIncrementAttribute
+	posLenAtt PositionLengthAttribute
+
+	previous     *util.AttributeState
+	previousType string
+	exhausted    bool
+}
+
+/* Constructs a new CommonGramsQueryFilter based on the provided CommonGramsFilter */
+func NewCommonGramsQueryFilter(input *CommonGramsFilter) *CommonGramsQueryFilter {
+	ans := &CommonGramsQueryFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	return ans
+}
+
+func (f *CommonGramsQueryFilter) Reset() error {
+	if err := f.TokenFilter.Reset(); err != nil {
+		return err
+	}
+	f.previous = nil
+	f.previousType = ""
+	f.exhausted = false
+	return nil
+}
+
+/*
+Output bigrams whenever possible to optimize queries. Only output
+unigrams when they are not a member of a bigram. Example:
+
+  - input: "the rain in spain falls mainly"
+  - output: "the_rain", "rain_in", "in_spain", "spain", "falls", "mainly"
+*/
+func (f *CommonGramsQueryFilter) IncrementToken() (bool, error) {
+	for !f.exhausted {
+		ok, err := f.input.IncrementToken()
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			break
+		}
+		current := f.Attributes().CaptureState()
+
+		if f.previous != nil && !f.IsGramType() {
+			f.Attributes().RestoreState(f.previous)
+			f.previous = current
+			f.previousType = f.typeAtt.Type()
+
+			if f.IsGramType() {
+				f.posIncAtt.SetPositionIncrement(1)
+				// We must set this back to 1 (from e.g. 2 or higher)
+				// otherwise the query will try to treat this gram as a
+				// phrase.
+				f.posLenAtt.SetPositionLength(1)
+			}
+			return true, nil
+		}
+
+		f.previous = current
+	}
+
+	f.exhausted = true
+
+	if f.previous == nil || f.previousType == GRAM_TYPE {
+		return false, nil
+	}
+
+	f.Attributes().RestoreState(f.previous)
+	f.previous = nil
+
+	if f.IsGramType() {
+		f.posIncAtt.SetPositionIncrement(1)
+		f.posLenAtt.SetPositionLength(1)
+	}
+	return true, nil
+}
+
+/* Convenience method to check if the current type is a gram type */
+func (f *CommonGramsQueryFilter) IsGramType() bool {
+	return f.typeAtt.Type() == GRAM_TYPE
+}
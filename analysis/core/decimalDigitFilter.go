@@ -0,0 +1,66 @@
+package core
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"unicode"
+)
+
+// core/DecimalDigitFilter.java
+
+/*
+Folds all Unicode digits in [:General_Category=Decimal_Number:] to
+Basic Latin digits (0-9), so that numbers written in different
+scripts, e.g. Arabic-Indic '٣' or Devanagari '३', match each other.
+*/
+type DecimalDigitFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+func NewDecimalDigitFilter(in TokenStream) *DecimalDigitFilter {
+	ans := &DecimalDigitFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *DecimalDigitFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	buffer := f.termAtt.Buffer()[:f.termAtt.Length()]
+	for i, ch := range buffer {
+		if ch > '9' {
+			if v, ok := digitValue(ch); ok {
+				buffer[i] = '0' + v
+			}
+		}
+	}
+	return true, nil
+}
+
+/*
+Returns the value of a decimal digit. The digits of each script are
+encoded as runs of ten consecutive code points starting at zero.
+*/
+func digitValue(ch rune) (rune, bool) {
+	if ch <= 0xFFFF {
+		for _, r := range unicode.Nd.R16 {
+			if c := uint16(ch); c >= r.Lo && c <= r.Hi {
+				return rune(c-r.Lo) % 10, true
+			}
+		}
+		return 0, false
+	}
+	for _, r := range unicode.Nd.R32 {
+		if c := uint32(ch); c >= r.Lo && c <= r.Hi {
+			return rune(c-r.Lo) % 10, true
+		}
+	}
+	return 0, false
+}
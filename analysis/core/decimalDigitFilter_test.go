@@ -0,0 +1,21 @@
+package core
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+func TestDecimalDigitFilter(t *testing.T) {
+	for input, expected := range map[string]string{
+		"123":        "123",
+		"٠١٢٣٤٥٦٧٨٩": "0123456789", // Arabic-Indic
+		"۱۲۳":        "123",        // Extended Arabic-Indic
+		"अंक१२३":     "अंक123",     // Devanagari
+		"x𝟙𝟚":        "x12",        // mathematical bold digits
+		"Ⅻ²":         "Ⅻ²",         // not decimal digits
+	} {
+		f := NewDecimalDigitFilter(NewKeywordTokenizer(strings.NewReader(input)))
+		analysis.AssertTokenStreamContents(t, f, expected)
+	}
+}
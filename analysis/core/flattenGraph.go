@@ -0,0 +1,186 @@
+package core
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// core/FlattenGraphFilter.java
+
+/*
+Converts an incoming graph token stream, such as one from a synonym
+graph filter, into a flat form so that all nodes form a single linear
+chain with no side paths. Every path through the graph touches every
+node. This is necessary when indexing a graph token stream, because
+the index does not save PositionLengthAttribute and so it cannot
+preserve the graph structure. However, at search time, query builders
+can correctly handle the graph and this token filter should not be
+used.
+
+Each section of the graph between two nodes that no token spans
+across is buffered, and its nodes are renumbered by the longest path
+leading to them, so side paths are squashed onto the longest one.
+Position increments and lengths are rewritten accordingly; holes
+left by removed tokens are preserved.
+
+If the graph was not already flat to start, this is likely a lossy
+process, i.e. it will often cause the graph to accept token sequences
+it should not, and to reject token sequences it should.
+*/
+type FlattenGraphFilter struct {
+	*TokenFilter
+	input     TokenStream
+	posIncAtt PositionIncrementAttribute
+	posLenAtt PositionLengthAttribute
+
+	// tokens of the current graph section
+	pending []*graphToken
+	// flattened tokens waiting to be emitted
+	output []*graphToken
+	// the input position of the last token read
+	inputPos int
+	// the input node the current section can't end before
+	maxTo int
+	// where the last flushed section ended, in the input and output
+	lastInputNode, lastOutputNode int
+	// output position of the last emitted token
+	lastOutputPos int
+	done          bool
+}
+
+type graphToken struct {
+	state    *util.AttributeState
+	from, to int
+	// output positions, assigned when flushed
+	outFrom, outTo int
+}
+
+func NewFlattenGraphFilter(in TokenStream) *FlattenGraphFilter {
+	ans := &FlattenGraphFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+	}
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	ans.resetState()
+	return ans
+}
+
+func (f *FlattenGraphFilter) IncrementToken() (bool, error) {
+	for {
+		if len(f.output) > 0 {
+			token := f.output[0]
+			f.output = f.output[1:]
+			f.Attributes().RestoreState(token.state)
+			f.posIncAtt.SetPositionIncrement(token.outFrom - f.lastOutputPos)
+			f.posLenAtt.SetPositionLength(token.outTo - token.outFrom)
+			f.lastOutputPos = token.outFrom
+			return true, nil
+		}
+		if f.done {
+			return false, nil
+		}
+
+		ok, err := f.input.IncrementToken()
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			f.done = true
+			f.flush()
+			continue
+		}
+
+		if f.inputPos += f.posIncAtt.PositionIncrement(); f.inputPos < 0 {
+			f.inputPos = 0 // first token can't have a position increment of 0
+		}
+		if len(f.pending) > 0 && f.inputPos >= f.maxTo {
+			// no pending token spans across this node: the section is
+			// complete
+			f.flush()
+		}
+		token := &graphToken{
+			state: f.Attributes().CaptureState(),
+			from:  f.inputPos,
+			to:    f.inputPos + f.posLenAtt.PositionLength(),
+		}
+		f.pending = append(f.pending, token)
+		if token.to > f.maxTo {
+			f.maxTo = token.to
+		}
+	}
+}
+
+/* Assigns output positions to the pending section, and queues it for output. */
+func (f *FlattenGraphFilter) flush() {
+	if len(f.pending) == 0 {
+		return
+	}
+
+	var nodes []int
+	seen := make(map[int]bool)
+	arriving := make(map[int][]*graphToken)
+	for _, token := range f.pending {
+		for _, n := range []int{token.from, token.to} {
+			if !seen[n] {
+				seen[n] = true
+				nodes = append(nodes, n)
+			}
+		}
+		arriving[token.to] = append(arriving[token.to], token)
+	}
+	sort.Ints(nodes)
+
+	out := make(map[int]int)
+	for i, n := range nodes {
+		if tokens := arriving[n]; len(tokens) > 0 {
+			// the longest path leading to this node
+			out[n] = -1
+			for _, token := range tokens {
+				if pos := out[token.from] + 1; pos > out[n] {
+					out[n] = pos
+				}
+			}
+		} else if i > 0 {
+			// a hole inside the section
+			out[n] = out[nodes[i-1]] + n - nodes[i-1]
+		} else {
+			// the start of the section, which may follow a hole
+			out[n] = f.lastOutputNode + n - f.lastInputNode
+		}
+	}
+
+	for _, token := range f.pending {
+		token.outFrom, token.outTo = out[token.from], out[token.to]
+	}
+	sort.Stable(byOutputPosition(f.pending))
+	f.output = append(f.output, f.pending...)
+
+	last := nodes[len(nodes)-1]
+	f.lastInputNode, f.lastOutputNode = last, out[last]
+	f.pending = nil
+}
+
+type byOutputPosition []*graphToken
+
+func (a byOutputPosition) Len() int           { return len(a) }
+func (a byOutputPosition) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byOutputPosition) Less(i, j int) bool { return a[i].outFrom < a[j].outFrom }
+
+func (f *FlattenGraphFilter) resetState() {
+	f.pending, f.output = nil, nil
+	f.inputPos, f.maxTo = -1, 0
+	f.lastInputNode, f.lastOutputNode = 0, 0
+	f.lastOutputPos = -1
+	f.done = false
+}
+
+func (f *FlattenGraphFilter) Reset() error {
+	if err := f.TokenFilter.Reset(); err != nil {
+		return err
+	}
+	f.resetState()
+	return nil
+}
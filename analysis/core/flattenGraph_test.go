@@ -0,0 +1,106 @@
+package core
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"testing"
+)
+
+type graphTestToken struct {
+	term           string
+	posInc, posLen int
+}
+
+/* Emits the given tokens, with their position increments and lengths. */
+type graphTestTokenStream struct {
+	*TokenStreamImpl
+	termAtt   CharTermAttribute
+	posIncAtt PositionIncrementAttribute
+	posLenAtt PositionLengthAttribute
+	tokens    []graphTestToken
+	upto      int
+}
+
+func newGraphTestTokenStream(tokens ...graphTestToken) *graphTestTokenStream {
+	ans := &graphTestTokenStream{TokenStreamImpl: NewTokenStream(), tokens: tokens}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	return ans
+}
+
+func (ts *graphTestTokenStream) Reset() error {
+	ts.upto = 0
+	return nil
+}
+
+func (ts *graphTestTokenStream) IncrementToken() (bool, error) {
+	if ts.upto == len(ts.tokens) {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	token := ts.tokens[ts.upto]
+	ts.termAtt.AppendString(token.term)
+	ts.posIncAtt.SetPositionIncrement(token.posInc)
+	ts.posLenAtt.SetPositionLength(token.posLen)
+	ts.upto++
+	return true, nil
+}
+
+func TestFlattenGraphFilter(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		input, output []graphTestToken
+	}{
+		{"already flat", []graphTestToken{
+			{"new", 1, 1}, {"ny", 0, 2}, {"york", 1, 1}, {"city", 1, 1},
+		}, []graphTestToken{
+			{"new", 1, 1}, {"ny", 0, 2}, {"york", 1, 1}, {"city", 1, 1},
+		}},
+		{"hole", []graphTestToken{
+			{"quick", 1, 1}, {"fox", 2, 1},
+		}, []graphTestToken{
+			{"quick", 1, 1}, {"fox", 2, 1},
+		}},
+		// nothing goes through position 1, so wtf is squashed
+		{"no side path", []graphTestToken{
+			{"wtf", 1, 2}, {"happened", 2, 1},
+		}, []graphTestToken{
+			{"wtf", 1, 1}, {"happened", 1, 1},
+		}},
+		{"side path", []graphTestToken{
+			{"wtf", 1, 3}, {"what", 0, 1}, {"the", 1, 1}, {"fudge", 1, 1}, {"happened", 1, 1},
+		}, []graphTestToken{
+			{"wtf", 1, 3}, {"what", 0, 1}, {"the", 1, 1}, {"fudge", 1, 1}, {"happened", 1, 1},
+		}},
+		// the section of the side path is squashed onto the longest one,
+		// keeping the hole before now
+		{"longer token", []graphTestToken{
+			{"usa", 1, 4}, {"united", 0, 1}, {"states", 1, 1}, {"of", 1, 2}, {"now", 3, 1},
+		}, []graphTestToken{
+			{"usa", 1, 3}, {"united", 0, 1}, {"states", 1, 1}, {"of", 1, 1}, {"now", 2, 1},
+		}},
+	} {
+		f := NewFlattenGraphFilter(newGraphTestTokenStream(test.input...))
+		termAtt := f.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+		if err := f.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		for i, expected := range test.output {
+			if ok, err := f.IncrementToken(); err != nil || !ok {
+				t.Fatalf("%v: expected token %v, got %v, %v", test.name, i, ok, err)
+			}
+			got := graphTestToken{
+				string(termAtt.Buffer()[:termAtt.Length()]),
+				f.posIncAtt.PositionIncrement(),
+				f.posLenAtt.PositionLength(),
+			}
+			if got != expected {
+				t.Errorf("%v: expected token %v to be %v, but got %v", test.name, i, expected, got)
+			}
+		}
+		if ok, err := f.IncrementToken(); err != nil || ok {
+			t.Errorf("%v: expected no more tokens, got %v, %v", test.name, ok, err)
+		}
+	}
+}
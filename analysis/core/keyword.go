@@ -0,0 +1,65 @@
+package core
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+)
+
+// core/KeywordTokenizer.java
+
+/* Emits the entire input as a single token. */
+type KeywordTokenizer struct {
+	*Tokenizer
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	done      bool
+	finalOff  int
+}
+
+func NewKeywordTokenizer(input io.RuneReader) *KeywordTokenizer {
+	ans := &KeywordTokenizer{Tokenizer: NewTokenizer(input)}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	return ans
+}
+
+func (t *KeywordTokenizer) IncrementToken() (bool, error) {
+	if t.done {
+		return false, nil
+	}
+	t.Attributes().Clear()
+	t.done = true
+	var buffer []rune
+	for {
+		ch, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return false, err
+		}
+		buffer = append(buffer, ch)
+	}
+	t.termAtt.CopyBuffer(buffer)
+	t.finalOff = t.CorrectOffset(len(buffer))
+	t.offsetAtt.SetOffset(t.CorrectOffset(0), t.finalOff)
+	return true, nil
+}
+
+func (t *KeywordTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	t.offsetAtt.SetOffset(t.finalOff, t.finalOff)
+	return nil
+}
+
+func (t *KeywordTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.done = false
+	t.finalOff = 0
+	return nil
+}
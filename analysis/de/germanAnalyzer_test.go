@@ -0,0 +1,21 @@
+package de
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestGermanAnalyzer(t *testing.T) {
+	a := NewGermanAnalyzer()
+	analysis.AssertAnalyzesTo(t, a, "Tisch Tische Tischen", "tisch", "tisch", "tisch")
+	analysis.AssertAnalyzesTo(t, a, "Häuser und das Haus", "haus", "haus")
+	// umlauts and ß are normalized
+	analysis.AssertAnalyzesTo(t, a, "Schaltflächen", "schaltflach")
+	analysis.AssertAnalyzesTo(t, a, "Schaltflaechen", "schaltflach")
+	analysis.AssertAnalyzesTo(t, a, "Straße", "strass")
+}
+
+func TestGermanStemExclusion(t *testing.T) {
+	a := NewGermanAnalyzerWithStemExclusion(GERMAN_STOP_WORDS_SET, map[string]bool{"tische": true})
+	analysis.AssertAnalyzesTo(t, a, "Tische Tischen", "tische", "tisch")
+}
@@ -0,0 +1,46 @@
+package de
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// de/GermanLightStemFilter.java
+
+/*
+A TokenFilter that applies GermanLightStemmer to stem German words.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type GermanLightStemFilter struct {
+	*TokenFilter
+	input      TokenStream
+	stemmer    *GermanLightStemmer
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+func NewGermanLightStemFilter(input TokenStream) *GermanLightStemFilter {
+	ans := &GermanLightStemFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		stemmer:     new(GermanLightStemmer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *GermanLightStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		newlen := f.stemmer.Stem(f.termAtt.Buffer(), f.termAtt.Length())
+		f.termAtt.SetLength(newlen)
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package de
+
+// de/GermanLightStemmer.java
+
+/*
+Light Stemmer for German.
+
+This stemmer implements the "UniNE" algorithm in:
+Light Stemming Approaches for the French, Portuguese, German and
+Hungarian Languages Jacques Savoy
+*/
+type GermanLightStemmer struct{}
+
+func (st *GermanLightStemmer) Stem(s []rune, length int) int {
+	for i, ch := range s[:length] {
+		switch ch {
+		case 'ä', 'à', 'á', 'â':
+			s[i] = 'a'
+		case 'ö', 'ò', 'ó', 'ô':
+			s[i] = 'o'
+		case 'ï', 'ì', 'í', 'î':
+			s[i] = 'i'
+		case 'ü', 'ù', 'ú', 'û':
+			s[i] = 'u'
+		}
+	}
+
+	length = st.step1(s, length)
+	return st.step2(s, length)
+}
+
+func stEnding(ch rune) bool {
+	switch ch {
+	case 'b', 'd', 'f', 'g', 'h', 'k', 'l', 'm', 'n', 't':
+		return true
+	}
+	return false
+}
+
+func (st *GermanLightStemmer) step1(s []rune, length int) int {
+	if length > 5 && s[length-3] == 'e' && s[length-2] == 'r' && s[length-1] == 'n' {
+		return length - 3
+	}
+
+	if length > 4 && s[length-2] == 'e' {
+		switch s[length-1] {
+		case 'm', 'n', 'r', 's':
+			return length - 2
+		}
+	}
+
+	if length > 3 && s[length-1] == 'e' {
+		return length - 1
+	}
+
+	if length > 3 && s[length-1] == 's' && stEnding(s[length-2]) {
+		return length - 1
+	}
+
+	return length
+}
+
+func (st *GermanLightStemmer) step2(s []rune, length int) int {
+	if length > 5 && s[length-3] == 'e' && s[length-2] == 's' && s[length-1] == 't' {
+		return length - 3
+	}
+
+	if length > 4 && s[length-2] == 'e' && (s[length-1] == 'r' || s[length-1] == 'n') {
+		return length - 2
+	}
+
+	if length > 4 && s[length-2] == 's' && s[length-1] == 't' && stEnding(s[length-3]) {
+		return length - 2
+	}
+
+	return length
+}
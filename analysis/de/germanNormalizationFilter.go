@@ -0,0 +1,98 @@
+package de
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// de/GermanNormalizationFilter.java
+
+// FSM with 3 states:
+const (
+	_N = 0 // ordinary state
+	_V = 1 // stops 'u' from entering umlaut state
+	_U = 2 // umlaut state, allows e-deletion
+)
+
+/*
+Normalizes German characters according to the heuristics of the
+German2 snowball algorithm. It allows for the fact that ä, ö and ü
+are sometimes written as ae, oe and ue.
+
+  - 'ß' is replaced by 'ss'
+  - 'ä', 'ö', 'ü' are replaced by 'a', 'o', 'u', respectively.
+  - 'ae' and 'oe' are replaced by 'a', and 'o', respectively.
+  - 'ue' is replaced by 'u', when not following a vowel or q.
+
+This is useful if you want this normalization without using the
+German2 stemmer, or perhaps no stemming at all.
+*/
+type GermanNormalizationFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+func NewGermanNormalizationFilter(input TokenStream) *GermanNormalizationFilter {
+	ans := &GermanNormalizationFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *GermanNormalizationFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+
+	state := _N
+	buffer := f.termAtt.Buffer()
+	length := f.termAtt.Length()
+	for i := 0; i < length; i++ {
+		switch buffer[i] {
+		case 'a', 'o':
+			state = _U
+		case 'u':
+			if state == _N {
+				state = _U
+			} else {
+				state = _V
+			}
+		case 'e':
+			if state == _U {
+				length = Delete(buffer, i, length)
+				i--
+			}
+			state = _V
+		case 'i', 'q', 'y':
+			state = _V
+		case 'ä':
+			buffer[i] = 'a'
+			state = _V
+		case 'ö':
+			buffer[i] = 'o'
+			state = _V
+		case 'ü':
+			buffer[i] = 'u'
+			state = _V
+		case 'ß':
+			expanded := make([]rune, 0, length+1)
+			expanded = append(expanded, buffer[:i]...)
+			expanded = append(expanded, 's', 's')
+			expanded = append(expanded, buffer[i+1:length]...)
+			f.termAtt.CopyBuffer(expanded)
+			buffer = f.termAtt.Buffer()
+			length++
+			i++
+			state = _N
+		default:
+			state = _N
+		}
+	}
+	f.termAtt.SetLength(length)
+	return true, nil
+}
@@ -0,0 +1,98 @@
+package el
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"unicode"
+)
+
+// el/GreekLowerCaseFilter.java
+
+/*
+Normalizes token text to lower case, removes some Greek diacritics,
+and standardizes final sigma to sigma.
+*/
+type GreekLowerCaseFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+func NewGreekLowerCaseFilter(in TokenStream) *GreekLowerCaseFilter {
+	ans := &GreekLowerCaseFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *GreekLowerCaseFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	buffer := f.termAtt.Buffer()[:f.termAtt.Length()]
+	for i, ch := range buffer {
+		buffer[i] = lowerCase(ch)
+	}
+	return true, nil
+}
+
+func lowerCase(codepoint rune) rune {
+	switch codepoint {
+	// There are two lowercase forms of sigma:
+	// U+03C2: small final sigma (end of word)
+	// U+03C3: small sigma (otherwise)
+	//
+	// Standardize both to U+03C3
+	case 'ς': // small final sigma
+		return 'σ' // small sigma
+
+	// Some greek characters contain diacritics.
+	// This filter removes these, converting to the lowercase base form.
+
+	case 'Ά', // capital alpha with tonos
+		'ά': // small alpha with tonos
+		return 'α' // small alpha
+
+	case 'Έ', // capital epsilon with tonos
+		'έ': // small epsilon with tonos
+		return 'ε' // small epsilon
+
+	case 'Ή', // capital eta with tonos
+		'ή': // small eta with tonos
+		return 'η' // small eta
+
+	case 'Ί', // capital iota with tonos
+		'Ϊ', // capital iota with dialytika
+		'ί', // small iota with tonos
+		'ϊ', // small iota with dialytika
+		'ΐ': // small iota with dialytika and tonos
+		return 'ι' // small iota
+
+	case 'Ύ', // capital upsilon with tonos
+		'Ϋ', // capital upsilon with dialytika
+		'ύ', // small upsilon with tonos
+		'ϋ', // small upsilon with dialytika
+		'ΰ': // small upsilon with dialytika and tonos
+		return 'υ' // small upsilon
+
+	case 'Ό', // capital omicron with tonos
+		'ό': // small omicron with tonos
+		return 'ο' // small omicron
+
+	case 'Ώ', // capital omega with tonos
+		'ώ': // small omega with tonos
+		return 'ω' // small omega
+
+	// The previous implementation did the conversion below.
+	// Only implemented for backwards compatibility with old indexes.
+
+	case '΢': // reserved
+		return 'ς' // small final sigma
+
+	default:
+		return unicode.ToLower(codepoint)
+	}
+}
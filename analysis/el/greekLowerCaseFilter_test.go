@@ -0,0 +1,21 @@
+package el
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+/* Returns the filter over input as a single token. */
+func lowerCaseFilter(input string) TokenStream {
+	return NewGreekLowerCaseFilter(NewKeywordTokenizer(strings.NewReader(input)))
+}
+
+func TestGreekLowerCaseFilter(t *testing.T) {
+	// final sigma and tonos are normalized
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("ΜΆΪΟΣ"), "μαιοσ")
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("άνθρωπος"), "ανθρωποσ")
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("Ώρα"), "ωρα")
+}
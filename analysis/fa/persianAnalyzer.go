@@ -0,0 +1,103 @@
+package fa
+
+import (
+	"github.com/balzaczyy/golucene/analysis/ar"
+	. "github.com/balzaczyy/golucene/analysis/core"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// fa/PersianAnalyzer.java
+
+/*
+The default set of Persian stop words: common function words and
+clitics. Since stop words are removed after normalization, the set is
+normalized as well.
+*/
+var PERSIAN_STOP_WORDS_SET = normalizeStopWords(map[string]bool{
+	"و": true, "در": true, "به": true, "از": true, "که": true,
+	"این": true, "را": true, "با": true, "است": true, "برای": true,
+	"آن": true, "یک": true, "خود": true, "تا": true, "کرد": true,
+	"بر": true, "هم": true, "نیز": true, "شد": true, "می": true,
+	"شده": true, "شود": true, "بود": true, "دارد": true, "ها": true,
+	"های": true, "او": true, "ما": true, "من": true, "تو": true,
+	"شما": true, "آنها": true, "ایشان": true, "ای": true, "اما": true,
+	"اگر": true, "یا": true, "نه": true, "هر": true, "چه": true,
+	"چون": true, "پس": true, "همه": true, "باید": true, "کند": true,
+	"کنند": true, "کرده": true, "بودن": true, "باشد": true,
+	"باشند": true, "نمی": true, "ولی": true, "زیرا": true,
+	"البته": true, "دیگر": true, "دیگری": true, "روی": true,
+	"بین": true, "پیش": true, "زیر": true, "بی": true,
+	"بدون": true, "همین": true, "همان": true, "آنچه": true,
+	"اینکه": true, "چند": true, "چنین": true, "وی": true, "ی": true,
+})
+
+func normalizeStopWords(words map[string]bool) map[string]bool {
+	arabic, persian := new(ar.ArabicNormalizer), new(PersianNormalizer)
+	ans := make(map[string]bool)
+	for word := range words {
+		buffer := []rune(word)
+		length := arabic.Normalize(buffer, len(buffer))
+		length = persian.Normalize(buffer, length)
+		ans[string(buffer[:length])] = true
+	}
+	return ans
+}
+
+/*
+Analyzer for Persian.
+
+This Analyzer uses PersianCharFilter which implies tokenizing around
+zero-width non-joiner in addition to whitespace. Some persian-specific
+variant forms (such as farsi yeh and keheh) are standardized.
+"Stemming" is accomplished via stopwords.
+*/
+type PersianAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet map[string]bool
+}
+
+func init() {
+	RegisterAnalyzer("persian", func() Analyzer {
+		return NewPersianAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (PERSIAN_STOP_WORDS_SET). */
+func NewPersianAnalyzer() *PersianAnalyzer {
+	return NewPersianAnalyzerWithStopWords(PERSIAN_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewPersianAnalyzerWithStopWords(stopWords map[string]bool) *PersianAnalyzer {
+	ans := &PersianAnalyzer{stopWordSet: stopWords}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+/*
+Creates TokenStreamComponents used to tokenize all the text in the
+provided reader, built from a StandardTokenizer filtered with
+LowerCaseFilter, DecimalDigitFilter, ArabicNormalizationFilter,
+PersianNormalizationFilter and Persian stop words.
+*/
+func (a *PersianAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = NewLowerCaseFilter(version, src)
+	tok = NewDecimalDigitFilter(tok)
+	tok = ar.NewArabicNormalizationFilter(tok)
+	// additional persian-specific normalization
+	tok = NewPersianNormalizationFilter(tok)
+	// the order here is important: the stopword list is normalized
+	// with the above!
+	return NewTokenStreamComponents(src, NewStopFilter(version, tok, a.stopWordSet))
+}
+
+/* Wraps the reader with PersianCharFilter. */
+func (a *PersianAnalyzer) InitReader(fieldName string, reader io.RuneReader) io.RuneReader {
+	return NewPersianCharFilter(reader)
+}
@@ -0,0 +1,15 @@
+package fa
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestPersianAnalyzer(t *testing.T) {
+	a := NewPersianAnalyzer()
+	// zero-width non-joiner separates the plural suffix, which is a stop word
+	analysis.AssertAnalyzesTo(t, a, "کتاب‌ها", "كتاب")
+	// farsi yeh and keheh are normalized, also in the stop words
+	analysis.AssertAnalyzesTo(t, a, "این کیف", "كيف")
+	analysis.AssertAnalyzesTo(t, a, "۱۳۹۳", "1393")
+}
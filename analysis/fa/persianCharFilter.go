@@ -0,0 +1,47 @@
+package fa
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// fa/PersianCharFilter.java
+
+const ZERO_WIDTH_NON_JOINER = '‌'
+
+/*
+CharFilter that replaces instances of Zero-width non-joiner with an
+ordinary space, so that the parts of a word written with ZWNJ (e.g.
+the plural suffix in 'کتاب‌ها') are tokenized as separate words.
+Since each rune is replaced by exactly one rune, offsets are not
+changed.
+*/
+type PersianCharFilter struct {
+	input io.RuneReader
+}
+
+func NewPersianCharFilter(in io.RuneReader) *PersianCharFilter {
+	return &PersianCharFilter{in}
+}
+
+func (f *PersianCharFilter) ReadRune() (rune, int, error) {
+	ch, size, err := f.input.ReadRune()
+	if err == nil && ch == ZERO_WIDTH_NON_JOINER {
+		return ' ', size, nil
+	}
+	return ch, size, err
+}
+
+func (f *PersianCharFilter) CorrectOffset(currentOff int) int {
+	if v, ok := f.input.(CharFilterService); ok {
+		return v.CorrectOffset(currentOff)
+	}
+	return currentOff
+}
+
+func (f *PersianCharFilter) Close() error {
+	if v, ok := f.input.(io.Closer); ok {
+		return v.Close()
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package fa
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// fa/PersianNormalizationFilter.java
+
+/* A TokenFilter that applies PersianNormalizer to normalize the orthography. */
+type PersianNormalizationFilter struct {
+	*TokenFilter
+	input      TokenStream
+	normalizer *PersianNormalizer
+	termAtt    CharTermAttribute
+}
+
+func NewPersianNormalizationFilter(input TokenStream) *PersianNormalizationFilter {
+	ans := &PersianNormalizationFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		normalizer:  new(PersianNormalizer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *PersianNormalizationFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	newlen := f.normalizer.Normalize(f.termAtt.Buffer(), f.termAtt.Length())
+	f.termAtt.SetLength(newlen)
+	return true, nil
+}
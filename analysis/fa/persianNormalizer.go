@@ -0,0 +1,54 @@
+package fa
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+)
+
+// fa/PersianNormalizer.java
+
+const (
+	YEH        = 'ي'
+	FARSI_YEH  = 'ی'
+	YEH_BARREE = 'ے'
+
+	KEHEH = 'ک'
+	KAF   = 'ك'
+
+	HAMZA_ABOVE = 'ٔ'
+
+	HEH_YEH  = 'ۀ'
+	HEH_GOAL = 'ہ'
+	HEH      = 'ه'
+)
+
+/*
+Normalizer for Persian.
+
+Normalization is done in-place for efficiency, operating on a
+termbuffer.
+
+Normalization is defined as:
+
+  - Normalization of various heh + hamza forms and heh goal to heh.
+  - Normalization of farsi yeh and yeh barree to arabic yeh
+  - Normalization of persian keheh to arabic kaf
+*/
+type PersianNormalizer struct{}
+
+/* Normalize an input buffer of Persian text, returning the length after normalization. */
+func (n *PersianNormalizer) Normalize(s []rune, length int) int {
+	for i := 0; i < length; i++ {
+		switch s[i] {
+		case FARSI_YEH, YEH_BARREE:
+			s[i] = YEH
+		case KEHEH:
+			s[i] = KAF
+		case HEH_YEH, HEH_GOAL:
+			s[i] = HEH
+		case HAMZA_ABOVE: // necessary for HEH + HAMZA
+			length = Delete(s, i, length)
+			i--
+		}
+	}
+	return length
+}
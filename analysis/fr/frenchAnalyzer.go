@@ -0,0 +1,126 @@
+package fr
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/miscellaneous"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// fr/FrenchAnalyzer.java
+
+/* Default set of articles for ElisionFilter */
+var DEFAULT_ARTICLES = map[string]bool{
+	"l": true, "m": true, "t": true, "qu": true, "n": true, "s": true,
+	"j": true, "d": true, "c": true, "jusqu": true, "quoiqu": true,
+	"lorsqu": true, "puisqu": true,
+}
+
+/* The default set of French stop words, from the snowball project. */
+var FRENCH_STOP_WORDS_SET = map[string]bool{
+	"au": true, "aux": true, "avec": true, "ce": true, "ces": true,
+	"dans": true, "de": true, "des": true, "du": true, "elle": true,
+	"en": true, "et": true, "eux": true, "il": true, "je": true,
+	"la": true, "le": true, "leur": true, "lui": true, "ma": true,
+	"mais": true, "me": true, "même": true, "mes": true, "moi": true,
+	"mon": true, "ne": true, "nos": true, "notre": true, "nous": true,
+	"on": true, "ou": true, "par": true, "pas": true, "pour": true,
+	"qu": true, "que": true, "qui": true, "sa": true, "se": true,
+	"ses": true, "son": true, "sur": true, "ta": true, "te": true,
+	"tes": true, "toi": true, "ton": true, "tu": true, "un": true,
+	"une": true, "vos": true, "votre": true, "vous": true, "c": true,
+	"d": true, "j": true, "l": true, "à": true, "m": true, "n": true,
+	"s": true, "t": true, "y": true, "été": true, "étée": true,
+	"étées": true, "étés": true, "étant": true, "suis": true,
+	"es": true, "est": true, "sommes": true, "êtes": true,
+	"sont": true, "serai": true, "seras": true, "sera": true,
+	"serons": true, "serez": true, "seront": true, "serais": true,
+	"serait": true, "serions": true, "seriez": true, "seraient": true,
+	"étais": true, "était": true, "étions": true, "étiez": true,
+	"étaient": true, "fus": true, "fut": true, "fûmes": true,
+	"fûtes": true, "furent": true, "sois": true, "soit": true,
+	"soyons": true, "soyez": true, "soient": true, "fusse": true,
+	"fusses": true, "fût": true, "fussions": true, "fussiez": true,
+	"fussent": true, "ayant": true, "eu": true, "eue": true,
+	"eues": true, "eus": true, "ai": true, "as": true, "avons": true,
+	"avez": true, "ont": true, "aurai": true, "auras": true,
+	"aura": true, "aurons": true, "aurez": true, "auront": true,
+	"aurais": true, "aurait": true, "aurions": true, "auriez": true,
+	"auraient": true, "avais": true, "avait": true, "avions": true,
+	"aviez": true, "avaient": true, "eut": true, "eûmes": true,
+	"eûtes": true, "eurent": true, "aie": true, "aies": true,
+	"ait": true, "ayons": true, "ayez": true, "aient": true,
+	"eusse": true, "eusses": true, "eût": true, "eussions": true,
+	"eussiez": true, "eussent": true, "ceci": true, "cela": true,
+	"celà": true, "cet": true, "cette": true, "ici": true, "ils": true,
+	"les": true, "leurs": true, "quel": true, "quels": true,
+	"quelle": true, "quelles": true, "sans": true, "soi": true,
+}
+
+/*
+Analyzer for French.
+
+Filters StandardTokenizer with StandardFilter, ElisionFilter,
+LowerCaseFilter, StopFilter and FrenchLightStemFilter. Terms in the
+stem exclusion set are marked as keywords, and not stemmed.
+
+You may specify the Version compatibility when creating
+FrenchAnalyzer:
+
+  - GoLucene supports 4.5+ only.
+*/
+type FrenchAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet      map[string]bool
+	stemExclusionSet map[string]bool
+}
+
+func init() {
+	RegisterAnalyzer("french", func() Analyzer {
+		return NewFrenchAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (FRENCH_STOP_WORDS_SET). */
+func NewFrenchAnalyzer() *FrenchAnalyzer {
+	return NewFrenchAnalyzerWithStopWords(FRENCH_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewFrenchAnalyzerWithStopWords(stopWords map[string]bool) *FrenchAnalyzer {
+	return NewFrenchAnalyzerWithStemExclusion(stopWords, nil)
+}
+
+/*
+Builds an analyzer with the given stop words. If a non-empty stem
+exclusion set is provided this analyzer will add a
+SetKeywordMarkerFilter before stemming.
+*/
+func NewFrenchAnalyzerWithStemExclusion(stopWords, stemExclusionSet map[string]bool) *FrenchAnalyzer {
+	ans := &FrenchAnalyzer{
+		stopWordSet:      stopWords,
+		stemExclusionSet: make(map[string]bool),
+	}
+	for k, v := range stemExclusionSet {
+		ans.stemExclusionSet[k] = v
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *FrenchAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = standard.NewStandardFilter(version, src)
+	tok = NewElisionFilter(tok, DEFAULT_ARTICLES)
+	tok = NewLowerCaseFilter(version, tok)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	if len(a.stemExclusionSet) > 0 {
+		tok = NewSetKeywordMarkerFilter(tok, a.stemExclusionSet)
+	}
+	tok = NewFrenchLightStemFilter(tok)
+	return NewTokenStreamComponents(src, tok)
+}
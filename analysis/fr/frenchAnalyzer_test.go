@@ -0,0 +1,30 @@
+package fr
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestFrenchAnalyzer(t *testing.T) {
+	a := NewFrenchAnalyzer()
+
+	// stop words and case
+	analysis.AssertAnalyzesTo(t, a, "chien CHAT CHEVAL", "chien", "chat", "cheval")
+	analysis.AssertAnalyzesTo(t, a, "le la chien les aux chat du des à cheval", "chien", "chat", "cheval")
+
+	// elision
+	analysis.AssertAnalyzesTo(t, a, "L'avion d'Air France", "avion", "air", "franc")
+	analysis.AssertAnalyzesTo(t, a, "aujourd'hui", "aujourd'hui")
+
+	// light stemming
+	analysis.AssertAnalyzesTo(t, a, "lances chismes habitable chiste éléments captifs",
+		"lanc", "chism", "habitabl", "chist", "element", "captif")
+	analysis.AssertAnalyzesTo(t, a, "finissions souffrirent rugissante",
+		"finision", "soufrirent", "rugisant")
+	analysis.AssertAnalyzesTo(t, a, "anticonstitutionnellement", "anticonstitutionel")
+}
+
+func TestFrenchAnalyzerStemExclusion(t *testing.T) {
+	a := NewFrenchAnalyzerWithStemExclusion(FRENCH_STOP_WORDS_SET, map[string]bool{"chevaux": true})
+	analysis.AssertAnalyzesTo(t, a, "chevaux chameaux", "chevaux", "chameau")
+}
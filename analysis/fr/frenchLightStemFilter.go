@@ -0,0 +1,46 @@
+package fr
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// fr/FrenchLightStemFilter.java
+
+/*
+A TokenFilter that applies FrenchLightStemmer to stem French words.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type FrenchLightStemFilter struct {
+	*TokenFilter
+	input      TokenStream
+	stemmer    *FrenchLightStemmer
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+func NewFrenchLightStemFilter(input TokenStream) *FrenchLightStemFilter {
+	ans := &FrenchLightStemFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		stemmer:     new(FrenchLightStemmer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *FrenchLightStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		newlen := f.stemmer.Stem(f.termAtt.Buffer(), f.termAtt.Length())
+		f.termAtt.SetLength(newlen)
+	}
+	return true, nil
+}
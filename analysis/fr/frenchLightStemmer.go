@@ -0,0 +1,244 @@
+package fr
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	"unicode"
+)
+
+// fr/FrenchLightStemmer.java
+
+/*
+Light Stemmer for French.
+
+This stemmer implements the "UniNE" algorithm in:
+Light Stemming Approaches for the French, Portuguese, German and
+Hungarian Languages Jacques Savoy
+*/
+type FrenchLightStemmer struct{}
+
+func (st *FrenchLightStemmer) Stem(s []rune, length int) int {
+	if length > 5 && s[length-1] == 'x' {
+		if s[length-3] == 'a' && s[length-2] == 'u' && s[length-4] != 'e' {
+			s[length-2] = 'l'
+		}
+		length--
+	}
+
+	if length > 3 && s[length-1] == 'x' {
+		length--
+	}
+
+	if length > 3 && s[length-1] == 's' {
+		length--
+	}
+
+	if length > 9 && EndsWith(s, length, "issement") {
+		length -= 6
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 8 && EndsWith(s, length, "issant") {
+		length -= 4
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 6 && EndsWith(s, length, "ement") {
+		length -= 4
+		if length > 3 && EndsWith(s, length, "ive") {
+			length--
+			s[length-1] = 'f'
+		}
+		return st.norm(s, length)
+	}
+
+	if length > 11 && EndsWith(s, length, "ficatrice") {
+		length -= 5
+		s[length-2] = 'e'
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 10 && EndsWith(s, length, "ficateur") {
+		length -= 4
+		s[length-2] = 'e'
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 9 && EndsWith(s, length, "catrice") {
+		length -= 3
+		s[length-4] = 'q'
+		s[length-3] = 'u'
+		s[length-2] = 'e'
+		// s[length-1] = 'r' <-- unnecessary, already 'r'.
+		return st.norm(s, length)
+	}
+
+	if length > 8 && EndsWith(s, length, "cateur") {
+		length -= 2
+		s[length-4] = 'q'
+		s[length-3] = 'u'
+		s[length-2] = 'e'
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 8 && EndsWith(s, length, "atrice") {
+		length -= 4
+		s[length-2] = 'e'
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 7 && EndsWith(s, length, "ateur") {
+		length -= 3
+		s[length-2] = 'e'
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 6 && EndsWith(s, length, "trice") {
+		length--
+		s[length-3] = 'e'
+		s[length-2] = 'u'
+		s[length-1] = 'r'
+	}
+
+	if length > 5 && EndsWith(s, length, "ième") {
+		return st.norm(s, length-4)
+	}
+
+	if length > 7 && EndsWith(s, length, "teuse") {
+		length -= 2
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 6 && EndsWith(s, length, "teur") {
+		length--
+		s[length-1] = 'r'
+		return st.norm(s, length)
+	}
+
+	if length > 5 && EndsWith(s, length, "euse") {
+		return st.norm(s, length-2)
+	}
+
+	if length > 8 && EndsWith(s, length, "ère") {
+		length--
+		s[length-2] = 'e'
+		return st.norm(s, length)
+	}
+
+	if length > 7 && EndsWith(s, length, "ive") {
+		length--
+		s[length-1] = 'f'
+		return st.norm(s, length)
+	}
+
+	if length > 4 && (EndsWith(s, length, "folle") || EndsWith(s, length, "molle")) {
+		length -= 2
+		s[length-1] = 'u'
+		return st.norm(s, length)
+	}
+
+	if length > 9 && EndsWith(s, length, "nnelle") {
+		return st.norm(s, length-5)
+	}
+
+	if length > 9 && EndsWith(s, length, "nnel") {
+		return st.norm(s, length-3)
+	}
+
+	if length > 4 && EndsWith(s, length, "ète") {
+		length--
+		s[length-2] = 'e'
+	}
+
+	if length > 8 && EndsWith(s, length, "ique") {
+		length -= 4
+	}
+
+	if length > 8 && EndsWith(s, length, "esse") {
+		return st.norm(s, length-3)
+	}
+
+	if length > 7 && EndsWith(s, length, "inage") {
+		return st.norm(s, length-3)
+	}
+
+	if length > 9 && EndsWith(s, length, "isation") {
+		length -= 7
+		if length > 5 && EndsWith(s, length, "ual") {
+			s[length-2] = 'e'
+		}
+		return st.norm(s, length)
+	}
+
+	if length > 9 && EndsWith(s, length, "isateur") {
+		return st.norm(s, length-7)
+	}
+
+	if length > 8 && EndsWith(s, length, "ation") {
+		return st.norm(s, length-5)
+	}
+
+	if length > 8 && EndsWith(s, length, "ition") {
+		return st.norm(s, length-5)
+	}
+
+	return st.norm(s, length)
+}
+
+func (st *FrenchLightStemmer) norm(s []rune, length int) int {
+	if length > 4 {
+		for i, ch := range s[:length] {
+			switch ch {
+			case 'à', 'á', 'â':
+				s[i] = 'a'
+			case 'ô':
+				s[i] = 'o'
+			case 'è', 'é', 'ê':
+				s[i] = 'e'
+			case 'ù', 'û':
+				s[i] = 'u'
+			case 'î':
+				s[i] = 'i'
+			case 'ç':
+				s[i] = 'c'
+			}
+		}
+
+		ch := s[0]
+		for i := 1; i < length; i++ {
+			if s[i] == ch && unicode.IsLetter(ch) {
+				length = Delete(s, i, length)
+				i--
+			} else {
+				ch = s[i]
+			}
+		}
+	}
+
+	if length > 4 && EndsWith(s, length, "ie") {
+		length -= 2
+	}
+
+	if length > 4 {
+		if s[length-1] == 'r' {
+			length--
+		}
+		if s[length-1] == 'e' {
+			length--
+		}
+		if s[length-1] == 'e' {
+			length--
+		}
+		if s[length-1] == s[length-2] && unicode.IsLetter(s[length-1]) {
+			length--
+		}
+	}
+	return length
+}
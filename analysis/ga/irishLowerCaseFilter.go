@@ -0,0 +1,60 @@
+package ga
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"unicode"
+)
+
+// ga/IrishLowerCaseFilter.java
+
+/*
+Normalises token text to lower case, handling t-prothesis and
+n-eclipsis (i.e., that 'nAthair' should become 'n-athair')
+*/
+type IrishLowerCaseFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+/* Create an IrishLowerCaseFilter that normalises Irish token text. */
+func NewIrishLowerCaseFilter(in TokenStream) *IrishLowerCaseFilter {
+	ans := &IrishLowerCaseFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *IrishLowerCaseFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	buffer := f.termAtt.Buffer()[:f.termAtt.Length()]
+	idx := 0
+	if len(buffer) > 1 && (buffer[0] == 'n' || buffer[0] == 't') && isUpperVowel(buffer[1]) {
+		term := make([]rune, 0, len(buffer)+1)
+		term = append(term, buffer[0], '-')
+		term = append(term, buffer[1:]...)
+		f.termAtt.CopyBuffer(term)
+		buffer = f.termAtt.Buffer()[:f.termAtt.Length()]
+		idx = 2
+	}
+	for i := idx; i < len(buffer); i++ {
+		buffer[i] = unicode.ToLower(buffer[i])
+	}
+	return true, nil
+}
+
+func isUpperVowel(v rune) bool {
+	switch v {
+	case 'A', 'E', 'I', 'O', 'U',
+		// vowels with acute accent (fada)
+		'Á', 'É', 'Í', 'Ó', 'Ú':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package ga
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+/* Returns the filter over input as a single token. */
+func lowerCaseFilter(input string) TokenStream {
+	return NewIrishLowerCaseFilter(NewKeywordTokenizer(strings.NewReader(input)))
+}
+
+func TestIrishLowerCaseFilter(t *testing.T) {
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("nAthair"), "n-athair")
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("tUISCE"), "t-uisce")
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("nÓg"), "n-óg")
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("Natural"), "natural")
+}
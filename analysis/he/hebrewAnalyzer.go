@@ -0,0 +1,67 @@
+package he
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+/* The default set of Hebrew stop words: common prepositions, pronouns and particles. */
+var HEBREW_STOP_WORDS_SET = map[string]bool{
+	"של": true, "את": true, "על": true, "עם": true, "זה": true,
+	"זו": true, "זאת": true, "הזה": true, "הזאת": true, "הוא": true,
+	"היא": true, "הם": true, "הן": true, "אני": true, "אתה": true,
+	"אנחנו": true, "אתם": true, "לא": true, "כי": true, "אם": true,
+	"גם": true, "או": true, "אבל": true, "אך": true, "כל": true,
+	"יש": true, "אין": true, "היה": true, "היתה": true, "היו": true,
+	"עד": true, "מן": true, "אל": true, "כמו": true, "אשר": true,
+	"רק": true, "כבר": true, "בין": true, "לפני": true, "אחרי": true,
+}
+
+/*
+Analyzer for Hebrew.
+
+StandardTokenizer already implements the Hebrew specific word break
+rules of UAX#29, keeping acronyms with gershayim (צה"ל) and words
+with geresh (ג'ירפה) together, while splitting on maqaf (־). The
+tokens are filtered with LowerCaseFilter (for embedded Latin text),
+DecimalDigitFilter, HebrewNormalizationFilter and StopFilter.
+
+Attached prefixes (ו, ה, ב, ל, מ, ש, כ) are not removed, since that
+requires a morphological dictionary to do reliably.
+*/
+type HebrewAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet map[string]bool
+}
+
+func init() {
+	RegisterAnalyzer("hebrew", func() Analyzer {
+		return NewHebrewAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (HEBREW_STOP_WORDS_SET). */
+func NewHebrewAnalyzer() *HebrewAnalyzer {
+	return NewHebrewAnalyzerWithStopWords(HEBREW_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewHebrewAnalyzerWithStopWords(stopWords map[string]bool) *HebrewAnalyzer {
+	ans := &HebrewAnalyzer{stopWordSet: stopWords}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *HebrewAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = NewLowerCaseFilter(version, src)
+	tok = NewDecimalDigitFilter(tok)
+	tok = NewHebrewNormalizationFilter(tok)
+	// stop words are matched against the normalized terms
+	return NewTokenStreamComponents(src, NewStopFilter(version, tok, a.stopWordSet))
+}
@@ -0,0 +1,16 @@
+package he
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestHebrewAnalyzer(t *testing.T) {
+	a := NewHebrewAnalyzer()
+	// points are removed, stop words dropped
+	analysis.AssertAnalyzesTo(t, a, "שָׁלוֹם של עולם", "שלום", "עולם")
+	// acronyms are kept together, gershayim normalized
+	analysis.AssertAnalyzesTo(t, a, "צה״ל צה\"ל", "צה\"ל", "צה\"ל")
+	// maqaf splits words
+	analysis.AssertAnalyzesTo(t, a, "בית־ספר", "בית", "ספר")
+}
@@ -0,0 +1,79 @@
+package he
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+const (
+	GERESH    = '׳'
+	GERSHAYIM = '״'
+)
+
+/*
+Normalizer for Hebrew.
+
+Normalization is done in-place, and is defined as:
+
+  - Removal of points (niqqud) and cantillation marks, which are
+    usually omitted in modern text.
+  - Normalization of geresh and gershayim to the ASCII apostrophe and
+    quotation mark commonly typed in their place, e.g. in acronyms
+    like 'צה״ל'.
+*/
+type HebrewNormalizer struct{}
+
+/* Normalize an input buffer of Hebrew text, returning the length after normalization. */
+func (n *HebrewNormalizer) Normalize(s []rune, length int) int {
+	for i := 0; i < length; i++ {
+		switch ch := s[i]; {
+		case ch == GERESH:
+			s[i] = '\''
+		case ch == GERSHAYIM:
+			s[i] = '"'
+		case isPoint(ch):
+			length = Delete(s, i, length)
+			i--
+		}
+	}
+	return length
+}
+
+/* Returns true for Hebrew cantillation marks and points. */
+func isPoint(ch rune) bool {
+	switch {
+	case ch >= '֑' && ch <= 'ֽ', ch == 'ֿ', ch == 'ׁ',
+		ch == 'ׂ', ch == 'ׄ', ch == 'ׅ', ch == 'ׇ':
+		return true
+	}
+	return false
+}
+
+/* A TokenFilter that applies HebrewNormalizer to normalize the orthography. */
+type HebrewNormalizationFilter struct {
+	*TokenFilter
+	input      TokenStream
+	normalizer *HebrewNormalizer
+	termAtt    CharTermAttribute
+}
+
+func NewHebrewNormalizationFilter(input TokenStream) *HebrewNormalizationFilter {
+	ans := &HebrewNormalizationFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		normalizer:  new(HebrewNormalizer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *HebrewNormalizationFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	newlen := f.normalizer.Normalize(f.termAtt.Buffer(), f.termAtt.Length())
+	f.termAtt.SetLength(newlen)
+	return true, nil
+}
@@ -0,0 +1,158 @@
+package it
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/miscellaneous"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// it/ItalianAnalyzer.java
+
+/* Default set of articles for ElisionFilter */
+var DEFAULT_ARTICLES = map[string]bool{
+	"c": true, "l": true, "all": true, "dall": true, "dell": true,
+	"nell": true, "sull": true, "coll": true, "pell": true, "gl": true,
+	"agl": true, "dagl": true, "degl": true, "negl": true,
+	"sugl": true, "un": true, "m": true, "t": true, "s": true,
+	"v": true, "d": true,
+}
+
+/* The default set of Italian stop words, from the snowball project. */
+var ITALIAN_STOP_WORDS_SET = map[string]bool{
+	"ad": true, "al": true, "allo": true, "ai": true, "agli": true,
+	"all": true, "agl": true, "alla": true, "alle": true, "con": true,
+	"col": true, "coi": true, "da": true, "dal": true, "dallo": true,
+	"dai": true, "dagli": true, "dall": true, "dagl": true,
+	"dalla": true, "dalle": true, "di": true, "del": true,
+	"dello": true, "dei": true, "degli": true, "dell": true,
+	"degl": true, "della": true, "delle": true, "in": true,
+	"nel": true, "nello": true, "nei": true, "negli": true,
+	"nell": true, "negl": true, "nella": true, "nelle": true,
+	"su": true, "sul": true, "sullo": true, "sui": true, "sugli": true,
+	"sull": true, "sugl": true, "sulla": true, "sulle": true,
+	"per": true, "tra": true, "contro": true, "io": true, "tu": true,
+	"lui": true, "lei": true, "noi": true, "voi": true, "loro": true,
+	"mio": true, "mia": true, "miei": true, "mie": true, "tuo": true,
+	"tua": true, "tuoi": true, "tue": true, "suo": true, "sua": true,
+	"suoi": true, "sue": true, "nostro": true, "nostra": true,
+	"nostri": true, "nostre": true, "vostro": true, "vostra": true,
+	"vostri": true, "vostre": true, "mi": true, "ti": true, "ci": true,
+	"vi": true, "lo": true, "la": true, "li": true, "le": true,
+	"gli": true, "ne": true, "il": true, "un": true, "uno": true,
+	"una": true, "ma": true, "ed": true, "se": true, "perché": true,
+	"anche": true, "come": true, "dov": true, "dove": true,
+	"che": true, "chi": true, "cui": true, "non": true, "più": true,
+	"quale": true, "quanto": true, "quanti": true, "quanta": true,
+	"quante": true, "quello": true, "quelli": true, "quella": true,
+	"quelle": true, "questo": true, "questi": true, "questa": true,
+	"queste": true, "si": true, "tutto": true, "tutti": true,
+	"a": true, "c": true, "e": true, "i": true, "l": true, "o": true,
+	"ho": true, "hai": true, "ha": true, "abbiamo": true,
+	"avete": true, "hanno": true, "abbia": true, "abbiate": true,
+	"abbiano": true, "avrò": true, "avrai": true, "avrà": true,
+	"avremo": true, "avrete": true, "avranno": true, "avrei": true,
+	"avresti": true, "avrebbe": true, "avremmo": true, "avreste": true,
+	"avrebbero": true, "avevo": true, "avevi": true, "aveva": true,
+	"avevamo": true, "avevate": true, "avevano": true, "ebbi": true,
+	"avesti": true, "ebbe": true, "avemmo": true, "aveste": true,
+	"ebbero": true, "avessi": true, "avesse": true, "avessimo": true,
+	"avessero": true, "avendo": true, "avuto": true, "avuta": true,
+	"avuti": true, "avute": true, "sono": true, "sei": true, "è": true,
+	"siamo": true, "siete": true, "sia": true, "siate": true,
+	"siano": true, "sarò": true, "sarai": true, "sarà": true,
+	"saremo": true, "sarete": true, "saranno": true, "sarei": true,
+	"saresti": true, "sarebbe": true, "saremmo": true, "sareste": true,
+	"sarebbero": true, "ero": true, "eri": true, "era": true,
+	"eravamo": true, "eravate": true, "erano": true, "fui": true,
+	"fosti": true, "fu": true, "fummo": true, "foste": true,
+	"furono": true, "fossi": true, "fosse": true, "fossimo": true,
+	"fossero": true, "essendo": true, "faccio": true, "fai": true,
+	"facciamo": true, "fanno": true, "faccia": true, "facciate": true,
+	"facciano": true, "farò": true, "farai": true, "farà": true,
+	"faremo": true, "farete": true, "faranno": true, "farei": true,
+	"faresti": true, "farebbe": true, "faremmo": true, "fareste": true,
+	"farebbero": true, "facevo": true, "facevi": true, "faceva": true,
+	"facevamo": true, "facevate": true, "facevano": true, "feci": true,
+	"facesti": true, "fece": true, "facemmo": true, "faceste": true,
+	"fecero": true, "facessi": true, "facesse": true,
+	"facessimo": true, "facessero": true, "facendo": true, "sto": true,
+	"stai": true, "sta": true, "stiamo": true, "stanno": true,
+	"stia": true, "stiate": true, "stiano": true, "starò": true,
+	"starai": true, "starà": true, "staremo": true, "starete": true,
+	"staranno": true, "starei": true, "staresti": true,
+	"starebbe": true, "staremmo": true, "stareste": true,
+	"starebbero": true, "stavo": true, "stavi": true, "stava": true,
+	"stavamo": true, "stavate": true, "stavano": true, "stetti": true,
+	"stesti": true, "stette": true, "stemmo": true, "steste": true,
+	"stettero": true, "stessi": true, "stesse": true, "stessimo": true,
+	"stessero": true, "stando": true,
+}
+
+/*
+Analyzer for Italian.
+
+Filters StandardTokenizer with StandardFilter, ElisionFilter,
+LowerCaseFilter, StopFilter and ItalianLightStemFilter. Terms in the
+stem exclusion set are marked as keywords, and not stemmed.
+
+You may specify the Version compatibility when creating
+ItalianAnalyzer:
+
+  - GoLucene supports 4.5+ only.
+*/
+type ItalianAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet      map[string]bool
+	stemExclusionSet map[string]bool
+}
+
+func init() {
+	RegisterAnalyzer("italian", func() Analyzer {
+		return NewItalianAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (ITALIAN_STOP_WORDS_SET). */
+func NewItalianAnalyzer() *ItalianAnalyzer {
+	return NewItalianAnalyzerWithStopWords(ITALIAN_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewItalianAnalyzerWithStopWords(stopWords map[string]bool) *ItalianAnalyzer {
+	return NewItalianAnalyzerWithStemExclusion(stopWords, nil)
+}
+
+/*
+Builds an analyzer with the given stop words. If a non-empty stem
+exclusion set is provided this analyzer will add a
+SetKeywordMarkerFilter before stemming.
+*/
+func NewItalianAnalyzerWithStemExclusion(stopWords, stemExclusionSet map[string]bool) *ItalianAnalyzer {
+	ans := &ItalianAnalyzer{
+		stopWordSet:      stopWords,
+		stemExclusionSet: make(map[string]bool),
+	}
+	for k, v := range stemExclusionSet {
+		ans.stemExclusionSet[k] = v
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *ItalianAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = standard.NewStandardFilter(version, src)
+	tok = NewElisionFilter(tok, DEFAULT_ARTICLES)
+	tok = NewLowerCaseFilter(version, tok)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	if len(a.stemExclusionSet) > 0 {
+		tok = NewSetKeywordMarkerFilter(tok, a.stemExclusionSet)
+	}
+	tok = NewItalianLightStemFilter(tok)
+	return NewTokenStreamComponents(src, tok)
+}
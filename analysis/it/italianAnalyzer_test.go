@@ -0,0 +1,26 @@
+package it
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestItalianAnalyzer(t *testing.T) {
+	a := NewItalianAnalyzer()
+
+	// stemming
+	analysis.AssertAnalyzesTo(t, a, "abbandonata", "abbandonat")
+	analysis.AssertAnalyzesTo(t, a, "abbandonati", "abbandonat")
+	analysis.AssertAnalyzesTo(t, a, "perché libertà amiche", "libert", "amic")
+	// stop words
+	analysis.AssertAnalyzesTo(t, a, "dallo")
+	// elision
+	analysis.AssertAnalyzesTo(t, a, "dell'Italia", "ital")
+	analysis.AssertAnalyzesTo(t, a, "l'Italiano", "italian")
+	analysis.AssertAnalyzesTo(t, a, "un’amicizia", "amiciz")
+}
+
+func TestItalianAnalyzerStemExclusion(t *testing.T) {
+	a := NewItalianAnalyzerWithStemExclusion(ITALIAN_STOP_WORDS_SET, map[string]bool{"abbandonata": true})
+	analysis.AssertAnalyzesTo(t, a, "abbandonata abbandonati", "abbandonata", "abbandonat")
+}
@@ -0,0 +1,46 @@
+package it
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// it/ItalianLightStemFilter.java
+
+/*
+A TokenFilter that applies ItalianLightStemmer to stem Italian words.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type ItalianLightStemFilter struct {
+	*TokenFilter
+	input      TokenStream
+	stemmer    *ItalianLightStemmer
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+func NewItalianLightStemFilter(input TokenStream) *ItalianLightStemFilter {
+	ans := &ItalianLightStemFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		stemmer:     new(ItalianLightStemmer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *ItalianLightStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		newlen := f.stemmer.Stem(f.termAtt.Buffer(), f.termAtt.Length())
+		f.termAtt.SetLength(newlen)
+	}
+	return true, nil
+}
@@ -0,0 +1,56 @@
+package it
+
+// it/ItalianLightStemmer.java
+
+/*
+Light Stemmer for Italian.
+
+This stemmer implements the algorithm described in: Report on CLEF-2001
+Experiments Jacques Savoy
+*/
+type ItalianLightStemmer struct{}
+
+func (st *ItalianLightStemmer) Stem(s []rune, length int) int {
+	if length < 6 {
+		return length
+	}
+
+	for i, ch := range s[:length] {
+		switch ch {
+		case 'à', 'á', 'â', 'ä':
+			s[i] = 'a'
+		case 'ò', 'ó', 'ô', 'ö':
+			s[i] = 'o'
+		case 'è', 'é', 'ê', 'ë':
+			s[i] = 'e'
+		case 'ù', 'ú', 'û', 'ü':
+			s[i] = 'u'
+		case 'ì', 'í', 'î', 'ï':
+			s[i] = 'i'
+		}
+	}
+
+	switch s[length-1] {
+	case 'e':
+		if s[length-2] == 'i' || s[length-2] == 'h' {
+			return length - 2
+		}
+		return length - 1
+	case 'i':
+		if s[length-2] == 'h' || s[length-2] == 'i' {
+			return length - 2
+		}
+		return length - 1
+	case 'a':
+		if s[length-2] == 'i' {
+			return length - 2
+		}
+		return length - 1
+	case 'o':
+		if s[length-2] == 'i' {
+			return length - 2
+		}
+		return length - 1
+	}
+	return length
+}
@@ -0,0 +1,100 @@
+package ja
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// ja/JapaneseAnalyzer.java
+
+/* The default set of Japanese stop words. */
+var DEFAULT_STOP_SET = map[string]bool{
+	"の": true, "に": true, "は": true, "を": true, "た": true, "が": true,
+	"で": true, "て": true, "と": true, "し": true, "れ": true, "さ": true,
+	"ある": true, "いる": true, "も": true, "する": true, "から": true,
+	"な": true, "こと": true, "として": true, "い": true, "や": true,
+	"れる": true, "など": true, "なっ": true, "ない": true, "この": true,
+	"ため": true, "その": true, "あっ": true, "よう": true, "また": true,
+	"もの": true, "という": true, "あり": true, "まで": true, "られ": true,
+	"なる": true, "へ": true, "か": true, "だ": true, "これ": true,
+	"によって": true, "により": true, "おり": true, "より": true, "による": true,
+	"ず": true, "なり": true, "られる": true, "において": true, "ば": true,
+	"なかっ": true, "なく": true, "しかし": true, "について": true, "せ": true,
+	"だっ": true, "その後": true, "できる": true, "それ": true, "う": true,
+	"ので": true, "なお": true, "のみ": true, "でき": true, "き": true,
+	"つ": true, "における": true, "および": true, "いう": true, "さらに": true,
+	"でも": true, "ら": true, "たり": true, "その他": true, "に関する": true,
+	"たち": true, "ます": true, "ん": true, "なら": true, "に対して": true,
+	"特に": true, "せる": true, "及び": true, "これら": true, "とき": true,
+	"では": true, "にて": true, "ほか": true, "ながら": true, "うち": true,
+	"そして": true, "とともに": true, "ただし": true, "かつて": true, "それぞれ": true,
+	"または": true, "お": true, "ほど": true, "ものの": true, "に対する": true,
+	"ほとんど": true, "と共に": true, "といった": true, "です": true, "とも": true,
+	"ところ": true, "ここ": true,
+}
+
+/* The default set of part-of-speech tags removed by JapanesePartOfSpeechStopFilter. */
+var DEFAULT_STOP_TAGS = map[string]bool{
+	"接続詞": true, "助詞": true, "助詞-格助詞": true, "助詞-格助詞-一般": true,
+	"助詞-格助詞-引用": true, "助詞-格助詞-連語": true, "助詞-接続助詞": true,
+	"助詞-係助詞": true, "助詞-副助詞": true, "助詞-間投助詞": true, "助詞-並立助詞": true,
+	"助詞-終助詞": true, "助詞-副助詞／並立助詞／終助詞": true, "助詞-連体化": true,
+	"助詞-副詞化": true, "助詞-特殊": true, "助動詞": true, "記号": true,
+	"記号-一般": true, "記号-読点": true, "記号-句点": true, "記号-空白": true,
+	"記号-括弧開": true, "記号-括弧閉": true, "その他-間投": true, "フィラー": true,
+	"非言語音": true,
+}
+
+/*
+Analyzer for Japanese that uses morphological analysis.
+
+Filters JapaneseTokenizer with JapaneseBaseFormFilter,
+JapanesePartOfSpeechStopFilter, StopFilter, JapaneseKatakanaStemFilter
+and LowerCaseFilter.
+*/
+type JapaneseAnalyzer struct {
+	*StopwordAnalyzerBase
+	mode           Mode
+	stopWords      map[string]bool
+	stopTags       map[string]bool
+	dictionary     *TokenInfoDictionary
+	costs          *ConnectionCosts
+	userDictionary *UserDictionary
+}
+
+/*
+Builds an analyzer with the given system dictionary and connection
+costs, the default stop words and stop tags, and DEFAULT_MODE.
+*/
+func NewJapaneseAnalyzer(dictionary *TokenInfoDictionary, costs *ConnectionCosts) *JapaneseAnalyzer {
+	return NewJapaneseAnalyzerWith(dictionary, costs, nil, DEFAULT_MODE, DEFAULT_STOP_SET, DEFAULT_STOP_TAGS)
+}
+
+func NewJapaneseAnalyzerWith(dictionary *TokenInfoDictionary, costs *ConnectionCosts,
+	userDictionary *UserDictionary, mode Mode, stopWords, stopTags map[string]bool) *JapaneseAnalyzer {
+
+	ans := &JapaneseAnalyzer{
+		mode:           mode,
+		stopWords:      stopWords,
+		stopTags:       stopTags,
+		dictionary:     dictionary,
+		costs:          costs,
+		userDictionary: userDictionary,
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *JapaneseAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := NewJapaneseTokenizer(reader, a.dictionary, a.costs, a.userDictionary, true, a.mode)
+	var tok TokenStream = NewJapaneseBaseFormFilter(src)
+	tok = NewJapanesePartOfSpeechStopFilter(version, tok, a.stopTags)
+	tok = NewStopFilter(version, tok, a.stopWords)
+	tok = NewJapaneseKatakanaStemFilter(tok)
+	tok = NewLowerCaseFilter(version, tok)
+	return NewTokenStreamComponents(src, tok)
+}
@@ -0,0 +1,49 @@
+package ja
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ja/JapaneseBaseFormFilter.java
+
+/*
+Replaces term text with the BaseFormAttribute.
+
+This acts as a lemmatizer for verbs and adjectives.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type JapaneseBaseFormFilter struct {
+	*TokenFilter
+	input        TokenStream
+	termAtt      CharTermAttribute
+	basicFormAtt BaseFormAttribute
+	keywordAtt   KeywordAttribute
+}
+
+func NewJapaneseBaseFormFilter(input TokenStream) *JapaneseBaseFormFilter {
+	ans := &JapaneseBaseFormFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.basicFormAtt = addAttribute(ans.Attributes(), "BaseFormAttribute").(BaseFormAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *JapaneseBaseFormFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		if baseForm := f.basicFormAtt.BaseForm(); baseForm != "" {
+			f.termAtt.CopyBuffer([]rune(baseForm))
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package ja
+
+import (
+	"unicode"
+)
+
+// ja/dict/CharacterDefinition.java
+
+/* Character classes used for unknown word processing. */
+const (
+	NGRAM = iota
+	DEFAULT
+	SPACE
+	SYMBOL
+	NUMERIC
+	ALPHA
+	CYRILLIC
+	GREEK
+	HIRAGANA
+	KATAKANA
+	KANJI
+	KANJINUMERIC
+	CHAR_CLASS_COUNT
+)
+
+var charClassNames = map[string]int{
+	"NGRAM":        NGRAM,
+	"DEFAULT":      DEFAULT,
+	"SPACE":        SPACE,
+	"SYMBOL":       SYMBOL,
+	"NUMERIC":      NUMERIC,
+	"ALPHA":        ALPHA,
+	"CYRILLIC":     CYRILLIC,
+	"GREEK":        GREEK,
+	"HIRAGANA":     HIRAGANA,
+	"KATAKANA":     KATAKANA,
+	"KANJI":        KANJI,
+	"KANJINUMERIC": KANJINUMERIC,
+}
+
+/*
+Whether unknown word processing is always invoked for a class, even
+when known words start at the same position, and whether characters
+of the same class are grouped into a single unknown word. Taken from
+IPADIC's char.def.
+*/
+var (
+	invokeClass = [CHAR_CLASS_COUNT]bool{
+		SYMBOL: true, NUMERIC: true, ALPHA: true, CYRILLIC: true,
+		GREEK: true, KATAKANA: true, KANJINUMERIC: true,
+	}
+	groupClass = [CHAR_CLASS_COUNT]bool{
+		DEFAULT: true, SPACE: true, SYMBOL: true, NUMERIC: true,
+		ALPHA: true, CYRILLIC: true, GREEK: true, HIRAGANA: true,
+		KATAKANA: true, KANJINUMERIC: true,
+	}
+)
+
+const HIRAGANA_KATAKANA_PROLONGED_SOUND_MARK = 'ー'
+
+func charClassOf(ch rune) int {
+	switch {
+	case unicode.IsSpace(ch):
+		return SPACE
+	case ch == '〇' || isKanjiNumeral(ch):
+		return KANJINUMERIC
+	case unicode.Is(unicode.Han, ch):
+		return KANJI
+	case unicode.Is(unicode.Hiragana, ch):
+		return HIRAGANA
+	case unicode.Is(unicode.Katakana, ch) || ch == HIRAGANA_KATAKANA_PROLONGED_SOUND_MARK:
+		return KATAKANA
+	case unicode.IsDigit(ch):
+		return NUMERIC
+	case unicode.Is(unicode.Latin, ch):
+		return ALPHA
+	case unicode.Is(unicode.Greek, ch):
+		return GREEK
+	case unicode.Is(unicode.Cyrillic, ch):
+		return CYRILLIC
+	case unicode.IsPunct(ch) || unicode.IsSymbol(ch):
+		return SYMBOL
+	}
+	return DEFAULT
+}
+
+func isKanjiNumeral(ch rune) bool {
+	switch ch {
+	case '一', '二', '三', '四', '五', '六', '七', '八', '九', '十',
+		'百', '千', '万', '億', '兆':
+		return true
+	}
+	return false
+}
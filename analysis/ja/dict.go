@@ -0,0 +1,307 @@
+package ja
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ja/dict/Dictionary.java
+
+/* Dictionary interface for retrieving morphological data by id. */
+type Dictionary interface {
+	// Get left id of specified word
+	LeftId(wordId int) int
+	// Get right id of specified word
+	RightId(wordId int) int
+	// Get word cost of specified word
+	WordCost(wordId int) int
+	// Get Part-Of-Speech of tokens
+	PartOfSpeech(wordId int) string
+	// Get reading of tokens, or "" if unknown
+	Reading(wordId int, surface []rune) string
+	// Get base form of word, or "" if the word is not inflected
+	BaseForm(wordId int, surface []rune) string
+	// Get pronunciation of tokens, or "" if unknown
+	Pronunciation(wordId int, surface []rune) string
+	// Get inflection type of tokens, or "" if not inflected
+	InflectionType(wordId int) string
+	// Get inflection form of tokens, or "" if not inflected
+	InflectionForm(wordId int) string
+}
+
+type dictEntry struct {
+	leftId, rightId, wordCost int
+	pos                       string
+	inflType, inflForm        string
+	baseForm                  string
+	reading, pronunciation    string
+}
+
+/* Base dictionary storing its entries in a slice indexed by word id. */
+type entryDictionary struct {
+	entries []dictEntry
+}
+
+func (d *entryDictionary) LeftId(wordId int) int          { return d.entries[wordId].leftId }
+func (d *entryDictionary) RightId(wordId int) int         { return d.entries[wordId].rightId }
+func (d *entryDictionary) WordCost(wordId int) int        { return d.entries[wordId].wordCost }
+func (d *entryDictionary) PartOfSpeech(wordId int) string { return d.entries[wordId].pos }
+
+func (d *entryDictionary) Reading(wordId int, surface []rune) string {
+	return d.entries[wordId].reading
+}
+
+func (d *entryDictionary) BaseForm(wordId int, surface []rune) string {
+	return d.entries[wordId].baseForm
+}
+
+func (d *entryDictionary) Pronunciation(wordId int, surface []rune) string {
+	return d.entries[wordId].pronunciation
+}
+
+func (d *entryDictionary) InflectionType(wordId int) string { return d.entries[wordId].inflType }
+func (d *entryDictionary) InflectionForm(wordId int) string { return d.entries[wordId].inflForm }
+
+/* Returns the column, or "" if it is missing or the mecab null value "*". */
+func column(record []string, i int) string {
+	if i >= len(record) || record[i] == "*" {
+		return ""
+	}
+	return record[i]
+}
+
+/*
+Parses one entry in mecab CSV format:
+
+	surface,leftId,rightId,cost,pos1,pos2,pos3,pos4,inflType,inflForm,baseForm,reading,pronunciation
+*/
+func parseEntry(record []string) (dictEntry, error) {
+	if len(record) < 5 {
+		return dictEntry{}, fmt.Errorf("invalid dictionary entry: %v", strings.Join(record, ","))
+	}
+	var ids [3]int
+	for i := range ids {
+		n, err := strconv.Atoi(strings.TrimSpace(record[i+1]))
+		if err != nil {
+			return dictEntry{}, fmt.Errorf("invalid dictionary entry: %v", strings.Join(record, ","))
+		}
+		ids[i] = n
+	}
+	var pos []string
+	for i := 4; i < 8; i++ {
+		if p := column(record, i); p != "" {
+			pos = append(pos, p)
+		}
+	}
+	return dictEntry{
+		leftId:        ids[0],
+		rightId:       ids[1],
+		wordCost:      ids[2],
+		pos:           strings.Join(pos, "-"),
+		inflType:      column(record, 8),
+		inflForm:      column(record, 9),
+		baseForm:      column(record, 10),
+		reading:       column(record, 11),
+		pronunciation: column(record, 12),
+	}, nil
+}
+
+func newCSVReader(r io.Reader) *csv.Reader {
+	ans := csv.NewReader(r)
+	ans.FieldsPerRecord = -1
+	ans.LazyQuotes = true
+	ans.Comment = '#'
+	return ans
+}
+
+// ja/dict/TokenInfoDictionary.java
+
+/*
+The system dictionary, mapping surface forms to the morphological
+data of its entries. It is loaded from the CSV files of a mecab
+dictionary such as IPADIC (which must be converted to UTF-8 first).
+*/
+type TokenInfoDictionary struct {
+	*entryDictionary
+	words     map[string][]int
+	maxLength int
+}
+
+func NewTokenInfoDictionary() *TokenInfoDictionary {
+	return &TokenInfoDictionary{
+		entryDictionary: new(entryDictionary),
+		words:           make(map[string][]int),
+	}
+}
+
+/* Loads all entries of the mecab CSV file into this dictionary. */
+func (d *TokenInfoDictionary) Load(r io.Reader) error {
+	rd := newCSVReader(r)
+	for {
+		record, err := rd.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		entry, err := parseEntry(record)
+		if err != nil {
+			return err
+		}
+		d.add(record[0], entry)
+	}
+}
+
+func (d *TokenInfoDictionary) add(surface string, entry dictEntry) {
+	wordId := len(d.entries)
+	d.entries = append(d.entries, entry)
+	d.words[surface] = append(d.words[surface], wordId)
+	if n := len([]rune(surface)); n > d.maxLength {
+		d.maxLength = n
+	}
+}
+
+/*
+Returns the ids of all entries whose surface form starts at offset
+off of text, grouped by length in ascending order.
+*/
+func (d *TokenInfoDictionary) lookup(text []rune, off int, fn func(length int, wordIds []int)) {
+	for length := 1; length <= d.maxLength && off+length <= len(text); length++ {
+		if wordIds, ok := d.words[string(text[off:off+length])]; ok {
+			fn(length, wordIds)
+		}
+	}
+}
+
+// ja/dict/UnknownDictionary.java
+
+/*
+Dictionary for unknown-word handling. Entries are keyed by character
+class instead of surface form, e.g. "KATAKANA,1285,1285,9461,名詞,一般".
+*/
+type UnknownDictionary struct {
+	*entryDictionary
+	classes [CHAR_CLASS_COUNT][]int
+}
+
+func NewUnknownDictionary() *UnknownDictionary {
+	return &UnknownDictionary{entryDictionary: new(entryDictionary)}
+}
+
+/* Loads the entries of a mecab unk.def file into this dictionary. */
+func (d *UnknownDictionary) Load(r io.Reader) error {
+	rd := newCSVReader(r)
+	for {
+		record, err := rd.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		class, ok := charClassNames[record[0]]
+		if !ok {
+			return fmt.Errorf("unknown character class: %v", record[0])
+		}
+		entry, err := parseEntry(record)
+		if err != nil {
+			return err
+		}
+		d.classes[class] = append(d.classes[class], len(d.entries))
+		d.entries = append(d.entries, entry)
+	}
+}
+
+func (d *UnknownDictionary) Reading(wordId int, surface []rune) string {
+	if r := d.entries[wordId].reading; r != "" {
+		return r
+	}
+	// katakana is read as it is written
+	for _, ch := range surface {
+		if charClassOf(ch) != KATAKANA {
+			return ""
+		}
+	}
+	return string(surface)
+}
+
+/* Entries of IPADIC's unk.def used when no unknown dictionary is given. */
+const defaultUnknownDefinitions = `DEFAULT,5,5,4769,記号,一般,*,*,*,*,*
+SPACE,9,9,8903,記号,空白,*,*,*,*,*
+KANJI,1285,1285,11426,名詞,一般,*,*,*,*,*
+SYMBOL,1283,1283,17585,名詞,サ変接続,*,*,*,*,*
+NUMERIC,1295,1295,27473,名詞,数,*,*,*,*,*
+ALPHA,1285,1285,13398,名詞,一般,*,*,*,*,*
+HIRAGANA,1285,1285,13069,名詞,一般,*,*,*,*,*
+KATAKANA,1285,1285,9461,名詞,一般,*,*,*,*,*
+KANJINUMERIC,1295,1295,27473,名詞,数,*,*,*,*,*
+GREEK,1285,1285,7884,名詞,一般,*,*,*,*,*
+CYRILLIC,1285,1285,7966,名詞,一般,*,*,*,*,*
+`
+
+/* Returns an UnknownDictionary with the entries of IPADIC's unk.def */
+func DefaultUnknownDictionary() *UnknownDictionary {
+	ans := NewUnknownDictionary()
+	if err := ans.Load(strings.NewReader(defaultUnknownDefinitions)); err != nil {
+		panic(err)
+	}
+	return ans
+}
+
+// ja/dict/ConnectionCosts.java
+
+/*
+n-gram connection cost data, loaded from a mecab matrix.def file.
+Unknown ids have a connection cost of zero.
+*/
+type ConnectionCosts struct {
+	forwardSize, backwardSize int
+	costs                     []int16
+}
+
+/* Loads the connection costs from a mecab matrix.def file. */
+func LoadConnectionCosts(r io.Reader) (*ConnectionCosts, error) {
+	scanner := bufio.NewScanner(r)
+	ans := new(ConnectionCosts)
+	first := true
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var nums []int
+		for _, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid connection cost entry: %v", scanner.Text())
+			}
+			nums = append(nums, n)
+		}
+		if first {
+			if len(nums) != 2 || nums[0] < 0 || nums[1] < 0 {
+				return nil, errors.New("invalid matrix.def header")
+			}
+			ans.forwardSize, ans.backwardSize = nums[0], nums[1]
+			ans.costs = make([]int16, nums[0]*nums[1])
+			first = false
+			continue
+		}
+		if len(nums) != 3 || nums[0] >= ans.forwardSize || nums[1] >= ans.backwardSize {
+			return nil, fmt.Errorf("invalid connection cost entry: %v", scanner.Text())
+		}
+		ans.costs[nums[1]*ans.forwardSize+nums[0]] = int16(nums[2])
+	}
+	return ans, scanner.Err()
+}
+
+/* Returns the cost of connecting a word with forwardId to one with backwardId. */
+func (c *ConnectionCosts) Get(forwardId, backwardId int) int {
+	if c == nil || forwardId >= c.forwardSize || backwardId >= c.backwardSize {
+		return 0
+	}
+	return int(c.costs[backwardId*c.forwardSize+forwardId])
+}
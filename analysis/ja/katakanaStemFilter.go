@@ -0,0 +1,79 @@
+package ja
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ja/JapaneseKatakanaStemFilter.java
+
+const DEFAULT_MINIMUM_LENGTH = 4
+
+/*
+A TokenFilter that normalizes common katakana spelling variations
+ending in a long sound character by removing this character (U+30FC).
+Only katakana words longer than a minimum length are stemmed (default
+is four).
+
+Note that only full-width katakana characters are supported.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type JapaneseKatakanaStemFilter struct {
+	*TokenFilter
+	input                 TokenStream
+	termAtt               CharTermAttribute
+	keywordAtt            KeywordAttribute
+	minimumKatakanaLength int
+}
+
+func NewJapaneseKatakanaStemFilter(input TokenStream) *JapaneseKatakanaStemFilter {
+	return NewJapaneseKatakanaStemFilterWithLength(input, DEFAULT_MINIMUM_LENGTH)
+}
+
+func NewJapaneseKatakanaStemFilterWithLength(input TokenStream, minimumLength int) *JapaneseKatakanaStemFilter {
+	ans := &JapaneseKatakanaStemFilter{
+		TokenFilter:           NewTokenFilter(input),
+		input:                 input,
+		minimumKatakanaLength: minimumLength,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *JapaneseKatakanaStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		f.termAtt.SetLength(f.stem(f.termAtt.Buffer(), f.termAtt.Length()))
+	}
+	return true, nil
+}
+
+func (f *JapaneseKatakanaStemFilter) stem(term []rune, length int) int {
+	if length < f.minimumKatakanaLength {
+		return length
+	}
+	if !isKatakana(term[:length]) {
+		return length
+	}
+	if term[length-1] == HIRAGANA_KATAKANA_PROLONGED_SOUND_MARK {
+		return length - 1
+	}
+	return length
+}
+
+func isKatakana(term []rune) bool {
+	for _, ch := range term {
+		// NOTE: Full-width katakana only
+		if ch < 0x30A0 || ch > 0x30FF {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package ja
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// ja/JapanesePartOfSpeechStopFilter.java
+
+/* Removes tokens that match a set of part-of-speech tags. */
+type JapanesePartOfSpeechStopFilter struct {
+	*FilteringTokenFilter
+	stopTags map[string]bool
+	posAtt   PartOfSpeechAttribute
+}
+
+/* Create a new JapanesePartOfSpeechStopFilter. */
+func NewJapanesePartOfSpeechStopFilter(matchVersion util.Version,
+	input TokenStream, stopTags map[string]bool) *JapanesePartOfSpeechStopFilter {
+
+	ans := &JapanesePartOfSpeechStopFilter{stopTags: stopTags}
+	ans.FilteringTokenFilter = NewFilteringTokenFilter(ans, matchVersion, input)
+	ans.posAtt = addAttribute(ans.Attributes(), "PartOfSpeechAttribute").(PartOfSpeechAttribute)
+	return ans
+}
+
+func (f *JapanesePartOfSpeechStopFilter) Accept() bool {
+	pos := f.posAtt.PartOfSpeech()
+	if pos == "" {
+		return true
+	}
+	_, ok := f.stopTags[pos]
+	return !ok
+}
@@ -0,0 +1,47 @@
+package ja
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ja/JapaneseReadingFormFilter.java
+
+/*
+A TokenFilter that replaces the term attribute with the reading of a
+token in either katakana or romaji form. The default reading form is
+katakana.
+*/
+type JapaneseReadingFormFilter struct {
+	*TokenFilter
+	input      TokenStream
+	termAtt    CharTermAttribute
+	readingAtt ReadingAttribute
+	useRomaji  bool
+}
+
+func NewJapaneseReadingFormFilter(input TokenStream, useRomaji bool) *JapaneseReadingFormFilter {
+	ans := &JapaneseReadingFormFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		useRomaji:   useRomaji,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.readingAtt = addAttribute(ans.Attributes(), "ReadingAttribute").(ReadingAttribute)
+	return ans
+}
+
+func (f *JapaneseReadingFormFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if reading := f.readingAtt.Reading(); reading != "" {
+		if f.useRomaji {
+			f.termAtt.CopyBuffer([]rune(Romanize(reading)))
+		} else {
+			f.termAtt.CopyBuffer([]rune(reading))
+		}
+	}
+	return true, nil
+}
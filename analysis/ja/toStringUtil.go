@@ -0,0 +1,115 @@
+package ja
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// ja/util/ToStringUtil.java
+
+var romaji = map[rune]string{
+	'ア': "a", 'イ': "i", 'ウ': "u", 'エ': "e", 'オ': "o",
+	'カ': "ka", 'キ': "ki", 'ク': "ku", 'ケ': "ke", 'コ': "ko",
+	'ガ': "ga", 'ギ': "gi", 'グ': "gu", 'ゲ': "ge", 'ゴ': "go",
+	'サ': "sa", 'シ': "shi", 'ス': "su", 'セ': "se", 'ソ': "so",
+	'ザ': "za", 'ジ': "ji", 'ズ': "zu", 'ゼ': "ze", 'ゾ': "zo",
+	'タ': "ta", 'チ': "chi", 'ツ': "tsu", 'テ': "te", 'ト': "to",
+	'ダ': "da", 'ヂ': "ji", 'ヅ': "zu", 'デ': "de", 'ド': "do",
+	'ナ': "na", 'ニ': "ni", 'ヌ': "nu", 'ネ': "ne", 'ノ': "no",
+	'ハ': "ha", 'ヒ': "hi", 'フ': "fu", 'ヘ': "he", 'ホ': "ho",
+	'バ': "ba", 'ビ': "bi", 'ブ': "bu", 'ベ': "be", 'ボ': "bo",
+	'パ': "pa", 'ピ': "pi", 'プ': "pu", 'ペ': "pe", 'ポ': "po",
+	'マ': "ma", 'ミ': "mi", 'ム': "mu", 'メ': "me", 'モ': "mo",
+	'ヤ': "ya", 'ユ': "yu", 'ヨ': "yo",
+	'ラ': "ra", 'リ': "ri", 'ル': "ru", 'レ': "re", 'ロ': "ro",
+	'ワ': "wa", 'ヰ': "i", 'ヱ': "e", 'ヲ': "o", 'ン': "n", 'ヴ': "vu",
+	'ァ': "a", 'ィ': "i", 'ゥ': "u", 'ェ': "e", 'ォ': "o",
+	'ャ': "ya", 'ュ': "yu", 'ョ': "yo", 'ヮ': "wa", 'ヵ': "ka", 'ヶ': "ke",
+}
+
+/* Combinations with small vowels, which are not simply yoon. */
+var romajiDigraphs = map[string]string{
+	"シェ": "she", "チェ": "che", "ジェ": "je",
+	"ティ": "ti", "ディ": "di", "トゥ": "tu", "ドゥ": "du",
+	"ファ": "fa", "フィ": "fi", "フェ": "fe", "フォ": "fo",
+	"ウィ": "wi", "ウェ": "we", "ウォ": "wo",
+	"ヴァ": "va", "ヴィ": "vi", "ヴェ": "ve", "ヴォ": "vo",
+}
+
+/*
+Romanizes katakana (or hiragana) input using the modified Hepburn
+system. Prolonged sound marks are dropped, and a syllabic n followed
+by a vowel or y is written as n'.
+*/
+func Romanize(s string) string {
+	in := []rune(s)
+	for i, ch := range in {
+		if unicode.Is(unicode.Hiragana, ch) && ch >= 'ぁ' && ch <= 'ゖ' {
+			in[i] = ch + 0x60 // to katakana
+		}
+	}
+
+	syllables := make([]string, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		ch := in[i]
+		if i+1 < len(in) {
+			if v, ok := romajiDigraphs[string(in[i:i+2])]; ok {
+				syllables = append(syllables, v)
+				i++
+				continue
+			}
+			if base, ok := romaji[ch]; ok && len(base) > 1 && strings.HasSuffix(base, "i") {
+				switch in[i+1] {
+				case 'ャ', 'ュ', 'ョ':
+					stem := base[:len(base)-1]
+					vowel := romaji[in[i+1]][1:]
+					if stem == "sh" || stem == "ch" || stem == "j" {
+						syllables = append(syllables, stem+vowel)
+					} else {
+						syllables = append(syllables, stem+"y"+vowel)
+					}
+					i++
+					continue
+				}
+			}
+		}
+		switch ch {
+		case HIRAGANA_KATAKANA_PROLONGED_SOUND_MARK:
+			// dropped
+		case 'ッ':
+			syllables = append(syllables, "ッ") // resolved below
+		default:
+			if v, ok := romaji[ch]; ok {
+				syllables = append(syllables, v)
+			} else {
+				syllables = append(syllables, string(ch))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for i, syl := range syllables {
+		switch {
+		case syl == "ッ":
+			// sokuon doubles the following consonant
+			if i+1 < len(syllables) && syllables[i+1] != "ッ" {
+				next := syllables[i+1]
+				if strings.HasPrefix(next, "ch") {
+					buf.WriteByte('t')
+				} else if c := next[0]; !strings.ContainsRune("aiueon", rune(c)) {
+					buf.WriteByte(c)
+				}
+			}
+		case syl == "n" && i+1 < len(syllables) && startsWithVowelOrY(syllables[i+1]):
+			buf.WriteString("n'")
+		default:
+			buf.WriteString(syl)
+		}
+	}
+	return buf.String()
+}
+
+func startsWithVowelOrY(s string) bool {
+	return s != "" && strings.ContainsRune("aiueoy", rune(s[0]))
+}
@@ -0,0 +1,60 @@
+package ja
+
+// ja/Token.java
+
+/* Token type reflecting the original source of this token */
+type TokenType int
+
+const (
+	// Known words from the system dictionary.
+	KNOWN = TokenType(iota)
+	// Unknown words (heuristically segmented).
+	UNKNOWN
+	// Known words from the user dictionary.
+	USER
+)
+
+/* Analyzed token with morphological data from its dictionary. */
+type Token struct {
+	dict    Dictionary
+	wordId  int
+	surface []rune
+	offset  int
+	typ     TokenType
+}
+
+func (t *Token) Surface() string { return string(t.surface) }
+
+/* Returns the start offset of the term in the analyzed text. */
+func (t *Token) Offset() int { return t.offset }
+
+func (t *Token) Length() int { return len(t.surface) }
+
+/* Returns reading. "" if token doesn't have reading. */
+func (t *Token) Reading() string { return t.dict.Reading(t.wordId, t.surface) }
+
+/* Returns pronunciation. "" if token doesn't have pronunciation. */
+func (t *Token) Pronunciation() string { return t.dict.Pronunciation(t.wordId, t.surface) }
+
+/* Returns part of speech. */
+func (t *Token) PartOfSpeech() string { return t.dict.PartOfSpeech(t.wordId) }
+
+/* Returns inflection type. "" if token doesn't inflect. */
+func (t *Token) InflectionType() string { return t.dict.InflectionType(t.wordId) }
+
+/* Returns inflection form. "" if token doesn't inflect. */
+func (t *Token) InflectionForm() string { return t.dict.InflectionForm(t.wordId) }
+
+/* Returns base form of the term. "" if token is not inflected. */
+func (t *Token) BaseForm() string { return t.dict.BaseForm(t.wordId, t.surface) }
+
+func (t *Token) Type() TokenType { return t.typ }
+
+/* Returns true if this token is known word. */
+func (t *Token) IsKnown() bool { return t.typ == KNOWN }
+
+/* Returns true if this token is unknown word. */
+func (t *Token) IsUnknown() bool { return t.typ == UNKNOWN }
+
+/* Returns true if this token is defined in user dictionary. */
+func (t *Token) IsUser() bool { return t.typ == USER }
@@ -0,0 +1,177 @@
+package ja
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// ja/tokenattributes/BaseFormAttribute.java
+
+/*
+Attribute for Token.BaseForm().
+
+Note: depending on part of speech, this value may not be applicable,
+and will be "".
+*/
+type BaseFormAttribute interface {
+	util.Attribute
+	BaseForm() string
+	SetToken(token *Token)
+}
+
+// ja/tokenattributes/PartOfSpeechAttribute.java
+
+/* Attribute for Token.PartOfSpeech(). */
+type PartOfSpeechAttribute interface {
+	util.Attribute
+	PartOfSpeech() string
+	SetToken(token *Token)
+}
+
+// ja/tokenattributes/ReadingAttribute.java
+
+/*
+Attribute for Kuromoji reading data.
+
+Note: in some cases this value may not be applicable, and will be "".
+*/
+type ReadingAttribute interface {
+	util.Attribute
+	Reading() string
+	Pronunciation() string
+	SetToken(token *Token)
+}
+
+// ja/tokenattributes/InflectionAttribute.java
+
+/*
+Attribute for Kuromoji inflection data.
+
+Note: in some cases this value may not be applicable, and will be "".
+*/
+type InflectionAttribute interface {
+	util.Attribute
+	InflectionType() string
+	InflectionForm() string
+	SetToken(token *Token)
+}
+
+/* Common part of the implementations below, backed by a Token. */
+type tokenAttributeImpl struct {
+	token *Token
+}
+
+func (a *tokenAttributeImpl) SetToken(token *Token) { a.token = token }
+func (a *tokenAttributeImpl) Clear()                { a.token = nil }
+
+func (a *tokenAttributeImpl) BaseForm() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.BaseForm()
+}
+
+func (a *tokenAttributeImpl) PartOfSpeech() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.PartOfSpeech()
+}
+
+func (a *tokenAttributeImpl) Reading() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.Reading()
+}
+
+func (a *tokenAttributeImpl) Pronunciation() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.Pronunciation()
+}
+
+func (a *tokenAttributeImpl) InflectionType() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.InflectionType()
+}
+
+func (a *tokenAttributeImpl) InflectionForm() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.InflectionForm()
+}
+
+type baseFormAttributeImpl struct{ tokenAttributeImpl }
+
+func (a *baseFormAttributeImpl) Interfaces() []string { return []string{"BaseFormAttribute"} }
+
+func (a *baseFormAttributeImpl) Clone() util.AttributeImpl {
+	return &baseFormAttributeImpl{a.tokenAttributeImpl}
+}
+
+func (a *baseFormAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(BaseFormAttribute).SetToken(a.token)
+}
+
+type partOfSpeechAttributeImpl struct{ tokenAttributeImpl }
+
+func (a *partOfSpeechAttributeImpl) Interfaces() []string { return []string{"PartOfSpeechAttribute"} }
+
+func (a *partOfSpeechAttributeImpl) Clone() util.AttributeImpl {
+	return &partOfSpeechAttributeImpl{a.tokenAttributeImpl}
+}
+
+func (a *partOfSpeechAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(PartOfSpeechAttribute).SetToken(a.token)
+}
+
+type readingAttributeImpl struct{ tokenAttributeImpl }
+
+func (a *readingAttributeImpl) Interfaces() []string { return []string{"ReadingAttribute"} }
+
+func (a *readingAttributeImpl) Clone() util.AttributeImpl {
+	return &readingAttributeImpl{a.tokenAttributeImpl}
+}
+
+func (a *readingAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(ReadingAttribute).SetToken(a.token)
+}
+
+type inflectionAttributeImpl struct{ tokenAttributeImpl }
+
+func (a *inflectionAttributeImpl) Interfaces() []string { return []string{"InflectionAttribute"} }
+
+func (a *inflectionAttributeImpl) Clone() util.AttributeImpl {
+	return &inflectionAttributeImpl{a.tokenAttributeImpl}
+}
+
+func (a *inflectionAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(InflectionAttribute).SetToken(a.token)
+}
+
+/*
+Returns the named Kuromoji attribute of the attribute source, adding
+it first if needed. These attributes are not known to the default
+attribute factory, so they must be added explicitly.
+*/
+func addAttribute(atts *util.AttributeSource, name string) util.Attribute {
+	if !atts.Has(name) {
+		switch name {
+		case "BaseFormAttribute":
+			atts.AddImpl(new(baseFormAttributeImpl))
+		case "PartOfSpeechAttribute":
+			atts.AddImpl(new(partOfSpeechAttributeImpl))
+		case "ReadingAttribute":
+			atts.AddImpl(new(readingAttributeImpl))
+		case "InflectionAttribute":
+			atts.AddImpl(new(inflectionAttributeImpl))
+		default:
+			return atts.Add(name)
+		}
+	}
+	return atts.Get(name)
+}
@@ -0,0 +1,349 @@
+package ja
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+	"math"
+	"unicode"
+)
+
+// ja/JapaneseTokenizer.java
+
+/* Tokenization mode: this determines how the tokenizer handles compound and unknown words. */
+type Mode int
+
+const (
+	// Ordinary segmentation: no decomposition for compounds.
+	NORMAL = Mode(iota)
+	// Segmentation geared towards search: this includes a
+	// decompounding process for long nouns.
+	SEARCH
+	// Extended mode outputs unigrams for unknown words.
+	EXTENDED
+)
+
+/* Default tokenization mode. Currently this is SEARCH. */
+const DEFAULT_MODE = SEARCH
+
+const (
+	SEARCH_MODE_KANJI_LENGTH  = 2
+	SEARCH_MODE_OTHER_LENGTH  = 7 // Must be >= SEARCH_MODE_KANJI_LENGTH
+	SEARCH_MODE_KANJI_PENALTY = 3000
+	SEARCH_MODE_OTHER_PENALTY = 1700
+)
+
+/*
+Tokenizer for Japanese that uses morphological analysis.
+
+This tokenizer sets a number of additional attributes:
+
+  - BaseFormAttribute containing base form for inflected adjectives
+    and verbs.
+  - PartOfSpeechAttribute containing part-of-speech.
+  - ReadingAttribute containing reading and pronunciation.
+  - InflectionAttribute containing additional part-of-speech
+    information for inflected forms.
+
+The segmentation is the lowest cost path through the lattice of all
+dictionary words and unknown word candidates of the input (Viterbi),
+where each path is charged the word costs of its words and the
+connection costs between adjacent words.
+
+Unlike Kuromoji, the whole input is read and analyzed at once, and
+SEARCH mode outputs only the decompounded segmentation (the compound
+token itself is discarded).
+*/
+type JapaneseTokenizer struct {
+	*Tokenizer
+
+	dictionary         *TokenInfoDictionary
+	unkDictionary      *UnknownDictionary
+	userDictionary     *UserDictionary
+	costs              *ConnectionCosts
+	mode               Mode
+	discardPunctuation bool
+
+	termAtt       CharTermAttribute
+	offsetAtt     OffsetAttribute
+	posIncAtt     PositionIncrementAttribute
+	posLengthAtt  PositionLengthAttribute
+	basicFormAtt  BaseFormAttribute
+	posAtt        PartOfSpeechAttribute
+	readingAtt    ReadingAttribute
+	inflectionAtt InflectionAttribute
+
+	buffer  []rune
+	pending []*Token
+	parsed  bool
+}
+
+/*
+Create a new JapaneseTokenizer. dictionary and costs are the system
+dictionary and its connection costs (either may be nil), userDictionary
+is an optional user dictionary which may be nil.
+*/
+func NewJapaneseTokenizer(input io.RuneReader, dictionary *TokenInfoDictionary,
+	costs *ConnectionCosts, userDictionary *UserDictionary,
+	discardPunctuation bool, mode Mode) *JapaneseTokenizer {
+
+	if dictionary == nil {
+		dictionary = NewTokenInfoDictionary()
+	}
+	ans := &JapaneseTokenizer{
+		Tokenizer:          NewTokenizer(input),
+		dictionary:         dictionary,
+		unkDictionary:      DefaultUnknownDictionary(),
+		userDictionary:     userDictionary,
+		costs:              costs,
+		mode:               mode,
+		discardPunctuation: discardPunctuation,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLengthAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	ans.basicFormAtt = addAttribute(ans.Attributes(), "BaseFormAttribute").(BaseFormAttribute)
+	ans.posAtt = addAttribute(ans.Attributes(), "PartOfSpeechAttribute").(PartOfSpeechAttribute)
+	ans.readingAtt = addAttribute(ans.Attributes(), "ReadingAttribute").(ReadingAttribute)
+	ans.inflectionAtt = addAttribute(ans.Attributes(), "InflectionAttribute").(InflectionAttribute)
+	return ans
+}
+
+/* Replaces the dictionary used for unknown words, e.g. one loaded from unk.def */
+func (t *JapaneseTokenizer) SetUnknownDictionary(unkDictionary *UnknownDictionary) {
+	t.unkDictionary = unkDictionary
+}
+
+func (t *JapaneseTokenizer) IncrementToken() (bool, error) {
+	if !t.parsed {
+		if err := t.parse(); err != nil {
+			return false, err
+		}
+	}
+	if len(t.pending) == 0 {
+		return false, nil
+	}
+	token := t.pending[0]
+	t.pending = t.pending[1:]
+
+	t.Attributes().Clear()
+	t.termAtt.CopyBuffer(token.surface)
+	t.basicFormAtt.SetToken(token)
+	t.posAtt.SetToken(token)
+	t.readingAtt.SetToken(token)
+	t.inflectionAtt.SetToken(token)
+	t.offsetAtt.SetOffset(t.CorrectOffset(token.offset), t.CorrectOffset(token.offset+token.Length()))
+	t.posIncAtt.SetPositionIncrement(1)
+	t.posLengthAtt.SetPositionLength(1)
+	return true, nil
+}
+
+func (t *JapaneseTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := t.CorrectOffset(len(t.buffer))
+	t.offsetAtt.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (t *JapaneseTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.buffer = nil
+	t.pending = nil
+	t.parsed = false
+	return nil
+}
+
+type latticeNode struct {
+	start, end int
+	dict       Dictionary
+	wordId     int
+	typ        TokenType
+	user       *userEntry
+	rightId    int
+	cost       int // least cost of any path from BOS to this node
+	back       int // index of the previous node on that path
+}
+
+/* Reads the whole input and runs the Viterbi search over it. */
+func (t *JapaneseTokenizer) parse() error {
+	for {
+		ch, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		t.buffer = append(t.buffer, ch)
+	}
+	t.parsed = true
+
+	text := t.buffer
+	nodes := []latticeNode{{back: -1}} // BOS
+	endAt := make([][]int, len(text)+1)
+	endAt[0] = []int{0}
+
+	add := func(pos, length int, dict Dictionary, wordId int, typ TokenType, user *userEntry) {
+		leftId := dict.LeftId(wordId)
+		wordCost := dict.WordCost(wordId)
+		if t.mode != NORMAL && typ != USER {
+			wordCost += computePenalty(text[pos : pos+length])
+		}
+		leastCost, leastIdx := math.MaxInt64, -1
+		for _, idx := range endAt[pos] {
+			if cost := nodes[idx].cost + t.costs.Get(nodes[idx].rightId, leftId); cost < leastCost {
+				leastCost, leastIdx = cost, idx
+			}
+		}
+		rightId := dict.RightId(wordId)
+		if user != nil {
+			rightId = dict.RightId(user.wordIds[len(user.wordIds)-1])
+		}
+		endAt[pos+length] = append(endAt[pos+length], len(nodes))
+		nodes = append(nodes, latticeNode{
+			start: pos, end: pos + length,
+			dict: dict, wordId: wordId, typ: typ, user: user,
+			rightId: rightId,
+			cost:    leastCost + wordCost,
+			back:    leastIdx,
+		})
+	}
+
+	for pos := 0; pos < len(text); pos++ {
+		if len(endAt[pos]) == 0 {
+			continue // not reachable
+		}
+
+		anyMatches := false
+		if t.userDictionary != nil {
+			t.userDictionary.lookup(text, pos, func(length int, entry *userEntry) {
+				add(pos, length, t.userDictionary, entry.wordIds[0], USER, entry)
+				anyMatches = true
+			})
+		}
+		// user matches take precedence over all other words
+		if anyMatches {
+			continue
+		}
+
+		t.dictionary.lookup(text, pos, func(length int, wordIds []int) {
+			for _, wordId := range wordIds {
+				add(pos, length, t.dictionary, wordId, KNOWN, nil)
+			}
+			anyMatches = true
+		})
+
+		// unknown words are only considered when no known word starts
+		// here, unless the character class always invokes them
+		class := charClassOf(text[pos])
+		if !anyMatches || invokeClass[class] {
+			length := 1
+			if groupClass[class] {
+				for pos+length < len(text) && charClassOf(text[pos+length]) == class {
+					length++
+				}
+			}
+			for _, wordId := range t.unkDictionary.classes[class] {
+				add(pos, length, t.unkDictionary, wordId, UNKNOWN, nil)
+			}
+			if len(t.unkDictionary.classes[class]) == 0 {
+				for _, wordId := range t.unkDictionary.classes[DEFAULT] {
+					add(pos, length, t.unkDictionary, wordId, UNKNOWN, nil)
+				}
+			}
+		}
+	}
+
+	// EOS: pick the least cost path ending at the end of the text
+	best, leastCost := -1, math.MaxInt64
+	for _, idx := range endAt[len(text)] {
+		if cost := nodes[idx].cost + t.costs.Get(nodes[idx].rightId, 0); cost < leastCost {
+			best, leastCost = idx, cost
+		}
+	}
+	assert2(len(text) == 0 || best > 0, "no path through the lattice")
+
+	var path []*latticeNode
+	for idx := best; idx > 0; idx = nodes[idx].back {
+		path = append(path, &nodes[idx])
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		t.backtrace(path[i])
+	}
+	return nil
+}
+
+/* Adds the tokens of the given lattice node to the pending tokens */
+func (t *JapaneseTokenizer) backtrace(node *latticeNode) {
+	text := t.buffer
+	switch {
+	case node.typ == USER:
+		// expand the user entry to its segmentation
+		offset := node.start
+		for i, wordId := range node.user.wordIds {
+			length := node.user.lengths[i]
+			t.emit(&Token{node.dict, wordId, text[offset : offset+length], offset, USER})
+			offset += length
+		}
+	case node.typ == UNKNOWN && t.mode == EXTENDED:
+		// output unigrams for unknown words
+		for i := node.start; i < node.end; i++ {
+			t.emit(&Token{node.dict, node.wordId, text[i : i+1], i, UNKNOWN})
+		}
+	default:
+		t.emit(&Token{node.dict, node.wordId, text[node.start:node.end], node.start, node.typ})
+	}
+}
+
+func (t *JapaneseTokenizer) emit(token *Token) {
+	if t.discardPunctuation && isPunctuationText(token.surface) {
+		return
+	}
+	t.pending = append(t.pending, token)
+}
+
+/* Returns the search mode penalty for a word of the given text */
+func computePenalty(text []rune) int {
+	length := len(text)
+	if length > SEARCH_MODE_KANJI_LENGTH {
+		allKanji := true
+		for _, ch := range text {
+			if charClassOf(ch) != KANJI {
+				allKanji = false
+				break
+			}
+		}
+		if allKanji { // Process only Kanji keywords
+			return (length - SEARCH_MODE_KANJI_LENGTH) * SEARCH_MODE_KANJI_PENALTY
+		} else if length > SEARCH_MODE_OTHER_LENGTH {
+			return (length - SEARCH_MODE_OTHER_LENGTH) * SEARCH_MODE_OTHER_PENALTY
+		}
+	}
+	return 0
+}
+
+func isPunctuationText(text []rune) bool {
+	for _, ch := range text {
+		if !isPunctuation(ch) {
+			return false
+		}
+	}
+	return len(text) > 0
+}
+
+func isPunctuation(ch rune) bool {
+	return unicode.IsSpace(ch) || unicode.IsPunct(ch) ||
+		unicode.IsSymbol(ch) || unicode.IsControl(ch)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
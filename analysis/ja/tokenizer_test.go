@@ -0,0 +1,107 @@
+package ja
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDictionary = `寿司,1285,1285,3000,名詞,一般,*,*,*,*,寿司,スシ,スシ
+が,148,148,3866,助詞,格助詞,一般,*,*,*,が,ガ,ガ
+食べ,691,691,7000,動詞,自立,*,*,一段,連用形,食べる,タベ,タベ
+たい,452,452,5000,助動詞,*,*,*,特殊・タイ,基本形,たい,タイ,タイ
+関西国際空港,1285,1285,2000,名詞,固有名詞,組織,*,*,*,*,カンサイコクサイクウコウ,カンサイコクサイクーコー
+関西,1285,1285,2000,名詞,固有名詞,地域,一般,*,*,*,カンサイ,カンサイ
+国際,1285,1285,2000,名詞,一般,*,*,*,*,*,コクサイ,コクサイ
+空港,1285,1285,2000,名詞,一般,*,*,*,*,*,クウコウ,クーコー
+`
+
+func newTestDictionary(t *testing.T) *TokenInfoDictionary {
+	dict := NewTokenInfoDictionary()
+	if err := dict.Load(strings.NewReader(testDictionary)); err != nil {
+		t.Fatal(err)
+	}
+	return dict
+}
+
+func TestJapaneseTokenizer(t *testing.T) {
+	dict := newTestDictionary(t)
+	ts := NewJapaneseTokenizer(strings.NewReader("寿司が食べたい。"), dict, nil, nil, true, NORMAL)
+	posAtt := ts.Attributes().Get("PartOfSpeechAttribute").(PartOfSpeechAttribute)
+	baseFormAtt := ts.Attributes().Get("BaseFormAttribute").(BaseFormAttribute)
+	var pos, baseForms []string
+	if err := ts.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		ok, err := ts.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		pos = append(pos, posAtt.PartOfSpeech())
+		baseForms = append(baseForms, baseFormAtt.BaseForm())
+	}
+	if expected := []string{"名詞-一般", "助詞-格助詞-一般", "動詞-自立", "助動詞"}; !reflect.DeepEqual(pos, expected) {
+		t.Errorf("expected %q, but got %q", expected, pos)
+	}
+	if expected := []string{"寿司", "が", "食べる", "たい"}; !reflect.DeepEqual(baseForms, expected) {
+		t.Errorf("expected %q, but got %q", expected, baseForms)
+	}
+
+	// unknown katakana words are grouped
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("寿司とコンピューター"), dict, nil, nil, true, NORMAL),
+		"寿司", "と", "コンピューター")
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("寿司、が"), dict, nil, nil, false, NORMAL),
+		"寿司", "、", "が")
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("コピー"), dict, nil, nil, true, EXTENDED),
+		"コ", "ピ", "ー")
+}
+
+func TestJapaneseTokenizerModes(t *testing.T) {
+	dict := newTestDictionary(t)
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("関西国際空港"), dict, nil, nil, true, NORMAL),
+		"関西国際空港")
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("関西国際空港"), dict, nil, nil, true, SEARCH),
+		"関西", "国際", "空港")
+
+	userDict, err := NewUserDictionary(strings.NewReader(
+		"# custom segmentation\n関西国際空港,関西 国際空港,カンサイ コクサイクウコウ,カスタム名詞\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis.AssertTokenStreamContents(t, NewJapaneseTokenizer(strings.NewReader("関西国際空港に"), dict, nil, userDict, true, NORMAL),
+		"関西", "国際空港", "に")
+}
+
+func TestJapaneseAnalyzer(t *testing.T) {
+	a := NewJapaneseAnalyzer(newTestDictionary(t), nil)
+	ts, err := a.TokenStreamForString("dummy", "寿司が食べたい。コンピューター")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis.AssertTokenStreamContents(t, ts, "寿司", "食べる", "コンピュータ")
+}
+
+func TestJapaneseReadingFormFilter(t *testing.T) {
+	dict := newTestDictionary(t)
+	analysis.AssertTokenStreamContents(t, NewJapaneseReadingFormFilter(NewJapaneseTokenizer(
+		strings.NewReader("寿司が食べたい"), dict, nil, nil, true, NORMAL), false),
+		"スシ", "ガ", "タベ", "タイ")
+	analysis.AssertTokenStreamContents(t, NewJapaneseReadingFormFilter(NewJapaneseTokenizer(
+		strings.NewReader("寿司が食べたい"), dict, nil, nil, true, NORMAL), true),
+		"sushi", "ga", "tabe", "tai")
+
+	for _, v := range [][2]string{
+		{"キョウト", "kyouto"}, {"ロバート", "robato"}, {"コンヤ", "kon'ya"},
+		{"ガッコウ", "gakkou"}, {"マッチャ", "matcha"}, {"ジュース", "jusu"},
+		{"フィルム", "firumu"}, {"しんぶん", "shinbun"},
+	} {
+		if got := Romanize(v[0]); got != v[1] {
+			t.Errorf("%v: expected %v, but got %v", v[0], v[1], got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ja
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ja/dict/UserDictionary.java
+
+const (
+	USER_WORD_COST = -100000
+	USER_LEFT_ID   = 5
+	USER_RIGHT_ID  = 5
+)
+
+type userEntry struct {
+	// ids of the entries of each segment, in order
+	wordIds []int
+	// lengths of each segment, summing up to the surface length
+	lengths []int
+}
+
+/*
+Class for building a User Dictionary. This class allows for custom
+segmentation of phrases. Each line has the form:
+
+	surface,segmentation,readings,part-of-speech
+
+where segmentation and readings are separated by spaces, e.g.:
+
+	関西国際空港,関西 国際 空港,カンサイ コクサイ クウコウ,カスタム名詞
+*/
+type UserDictionary struct {
+	*entryDictionary
+	words     map[string]*userEntry
+	maxLength int
+}
+
+/* Parses the user dictionary, one entry per line. */
+func NewUserDictionary(r io.Reader) (*UserDictionary, error) {
+	ans := &UserDictionary{
+		entryDictionary: new(entryDictionary),
+		words:           make(map[string]*userEntry),
+	}
+	rd := newCSVReader(r)
+	for {
+		record, err := rd.Read()
+		if err == io.EOF {
+			return ans, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(record) < 4 {
+			return nil, fmt.Errorf("invalid user dictionary entry: %v", strings.Join(record, ","))
+		}
+		surface := strings.Replace(strings.TrimSpace(record[0]), " ", "", -1)
+		segments := strings.Fields(record[1])
+		readings := strings.Fields(record[2])
+		if len(segments) != len(readings) {
+			return nil, fmt.Errorf("illegal user dictionary entry %v - the number of segmentations (%v) does not the match number of readings (%v)",
+				surface, len(segments), len(readings))
+		}
+		if strings.Join(segments, "") != surface {
+			return nil, fmt.Errorf("illegal user dictionary entry %v - the segmentation does not match the surface", surface)
+		}
+		entry := new(userEntry)
+		for i, seg := range segments {
+			entry.wordIds = append(entry.wordIds, len(ans.entries))
+			entry.lengths = append(entry.lengths, len([]rune(seg)))
+			ans.entries = append(ans.entries, dictEntry{
+				leftId:   USER_LEFT_ID,
+				rightId:  USER_RIGHT_ID,
+				wordCost: USER_WORD_COST,
+				pos:      strings.TrimSpace(record[3]),
+				reading:  readings[i],
+			})
+		}
+		ans.words[surface] = entry
+		if n := len([]rune(surface)); n > ans.maxLength {
+			ans.maxLength = n
+		}
+	}
+}
+
+/* Returns the user entries starting at offset off of text. */
+func (d *UserDictionary) lookup(text []rune, off int, fn func(length int, entry *userEntry)) {
+	for length := 1; length <= d.maxLength && off+length <= len(text); length++ {
+		if entry, ok := d.words[string(text[off:off+length])]; ok {
+			fn(length, entry)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package ko
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// ko/KoreanAnalyzer.java
+
+/*
+Analyzer for Korean that uses morphological analysis.
+
+Filters KoreanTokenizer with KoreanPartOfSpeechStopFilter,
+KoreanReadingFormFilter and LowerCaseFilter.
+*/
+type KoreanAnalyzer struct {
+	*AnalyzerImpl
+	dictionary            *TokenInfoDictionary
+	costs                 *ConnectionCosts
+	userDictionary        *UserDictionary
+	mode                  DecompoundMode
+	stopTags              map[Tag]bool
+	outputUnknownUnigrams bool
+}
+
+/*
+Builds an analyzer with the given system dictionary and connection
+costs, DEFAULT_DECOMPOUND and DEFAULT_STOP_TAGS.
+*/
+func NewKoreanAnalyzer(dictionary *TokenInfoDictionary, costs *ConnectionCosts) *KoreanAnalyzer {
+	return NewKoreanAnalyzerWith(dictionary, costs, nil, DEFAULT_DECOMPOUND, DEFAULT_STOP_TAGS, false)
+}
+
+func NewKoreanAnalyzerWith(dictionary *TokenInfoDictionary, costs *ConnectionCosts,
+	userDictionary *UserDictionary, mode DecompoundMode, stopTags map[Tag]bool,
+	outputUnknownUnigrams bool) *KoreanAnalyzer {
+
+	ans := &KoreanAnalyzer{
+		dictionary:            dictionary,
+		costs:                 costs,
+		userDictionary:        userDictionary,
+		mode:                  mode,
+		stopTags:              stopTags,
+		outputUnknownUnigrams: outputUnknownUnigrams,
+	}
+	ans.AnalyzerImpl = NewAnalyzer()
+	ans.Spi = ans
+	return ans
+}
+
+func (a *KoreanAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := NewKoreanTokenizer(reader, a.dictionary, a.costs, a.userDictionary, a.mode,
+		a.outputUnknownUnigrams, true)
+	var tok TokenStream = NewKoreanPartOfSpeechStopFilter(version, src, a.stopTags)
+	tok = NewKoreanReadingFormFilter(tok)
+	tok = NewLowerCaseFilter(version, tok)
+	return NewTokenStreamComponents(src, tok)
+}
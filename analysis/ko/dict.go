@@ -0,0 +1,357 @@
+package ko
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ko/dict/Dictionary.java
+
+/* A morpheme extracted from a compound token. */
+type Morpheme struct {
+	PosTag      Tag
+	SurfaceForm string
+}
+
+/* Dictionary interface for retrieving morphological data by id. */
+type Dictionary interface {
+	// Get left id of specified word
+	LeftId(wordId int) int
+	// Get right id of specified word
+	RightId(wordId int) int
+	// Get word cost of specified word
+	WordCost(wordId int) int
+	// Get the POSType of specified word (morpheme, compound,
+	// inflect or pre-analysis)
+	POSType(wordId int) POSType
+	// Get the left Tag of specified word. For MORPHEME and COMPOUND
+	// the left and right POS are the same.
+	LeftPOS(wordId int) Tag
+	// Get the right Tag of specified word.
+	RightPOS(wordId int) Tag
+	// Get the reading of specified word (mainly used for Hanja to
+	// Hangul conversion), or "" if the reading is the surface form.
+	Reading(wordId int) string
+	// Get the morphemes of specified word (e.g. 가깝으나:
+	// 가깝/VA + 으나/E), or nil for simple morphemes.
+	Morphemes(wordId int) []Morpheme
+}
+
+type dictEntry struct {
+	leftId, rightId, wordCost int
+	posType                   POSType
+	leftPOS, rightPOS         Tag
+	reading                   string
+	morphemes                 []Morpheme
+}
+
+/* Base dictionary storing its entries in a slice indexed by word id. */
+type entryDictionary struct {
+	entries []dictEntry
+}
+
+func (d *entryDictionary) LeftId(wordId int) int           { return d.entries[wordId].leftId }
+func (d *entryDictionary) RightId(wordId int) int          { return d.entries[wordId].rightId }
+func (d *entryDictionary) WordCost(wordId int) int         { return d.entries[wordId].wordCost }
+func (d *entryDictionary) POSType(wordId int) POSType      { return d.entries[wordId].posType }
+func (d *entryDictionary) LeftPOS(wordId int) Tag          { return d.entries[wordId].leftPOS }
+func (d *entryDictionary) RightPOS(wordId int) Tag         { return d.entries[wordId].rightPOS }
+func (d *entryDictionary) Reading(wordId int) string       { return d.entries[wordId].reading }
+func (d *entryDictionary) Morphemes(wordId int) []Morpheme { return d.entries[wordId].morphemes }
+
+func column(record []string, i int) string {
+	if i >= len(record) || record[i] == "*" {
+		return ""
+	}
+	return record[i]
+}
+
+/*
+Parses one entry in mecab-ko-dic CSV format:
+
+	surface,leftId,rightId,cost,POS,semantic,jongseong,reading,type,startPOS,endPOS,expression
+
+e.g. "가락지나물,1781,3533,2776,NNG,*,T,가락지나물,Compound,*,*,가락지/NNG/*+나물/NNG/*"
+*/
+func parseEntry(record []string) (dictEntry, error) {
+	invalid := fmt.Errorf("invalid dictionary entry: %v", strings.Join(record, ","))
+	if len(record) < 5 {
+		return dictEntry{}, invalid
+	}
+	var ids [3]int
+	for i := range ids {
+		n, err := strconv.Atoi(strings.TrimSpace(record[i+1]))
+		if err != nil {
+			return dictEntry{}, invalid
+		}
+		ids[i] = n
+	}
+	entry := dictEntry{leftId: ids[0], rightId: ids[1], wordCost: ids[2]}
+
+	var err error
+	if typ := column(record, 8); typ != "" {
+		if entry.posType, err = ResolvePOSType(typ); err != nil {
+			return dictEntry{}, err
+		}
+	}
+	if entry.posType == MORPHEME || entry.posType == COMPOUND {
+		if entry.leftPOS, err = ResolveTag(record[4]); err != nil {
+			return dictEntry{}, err
+		}
+		entry.rightPOS = entry.leftPOS
+	} else {
+		if entry.leftPOS, err = ResolveTag(column(record, 9)); err != nil {
+			return dictEntry{}, err
+		}
+		if entry.rightPOS, err = ResolveTag(column(record, 10)); err != nil {
+			return dictEntry{}, err
+		}
+	}
+	if reading := column(record, 7); reading != record[0] {
+		entry.reading = reading
+	}
+	if expression := column(record, 11); expression != "" {
+		for _, part := range strings.Split(expression, "+") {
+			fields := strings.Split(part, "/")
+			if len(fields) < 2 {
+				return dictEntry{}, invalid
+			}
+			tag, err := ResolveTag(fields[1])
+			if err != nil {
+				return dictEntry{}, err
+			}
+			entry.morphemes = append(entry.morphemes, Morpheme{tag, fields[0]})
+		}
+	}
+	return entry, nil
+}
+
+func newCSVReader(r io.Reader) *csv.Reader {
+	ans := csv.NewReader(r)
+	ans.FieldsPerRecord = -1
+	ans.LazyQuotes = true
+	ans.Comment = '#'
+	return ans
+}
+
+// ko/dict/TokenInfoDictionary.java
+
+/*
+The system dictionary, mapping surface forms to the morphological
+data of its entries. It is loaded from the CSV files of mecab-ko-dic.
+*/
+type TokenInfoDictionary struct {
+	*entryDictionary
+	words     map[string][]int
+	maxLength int
+}
+
+func NewTokenInfoDictionary() *TokenInfoDictionary {
+	return &TokenInfoDictionary{
+		entryDictionary: new(entryDictionary),
+		words:           make(map[string][]int),
+	}
+}
+
+/* Loads all entries of the mecab-ko-dic CSV file into this dictionary. */
+func (d *TokenInfoDictionary) Load(r io.Reader) error {
+	rd := newCSVReader(r)
+	for {
+		record, err := rd.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		entry, err := parseEntry(record)
+		if err != nil {
+			return err
+		}
+		wordId := len(d.entries)
+		d.entries = append(d.entries, entry)
+		d.words[record[0]] = append(d.words[record[0]], wordId)
+		if n := len([]rune(record[0])); n > d.maxLength {
+			d.maxLength = n
+		}
+	}
+}
+
+func (d *TokenInfoDictionary) lookup(text []rune, off int, fn func(length int, wordIds []int)) {
+	for length := 1; length <= d.maxLength && off+length <= len(text); length++ {
+		if wordIds, ok := d.words[string(text[off:off+length])]; ok {
+			fn(length, wordIds)
+		}
+	}
+}
+
+// ko/dict/UnknownDictionary.java
+
+/* Dictionary for unknown-word handling, keyed by character class. */
+type UnknownDictionary struct {
+	*entryDictionary
+	classes [CHAR_CLASS_COUNT][]int
+}
+
+func NewUnknownDictionary() *UnknownDictionary {
+	return &UnknownDictionary{entryDictionary: new(entryDictionary)}
+}
+
+/* Loads the entries of a mecab-ko-dic unk.def file into this dictionary. */
+func (d *UnknownDictionary) Load(r io.Reader) error {
+	rd := newCSVReader(r)
+	for {
+		record, err := rd.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		class, ok := charClassNames[record[0]]
+		if !ok {
+			return fmt.Errorf("unknown character class: %v", record[0])
+		}
+		entry, err := parseEntry(record)
+		if err != nil {
+			return err
+		}
+		d.classes[class] = append(d.classes[class], len(d.entries))
+		d.entries = append(d.entries, entry)
+	}
+}
+
+const defaultUnknownDefinitions = `DEFAULT,1801,3566,3640,SY,*,*,*,*,*,*,*
+SPACE,1801,3566,3640,SP,*,*,*,*,*,*,*
+HANGUL,1800,3565,3000,UNKNOWN,*,*,*,*,*,*,*
+HANJA,1798,3563,3000,SH,*,*,*,*,*,*,*
+ALPHA,1799,3564,3000,SL,*,*,*,*,*,*,*
+NUMERIC,1802,3567,3000,SN,*,*,*,*,*,*,*
+SYMBOL,1801,3566,3640,SY,*,*,*,*,*,*,*
+`
+
+/* Returns an UnknownDictionary with default entries for each character class. */
+func DefaultUnknownDictionary() *UnknownDictionary {
+	ans := NewUnknownDictionary()
+	if err := ans.Load(strings.NewReader(defaultUnknownDefinitions)); err != nil {
+		panic(err)
+	}
+	return ans
+}
+
+// ko/dict/CharacterDefinition.java
+
+/* Character classes used for unknown word processing. */
+const (
+	DEFAULT = iota
+	SPACE
+	HANGUL
+	HANJA
+	ALPHA
+	NUMERIC
+	SYMBOL
+	CHAR_CLASS_COUNT
+)
+
+var charClassNames = map[string]int{
+	"DEFAULT": DEFAULT,
+	"SPACE":   SPACE,
+	"HANGUL":  HANGUL,
+	"HANJA":   HANJA,
+	"ALPHA":   ALPHA,
+	"NUMERIC": NUMERIC,
+	"SYMBOL":  SYMBOL,
+}
+
+/* Whether unknown word processing is always invoked for a class. */
+var invokeClass = [CHAR_CLASS_COUNT]bool{ALPHA: true, NUMERIC: true, SYMBOL: true}
+
+/* Whether characters of the same class are grouped into one unknown word. */
+var groupClass = [CHAR_CLASS_COUNT]bool{
+	DEFAULT: true, SPACE: true, HANGUL: true, ALPHA: true, NUMERIC: true, SYMBOL: true,
+}
+
+func charClassOf(ch rune) int {
+	switch {
+	case unicode.IsSpace(ch):
+		return SPACE
+	case unicode.Is(unicode.Hangul, ch):
+		return HANGUL
+	case unicode.Is(unicode.Han, ch):
+		return HANJA
+	case unicode.IsDigit(ch):
+		return NUMERIC
+	case unicode.IsLetter(ch):
+		return ALPHA
+	case unicode.IsPunct(ch) || unicode.IsSymbol(ch):
+		return SYMBOL
+	}
+	return DEFAULT
+}
+
+/* Returns true if the last syllable of the text has a final consonant (jongseong). */
+func hasJongseong(text []rune) bool {
+	if len(text) == 0 {
+		return false
+	}
+	ch := text[len(text)-1]
+	if ch < 0xAC00 || ch > 0xD7A3 {
+		return false
+	}
+	return (ch-0xAC00)%28 != 0
+}
+
+// ko/dict/ConnectionCosts.java
+
+/* n-gram connection cost data, loaded from a mecab matrix.def file. */
+type ConnectionCosts struct {
+	forwardSize, backwardSize int
+	costs                     []int16
+}
+
+/* Loads the connection costs from a mecab matrix.def file. */
+func LoadConnectionCosts(r io.Reader) (*ConnectionCosts, error) {
+	scanner := bufio.NewScanner(r)
+	ans := new(ConnectionCosts)
+	first := true
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var nums []int
+		for _, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid connection cost entry: %v", scanner.Text())
+			}
+			nums = append(nums, n)
+		}
+		if first {
+			if len(nums) != 2 || nums[0] < 0 || nums[1] < 0 {
+				return nil, errors.New("invalid matrix.def header")
+			}
+			ans.forwardSize, ans.backwardSize = nums[0], nums[1]
+			ans.costs = make([]int16, nums[0]*nums[1])
+			first = false
+			continue
+		}
+		if len(nums) != 3 || nums[0] >= ans.forwardSize || nums[1] >= ans.backwardSize {
+			return nil, fmt.Errorf("invalid connection cost entry: %v", scanner.Text())
+		}
+		ans.costs[nums[1]*ans.forwardSize+nums[0]] = int16(nums[2])
+	}
+	return ans, scanner.Err()
+}
+
+/* Returns the cost of connecting a word with forwardId to one with backwardId. */
+func (c *ConnectionCosts) Get(forwardId, backwardId int) int {
+	if c == nil || forwardId >= c.forwardSize || backwardId >= c.backwardSize {
+		return 0
+	}
+	return int(c.costs[backwardId*c.forwardSize+forwardId])
+}
@@ -0,0 +1,48 @@
+package ko
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// ko/KoreanPartOfSpeechStopFilter.java
+
+/* Default list of tags to filter. */
+var DEFAULT_STOP_TAGS = map[Tag]bool{
+	TAG_E: true, TAG_IC: true, TAG_J: true, TAG_MAG: true, TAG_MAJ: true,
+	TAG_MM: true, TAG_SP: true, TAG_SSC: true, TAG_SSO: true, TAG_SC: true,
+	TAG_SE: true, TAG_XPN: true, TAG_XSA: true, TAG_XSN: true, TAG_XSV: true,
+	TAG_UNA: true, TAG_NA: true, TAG_VSV: true,
+}
+
+/*
+Removes tokens that match a set of part-of-speech tags. A token is
+removed if its left part of speech is in the set. For compound tokens
+output in MIXED mode, it is removed if both the left and right part
+of speech are in the set.
+*/
+type KoreanPartOfSpeechStopFilter struct {
+	*FilteringTokenFilter
+	stopTags map[Tag]bool
+	posAtt   PartOfSpeechAttribute
+}
+
+/* Create a new KoreanPartOfSpeechStopFilter. */
+func NewKoreanPartOfSpeechStopFilter(matchVersion util.Version,
+	input TokenStream, stopTags map[Tag]bool) *KoreanPartOfSpeechStopFilter {
+
+	ans := &KoreanPartOfSpeechStopFilter{stopTags: stopTags}
+	ans.FilteringTokenFilter = NewFilteringTokenFilter(ans, matchVersion, input)
+	ans.posAtt = addAttribute(ans.Attributes(), "PartOfSpeechAttribute").(PartOfSpeechAttribute)
+	return ans
+}
+
+func (f *KoreanPartOfSpeechStopFilter) Accept() bool {
+	leftPOS := f.posAtt.LeftPOS()
+	_, ok := f.stopTags[leftPOS]
+	if ok && f.posAtt.POSType() != MORPHEME {
+		_, ok = f.stopTags[f.posAtt.RightPOS()]
+	}
+	return !ok
+}
@@ -0,0 +1,107 @@
+package ko
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ko/POS.java
+
+/* The type of the token. */
+type POSType int
+
+const (
+	// A simple morpheme.
+	MORPHEME = POSType(iota)
+	// Compound noun.
+	COMPOUND
+	// Inflected token.
+	INFLECT
+	// Pre-analysis token.
+	PREANALYSIS
+)
+
+/* Part of speech tag for Korean based on Sejong corpus classification. */
+type Tag int
+
+const (
+	TAG_E       = Tag(iota) // Verbal endings
+	TAG_IC                  // Interjection
+	TAG_J                   // Ending Particle
+	TAG_MAG                 // General Adverb
+	TAG_MAJ                 // Conjunctive adverb
+	TAG_MM                  // Determiner
+	TAG_NNG                 // General Noun
+	TAG_NNP                 // Proper Noun
+	TAG_NNB                 // Dependent noun
+	TAG_NNBC                // Dependent noun
+	TAG_NP                  // Pronoun
+	TAG_NR                  // Numeral
+	TAG_SF                  // Terminal punctuation
+	TAG_SH                  // Chinese Characeter
+	TAG_SL                  // Foreign language
+	TAG_SN                  // Number
+	TAG_SP                  // Space
+	TAG_SSC                 // Closing brackets
+	TAG_SSO                 // Opening brackets
+	TAG_SC                  // Separator
+	TAG_SY                  // Other symbol
+	TAG_SE                  // Ellipsis
+	TAG_VA                  // Adjective
+	TAG_VCN                 // Negative designator
+	TAG_VCP                 // Positive designator
+	TAG_VV                  // Verb
+	TAG_VX                  // Auxiliary Verb or Adjective
+	TAG_XPN                 // Prefix
+	TAG_XR                  // Root
+	TAG_XSA                 // Adjective Suffix
+	TAG_XSN                 // Noun Suffix
+	TAG_XSV                 // Verb Suffix
+	TAG_UNKNOWN             // Unknown
+	TAG_UNA                 // Unknown
+	TAG_NA                  // Unknown
+	TAG_VSV                 // Unknown
+)
+
+var tagNames = []string{
+	"E", "IC", "J", "MAG", "MAJ", "MM", "NNG", "NNP", "NNB", "NNBC",
+	"NP", "NR", "SF", "SH", "SL", "SN", "SP", "SSC", "SSO", "SC", "SY",
+	"SE", "VA", "VCN", "VCP", "VV", "VX", "XPN", "XR", "XSA", "XSN",
+	"XSV", "UNKNOWN", "UNA", "NA", "VSV",
+}
+
+func (t Tag) String() string { return tagNames[t] }
+
+/*
+Returns the Tag of the provided name. Verbal endings (E*) and ending
+particles (J*) are resolved to their general tag.
+*/
+func ResolveTag(name string) (Tag, error) {
+	name = strings.ToUpper(name)
+	if strings.HasPrefix(name, "E") {
+		return TAG_E, nil
+	} else if strings.HasPrefix(name, "J") {
+		return TAG_J, nil
+	}
+	for i, n := range tagNames {
+		if n == name {
+			return Tag(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown part of speech tag: %v", name)
+}
+
+/* Returns the POSType of the provided name. */
+func ResolvePOSType(name string) (POSType, error) {
+	switch strings.ToUpper(name) {
+	case "*":
+		return MORPHEME, nil
+	case "COMPOUND":
+		return COMPOUND, nil
+	case "INFLECT":
+		return INFLECT, nil
+	case "PREANALYSIS":
+		return PREANALYSIS, nil
+	}
+	return 0, fmt.Errorf("unknown token type: %v", name)
+}
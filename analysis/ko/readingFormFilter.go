@@ -0,0 +1,40 @@
+package ko
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// ko/KoreanReadingFormFilter.java
+
+/*
+Replaces term text with the ReadingAttribute which is the Hangul
+transcription of Hanja characters.
+*/
+type KoreanReadingFormFilter struct {
+	*TokenFilter
+	input      TokenStream
+	termAtt    CharTermAttribute
+	readingAtt ReadingAttribute
+}
+
+func NewKoreanReadingFormFilter(input TokenStream) *KoreanReadingFormFilter {
+	ans := &KoreanReadingFormFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.readingAtt = addAttribute(ans.Attributes(), "ReadingAttribute").(ReadingAttribute)
+	return ans
+}
+
+func (f *KoreanReadingFormFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if reading := f.readingAtt.Reading(); reading != "" {
+		f.termAtt.CopyBuffer([]rune(reading))
+	}
+	return true, nil
+}
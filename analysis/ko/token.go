@@ -0,0 +1,77 @@
+package ko
+
+// ko/Token.java
+
+/* Token type reflecting the original source of this token */
+type TokenType int
+
+const (
+	// Known words from the system dictionary.
+	KNOWN = TokenType(iota)
+	// Unknown words (heuristically segmented).
+	UNKNOWN
+	// Known words from the user dictionary.
+	USER
+)
+
+/* Analyzed token with morphological data. */
+type Token struct {
+	dict    Dictionary
+	wordId  int
+	surface []rune
+	offset  int
+	typ     TokenType
+	// set for the parts of a decompounded token
+	morpheme *Morpheme
+
+	posIncr, posLen int
+}
+
+func (t *Token) Surface() string { return string(t.surface) }
+
+/* Returns the start offset of the term in the analyzed text. */
+func (t *Token) Offset() int { return t.offset }
+
+func (t *Token) Length() int { return len(t.surface) }
+
+/* Get the POSType of the token. */
+func (t *Token) POSType() POSType {
+	if t.morpheme != nil {
+		return MORPHEME
+	}
+	return t.dict.POSType(t.wordId)
+}
+
+/* Get the left part of speech of the token. */
+func (t *Token) LeftPOS() Tag {
+	if t.morpheme != nil {
+		return t.morpheme.PosTag
+	}
+	return t.dict.LeftPOS(t.wordId)
+}
+
+/* Get the right part of speech of the token. */
+func (t *Token) RightPOS() Tag {
+	if t.morpheme != nil {
+		return t.morpheme.PosTag
+	}
+	return t.dict.RightPOS(t.wordId)
+}
+
+/* Get the reading of the token, or "" if it is the surface form. */
+func (t *Token) Reading() string {
+	if t.morpheme != nil {
+		return ""
+	}
+	return t.dict.Reading(t.wordId)
+}
+
+/* Get the morphemes of the token, or nil for simple morphemes. */
+func (t *Token) Morphemes() []Morpheme {
+	if t.morpheme != nil {
+		return nil
+	}
+	return t.dict.Morphemes(t.wordId)
+}
+
+func (t *Token) Type() TokenType { return t.typ }
@@ -0,0 +1,121 @@
+package ko
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// ko/tokenattributes/PartOfSpeechAttribute.java
+
+/* Part of Speech attributes for Korean. */
+type PartOfSpeechAttribute interface {
+	util.Attribute
+	// Get the POSType of the token.
+	POSType() POSType
+	// Get the left part of speech of the token.
+	LeftPOS() Tag
+	// Get the right part of speech of the token.
+	RightPOS() Tag
+	// Get the morphemes of the token, or nil if the token is not
+	// decompounded.
+	Morphemes() []Morpheme
+	SetToken(token *Token)
+}
+
+// ko/tokenattributes/ReadingAttribute.java
+
+/*
+Attribute for Korean reading data.
+
+Note: in some cases this value may not be applicable, and will be "".
+*/
+type ReadingAttribute interface {
+	util.Attribute
+	Reading() string
+	SetToken(token *Token)
+}
+
+type partOfSpeechAttributeImpl struct {
+	token *Token
+}
+
+func (a *partOfSpeechAttributeImpl) Interfaces() []string  { return []string{"PartOfSpeechAttribute"} }
+func (a *partOfSpeechAttributeImpl) SetToken(token *Token) { a.token = token }
+func (a *partOfSpeechAttributeImpl) Clear()                { a.token = nil }
+
+func (a *partOfSpeechAttributeImpl) POSType() POSType {
+	if a.token == nil {
+		return MORPHEME
+	}
+	return a.token.POSType()
+}
+
+func (a *partOfSpeechAttributeImpl) LeftPOS() Tag {
+	if a.token == nil {
+		return TAG_UNKNOWN
+	}
+	return a.token.LeftPOS()
+}
+
+func (a *partOfSpeechAttributeImpl) RightPOS() Tag {
+	if a.token == nil {
+		return TAG_UNKNOWN
+	}
+	return a.token.RightPOS()
+}
+
+func (a *partOfSpeechAttributeImpl) Morphemes() []Morpheme {
+	if a.token == nil {
+		return nil
+	}
+	return a.token.Morphemes()
+}
+
+func (a *partOfSpeechAttributeImpl) Clone() util.AttributeImpl {
+	return &partOfSpeechAttributeImpl{a.token}
+}
+
+func (a *partOfSpeechAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(PartOfSpeechAttribute).SetToken(a.token)
+}
+
+type readingAttributeImpl struct {
+	token *Token
+}
+
+func (a *readingAttributeImpl) Interfaces() []string  { return []string{"ReadingAttribute"} }
+func (a *readingAttributeImpl) SetToken(token *Token) { a.token = token }
+func (a *readingAttributeImpl) Clear()                { a.token = nil }
+
+func (a *readingAttributeImpl) Reading() string {
+	if a.token == nil {
+		return ""
+	}
+	return a.token.Reading()
+}
+
+func (a *readingAttributeImpl) Clone() util.AttributeImpl {
+	return &readingAttributeImpl{a.token}
+}
+
+func (a *readingAttributeImpl) CopyTo(target util.AttributeImpl) {
+	target.(ReadingAttribute).SetToken(a.token)
+}
+
+/*
+Returns the named Korean attribute of the attribute source, adding it
+first if needed. These attributes are not known to the default
+attribute factory, so they must be added explicitly.
+*/
+func addAttribute(atts *util.AttributeSource, name string) util.Attribute {
+	if !atts.Has(name) {
+		switch name {
+		case "PartOfSpeechAttribute":
+			atts.AddImpl(new(partOfSpeechAttributeImpl))
+		case "ReadingAttribute":
+			atts.AddImpl(new(readingAttributeImpl))
+		default:
+			return atts.Add(name)
+		}
+	}
+	return atts.Get(name)
+}
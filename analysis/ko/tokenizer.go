@@ -0,0 +1,336 @@
+package ko
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+	"math"
+	"unicode"
+)
+
+// ko/KoreanTokenizer.java
+
+/* Decompound mode: this determines how the tokenizer handles COMPOUND, INFLECT and PREANALYSIS tokens. */
+type DecompoundMode int
+
+const (
+	// No decomposition for compound.
+	NONE = DecompoundMode(iota)
+	// Decompose compounds and discards the original form (default).
+	DISCARD
+	// Decompose compounds and keeps the original form.
+	MIXED
+)
+
+/* Default mode for the decompound of tokens (DISCARD). */
+const DEFAULT_DECOMPOUND = DISCARD
+
+/*
+Tokenizer for Korean that uses morphological analysis.
+
+This tokenizer sets a number of additional attributes:
+
+  - PartOfSpeechAttribute containing part-of-speech.
+  - ReadingAttribute containing reading.
+
+The segmentation is the lowest cost path (Viterbi) through the lattice
+of dictionary words and unknown word candidates. The whole input is
+read and analyzed at once.
+*/
+type KoreanTokenizer struct {
+	*Tokenizer
+
+	dictionary            *TokenInfoDictionary
+	unkDictionary         *UnknownDictionary
+	userDictionary        *UserDictionary
+	costs                 *ConnectionCosts
+	mode                  DecompoundMode
+	outputUnknownUnigrams bool
+	discardPunctuation    bool
+
+	termAtt      CharTermAttribute
+	offsetAtt    OffsetAttribute
+	posIncAtt    PositionIncrementAttribute
+	posLengthAtt PositionLengthAttribute
+	posAtt       PartOfSpeechAttribute
+	readingAtt   ReadingAttribute
+
+	buffer  []rune
+	pending []*Token
+	parsed  bool
+}
+
+/*
+Create a new KoreanTokenizer. dictionary and costs are the system
+dictionary and its connection costs (either may be nil), userDictionary
+is an optional user dictionary which may be nil.
+*/
+func NewKoreanTokenizer(input io.RuneReader, dictionary *TokenInfoDictionary,
+	costs *ConnectionCosts, userDictionary *UserDictionary, mode DecompoundMode,
+	outputUnknownUnigrams, discardPunctuation bool) *KoreanTokenizer {
+
+	if dictionary == nil {
+		dictionary = NewTokenInfoDictionary()
+	}
+	ans := &KoreanTokenizer{
+		Tokenizer:             NewTokenizer(input),
+		dictionary:            dictionary,
+		unkDictionary:         DefaultUnknownDictionary(),
+		userDictionary:        userDictionary,
+		costs:                 costs,
+		mode:                  mode,
+		outputUnknownUnigrams: outputUnknownUnigrams,
+		discardPunctuation:    discardPunctuation,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.posLengthAtt = ans.Attributes().Add("PositionLengthAttribute").(PositionLengthAttribute)
+	ans.posAtt = addAttribute(ans.Attributes(), "PartOfSpeechAttribute").(PartOfSpeechAttribute)
+	ans.readingAtt = addAttribute(ans.Attributes(), "ReadingAttribute").(ReadingAttribute)
+	return ans
+}
+
+/* Replaces the dictionary used for unknown words, e.g. one loaded from unk.def */
+func (t *KoreanTokenizer) SetUnknownDictionary(unkDictionary *UnknownDictionary) {
+	t.unkDictionary = unkDictionary
+}
+
+func (t *KoreanTokenizer) IncrementToken() (bool, error) {
+	if !t.parsed {
+		if err := t.parse(); err != nil {
+			return false, err
+		}
+	}
+	if len(t.pending) == 0 {
+		return false, nil
+	}
+	token := t.pending[0]
+	t.pending = t.pending[1:]
+
+	t.Attributes().Clear()
+	t.termAtt.CopyBuffer(token.surface)
+	t.posAtt.SetToken(token)
+	t.readingAtt.SetToken(token)
+	t.offsetAtt.SetOffset(t.CorrectOffset(token.offset), t.CorrectOffset(token.offset+token.Length()))
+	t.posIncAtt.SetPositionIncrement(token.posIncr)
+	t.posLengthAtt.SetPositionLength(token.posLen)
+	return true, nil
+}
+
+func (t *KoreanTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := t.CorrectOffset(len(t.buffer))
+	t.offsetAtt.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (t *KoreanTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.buffer = nil
+	t.pending = nil
+	t.parsed = false
+	return nil
+}
+
+type latticeNode struct {
+	start, end int
+	dict       Dictionary
+	wordId     int
+	typ        TokenType
+	cost       int // least cost of any path from BOS to this node
+	back       int // index of the previous node on that path
+}
+
+/* Reads the whole input and runs the Viterbi search over it. */
+func (t *KoreanTokenizer) parse() error {
+	for {
+		ch, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		t.buffer = append(t.buffer, ch)
+	}
+	t.parsed = true
+
+	text := t.buffer
+	nodes := []latticeNode{{back: -1}} // BOS
+	endAt := make([][]int, len(text)+1)
+	endAt[0] = []int{0}
+
+	add := func(pos, length int, dict Dictionary, wordId int, typ TokenType) {
+		leftId := dict.LeftId(wordId)
+		leastCost, leastIdx := math.MaxInt64, -1
+		for _, idx := range endAt[pos] {
+			prev := &nodes[idx]
+			rightId := 0
+			if idx > 0 {
+				rightId = prev.dict.RightId(prev.wordId)
+			}
+			if cost := prev.cost + t.costs.Get(rightId, leftId); cost < leastCost {
+				leastCost, leastIdx = cost, idx
+			}
+		}
+		endAt[pos+length] = append(endAt[pos+length], len(nodes))
+		nodes = append(nodes, latticeNode{
+			start: pos, end: pos + length,
+			dict: dict, wordId: wordId, typ: typ,
+			cost: leastCost + dict.WordCost(wordId),
+			back: leastIdx,
+		})
+	}
+
+	for pos := 0; pos < len(text); pos++ {
+		if len(endAt[pos]) == 0 {
+			continue // not reachable
+		}
+
+		anyMatches := false
+		if t.userDictionary != nil {
+			t.userDictionary.lookup(text, pos, func(length, wordId int) {
+				add(pos, length, t.userDictionary, wordId, USER)
+				anyMatches = true
+			})
+		}
+		// user matches take precedence over all other words
+		if anyMatches {
+			continue
+		}
+
+		t.dictionary.lookup(text, pos, func(length int, wordIds []int) {
+			for _, wordId := range wordIds {
+				add(pos, length, t.dictionary, wordId, KNOWN)
+			}
+			anyMatches = true
+		})
+
+		class := charClassOf(text[pos])
+		if !anyMatches || invokeClass[class] {
+			length := 1
+			if groupClass[class] {
+				for pos+length < len(text) && charClassOf(text[pos+length]) == class {
+					length++
+				}
+			}
+			wordIds := t.unkDictionary.classes[class]
+			if len(wordIds) == 0 {
+				wordIds = t.unkDictionary.classes[DEFAULT]
+			}
+			for _, wordId := range wordIds {
+				add(pos, length, t.unkDictionary, wordId, UNKNOWN)
+			}
+		}
+	}
+
+	// EOS: pick the least cost path ending at the end of the text
+	best, leastCost := -1, math.MaxInt64
+	for _, idx := range endAt[len(text)] {
+		node := &nodes[idx]
+		if cost := node.cost + t.costs.Get(node.dict.RightId(node.wordId), 0); cost < leastCost {
+			best, leastCost = idx, cost
+		}
+	}
+	assert2(len(text) == 0 || best > 0, "no path through the lattice")
+
+	var path []*latticeNode
+	for idx := best; idx > 0; idx = nodes[idx].back {
+		path = append(path, &nodes[idx])
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		t.backtrace(path[i])
+	}
+	return nil
+}
+
+/* Adds the tokens of the given lattice node to the pending tokens */
+func (t *KoreanTokenizer) backtrace(node *latticeNode) {
+	surface := t.buffer[node.start:node.end]
+	if t.discardPunctuation && isPunctuationText(surface) {
+		return
+	}
+	token := &Token{
+		dict: node.dict, wordId: node.wordId, surface: surface,
+		offset: node.start, typ: node.typ, posIncr: 1, posLen: 1,
+	}
+
+	if node.typ == UNKNOWN && t.outputUnknownUnigrams {
+		for i := range surface {
+			unigram := *token
+			unigram.surface = surface[i : i+1]
+			unigram.offset = node.start + i
+			t.pending = append(t.pending, &unigram)
+		}
+		return
+	}
+
+	posType := node.dict.POSType(node.wordId)
+	morphemes := node.dict.Morphemes(node.wordId)
+	if t.mode == NONE || (posType != COMPOUND && posType != PREANALYSIS) ||
+		!t.matchesSurface(morphemes, surface) {
+		t.pending = append(t.pending, token)
+		return
+	}
+
+	if t.mode == MIXED {
+		token.posLen = len(morphemes)
+		t.pending = append(t.pending, token)
+	}
+	offset := node.start
+	for i := range morphemes {
+		length := len([]rune(morphemes[i].SurfaceForm))
+		part := &Token{
+			dict: node.dict, wordId: node.wordId,
+			surface: t.buffer[offset : offset+length],
+			offset:  offset, typ: node.typ, morpheme: &morphemes[i],
+			posIncr: 1, posLen: 1,
+		}
+		if i == 0 && t.mode == MIXED {
+			part.posIncr = 0
+		}
+		t.pending = append(t.pending, part)
+		offset += length
+	}
+}
+
+/*
+Returns true if the morphemes spell out the surface, so that they can
+be output as parts of it with proper offsets.
+*/
+func (t *KoreanTokenizer) matchesSurface(morphemes []Morpheme, surface []rune) bool {
+	if len(morphemes) == 0 {
+		return false
+	}
+	s := ""
+	for _, m := range morphemes {
+		s += m.SurfaceForm
+	}
+	return s == string(surface)
+}
+
+func isPunctuationText(text []rune) bool {
+	for _, ch := range text {
+		if !isPunctuation(ch) {
+			return false
+		}
+	}
+	return len(text) > 0
+}
+
+func isPunctuation(ch rune) bool {
+	return unicode.IsSpace(ch) || unicode.IsPunct(ch) ||
+		unicode.IsSymbol(ch) || unicode.IsControl(ch)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,76 @@
+package ko
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDictionary = `가락지나물,1781,3533,2000,NNG,*,T,가락지나물,Compound,*,*,가락지/NNG/*+나물/NNG/*
+가락지,1781,3533,3000,NNG,*,F,가락지,*,*,*,*
+나물,1781,3533,3000,NNG,*,T,나물,*,*,*,*
+을,1794,3559,1000,JKO,*,T,을,*,*,*,*
+먹,2421,3574,1000,VV,*,T,먹,*,*,*,*
+었,2422,3575,1000,EP,*,T,었,*,*,*,*
+다,2423,3576,1000,EF,*,F,다,*,*,*,*
+大韓民國,1781,3533,1000,NNP,*,T,대한민국,*,*,*,*
+`
+
+func newTestDictionary(t *testing.T) *TokenInfoDictionary {
+	dict := NewTokenInfoDictionary()
+	if err := dict.Load(strings.NewReader(testDictionary)); err != nil {
+		t.Fatal(err)
+	}
+	return dict
+}
+
+func assertTokens(t *testing.T, ts TokenStream, expected []string, posIncs []int) {
+	t.Helper()
+	var terms []string
+	var incs []int
+	for _, token := range analysis.ConsumeTokens(t, ts) {
+		terms = append(terms, token.Term)
+		incs = append(incs, token.PosInc)
+	}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("expected %q, but got %q", expected, terms)
+	}
+	if posIncs != nil && !reflect.DeepEqual(incs, posIncs) {
+		t.Errorf("expected position increments %v, but got %v", posIncs, incs)
+	}
+}
+
+func TestKoreanTokenizerDecompound(t *testing.T) {
+	dict := newTestDictionary(t)
+	text := "가락지나물을 먹었다"
+	assertTokens(t, NewKoreanTokenizer(strings.NewReader(text), dict, nil, nil, NONE, false, true),
+		[]string{"가락지나물", "을", "먹", "었", "다"}, nil)
+	assertTokens(t, NewKoreanTokenizer(strings.NewReader(text), dict, nil, nil, DISCARD, false, true),
+		[]string{"가락지", "나물", "을", "먹", "었", "다"}, []int{1, 1, 1, 1, 1, 1})
+	assertTokens(t, NewKoreanTokenizer(strings.NewReader(text), dict, nil, nil, MIXED, false, true),
+		[]string{"가락지나물", "가락지", "나물", "을", "먹", "었", "다"}, []int{1, 0, 1, 1, 1, 1, 1})
+}
+
+func TestKoreanTokenizerUserDictionary(t *testing.T) {
+	userDict, err := NewUserDictionary(strings.NewReader("# comment\n세종시 세종 시\nc++\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := NewKoreanTokenizer(strings.NewReader("세종시"), nil, nil, userDict, DISCARD, false, true)
+	assertTokens(t, ts, []string{"세종", "시"}, nil)
+
+	// unknown words
+	assertTokens(t, NewKoreanTokenizer(strings.NewReader("미등록"), nil, nil, nil, DISCARD, true, true),
+		[]string{"미", "등", "록"}, nil)
+}
+
+func TestKoreanAnalyzer(t *testing.T) {
+	a := NewKoreanAnalyzer(newTestDictionary(t), nil)
+	ts, err := a.TokenStreamForString("dummy", "가락지나물을 먹었다. 大韓民國")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTokens(t, ts, []string{"가락지", "나물", "먹", "대한민국"}, []int{1, 1, 2, 3})
+}
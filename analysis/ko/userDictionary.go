@@ -0,0 +1,93 @@
+package ko
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ko/dict/UserDictionary.java
+
+const (
+	USER_WORD_COST = -100000
+	// NNG left
+	USER_LEFT_ID = 1781
+	// NNG right
+	USER_RIGHT_ID = 3533
+	// NNG right with hangul and a coda on the last char
+	USER_RIGHT_ID_T = 3535
+	// NNG right with hangul and no coda on the last char
+	USER_RIGHT_ID_F = 3534
+)
+
+/*
+Class for building a User Dictionary. Each line holds a general noun
+(NNG), optionally followed by its segmentation, e.g.:
+
+	c++
+	세종시 세종 시
+
+Lines starting with # are comments.
+*/
+type UserDictionary struct {
+	*entryDictionary
+	words     map[string]int
+	maxLength int
+}
+
+func NewUserDictionary(r io.Reader) (*UserDictionary, error) {
+	ans := &UserDictionary{
+		entryDictionary: new(entryDictionary),
+		words:           make(map[string]int),
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexRune(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		surface := []rune(fields[0])
+		entry := dictEntry{
+			leftId:   USER_LEFT_ID,
+			rightId:  USER_RIGHT_ID,
+			wordCost: USER_WORD_COST,
+			leftPOS:  TAG_NNG,
+			rightPOS: TAG_NNG,
+		}
+		if charClassOf(surface[len(surface)-1]) == HANGUL {
+			if hasJongseong(surface) {
+				entry.rightId = USER_RIGHT_ID_T
+			} else {
+				entry.rightId = USER_RIGHT_ID_F
+			}
+		}
+		if len(fields) > 1 {
+			if strings.Join(fields[1:], "") != fields[0] {
+				return nil, fmt.Errorf("illegal user dictionary entry %v - the segmentation does not match the surface", fields[0])
+			}
+			entry.posType = COMPOUND
+			for _, seg := range fields[1:] {
+				entry.morphemes = append(entry.morphemes, Morpheme{TAG_NNG, seg})
+			}
+		}
+		ans.words[fields[0]] = len(ans.entries)
+		ans.entries = append(ans.entries, entry)
+		if len(surface) > ans.maxLength {
+			ans.maxLength = len(surface)
+		}
+	}
+	return ans, scanner.Err()
+}
+
+func (d *UserDictionary) lookup(text []rune, off int, fn func(length, wordId int)) {
+	for length := 1; length <= d.maxLength && off+length <= len(text); length++ {
+		if wordId, ok := d.words[string(text[off:off+length])]; ok {
+			fn(length, wordId)
+		}
+	}
+}
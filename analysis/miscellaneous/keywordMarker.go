@@ -0,0 +1,77 @@
+package miscellaneous
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// miscellaneous/KeywordMarkerFilter.java
+
+type KeywordMarkerFilterSPI interface {
+	// Returns true if the current token is a keyword, otherwise false
+	IsKeyword() bool
+}
+
+/*
+Marks terms as keywords via the KeywordAttribute.
+
+Concrete filters decide which tokens are keywords by implementing
+KeywordMarkerFilterSPI.
+*/
+type KeywordMarkerFilter struct {
+	*TokenFilter
+	spi        KeywordMarkerFilterSPI
+	input      TokenStream
+	keywordAtt KeywordAttribute
+}
+
+/* Creates a new KeywordMarkerFilter */
+func NewKeywordMarkerFilter(spi KeywordMarkerFilterSPI, in TokenStream) *KeywordMarkerFilter {
+	ans := &KeywordMarkerFilter{
+		TokenFilter: NewTokenFilter(in),
+		spi:         spi,
+		input:       in,
+	}
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *KeywordMarkerFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if f.spi.IsKeyword() {
+		f.keywordAtt.SetKeyword(true)
+	}
+	return true, nil
+}
+
+// miscellaneous/SetKeywordMarkerFilter.java
+
+/*
+Marks terms as keywords via the KeywordAttribute. Each token contained
+in the provided set is marked as a keyword.
+*/
+type SetKeywordMarkerFilter struct {
+	*KeywordMarkerFilter
+	termAtt    CharTermAttribute
+	keywordSet map[string]bool
+}
+
+/*
+Create a new SetKeywordMarkerFilter, that marks the current token as a
+keyword if the tokens term buffer is contained in the given set via
+the KeywordAttribute.
+*/
+func NewSetKeywordMarkerFilter(in TokenStream, keywordSet map[string]bool) *SetKeywordMarkerFilter {
+	ans := &SetKeywordMarkerFilter{keywordSet: keywordSet}
+	ans.KeywordMarkerFilter = NewKeywordMarkerFilter(ans, in)
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *SetKeywordMarkerFilter) IsKeyword() bool {
+	_, ok := f.keywordSet[string(f.termAtt.Buffer()[:f.termAtt.Length()])]
+	return ok
+}
@@ -0,0 +1,171 @@
+package miscellaneous
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+const (
+	_AA    = 'Å'
+	_aa    = 'å'
+	_AE    = 'Æ'
+	_ae    = 'æ'
+	_AE_se = 'Ä'
+	_ae_se = 'ä'
+	_OE    = 'Ø'
+	_oe    = 'ø'
+	_OE_se = 'Ö'
+	_oe_se = 'ö'
+)
+
+// miscellaneous/ScandinavianNormalizationFilter.java
+
+/*
+This filter normalize use of the interchangeable Scandinavian
+characters æÆäÄöÖøØ and folded variants (aa, ao, ae, oe and oo) by
+transforming them to åÅæÆøØ.
+
+It's a semantically less destructive solution than
+ScandinavianFoldingFilter, most useful when a person with a
+Norwegian or Danish keyboard queries a Swedish index and vice versa.
+This filter does not perform the common Swedish folds of å and ä to a
+nor ö to o.
+
+blåbærsyltetøj == blåbärsyltetöj == blaabaarsyltetoej but not
+blabarsyltetoj; räksmörgås == ræksmørgås == ræksmörgaos ==
+raeksmoergaas but not raksmorgas
+*/
+type ScandinavianNormalizationFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+func NewScandinavianNormalizationFilter(input TokenStream) *ScandinavianNormalizationFilter {
+	ans := &ScandinavianNormalizationFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *ScandinavianNormalizationFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+
+	buffer := f.termAtt.Buffer()
+	length := f.termAtt.Length()
+	for i := 0; i < length; i++ {
+		switch ch := buffer[i]; {
+		case ch == _ae_se:
+			buffer[i] = _ae
+		case ch == _AE_se:
+			buffer[i] = _AE
+		case ch == _oe_se:
+			buffer[i] = _oe
+		case ch == _OE_se:
+			buffer[i] = _OE
+		case length-1 > i:
+			next := buffer[i+1]
+			switch {
+			case ch == 'a' && (next == 'a' || next == 'o' || next == 'A' || next == 'O'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _aa
+			case ch == 'A' && (next == 'a' || next == 'A' || next == 'o' || next == 'O'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _AA
+			case ch == 'a' && (next == 'e' || next == 'E'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _ae
+			case ch == 'A' && (next == 'e' || next == 'E'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _AE
+			case ch == 'o' && (next == 'e' || next == 'E' || next == 'o' || next == 'O'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _oe
+			case ch == 'O' && (next == 'e' || next == 'E' || next == 'o' || next == 'O'):
+				length = Delete(buffer, i+1, length)
+				buffer[i] = _OE
+			}
+		}
+	}
+	f.termAtt.SetLength(length)
+	return true, nil
+}
+
+// miscellaneous/ScandinavianFoldingFilter.java
+
+/*
+This filter folds Scandinavian characters åÅäæÄÆ->a and öÖøØ->o. It
+also discriminate against use of double vowels aa, ae, ao, oe and oo,
+leaving just the first one.
+
+It's a semantically more destructive solution than
+ScandinavianNormalizationFilter but can in addition help with
+matching raksmorgas as räksmörgås.
+
+blåbærsyltetøj == blåbärsyltetöj == blaabaarsyltetoej ==
+blabarsyltetoj; räksmörgås == ræksmørgås == ræksmörgaos ==
+raeksmoergaas == raksmorgas
+
+Background: Swedish åäö are in fact the same letters as Norwegian and
+Danish åæø and thus interchangeable when used between these
+languages. They are however folded differently when people type them
+on a keyboard lacking these characters.
+
+In that situation almost all Swedish people use a, a, o instead of å,
+ä, ö. Norwegians and Danes on the other hand usually type aa, ae and
+oe instead of å, æ and ø. Some do however use a, a, o, oo, ao and
+sometimes permutations of everything above.
+
+This filter solves that mismatch problem, but might also cause new.
+*/
+type ScandinavianFoldingFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+func NewScandinavianFoldingFilter(input TokenStream) *ScandinavianFoldingFilter {
+	ans := &ScandinavianFoldingFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *ScandinavianFoldingFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+
+	buffer := f.termAtt.Buffer()
+	length := f.termAtt.Length()
+	for i := 0; i < length; i++ {
+		switch ch := buffer[i]; {
+		case ch == _aa || ch == _ae_se || ch == _ae:
+			buffer[i] = 'a'
+		case ch == _AA || ch == _AE_se || ch == _AE:
+			buffer[i] = 'A'
+		case ch == _oe || ch == _oe_se:
+			buffer[i] = 'o'
+		case ch == _OE || ch == _OE_se:
+			buffer[i] = 'O'
+		case length-1 > i:
+			next := buffer[i+1]
+			if (ch == 'a' || ch == 'A') && (next == 'a' || next == 'A' || next == 'e' || next == 'E' || next == 'o' || next == 'O') {
+				length = Delete(buffer, i+1, length)
+			} else if (ch == 'o' || ch == 'O') && (next == 'e' || next == 'E' || next == 'o' || next == 'O') {
+				length = Delete(buffer, i+1, length)
+			}
+		}
+	}
+	f.termAtt.SetLength(length)
+	return true, nil
+}
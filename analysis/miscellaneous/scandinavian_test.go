@@ -0,0 +1,72 @@
+package miscellaneous
+
+import (
+	"github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+/* Checks that the filter turns the input, as a single token, into the expected term. */
+func assertFiltersTo(t *testing.T, newFilter func(TokenStream) TokenStream, input, expected string) {
+	f := newFilter(core.NewKeywordTokenizer(strings.NewReader(input)))
+	analysis.AssertTokenStreamContents(t, f, expected)
+}
+
+func TestScandinavianNormalizationFilter(t *testing.T) {
+	newFilter := func(in TokenStream) TokenStream { return NewScandinavianNormalizationFilter(in) }
+	for _, test := range [][2]string{
+		{"aeäaeeea", "æææeea"},
+		{"aeäaeeeae", "æææeeæ"},
+		{"aeaeeeae", "ææeeæ"},
+		{"bøen", "bøen"},
+		{"bOEen", "bØen"},
+		{"åene", "åene"},
+		{"blåbærsyltetøj", "blåbærsyltetøj"},
+		{"blaabaersyltetöj", "blåbærsyltetøj"},
+		{"räksmörgås", "ræksmørgås"},
+		{"raeksmörgaos", "ræksmørgås"},
+		{"raeksmörgaas", "ræksmørgås"},
+		{"raeksmoergås", "ræksmørgås"},
+		{"ab", "ab"}, {"ob", "ob"}, {"Ab", "Ab"}, {"Ob", "Ob"},
+		{"å", "å"}, {"aa", "å"}, {"aA", "å"}, {"ao", "å"}, {"aO", "å"},
+		{"AA", "Å"}, {"Aa", "Å"}, {"Ao", "Å"}, {"AO", "Å"},
+		{"æ", "æ"}, {"ä", "æ"}, {"Æ", "Æ"}, {"Ä", "Æ"},
+		{"ae", "æ"}, {"aE", "æ"}, {"Ae", "Æ"}, {"AE", "Æ"},
+		{"ö", "ø"}, {"ø", "ø"}, {"Ö", "Ø"}, {"Ø", "Ø"},
+		{"oo", "ø"}, {"oe", "ø"}, {"oO", "ø"}, {"oE", "ø"},
+		{"Oo", "Ø"}, {"Oe", "Ø"}, {"OO", "Ø"}, {"OE", "Ø"},
+	} {
+		assertFiltersTo(t, newFilter, test[0], test[1])
+	}
+}
+
+func TestScandinavianFoldingFilter(t *testing.T) {
+	newFilter := func(in TokenStream) TokenStream { return NewScandinavianFoldingFilter(in) }
+	for _, test := range [][2]string{
+		{"aeäaeeea", "aaaeea"},
+		{"aeäaeeeae", "aaaeea"},
+		{"aeaeeeae", "aaeea"},
+		{"bøen", "boen"},
+		{"åene", "aene"},
+		{"blåbærsyltetøj", "blabarsyltetoj"},
+		{"blaabaarsyltetoej", "blabarsyltetoj"},
+		{"blåbärsyltetöj", "blabarsyltetoj"},
+		{"raksmorgas", "raksmorgas"},
+		{"räksmörgås", "raksmorgas"},
+		{"ræksmørgås", "raksmorgas"},
+		{"raeksmoergaas", "raksmorgas"},
+		{"ræksmörgaos", "raksmorgas"},
+		{"ab", "ab"}, {"ob", "ob"}, {"Ab", "Ab"}, {"Ob", "Ob"},
+		{"å", "a"}, {"aa", "a"}, {"aA", "a"}, {"ao", "a"}, {"aO", "a"},
+		{"AA", "A"}, {"Aa", "A"}, {"Ao", "A"}, {"AO", "A"},
+		{"æ", "a"}, {"ä", "a"}, {"Æ", "A"}, {"Ä", "A"},
+		{"ae", "a"}, {"aE", "a"}, {"Ae", "A"}, {"AE", "A"},
+		{"ö", "o"}, {"ø", "o"}, {"Ö", "O"}, {"Ø", "O"},
+		{"oo", "o"}, {"oe", "o"}, {"oO", "o"}, {"oE", "o"},
+		{"Oo", "O"}, {"Oe", "O"}, {"OO", "O"}, {"OE", "O"},
+	} {
+		assertFiltersTo(t, newFilter, test[0], test[1])
+	}
+}
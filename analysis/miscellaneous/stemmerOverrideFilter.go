@@ -0,0 +1,65 @@
+package miscellaneous
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"strings"
+)
+
+// miscellaneous/StemmerOverrideFilter.java
+
+/*
+Provides the ability to override any KeywordAttribute aware stemmer
+with custom dictionary-based stemming. Overridden terms are marked as
+keywords, so that stemmers further down the chain leave them alone.
+*/
+type StemmerOverrideFilter struct {
+	*TokenFilter
+	input      TokenStream
+	dictionary map[string]string
+	ignoreCase bool
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+/*
+Create a new StemmerOverrideFilter, performing dictionary-based
+stemming with the provided dictionary. If ignoreCase is true, terms
+are looked up in lower case, and the dictionary keys must be given in
+lower case.
+
+Any dictionary-stemmed terms will be marked with KeywordAttribute so
+that they will not be stemmed with stemmers down the chain.
+*/
+func NewStemmerOverrideFilter(input TokenStream, dictionary map[string]string, ignoreCase bool) *StemmerOverrideFilter {
+	ans := &StemmerOverrideFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		dictionary:  dictionary,
+		ignoreCase:  ignoreCase,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *StemmerOverrideFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if len(f.dictionary) == 0 {
+		return true, nil
+	}
+	if !f.keywordAtt.IsKeyword() { // don't muck with already-keyworded terms
+		term := string(f.termAtt.Buffer()[:f.termAtt.Length()])
+		if f.ignoreCase {
+			term = strings.ToLower(term)
+		}
+		if stem, ok := f.dictionary[term]; ok {
+			f.termAtt.CopyBuffer([]rune(stem))
+			f.keywordAtt.SetKeyword(true)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package miscellaneous
+
+import (
+	"github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+func TestStemmerOverrideFilter(t *testing.T) {
+	dictionary := map[string]string{"booked": "books", "mice": "mouse"}
+	for _, test := range []struct {
+		input, expected string
+		ignoreCase      bool
+		keyword         bool
+	}{
+		{"booked", "books", false, true},
+		{"Booked", "Booked", false, false},
+		{"Booked", "books", true, true},
+		{"MICE", "mouse", true, true},
+		{"cats", "cats", true, false},
+	} {
+		f := NewStemmerOverrideFilter(core.NewKeywordTokenizer(strings.NewReader(test.input)),
+			dictionary, test.ignoreCase)
+		termAtt := f.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+		keywordAtt := f.Attributes().Get("KeywordAttribute").(KeywordAttribute)
+		if err := f.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := f.IncrementToken(); err != nil || !ok {
+			t.Fatalf("%q: expected a token, got %v, %v", test.input, ok, err)
+		}
+		if term := string(termAtt.Buffer()[:termAtt.Length()]); term != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.input, test.expected, term)
+		}
+		if keywordAtt.IsKeyword() != test.keyword {
+			t.Errorf("%q: expected keyword=%v", test.input, test.keyword)
+		}
+	}
+}
+
+func TestStemmerOverrideFilterKeepsKeywords(t *testing.T) {
+	// terms already marked as keywords are left alone
+	var f TokenStream = core.NewKeywordTokenizer(strings.NewReader("mice"))
+	f = NewSetKeywordMarkerFilter(f, map[string]bool{"mice": true})
+	f = NewStemmerOverrideFilter(f, map[string]string{"mice": "mouse"}, false)
+	analysis.AssertTokenStreamContents(t, f, "mice")
+}
@@ -0,0 +1,127 @@
+package nl
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/miscellaneous"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// nl/DutchAnalyzer.java
+
+/* The default set of Dutch stop words, from the snowball project. */
+var DUTCH_STOP_WORDS_SET = map[string]bool{
+	"de": true, "en": true, "van": true, "ik": true, "te": true,
+	"dat": true, "die": true, "in": true, "een": true, "hij": true,
+	"het": true, "niet": true, "zijn": true, "is": true, "was": true,
+	"op": true, "aan": true, "met": true, "als": true, "voor": true,
+	"had": true, "er": true, "maar": true, "om": true, "hem": true,
+	"dan": true, "zou": true, "of": true, "wat": true, "mijn": true,
+	"men": true, "dit": true, "zo": true, "door": true, "over": true,
+	"ze": true, "zich": true, "bij": true, "ook": true, "tot": true,
+	"je": true, "mij": true, "uit": true, "der": true, "daar": true,
+	"haar": true, "naar": true, "heb": true, "hoe": true, "heeft": true,
+	"hebben": true, "deze": true, "u": true, "want": true, "nog": true,
+	"zal": true, "me": true, "zij": true, "nu": true, "ge": true,
+	"geen": true, "omdat": true, "iets": true, "worden": true,
+	"toch": true, "al": true, "waren": true, "veel": true, "meer": true,
+	"doen": true, "toen": true, "moet": true, "ben": true,
+	"zonder": true, "kan": true, "hun": true, "dus": true,
+	"alles": true, "onder": true, "ja": true, "eens": true,
+	"hier": true, "wie": true, "werd": true, "altijd": true,
+	"doch": true, "wordt": true, "wezen": true, "kunnen": true,
+	"ons": true, "zelf": true, "tegen": true, "na": true, "reeds": true,
+	"wil": true, "kon": true, "niets": true, "uw": true, "iemand": true,
+	"geweest": true, "andere": true,
+}
+
+/*
+Default stem dictionary, overriding the stems of some words which
+the stemmer gets wrong.
+*/
+var DEFAULT_STEM_DICT = map[string]string{
+	"fiets":     "fiets", // otherwise fiet
+	"bromfiets": "bromfiets",
+	"ei":        "eier",
+	"kind":      "kinder",
+}
+
+/*
+Analyzer for Dutch language.
+
+Supports an external list of stopwords (words that will not be
+indexed at all), an external list of exclusions (word that will not
+be stemmed, but indexed) and an external list of word-stem pairs that
+overrule the algorithm (dictionary stemming). A default set of
+stopwords is used unless an alternative list is specified, but the
+exclusion list is empty by default.
+
+Filters StandardTokenizer with StandardFilter, LowerCaseFilter,
+StopFilter, StemmerOverrideFilter and DutchStemFilter.
+
+You may specify the Version compatibility when creating
+DutchAnalyzer:
+
+  - GoLucene supports 4.5+ only.
+*/
+type DutchAnalyzer struct {
+	*StopwordAnalyzerBase
+	stoptable map[string]bool
+	excltable map[string]bool
+	stemdict  map[string]string
+}
+
+func init() {
+	RegisterAnalyzer("dutch", func() Analyzer {
+		return NewDutchAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (DUTCH_STOP_WORDS_SET). */
+func NewDutchAnalyzer() *DutchAnalyzer {
+	return NewDutchAnalyzerWithStopWords(DUTCH_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewDutchAnalyzerWithStopWords(stopWords map[string]bool) *DutchAnalyzer {
+	return NewDutchAnalyzerWithStemDict(stopWords, nil, DEFAULT_STEM_DICT)
+}
+
+/*
+Builds an analyzer with the given stop words, stem exclusion words
+and stem override dictionary. A nil or empty dictionary disables
+dictionary stemming.
+*/
+func NewDutchAnalyzerWithStemDict(stopWords, stemExclusionTable map[string]bool,
+	stemOverrideDict map[string]string) *DutchAnalyzer {
+
+	ans := &DutchAnalyzer{
+		stoptable: stopWords,
+		excltable: make(map[string]bool),
+		stemdict:  stemOverrideDict,
+	}
+	for k, v := range stemExclusionTable {
+		ans.excltable[k] = v
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *DutchAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = standard.NewStandardFilter(version, src)
+	tok = NewLowerCaseFilter(version, tok)
+	tok = NewStopFilter(version, tok, a.stoptable)
+	if len(a.excltable) > 0 {
+		tok = NewSetKeywordMarkerFilter(tok, a.excltable)
+	}
+	if len(a.stemdict) > 0 {
+		tok = NewStemmerOverrideFilter(tok, a.stemdict, false)
+	}
+	tok = NewDutchStemFilter(tok)
+	return NewTokenStreamComponents(src, tok)
+}
@@ -0,0 +1,56 @@
+package nl
+
+import (
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"testing"
+)
+
+func TestDutchStemmer(t *testing.T) {
+	a := NewDutchAnalyzer()
+	for _, v := range [][2]string{
+		{"lichaamsziek", "lichaamsziek"},
+		{"lichamelijk", "licham"},
+		{"lichamelijke", "licham"},
+		{"lichamelijkheden", "licham"},
+		{"lichamen", "licham"},
+		{"lichere", "licher"},
+		{"licht", "licht"},
+		{"lichtbeeld", "lichtbeeld"},
+		{"lichtdoorlatende", "lichtdoorlat"},
+		{"lichte", "licht"},
+		{"lichten", "licht"},
+		{"lichtende", "lichtend"},
+		{"lichtere", "lichter"},
+		{"lichters", "lichter"},
+		{"lichtgevoeligheid", "lichtgevoel"},
+		{"lichtje", "lichtj"},
+		{"lichtjes", "lichtjes"},
+		{"lichtkranten", "lichtkrant"},
+		{"lichtte", "licht"},
+		{"lichtten", "licht"},
+		{"lichttoetreding", "lichttoetred"},
+		{"lichtzinnige", "lichtzinn"},
+		{"lid", "lid"},
+		{"opheffen", "opheff"},
+		{"opheffende", "opheff"},
+		{"opheffing", "opheff"},
+		{"maan", "man"},
+	} {
+		analysis.AssertAnalyzesTo(t, a, v[0], v[1])
+	}
+}
+
+func TestDutchStemOverride(t *testing.T) {
+	a := NewDutchAnalyzer()
+	analysis.AssertAnalyzesTo(t, a, "fiets", "fiets")
+	analysis.AssertAnalyzesTo(t, a, "ei kind", "eier", "kinder")
+
+	a = NewDutchAnalyzerWithStemDict(DUTCH_STOP_WORDS_SET, nil, nil)
+	analysis.AssertAnalyzesTo(t, a, "fiets", "fiet")
+}
+
+func TestDutchStemExclusion(t *testing.T) {
+	a := NewDutchAnalyzerWithStemDict(DUTCH_STOP_WORDS_SET,
+		map[string]bool{"lichamelijk": true}, DEFAULT_STEM_DICT)
+	analysis.AssertAnalyzesTo(t, a, "de lichamelijk lichamelijke", "lichamelijk", "licham")
+}
@@ -0,0 +1,46 @@
+package nl
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// nl/DutchStemFilter.java
+
+/*
+A TokenFilter that applies DutchStemmer to stem Dutch words.
+
+To prevent terms from being stemmed use an instance of
+SetKeywordMarkerFilter or a custom TokenFilter that sets the
+KeywordAttribute before this TokenStream.
+*/
+type DutchStemFilter struct {
+	*TokenFilter
+	input      TokenStream
+	stemmer    *DutchStemmer
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+}
+
+func NewDutchStemFilter(input TokenStream) *DutchStemFilter {
+	ans := &DutchStemFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		stemmer:     new(DutchStemmer),
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *DutchStemFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() {
+		newlen := f.stemmer.Stem(f.termAtt.Buffer(), f.termAtt.Length())
+		f.termAtt.SetLength(newlen)
+	}
+	return true, nil
+}
@@ -0,0 +1,242 @@
+package nl
+
+// nl/DutchStemmer.java
+
+/*
+A stemmer for Dutch words.
+
+The algorithm is an implementation of the Dutch stemming algorithm
+of the snowball project:
+
+	http://snowball.tartarus.org/algorithms/dutch/stemmer.html
+
+Stem() works in place, and never makes the term longer.
+*/
+type DutchStemmer struct{}
+
+func isVowel(ch rune) bool {
+	switch ch {
+	case 'a', 'e', 'i', 'o', 'u', 'y', 'è':
+		return true
+	}
+	return false
+}
+
+type dutchWord struct {
+	s      []rune
+	r1, r2 int
+	eFound bool
+}
+
+func (w *dutchWord) endsWith(suffix string) bool {
+	rs := []rune(suffix)
+	if len(rs) > len(w.s) {
+		return false
+	}
+	for i, ch := range rs {
+		if w.s[len(w.s)-len(rs)+i] != ch {
+			return false
+		}
+	}
+	return true
+}
+
+/* Returns true if the rune before the given suffix length is a non-vowel. */
+func (w *dutchWord) nonVowelBefore(n int) bool {
+	i := len(w.s) - n - 1
+	return i >= 0 && !isVowel(w.s[i])
+}
+
+func (w *dutchWord) inR1(n int) bool { return len(w.s)-n >= w.r1 }
+func (w *dutchWord) inR2(n int) bool { return len(w.s)-n >= w.r2 }
+
+func (w *dutchWord) cut(n int) { w.s = w.s[:len(w.s)-n] }
+
+/* If the word ends with kk, dd or tt, removes the last letter. */
+func (w *dutchWord) undouble() {
+	if w.endsWith("kk") || w.endsWith("dd") || w.endsWith("tt") {
+		w.cut(1)
+	}
+}
+
+/*
+Deletes the suffix of length n if it is in R1, preceded by a
+non-vowel and not preceded by 'gem', and then undoubles the ending.
+*/
+func (w *dutchWord) enEnding(n int) {
+	if !w.inR1(n) || !w.nonVowelBefore(n) {
+		return
+	}
+	if i := len(w.s) - n; i >= 3 && string(w.s[i-3:i]) == "gem" {
+		return
+	}
+	w.cut(n)
+	w.undouble()
+}
+
+func (st *DutchStemmer) Stem(s []rune, length int) int {
+	w := &dutchWord{s: s[:length]}
+	w.prelude()
+	w.markRegions()
+	w.step1()
+	w.step2()
+	w.step3a()
+	w.step3b()
+	w.step4()
+	w.postlude()
+	return len(w.s)
+}
+
+func (w *dutchWord) prelude() {
+	for i, ch := range w.s {
+		switch ch {
+		case 'ä', 'á':
+			w.s[i] = 'a'
+		case 'ë', 'é':
+			w.s[i] = 'e'
+		case 'ï', 'í':
+			w.s[i] = 'i'
+		case 'ö', 'ó':
+			w.s[i] = 'o'
+		case 'ü', 'ú':
+			w.s[i] = 'u'
+		}
+	}
+	if len(w.s) > 0 && w.s[0] == 'y' {
+		w.s[0] = 'Y'
+	}
+	for i := 1; i < len(w.s); i++ {
+		if !isVowel(w.s[i-1]) {
+			continue
+		}
+		switch w.s[i] {
+		case 'y':
+			w.s[i] = 'Y'
+		case 'i':
+			if i+1 < len(w.s) && isVowel(w.s[i+1]) {
+				w.s[i] = 'I'
+			}
+		}
+	}
+}
+
+func (w *dutchWord) markRegions() {
+	next := func(from int) int {
+		for i := from; i+1 < len(w.s); i++ {
+			if isVowel(w.s[i]) && !isVowel(w.s[i+1]) {
+				return i + 2
+			}
+		}
+		return len(w.s)
+	}
+	w.r1 = next(0)
+	w.r2 = next(w.r1)
+	if w.r1 < 3 {
+		w.r1 = 3
+	}
+}
+
+func (w *dutchWord) step1() {
+	switch {
+	case w.endsWith("heden"):
+		if w.inR1(5) {
+			w.cut(2)
+			w.s[len(w.s)-1] = 'i'
+			w.s = append(w.s, 'd')
+		}
+	case w.endsWith("ene"):
+		w.enEnding(3)
+	case w.endsWith("en"):
+		w.enEnding(2)
+	case w.endsWith("se"):
+		w.sEnding(2)
+	case w.endsWith("s"):
+		w.sEnding(1)
+	}
+}
+
+func (w *dutchWord) sEnding(n int) {
+	if w.inR1(n) && w.nonVowelBefore(n) && w.s[len(w.s)-n-1] != 'j' {
+		w.cut(n)
+	}
+}
+
+func (w *dutchWord) step2() {
+	w.eFound = false
+	if w.endsWith("e") && w.inR1(1) && w.nonVowelBefore(1) {
+		w.cut(1)
+		w.eFound = true
+		w.undouble()
+	}
+}
+
+func (w *dutchWord) step3a() {
+	if w.endsWith("heid") && w.inR2(4) && !(len(w.s) > 4 && w.s[len(w.s)-5] == 'c') {
+		w.cut(4)
+		if w.endsWith("en") {
+			w.enEnding(2)
+		}
+	}
+}
+
+func (w *dutchWord) step3b() {
+	switch {
+	case w.endsWith("end") || w.endsWith("ing"):
+		if w.inR2(3) {
+			w.cut(3)
+			if w.endsWith("ig") && w.inR2(2) && !(len(w.s) > 2 && w.s[len(w.s)-3] == 'e') {
+				w.cut(2)
+			} else {
+				w.undouble()
+			}
+		}
+	case w.endsWith("ig"):
+		if w.inR2(2) && !(len(w.s) > 2 && w.s[len(w.s)-3] == 'e') {
+			w.cut(2)
+		}
+	case w.endsWith("lijk"):
+		if w.inR2(4) {
+			w.cut(4)
+			w.step2()
+		}
+	case w.endsWith("baar"):
+		if w.inR2(4) {
+			w.cut(4)
+		}
+	case w.endsWith("bar"):
+		if w.inR2(3) && w.eFound {
+			w.cut(3)
+		}
+	}
+}
+
+/*
+If the word ends CVD, where C is a non-vowel, D is a non-vowel other
+than I, and V is double a, e, o or u, removes one of the vowels from V.
+*/
+func (w *dutchWord) step4() {
+	n := len(w.s)
+	if n < 4 {
+		return
+	}
+	c, v1, v2, d := w.s[n-4], w.s[n-3], w.s[n-2], w.s[n-1]
+	if isVowel(c) || isVowel(d) || d == 'I' || v1 != v2 {
+		return
+	}
+	switch v1 {
+	case 'a', 'e', 'o', 'u':
+		w.s[n-2] = d
+		w.cut(1)
+	}
+}
+
+func (w *dutchWord) postlude() {
+	for i, ch := range w.s {
+		switch ch {
+		case 'I':
+			w.s[i] = 'i'
+		case 'Y':
+			w.s[i] = 'y'
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package opennlp
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// opennlp/OpenNLPPOSFilter.java
+
+/*
+Tagger is the integration point for external NLP taggers, e.g. a
+part-of-speech tagger or a named entity recognizer. Users implement it
+by calling out to their tagger of choice.
+*/
+type Tagger interface {
+	// Returns one tag for each of the given tokens, in order. An empty
+	// tag leaves the type (and payload) of its token unchanged.
+	Tag(tokens []string) ([]string, error)
+}
+
+/*
+TaggerFunc adapts an ordinary function to the Tagger interface.
+*/
+type TaggerFunc func(tokens []string) ([]string, error)
+
+func (f TaggerFunc) Tag(tokens []string) ([]string, error) { return f(tokens) }
+
+/*
+Run an external Tagger over the tokens of the stream, and set the
+TypeAttribute of each token to its tag (e.g. a POS tag or an entity
+label). Optionally, the tag is also stored as the token's payload, so
+that it is indexed and can be used for payload-based scoring.
+
+All tokens of the stream are passed to the tagger in one call, since
+taggers need the context of the surrounding words; the input is
+consumed completely on the first call to IncrementToken().
+*/
+type TaggerFilter struct {
+	*TokenFilter
+	input          TokenStream
+	tagger         Tagger
+	tagsAsPayloads bool
+
+	termAtt    CharTermAttribute
+	typeAtt    TypeAttribute
+	payloadAtt PayloadAttribute
+
+	states []*util.AttributeState
+	tags   []string
+	index  int
+	filled bool
+}
+
+/* Creates a filter setting the types of the tokens to their tags. */
+func NewTaggerFilter(input TokenStream, tagger Tagger) *TaggerFilter {
+	return newTaggerFilter(input, tagger, false)
+}
+
+/* Creates a filter setting both the types and the payloads of the tokens to their tags. */
+func NewTaggerFilterWithPayloads(input TokenStream, tagger Tagger) *TaggerFilter {
+	return newTaggerFilter(input, tagger, true)
+}
+
+func newTaggerFilter(input TokenStream, tagger Tagger, tagsAsPayloads bool) *TaggerFilter {
+	ans := &TaggerFilter{
+		TokenFilter:    NewTokenFilter(input),
+		input:          input,
+		tagger:         tagger,
+		tagsAsPayloads: tagsAsPayloads,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	if tagsAsPayloads {
+		ans.payloadAtt = ans.Attributes().Add("PayloadAttribute").(PayloadAttribute)
+	}
+	return ans
+}
+
+func (f *TaggerFilter) IncrementToken() (bool, error) {
+	if !f.filled {
+		if err := f.fill(); err != nil {
+			return false, err
+		}
+	}
+	if f.index >= len(f.states) {
+		return false, nil
+	}
+	f.Attributes().Clear()
+	f.Attributes().RestoreState(f.states[f.index])
+	if tag := f.tags[f.index]; tag != "" {
+		f.typeAtt.SetType(tag)
+		if f.tagsAsPayloads {
+			f.payloadAtt.SetPayload([]byte(tag))
+		}
+	}
+	f.index++
+	return true, nil
+}
+
+/* Buffers all tokens of the input and tags them. */
+func (f *TaggerFilter) fill() error {
+	f.filled = true
+	var terms []string
+	for {
+		ok, err := f.input.IncrementToken()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		terms = append(terms, string(f.termAtt.Buffer()[:f.termAtt.Length()]))
+		f.states = append(f.states, f.Attributes().CaptureState())
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	tags, err := f.tagger.Tag(terms)
+	if err != nil {
+		return err
+	}
+	if len(tags) != len(terms) {
+		return fmt.Errorf("tagger returned %v tags for %v tokens", len(tags), len(terms))
+	}
+	f.tags = tags
+	return nil
+}
+
+func (f *TaggerFilter) Reset() error {
+	if err := f.TokenFilter.Reset(); err != nil {
+		return err
+	}
+	f.states = nil
+	f.tags = nil
+	f.index = 0
+	f.filled = false
+	return nil
+}
@@ -0,0 +1,79 @@
+package opennlp
+
+import (
+	"errors"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var posTagger = TaggerFunc(func(tokens []string) ([]string, error) {
+	tags := make([]string, len(tokens))
+	for i, token := range tokens {
+		switch {
+		case token == "the":
+			tags[i] = "DT"
+		case strings.HasSuffix(token, "s"):
+			tags[i] = "VBZ"
+		case i > 0 && tags[i-1] == "DT":
+			tags[i] = "NN"
+		}
+	}
+	return tags, nil
+})
+
+func TestTaggerFilter(t *testing.T) {
+	src := standard.NewStandardTokenizer(util.VERSION_45, strings.NewReader("the dog barks loudly"))
+	f := NewTaggerFilterWithPayloads(src, posTagger)
+	typeAtt := f.Attributes().Get("TypeAttribute").(TypeAttribute)
+	payloadAtt := f.Attributes().Get("PayloadAttribute").(PayloadAttribute)
+
+	for round := 0; round < 2; round++ {
+		if err := f.Reset(); err != nil {
+			t.Fatal(err)
+		}
+		var types, payloads []string
+		for {
+			ok, err := f.IncrementToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			types = append(types, typeAtt.Type())
+			payloads = append(payloads, string(payloadAtt.Payload()))
+		}
+		if expected := []string{"DT", "NN", "VBZ", "<ALPHANUM>"}; !reflect.DeepEqual(types, expected) {
+			t.Errorf("expected types %q, but got %q", expected, types)
+		}
+		if expected := []string{"DT", "NN", "VBZ", ""}; !reflect.DeepEqual(payloads, expected) {
+			t.Errorf("expected payloads %q, but got %q", expected, payloads)
+		}
+		if err := f.End(); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := src.SetReader(strings.NewReader("the dog barks loudly")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTaggerFilterError(t *testing.T) {
+	src := standard.NewStandardTokenizer(util.VERSION_45, strings.NewReader("a b"))
+	f := NewTaggerFilter(src, TaggerFunc(func(tokens []string) ([]string, error) {
+		return nil, errors.New("tagger failed")
+	}))
+	if err := f.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.IncrementToken(); err == nil || err.Error() != "tagger failed" {
+		t.Errorf("expected tagger error, but got %v", err)
+	}
+}
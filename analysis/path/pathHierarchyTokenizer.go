@@ -0,0 +1,166 @@
+package path
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+)
+
+// path/PathHierarchyTokenizer.java
+
+const (
+	DEFAULT_DELIMITER = '/'
+	DEFAULT_SKIP      = 0
+)
+
+/*
+Tokenizer for path-like hierarchies.
+
+Take something like:
+
+	/something/something/else
+
+and make:
+
+	/something
+	/something/something
+	/something/something/else
+
+All tokens but the first have a position increment of 0, so that a
+document is matched by a term query on any of its ancestor paths.
+The delimiter can be replaced by another character in the tokens, and
+a number of leading path elements can be skipped.
+*/
+type PathHierarchyTokenizer struct {
+	*Tokenizer
+
+	delimiter   rune
+	replacement rune
+	skip        int
+
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	posAtt    PositionIncrementAttribute
+
+	startPosition int
+	skipped       int
+	endDelimiter  bool
+	resultToken   []rune
+
+	charsRead int
+}
+
+func NewPathHierarchyTokenizer(input io.RuneReader) *PathHierarchyTokenizer {
+	return NewPathHierarchyTokenizerWith(input, DEFAULT_DELIMITER, DEFAULT_DELIMITER, DEFAULT_SKIP)
+}
+
+func NewPathHierarchyTokenizerWith(input io.RuneReader, delimiter, replacement rune, skip int) *PathHierarchyTokenizer {
+	assert2(skip >= 0, "skip cannot be negative")
+	ans := &PathHierarchyTokenizer{
+		Tokenizer:   NewTokenizer(input),
+		delimiter:   delimiter,
+		replacement: replacement,
+		skip:        skip,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.posAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	return ans
+}
+
+func (t *PathHierarchyTokenizer) IncrementToken() (bool, error) {
+	t.Attributes().Clear()
+	term := append([]rune(nil), t.resultToken...)
+	if len(t.resultToken) == 0 {
+		t.posAtt.SetPositionIncrement(1)
+	} else {
+		t.posAtt.SetPositionIncrement(0)
+	}
+	added := false
+	if t.endDelimiter {
+		term = append(term, t.replacement)
+		t.endDelimiter = false
+		added = true
+	}
+
+	for {
+		c, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			if t.skipped > t.skip {
+				t.termAtt.CopyBuffer(term)
+				t.offsetAtt.SetOffset(t.CorrectOffset(t.startPosition), t.CorrectOffset(t.startPosition+len(term)))
+				if added {
+					t.resultToken = term
+				}
+				return added, nil
+			}
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		t.charsRead++
+
+		if !added {
+			added = true
+			t.skipped++
+			if t.skipped > t.skip {
+				if c == t.delimiter {
+					c = t.replacement
+				}
+				term = append(term, c)
+			} else {
+				t.startPosition++
+			}
+		} else if c == t.delimiter {
+			if t.skipped > t.skip {
+				t.endDelimiter = true
+				break
+			}
+			t.skipped++
+			if t.skipped > t.skip {
+				term = append(term, t.replacement)
+			} else {
+				t.startPosition++
+			}
+		} else {
+			if t.skipped > t.skip {
+				term = append(term, c)
+			} else {
+				t.startPosition++
+			}
+		}
+	}
+	t.termAtt.CopyBuffer(term)
+	t.offsetAtt.SetOffset(t.CorrectOffset(t.startPosition), t.CorrectOffset(t.startPosition+len(term)))
+	t.resultToken = term
+	return true, nil
+}
+
+func (t *PathHierarchyTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := t.CorrectOffset(t.charsRead)
+	t.offsetAtt.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (t *PathHierarchyTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.resultToken = nil
+	t.charsRead = 0
+	t.endDelimiter = false
+	t.skipped = 0
+	t.startPosition = 0
+	return nil
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,85 @@
+package path
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func assertTokenStreamContents(t *testing.T, ts TokenStream,
+	terms []string, starts, ends, posIncs []int, finalOffset int) {
+
+	termAtt := ts.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	offsetAtt := ts.Attributes().Get("OffsetAttribute").(OffsetAttribute)
+	posIncAtt := ts.Attributes().Get("PositionIncrementAttribute").(PositionIncrementAttribute)
+	if err := ts.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	var gotTerms []string
+	var gotStarts, gotEnds, gotPosIncs []int
+	for {
+		ok, err := ts.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		gotTerms = append(gotTerms, string(termAtt.Buffer()[:termAtt.Length()]))
+		gotStarts = append(gotStarts, offsetAtt.StartOffset())
+		gotEnds = append(gotEnds, offsetAtt.EndOffset())
+		gotPosIncs = append(gotPosIncs, posIncAtt.PositionIncrement())
+	}
+	if err := ts.End(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(terms, gotTerms) {
+		t.Errorf("expected terms %q, but got %q", terms, gotTerms)
+	}
+	if !reflect.DeepEqual(starts, gotStarts) || !reflect.DeepEqual(ends, gotEnds) {
+		t.Errorf("expected offsets %v-%v, but got %v-%v", starts, ends, gotStarts, gotEnds)
+	}
+	if !reflect.DeepEqual(posIncs, gotPosIncs) {
+		t.Errorf("expected position increments %v, but got %v", posIncs, gotPosIncs)
+	}
+	if offsetAtt.EndOffset() != finalOffset {
+		t.Errorf("expected final offset %v, but was %v", finalOffset, offsetAtt.EndOffset())
+	}
+}
+
+func TestPathHierarchyTokenizerBasic(t *testing.T) {
+	assertTokenStreamContents(t, NewPathHierarchyTokenizer(strings.NewReader("/a/b/c")),
+		[]string{"/a", "/a/b", "/a/b/c"},
+		[]int{0, 0, 0}, []int{2, 4, 6}, []int{1, 0, 0}, 6)
+	assertTokenStreamContents(t, NewPathHierarchyTokenizer(strings.NewReader("/a/b/c/")),
+		[]string{"/a", "/a/b", "/a/b/c", "/a/b/c/"},
+		[]int{0, 0, 0, 0}, []int{2, 4, 6, 7}, []int{1, 0, 0, 0}, 7)
+	assertTokenStreamContents(t, NewPathHierarchyTokenizer(strings.NewReader("a/b/c")),
+		[]string{"a", "a/b", "a/b/c"},
+		[]int{0, 0, 0}, []int{1, 3, 5}, []int{1, 0, 0}, 5)
+}
+
+func TestPathHierarchyTokenizerReplaceAndSkip(t *testing.T) {
+	assertTokenStreamContents(t, NewPathHierarchyTokenizerWith(strings.NewReader("/a/b/c"), '/', '\\', 0),
+		[]string{`\a`, `\a\b`, `\a\b\c`},
+		[]int{0, 0, 0}, []int{2, 4, 6}, []int{1, 0, 0}, 6)
+	assertTokenStreamContents(t, NewPathHierarchyTokenizerWith(strings.NewReader("/a/b/c"), '/', '/', 1),
+		[]string{"/b", "/b/c"},
+		[]int{2, 2}, []int{4, 6}, []int{1, 0}, 6)
+	assertTokenStreamContents(t, NewPathHierarchyTokenizerWith(strings.NewReader("/a"), '/', '/', 1),
+		nil, nil, nil, nil, 2)
+}
+
+func TestReversePathHierarchyTokenizer(t *testing.T) {
+	assertTokenStreamContents(t, NewReversePathHierarchyTokenizer(strings.NewReader("/a/b/c")),
+		[]string{"/a/b/c", "a/b/c", "b/c", "c"},
+		[]int{0, 1, 3, 5}, []int{6, 6, 6, 6}, []int{1, 0, 0, 0}, 6)
+	assertTokenStreamContents(t, NewReversePathHierarchyTokenizerWith(strings.NewReader("www.site.co.uk"), '.', '.', 0),
+		[]string{"www.site.co.uk", "site.co.uk", "co.uk", "uk"},
+		[]int{0, 4, 9, 12}, []int{14, 14, 14, 14}, []int{1, 0, 0, 0}, 14)
+	assertTokenStreamContents(t, NewReversePathHierarchyTokenizerWith(strings.NewReader("/a/b/c"), '/', '/', 1),
+		[]string{"/a/b/", "a/b/", "b/"},
+		[]int{0, 1, 3}, []int{5, 5, 5}, []int{1, 0, 0}, 6)
+}
@@ -0,0 +1,130 @@
+package path
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+)
+
+// path/ReversePathHierarchyTokenizer.java
+
+/*
+Tokenizer for domain-like hierarchies.
+
+Take something like:
+
+	www.site.co.uk
+
+and make:
+
+	www.site.co.uk
+	site.co.uk
+	co.uk
+	uk
+
+The skip parameter skips trailing, instead of leading, elements.
+*/
+type ReversePathHierarchyTokenizer struct {
+	*Tokenizer
+
+	delimiter   rune
+	replacement rune
+	skip        int
+
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	posAtt    PositionIncrementAttribute
+
+	endPosition        int
+	finalOffset        int
+	skipped            int
+	resultToken        []rune
+	delimiterPositions []int
+	delimitersCount    int
+}
+
+func NewReversePathHierarchyTokenizer(input io.RuneReader) *ReversePathHierarchyTokenizer {
+	return NewReversePathHierarchyTokenizerWith(input, DEFAULT_DELIMITER, DEFAULT_DELIMITER, DEFAULT_SKIP)
+}
+
+func NewReversePathHierarchyTokenizerWith(input io.RuneReader, delimiter, replacement rune, skip int) *ReversePathHierarchyTokenizer {
+	assert2(skip >= 0, "skip cannot be negative")
+	ans := &ReversePathHierarchyTokenizer{
+		Tokenizer:       NewTokenizer(input),
+		delimiter:       delimiter,
+		replacement:     replacement,
+		skip:            skip,
+		delimitersCount: -1,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.posAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	return ans
+}
+
+func (t *ReversePathHierarchyTokenizer) IncrementToken() (bool, error) {
+	t.Attributes().Clear()
+	if t.delimitersCount == -1 {
+		length := 0
+		t.delimiterPositions = append(t.delimiterPositions, 0)
+		for {
+			c, _, err := t.Input.ReadRune()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return false, err
+			}
+			length++
+			if c == t.delimiter {
+				t.delimiterPositions = append(t.delimiterPositions, length)
+				t.resultToken = append(t.resultToken, t.replacement)
+			} else {
+				t.resultToken = append(t.resultToken, c)
+			}
+		}
+		t.delimitersCount = len(t.delimiterPositions)
+		if t.delimiterPositions[t.delimitersCount-1] < length {
+			t.delimiterPositions = append(t.delimiterPositions, length)
+			t.delimitersCount++
+		}
+		if idx := t.delimitersCount - 1 - t.skip; idx >= 0 {
+			// otherwise its ok, because we will skip and return false
+			t.endPosition = t.delimiterPositions[idx]
+		}
+		t.finalOffset = t.CorrectOffset(length)
+		t.posAtt.SetPositionIncrement(1)
+	} else {
+		t.posAtt.SetPositionIncrement(0)
+	}
+
+	if t.skipped < t.delimitersCount-t.skip-1 {
+		start := t.delimiterPositions[t.skipped]
+		t.termAtt.CopyBuffer(t.resultToken[start:t.endPosition])
+		t.offsetAtt.SetOffset(t.CorrectOffset(start), t.CorrectOffset(t.endPosition))
+		t.skipped++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (t *ReversePathHierarchyTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	// set final offset
+	t.offsetAtt.SetOffset(t.finalOffset, t.finalOffset)
+	return nil
+}
+
+func (t *ReversePathHierarchyTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.resultToken = nil
+	t.finalOffset = 0
+	t.endPosition = 0
+	t.skipped = 0
+	t.delimitersCount = -1
+	t.delimiterPositions = nil
+	return nil
+}
@@ -0,0 +1,163 @@
+package pl
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/miscellaneous"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/analysis/stempel"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// pl/PolishAnalyzer.java
+
+/* The default set of Polish stop words. */
+var POLISH_STOP_WORDS_SET = map[string]bool{
+	"a": true, "aby": true, "ach": true, "acz": true,
+	"aczkolwiek": true, "aj": true, "albo": true, "ale": true,
+	"ależ": true, "ani": true, "aż": true, "bardziej": true,
+	"bardzo": true, "bo": true, "bowiem": true, "by": true,
+	"byli": true, "bynajmniej": true, "być": true, "był": true,
+	"była": true, "było": true, "były": true, "będzie": true,
+	"będą": true, "cali": true, "cała": true, "cały": true, "ci": true,
+	"cię": true, "ciebie": true, "co": true, "cokolwiek": true,
+	"coś": true, "czasami": true, "czasem": true, "czemu": true,
+	"czy": true, "czyli": true, "daleko": true, "dla": true,
+	"dlaczego": true, "dlatego": true, "do": true, "dobrze": true,
+	"dokąd": true, "dość": true, "dużo": true, "dwa": true,
+	"dwaj": true, "dwie": true, "dwoje": true, "dziś": true,
+	"dzisiaj": true, "gdy": true, "gdyby": true, "gdyż": true,
+	"gdzie": true, "gdziekolwiek": true, "gdzieś": true, "i": true,
+	"ich": true, "ile": true, "im": true, "inna": true, "inne": true,
+	"inny": true, "innych": true, "iż": true, "ja": true, "jak": true,
+	"jakaś": true, "jakby": true, "jaki": true, "jakichś": true,
+	"jakie": true, "jakiś": true, "jakiż": true, "jakkolwiek": true,
+	"jako": true, "jakoś": true, "je": true, "jeden": true,
+	"jedna": true, "jedno": true, "jednak": true, "jednakże": true,
+	"jego": true, "jej": true, "jemu": true, "jest": true,
+	"jestem": true, "jeszcze": true, "jeśli": true, "jeżeli": true,
+	"już": true, "ją": true, "każdy": true, "kiedy": true,
+	"kilka": true, "kimś": true, "kto": true, "ktokolwiek": true,
+	"ktoś": true, "która": true, "które": true, "którego": true,
+	"której": true, "który": true, "których": true, "którym": true,
+	"którzy": true, "ku": true, "lat": true, "lecz": true, "lub": true,
+	"ma": true, "mają": true, "mało": true, "mam": true, "mi": true,
+	"mimo": true, "między": true, "mną": true, "mnie": true,
+	"mogą": true, "moi": true, "moim": true, "moja": true, "moje": true,
+	"może": true, "możliwe": true, "można": true, "mój": true,
+	"mu": true, "musi": true, "my": true, "na": true, "nad": true,
+	"nam": true, "nami": true, "nas": true, "nasi": true, "nasz": true,
+	"nasza": true, "nasze": true, "naszego": true, "naszych": true,
+	"natomiast": true, "natychmiast": true, "nawet": true, "nią": true,
+	"nic": true, "nich": true, "nie": true, "niech": true,
+	"niego": true, "niej": true, "niemu": true, "nigdy": true,
+	"nim": true, "nimi": true, "niż": true, "no": true, "o": true,
+	"obok": true, "od": true, "około": true, "on": true, "ona": true,
+	"one": true, "oni": true, "ono": true, "oraz": true, "oto": true,
+	"owszem": true, "pan": true, "pana": true, "pani": true, "po": true,
+	"pod": true, "podczas": true, "pomimo": true, "ponad": true,
+	"ponieważ": true, "powinien": true, "powinna": true,
+	"powinni": true, "powinno": true, "poza": true, "prawie": true,
+	"przecież": true, "przed": true, "przede": true, "przedtem": true,
+	"przez": true, "przy": true, "roku": true, "również": true,
+	"sam": true, "sama": true, "są": true, "się": true, "skąd": true,
+	"sobie": true, "sobą": true, "sposób": true, "swoje": true,
+	"ta": true, "tak": true, "taka": true, "taki": true, "takie": true,
+	"także": true, "tam": true, "te": true, "tego": true, "tej": true,
+	"temu": true, "ten": true, "teraz": true, "też": true, "to": true,
+	"tobą": true, "tobie": true, "toteż": true, "trzeba": true,
+	"tu": true, "tutaj": true, "twoi": true, "twoim": true,
+	"twoja": true, "twoje": true, "twym": true, "twój": true,
+	"ty": true, "tych": true, "tylko": true, "tym": true, "u": true,
+	"w": true, "wam": true, "wami": true, "was": true, "wasz": true,
+	"wasza": true, "wasze": true, "we": true, "według": true,
+	"wiele": true, "wielu": true, "więc": true, "więcej": true,
+	"wszyscy": true, "wszystkich": true, "wszystkie": true,
+	"wszystkim": true, "wszystko": true, "wtedy": true, "wy": true,
+	"właśnie": true, "z": true, "za": true, "zapewne": true,
+	"zawsze": true, "ze": true, "zł": true, "znowu": true, "znów": true,
+	"został": true, "żaden": true, "żadna": true, "żadne": true,
+	"żadnych": true, "że": true, "żeby": true,
+}
+
+/*
+Analyzer for Polish.
+
+Filters StandardTokenizer with StandardFilter, LowerCaseFilter,
+StopFilter and StempelFilter. The stemmer table is not bundled; load
+Lucene's stemmer_20000.tbl (or a table compiled with
+stempel.CompileStemmerTable()) with stempel.LoadStemmerTable(), or
+use LoadPolishAnalyzer().
+*/
+type PolishAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet      map[string]bool
+	stemExclusionSet map[string]bool
+	stemTable        stempel.StemmerTable
+}
+
+/*
+Builds an analyzer with the default stop words, and the stemmer table
+read from r by stempel.LoadStemmerTable().
+*/
+func LoadPolishAnalyzer(r io.Reader) (*PolishAnalyzer, error) {
+	table, err := stempel.LoadStemmerTable(r)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("cannot load the Polish stemmer table: %v", err))
+	}
+	return NewPolishAnalyzer(table), nil
+}
+
+/* Builds an analyzer with the default stop words (POLISH_STOP_WORDS_SET). */
+func NewPolishAnalyzer(stemTable stempel.StemmerTable) *PolishAnalyzer {
+	return NewPolishAnalyzerWithStopWords(stemTable, POLISH_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewPolishAnalyzerWithStopWords(stemTable stempel.StemmerTable, stopWords map[string]bool) *PolishAnalyzer {
+	return NewPolishAnalyzerWithStemExclusion(stemTable, stopWords, nil)
+}
+
+/*
+Builds an analyzer with the given stop words. If a non-empty stem
+exclusion set is provided this analyzer will add a
+SetKeywordMarkerFilter before stemming.
+*/
+func NewPolishAnalyzerWithStemExclusion(stemTable stempel.StemmerTable,
+	stopWords, stemExclusionSet map[string]bool) *PolishAnalyzer {
+
+	assert2(stemTable != nil, "a stemmer table is required, see stempel.LoadStemmerTable()")
+	ans := &PolishAnalyzer{
+		stopWordSet:      stopWords,
+		stemExclusionSet: make(map[string]bool),
+		stemTable:        stemTable,
+	}
+	for k, v := range stemExclusionSet {
+		ans.stemExclusionSet[k] = v
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *PolishAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := standard.NewStandardTokenizer(version, reader)
+	var tok TokenStream = standard.NewStandardFilter(version, src)
+	tok = NewLowerCaseFilter(version, tok)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	if len(a.stemExclusionSet) > 0 {
+		tok = NewSetKeywordMarkerFilter(tok, a.stemExclusionSet)
+	}
+	tok = stempel.NewStempelFilter(tok, stempel.NewStempelStemmer(a.stemTable))
+	return NewTokenStreamComponents(src, tok)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
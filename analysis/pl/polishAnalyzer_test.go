@@ -0,0 +1,52 @@
+package pl
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/analysis/stempel"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+/* The stemmer table is not bundled, so the tests compile a small one. */
+const trainingData = `kot kota kotu kotem kocie koty kotów kotom kotami kotach
+książka książki książce książkę książką książek książkom
+czytać czytam czytasz czyta czytamy czytacie czytają
+`
+
+func newTestTable(t *testing.T) stempel.StemmerTable {
+	table, err := stempel.CompileStemmerTable(strings.NewReader(trainingData), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestPolishAnalyzer(t *testing.T) {
+	a := NewPolishAnalyzer(newTestTable(t))
+	analysis.AssertAnalyzesTo(t, a, "Koty czytają książki", "kot", "czytać", "książka")
+	// stop words are removed
+	analysis.AssertAnalyzesTo(t, a, "Kot i książka, ale nie koty", "kot", "książka", "kot")
+}
+
+func TestPolishStemExclusion(t *testing.T) {
+	a := NewPolishAnalyzerWithStemExclusion(newTestTable(t),
+		POLISH_STOP_WORDS_SET, map[string]bool{"koty": true})
+	analysis.AssertAnalyzesTo(t, a, "koty kotów", "koty", "kot")
+}
+
+func TestLoadPolishAnalyzer(t *testing.T) {
+	var buf bytes.Buffer
+	if err := stempel.StoreStemmerTable(&buf, newTestTable(t)); err != nil {
+		t.Fatal(err)
+	}
+	a, err := LoadPolishAnalyzer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysis.AssertAnalyzesTo(t, a, "kotami książkom", "kot", "książka")
+
+	if _, err = LoadPolishAnalyzer(strings.NewReader("")); err == nil {
+		t.Error("Expected an error for a missing stemmer table")
+	}
+}
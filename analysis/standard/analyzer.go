@@ -41,6 +41,12 @@ func NewStandardAnalyzerWithStopWords(stopWords map[string]bool) *StandardAnalyz
 	return ans
 }
 
+func init() {
+	RegisterAnalyzer("standard", func() Analyzer {
+		return NewStandardAnalyzer()
+	})
+}
+
 /* Buils an analyzer with the default stop words (STOP_WORDS_SET). */
 func NewStandardAnalyzer() *StandardAnalyzer {
 	return NewStandardAnalyzerWithStopWords(STOP_WORDS_SET)
@@ -48,9 +54,9 @@ func NewStandardAnalyzer() *StandardAnalyzer {
 
 func (a *StandardAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
 	version := a.Version()
-	src := newStandardTokenizer(version, reader)
+	src := NewStandardTokenizer(version, reader)
 	src.maxTokenLength = a.maxTokenLength
-	var tok TokenStream = newStandardFilter(version, src)
+	var tok TokenStream = NewStandardFilter(version, src)
 	tok = NewLowerCaseFilter(version, tok)
 	tok = NewStopFilter(version, tok, a.stopWordSet)
 	ans := NewTokenStreamComponents(src, tok)
@@ -1,6 +1,7 @@
 package standard
 
 import (
+	"fmt"
 	. "github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/util"
 )
@@ -14,7 +15,7 @@ type StandardFilter struct {
 	input        TokenStream
 }
 
-func newStandardFilter(matchVersion util.Version, in TokenStream) *StandardFilter {
+func NewStandardFilter(matchVersion util.Version, in TokenStream) *StandardFilter {
 	return &StandardFilter{
 		TokenFilter:  NewTokenFilter(in),
 		matchVersion: matchVersion,
@@ -32,3 +33,9 @@ func assert(ok bool) {
 		panic("assert fail")
 	}
 }
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -83,7 +83,7 @@ type StandardTokenizer struct {
 Creates a new instance of the StandardTokenizer. Attaches the input
 to the newly created JFlex scanner.
 */
-func newStandardTokenizer(matchVersion util.Version, input io.RuneReader) *StandardTokenizer {
+func NewStandardTokenizer(matchVersion util.Version, input io.RuneReader) *StandardTokenizer {
 	ans := &StandardTokenizer{
 		Tokenizer:      NewTokenizer(input),
 		maxTokenLength: DEFAULT_MAX_TOKEN_LENGTH,
@@ -101,6 +101,16 @@ func (t *StandardTokenizer) init(matchVersion util.Version) {
 	t.scanner = newStandardTokenizerImpl(nil)
 }
 
+/* Set the max allowed token length. Any token longer than this is skipped. */
+func (t *StandardTokenizer) SetMaxTokenLength(length int) {
+	assert2(length > 0, "maxTokenLength must be greater than zero")
+	t.maxTokenLength = length
+}
+
+func (t *StandardTokenizer) MaxTokenLength() int {
+	return t.maxTokenLength
+}
+
 func (t *StandardTokenizer) IncrementToken() (bool, error) {
 	t.Attributes().Clear()
 	t.skippedPositions = 0
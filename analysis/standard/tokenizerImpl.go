@@ -310,7 +310,6 @@ func zzUnpackAttribute(packed []int) []int {
 			j++
 			count--
 		}
-		i += 2
 	}
 	return m
 }
@@ -424,6 +423,16 @@ func (t *StandardTokenizerImpl) zzRefill() (bool, error) {
 		t.zzStartRead = 0
 	}
 
+	// is the buffer big enough?
+	if t.zzCurrentPos >= len(t.zzBuffer)-t.zzFinalHighSurrogate {
+		// if not: blow it up
+		newBuffer := make([]rune, len(t.zzBuffer)*2)
+		copy(newBuffer, t.zzBuffer)
+		t.zzBuffer = newBuffer
+		t.zzEndRead += t.zzFinalHighSurrogate
+		t.zzFinalHighSurrogate = 0
+	}
+
 	// fill the buffer with new input
 	var requested = len(t.zzBuffer) - t.zzEndRead - t.zzFinalHighSurrogate
 	var totalRead = 0
@@ -439,9 +448,8 @@ func (t *StandardTokenizerImpl) zzRefill() (bool, error) {
 
 	if totalRead > 0 {
 		t.zzEndRead += totalRead
-		if totalRead == requested { // possibly more input available
-			panic("niy")
-		}
+		// potentially more input available; runes are never split, so
+		// there is no trailing high surrogate to hold back
 		return false, nil
 	}
 
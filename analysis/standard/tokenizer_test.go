@@ -0,0 +1,98 @@
+package standard
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"strings"
+	"testing"
+)
+
+type standardToken struct {
+	term, typ  string
+	start, end int
+	posInc     int
+}
+
+func standardTokens(t *testing.T, input string) []standardToken {
+	tokenizer := NewStandardTokenizer(util.VERSION_LATEST, strings.NewReader(input))
+	termAtt := tokenizer.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	typeAtt := tokenizer.Attributes().Get("TypeAttribute").(TypeAttribute)
+	offsetAtt := tokenizer.Attributes().Get("OffsetAttribute").(OffsetAttribute)
+	posIncAtt := tokenizer.Attributes().Get("PositionIncrementAttribute").(PositionIncrementAttribute)
+	if err := tokenizer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []standardToken
+	for {
+		ok, err := tokenizer.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		tokens = append(tokens, standardToken{
+			string(termAtt.Buffer()[:termAtt.Length()]), typeAtt.Type(),
+			offsetAtt.StartOffset(), offsetAtt.EndOffset(),
+			posIncAtt.PositionIncrement(),
+		})
+	}
+	if err := tokenizer.End(); err != nil {
+		t.Fatal(err)
+	}
+	if end := len([]rune(input)); offsetAtt.EndOffset() != end {
+		t.Errorf("expected final offset %v, but was %v", end, offsetAtt.EndOffset())
+	}
+	return tokens
+}
+
+func assertStandardTokens(t *testing.T, input string, expected ...standardToken) {
+	got := standardTokens(t, input)
+	if len(got) != len(expected) {
+		t.Fatalf("%q: expected %v, but got %v", input, expected, got)
+	}
+	for i, v := range expected {
+		if got[i] != v {
+			t.Errorf("%q: expected token %v, but got %v", input, v, got[i])
+		}
+	}
+}
+
+func TestStandardTokenizer(t *testing.T) {
+	assertStandardTokens(t, "The quick-brown fox's 2 jumps, 3.5 km",
+		standardToken{"The", "<ALPHANUM>", 0, 3, 1},
+		standardToken{"quick", "<ALPHANUM>", 4, 9, 1},
+		standardToken{"brown", "<ALPHANUM>", 10, 15, 1},
+		standardToken{"fox's", "<ALPHANUM>", 16, 21, 1},
+		standardToken{"2", "<NUM>", 22, 23, 1},
+		standardToken{"jumps", "<ALPHANUM>", 24, 29, 1},
+		standardToken{"3.5", "<NUM>", 31, 34, 1},
+		standardToken{"km", "<ALPHANUM>", 35, 37, 1})
+}
+
+func TestStandardTokenizerLongInput(t *testing.T) {
+	// spans several refills of the scanner buffer
+	var words []string
+	for i := 0; i < 200; i++ {
+		words = append(words, fmt.Sprintf("w%v", i))
+	}
+	input := strings.Join(words, " ")
+	tokens := standardTokens(t, input)
+	if len(tokens) != len(words) {
+		t.Fatalf("expected %v tokens, but got %v", len(words), len(tokens))
+	}
+	for i, v := range tokens {
+		if v.term != words[i] || string([]rune(input)[v.start:v.end]) != words[i] {
+			t.Errorf("expected token %v, but got %v", words[i], v)
+		}
+	}
+}
+
+func TestStandardTokenizerTooLongToken(t *testing.T) {
+	// longer than the scanner buffer, and than the max token length
+	long := strings.Repeat("x", 2*DEFAULT_MAX_TOKEN_LENGTH)
+	assertStandardTokens(t, "a "+long+" b",
+		standardToken{"a", "<ALPHANUM>", 0, 1, 1},
+		standardToken{"b", "<ALPHANUM>", 3 + len(long), 4 + len(long), 2})
+}
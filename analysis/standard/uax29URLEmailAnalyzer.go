@@ -0,0 +1,71 @@
+package standard
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// standard/UAX29URLEmailAnalyzer.java
+
+/*
+Filters UAX29URLEmailTokenizer with StandardFilter, LowerCaseFilter
+and StopFilter, using a list of English stop words.
+*/
+type UAX29URLEmailAnalyzer struct {
+	*StopwordAnalyzerBase
+	stopWordSet    map[string]bool
+	maxTokenLength int
+}
+
+/* Builds an analyzer with the given stop words. */
+func NewUAX29URLEmailAnalyzerWithStopWords(stopWords map[string]bool) *UAX29URLEmailAnalyzer {
+	ans := &UAX29URLEmailAnalyzer{
+		stopWordSet:    stopWords,
+		maxTokenLength: DEFAULT_MAX_TOKEN_LENGTH,
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func init() {
+	RegisterAnalyzer("uax29urlemail", func() Analyzer {
+		return NewUAX29URLEmailAnalyzer()
+	})
+}
+
+/* Builds an analyzer with the default stop words (STOP_WORDS_SET). */
+func NewUAX29URLEmailAnalyzer() *UAX29URLEmailAnalyzer {
+	return NewUAX29URLEmailAnalyzerWithStopWords(STOP_WORDS_SET)
+}
+
+/*
+Set maximum allowed token length. If a token is seen that exceeds
+this length then it is discarded. This setting only takes effect the
+next time tokenStream() is called.
+*/
+func (a *UAX29URLEmailAnalyzer) SetMaxTokenLength(length int) {
+	a.maxTokenLength = length
+}
+
+func (a *UAX29URLEmailAnalyzer) MaxTokenLength() int {
+	return a.maxTokenLength
+}
+
+func (a *UAX29URLEmailAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := NewUAX29URLEmailTokenizer(version, reader)
+	src.maxTokenLength = a.maxTokenLength
+	var tok TokenStream = NewStandardFilter(version, src)
+	tok = NewLowerCaseFilter(version, tok)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	ans := NewTokenStreamComponents(src, tok)
+	super := ans.SetReader
+	ans.SetReader = func(reader io.RuneReader) error {
+		src.maxTokenLength = a.maxTokenLength
+		return super(reader)
+	}
+	return ans
+}
@@ -0,0 +1,150 @@
+package standard
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"io"
+)
+
+// standard/UAX29URLEmailTokenizer.java
+
+const (
+	UAX29_ALPHANUM        = 0
+	UAX29_NUM             = 1
+	UAX29_SOUTHEAST_ASIAN = 2
+	UAX29_IDEOGRAPHIC     = 3
+	UAX29_HIRAGANA        = 4
+	UAX29_KATAKANA        = 5
+	UAX29_HANGUL          = 6
+	UAX29_URL             = 7
+	UAX29_EMAIL           = 8
+)
+
+/* String token types that correspond to token type int constants */
+var UAX29_URL_EMAIL_TOKEN_TYPES = []string{
+	TOKEN_TYPES[ALPHANUM],
+	TOKEN_TYPES[NUM],
+	TOKEN_TYPES[SOUTHEAST_ASIAN],
+	TOKEN_TYPES[IDEOGRAPHIC],
+	TOKEN_TYPES[HIRAGANA],
+	TOKEN_TYPES[KATAKANA],
+	TOKEN_TYPES[HANGUL],
+	"<URL>",
+	"<EMAIL>",
+}
+
+/*
+This class implements Word Break rules from the Unicode Text
+Segmentation algorithm, as specified in Unicode Standard Annex #29
+URLs and email addresses are also tokenized according to the relevant
+RFCs.
+
+Tokens produced are of the following types:
+
+  - <ALPHANUM>: A sequence of alphabetic and numeric characters
+  - <NUM>: A number
+  - <URL>: A URL
+  - <EMAIL>: An email address
+  - <SOUTHEAST_ASIAN>: A sequence of characters from South and Southeast Asian languages, including Thai, Lao, Myanmar, and Khmer
+  - <IDEOGRAPHIC>: A single CJKV ideographic character
+  - <HIRAGANA>: A single hiragana character
+  - <KATAKANA>: A sequence of katakana characters
+  - <HANGUL>: A sequence of Hangul characters
+
+StandardTokenizer would split URLs and email addresses at their
+punctuation; this tokenizer keeps each of them as a single token.
+*/
+type UAX29URLEmailTokenizer struct {
+	*Tokenizer
+
+	// A private instance of the scanner
+	scanner StandardTokenizerInterface
+
+	skippedPositions int
+	maxTokenLength   int
+
+	// this tokenizer generates three attributes:
+	// term offset, positionIncrement and type
+
+	termAtt    CharTermAttribute
+	offsetAtt  OffsetAttribute
+	posIncrAtt PositionIncrementAttribute
+	typeAtt    TypeAttribute
+}
+
+/* Creates a new instance of the UAX29URLEmailTokenizer. */
+func NewUAX29URLEmailTokenizer(matchVersion util.Version, input io.RuneReader) *UAX29URLEmailTokenizer {
+	ans := &UAX29URLEmailTokenizer{
+		Tokenizer:      NewTokenizer(input),
+		scanner:        newUAX29URLEmailTokenizerImpl(nil),
+		maxTokenLength: DEFAULT_MAX_TOKEN_LENGTH,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.posIncrAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	return ans
+}
+
+/* Set the max allowed token length. Any token longer than this is skipped. */
+func (t *UAX29URLEmailTokenizer) SetMaxTokenLength(length int) {
+	assert2(length > 0, "maxTokenLength must be greater than zero")
+	t.maxTokenLength = length
+}
+
+func (t *UAX29URLEmailTokenizer) MaxTokenLength() int {
+	return t.maxTokenLength
+}
+
+func (t *UAX29URLEmailTokenizer) IncrementToken() (bool, error) {
+	t.Attributes().Clear()
+	t.skippedPositions = 0
+
+	for {
+		tokenType, err := t.scanner.nextToken()
+		if tokenType == YYEOF || err != nil {
+			return false, err
+		}
+
+		if t.scanner.yylength() <= t.maxTokenLength {
+			t.posIncrAtt.SetPositionIncrement(t.skippedPositions + 1)
+			t.scanner.text(t.termAtt)
+			start := t.scanner.yychar()
+			t.offsetAtt.SetOffset(t.CorrectOffset(start), t.CorrectOffset(start+t.termAtt.Length()))
+			t.typeAtt.SetType(UAX29_URL_EMAIL_TOKEN_TYPES[tokenType])
+			return true, nil
+		}
+		// When we skip a too-long term, we still increment the positionincrement
+		t.skippedPositions++
+	}
+}
+
+func (t *UAX29URLEmailTokenizer) End() error {
+	err := t.Tokenizer.End()
+	if err == nil {
+		// set final offset
+		finalOffset := t.CorrectOffset(t.scanner.yychar() + t.scanner.yylength())
+		t.offsetAtt.SetOffset(finalOffset, finalOffset)
+		// adjust any skipped tokens
+		t.posIncrAtt.SetPositionIncrement(t.posIncrAtt.PositionIncrement() + t.skippedPositions)
+	}
+	return err
+}
+
+func (t *UAX29URLEmailTokenizer) Close() error {
+	if err := t.Tokenizer.Close(); err != nil {
+		return err
+	}
+	t.scanner.yyreset(t.Input)
+	return nil
+}
+
+func (t *UAX29URLEmailTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	t.scanner.yyreset(t.Input)
+	t.skippedPositions = 0
+	return nil
+}
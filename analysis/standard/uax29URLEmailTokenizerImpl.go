@@ -0,0 +1,388 @@
+package standard
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// standard/UAX29URLEmailTokenizerImpl.java
+
+/*
+Lucene generates this scanner with JFlex, from a grammar which extends
+StandardTokenizerImpl's with rules for URLs and email addresses. As
+there is no GoFlex yet, URLs and email addresses are recognized here
+by regular expressions instead, and all text between them is handed to
+a StandardTokenizerImpl, whose token types are translated.
+
+Recognized are:
+
+  - email addresses, e.g. "john.doe@example.com";
+  - URLs with a scheme, e.g. "https://example.com:8080/a?b=c#d";
+  - URLs without a scheme, whose host ends with a known top-level
+    domain, e.g. "www.example.com/index.html" or "example.org".
+
+Trailing punctuation, e.g. the full stop ending a sentence, and
+unbalanced closing brackets are not considered part of a URL.
+*/
+type uax29URLEmailTokenizerImpl struct {
+	// the input device
+	zzReader io.RuneReader
+
+	// the whole input, read at the first call to nextToken()
+	buffer []rune
+	loaded bool
+	// the input as string, with the rune offset of each of its bytes,
+	// and the byte offset of each of its runes
+	str         string
+	runeOffsets []int
+	byteOffsets []int
+
+	// the scanner of the text between URLs and email addresses, and
+	// the range of the input it was given
+	std              *StandardTokenizerImpl
+	inSegment        bool
+	segStart, segEnd int
+
+	// the next URL or email address found ahead, if any
+	hasPending               bool
+	pendingStart, pendingEnd int
+	pendingType              int
+	// true if there is no URL or email address after the current position
+	noMoreMatches bool
+
+	// the current position in the input
+	pos int
+	// the range of the current token
+	_yychar, _yylength int
+}
+
+func newUAX29URLEmailTokenizerImpl(in io.RuneReader) *uax29URLEmailTokenizerImpl {
+	return &uax29URLEmailTokenizerImpl{
+		zzReader: in,
+		std:      newStandardTokenizerImpl(nil),
+	}
+}
+
+func (t *uax29URLEmailTokenizerImpl) yychar() int {
+	return t._yychar
+}
+
+func (t *uax29URLEmailTokenizerImpl) yylength() int {
+	return t._yylength
+}
+
+/* Fills CharTermAttribute with the current token text. */
+func (t *uax29URLEmailTokenizerImpl) text(tt CharTermAttribute) {
+	tt.CopyBuffer(t.buffer[t._yychar : t._yychar+t._yylength])
+}
+
+func (t *uax29URLEmailTokenizerImpl) yyreset(reader io.RuneReader) {
+	t.zzReader = reader
+	t.buffer, t.loaded = nil, false
+	t.str, t.runeOffsets, t.byteOffsets = "", nil, nil
+	t.inSegment = false
+	t.segStart, t.segEnd = 0, 0
+	t.hasPending, t.noMoreMatches = false, false
+	t.pos, t._yychar, t._yylength = 0, 0, 0
+}
+
+/* Reads the whole input. */
+func (t *uax29URLEmailTokenizerImpl) load() error {
+	t.loaded = true
+	if t.zzReader == nil {
+		return nil
+	}
+	for {
+		ch, _, err := t.zzReader.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		t.buffer = append(t.buffer, ch)
+	}
+	t.str = string(t.buffer)
+	t.runeOffsets = make([]int, len(t.str)+1)
+	t.byteOffsets = make([]int, 0, len(t.buffer)+1)
+	for offset := range t.str {
+		t.runeOffsets[offset] = len(t.byteOffsets)
+		t.byteOffsets = append(t.byteOffsets, offset)
+	}
+	t.runeOffsets[len(t.str)] = len(t.buffer)
+	t.byteOffsets = append(t.byteOffsets, len(t.str))
+	return nil
+}
+
+func (t *uax29URLEmailTokenizerImpl) nextToken() (int, error) {
+	if !t.loaded {
+		if err := t.load(); err != nil {
+			return 0, err
+		}
+	}
+
+	for {
+		if t.inSegment {
+			tokenType, err := t.std.nextToken()
+			if err != nil {
+				return 0, err
+			}
+			if tokenType != YYEOF {
+				t._yychar = t.segStart + t.std.yychar()
+				t._yylength = t.std.yylength()
+				return standardToUAX29Type(tokenType), nil
+			}
+			t.inSegment = false
+			t.pos = t.segEnd
+		}
+
+		if !t.hasPending && !t.noMoreMatches {
+			t.findNextMatch(t.pos)
+		}
+		if t.hasPending && t.pendingStart == t.pos {
+			t.hasPending = false
+			t._yychar = t.pendingStart
+			t._yylength = t.pendingEnd - t.pendingStart
+			t.pos = t.pendingEnd
+			return t.pendingType, nil
+		}
+
+		end := len(t.buffer)
+		if t.hasPending {
+			end = t.pendingStart
+		}
+		if t.pos >= end {
+			// nothing left; leave yychar() + yylength() at the end of input
+			t._yychar, t._yylength = len(t.buffer), 0
+			return YYEOF, nil
+		}
+		t.segStart, t.segEnd = t.pos, end
+		t.std.yyreset(strings.NewReader(string(t.buffer[t.segStart:t.segEnd])))
+		t.inSegment = true
+	}
+}
+
+func standardToUAX29Type(tokenType int) int {
+	switch tokenType {
+	case NUM:
+		return UAX29_NUM
+	case SOUTHEAST_ASIAN:
+		return UAX29_SOUTHEAST_ASIAN
+	case IDEOGRAPHIC:
+		return UAX29_IDEOGRAPHIC
+	case HIRAGANA:
+		return UAX29_HIRAGANA
+	case KATAKANA:
+		return UAX29_KATAKANA
+	case HANGUL:
+		return UAX29_HANGUL
+	default:
+		return UAX29_ALPHANUM
+	}
+}
+
+const (
+	domainLabel = `[\p{L}\p{N}](?:[\p{L}\p{N}-]*[\p{L}\p{N}])?`
+	urlChars    = `[\p{L}\p{N}\-._~!$&'()*+,;=:@%/?#\[\]]`
+	port        = `(?::[0-9]{1,5})?`
+)
+
+var (
+	emailPattern = regexp.MustCompile(
+		`[\p{L}\p{N}!#$%&'*+/=?^_` + "`" + `{|}~-]+(?:\.[\p{L}\p{N}!#$%&'*+/=?^_` + "`" + `{|}~-]+)*` +
+			`@(?:(?:` + domainLabel + `\.)+(` + domainLabel + `)|\[[0-9]{1,3}(?:\.[0-9]{1,3}){3}\])`)
+	schemeURLPattern = regexp.MustCompile(
+		`(?i)(?:https?|ftps?|file|sftp|ssh|git|svn|ldaps?|telnet|news|nntp|rtsp|mms)://` + urlChars + `+`)
+	hostURLPattern = regexp.MustCompile(
+		`(?:` + domainLabel + `\.)+(` + domainLabel + `)` + port + `(?:/` + urlChars + `*)?`)
+)
+
+/*
+Looks for the first URL or email address starting at or after the
+given rune offset, and records it as pending.
+*/
+func (t *uax29URLEmailTokenizerImpl) findNextMatch(from int) {
+	t.hasPending = false
+	best := -1
+	var bestEnd, bestType int
+	fromByte := t.byteOffsets[from]
+
+	// email addresses win over URLs starting at the same offset
+	if start, end, ok := t.find(emailPattern, fromByte, t.acceptEmail); ok {
+		best, bestEnd, bestType = start, end, UAX29_EMAIL
+	}
+	if start, end, ok := t.find(schemeURLPattern, fromByte, t.acceptSchemeURL); ok && (best < 0 || start < best) {
+		best, bestEnd, bestType = start, end, UAX29_URL
+	}
+	if start, end, ok := t.find(hostURLPattern, fromByte, t.acceptHostURL); ok && (best < 0 || start < best) {
+		best, bestEnd, bestType = start, end, UAX29_URL
+	}
+
+	if best < 0 {
+		t.noMoreMatches = true
+		return
+	}
+	t.hasPending = true
+	t.pendingStart, t.pendingEnd = t.runeOffsets[best], t.runeOffsets[bestEnd]
+	t.pendingType = bestType
+}
+
+/*
+Returns the byte range of the first match of the pattern at or after
+the given byte offset that is accepted, as [start, end).
+*/
+func (t *uax29URLEmailTokenizerImpl) find(pattern *regexp.Regexp,
+	from int, accept func(m []int) (int, bool)) (int, int, bool) {
+
+	for from < len(t.str) {
+		m := pattern.FindStringSubmatchIndex(t.str[from:])
+		if m == nil {
+			return 0, 0, false
+		}
+		for i, v := range m {
+			if v >= 0 {
+				m[i] = v + from
+			}
+		}
+		if end, ok := accept(m); ok {
+			return m[0], end, true
+		}
+		// retry after the first rune of the rejected match
+		_, size := utf8.DecodeRuneInString(t.str[m[0]:])
+		from = m[0] + size
+	}
+	return 0, 0, false
+}
+
+func (t *uax29URLEmailTokenizerImpl) acceptEmail(m []int) (int, bool) {
+	if !t.boundaryBefore(m[0]) || !t.boundaryAfter(m[1]) {
+		return 0, false
+	}
+	if m[2] >= 0 && !isTLD(t.str[m[2]:m[3]]) {
+		return 0, false
+	}
+	return m[1], true
+}
+
+func (t *uax29URLEmailTokenizerImpl) acceptSchemeURL(m []int) (int, bool) {
+	if !t.boundaryBefore(m[0]) {
+		return 0, false
+	}
+	end := t.trimURL(m[0], m[1])
+	if strings.HasSuffix(t.str[m[0]:end], "://") {
+		return 0, false
+	}
+	return end, true
+}
+
+func (t *uax29URLEmailTokenizerImpl) acceptHostURL(m []int) (int, bool) {
+	if !t.boundaryBefore(m[0]) || !isTLD(t.str[m[2]:m[3]]) {
+		return 0, false
+	}
+	end := t.trimURL(m[0], m[1])
+	if !t.boundaryAfter(end) {
+		return 0, false
+	}
+	return end, true
+}
+
+/*
+Strips trailing punctuation, and closing brackets which have no
+matching opening bracket within the URL.
+*/
+func (t *uax29URLEmailTokenizerImpl) trimURL(start, end int) int {
+	for end > start {
+		ch, size := utf8.DecodeLastRuneInString(t.str[start:end])
+		switch ch {
+		case '.', ',', ';', ':', '!', '?', '\'':
+			end -= size
+			continue
+		case ')', ']':
+			open := "("
+			if ch == ']' {
+				open = "["
+			}
+			s := t.str[start:end]
+			if strings.Count(s, open) < strings.Count(s, string(ch)) {
+				end -= size
+				continue
+			}
+		}
+		return end
+	}
+	return end
+}
+
+/* Whether a match may start at the given byte offset. */
+func (t *uax29URLEmailTokenizerImpl) boundaryBefore(offset int) bool {
+	if offset == 0 {
+		return true
+	}
+	ch, _ := utf8.DecodeLastRuneInString(t.str[:offset])
+	return !isWordChar(ch) && ch != '.' && ch != '@' && ch != '/' && ch != ':'
+}
+
+/* Whether a match may end at the given byte offset. */
+func (t *uax29URLEmailTokenizerImpl) boundaryAfter(offset int) bool {
+	if offset >= len(t.str) {
+		return true
+	}
+	ch, _ := utf8.DecodeRuneInString(t.str[offset:])
+	return !isWordChar(ch) && ch != '@'
+}
+
+func isWordChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || unicode.IsMark(ch) || ch == '_' || ch == '-'
+}
+
+func isTLD(s string) bool {
+	s = strings.ToLower(s)
+	if len(s) == 2 {
+		return COUNTRY_CODE_TLDS[s]
+	}
+	return GENERIC_TLDS[s]
+}
+
+/* Generic top-level domains recognized in URLs without a scheme. */
+var GENERIC_TLDS = toSet(
+	"aero", "app", "arpa", "asia", "biz", "blog", "cat", "cloud", "com",
+	"coop", "dev", "edu", "gov", "info", "int", "io", "jobs", "mil",
+	"mobi", "museum", "name", "net", "online", "org", "pro", "shop",
+	"site", "tech", "tel", "travel", "xxx", "xyz")
+
+/* Country code top-level domains recognized in URLs without a scheme. */
+var COUNTRY_CODE_TLDS = toSet(
+	"ac", "ad", "ae", "af", "ag", "ai", "al", "am", "ao", "aq", "ar",
+	"as", "at", "au", "aw", "ax", "az", "ba", "bb", "bd", "be", "bf",
+	"bg", "bh", "bi", "bj", "bm", "bn", "bo", "br", "bs", "bt", "bw",
+	"by", "bz", "ca", "cc", "cd", "cf", "cg", "ch", "ci", "ck", "cl",
+	"cm", "cn", "co", "cr", "cu", "cv", "cw", "cx", "cy", "cz", "de",
+	"dj", "dk", "dm", "do", "dz", "ec", "ee", "eg", "er", "es", "et",
+	"eu", "fi", "fj", "fk", "fm", "fo", "fr", "ga", "gb", "gd", "ge",
+	"gf", "gg", "gh", "gi", "gl", "gm", "gn", "gp", "gq", "gr", "gs",
+	"gt", "gu", "gw", "gy", "hk", "hm", "hn", "hr", "ht", "hu", "id",
+	"ie", "il", "im", "in", "iq", "ir", "is", "it", "je", "jm", "jo",
+	"jp", "ke", "kg", "kh", "ki", "km", "kn", "kp", "kr", "kw", "ky",
+	"kz", "la", "lb", "lc", "li", "lk", "lr", "ls", "lt", "lu", "lv",
+	"ly", "ma", "mc", "md", "me", "mg", "mh", "mk", "ml", "mm", "mn",
+	"mo", "mp", "mq", "mr", "ms", "mt", "mu", "mv", "mw", "mx", "my",
+	"mz", "na", "nc", "ne", "nf", "ng", "ni", "nl", "no", "np", "nr",
+	"nu", "nz", "om", "pa", "pe", "pf", "pg", "ph", "pk", "pl", "pm",
+	"pn", "pr", "ps", "pt", "pw", "py", "qa", "re", "ro", "rs", "ru",
+	"rw", "sa", "sb", "sc", "sd", "se", "sg", "sh", "si", "sk", "sl",
+	"sm", "sn", "so", "sr", "ss", "st", "su", "sv", "sx", "sy", "sz",
+	"tc", "td", "tf", "tg", "th", "tj", "tk", "tl", "tm", "tn", "to",
+	"tr", "tt", "tv", "tw", "tz", "ua", "ug", "uk", "us", "uy", "uz",
+	"va", "vc", "ve", "vg", "vi", "vn", "vu", "wf", "ws", "ye", "yt",
+	"za", "zm", "zw")
+
+func toSet(words ...string) map[string]bool {
+	ans := make(map[string]bool)
+	for _, w := range words {
+		ans[w] = true
+	}
+	return ans
+}
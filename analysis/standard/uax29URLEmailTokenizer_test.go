@@ -0,0 +1,102 @@
+package standard
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"strings"
+	"testing"
+)
+
+type expectedToken struct {
+	term, typ  string
+	start, end int
+}
+
+func assertTokenizesTo(t *testing.T, input string, expected ...expectedToken) {
+	tokenizer := NewUAX29URLEmailTokenizer(util.VERSION_LATEST, strings.NewReader(input))
+	termAtt := tokenizer.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	typeAtt := tokenizer.Attributes().Get("TypeAttribute").(TypeAttribute)
+	offsetAtt := tokenizer.Attributes().Get("OffsetAttribute").(OffsetAttribute)
+	if err := tokenizer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		ok, err := tokenizer.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			if i != len(expected) {
+				t.Errorf("%q: expected %v tokens, but got %v", input, len(expected), i)
+			}
+			break
+		}
+		if i >= len(expected) {
+			t.Errorf("%q: unexpected token %v", input, string(termAtt.Buffer()[:termAtt.Length()]))
+			continue
+		}
+		got := expectedToken{string(termAtt.Buffer()[:termAtt.Length()]), typeAtt.Type(), offsetAtt.StartOffset(), offsetAtt.EndOffset()}
+		if got != expected[i] {
+			t.Errorf("%q: expected token %v, but got %v", input, expected[i], got)
+		}
+	}
+	if err := tokenizer.End(); err != nil {
+		t.Fatal(err)
+	}
+	if end := len([]rune(input)); offsetAtt.EndOffset() != end {
+		t.Errorf("%q: expected final offset %v, but was %v", input, end, offsetAtt.EndOffset())
+	}
+}
+
+func TestUAX29URLEmailTokenizerPlainText(t *testing.T) {
+	assertTokenizesTo(t, "The quick 2 foxes.",
+		expectedToken{"The", "<ALPHANUM>", 0, 3},
+		expectedToken{"quick", "<ALPHANUM>", 4, 9},
+		expectedToken{"2", "<NUM>", 10, 11},
+		expectedToken{"foxes", "<ALPHANUM>", 12, 17})
+}
+
+func TestUAX29URLEmailTokenizerURLs(t *testing.T) {
+	assertTokenizesTo(t, "see http://example.com:8080/a/b?c=d#e, or www.example.org.",
+		expectedToken{"see", "<ALPHANUM>", 0, 3},
+		expectedToken{"http://example.com:8080/a/b?c=d#e", "<URL>", 4, 37},
+		expectedToken{"or", "<ALPHANUM>", 39, 41},
+		expectedToken{"www.example.org", "<URL>", 42, 57})
+	assertTokenizesTo(t, "(https://en.wikipedia.org/wiki/Go_(game))",
+		expectedToken{"https://en.wikipedia.org/wiki/Go_(game)", "<URL>", 1, 40})
+	// not a known top-level domain
+	assertTokenizesTo(t, "main.go",
+		expectedToken{"main.go", "<ALPHANUM>", 0, 7})
+}
+
+func TestUAX29URLEmailTokenizerEmails(t *testing.T) {
+	assertTokenizesTo(t, "mail John.Doe+tag@mail.example.com now",
+		expectedToken{"mail", "<ALPHANUM>", 0, 4},
+		expectedToken{"John.Doe+tag@mail.example.com", "<EMAIL>", 5, 34},
+		expectedToken{"now", "<ALPHANUM>", 35, 38})
+	assertTokenizesTo(t, "<root@[10.0.0.1]>",
+		expectedToken{"root@[10.0.0.1]", "<EMAIL>", 1, 16})
+}
+
+func TestUAX29URLEmailTokenizerLongInput(t *testing.T) {
+	input := strings.Repeat("word ", 100) + "a@b.com"
+	tokenizer := NewUAX29URLEmailTokenizer(util.VERSION_LATEST, strings.NewReader(input))
+	typeAtt := tokenizer.Attributes().Get("TypeAttribute").(TypeAttribute)
+	if err := tokenizer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	n, last := 0, ""
+	for {
+		ok, err := tokenizer.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		n, last = n+1, typeAtt.Type()
+	}
+	if n != 101 || last != "<EMAIL>" {
+		t.Errorf("expected 101 tokens ending with <EMAIL>, but got %v ending with %v", n, last)
+	}
+}
@@ -0,0 +1,174 @@
+package stempel
+
+import (
+	"bytes"
+)
+
+// egothor/stemmer/Diff.java
+
+/*
+The Diff object generates a patch string.
+
+A patch string is actually a command to a stemmer telling it how to
+reduce a word to its root. For example, to reduce the word teacher
+to its root teach the patch string Db would be generated. This
+command tells the stemmer to delete the last 2 characters from the
+word teacher to reach the stem (the patch commands are applied
+starting from the last character in order to save time).
+
+Each command is a pair of an operation and its parameter:
+
+  - '-' skips (parameter - 'a' + 1) characters
+  - 'D' deletes (parameter - 'a' + 1) characters
+  - 'R' replaces the current character with the parameter
+  - 'I' inserts the parameter
+*/
+type Diff struct {
+	// cost of the operations
+	insert, delete, replace, noop int
+}
+
+/* Constructor for the Diff object, with unit costs for all operations. */
+func NewDiff() *Diff {
+	return &Diff{insert: 1, delete: 1, replace: 1, noop: 0}
+}
+
+/*
+Constructor for the Diff object with the given costs of insertion,
+deletion, replacement and no-op.
+*/
+func NewDiffWithCosts(ins, del, rep, noop int) *Diff {
+	return &Diff{ins, del, rep, noop}
+}
+
+/*
+Apply the given patch string diff to the given string dest, and
+returns the result. Malformed or inapplicable patches leave the
+result as far as they could be applied.
+*/
+func ApplyDiff(dest []rune, diff string) []rune {
+	if len(dest) == 0 {
+		return dest
+	}
+	cmds := []rune(diff)
+	pos := len(dest) - 1
+	for i := 0; i+1 < len(cmds); i += 2 {
+		cmd, param := cmds[i], cmds[i+1]
+		parNum := int(param - 'a' + 1)
+		switch cmd {
+		case '-':
+			pos = pos - parNum + 1
+		case 'R':
+			if pos < 0 || pos >= len(dest) {
+				return dest
+			}
+			dest[pos] = param
+		case 'D':
+			o := pos
+			pos -= parNum - 1
+			if pos < 0 || o >= len(dest) {
+				return dest
+			}
+			dest = append(dest[:pos], dest[o+1:]...)
+		case 'I':
+			pos++
+			if pos < 0 || pos > len(dest) {
+				return dest
+			}
+			dest = append(dest, 0)
+			copy(dest[pos+1:], dest[pos:])
+			dest[pos] = param
+		}
+		pos--
+	}
+	return dest
+}
+
+/* Construct a patch string that transforms a to b. */
+func (d *Diff) Exec(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	const (
+		opDiag = iota // no change
+		opX           // delete
+		opY           // insert
+		opR           // replace
+	)
+	maxx, maxy := len(ra)+1, len(rb)+1
+	net := make([][]int, maxx)
+	way := make([][]int, maxx)
+	for x := range net {
+		net[x] = make([]int, maxy)
+		way[x] = make([]int, maxy)
+	}
+	for x := 1; x < maxx; x++ {
+		net[x][0] = x * d.delete
+		way[x][0] = opX
+	}
+	for y := 1; y < maxy; y++ {
+		net[0][y] = y * d.insert
+		way[0][y] = opY
+	}
+	for x := 1; x < maxx; x++ {
+		for y := 1; y < maxy; y++ {
+			best, op := net[x-1][y]+d.delete, opX
+			if c := net[x][y-1] + d.insert; c < best {
+				best, op = c, opY
+			}
+			if ra[x-1] == rb[y-1] {
+				if c := net[x-1][y-1] + d.noop; c <= best {
+					best, op = c, opDiag
+				}
+			} else if c := net[x-1][y-1] + d.replace; c < best {
+				best, op = c, opR
+			}
+			net[x][y], way[x][y] = best, op
+		}
+	}
+
+	// traceback
+	var result bytes.Buffer
+	const base = 'a' - 1
+	deletes, equals := rune(base), rune(base)
+	flushDeletes := func() {
+		if deletes != base {
+			result.WriteRune('D')
+			result.WriteRune(deletes)
+			deletes = base
+		}
+	}
+	flushEquals := func() {
+		if equals != base {
+			result.WriteRune('-')
+			result.WriteRune(equals)
+			equals = base
+		}
+	}
+	for x, y := maxx-1, maxy-1; x+y != 0; {
+		switch way[x][y] {
+		case opX:
+			flushEquals()
+			deletes++
+			x--
+		case opY:
+			flushDeletes()
+			flushEquals()
+			y--
+			result.WriteRune('I')
+			result.WriteRune(rb[y])
+		case opR:
+			flushDeletes()
+			flushEquals()
+			y--
+			result.WriteRune('R')
+			result.WriteRune(rb[y])
+			x--
+		case opDiag:
+			flushDeletes()
+			equals++
+			x--
+			y--
+		}
+	}
+	flushDeletes()
+	return result.String()
+}
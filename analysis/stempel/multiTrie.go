@@ -0,0 +1,188 @@
+package stempel
+
+import (
+	"bytes"
+)
+
+// egothor/stemmer/MultiTrie2.java
+
+/* End-of-match marker of the command chains of a MultiTrie2. */
+const EOM = '*'
+
+var EOM_NODE = string(EOM)
+
+/*
+The MultiTrie is a Trie of Tries.
+
+It stores words and their associated patch commands. The MultiTrie2
+is optimized for smaller storage requirements: each patch command is
+split at its skip commands, and each part is stored in its own trie,
+keyed by the remainder of the word.
+*/
+type MultiTrie2 struct {
+	tries   []*Trie
+	forward bool
+	by      int
+}
+
+/* Constructor for the MultiTrie2 object. */
+func NewMultiTrie2(forward bool) *MultiTrie2 {
+	return &MultiTrie2{forward: forward, by: 1}
+}
+
+func readMultiTrie2(in *dataInput) (*MultiTrie2, error) {
+	ans := new(MultiTrie2)
+	ans.forward = in.readBoolean()
+	ans.by = in.readInt()
+	for i := in.readInt(); i > 0 && in.err == nil; i-- {
+		t, err := readTrie(in)
+		if err != nil {
+			return nil, err
+		}
+		ans.tries = append(ans.tries, t)
+	}
+	return ans, in.err
+}
+
+func (m *MultiTrie2) store(out *dataOutput) {
+	out.writeBoolean(m.forward)
+	out.writeInt(m.by)
+	out.writeInt(len(m.tries))
+	for _, t := range m.tries {
+		t.store(out)
+	}
+}
+
+/*
+Return the element that is stored as last on a path belonging to the
+given key, or "" if there is none.
+*/
+func (m *MultiTrie2) GetLastOnPath(key string) string {
+	var result bytes.Buffer
+	lastkey := key
+	var lastch rune = ' '
+	var prev string
+	for _, t := range m.tries {
+		r := []rune(t.GetLastOnPath(lastkey))
+		if len(r) == 0 || (len(r) == 1 && r[0] == EOM) {
+			break
+		}
+		if cannotFollow(lastch, r[0]) || len(r) < 2 {
+			break
+		}
+		lastch = r[len(r)-2]
+		if r[0] == '-' {
+			if prev != "" {
+				key = m.skip(key, lengthPP(prev))
+			}
+			key = m.skip(key, lengthPP(string(r)))
+		}
+		prev = string(r)
+		result.WriteString(prev)
+		if key != "" {
+			lastkey = key
+		}
+	}
+	return result.String()
+}
+
+/* Add the given key associated with the given patch command. */
+func (m *MultiTrie2) Add(key, cmd string) {
+	if cmd == "" {
+		return
+	}
+	p := decompose(cmd)
+	levels := len(p)
+	for levels >= len(m.tries) {
+		m.tries = append(m.tries, NewTrie(m.forward))
+	}
+	lastkey := key
+	for i := 0; i < levels; i++ {
+		if key != "" {
+			m.tries[i].Add(key, p[i])
+			lastkey = key
+		} else {
+			m.tries[i].Add(lastkey, p[i])
+		}
+		if p[i] != "" && p[i][0] == '-' {
+			if i > 0 {
+				key = m.skip(key, lengthPP(p[i-1]))
+			}
+			key = m.skip(key, lengthPP(p[i]))
+		}
+	}
+	if key != "" {
+		m.tries[levels].Add(key, EOM_NODE)
+	} else {
+		m.tries[levels].Add(lastkey, EOM_NODE)
+	}
+}
+
+/* Break the given patch command into its constituent pieces, split at skip commands. */
+func decompose(cmd string) []string {
+	rs := []rune(cmd)
+	var parts []string
+	for i := 0; 0 <= i && i < len(rs); {
+		next := dashEven(rs, i)
+		if i == next {
+			end := i + 2
+			if end > len(rs) {
+				end = len(rs)
+			}
+			parts = append(parts, string(rs[i:end]))
+			i = next + 2
+		} else {
+			if next < 0 {
+				parts = append(parts, string(rs[i:]))
+			} else {
+				parts = append(parts, string(rs[i:next]))
+			}
+			i = next
+		}
+	}
+	return parts
+}
+
+func cannotFollow(after, goes rune) bool {
+	switch after {
+	case '-', 'D':
+		return after == goes
+	}
+	return false
+}
+
+func (m *MultiTrie2) skip(in string, count int) string {
+	rs := []rune(in)
+	if count > len(rs) {
+		count = len(rs)
+	}
+	if m.forward {
+		return string(rs[count:])
+	}
+	return string(rs[:len(rs)-count])
+}
+
+func dashEven(in []rune, from int) int {
+	for from < len(in) {
+		if in[from] == '-' {
+			return from
+		}
+		from += 2
+	}
+	return -1
+}
+
+/* Returns the number of characters of the word consumed by the patch command. */
+func lengthPP(cmd string) int {
+	rs := []rune(cmd)
+	length := 0
+	for i := 0; i+1 < len(rs); i += 2 {
+		switch rs[i] {
+		case '-', 'D':
+			length += int(rs[i+1] - 'a' + 1)
+		case 'R':
+			length++
+		}
+	}
+	return length
+}
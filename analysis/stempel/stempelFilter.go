@@ -0,0 +1,61 @@
+package stempel
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+)
+
+// stempel/StempelFilter.java
+
+/* Minimum length of input words to be processed. Shorter words are returned unchanged. */
+const DEFAULT_MIN_LENGTH = 3
+
+/*
+Transforms the token stream as per the stemming algorithm.
+
+Note: the input to the stemming filter must already be in lower case,
+so you will need to use LowerCaseFilter or LowerCaseTokenizer farther
+down the Tokenizer chain in order for this to work properly!
+*/
+type StempelFilter struct {
+	*TokenFilter
+	input      TokenStream
+	termAtt    CharTermAttribute
+	keywordAtt KeywordAttribute
+	stemmer    *StempelStemmer
+	minLength  int
+}
+
+/* Create filter using the supplied stemming table. */
+func NewStempelFilter(in TokenStream, stemmer *StempelStemmer) *StempelFilter {
+	return NewStempelFilterWithMinLength(in, stemmer, DEFAULT_MIN_LENGTH)
+}
+
+/*
+Create filter using the supplied stemming table. Only words longer
+than minLength are stemmed.
+*/
+func NewStempelFilterWithMinLength(in TokenStream, stemmer *StempelStemmer, minLength int) *StempelFilter {
+	ans := &StempelFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+		stemmer:     stemmer,
+		minLength:   minLength,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.keywordAtt = ans.Attributes().Add("KeywordAttribute").(KeywordAttribute)
+	return ans
+}
+
+func (f *StempelFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	if !f.keywordAtt.IsKeyword() && f.termAtt.Length() > f.minLength {
+		if stem := f.stemmer.Stem(f.termAtt.Buffer()[:f.termAtt.Length()]); stem != nil {
+			f.termAtt.CopyBuffer(stem)
+		}
+	}
+	return true, nil
+}
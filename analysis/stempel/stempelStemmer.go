@@ -0,0 +1,119 @@
+package stempel
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// stempel/StempelStemmer.java
+
+/*
+A stemmer table maps words to the patch commands (see Diff) that
+reduce them to their stems. It is implemented by Trie and MultiTrie2.
+*/
+type StemmerTable interface {
+	// Return the patch command stored for the longest matching
+	// suffix (or prefix, for forward tables) of the key.
+	GetLastOnPath(key string) string
+	// Add the given key associated with the given patch command.
+	Add(key, cmd string)
+}
+
+/*
+Load a stemmer table in the binary format of the egothor stemmer, as
+used by the stemmer tables shipped with Lucene (e.g. the Polish
+stemmer_20000.tbl).
+*/
+func LoadStemmerTable(r io.Reader) (StemmerTable, error) {
+	in := &dataInput{r: bufio.NewReader(r)}
+	method := strings.ToUpper(in.readUTF())
+	if in.err != nil {
+		return nil, in.err
+	}
+	if strings.IndexRune(method, 'M') < 0 {
+		return readTrie(in)
+	}
+	return readMultiTrie2(in)
+}
+
+/* Write the stemmer table in the format read by LoadStemmerTable(). */
+func StoreStemmerTable(w io.Writer, table StemmerTable) error {
+	out := &dataOutput{w: bufio.NewWriter(w)}
+	switch t := table.(type) {
+	case *Trie:
+		out.writeUTF("-")
+		t.store(out)
+	case *MultiTrie2:
+		out.writeUTF("-M")
+		t.store(out)
+	default:
+		return errors.New(fmt.Sprintf("cannot store stemmer table of type %T", table))
+	}
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+/*
+Compile a stemmer table from training data. Each line holds a stem
+followed by the words that reduce to it, separated by white space:
+
+	stem word1 word2 ...
+
+Words are read backwards, since inflection mostly affects the end of
+words. If multi is true, the more compact MultiTrie2 is built.
+*/
+func CompileStemmerTable(r io.Reader, multi bool) (StemmerTable, error) {
+	var table StemmerTable
+	if multi {
+		table = NewMultiTrie2(false)
+	} else {
+		table = NewTrie(false)
+	}
+	diff := NewDiff()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.ToLower(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		stem := fields[0]
+		table.Add(stem, "-a") // keep the stem itself
+		for _, token := range fields[1:] {
+			if token != stem {
+				table.Add(token, diff.Exec(token, stem))
+			}
+		}
+	}
+	return table, scanner.Err()
+}
+
+/*
+Transforms words into their stems using a stemmer table. This class is
+not thread-safe.
+*/
+type StempelStemmer struct {
+	stemmer StemmerTable
+}
+
+/* Create a Stemmer using the given stemmer table. */
+func NewStempelStemmer(stemmer StemmerTable) *StempelStemmer {
+	return &StempelStemmer{stemmer}
+}
+
+/* Stem a word, returning nil if it cannot be stemmed. */
+func (s *StempelStemmer) Stem(word []rune) []rune {
+	cmd := s.stemmer.GetLastOnPath(string(word))
+	if cmd == "" {
+		return nil
+	}
+	buffer := ApplyDiff(append([]rune(nil), word...), cmd)
+	if len(buffer) == 0 {
+		return nil
+	}
+	return buffer
+}
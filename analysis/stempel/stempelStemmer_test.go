@@ -0,0 +1,76 @@
+package stempel
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const trainingData = `dom domu domowi domem domy domów domom domami domach
+kot kota kotu kotem kocie koty kotów kotom kotami kotach
+czytać czytam czytasz czyta czytamy czytacie czytają czytałem czytała
+książka książki książce książkę książką książek książkom
+teach teacher teaching teaches taught
+`
+
+func TestDiff(t *testing.T) {
+	diff := NewDiff()
+	for _, v := range [][2]string{
+		{"teacher", "teach"}, {"taught", "teach"}, {"kotów", "kot"},
+		{"książce", "książka"}, {"abc", ""}, {"", "abc"}, {"x", "xyz"},
+		{"przeczytać", "czytać"}, {"same", "same"},
+	} {
+		cmd := diff.Exec(v[0], v[1])
+		if got := string(ApplyDiff([]rune(v[0]), cmd)); v[0] != "" && got != v[1] {
+			t.Errorf("%v -> %v: patch %q gives %v", v[0], v[1], cmd, got)
+		}
+	}
+	if cmd := diff.Exec("teacher", "teach"); cmd != "Db" {
+		t.Errorf("expected patch Db, but got %q", cmd)
+	}
+}
+
+func checkTable(t *testing.T, table StemmerTable) {
+	stemmer := NewStempelStemmer(table)
+	for _, line := range strings.Split(strings.TrimSpace(trainingData), "\n") {
+		fields := strings.Fields(line)
+		for _, word := range fields {
+			if got := string(stemmer.Stem([]rune(word))); got != fields[0] {
+				t.Errorf("%v: expected stem %v, but got %v", word, fields[0], got)
+			}
+		}
+	}
+}
+
+func TestStemmerTable(t *testing.T) {
+	for _, multi := range []bool{false, true} {
+		table, err := CompileStemmerTable(strings.NewReader(trainingData), multi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTable(t, table)
+
+		var buf bytes.Buffer
+		if err = StoreStemmerTable(&buf, table); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadStemmerTable(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTable(t, loaded)
+	}
+}
+
+/* A table of its own type cannot be stored. */
+type mapTable map[string]string
+
+func (m mapTable) GetLastOnPath(key string) string { return m[key] }
+func (m mapTable) Add(key, cmd string)             { m[key] = cmd }
+
+func TestStoreUnknownStemmerTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := StoreStemmerTable(&buf, mapTable{"koty": "Da"}); err == nil {
+		t.Error("Expected an error storing a table of an unknown type")
+	}
+}
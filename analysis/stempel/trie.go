@@ -0,0 +1,318 @@
+package stempel
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"unicode/utf16"
+)
+
+// egothor/stemmer/Cell.java
+
+/* A Cell is a portion of a trie. */
+type cell struct {
+	// next row id in this way
+	ref int
+	// command of the cell
+	cmd int
+	// how many cmd-s was in subtrie before pack()
+	cnt int
+	// how many chars would be discarded from input key in this way
+	skip int
+}
+
+// egothor/stemmer/Row.java
+
+/* The Row class represents a row in a matrix representation of a trie. */
+type row struct {
+	cells map[rune]*cell
+}
+
+func newRow() *row {
+	return &row{cells: make(map[rune]*cell)}
+}
+
+func (r *row) at(ch rune) *cell {
+	c, ok := r.cells[ch]
+	if !ok {
+		c = &cell{ref: -1, cmd: -1}
+		r.cells[ch] = c
+	}
+	return c
+}
+
+/* Return the command in the Cell associated with the given character, or -1. */
+func (r *row) getCmd(ch rune) int {
+	if c, ok := r.cells[ch]; ok {
+		return c.cmd
+	}
+	return -1
+}
+
+/* Return the reference to the next Row in the Cell associated with the given character, or -1. */
+func (r *row) getRef(ch rune) int {
+	if c, ok := r.cells[ch]; ok {
+		return c.ref
+	}
+	return -1
+}
+
+func (r *row) setCmd(ch rune, cmd int) {
+	c := r.at(ch)
+	c.cnt++
+	c.cmd = cmd
+}
+
+func (r *row) setRef(ch rune, ref int) {
+	r.at(ch).ref = ref
+}
+
+// egothor/stemmer/Trie.java
+
+/*
+A Trie is used to store a dictionary of words and their stems.
+
+Actually, what is stored are words with their respective patch
+commands. A trie can be termed forward (words read in forward order)
+or backward (words read in backward order).
+*/
+type Trie struct {
+	rows    []*row
+	cmds    []string
+	root    int
+	forward bool
+}
+
+/* Constructor for the Trie object. */
+func NewTrie(forward bool) *Trie {
+	return &Trie{
+		rows:    []*row{newRow()},
+		forward: forward,
+	}
+}
+
+/* Returns the characters of the key in the order the trie reads them. */
+func (t *Trie) keyOrder(key string) []rune {
+	rs := []rune(key)
+	if !t.forward {
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+	}
+	return rs
+}
+
+/* Add the given key associated with the given patch command. */
+func (t *Trie) Add(key, cmd string) {
+	if key == "" || cmd == "" {
+		return
+	}
+	idCmd := -1
+	for i, c := range t.cmds {
+		if c == cmd {
+			idCmd = i
+			break
+		}
+	}
+	if idCmd == -1 {
+		idCmd = len(t.cmds)
+		t.cmds = append(t.cmds, cmd)
+	}
+
+	r := t.rows[t.root]
+	e := t.keyOrder(key)
+	for _, ch := range e[:len(e)-1] {
+		if node := r.getRef(ch); node >= 0 {
+			r = t.rows[node]
+		} else {
+			node = len(t.rows)
+			n := newRow()
+			t.rows = append(t.rows, n)
+			r.setRef(ch, node)
+			r = n
+		}
+	}
+	r.setCmd(e[len(e)-1], idCmd)
+}
+
+/*
+Return the element that is stored as last on a path associated with
+the given key, or "" if there is none.
+*/
+func (t *Trie) GetLastOnPath(key string) string {
+	e := t.keyOrder(key)
+	if len(e) == 0 {
+		return ""
+	}
+	now := t.rows[t.root]
+	last := ""
+	for _, ch := range e[:len(e)-1] {
+		if w := now.getCmd(ch); w >= 0 {
+			last = t.cmds[w]
+		}
+		w := now.getRef(ch)
+		if w < 0 {
+			return last
+		}
+		now = t.rows[w]
+	}
+	if w := now.getCmd(e[len(e)-1]); w >= 0 {
+		return t.cmds[w]
+	}
+	return last
+}
+
+/* Return all patch commands stored in the trie, in the order they were added. */
+func (t *Trie) Commands() []string { return t.cmds }
+
+/*
+Loads a trie in the binary format of the egothor stemmer (Java's
+DataOutput encoding), which is also used for Lucene's stemmer tables.
+*/
+func readTrie(in *dataInput) (*Trie, error) {
+	t := new(Trie)
+	t.forward = in.readBoolean()
+	t.root = in.readInt()
+	for i := in.readInt(); i > 0 && in.err == nil; i-- {
+		t.cmds = append(t.cmds, in.readUTF())
+	}
+	for i := in.readInt(); i > 0 && in.err == nil; i-- {
+		r := newRow()
+		for j := in.readInt(); j > 0 && in.err == nil; j-- {
+			ch := in.readChar()
+			c := new(cell)
+			c.cmd = in.readInt()
+			c.cnt = in.readInt()
+			c.ref = in.readInt()
+			c.skip = in.readInt()
+			r.cells[ch] = c
+		}
+		t.rows = append(t.rows, r)
+	}
+	if in.err == nil && (t.root < 0 || t.root >= len(t.rows)) {
+		return nil, errors.New("corrupted stemmer table: invalid root")
+	}
+	return t, in.err
+}
+
+func (t *Trie) store(out *dataOutput) {
+	out.writeBoolean(t.forward)
+	out.writeInt(t.root)
+	out.writeInt(len(t.cmds))
+	for _, cmd := range t.cmds {
+		out.writeUTF(cmd)
+	}
+	out.writeInt(len(t.rows))
+	for _, r := range t.rows {
+		chars := make([]int, 0, len(r.cells))
+		for ch := range r.cells {
+			chars = append(chars, int(ch))
+		}
+		sort.Ints(chars)
+		out.writeInt(len(chars))
+		for _, ch := range chars {
+			c := r.cells[rune(ch)]
+			out.writeChar(rune(ch))
+			out.writeInt(c.cmd)
+			out.writeInt(c.cnt)
+			out.writeInt(c.ref)
+			out.writeInt(c.skip)
+		}
+	}
+}
+
+/* Java's DataInput, remembering the first error. */
+type dataInput struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (in *dataInput) read(n int) []byte {
+	buf := make([]byte, n)
+	if in.err == nil {
+		_, in.err = io.ReadFull(in.r, buf)
+	}
+	return buf
+}
+
+func (in *dataInput) readBoolean() bool { return in.read(1)[0] != 0 }
+func (in *dataInput) readInt() int      { return int(int32(binary.BigEndian.Uint32(in.read(4)))) }
+func (in *dataInput) readChar() rune    { return rune(binary.BigEndian.Uint16(in.read(2))) }
+
+/* Reads a string in Java's modified UTF-8. */
+func (in *dataInput) readUTF() string {
+	b := in.read(int(binary.BigEndian.Uint16(in.read(2))))
+	var chars []uint16
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c < 0x80:
+			chars = append(chars, uint16(c))
+			i++
+		case c&0xE0 == 0xC0 && i+1 < len(b):
+			chars = append(chars, uint16(c&0x1F)<<6|uint16(b[i+1]&0x3F))
+			i += 2
+		case c&0xF0 == 0xE0 && i+2 < len(b):
+			chars = append(chars, uint16(c&0x0F)<<12|uint16(b[i+1]&0x3F)<<6|uint16(b[i+2]&0x3F))
+			i += 3
+		default:
+			if in.err == nil {
+				in.err = errors.New("malformed modified UTF-8 input")
+			}
+			return ""
+		}
+	}
+	return string(utf16.Decode(chars))
+}
+
+/* Java's DataOutput, remembering the first error. */
+type dataOutput struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (out *dataOutput) write(b []byte) {
+	if out.err == nil {
+		_, out.err = out.w.Write(b)
+	}
+}
+
+func (out *dataOutput) writeBoolean(v bool) {
+	if v {
+		out.write([]byte{1})
+	} else {
+		out.write([]byte{0})
+	}
+}
+
+func (out *dataOutput) writeInt(v int) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(int32(v)))
+	out.write(b[:])
+}
+
+func (out *dataOutput) writeChar(v rune) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	out.write(b[:])
+}
+
+/* Writes a string in Java's modified UTF-8. */
+func (out *dataOutput) writeUTF(s string) {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		switch {
+		case c != 0 && c < 0x80:
+			b = append(b, byte(c))
+		case c < 0x800:
+			b = append(b, byte(0xC0|c>>6), byte(0x80|c&0x3F))
+		default:
+			b = append(b, byte(0xE0|c>>12), byte(0x80|(c>>6)&0x3F), byte(0x80|c&0x3F))
+		}
+	}
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(b)))
+	out.write(n[:])
+	out.write(b)
+}
@@ -0,0 +1,73 @@
+package th
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"io"
+)
+
+// th/ThaiAnalyzer.java
+
+/* The default set of Thai stop words. */
+var THAI_STOP_WORDS_SET = map[string]bool{
+	"ไว้": true, "ไม่": true, "ไป": true, "ได้": true, "ให้": true,
+	"ใน": true, "โดย": true, "แห่ง": true, "แล้ว": true, "และ": true,
+	"แรก": true, "แบบ": true, "แต่": true, "เอง": true, "เห็น": true,
+	"เลย": true, "เริ่ม": true, "เรา": true, "เมื่อ": true,
+	"เพื่อ": true, "เพราะ": true, "เป็นการ": true, "เป็น": true,
+	"เนื่องจาก": true, "เดียวกัน": true, "เดียว": true, "เช่น": true,
+	"เฉพาะ": true, "เคย": true, "เข้า": true, "เขา": true, "อีก": true,
+	"อาจ": true, "อะไร": true, "ออก": true, "อย่าง": true, "อยู่": true,
+	"อยาก": true, "หาก": true, "หลาย": true, "หลังจาก": true,
+	"หลัง": true, "หรือ": true, "หนึ่ง": true, "ส่วน": true,
+	"ส่ง": true, "สุด": true, "สำหรับ": true, "ว่า": true, "วัน": true,
+	"ลง": true, "ร่วม": true, "ราย": true, "รับ": true, "ระหว่าง": true,
+	"รวม": true, "ยัง": true, "มี": true, "มาก": true, "มา": true,
+	"พร้อม": true, "พบ": true, "ผ่าน": true, "ผล": true, "บาง": true,
+	"น่า": true, "นี้": true, "นำ": true, "นั้น": true, "นัก": true,
+	"นอกจาก": true, "ทุก": true, "ที่สุด": true, "ที่": true,
+	"ทำให้": true, "ทำ": true, "ทาง": true, "ทั้งนี้": true,
+	"ทั้ง": true, "ถ้า": true, "ถูก": true, "ถึง": true, "ต้อง": true,
+	"ต่าง": true, "ต่อ": true, "ตาม": true, "ตั้งแต่": true,
+	"ตั้ง": true, "ด้าน": true, "ด้วย": true, "ดัง": true, "ซึ่ง": true,
+	"ช่วง": true, "จึง": true, "จาก": true, "จัด": true, "จะ": true,
+	"คือ": true, "ความ": true, "ครั้ง": true, "คง": true, "ขึ้น": true,
+	"ของ": true, "ขอ": true, "ขณะ": true, "ก่อน": true, "ก็": true,
+	"การ": true, "กับ": true, "กัน": true, "กว่า": true, "กล่าว": true,
+}
+
+/*
+Analyzer for Thai language. It uses ThaiTokenizer with the given
+dictionary to break words, filtered with LowerCaseFilter and
+StopFilter.
+*/
+type ThaiAnalyzer struct {
+	*StopwordAnalyzerBase
+	dictionary  map[string]bool
+	stopWordSet map[string]bool
+}
+
+/* Builds an analyzer with the given dictionary and the default stop words (THAI_STOP_WORDS_SET). */
+func NewThaiAnalyzer(dictionary map[string]bool) *ThaiAnalyzer {
+	return NewThaiAnalyzerWithStopWords(dictionary, THAI_STOP_WORDS_SET)
+}
+
+/* Builds an analyzer with the given dictionary and stop words. */
+func NewThaiAnalyzerWithStopWords(dictionary, stopWords map[string]bool) *ThaiAnalyzer {
+	ans := &ThaiAnalyzer{
+		dictionary:  dictionary,
+		stopWordSet: stopWords,
+	}
+	ans.StopwordAnalyzerBase = NewStopwordAnalyzerBaseWithStopWords(stopWords)
+	ans.Spi = ans
+	return ans
+}
+
+func (a *ThaiAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *TokenStreamComponents {
+	version := a.Version()
+	src := NewThaiTokenizer(reader, a.dictionary)
+	var tok TokenStream = NewLowerCaseFilter(version, src)
+	tok = NewStopFilter(version, tok, a.stopWordSet)
+	return NewTokenStreamComponents(src, tok)
+}
@@ -0,0 +1,260 @@
+package th
+
+import (
+	"bufio"
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// th/ThaiTokenizer.java
+
+/*
+Tokenizer that uses word-break rules to find word boundaries in Thai
+text, which is written without spaces between words.
+
+The input is split with the Unicode word-break rules of UAX #29, as
+implemented by StandardTokenizer, and read incrementally. UAX #29
+does not break runs of Thai and other South East Asian letters, as
+their word boundaries can only be found with a dictionary: the rules
+return such a run as a single <SOUTHEAST_ASIAN> token. The Thai parts
+of these runs are split into character clusters (a leading vowel, a
+consonant and its attached marks and following vowels), which are
+then combined into the dictionary words covering the run with the
+least unknown clusters and words (maximal matching). Clusters not
+covered by the dictionary are emitted one by one, just like unigrams
+for CJK text, so that phrase queries still match them. Without a
+dictionary, only clusters are emitted.
+
+Other tokens, and the other parts of South East Asian runs, e.g. Lao,
+are emitted as found by UAX #29, with the token types of
+StandardTokenizer. Only the current run is buffered, however long.
+*/
+type ThaiTokenizer struct {
+	*Tokenizer
+	words         *standard.StandardTokenizer
+	dictionary    map[string]bool
+	maxWordLength int
+
+	termAtt   CharTermAttribute
+	offsetAtt OffsetAttribute
+	typeAtt   TypeAttribute
+
+	wordTermAtt   CharTermAttribute
+	wordOffsetAtt OffsetAttribute
+	wordTypeAtt   TypeAttribute
+
+	buffer  []rune // the current word
+	offset  int    // start of the current word in the input
+	pending []thaiToken
+}
+
+type thaiToken struct {
+	start, end int // in the current word
+	typ        string
+}
+
+/* Creates a new ThaiTokenizer segmenting with the given word list, which may be nil. */
+func NewThaiTokenizer(input io.RuneReader, dictionary map[string]bool) *ThaiTokenizer {
+	ans := &ThaiTokenizer{
+		Tokenizer:  NewTokenizer(input),
+		words:      standard.NewStandardTokenizer(util.VERSION_LATEST, input),
+		dictionary: dictionary,
+	}
+	for word := range dictionary {
+		if n := len([]rune(word)); n > ans.maxWordLength {
+			ans.maxWordLength = n
+		}
+	}
+	// runs of Thai text must not be skipped
+	ans.words.SetMaxTokenLength(math.MaxInt32)
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	ans.typeAtt = ans.Attributes().Add("TypeAttribute").(TypeAttribute)
+	ans.wordTermAtt = ans.words.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	ans.wordOffsetAtt = ans.words.Attributes().Get("OffsetAttribute").(OffsetAttribute)
+	ans.wordTypeAtt = ans.words.Attributes().Get("TypeAttribute").(TypeAttribute)
+	return ans
+}
+
+/* Loads a word list with one word per line; lines starting with '#' are ignored. */
+func LoadThaiDictionary(r io.Reader) (map[string]bool, error) {
+	ans := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && line[0] != '#' {
+			ans[line] = true
+		}
+	}
+	return ans, scanner.Err()
+}
+
+/*
+Hides the offset correction of a CharFilter from the word tokenizer,
+so that its offsets can be corrected once, including the ones of the
+tokens found within a word.
+*/
+type uncorrectedReader struct {
+	io.RuneReader
+}
+
+func (t *ThaiTokenizer) IncrementToken() (bool, error) {
+	for len(t.pending) == 0 {
+		ok, err := t.words.IncrementToken()
+		if err != nil || !ok {
+			return false, err
+		}
+		t.buffer = append(t.buffer[:0], t.wordTermAtt.Buffer()[:t.wordTermAtt.Length()]...)
+		t.offset = t.wordOffsetAtt.StartOffset()
+		if typ := t.wordTypeAtt.Type(); typ == standard.TOKEN_TYPES[standard.SOUTHEAST_ASIAN] {
+			t.split()
+		} else {
+			t.pending = append(t.pending, thaiToken{0, len(t.buffer), typ})
+		}
+	}
+	token := t.pending[0]
+	t.pending = t.pending[1:]
+
+	t.Attributes().Clear()
+	t.termAtt.CopyBuffer(t.buffer[token.start:token.end])
+	t.offsetAtt.SetOffset(t.CorrectOffset(t.offset+token.start), t.CorrectOffset(t.offset+token.end))
+	t.typeAtt.SetType(token.typ)
+	return true, nil
+}
+
+func (t *ThaiTokenizer) End() error {
+	if err := t.Tokenizer.End(); err != nil {
+		return err
+	}
+	if err := t.words.End(); err != nil {
+		return err
+	}
+	// set final offset
+	finalOffset := t.CorrectOffset(t.wordOffsetAtt.EndOffset())
+	t.offsetAtt.SetOffset(finalOffset, finalOffset)
+	return nil
+}
+
+func (t *ThaiTokenizer) Close() error {
+	if err := t.words.Close(); err != nil {
+		return err
+	}
+	return t.Tokenizer.Close()
+}
+
+func (t *ThaiTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	if err := t.words.Close(); err != nil {
+		return err
+	}
+	if err := t.words.SetReader(uncorrectedReader{t.Input}); err != nil {
+		return err
+	}
+	if err := t.words.Reset(); err != nil {
+		return err
+	}
+	t.buffer = t.buffer[:0]
+	t.pending = nil
+	return nil
+}
+
+/*
+Splits the current South East Asian word into its Thai runs, which
+are segmented, and the runs of other letters, e.g. Lao, which are
+emitted as they are.
+*/
+func (t *ThaiTokenizer) split() {
+	for i := 0; i < len(t.buffer); {
+		start, thai := i, isThai(t.buffer[i])
+		for i < len(t.buffer) && isThai(t.buffer[i]) == thai {
+			i++
+		}
+		if thai {
+			t.segment(start, i)
+		} else {
+			t.pending = append(t.pending, thaiToken{start, i, standard.TOKEN_TYPES[standard.SOUTHEAST_ASIAN]})
+		}
+	}
+}
+
+/*
+Splits the Thai run text[start:end] into the sequence of dictionary
+words and unknown clusters with the least unknown clusters, and then
+the least tokens.
+*/
+func (t *ThaiTokenizer) segment(start, end int) {
+	bounds := clusterBoundaries(t.buffer, start, end)
+	type cost struct {
+		unknown, tokens int
+		back            int
+	}
+	less := func(a, b cost) bool {
+		return a.unknown < b.unknown || a.unknown == b.unknown && a.tokens < b.tokens
+	}
+	best := make([]cost, len(bounds))
+	for j := 1; j < len(bounds); j++ {
+		// an unknown cluster
+		best[j] = cost{best[j-1].unknown + 1, best[j-1].tokens + 1, j - 1}
+		for i := j - 1; i >= 0 && bounds[j]-bounds[i] <= t.maxWordLength; i-- {
+			if t.dictionary[string(t.buffer[bounds[i]:bounds[j]])] {
+				if c := (cost{best[i].unknown, best[i].tokens + 1, i}); less(c, best[j]) {
+					best[j] = c
+				}
+			}
+		}
+	}
+	var tokens []thaiToken
+	for j := len(bounds) - 1; j > 0; j = best[j].back {
+		tokens = append(tokens, thaiToken{bounds[best[j].back], bounds[j], standard.TOKEN_TYPES[standard.SOUTHEAST_ASIAN]})
+	}
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t.pending = append(t.pending, tokens[i])
+	}
+}
+
+/*
+Returns the boundaries of the Thai character clusters in
+text[start:end], including start and end: a cluster is an optional
+leading vowel, a consonant, and the marks and vowels that follow it.
+*/
+func clusterBoundaries(text []rune, start, end int) []int {
+	ans := []int{start}
+	for i := start; i < end; {
+		if isLeadingVowel(text[i]) {
+			i++
+		}
+		if i < end && !isLeadingVowel(text[i]) {
+			i++
+		}
+		for i < end && isFollowing(text[i]) {
+			i++
+		}
+		ans = append(ans, i)
+	}
+	return ans
+}
+
+func isThai(ch rune) bool {
+	return unicode.Is(unicode.Thai, ch) && !unicode.IsDigit(ch) && !unicode.IsPunct(ch)
+}
+
+/* Returns true for the vowels written before the consonant: เ แ โ ใ ไ */
+func isLeadingVowel(ch rune) bool {
+	return ch >= 'เ' && ch <= 'ไ'
+}
+
+/* Returns true for marks and vowels attached to the preceding consonant. */
+func isFollowing(ch rune) bool {
+	switch {
+	case ch == 'ะ', ch == 'า', ch == 'ำ', ch == 'ๅ', ch == 'ๆ':
+		return true
+	}
+	return unicode.Is(unicode.Mn, ch)
+}
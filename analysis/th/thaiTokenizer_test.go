@@ -0,0 +1,136 @@
+package th
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testDictionary = map[string]bool{
+	"การ": true, "ทดสอบ": true, "ภาษา": true, "ไทย": true, "ภา": true,
+}
+
+func TestThaiAnalyzer(t *testing.T) {
+	a := NewThaiAnalyzer(testDictionary)
+	// words are found with the dictionary, stop words removed
+	analysis.AssertAnalyzesTo(t, a, "การทดสอบภาษาไทย", "ทดสอบ", "ภาษา", "ไทย")
+	// mixed with latin text and numbers
+	analysis.AssertAnalyzesTo(t, a, "ภาษาไทย Go 1.5", "ภาษา", "ไทย", "go", "1.5")
+	// other south east asian scripts are kept as found by UAX#29
+	analysis.AssertAnalyzesTo(t, a, "ไทย, ລາວ", "ไทย", "ລາວ")
+	// unknown words are split into clusters
+	analysis.AssertAnalyzesTo(t, a, "ไทยเกาะ", "ไทย", "เกาะ")
+	analysis.AssertAnalyzesTo(t, a, "ไทยกิน", "ไทย", "กิ", "น")
+}
+
+func TestThaiTokenizerNoDictionary(t *testing.T) {
+	a := NewThaiAnalyzerWithStopWords(nil, nil)
+	analysis.AssertAnalyzesTo(t, a, "ภาษาไทย", "ภา", "ษา", "ไท", "ย")
+}
+
+type thaiTestToken struct {
+	term, typ  string
+	start, end int
+}
+
+func TestThaiTokenizerOffsets(t *testing.T) {
+	input := "การทดสอบ Go ๑๒, ไทยกิน"
+	tokenizer := NewThaiTokenizer(strings.NewReader(input), testDictionary)
+	termAtt := tokenizer.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	typeAtt := tokenizer.Attributes().Get("TypeAttribute").(TypeAttribute)
+	offsetAtt := tokenizer.Attributes().Get("OffsetAttribute").(OffsetAttribute)
+	if err := tokenizer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []thaiTestToken
+	for {
+		ok, err := tokenizer.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		tokens = append(tokens, thaiTestToken{
+			string(termAtt.Buffer()[:termAtt.Length()]), typeAtt.Type(),
+			offsetAtt.StartOffset(), offsetAtt.EndOffset(),
+		})
+	}
+	if err := tokenizer.End(); err != nil {
+		t.Fatal(err)
+	}
+	if end := len([]rune(input)); offsetAtt.EndOffset() != end {
+		t.Errorf("expected final offset %v, but was %v", end, offsetAtt.EndOffset())
+	}
+	expected := []thaiTestToken{
+		{"การ", "<SOUTHEAST_ASIAN>", 0, 3},
+		{"ทดสอบ", "<SOUTHEAST_ASIAN>", 3, 8},
+		{"Go", "<ALPHANUM>", 9, 11},
+		{"๑๒", "<NUM>", 12, 14},
+		{"ไทย", "<SOUTHEAST_ASIAN>", 16, 19},
+		{"กิ", "<SOUTHEAST_ASIAN>", 19, 21},
+		{"น", "<SOUTHEAST_ASIAN>", 21, 22},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected %v, but got %v", expected, tokens)
+	}
+}
+
+/* Counts the runes read from the input. */
+type countingReader struct {
+	*strings.Reader
+	read int
+}
+
+func (r *countingReader) ReadRune() (rune, int, error) {
+	ch, size, err := r.Reader.ReadRune()
+	if err == nil {
+		r.read++
+	}
+	return ch, size, err
+}
+
+func TestThaiTokenizerReadsIncrementally(t *testing.T) {
+	input := strings.Repeat("ภาษาไทย ", 1000)
+	reader := &countingReader{Reader: strings.NewReader(input)}
+	tokenizer := NewThaiTokenizer(reader, testDictionary)
+	termAtt := tokenizer.Attributes().Get("CharTermAttribute").(CharTermAttribute)
+	if err := tokenizer.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tokenizer.IncrementToken(); err != nil || !ok {
+		t.Fatalf("expected a token, but got %v, %v", ok, err)
+	}
+	if term := string(termAtt.Buffer()[:termAtt.Length()]); term != "ภาษา" {
+		t.Errorf("expected ภาษา, but got %v", term)
+	}
+	if total := len([]rune(input)); reader.read >= total {
+		t.Errorf("expected the input to be read incrementally, but %v of %v runes were read", reader.read, total)
+	}
+	count := 1
+	for {
+		ok, err := tokenizer.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		count++
+	}
+	if count != 2000 {
+		t.Errorf("expected 2000 tokens, but got %v", count)
+	}
+}
+
+func TestThaiTokenizerLongRun(t *testing.T) {
+	// longer than the default max token length of StandardTokenizer
+	a := NewThaiAnalyzerWithStopWords(testDictionary, nil)
+	var expected []string
+	for i := 0; i < 100; i++ {
+		expected = append(expected, "ภาษา", "ไทย")
+	}
+	analysis.AssertAnalyzesTo(t, a, strings.Repeat("ภาษาไทย", 100), expected...)
+}
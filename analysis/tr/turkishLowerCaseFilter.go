@@ -0,0 +1,101 @@
+package tr
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"unicode"
+)
+
+// tr/TurkishLowerCaseFilter.java
+
+const (
+	LATIN_CAPITAL_LETTER_I       = 'I'
+	LATIN_SMALL_LETTER_I         = 'i'
+	LATIN_SMALL_LETTER_DOTLESS_I = 'ı'
+	COMBINING_DOT_ABOVE          = '̇'
+)
+
+/*
+Normalizes Turkish token text to lower case.
+
+Turkish and Azeri have unique casing behavior for some characters.
+This filter applies Turkish lowercase rules: 'I' is lowercased to the
+dotless 'ı', while 'I' followed by COMBINING_DOT_ABOVE (the decomposed
+form of 'İ') becomes 'i'. The plain LowerCaseFilter would produce 'i'
+in both cases.
+*/
+type TurkishLowerCaseFilter struct {
+	*TokenFilter
+	input   TokenStream
+	termAtt CharTermAttribute
+}
+
+/* Create a new TurkishLowerCaseFilter, that normalizes Turkish token text to lower case. */
+func NewTurkishLowerCaseFilter(in TokenStream) *TurkishLowerCaseFilter {
+	ans := &TurkishLowerCaseFilter{
+		TokenFilter: NewTokenFilter(in),
+		input:       in,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (f *TurkishLowerCaseFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if err != nil || !ok {
+		return false, err
+	}
+	buffer := f.termAtt.Buffer()
+	length := f.termAtt.Length()
+	iOrAfter := false
+	for i := 0; i < length; {
+		ch := buffer[i]
+		iOrAfter = ch == LATIN_CAPITAL_LETTER_I || (iOrAfter && unicode.Is(unicode.Mn, ch))
+		if iOrAfter { // all the special I turkish handling happens here.
+			switch ch {
+			case COMBINING_DOT_ABOVE:
+				// remove COMBINING_DOT_ABOVE to mimic composed lowercase
+				length = deleteRune(buffer, i, length)
+				continue
+			case LATIN_CAPITAL_LETTER_I:
+				// i itself, it depends if it is followed by COMBINING_DOT_ABOVE
+				// if it is, we will make it small i and later remove the dot
+				if isBeforeDot(buffer, i+1, length) {
+					buffer[i] = LATIN_SMALL_LETTER_I
+				} else {
+					buffer[i] = LATIN_SMALL_LETTER_DOTLESS_I
+					// no COMBINING_DOT_ABOVE follows
+					iOrAfter = false
+				}
+				i++
+				continue
+			}
+		}
+		buffer[i] = unicode.ToLower(ch)
+		i++
+	}
+	f.termAtt.SetLength(length)
+	return true, nil
+}
+
+/*
+Lookahead for a COMBINING_DOT_ABOVE, returns true if it's found
+before any other character which isn't a non-spacing mark.
+*/
+func isBeforeDot(s []rune, pos, length int) bool {
+	for _, ch := range s[pos:length] {
+		if !unicode.Is(unicode.Mn, ch) {
+			return false
+		}
+		if ch == COMBINING_DOT_ABOVE {
+			return true
+		}
+	}
+	return false
+}
+
+/* Delete a character in-place, returns the new length. */
+func deleteRune(s []rune, pos, length int) int {
+	copy(s[pos:], s[pos+1:length])
+	return length - 1
+}
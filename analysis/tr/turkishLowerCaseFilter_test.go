@@ -0,0 +1,25 @@
+package tr
+
+import (
+	. "github.com/balzaczyy/golucene/analysis/core"
+	. "github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+/* Returns the filter over input as a single token. */
+func lowerCaseFilter(input string) TokenStream {
+	return NewTurkishLowerCaseFilter(NewKeywordTokenizer(strings.NewReader(input)))
+}
+
+func TestTurkishLowerCaseFilter(t *testing.T) {
+	// composed İ
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("\u0130STANBUL"), "istanbul")
+	// decomposed İ
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("I\u0307STANBUL"), "istanbul")
+	// dotless I
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("ISPARTA"), "\u0131sparta")
+	// other non-spacing marks may come before the dot
+	analysis.AssertTokenStreamContents(t, lowerCaseFilter("I\u0316\u0307STANBUL"), "i\u0316stanbul")
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	. "github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"unicode"
+)
+
+// util/ElisionFilter.java
+
+/*
+Removes elisions from a TokenStream. For example, "l'avion" (the
+plane) will be tokenized as "avion" (plane).
+
+Articles are matched case-insensitively, and should be given in lower
+case.
+*/
+type ElisionFilter struct {
+	*TokenFilter
+	input    TokenStream
+	articles map[string]bool
+	termAtt  CharTermAttribute
+}
+
+/* Constructs an elision filter with a Set of stop words */
+func NewElisionFilter(input TokenStream, articles map[string]bool) *ElisionFilter {
+	ans := &ElisionFilter{
+		TokenFilter: NewTokenFilter(input),
+		input:       input,
+		articles:    articles,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+/* Increments the TokenStream with a CharTermAttribute without elisioned start */
+func (f *ElisionFilter) IncrementToken() (bool, error) {
+	ok, err := f.input.IncrementToken()
+	if !ok || err != nil {
+		return false, err
+	}
+	termBuffer := f.termAtt.Buffer()
+	termLength := f.termAtt.Length()
+
+	index := -1
+	for i, ch := range termBuffer[:termLength] {
+		if ch == '\'' || ch == '’' {
+			index = i
+			break
+		}
+	}
+
+	// An apostrophe has been found. If the prefix is an article strip it off.
+	if index >= 0 {
+		prefix := make([]rune, index)
+		for i, ch := range termBuffer[:index] {
+			prefix[i] = unicode.ToLower(ch)
+		}
+		if _, ok := f.articles[string(prefix)]; ok {
+			f.termAtt.CopyBuffer(termBuffer[index+1 : termLength])
+		}
+	}
+	return true, nil
+}
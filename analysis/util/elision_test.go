@@ -0,0 +1,18 @@
+package util_test
+
+import (
+	"github.com/balzaczyy/golucene/analysis/standard"
+	. "github.com/balzaczyy/golucene/analysis/util"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/test_framework/analysis"
+	"strings"
+	"testing"
+)
+
+func TestElisionFilter(t *testing.T) {
+	input := "Plop, juste pour voir l'embrouille avec O'brian. M'enfin, l’avion."
+	src := standard.NewStandardTokenizer(util.VERSION_LATEST, strings.NewReader(input))
+	f := NewElisionFilter(src, map[string]bool{"l": true, "m": true})
+	analysis.AssertTokenStreamContents(t, f,
+		"Plop", "juste", "pour", "voir", "embrouille", "avec", "O'brian", "enfin", "avion")
+}
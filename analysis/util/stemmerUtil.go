@@ -0,0 +1,61 @@
+package util
+
+// util/StemmerUtil.java
+
+/* Returns true if the rune slice starts with the prefix. */
+func StartsWith(s []rune, length int, prefix string) bool {
+	p := []rune(prefix)
+	if len(p) > length {
+		return false
+	}
+	for i, ch := range p {
+		if s[i] != ch {
+			return false
+		}
+	}
+	return true
+}
+
+/* Returns true if the rune slice ends with the suffix. */
+func EndsWith(s []rune, length int, suffix string) bool {
+	p := []rune(suffix)
+	if len(p) > length {
+		return false
+	}
+	for i := len(p) - 1; i >= 0; i-- {
+		if s[length-(len(p)-i)] != p[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Delete a character in-place. Returns the length of the buffer after
+deletion.
+*/
+func Delete(s []rune, pos, length int) int {
+	assert(pos < length)
+	if pos < length-1 { // don't copy if asked to delete last character
+		copy(s[pos:], s[pos+1:length])
+	}
+	return length - 1
+}
+
+/*
+Delete n characters in-place. Returns the length of the buffer after
+deletion.
+*/
+func DeleteN(s []rune, pos, length, nChars int) int {
+	assert(pos+nChars <= length)
+	if pos+nChars < length { // don't copy if asked to delete the last characters
+		copy(s[pos:], s[pos+nChars:length])
+	}
+	return length - nChars
+}
+
+func assert(ok bool) {
+	if !ok {
+		panic("assert fail")
+	}
+}
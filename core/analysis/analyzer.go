@@ -88,7 +88,7 @@ func (a *AnalyzerImpl) CreateComponents(fieldName string, reader io.RuneReader)
 
 func (a *AnalyzerImpl) TokenStreamForReader(fieldName string, reader io.RuneReader) (TokenStream, error) {
 	components := a.reuseStrategy.ReusableComponents(a, fieldName)
-	r := a.InitReader(fieldName, reader)
+	r := a.Spi.InitReader(fieldName, reader)
 	if components == nil {
 		panic("not implemented yet")
 	} else {
@@ -108,7 +108,7 @@ func (a *AnalyzerImpl) TokenStreamForString(fieldName, text string) (TokenStream
 		strReader = components.reusableStringReader
 	}
 	strReader.setValue(text)
-	r := a.InitReader(fieldName, strReader)
+	r := a.Spi.InitReader(fieldName, strReader)
 	if components == nil {
 		components = a.Spi.CreateComponents(fieldName, r)
 		a.reuseStrategy.SetReusableComponents(a, fieldName, components)
@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// analysis/util/AnalysisSPILoader.java
+
+/*
+Creates a new Analyzer. Analyzers keep per-use state, so the registry
+holds factories rather than shared instances.
+*/
+type AnalyzerFactory func() Analyzer
+
+var allAnalyzers = util.NewNamedSPILoader("Analyzer")
+
+/*
+Registers an analyzer factory by name, e.g. in the init() function of
+the package providing it, so that the analyzer named in a
+configuration can be resolved with NewAnalyzerByName().
+*/
+func RegisterAnalyzer(name string, factory AnalyzerFactory) {
+	allAnalyzers.Register(name, factory)
+}
+
+/* Creates a new instance of the analyzer registered under name. */
+func NewAnalyzerByName(name string) (Analyzer, error) {
+	factory, err := allAnalyzers.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory.(AnalyzerFactory)(), nil
+}
+
+/* Returns a list of all available analyzer names. */
+func AvailableAnalyzers() []string {
+	return allAnalyzers.AvailableServices()
+}
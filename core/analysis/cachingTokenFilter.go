@@ -36,13 +36,29 @@ func (f *CachingTokenFilter) IncrementToken() (bool, error) {
 	return true, nil
 }
 
-func (f *CachingTokenFilter) Reset() {
+func (f *CachingTokenFilter) End() error {
+	if f.finalState != nil {
+		f.Attributes().RestoreState(f.finalState)
+	}
+	return nil
+}
+
+/*
+Rewinds the iterator to the beginning of the cached list.
+
+Note that this does not call Reset() on the wrapped tokenstream ever,
+even the first time. You should Reset() the inner tokenstream before
+wrapping it with CachingTokenFilter.
+*/
+func (f *CachingTokenFilter) Reset() error {
 	if f.cache != nil {
 		f.cacheIdx = 0
 	}
+	return nil
 }
 
 func (f *CachingTokenFilter) fillCache() error {
+	f.cache = make([]*util.AttributeState, 0)
 	ok, err := f.input.IncrementToken()
 	for ok && err == nil {
 		f.cache = append(f.cache, f.Attributes().CaptureState())
@@ -17,6 +17,10 @@ type CharTermAttribute interface {
 	// NOTE: the returned buffer may be larger than the valid Length().
 	Buffer() []rune
 	Length() int
+	// Set number of valid characters (length of the term) in the
+	// termBuffer slice. Use this to truncate the termBuffer or to
+	// synchronize with external manipulation of the termBuffer.
+	SetLength(int) CharTermAttribute
 	// Appends teh specified string to this character sequence.
 	//
 	// The character of the string argument are appended, in order,
@@ -76,6 +80,13 @@ func (a *CharTermAttributeImpl) Length() int {
 	return a.termLength
 }
 
+func (a *CharTermAttributeImpl) SetLength(length int) CharTermAttribute {
+	assert2(length <= len(a.termBuffer), "length %v exceeds the size of the termBuffer (%v)",
+		length, len(a.termBuffer))
+	a.termLength = length
+	return a
+}
+
 func (a *CharTermAttributeImpl) AppendString(s string) CharTermAttribute {
 	if s == "" { // needed for Appendable compliance
 		return a.appendNil()
@@ -44,6 +44,15 @@ func (a *PackedTokenAttributeImpl) PositionIncrement() int {
 	return a.positionIncrement
 }
 
+func (a *PackedTokenAttributeImpl) SetPositionLength(positionLength int) {
+	assert2(positionLength >= 1, "Position length must be 1 or greater: got %v", positionLength)
+	a.positionLength = positionLength
+}
+
+func (a *PackedTokenAttributeImpl) PositionLength() int {
+	return a.positionLength
+}
+
 func (a *PackedTokenAttributeImpl) StartOffset() int {
 	return a.startOffset
 }
@@ -60,6 +69,10 @@ func (a *PackedTokenAttributeImpl) SetOffset(startOffset, endOffset int) {
 	a.endOffset = endOffset
 }
 
+func (a *PackedTokenAttributeImpl) Type() string {
+	return a.typ
+}
+
 func (a *PackedTokenAttributeImpl) SetType(typ string) {
 	a.typ = typ
 }
@@ -4,7 +4,20 @@ import (
 	"github.com/balzaczyy/golucene/core/util"
 )
 
+/*
+Determines how many positions this token spans. Very few analyzer
+components actually produce this attribute, and indexing ignores it,
+but it's useful to express the graph structure naturally produced by
+decompounding, word splitting/joining, synonym filtering, etc.
+
+NOTE: this is optional, and most analyzers don't change the default
+value (1).
+*/
 type PositionLengthAttribute interface {
 	util.Attribute
+	// Set the position length of this Token.
+	// The default value is one.
 	SetPositionLength(int)
+	// Returns the position length of this Token.
+	PositionLength() int
 }
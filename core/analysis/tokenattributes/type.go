@@ -9,6 +9,8 @@ const DEFAULT_TYPE = "word"
 /* A Token's lexical type. The default value is "word". */
 type TypeAttribute interface {
 	util.Attribute
+	// Returns this Token's lexical type. Defaults to "word".
+	Type() string
 	// Set the lexical type.
 	SetType(string)
 }
@@ -28,6 +30,10 @@ func (a *TypeAttributeImpl) Interfaces() []string {
 	return []string{"TypeAttribute"}
 }
 
+func (a *TypeAttributeImpl) Type() string {
+	return a.typ
+}
+
 func (a *TypeAttributeImpl) SetType(typ string) {
 	a.typ = typ
 }
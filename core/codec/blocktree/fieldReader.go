@@ -3,6 +3,7 @@ package blocktree
 import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/automaton"
 	"github.com/balzaczyy/golucene/core/util/fst"
 )
 
@@ -64,10 +65,29 @@ func newFieldReader(parent *BlockTreeTermsReader,
 	return r, err
 }
 
+/* Returns the memory used by the terms index of the field. */
+func (r *FieldReader) RamBytesUsed() int64 {
+	if r.index == nil {
+		return 0
+	}
+	return r.index.RamBytesUsed()
+}
+
 func (r *FieldReader) Iterator(reuse TermsEnum) TermsEnum {
 	return newSegmentTermsEnum(r)
 }
 
+/*
+Intersects the terms with the automaton, skipping the blocks whose
+prefix the automaton rejects.
+*/
+func (r *FieldReader) Intersect(compiled *automaton.CompiledAutomaton, startTerm []byte) TermsEnum {
+	if compiled.Type == automaton.AUTOMATON_TYPE_NONE {
+		return EMPTY_TERMS_ENUM
+	}
+	return newIntersectTermsEnum(r, compiled, startTerm)
+}
+
 func (r *FieldReader) SumTotalTermFreq() int64 {
 	return r.sumTotalTermFreq
 }
@@ -79,3 +99,18 @@ func (r *FieldReader) SumDocFreq() int64 {
 func (r *FieldReader) DocCount() int {
 	return int(r.docCount)
 }
+
+/* Returns the number of terms of the field in this segment. */
+func (r *FieldReader) Size() int64 {
+	return r.numTerms
+}
+
+/* Returns the smallest term of the field, or nil if not recorded. */
+func (r *FieldReader) Min() []byte {
+	return r.minTerm
+}
+
+/* Returns the largest term of the field, or nil if not recorded. */
+func (r *FieldReader) Max() []byte {
+	return r.maxTerm
+}
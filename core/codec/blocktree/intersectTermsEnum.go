@@ -0,0 +1,184 @@
+package blocktree
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	"github.com/balzaczyy/golucene/core/util/fst"
+)
+
+// blocktree/IntersectTermsEnum.java
+
+/*
+This is used to implement efficient Terms.Intersect() for block-tree.
+Note that it cannot seek, except for the initial term on init. It
+just "nexts" through the intersection of the automaton and the terms.
+
+All terms of a block share the block's prefix, so the automaton is
+run once over the prefix when the block is reached as a sub-block of
+its parent; if the automaton rejects the prefix, or all terms of the
+block sort before the start term, the whole block is skipped without
+being loaded.
+*/
+type intersectTermsEnum struct {
+	*SegmentTermsEnum
+	runAutomaton *automaton.ByteRunAutomaton
+	startTerm    []byte
+	// Automaton state after the prefix of the frame, by frame ord
+	states []int
+}
+
+func newIntersectTermsEnum(r *FieldReader, compiled *automaton.CompiledAutomaton, startTerm []byte) *intersectTermsEnum {
+	assert(compiled.RunAutomaton != nil)
+	return &intersectTermsEnum{
+		SegmentTermsEnum: newSegmentTermsEnum(r),
+		runAutomaton:     compiled.RunAutomaton,
+		startTerm:        startTerm,
+	}
+}
+
+func (e *intersectTermsEnum) setState(ord, state int) {
+	for len(e.states) <= ord {
+		e.states = append(e.states, -1)
+	}
+	e.states[ord] = state
+}
+
+/* Runs the automaton from the state over the bytes; -1 if rejected. */
+func (e *intersectTermsEnum) step(state int, suffix []byte) int {
+	for _, b := range suffix {
+		if state = e.runAutomaton.Step(state, int(b)); state == -1 {
+			break
+		}
+	}
+	return state
+}
+
+/* Returns true if all terms starting with the prefix are <= startTerm. */
+func (e *intersectTermsEnum) beforeStart(prefix []byte) bool {
+	if e.startTerm == nil {
+		return false
+	}
+	if len(prefix) <= len(e.startTerm) {
+		return bytes.Compare(prefix, e.startTerm[:len(prefix)]) < 0
+	}
+	return bytes.Compare(prefix, e.startTerm) < 0
+}
+
+func (e *intersectTermsEnum) Next() ([]byte, error) {
+	for {
+		term, err := e.nextTerm()
+		if term == nil || err != nil {
+			return nil, err
+		}
+		f := e.currentFrame
+		state := e.step(e.states[f.ord], term[f.prefix:])
+		if state != -1 && e.runAutomaton.IsAccept(state) &&
+			(e.startTerm == nil || bytes.Compare(term, e.startTerm) > 0) {
+			return term, nil
+		}
+	}
+}
+
+/*
+Like SegmentTermsEnum.Next(), except that sub-blocks the automaton
+cannot accept are skipped instead of pushed.
+*/
+func (e *intersectTermsEnum) nextTerm() (buf []byte, err error) {
+	if e.eof {
+		return nil, nil
+	}
+	if e.in == nil {
+		// Fresh TermsEnum; load the root block:
+		var arc *fst.Arc
+		if e.fr.index != nil {
+			arc = e.fr.index.FirstArc(e.arcs[0])
+			// Empty string prefix must have an output in the index!
+			assert(arc.IsFinal())
+		}
+		if e.currentFrame, err = e.pushFrame(arc, e.fr.rootCode, 0); err != nil {
+			return nil, err
+		}
+		if err = e.currentFrame.loadBlock(); err != nil {
+			return nil, err
+		}
+		e.setState(e.currentFrame.ord, e.runAutomaton.InitialState())
+	}
+
+	e.targetBeforeCurrentLength = e.currentFrame.ord
+
+	for {
+		// Pop finished blocks
+		for e.currentFrame.nextEnt == e.currentFrame.entCount {
+			if !e.currentFrame.isLastInFloor {
+				if err = e.currentFrame.loadNextFloorBlock(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if e.currentFrame.ord == 0 {
+				e.eof = true
+				e.term.SetLength(0)
+				e.validIndexPrefix = 0
+				e.currentFrame.rewind()
+				e.termExists = false
+				return nil, nil
+			}
+			lastFP := e.currentFrame.fpOrig
+			e.currentFrame = e.stack[e.currentFrame.ord-1]
+			if e.currentFrame.nextEnt == -1 || e.currentFrame.lastSubFP != lastFP {
+				// We popped into a frame that's not loaded yet or not
+				// scan'd to the right entry
+				e.currentFrame.scanToFloorFrame(e.term.Get().ToBytes())
+				if err = e.currentFrame.loadBlock(); err != nil {
+					return nil, err
+				}
+				e.currentFrame.scanToSubBlock(lastFP)
+			}
+		}
+
+		if !e.currentFrame.next() {
+			return e.Term(), nil
+		}
+
+		// A sub-block: all its terms start with the current term
+		f := e.currentFrame
+		prefix := e.term.Bytes()[:e.term.Length()]
+		state := e.step(e.states[f.ord], prefix[f.prefix:])
+		if state == -1 || e.beforeStart(prefix) {
+			continue // skip the whole block
+		}
+		if e.currentFrame, err = e.pushFrameAt(nil, f.lastSubFP, e.term.Length()); err != nil {
+			return nil, err
+		}
+		// This is a "next" frame -- even if it's floor'd we must
+		// pretend it isn't so we don't try to scan to the right
+		// floor frame:
+		e.currentFrame.isFloor = false
+		if err = e.currentFrame.loadBlock(); err != nil {
+			return nil, err
+		}
+		e.setState(e.currentFrame.ord, state)
+	}
+}
+
+func (e *intersectTermsEnum) SeekExact(text []byte) (bool, error) {
+	panic("not supported")
+}
+
+func (e *intersectTermsEnum) SeekCeil(text []byte) SeekStatus {
+	panic("not supported")
+}
+
+func (e *intersectTermsEnum) SeekExactByPosition(ord int64) error {
+	panic("not supported")
+}
+
+func (e *intersectTermsEnum) SeekExactFromLast(text []byte, state TermState) error {
+	panic("not supported")
+}
+
+func (e *intersectTermsEnum) String() string {
+	return fmt.Sprintf("IntersectTermsEnum(%v)", e.fr.fieldInfo.Name)
+}
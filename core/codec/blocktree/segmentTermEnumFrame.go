@@ -144,7 +144,7 @@ func (f *segmentTermsEnumFrame) loadBlock() (err error) {
 	assert(f.entCount > 0)
 	f.isLastInFloor = (code & 1) != 0
 
-	assert2(f.arc == nil || !f.isLastInFloor || !f.isFloor,
+	assert2(f.arc == nil || f.isLastInFloor || f.isFloor,
 		"fp=%v arc=%v isFloor=%v isLastInFloor=%v",
 		f.fp, f.arc, f.isFloor, f.isLastInFloor)
 
@@ -241,13 +241,56 @@ func (f *segmentTermsEnumFrame) next() bool {
 	return f.nextNonLeaf()
 }
 
+func (f *segmentTermsEnumFrame) loadNextFloorBlock() error {
+	// fmt.Printf("    loadNextFloorBlock fp=%v fpEnd=%v\n", f.fp, f.fpEnd)
+	assert2(f.arc == nil || f.isFloor, "arc=%v isFloor=%v", f.arc, f.isFloor)
+	f.fp = f.fpEnd
+	f.nextEnt = -1
+	return f.loadBlock()
+}
+
 // Decodes next entry; returns true if it's a sub-block
 func (f *segmentTermsEnumFrame) nextLeaf() bool {
-	panic("not implemented yet")
+	// fmt.Printf("  frame.next ord=%v nextEnt=%v entCount=%v\n", f.ord, f.nextEnt, f.entCount)
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	f.suffix, _ = asInt(f.suffixesReader.ReadVInt())
+	f.readSuffix()
+	f.ste.termExists = true
+	return false
 }
 
 func (f *segmentTermsEnumFrame) nextNonLeaf() bool {
-	panic("not implemented yet")
+	// fmt.Printf("  frame.next ord=%v nextEnt=%v entCount=%v\n", f.ord, f.nextEnt, f.entCount)
+	assert2(f.nextEnt != -1 && f.nextEnt < f.entCount,
+		"nextEnt=%v entCount=%v fp=%v", f.nextEnt, f.entCount, f.fp)
+	f.nextEnt++
+	code, _ := asInt(f.suffixesReader.ReadVInt())
+	f.suffix = int(uint(code) >> 1)
+	f.readSuffix()
+	if (code & 1) == 0 {
+		// A normal term
+		f.ste.termExists = true
+		f.subCode = 0
+		f.state.TermBlockOrd++
+		return false
+	}
+	// A sub-block; make sub-FP absolute:
+	f.ste.termExists = false
+	f.subCode, _ = f.suffixesReader.ReadVLong()
+	f.lastSubFP = f.fp - f.subCode
+	// fmt.Printf("    lastSubFP=%v\n", f.lastSubFP)
+	return true
+}
+
+/* Appends the suffix of the current entry to the prefix of the term. */
+func (f *segmentTermsEnumFrame) readSuffix() {
+	f.startBytePos = f.suffixesReader.Pos
+	length := f.prefix + f.suffix
+	f.ste.term.Grow(length)
+	f.ste.term.SetLength(length)
+	f.suffixesReader.ReadBytes(f.ste.term.Bytes()[f.prefix:length])
 }
 
 // TODO: make this array'd so we can do bin search?
@@ -261,10 +304,10 @@ func (f *segmentTermsEnumFrame) scanToFloorFrame(target []byte) {
 	}
 
 	targetLabel := int(target[f.prefix])
-	fmt.Printf("    scanToFloorFrame fpOrig=%v targetLabel=%x vs nextFloorLabel=%x numFollowFloorBlocks=%v\n",
-		f.fpOrig, targetLabel, f.nextFloorLabel, f.numFollowFloorBlocks)
+	// fmt.Printf("    scanToFloorFrame fpOrig=%v targetLabel=%x vs nextFloorLabel=%x numFollowFloorBlocks=%v\n",
+	// 	f.fpOrig, targetLabel, f.nextFloorLabel, f.numFollowFloorBlocks)
 	if targetLabel < f.nextFloorLabel {
-		fmt.Println("      already on correct block")
+		// fmt.Println("      already on correct block")
 		return
 	}
 
@@ -283,16 +326,22 @@ func (f *segmentTermsEnumFrame) scanToFloorFrame(target []byte) {
 
 		if f.isLastInFloor {
 			f.nextFloorLabel = 256
-			fmt.Printf("        stop!  last block nextFloorLabel=%x\n", f.nextFloorLabel)
+			// fmt.Printf("        stop!  last block nextFloorLabel=%x\n", f.nextFloorLabel)
 			break
 		} else {
-			panic("niy")
+			b, _ := f.floorDataReader.ReadByte()
+			f.nextFloorLabel = int(b)
+			// fmt.Printf("        label=%x\n", f.nextFloorLabel)
+			if targetLabel < f.nextFloorLabel {
+				// fmt.Println("        stop!")
+				break
+			}
 		}
 	}
 
 	if newFP != f.fp {
 		// Force re-load of the block:
-		fmt.Printf("      force switch to fp=%v oldFP=%v\n", newFP, f.fp)
+		// fmt.Printf("      force switch to fp=%v oldFP=%v\n", newFP, f.fp)
 		f.nextEnt = -1
 		f.fp = newFP
 	} else {
@@ -300,6 +349,34 @@ func (f *segmentTermsEnumFrame) scanToFloorFrame(target []byte) {
 	}
 }
 
+// Used only by next(); advances to the sub-block whose fp is subFP
+func (f *segmentTermsEnumFrame) scanToSubBlock(subFP int64) {
+	assert(!f.isLeafBlock)
+	// fmt.Printf("  scanToSubBlock fp=%v subFP=%v entCount=%v lastSubFP=%v\n",
+	// 	f.fp, subFP, f.entCount, f.lastSubFP)
+	if f.lastSubFP == subFP {
+		// fmt.Println("    already positioned")
+		return
+	}
+	assert2(subFP < f.fp, "fp=%v subFP=%v", f.fp, subFP)
+	targetSubCode := f.fp - subFP
+	for {
+		assert(f.nextEnt < f.entCount)
+		f.nextEnt++
+		code, _ := asInt(f.suffixesReader.ReadVInt())
+		f.suffixesReader.SkipBytes(int64(uint(code) >> 1))
+		if (code & 1) != 0 {
+			subCode, _ := f.suffixesReader.ReadVLong()
+			if targetSubCode == subCode {
+				f.lastSubFP = subFP
+				return
+			}
+		} else {
+			f.state.TermBlockOrd++
+		}
+	}
+}
+
 func (f *segmentTermsEnumFrame) decodeMetaData() (err error) {
 	// fmt.Printf("BTTR.decodeMetadata seg=%v mdUpto=%v vs termBlockOrd=%v\n",
 	// 	f.ste.fr.parent.segment, f.metaDataUpto, f.state.TermBlockOrd)
@@ -481,7 +558,7 @@ func (f *segmentTermsEnumFrame) scanToTermLeaf(target []byte, exactOnly bool) (s
 	// to the foo* block, but the last term in this block
 	// was fooz (and, eg, first term in the next block will
 	// bee fop).
-	fmt.Println("      block end")
+	// fmt.Println("      block end")
 	if exactOnly {
 		f.fillTerm()
 	}
@@ -497,14 +574,18 @@ func (f *segmentTermsEnumFrame) scanToTermLeaf(target []byte, exactOnly bool) (s
 func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 	exactOnly bool) (status SeekStatus, err error) {
 
-	fmt.Printf(
-		"    scanToTermNonLeaf: block fp=%v prefix=%v nextEnt=%v (of %v) target=%v term=%v",
-		f.fp, f.prefix, f.nextEnt, f.entCount, brToString(target), "" /*brToString(term)*/)
+	// fmt.Printf(
+	// 	"    scanToTermNonLeaf: block fp=%v prefix=%v nextEnt=%v (of %v) target=%v term=%v",
+	// 	f.fp, f.prefix, f.nextEnt, f.entCount, brToString(target), "" /*brToString(term)*/)
 
 	assert(f.nextEnt != -1)
 
 	if f.nextEnt == f.entCount {
-		panic("not implemented yet")
+		if exactOnly {
+			f.fillTerm()
+			f.ste.termExists = f.subCode == 0
+		}
+		return SEEK_STATUS_END, nil
 	}
 
 	assert(f.prefixMatches(target))
@@ -574,7 +655,7 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 					panic("niy")
 				}
 
-				fmt.Println("        not found")
+				// fmt.Println("        not found")
 				return SEEK_STATUS_NOT_FOUND, nil
 			} else if stop {
 				// Exact match!
@@ -584,7 +665,7 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 
 				assert(f.ste.termExists)
 				f.fillTerm()
-				fmt.Println("        found!")
+				// fmt.Println("        found!")
 				return SEEK_STATUS_FOUND, nil
 			}
 		}
@@ -603,7 +684,7 @@ func (f *segmentTermsEnumFrame) scanToTermNonLeaf(target []byte,
 	// E.g., target could be foozzz, and terms index pointed us to the
 	// foo* block, but the last term in this block was fooz (and, e.g.,
 	// first term in the next block will be fop).
-	fmt.Println("      block end")
+	// fmt.Println("      block end")
 	if exactOnly {
 		f.fillTerm()
 	}
@@ -8,6 +8,8 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sort"
 )
 
 // BlockTreeTermsReader.java
@@ -334,6 +336,35 @@ func (r *BlockTreeTermsReader) Terms(field string) Terms {
 	return &ans
 }
 
+/* Returns the memory used by the terms index of each field, and by the postings reader. */
+func (r *BlockTreeTermsReader) RamBytesUsed() int64 {
+	size := util.ShallowSizeOfInstance(reflect.TypeOf(r))
+	if a, ok := r.postingsReader.(util.Accountable); ok {
+		size += a.RamBytesUsed()
+	}
+	for _, field := range r.fields {
+		size += field.RamBytesUsed()
+	}
+	return size
+}
+
+func (r *BlockTreeTermsReader) ChildResources() []util.Accountable {
+	names := make([]string, 0, len(r.fields))
+	for name := range r.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var ans []util.Accountable
+	for _, name := range names {
+		field := r.fields[name]
+		ans = append(ans, util.NamedAccountableOf(fmt.Sprintf("field '%v'", name), &field))
+	}
+	if a, ok := r.postingsReader.(util.Accountable); ok {
+		ans = append(ans, util.NamedAccountableOf("delegate", a))
+	}
+	return ans
+}
+
 func (r *BlockTreeTermsReader) Close() error {
 	defer func() {
 		// Clear so refs to terms index is GCable even if
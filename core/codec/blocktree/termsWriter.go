@@ -468,7 +468,7 @@ func (w *TermsWriter) writeBlocks(prefixLength, count int) (err error) {
 		}
 
 		if suffixLeadLabel != lastSuffixLeadLabel {
-			if itemsInBlock := i + count - nextBlockStart; itemsInBlock >= w.owner.minItemsInBlock &&
+			if itemsInBlock := start + i - nextBlockStart; itemsInBlock >= w.owner.minItemsInBlock &&
 				end-nextBlockStart > w.owner.maxItemsInBlock {
 				// The count is too large for one block, so we must break
 				// it into "floor" blocks, where we record the leading
@@ -481,7 +481,7 @@ func (w *TermsWriter) writeBlocks(prefixLength, count int) (err error) {
 				isFloor := itemsInBlock < count
 				var block *PendingBlock
 				if block, err = w.writeBlock(prefixLength, isFloor,
-					nextFloorLeadLabel, nextBlockStart, i+count, hasTerms,
+					nextFloorLeadLabel, nextBlockStart, start+i, hasTerms,
 					hasSubBlocks); err != nil {
 					return
 				}
@@ -490,7 +490,7 @@ func (w *TermsWriter) writeBlocks(prefixLength, count int) (err error) {
 				hasTerms = false
 				hasSubBlocks = false
 				nextFloorLeadLabel = suffixLeadLabel
-				nextBlockStart = i + count
+				nextBlockStart = start + i
 			}
 
 			lastSuffixLeadLabel = suffixLeadLabel
@@ -7,6 +7,7 @@ import (
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
 	"github.com/balzaczyy/golucene/core/util/packed"
+	"reflect"
 )
 
 // codec/compressing/CompressingStoredFieldsIndexReader.java
@@ -155,6 +156,23 @@ func (r *CompressingStoredFieldsIndexReader) startPointer(docID int) int64 {
 	return r.startPointers[block] + r.relativeStartPointer(block, relativeChunk)
 }
 
+func (r *CompressingStoredFieldsIndexReader) RamBytesUsed() int64 {
+	res := util.ShallowSizeOfInstance(reflect.TypeOf(r))
+	res += util.ShallowSizeOf(r.docBasesDeltas)
+	for _, d := range r.docBasesDeltas {
+		res += d.RamBytesUsed()
+	}
+	res += util.ShallowSizeOf(r.startPointersDeltas)
+	for _, d := range r.startPointersDeltas {
+		res += d.RamBytesUsed()
+	}
+	res += util.ShallowSizeOf(r.docBases)
+	res += util.ShallowSizeOf(r.startPointers)
+	res += util.ShallowSizeOf(r.avgChunkDocs)
+	res += util.ShallowSizeOf(r.avgChunkSizes)
+	return res
+}
+
 func (r *CompressingStoredFieldsIndexReader) Clone() *CompressingStoredFieldsIndexReader {
 	return r
 }
@@ -169,6 +169,15 @@ func (r *CompressingStoredFieldsReader) ensureOpen() {
 }
 
 // Close the underlying IndexInputs
+/* Returns the memory used by the index of the chunks of documents. */
+func (r *CompressingStoredFieldsReader) RamBytesUsed() int64 {
+	return r.indexReader.RamBytesUsed()
+}
+
+func (r *CompressingStoredFieldsReader) ChildResources() []util.Accountable {
+	return []util.Accountable{util.NamedAccountableOf("stored field index", r.indexReader)}
+}
+
 func (r *CompressingStoredFieldsReader) Close() (err error) {
 	if !r.closed {
 		if err = util.Close(r.fieldsStream); err == nil {
@@ -182,7 +191,15 @@ func (r *CompressingStoredFieldsReader) readField(in util.DataInput,
 	visitor StoredFieldVisitor, info *model.FieldInfo, bits int) (err error) {
 	switch bits & TYPE_MASK {
 	case BYTE_ARR:
-		panic("not implemented yet")
+		var length int
+		if length, err = int32AsInt(in.ReadVInt()); err != nil {
+			return err
+		}
+		data := make([]byte, length)
+		if err = in.ReadBytes(data); err != nil {
+			return err
+		}
+		err = visitor.BinaryField(info, data)
 	case STRING:
 		var length int
 		if length, err = int32AsInt(in.ReadVInt()); err != nil {
@@ -192,7 +209,7 @@ func (r *CompressingStoredFieldsReader) readField(in util.DataInput,
 		if err = in.ReadBytes(data); err != nil {
 			return err
 		}
-		visitor.StringField(info, string(data))
+		err = visitor.StringField(info, string(data))
 	case NUMERIC_INT:
 		panic("not implemented yet")
 	case NUMERIC_FLOAT:
@@ -204,7 +221,24 @@ func (r *CompressingStoredFieldsReader) readField(in util.DataInput,
 	default:
 		panic(fmt.Sprintf("Unknown type flag: %x", bits))
 	}
-	return nil
+	return
+}
+
+func (r *CompressingStoredFieldsReader) skipField(in util.DataInput, bits int) (err error) {
+	switch bits & TYPE_MASK {
+	case BYTE_ARR, STRING:
+		var length int
+		if length, err = int32AsInt(in.ReadVInt()); err == nil {
+			err = in.ReadBytes(make([]byte, length))
+		}
+	case NUMERIC_INT, NUMERIC_FLOAT:
+		_, err = in.ReadInt()
+	case NUMERIC_LONG, NUMERIC_DOUBLE:
+		_, err = in.ReadLong()
+	default:
+		panic(fmt.Sprintf("Unknown type flag: %x", bits))
+	}
+	return
 }
 
 func (r *CompressingStoredFieldsReader) VisitDocument(docID int, visitor StoredFieldVisitor) error {
@@ -342,9 +376,13 @@ func (r *CompressingStoredFieldsReader) VisitDocument(docID int, visitor StoredF
 		}
 		switch status {
 		case STORED_FIELD_VISITOR_STATUS_YES:
-			r.readField(documentInput, visitor, fieldInfo, bits)
+			if err = r.readField(documentInput, visitor, fieldInfo, bits); err != nil {
+				return err
+			}
 		case STORED_FIELD_VISITOR_STATUS_NO:
-			panic("not implemented yet")
+			if err = r.skipField(documentInput, bits); err != nil {
+				return err
+			}
 		case STORED_FIELD_VISITOR_STATUS_STOP:
 			return nil
 		}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/balzaczyy/golucene/core/codec"
 	"github.com/balzaczyy/golucene/core/codec/lucene40"
+	"github.com/balzaczyy/golucene/core/codec/spi"
 	"github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
@@ -382,6 +383,109 @@ func (w *CompressingStoredFieldsWriter) Finish(fis model.FieldInfos, numDocs int
 	return nil
 }
 
+/*
+Merges the stored fields of the segments of the state. Segments
+written with the same format and settings, without deletions and
+with the same field numbers, have their compressed chunks copied as
+is instead of being decompressed and re-compressed document by
+document.
+*/
+func (w *CompressingStoredFieldsWriter) Merge(state *spi.MergeState) (int, error) {
+	docCount := 0
+	for i, reader := range state.StoredFieldsReaders {
+		var n int
+		var err error
+		if r, ok := reader.(*CompressingStoredFieldsReader); ok &&
+			state.LiveDocs[i] == nil && w.canBulkCopy(r, state.FieldInfos) {
+			n, err = w.copyChunks(r)
+		} else {
+			n, err = spi.CopyStoredFields(w, state, i)
+		}
+		if err != nil {
+			return 0, err
+		}
+		docCount += n
+	}
+	return docCount, w.Finish(state.FieldInfos, docCount)
+}
+
+/*
+Returns true if the chunks of the reader can be copied as is, i.e.
+they were written in the current format, with the same compression
+mode and chunk size, and the numbers of their fields are unchanged
+in the merged field infos.
+*/
+func (w *CompressingStoredFieldsWriter) canBulkCopy(r *CompressingStoredFieldsReader,
+	fis model.FieldInfos) bool {
+
+	if r.version != VERSION_CURRENT ||
+		r.compressionMode != w.compressionMode ||
+		r.chunkSize != w.chunkSize ||
+		r.packedIntsVersion != packed.VERSION_CURRENT {
+		return false
+	}
+	for _, fi := range r.fieldInfos.Values {
+		if merged := fis.FieldInfoByName(fi.Name); merged == nil || merged.Number != fi.Number {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Copies the chunks of the reader to the end of the fields stream,
+only rewriting the doc base of each of them, and returns the number
+of documents copied.
+*/
+func (w *CompressingStoredFieldsWriter) copyChunks(r *CompressingStoredFieldsReader) (int, error) {
+	if w.numBufferedDocs > 0 {
+		if err := w.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if r.numDocs == 0 {
+		return 0, nil
+	}
+	in := r.fieldsStream.Clone()
+	if err := in.Seek(r.indexReader.startPointer(0)); err != nil {
+		return 0, err
+	}
+	for docID := 0; docID < r.numDocs; {
+		base, err := int32AsInt(in.ReadVInt())
+		if err != nil {
+			return 0, err
+		}
+		chunkDocs, err := int32AsInt(in.ReadVInt())
+		if err != nil {
+			return 0, err
+		}
+		if base != docID || chunkDocs <= 0 || base+chunkDocs > r.numDocs {
+			return 0, errors.New(fmt.Sprintf(
+				"Corrupted: expected docBase=%v, got docBase=%v, chunkDocs=%v, numDocs=%v (resource=%v)",
+				docID, base, chunkDocs, r.numDocs, in))
+		}
+		if err = w.indexWriter.writeIndex(chunkDocs, w.fieldsStream.FilePointer()); err != nil {
+			return 0, err
+		}
+		if err = w.fieldsStream.WriteVInt(int32(w.docBase)); err != nil {
+			return 0, err
+		}
+		if err = w.fieldsStream.WriteVInt(int32(chunkDocs)); err != nil {
+			return 0, err
+		}
+		docID += chunkDocs
+		w.docBase += chunkDocs
+		end := r.maxPointer
+		if docID < r.numDocs {
+			end = r.indexReader.startPointer(docID)
+		}
+		if err = w.fieldsStream.CopyBytes(in, end-in.FilePointer()); err != nil {
+			return 0, err
+		}
+	}
+	return r.numDocs, nil
+}
+
 // util/GrowableByteArrayDataOutput.java
 
 /* A DataOutput that can be used to build a []byte */
@@ -15,6 +15,9 @@ const (
 	/* Change DGaps to encode gaps between cleared bits, not set: */
 	BV_VERSION_DGAPS_CLEARED = 1
 
+	/* Oldest version which can be read; pre-4.0 formats are not supported. */
+	BV_VERSION_START = BV_VERSION_DGAPS_CLEARED
+
 	BV_VERSION_CHECKSUM = 2
 
 	/* Imcrement version to change it: */
@@ -138,6 +141,17 @@ func (bv *BitVector) InvertAll() {
 		for idx, v := range bv.bits {
 			bv.bits[idx] = byte(^v)
 		}
+		bv.clearUnusedBits()
+	}
+}
+
+/* Clears the bits of the last byte past the size of this vector. */
+func (bv *BitVector) clearUnusedBits() {
+	// Take care not to invert the "unused" bits in the last byte:
+	if len(bv.bits) > 0 {
+		if lastNBits := bv.size & 7; lastNBits != 0 {
+			bv.bits[len(bv.bits)-1] &= byte(1<<uint(lastNBits)) - 1
+		}
 	}
 }
 
@@ -188,7 +202,151 @@ Indicates if the bit vector is sparse and should be saved as a d-gaps
 list, or dense, and should be saved as a bit set.
 */
 func (bv *BitVector) isSparse() bool {
-	panic("not implemented yet")
+	clearedCount := bv.size - bv.Count()
+	if clearedCount == 0 {
+		return true
+	}
+
+	avgGapLength := len(bv.bits) / clearedCount
+
+	// expected number of bytes for vint encoding of each gap
+	var expectedDGapBytes int
+	switch {
+	case avgGapLength <= (1 << 7):
+		expectedDGapBytes = 1
+	case avgGapLength <= (1 << 14):
+		expectedDGapBytes = 2
+	case avgGapLength <= (1 << 21):
+		expectedDGapBytes = 3
+	case avgGapLength <= (1 << 28):
+		expectedDGapBytes = 4
+	default:
+		expectedDGapBytes = 5
+	}
+
+	// +1 because we write the byte itself that contains the set bit
+	bytesPerSetBit := expectedDGapBytes + 1
+
+	// note: adding 32 because we start with -1 to indicate d-gaps
+	// format.
+	expectedBits := int64(32 + 8*bytesPerSetBit*clearedCount)
+
+	// note: factor is for read/write of byte-arrays being faster than
+	// vints.
+	const factor = 10
+	return factor*expectedBits < int64(bv.size)
+}
+
+/*
+Reads a vector written by Write() from the file name in Directory d.
+*/
+func ReadBitVector(d store.Directory, name string, ctx store.IOContext) (bv *BitVector, err error) {
+	var input store.ChecksumIndexInput
+	if input, err = d.OpenChecksumInput(name, ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = mergeError(err, input.Close())
+	}()
+
+	var firstInt int32
+	if firstInt, err = input.ReadInt(); err != nil {
+		return nil, err
+	}
+	if firstInt != -2 {
+		return nil, errors.New(fmt.Sprintf(
+			"pre-4.0 deleted docs are not supported (resource=%v)", input))
+	}
+	var version int32
+	if version, err = codec.CheckHeader(input, CODEC, BV_VERSION_START, BV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	var size int32
+	if size, err = input.ReadInt(); err != nil {
+		return nil, err
+	}
+	bv = new(BitVector)
+	if size == -1 {
+		err = bv.readClearedDgaps(input)
+	} else {
+		bv.size = int(size)
+		err = bv.readBits(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version >= BV_VERSION_CHECKSUM {
+		_, err = codec.CheckFooter(input)
+	} else {
+		err = codec.CheckEOF(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	countSav := bv.count
+	if bv.count = -1; countSav != bv.Count() {
+		return nil, errors.New(fmt.Sprintf(
+			"saved count was %v but recomputed count is %v (resource=%v)", countSav, bv.count, input))
+	}
+	return bv, nil
+}
+
+/* Checks the size and count read from the file, before allocating the bits. */
+func (bv *BitVector) readSizeAndCount(input store.IndexInput) error {
+	count, err := input.ReadInt()
+	if err != nil {
+		return err
+	}
+	if bv.size < 0 || count < 0 || int(count) > bv.size {
+		return errors.New(fmt.Sprintf("invalid size=%v count=%v (resource=%v)", bv.size, count, input))
+	}
+	bv.count = int(count)
+	return nil
+}
+
+/* Read as a bit set */
+func (bv *BitVector) readBits(input store.IndexInput) error {
+	if err := bv.readSizeAndCount(input); err != nil {
+		return err
+	}
+	n := numBytes(bv.size)
+	if int64(n) > input.Length()-input.FilePointer() {
+		return errors.New(fmt.Sprintf("%v bytes of bits past the end of file (resource=%v)", n, input))
+	}
+	bv.bits = make([]byte, n)
+	return input.ReadBytes(bv.bits)
+}
+
+/* Read as a d-gaps cleared bits list */
+func (bv *BitVector) readClearedDgaps(input store.IndexInput) error {
+	size, err := input.ReadInt()
+	if err != nil {
+		return err
+	}
+	bv.size = int(size)
+	if err = bv.readSizeAndCount(input); err != nil {
+		return err
+	}
+	bv.bits = make([]byte, numBytes(bv.size))
+	for i := range bv.bits {
+		bv.bits[i] = 0xff
+	}
+	bv.clearUnusedBits()
+	last, numCleared := 0, bv.size-bv.count
+	for numCleared > 0 {
+		gap, err := input.ReadVInt()
+		if err != nil {
+			return err
+		}
+		if last += int(gap); gap < 0 || last >= len(bv.bits) {
+			return errors.New(fmt.Sprintf("invalid d-gap %v (resource=%v)", gap, input))
+		}
+		if bv.bits[last], err = input.ReadByte(); err != nil {
+			return err
+		}
+		numCleared -= 8 - util.BitCount(bv.bits[last])
+	}
+	return nil
 }
 
 func (bv *BitVector) assertCount() {
@@ -0,0 +1,44 @@
+package lucene41
+
+import (
+	"errors"
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+/* IndexInput failing every vInt read. */
+type failingInput struct {
+	store.IndexInput
+}
+
+func (in *failingInput) ReadVInt() (int32, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestBlockDocsEnumAdvanceError(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	out, err := dir.CreateOutput("_0.doc", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in, err := dir.OpenInput("_0.doc", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	de := &blockDocsEnum{
+		docDeltaBuffer: make([]int, MAX_DATA_SIZE),
+		freqBuffer:     make([]int, MAX_DATA_SIZE),
+		docIn:          &failingInput{in},
+		indexHasFreq:   true,
+		docFreq:        3,
+		docBufferUpto:  LUCENE41_BLOCK_SIZE,
+	}
+	if _, err = de.Advance(1); err == nil || err.Error() != "read failed" {
+		t.Errorf("Advance should report the read error, but got %v", err)
+	}
+}
@@ -0,0 +1,378 @@
+package lucene410
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec/compressing"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
+	"sync"
+)
+
+// lucene410/Lucene410DocValuesConsumer.java#addBinaryField
+
+/*
+Encodings of binary doc values. The encoding is chosen per field by
+the writer:
+
+- BINARY_FIXED_UNCOMPRESSED: all values have the same length and
+are stored verbatim; the address of a value is computed. Selected
+automatically instead of BINARY_VARIABLE_UNCOMPRESSED when possible,
+and the other way around when the lengths differ.
+- BINARY_VARIABLE_UNCOMPRESSED: values are stored verbatim, with a
+packed address per document.
+- BINARY_PREFIX_COMPRESSED: values are grouped into blocks of
+INTERVAL_COUNT documents; the first value of a block is stored in
+full, the others as the suffix after the prefix shared with the
+previous value. Good for keys, paths and other values which tend to
+share long prefixes with their neighbours.
+- BINARY_LZ4_COMPRESSED: values are grouped into blocks of
+BLOCK_COUNT documents whose concatenation is compressed with LZ4,
+with a packed address per block. Good for large per-document blobs,
+like vectors or serialized metadata, at the cost of decompressing a
+whole block on lookup.
+
+The encoding of the fields written by Lucene410DocValuesFormat is
+given to NewLucene410DocValuesFormat().
+*/
+const (
+	BINARY_FIXED_UNCOMPRESSED    = 0
+	BINARY_VARIABLE_UNCOMPRESSED = 1
+	BINARY_PREFIX_COMPRESSED     = 2
+	BINARY_LZ4_COMPRESSED        = 3
+)
+
+const (
+	INTERVAL_SHIFT = 4
+	INTERVAL_COUNT = 1 << INTERVAL_SHIFT
+	INTERVAL_MASK  = INTERVAL_COUNT - 1
+
+	BLOCK_SHIFT = 5
+	BLOCK_COUNT = 1 << BLOCK_SHIFT
+	BLOCK_MASK  = BLOCK_COUNT - 1
+)
+
+/*
+Writes the binary doc values returned by iter, one per document (nil
+for documents without value), into data with the requested encoding,
+and their entry into meta. The entry can be read back by
+ReadBinaryField().
+*/
+func WriteBinaryField(meta, data store.IndexOutput, format int,
+	iter func() func() ([]byte, bool)) (err error) {
+
+	count, minLength, maxLength := 0, math.MaxInt32, 0
+	next := iter()
+	for v, ok := next(); ok; v, ok = next() {
+		if len(v) < minLength {
+			minLength = len(v)
+		}
+		if len(v) > maxLength {
+			maxLength = len(v)
+		}
+		count++
+	}
+	if count == 0 {
+		minLength = 0
+	}
+	if format == BINARY_VARIABLE_UNCOMPRESSED && minLength == maxLength {
+		format = BINARY_FIXED_UNCOMPRESSED
+	} else if format == BINARY_FIXED_UNCOMPRESSED && minLength != maxLength {
+		format = BINARY_VARIABLE_UNCOMPRESSED
+	}
+
+	startFP := data.FilePointer()
+	var addresses []int64
+	next = func(next func() ([]byte, bool)) func() ([]byte, bool) {
+		return func() ([]byte, bool) {
+			v, ok := next()
+			if ok && v == nil {
+				v = []byte{} // missing value
+			}
+			return v, ok
+		}
+	}(iter())
+	switch format {
+	case BINARY_FIXED_UNCOMPRESSED:
+		for v, ok := next(); ok; v, ok = next() {
+			if err = data.WriteBytes(v); err != nil {
+				return err
+			}
+		}
+	case BINARY_VARIABLE_UNCOMPRESSED:
+		addresses = append(addresses, 0)
+		for v, ok := next(); ok; v, ok = next() {
+			if err = data.WriteBytes(v); err != nil {
+				return err
+			}
+			addresses = append(addresses, data.FilePointer()-startFP)
+		}
+	case BINARY_PREFIX_COMPRESSED:
+		var last []byte
+		i := 0
+		for v, ok := next(); ok; v, ok = next() {
+			if i&INTERVAL_MASK == 0 {
+				addresses = append(addresses, data.FilePointer()-startFP)
+				err = store.Stream(data).WriteVInt(int32(len(v))).WriteBytes(v).Close()
+			} else {
+				prefix := sharedPrefix(last, v)
+				err = store.Stream(data).WriteVInt(int32(prefix)).
+					WriteVInt(int32(len(v) - prefix)).
+					WriteBytes(v[prefix:]).
+					Close()
+			}
+			if err != nil {
+				return err
+			}
+			last = append(last[:0], v...)
+			i++
+		}
+	case BINARY_LZ4_COMPRESSED:
+		compressor := compressing.COMPRESSION_MODE_FAST.NewCompressor()
+		var lengths []int
+		var buffer []byte
+		flush := func() error {
+			addresses = append(addresses, data.FilePointer()-startFP)
+			for _, l := range lengths {
+				if err := data.WriteVInt(int32(l)); err != nil {
+					return err
+				}
+			}
+			if len(buffer) > 0 {
+				if err := compressor(buffer, data); err != nil {
+					return err
+				}
+			}
+			lengths, buffer = lengths[:0], buffer[:0]
+			return nil
+		}
+		for v, ok := next(); ok; v, ok = next() {
+			lengths = append(lengths, len(v))
+			buffer = append(buffer, v...)
+			if len(lengths) == BLOCK_COUNT {
+				if err = flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if len(lengths) > 0 {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	default:
+		panic(fmt.Sprintf("unknown binary format: %v", format))
+	}
+
+	if err = store.Stream(meta).WriteByte(byte(format)).
+		WriteVInt(int32(count)).
+		WriteVInt(int32(minLength)).
+		WriteVInt(int32(maxLength)).
+		WriteLong(startFP).
+		WriteLong(data.FilePointer() - startFP).
+		Close(); err != nil {
+		return err
+	}
+	if format == BINARY_FIXED_UNCOMPRESSED {
+		return nil
+	}
+
+	// addresses are only needed for random access
+	bitsPerValue := packed.BitsRequired(data.FilePointer() - startFP)
+	if err = store.Stream(meta).WriteVInt(int32(len(addresses))).
+		WriteVInt(int32(bitsPerValue)).
+		WriteLong(data.FilePointer()).
+		Close(); err != nil {
+		return err
+	}
+	writer := packed.WriterNoHeader(data, packed.PackedFormat(packed.PACKED),
+		len(addresses), bitsPerValue, packed.DEFAULT_BUFFER_SIZE)
+	for _, address := range addresses {
+		if err = writer.Add(address); err != nil {
+			return err
+		}
+	}
+	return writer.Finish()
+}
+
+func sharedPrefix(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// lucene410/Lucene410DocValuesProducer.java#getBinary
+
+/*
+Reads the binary entry written by WriteBinaryField() from meta, and
+loads the (possibly compressed) values from data into memory.
+*/
+func ReadBinaryField(meta, data store.IndexInput) (BinaryDocValues, error) {
+	format, err := meta.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var count, minLength, maxLength int32
+	if count, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if minLength, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if maxLength, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	var offset, length int64
+	if offset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if length, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+
+	if err = data.Seek(offset); err != nil {
+		return nil, err
+	}
+	bytes := make([]byte, length)
+	if err = data.ReadBytes(bytes); err != nil {
+		return nil, err
+	}
+
+	if format == BINARY_FIXED_UNCOMPRESSED {
+		if int64(count)*int64(minLength) != length {
+			return nil, errors.New(fmt.Sprintf(
+				"Corrupted: fixed length %v x %v values != %v (resource=%v)",
+				minLength, count, length, data))
+		}
+		return &fixedBinaryDocValues{bytes, int(minLength)}, nil
+	}
+
+	var addressCount, bitsPerValue int32
+	if addressCount, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if bitsPerValue, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	var addressesOffset int64
+	if addressesOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if err = data.Seek(addressesOffset); err != nil {
+		return nil, err
+	}
+	addresses, err := packed.ReaderNoHeader(data, packed.PackedFormat(packed.PACKED),
+		packed.VERSION_CURRENT, addressCount, uint32(bitsPerValue))
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case BINARY_VARIABLE_UNCOMPRESSED:
+		return &variableBinaryDocValues{bytes, addresses}, nil
+	case BINARY_PREFIX_COMPRESSED:
+		return &prefixBinaryDocValues{bytes, addresses, int(maxLength)}, nil
+	case BINARY_LZ4_COMPRESSED:
+		return &lz4BinaryDocValues{
+			bytes:     bytes,
+			addresses: addresses,
+			count:     int(count),
+			block:     -1,
+		}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown binary format: %v (resource=%v)", format, meta))
+}
+
+type fixedBinaryDocValues struct {
+	bytes  []byte
+	length int
+}
+
+func (v *fixedBinaryDocValues) Get(docID int) []byte {
+	start := docID * v.length
+	return v.bytes[start : start+v.length]
+}
+
+type variableBinaryDocValues struct {
+	bytes     []byte
+	addresses packed.PackedIntsReader
+}
+
+func (v *variableBinaryDocValues) Get(docID int) []byte {
+	return v.bytes[v.addresses.Get(docID):v.addresses.Get(docID+1)]
+}
+
+type prefixBinaryDocValues struct {
+	bytes     []byte
+	addresses packed.PackedIntsReader
+	maxLength int
+}
+
+/* Decodes the block of the document up to the document. */
+func (v *prefixBinaryDocValues) Get(docID int) []byte {
+	in := store.NewByteArrayDataInput(v.bytes)
+	in.Pos = int(v.addresses.Get(docID >> INTERVAL_SHIFT))
+	term := make([]byte, 0, v.maxLength)
+	for i := 0; i <= docID&INTERVAL_MASK; i++ {
+		prefix := 0
+		if i > 0 {
+			n, _ := in.ReadVInt()
+			prefix = int(n)
+		}
+		suffix, _ := in.ReadVInt()
+		term = term[:prefix+int(suffix)]
+		in.ReadBytes(term[prefix:])
+	}
+	return term
+}
+
+type lz4BinaryDocValues struct {
+	bytes     []byte
+	addresses packed.PackedIntsReader
+	count     int
+
+	// the last decompressed block, so that reading documents in order
+	// only decompresses each block once
+	sync.Mutex
+	block  int
+	starts []int
+	buffer []byte
+}
+
+func (v *lz4BinaryDocValues) Get(docID int) []byte {
+	v.Lock()
+	defer v.Unlock()
+	if block := docID >> BLOCK_SHIFT; block != v.block {
+		v.decompress(block)
+	}
+	i := docID & BLOCK_MASK
+	return v.buffer[v.starts[i]:v.starts[i+1]]
+}
+
+func (v *lz4BinaryDocValues) decompress(block int) {
+	in := store.NewByteArrayDataInput(v.bytes)
+	in.Pos = int(v.addresses.Get(block))
+	n := v.count - block<<BLOCK_SHIFT
+	if n > BLOCK_COUNT {
+		n = BLOCK_COUNT
+	}
+	// a new slice is allocated for each block, so that values returned
+	// previously are left untouched
+	v.starts = make([]int, n+1)
+	for i := 0; i < n; i++ {
+		length, _ := in.ReadVInt()
+		v.starts[i+1] = v.starts[i] + int(length)
+	}
+	v.buffer = nil
+	if total := v.starts[n]; total > 0 {
+		var err error
+		if v.buffer, err = compressing.LZ4Decompressor(in, total, 0, total, nil); err != nil {
+			panic(err) // in memory, can only be corrupted
+		}
+	}
+	v.block = block
+}
@@ -0,0 +1,109 @@
+package lucene410
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/store"
+	"math/rand"
+	"testing"
+)
+
+func TestBinaryDocValuesRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	variable := make([][]byte, 1000)
+	for i := range variable {
+		if i%7 != 0 { // some docs without value
+			variable[i] = []byte(fmt.Sprintf("/path/to/doc/%v/%v", i/100, r.Intn(1000)))
+		}
+	}
+	blob := bytes.Repeat([]byte("vector:0.25,0.50,0.75;"), 100)
+	blobs := make([][]byte, 100)
+	for i := range blobs {
+		blobs[i] = append([]byte(fmt.Sprint(i)), blob...)
+	}
+	fixed := make([][]byte, 50)
+	for i := range fixed {
+		fixed[i] = []byte(fmt.Sprintf("%04d", i))
+	}
+
+	fields := []struct {
+		format int
+		values [][]byte
+	}{
+		{BINARY_VARIABLE_UNCOMPRESSED, fixed}, // downgraded to fixed
+		{BINARY_VARIABLE_UNCOMPRESSED, variable},
+		{BINARY_PREFIX_COMPRESSED, variable},
+		{BINARY_LZ4_COMPRESSED, variable},
+		{BINARY_LZ4_COMPRESSED, blobs},
+		{BINARY_PREFIX_COMPRESSED, nil},
+	}
+
+	dir := store.NewRAMDirectory()
+	meta, err := dir.CreateOutput("dv.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := dir.CreateOutput("dv.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int64
+	for _, f := range fields {
+		values := f.values
+		start := data.FilePointer()
+		if err = WriteBinaryField(meta, data, f.format, func() func() ([]byte, bool) {
+			i := 0
+			return func() ([]byte, bool) {
+				if i == len(values) {
+					return nil, false
+				}
+				i++
+				return values[i-1], true
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, data.FilePointer()-start)
+	}
+	if err = meta.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = data.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sizes[2] >= sizes[1] {
+		t.Errorf("Expected prefix compression to be smaller than %v, got %v", sizes[1], sizes[2])
+	}
+	if raw := int64(len(blobs) * len(blobs[0])); sizes[4]*10 > raw {
+		t.Errorf("Expected LZ4 compression of %v bytes of blobs, got %v", raw, sizes[4])
+	}
+
+	in, err := dir.OpenInput("dv.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	dataIn, err := dir.OpenInput("dv.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataIn.Close()
+	for n, f := range fields {
+		dv, err := ReadBinaryField(in, dataIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// backward, then random access
+		for i := len(f.values) - 1; i >= 0; i-- {
+			if got := dv.Get(i); !bytes.Equal(got, f.values[i]) {
+				t.Fatalf("Field %v: expected %q for doc %v, got %q", n, f.values[i], i, got)
+			}
+		}
+		for k := 0; k < len(f.values); k++ {
+			i := r.Intn(len(f.values))
+			if got := dv.Get(i); !bytes.Equal(got, f.values[i]) {
+				t.Fatalf("Field %v: expected %q for doc %v, got %q", n, f.values[i], i, got)
+			}
+		}
+	}
+}
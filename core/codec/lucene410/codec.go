@@ -8,6 +8,7 @@ import (
 	"github.com/balzaczyy/golucene/core/codec/lucene49"
 	"github.com/balzaczyy/golucene/core/codec/perfield"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/util/hnsw"
 )
 
 // codec/lucene410/Lucene410Codec.java
@@ -25,10 +26,24 @@ extend FilterCodec (or embeds the Codec in Go style).
 */
 type Lucene410Codec struct {
 	*CodecImpl
+	knnVectorsFormat KnnVectorsFormat
 }
 
 func newLucene410Codec() *Lucene410Codec {
-	return &Lucene410Codec{NewCodec("Lucene410",
+	defaultDVFormat := NewLucene410DocValuesFormat(BINARY_VARIABLE_UNCOMPRESSED)
+	return NewLucene410CodecWithDocValuesFormat(func(field string) DocValuesFormat {
+		return defaultDVFormat
+	})
+}
+
+/*
+Returns a codec writing the doc values of each field with the format
+returned by f, e.g. a Lucene410DocValuesFormat compressing the binary
+values of the field. The choices are recorded into the index, so that
+the segments are read back by the registered codec.
+*/
+func NewLucene410CodecWithDocValuesFormat(f func(field string) DocValuesFormat) *Lucene410Codec {
+	return &Lucene410Codec{CodecImpl: NewCodec("Lucene410",
 		lucene41.NewLucene41StoredFieldsFormat(),
 		lucene42.NewLucene42TermVectorsFormat(),
 		lucene46.NewLucene46FieldInfosFormat(),
@@ -37,9 +52,11 @@ func newLucene410Codec() *Lucene410Codec {
 		perfield.NewPerFieldPostingsFormat(func(field string) PostingsFormat {
 			return LoadPostingsFormat("Lucene41")
 		}),
-		perfield.NewPerFieldDocValuesFormat(func(field string) DocValuesFormat {
-			panic("not implemented yet")
-		}),
+		perfield.NewPerFieldDocValuesFormat(f),
 		new(lucene49.Lucene49NormsFormat),
-	)}
+	), knnVectorsFormat: NewLucene410KnnVectorsFormat(hnsw.DEFAULT_MAX_CONN, hnsw.DEFAULT_BEAM_WIDTH)}
+}
+
+func (codec *Lucene410Codec) KnnVectorsFormat() KnnVectorsFormat {
+	return codec.knnVectorsFormat
 }
@@ -0,0 +1,382 @@
+package lucene410
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// lucene410/Lucene410DocValuesFormat.java
+
+func init() {
+	RegisterDocValuesFormat(NewLucene410DocValuesFormat(BINARY_VARIABLE_UNCOMPRESSED))
+}
+
+const (
+	DV_DATA_CODEC      = "Lucene410DocValuesData"
+	DV_DATA_EXTENSION  = "dvd"
+	DV_META_CODEC      = "Lucene410DocValuesMetadata"
+	DV_META_EXTENSION  = "dvm"
+	DV_VERSION_START   = 0
+	DV_VERSION_CURRENT = DV_VERSION_START
+)
+
+// Types of the entries of the meta file
+const (
+	DV_NUMERIC = 0
+	DV_BINARY  = 1
+	DV_SORTED  = 2
+)
+
+/*
+Stores the doc values of each field in a data file (.dvd); the meta
+file (.dvm) lists the number and type of each field followed by its
+entry, and ends with -1.
+
+Numeric values are written by WriteNumericField(), which chooses a
+dense or sparse encoding per field. Binary values are written by
+WriteBinaryField() with the encoding the format was created with,
+which is recorded into the entry of each field: all instances can read
+the segments written by any of them, so only the default one is
+registered. Sorted values are written as their number, their unique
+values with BINARY_PREFIX_COMPRESSED, as they share prefixes once
+sorted, and the numeric ordinal of each document.
+
+The whole values are loaded in memory when the segment is opened.
+*/
+type Lucene410DocValuesFormat struct {
+	binaryFormat int
+}
+
+/*
+Creates a format encoding binary values with the given encoding, one
+of the BINARY_* constants.
+*/
+func NewLucene410DocValuesFormat(binaryFormat int) *Lucene410DocValuesFormat {
+	assert2(binaryFormat >= BINARY_FIXED_UNCOMPRESSED && binaryFormat <= BINARY_LZ4_COMPRESSED,
+		"unknown binary format: %v", binaryFormat)
+	return &Lucene410DocValuesFormat{binaryFormat}
+}
+
+func (f *Lucene410DocValuesFormat) Name() string {
+	return "Lucene410"
+}
+
+func (f *Lucene410DocValuesFormat) FieldsConsumer(state *SegmentWriteState) (DocValuesConsumer, error) {
+	w, err := newDocValuesConsumer(state, f.binaryFormat)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (f *Lucene410DocValuesFormat) FieldsProducer(state SegmentReadState) (DocValuesProducer, error) {
+	r, err := newDocValuesProducer(state)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// lucene410/Lucene410DocValuesConsumer.java
+
+type docValuesConsumer struct {
+	data, meta   store.IndexOutput
+	maxDoc       int
+	binaryFormat int
+}
+
+func newDocValuesConsumer(state *SegmentWriteState, binaryFormat int) (w *docValuesConsumer, err error) {
+	w = &docValuesConsumer{
+		maxDoc:       state.SegmentInfo.DocCount(),
+		binaryFormat: binaryFormat,
+	}
+	var success = false
+	defer func() {
+		if !success {
+			util.CloseWhileSuppressingError(w)
+		}
+	}()
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, DV_DATA_EXTENSION)
+	if w.data, err = state.Directory.CreateOutput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.data, DV_DATA_CODEC, DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, DV_META_EXTENSION)
+	if w.meta, err = state.Directory.CreateOutput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.meta, DV_META_CODEC, DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	success = true
+	return w, nil
+}
+
+func (w *docValuesConsumer) AddNumericField(field *FieldInfo,
+	iter func() func() (interface{}, bool)) error {
+
+	if err := store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(DV_NUMERIC).
+		Close(); err != nil {
+		return err
+	}
+	return WriteNumericField(w.meta, w.data, w.maxDoc, iter)
+}
+
+func (w *docValuesConsumer) AddBinaryField(field *FieldInfo,
+	iter func() func() ([]byte, bool)) error {
+
+	if err := store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(DV_BINARY).
+		Close(); err != nil {
+		return err
+	}
+	return WriteBinaryField(w.meta, w.data, w.binaryFormat, iter)
+}
+
+func (w *docValuesConsumer) AddSortedField(field *FieldInfo,
+	values func() func() ([]byte, bool),
+	docToOrd func() func() (interface{}, bool)) error {
+
+	valueCount := 0
+	next := values()
+	for _, ok := next(); ok; _, ok = next() {
+		valueCount++
+	}
+	if err := store.Stream(w.meta).WriteVInt(field.Number).
+		WriteByte(DV_SORTED).
+		WriteVInt(int32(valueCount)).
+		Close(); err != nil {
+		return err
+	}
+	if err := WriteBinaryField(w.meta, w.data, BINARY_PREFIX_COMPRESSED, values); err != nil {
+		return err
+	}
+	return WriteNumericField(w.meta, w.data, w.maxDoc, docToOrd)
+}
+
+func (w *docValuesConsumer) Close() (err error) {
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(w.data, w.meta)
+		} else {
+			util.CloseWhileSuppressingError(w.data, w.meta)
+		}
+	}()
+
+	if w.meta != nil {
+		if err = w.meta.WriteVInt(-1); err != nil { // write EOF marker
+			return
+		}
+		if err = codec.WriteFooter(w.meta); err != nil { // write checksum
+			return
+		}
+	}
+	if w.data != nil {
+		if err = codec.WriteFooter(w.data); err != nil { // write checksum
+			return
+		}
+	}
+	success = true
+	return nil
+}
+
+// lucene410/Lucene410DocValuesProducer.java
+
+type docValuesProducer struct {
+	numerics map[int32]*NumericField
+	binaries map[int32]BinaryDocValues
+	sorteds  map[int32]SortedDocValues
+}
+
+func newDocValuesProducer(state SegmentReadState) (r *docValuesProducer, err error) {
+	r = &docValuesProducer{
+		numerics: make(map[int32]*NumericField),
+		binaries: make(map[int32]BinaryDocValues),
+		sorteds:  make(map[int32]SortedDocValues),
+	}
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, DV_DATA_EXTENSION)
+	var data store.IndexInput
+	if data, err = state.Dir.OpenInput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err == nil {
+			err = data.Close()
+		} else {
+			util.CloseWhileSuppressingError(data)
+		}
+	}()
+	var version int32
+	if version, err = codec.CheckHeader(data, DV_DATA_CODEC,
+		DV_VERSION_START, DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	if _, err = codec.RetrieveChecksum(data); err != nil {
+		return nil, err
+	}
+
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, DV_META_EXTENSION)
+	var meta store.ChecksumIndexInput
+	if meta, err = state.Dir.OpenChecksumInput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err == nil {
+			err = meta.Close()
+		} else {
+			util.CloseWhileSuppressingError(meta)
+		}
+	}()
+	var version2 int32
+	if version2, err = codec.CheckHeader(meta, DV_META_CODEC,
+		DV_VERSION_START, DV_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	if version2 != version {
+		return nil, errors.New("Format versions mismatch")
+	}
+	if err = r.readFields(meta, data, state.FieldInfos); err != nil {
+		return nil, err
+	}
+	if _, err = codec.CheckFooter(meta); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *docValuesProducer) readFields(meta, data store.IndexInput, infos FieldInfos) error {
+	for {
+		fieldNumber, err := meta.ReadVInt()
+		if err != nil {
+			return err
+		}
+		if fieldNumber == -1 {
+			return nil
+		}
+		info := infos.FieldInfoByNumber(int(fieldNumber))
+		if info == nil {
+			return errors.New(fmt.Sprintf("Invalid field number: %v (resource=%v)", fieldNumber, meta))
+		}
+		typ, err := meta.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case DV_NUMERIC:
+			if info.DocValuesType() != DOC_VALUES_TYPE_NUMERIC {
+				return errors.New(fmt.Sprintf("Invalid field: %v (resource=%v)", info.Name, meta))
+			}
+			if r.numerics[fieldNumber], err = ReadNumericField(meta, data); err != nil {
+				return err
+			}
+		case DV_BINARY:
+			if info.DocValuesType() != DOC_VALUES_TYPE_BINARY {
+				return errors.New(fmt.Sprintf("Invalid field: %v (resource=%v)", info.Name, meta))
+			}
+			if r.binaries[fieldNumber], err = ReadBinaryField(meta, data); err != nil {
+				return err
+			}
+		case DV_SORTED:
+			if info.DocValuesType() != DOC_VALUES_TYPE_SORTED {
+				return errors.New(fmt.Sprintf("Invalid field: %v (resource=%v)", info.Name, meta))
+			}
+			if r.sorteds[fieldNumber], err = readSortedField(meta, data); err != nil {
+				return err
+			}
+		default:
+			return errors.New(fmt.Sprintf("Invalid entry type: %v, field: %v (resource=%v)", typ, info.Name, meta))
+		}
+	}
+}
+
+func (r *docValuesProducer) Numeric(field *FieldInfo) (NumericDocValues, error) {
+	if f, ok := r.numerics[field.Number]; ok {
+		return f.Values, nil
+	}
+	return nil, nil
+}
+
+func (r *docValuesProducer) NumericIterator(field *FieldInfo) (NumericDocValuesIterator, error) {
+	if f, ok := r.numerics[field.Number]; ok {
+		return f.Iterator(), nil
+	}
+	return nil, nil
+}
+
+func (r *docValuesProducer) Binary(field *FieldInfo) (BinaryDocValues, error) {
+	if v, ok := r.binaries[field.Number]; ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (r *docValuesProducer) Sorted(field *FieldInfo) (SortedDocValues, error) {
+	if v, ok := r.sorteds[field.Number]; ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (r *docValuesProducer) SortedSet(field *FieldInfo) (SortedSetDocValues, error) {
+	panic("not implemented yet")
+}
+
+func (r *docValuesProducer) Close() error {
+	return nil // everything is loaded when opened
+}
+
+/* Reads the sorted entry written by AddSortedField(). */
+func readSortedField(meta, data store.IndexInput) (SortedDocValues, error) {
+	valueCount, err := meta.ReadVInt()
+	if err != nil {
+		return nil, err
+	}
+	values, err := ReadBinaryField(meta, data)
+	if err != nil {
+		return nil, err
+	}
+	ords, err := ReadNumericField(meta, data)
+	if err != nil {
+		return nil, err
+	}
+	return &sortedDocValues{int(valueCount), values, ords}, nil
+}
+
+type sortedDocValues struct {
+	valueCount int
+	values     BinaryDocValues
+	ords       *NumericField
+}
+
+func (v *sortedDocValues) Ord(docID int) int {
+	if !v.ords.DocsWithField.At(docID) {
+		return -1
+	}
+	return int(v.ords.Values(docID))
+}
+
+func (v *sortedDocValues) LookupOrd(ord int) []byte {
+	return v.values.Get(ord)
+}
+
+func (v *sortedDocValues) ValueCount() int {
+	return v.valueCount
+}
+
+func (v *sortedDocValues) Get(docID int) []byte {
+	if ord := v.Ord(docID); ord != -1 {
+		return v.LookupOrd(ord)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package lucene410
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/hnsw"
+	"math"
+	"sort"
+)
+
+// lucene91/Lucene91HnswVectorsWriter.java
+
+/* Seed of the graph builder, so that indexes are reproducible. */
+const HNSW_GRAPH_SEED = 42
+
+/*
+Writes the vectors returned by iter, one per document (nil for
+documents without vector), along with the HNSW graph built over them
+with the given similarity, into data, and their entry into meta. The
+entry can be read back by ReadHnswVectors().
+
+Data holds the vectors as 4 bytes per dimension, the neighbors of each
+node on each of its levels as sorted, delta-encoded VInts, and the
+document of each node.
+*/
+func WriteHnswVectors(meta, data store.IndexOutput, iter func() func() ([]float32, bool),
+	similarity hnsw.Similarity, maxConn, beamWidth int) (err error) {
+
+	vectors := new(hnswVectors)
+	doc := 0
+	next := iter()
+	for v, ok := next(); ok; v, ok = next() {
+		if v != nil {
+			if vectors.dimension == 0 {
+				vectors.dimension = len(v)
+			} else if len(v) != vectors.dimension {
+				return errors.New(fmt.Sprintf(
+					"vector of doc %v has %v dimensions, but previous ones have %v",
+					doc, len(v), vectors.dimension))
+			}
+			vectors.values = append(vectors.values, v...)
+			vectors.docs = append(vectors.docs, doc)
+		}
+		doc++
+	}
+	graph := hnsw.NewBuilder(vectors, similarity, maxConn, beamWidth, HNSW_GRAPH_SEED).Build()
+
+	vectorsOffset := data.FilePointer()
+	for _, v := range vectors.values {
+		if err = data.WriteInt(int32(math.Float32bits(v))); err != nil {
+			return err
+		}
+	}
+
+	graphOffset := data.FilePointer()
+	for node := 0; node < graph.Size(); node++ {
+		level := graph.NodeLevel(node)
+		if err = data.WriteVInt(int32(level + 1)); err != nil {
+			return err
+		}
+		for l := 0; l <= level; l++ {
+			neighbors := append([]int(nil), graph.Neighbors(l, node)...)
+			sort.Ints(neighbors)
+			if err = data.WriteVInt(int32(len(neighbors))); err != nil {
+				return err
+			}
+			last := 0
+			for _, n := range neighbors {
+				if err = data.WriteVInt(int32(n - last)); err != nil {
+					return err
+				}
+				last = n
+			}
+		}
+	}
+
+	docsOffset := data.FilePointer()
+	last := 0
+	for _, d := range vectors.docs {
+		if err = data.WriteVInt(int32(d - last)); err != nil {
+			return err
+		}
+		last = d
+	}
+
+	return store.Stream(meta).WriteVInt(int32(vectors.Size())).
+		WriteVInt(int32(vectors.dimension)).
+		WriteVInt(int32(graph.EntryNode() + 1)).
+		WriteLong(vectorsOffset).
+		WriteLong(graphOffset).
+		WriteLong(docsOffset).
+		Close()
+}
+
+/* Vectors of a field, flattened, and the document of each. */
+type hnswVectors struct {
+	dimension int
+	values    []float32
+	docs      []int
+}
+
+func (v *hnswVectors) Size() int      { return len(v.docs) }
+func (v *hnswVectors) Dimension() int { return v.dimension }
+
+func (v *hnswVectors) VectorValue(ord int) []float32 {
+	return v.values[ord*v.dimension : (ord+1)*v.dimension]
+}
+
+// lucene91/Lucene91HnswVectorsReader.java
+
+/*
+The vectors of a field and their HNSW graph, loaded in memory, for
+approximate nearest neighbor search. Vectors are identified by their
+ordinal; Doc() returns the document of each.
+*/
+type HnswVectorsReader struct {
+	*hnswVectors
+	graph *hnsw.Graph
+}
+
+/*
+Reads the entry written by WriteHnswVectors() from meta, and loads
+the vectors and the graph from data.
+*/
+func ReadHnswVectors(meta, data store.IndexInput) (*HnswVectorsReader, error) {
+	var size, dimension, entry int32
+	var err error
+	if size, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if dimension, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if entry, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	var vectorsOffset, graphOffset, docsOffset int64
+	if vectorsOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if graphOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if docsOffset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	// corrupted counts must not allocate past the end of the data
+	// (each vector takes 4 bytes per dimension, and its doc a VInt)
+	if size < 0 || dimension < 0 || int64(size)*(int64(dimension)*4+1) > data.Length() {
+		return nil, errors.New(fmt.Sprintf(
+			"Corrupted: %v vectors of %v dimensions (resource=%v)", size, dimension, meta))
+	} else if entry < 0 || entry > size {
+		return nil, errors.New(fmt.Sprintf(
+			"Corrupted: entry node %v of %v vectors (resource=%v)", entry-1, size, meta))
+	}
+
+	vectors := &hnswVectors{
+		dimension: int(dimension),
+		values:    make([]float32, int(size)*int(dimension)),
+		docs:      make([]int, size),
+	}
+	if err = data.Seek(vectorsOffset); err != nil {
+		return nil, err
+	}
+	for i := range vectors.values {
+		n, err := data.ReadInt()
+		if err != nil {
+			return nil, err
+		}
+		vectors.values[i] = math.Float32frombits(uint32(n))
+	}
+
+	if err = data.Seek(graphOffset); err != nil {
+		return nil, err
+	}
+	neighbors := make([][][]int, size)
+	for node := range neighbors {
+		levels, err := readCount(data)
+		if err != nil {
+			return nil, err
+		}
+		neighbors[node] = make([][]int, levels)
+		for l := range neighbors[node] {
+			count, err := readCount(data)
+			if err != nil {
+				return nil, err
+			}
+			ns := make([]int, count)
+			last := 0
+			for i := range ns {
+				delta, err := asInt(data.ReadVInt())
+				if err != nil {
+					return nil, err
+				}
+				last += delta
+				if last >= int(size) {
+					return nil, errors.New(fmt.Sprintf(
+						"Corrupted: neighbor %v of node %v out of bounds (resource=%v)", last, node, data))
+				}
+				ns[i] = last
+			}
+			neighbors[node][l] = ns
+		}
+	}
+
+	if err = data.Seek(docsOffset); err != nil {
+		return nil, err
+	}
+	last := 0
+	for i := range vectors.docs {
+		delta, err := asInt(data.ReadVInt())
+		if err != nil {
+			return nil, err
+		}
+		last += delta
+		vectors.docs[i] = last
+	}
+
+	return &HnswVectorsReader{vectors, hnsw.NewGraphFrom(neighbors, int(entry)-1)}, nil
+}
+
+func asInt(n int32, err error) (int, error) {
+	return int(n), err
+}
+
+/*
+Reads the number of the VInts that follow, which must all fit in the
+rest of in.
+*/
+func readCount(in store.IndexInput) (int, error) {
+	n, err := in.ReadVInt()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || int64(n) > in.Length()-in.FilePointer() {
+		return 0, errors.New(fmt.Sprintf(
+			"Corrupted: count %v past EOF: pos=%v length=%v (resource=%v)",
+			n, in.FilePointer(), in.Length(), in))
+	}
+	return int(n), nil
+}
+
+/* Returns the document of the vector. */
+func (r *HnswVectorsReader) Doc(ord int) int { return r.docs[ord] }
+
+func (r *HnswVectorsReader) Graph() *hnsw.Graph { return r.graph }
+
+/*
+Returns the (approximately) k nearest documents to the target, nearest
+first, as neighbors whose Node is the document. ef, at least k, is the
+size of the search queue: the larger, the better the recall, but the
+slower. Only documents accepted by acceptDocs (all if nil) are
+returned. The similarity must be the one the graph was built with.
+*/
+func (r *HnswVectorsReader) Search(target []float32, k, ef int,
+	similarity hnsw.Similarity, acceptDocs util.Bits) ([]hnsw.Neighbor, error) {
+
+	if r.Size() == 0 {
+		return nil, nil
+	} else if len(target) != r.dimension {
+		return nil, errors.New(fmt.Sprintf(
+			"target has %v dimensions, but vectors have %v", len(target), r.dimension))
+	}
+	var acceptOrds util.Bits
+	if acceptDocs != nil {
+		acceptOrds = &ordsToDocsBits{acceptDocs, r.docs}
+	}
+	ans := hnsw.Search(target, k, ef, r, similarity, r.graph, acceptOrds)
+	for i := range ans {
+		ans[i].Node = r.docs[ans[i].Node]
+	}
+	return ans, nil
+}
+
+/* Accepts the ordinals of the accepted documents. */
+type ordsToDocsBits struct {
+	acceptDocs util.Bits
+	docs       []int
+}
+
+func (b *ordsToDocsBits) At(ord int) bool { return b.acceptDocs.At(b.docs[ord]) }
+func (b *ordsToDocsBits) Length() int     { return len(b.docs) }
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
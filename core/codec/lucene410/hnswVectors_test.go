@@ -0,0 +1,150 @@
+package lucene410
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/hnsw"
+	"math/rand"
+	"testing"
+)
+
+func dotProduct(v1, v2 []float32) (ans float32) {
+	for i, v := range v1 {
+		ans += v * v2[i]
+	}
+	return
+}
+
+type oddDocs int
+
+func (b oddDocs) At(i int) bool { return i%2 == 1 }
+func (b oddDocs) Length() int   { return int(b) }
+
+func TestHnswVectorsRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vectors := make([][]float32, 500)
+	for i := range vectors {
+		if i%5 != 0 { // some docs without vector
+			vectors[i] = []float32{r.Float32(), r.Float32(), r.Float32(), r.Float32()}
+		}
+	}
+	iter := func() func() ([]float32, bool) {
+		i := 0
+		return func() ([]float32, bool) {
+			if i == len(vectors) {
+				return nil, false
+			}
+			i++
+			return vectors[i-1], true
+		}
+	}
+
+	dir := store.NewRAMDirectory()
+	meta, err := dir.CreateOutput("vec.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := dir.CreateOutput("vec.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteHnswVectors(meta, data, iter, dotProduct, hnsw.DEFAULT_MAX_CONN, hnsw.DEFAULT_BEAM_WIDTH); err != nil {
+		t.Fatal(err)
+	}
+	if err = meta.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = data.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	metaIn, err := dir.OpenInput("vec.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metaIn.Close()
+	dataIn, err := dir.OpenInput("vec.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataIn.Close()
+	reader, err := ReadHnswVectors(metaIn, dataIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Size() != 400 || reader.Dimension() != 4 {
+		t.Fatalf("Expected 400 vectors of 4 dimensions, got %v of %v", reader.Size(), reader.Dimension())
+	}
+	for ord := 0; ord < reader.Size(); ord++ {
+		doc := reader.Doc(ord)
+		for i, v := range reader.VectorValue(ord) {
+			if v != vectors[doc][i] {
+				t.Fatalf("Expected %v for doc %v, got %v", vectors[doc], doc, reader.VectorValue(ord))
+			}
+		}
+	}
+
+	// the graph was read back as built
+	expected := hnsw.NewBuilder(reader, dotProduct, hnsw.DEFAULT_MAX_CONN, hnsw.DEFAULT_BEAM_WIDTH, HNSW_GRAPH_SEED).Build()
+	if expected.String() != reader.Graph().String() {
+		t.Fatalf("Expected %v, got %v", expected, reader.Graph())
+	}
+
+	target := []float32{1, 0, 0, 0}
+	hits, err := reader.Search(target, 5, 50, dotProduct, oddDocs(len(vectors)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 5 {
+		t.Fatalf("Expected 5 hits, got %v", hits)
+	}
+	for _, hit := range hits {
+		if hit.Node%2 != 1 || vectors[hit.Node] == nil {
+			t.Errorf("Expected an odd doc with a vector, got %v", hit.Node)
+		} else if hit.Score != dotProduct(target, vectors[hit.Node]) {
+			t.Errorf("Expected score %v for doc %v, got %v", dotProduct(target, vectors[hit.Node]), hit.Node, hit.Score)
+		}
+	}
+
+	if _, err = reader.Search([]float32{1, 0}, 5, 50, dotProduct, nil); err == nil {
+		t.Error("Expected an error for a target of another dimension")
+	}
+}
+
+func TestReadCorruptedHnswVectors(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	meta, err := dir.CreateOutput("vec.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1<<20 vectors of 1024 dimensions, in an empty data file
+	meta.WriteVInt(1 << 20)
+	meta.WriteVInt(1024)
+	meta.WriteVInt(1)
+	meta.WriteLong(0)
+	meta.WriteLong(0)
+	meta.WriteLong(0)
+	if err = meta.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := dir.CreateOutput("vec.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = data.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	metaIn, err := dir.OpenInput("vec.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer metaIn.Close()
+	dataIn, err := dir.OpenInput("vec.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataIn.Close()
+	if _, err = ReadHnswVectors(metaIn, dataIn); err == nil {
+		t.Error("Expected corrupted sizes to fail")
+	}
+}
@@ -0,0 +1,273 @@
+package lucene410
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/hnsw"
+)
+
+// lucene91/Lucene91HnswVectorsFormat.java
+
+const (
+	KNN_VECTORS_DATA_CODEC      = "Lucene410KnnVectorsData"
+	KNN_VECTORS_DATA_EXTENSION  = "vec"
+	KNN_VECTORS_META_CODEC      = "Lucene410KnnVectorsMeta"
+	KNN_VECTORS_META_EXTENSION  = "vem"
+	KNN_VECTORS_VERSION_START   = 0
+	KNN_VECTORS_VERSION_CURRENT = KNN_VECTORS_VERSION_START
+)
+
+/*
+Stores the vectors of each field along with their HNSW graph, written
+by WriteHnswVectors(), in a data file (.vec); the meta file (.vem)
+lists the number and similarity of each field followed by its entry,
+and ends with -1.
+
+The whole vectors and graphs are loaded in memory when the segment is
+opened.
+*/
+type Lucene410KnnVectorsFormat struct {
+	maxConn, beamWidth int
+}
+
+/*
+Creates a format whose graphs are built with the given maximum number
+of neighbors per node and queue size (see hnsw.NewBuilder()).
+*/
+func NewLucene410KnnVectorsFormat(maxConn, beamWidth int) *Lucene410KnnVectorsFormat {
+	assert2(maxConn > 0, "maxConn must be positive, got %v", maxConn)
+	assert2(beamWidth > 0, "beamWidth must be positive, got %v", beamWidth)
+	return &Lucene410KnnVectorsFormat{maxConn, beamWidth}
+}
+
+func (f *Lucene410KnnVectorsFormat) VectorsWriter(state *SegmentWriteState) (KnnVectorsWriter, error) {
+	w, err := newKnnVectorsWriter(state, f.maxConn, f.beamWidth)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (f *Lucene410KnnVectorsFormat) VectorsReader(state SegmentReadState) (KnnVectorsReader, error) {
+	r, err := newKnnVectorsReader(state)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+/* Returns the similarity of the graph builder and searcher. */
+func hnswSimilarity(similarity VectorSimilarity) hnsw.Similarity {
+	return func(v1, v2 []float32) float32 {
+		// the dimensions of the vectors of a field are checked when
+		// indexing, and of the target when searching
+		ans, _ := similarity.Compare(v1, v2)
+		return ans
+	}
+}
+
+// lucene91/Lucene91HnswVectorsWriter.java
+
+type knnVectorsWriter struct {
+	data, meta         store.IndexOutput
+	maxConn, beamWidth int
+}
+
+func newKnnVectorsWriter(state *SegmentWriteState, maxConn, beamWidth int) (w *knnVectorsWriter, err error) {
+	w = &knnVectorsWriter{maxConn: maxConn, beamWidth: beamWidth}
+	var success = false
+	defer func() {
+		if !success {
+			util.CloseWhileSuppressingError(w)
+		}
+	}()
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, KNN_VECTORS_DATA_EXTENSION)
+	if w.data, err = state.Directory.CreateOutput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.data, KNN_VECTORS_DATA_CODEC, KNN_VECTORS_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, KNN_VECTORS_META_EXTENSION)
+	if w.meta, err = state.Directory.CreateOutput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+	if err = codec.WriteHeader(w.meta, KNN_VECTORS_META_CODEC, KNN_VECTORS_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	success = true
+	return w, nil
+}
+
+func (w *knnVectorsWriter) AddField(field *FieldInfo, iter func() func() ([]float32, bool)) error {
+	dimension := field.VectorDimension()
+	assert2(dimension > 0, "field '%v' has no vector values", field.Name)
+	next := iter()
+	for v, ok := next(); ok; v, ok = next() {
+		if v != nil && len(v) != dimension {
+			return errors.New(fmt.Sprintf(
+				"vector of field '%v' has %v dimensions, but the field has %v",
+				field.Name, len(v), dimension))
+		}
+	}
+
+	if err := store.Stream(w.meta).WriteVInt(field.Number).
+		WriteVInt(int32(field.VectorSimilarity())).
+		Close(); err != nil {
+		return err
+	}
+	return WriteHnswVectors(w.meta, w.data, iter,
+		hnswSimilarity(field.VectorSimilarity()), w.maxConn, w.beamWidth)
+}
+
+func (w *knnVectorsWriter) Close() (err error) {
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(w.data, w.meta)
+		} else {
+			util.CloseWhileSuppressingError(w.data, w.meta)
+		}
+	}()
+
+	if w.meta != nil {
+		if err = w.meta.WriteVInt(-1); err != nil { // write EOF marker
+			return
+		}
+		if err = codec.WriteFooter(w.meta); err != nil { // write checksum
+			return
+		}
+	}
+	if w.data != nil {
+		if err = codec.WriteFooter(w.data); err != nil { // write checksum
+			return
+		}
+	}
+	success = true
+	return nil
+}
+
+// lucene91/Lucene91HnswVectorsReader.java
+
+type knnVectorsReader struct {
+	fields map[int32]*knnVectorValues
+}
+
+/* The vectors of a field, with their graph. */
+type knnVectorValues struct {
+	*HnswVectorsReader
+	similarity VectorSimilarity
+}
+
+func (v *knnVectorValues) Similarity() VectorSimilarity { return v.similarity }
+
+func (v *knnVectorValues) Search(target []float32, k, ef int, acceptDocs util.Bits) ([]hnsw.Neighbor, error) {
+	return v.HnswVectorsReader.Search(target, k, ef, hnswSimilarity(v.similarity), acceptDocs)
+}
+
+func newKnnVectorsReader(state SegmentReadState) (r *knnVectorsReader, err error) {
+	r = &knnVectorsReader{fields: make(map[int32]*knnVectorValues)}
+
+	dataName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, KNN_VECTORS_DATA_EXTENSION)
+	var data store.IndexInput
+	if data, err = state.Dir.OpenInput(dataName, state.Context); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err == nil {
+			err = data.Close()
+		} else {
+			util.CloseWhileSuppressingError(data)
+		}
+	}()
+	var version int32
+	if version, err = codec.CheckHeader(data, KNN_VECTORS_DATA_CODEC,
+		KNN_VECTORS_VERSION_START, KNN_VECTORS_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	if _, err = codec.RetrieveChecksum(data); err != nil {
+		return nil, err
+	}
+
+	metaName := util.SegmentFileName(state.SegmentInfo.Name, state.SegmentSuffix, KNN_VECTORS_META_EXTENSION)
+	var meta store.ChecksumIndexInput
+	if meta, err = state.Dir.OpenChecksumInput(metaName, state.Context); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err == nil {
+			err = meta.Close()
+		} else {
+			util.CloseWhileSuppressingError(meta)
+		}
+	}()
+	var version2 int32
+	if version2, err = codec.CheckHeader(meta, KNN_VECTORS_META_CODEC,
+		KNN_VECTORS_VERSION_START, KNN_VECTORS_VERSION_CURRENT); err != nil {
+		return nil, err
+	}
+	if version2 != version {
+		return nil, errors.New("Format versions mismatch")
+	}
+	if err = r.readFields(meta, data, state.FieldInfos); err != nil {
+		return nil, err
+	}
+	if _, err = codec.CheckFooter(meta); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *knnVectorsReader) readFields(meta, data store.IndexInput, infos FieldInfos) error {
+	for {
+		fieldNumber, err := meta.ReadVInt()
+		if err != nil {
+			return err
+		}
+		if fieldNumber == -1 {
+			return nil
+		}
+		info := infos.FieldInfoByNumber(int(fieldNumber))
+		if info == nil {
+			return errors.New(fmt.Sprintf("Invalid field number: %v (resource=%v)", fieldNumber, meta))
+		} else if info.VectorDimension() == 0 {
+			return errors.New(fmt.Sprintf("Invalid field: %v (resource=%v)", info.Name, meta))
+		}
+		similarity, err := meta.ReadVInt()
+		if err != nil {
+			return err
+		}
+		if VectorSimilarity(similarity) != info.VectorSimilarity() {
+			return errors.New(fmt.Sprintf(
+				"Vector similarity mismatch for field %v: %v != %v (resource=%v)",
+				info.Name, VectorSimilarity(similarity), info.VectorSimilarity(), meta))
+		}
+		vectors, err := ReadHnswVectors(meta, data)
+		if err != nil {
+			return err
+		}
+		if vectors.Size() > 0 && vectors.Dimension() != info.VectorDimension() {
+			return errors.New(fmt.Sprintf(
+				"Vector dimension mismatch for field %v: %v != %v (resource=%v)",
+				info.Name, vectors.Dimension(), info.VectorDimension(), meta))
+		}
+		r.fields[fieldNumber] = &knnVectorValues{vectors, info.VectorSimilarity()}
+	}
+}
+
+func (r *knnVectorsReader) VectorValues(field *FieldInfo) (VectorValues, error) {
+	if v, ok := r.fields[field.Number]; ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func (r *knnVectorsReader) Close() error {
+	return nil // everything is loaded when opened
+}
@@ -0,0 +1,328 @@
+package lucene410
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
+	"sort"
+)
+
+// lucene54/Lucene54DocValuesConsumer.java#addNumericField
+
+/*
+Encodings of numeric doc values. The encoding is chosen per field by
+the writer:
+
+- NUMERIC_DENSE: one packed delta from the minimum value per
+document, followed by the bit set of the documents with a value,
+unless all of them have one.
+- NUMERIC_SPARSE: the packed IDs of the documents with a value,
+followed by their packed deltas only. Selected when less than
+SPARSE_DENSITY of the documents have a value, so that fields present
+in a small fraction of the documents don't pay for the others.
+*/
+const (
+	NUMERIC_DENSE  = 0
+	NUMERIC_SPARSE = 1
+)
+
+// Ratio of the documents with a value under which a field is sparse.
+const SPARSE_DENSITY = 0.01
+
+/*
+Writes the numeric doc values returned by iter, one int64 per
+document of the segment (nil for documents without value), into
+data, and their entry into meta. The entry can be read back by
+ReadNumericField().
+*/
+func WriteNumericField(meta, data store.IndexOutput, maxDoc int,
+	iter func() func() (interface{}, bool)) (err error) {
+
+	count, docs := 0, 0
+	minValue, maxValue := int64(math.MaxInt64), int64(math.MinInt64)
+	next := iter()
+	for v, ok := next(); ok; v, ok = next() {
+		if v != nil {
+			n := v.(int64)
+			if n < minValue {
+				minValue = n
+			}
+			if n > maxValue {
+				maxValue = n
+			}
+			count++
+		}
+		docs++
+	}
+	assert2(docs == maxDoc, "expected %v values, got %v", maxDoc, docs)
+	if count == 0 {
+		minValue, maxValue = 0, 0
+	}
+	format := NUMERIC_DENSE
+	if float64(count) < SPARSE_DENSITY*float64(maxDoc) {
+		format = NUMERIC_SPARSE
+	}
+	// deltas are unsigned, so that they never overflow
+	bitsPerValue := packed.UnsignedBitsRequired(maxValue - minValue)
+
+	if err = store.Stream(meta).WriteByte(byte(format)).
+		WriteVInt(int32(maxDoc)).
+		WriteVInt(int32(count)).
+		WriteLong(minValue).
+		WriteVInt(int32(bitsPerValue)).
+		WriteLong(data.FilePointer()).
+		Close(); err != nil {
+		return err
+	}
+
+	switch format {
+	case NUMERIC_SPARSE:
+		if count == 0 {
+			return nil
+		}
+		docIDs := packed.WriterNoHeader(data, packed.PackedFormat(packed.PACKED),
+			count, packed.BitsRequired(int64(maxDoc-1)), packed.DEFAULT_BUFFER_SIZE)
+		next, docID := iter(), 0
+		for v, ok := next(); ok; v, ok = next() {
+			if v != nil {
+				if err = docIDs.Add(int64(docID)); err != nil {
+					return err
+				}
+			}
+			docID++
+		}
+		if err = docIDs.Finish(); err != nil {
+			return err
+		}
+		deltas := packed.WriterNoHeader(data, packed.PackedFormat(packed.PACKED),
+			count, bitsPerValue, packed.DEFAULT_BUFFER_SIZE)
+		next = iter()
+		for v, ok := next(); ok; v, ok = next() {
+			if v != nil {
+				if err = deltas.Add(v.(int64) - minValue); err != nil {
+					return err
+				}
+			}
+		}
+		return deltas.Finish()
+
+	default:
+		if maxDoc == 0 {
+			return nil
+		}
+		deltas := packed.WriterNoHeader(data, packed.PackedFormat(packed.PACKED),
+			maxDoc, bitsPerValue, packed.DEFAULT_BUFFER_SIZE)
+		docsWithField := make([]byte, (maxDoc+7)/8)
+		next, docID := iter(), 0
+		for v, ok := next(); ok; v, ok = next() {
+			var delta int64
+			if v != nil {
+				delta = v.(int64) - minValue
+				docsWithField[docID>>3] |= 1 << uint(docID&7)
+			}
+			if err = deltas.Add(delta); err != nil {
+				return err
+			}
+			docID++
+		}
+		if err = deltas.Finish(); err != nil {
+			return err
+		}
+		if count < maxDoc {
+			return data.WriteBytes(docsWithField)
+		}
+		return nil
+	}
+}
+
+// lucene54/Lucene54DocValuesProducer.java#getNumeric
+
+/* Numeric doc values loaded by ReadNumericField(). */
+type NumericField struct {
+	// Returns the value of a document, 0 if it has none.
+	Values NumericDocValues
+	// The documents having a value.
+	DocsWithField util.Bits
+	// Number of documents having a value.
+	Count int
+
+	maxDoc   int
+	minValue int64
+	docIDs   packed.PackedIntsReader // sparse only
+	deltas   packed.PackedIntsReader
+}
+
+/*
+Reads the numeric entry written by WriteNumericField() from meta, and
+loads its values from data into memory.
+*/
+func ReadNumericField(meta, data store.IndexInput) (*NumericField, error) {
+	format, err := meta.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var maxDoc, count, bitsPerValue int32
+	if maxDoc, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	if count, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	f := &NumericField{maxDoc: int(maxDoc), Count: int(count)}
+	if f.minValue, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if bitsPerValue, err = meta.ReadVInt(); err != nil {
+		return nil, err
+	}
+	var offset int64
+	if offset, err = meta.ReadLong(); err != nil {
+		return nil, err
+	}
+	if count > maxDoc {
+		return nil, errors.New(fmt.Sprintf(
+			"Corrupted: %v values for %v documents (resource=%v)", count, maxDoc, meta))
+	}
+	if err = data.Seek(offset); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case NUMERIC_SPARSE:
+		if count == 0 {
+			f.Values = func(int) int64 { return 0 }
+			f.DocsWithField = util.MatchNoBits(maxDoc)
+			return f, nil
+		}
+		docBits := uint32(packed.BitsRequired(int64(maxDoc - 1)))
+		if f.docIDs, err = packed.ReaderNoHeader(data, packed.PackedFormat(packed.PACKED),
+			packed.VERSION_CURRENT, count, docBits); err != nil {
+			return nil, err
+		}
+		if f.deltas, err = packed.ReaderNoHeader(data, packed.PackedFormat(packed.PACKED),
+			packed.VERSION_CURRENT, count, uint32(bitsPerValue)); err != nil {
+			return nil, err
+		}
+		f.Values = func(docID int) int64 {
+			if i := f.search(0, docID); i < f.Count && int(f.docIDs.Get(i)) == docID {
+				return f.minValue + f.deltas.Get(i)
+			}
+			return 0
+		}
+		f.DocsWithField = &sparseBits{f}
+		return f, nil
+
+	case NUMERIC_DENSE:
+		if maxDoc > 0 {
+			if f.deltas, err = packed.ReaderNoHeader(data, packed.PackedFormat(packed.PACKED),
+				packed.VERSION_CURRENT, maxDoc, uint32(bitsPerValue)); err != nil {
+				return nil, err
+			}
+		}
+		if count == maxDoc {
+			f.Values = func(docID int) int64 {
+				return f.minValue + f.deltas.Get(docID)
+			}
+			f.DocsWithField = util.MatchAllBits(maxDoc)
+			return f, nil
+		}
+		bytes := make([]byte, (maxDoc+7)/8)
+		if err = data.ReadBytes(bytes); err != nil {
+			return nil, err
+		}
+		docsWithField := util.NewFixedBitSetOf(int(maxDoc))
+		for docID := 0; docID < int(maxDoc); docID++ {
+			if bytes[docID>>3]&(1<<uint(docID&7)) != 0 {
+				docsWithField.Set(docID)
+			}
+		}
+		f.Values = func(docID int) int64 {
+			if docsWithField.At(docID) {
+				return f.minValue + f.deltas.Get(docID)
+			}
+			return 0
+		}
+		f.DocsWithField = docsWithField
+		return f, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown numeric format: %v (resource=%v)", format, meta))
+}
+
+/*
+Returns an iterator over the documents having a value, which only
+visits those documents if the field is sparse.
+*/
+func (f *NumericField) Iterator() NumericDocValuesIterator {
+	if f.docIDs == nil {
+		docsWithField := f.DocsWithField
+		if _, ok := docsWithField.(util.MatchAllBits); ok {
+			docsWithField = nil
+		}
+		return NewNumericDocValuesIterator(f.Values, docsWithField, f.maxDoc)
+	}
+	return &sparseNumericIterator{f, -1, 0}
+}
+
+/* Returns the first index from lo whose doc ID is at least target. */
+func (f *NumericField) search(lo, target int) int {
+	return lo + sort.Search(f.Count-lo, func(i int) bool {
+		return int(f.docIDs.Get(lo+i)) >= target
+	})
+}
+
+type sparseBits struct {
+	f *NumericField
+}
+
+func (b *sparseBits) At(docID int) bool {
+	i := b.f.search(0, docID)
+	return i < b.f.Count && int(b.f.docIDs.Get(i)) == docID
+}
+
+func (b *sparseBits) Length() int {
+	return b.f.maxDoc
+}
+
+type sparseNumericIterator struct {
+	f   *NumericField
+	doc int
+	// index of the current doc if it has a value, of the next doc with
+	// a value otherwise
+	index int
+}
+
+func (it *sparseNumericIterator) DocId() int {
+	return it.doc
+}
+
+func (it *sparseNumericIterator) NextDoc() (int, error) {
+	return it.Advance(it.doc + 1)
+}
+
+func (it *sparseNumericIterator) Advance(target int) (int, error) {
+	if it.index = it.f.search(it.index, target); it.index < it.f.Count {
+		it.doc = int(it.f.docIDs.Get(it.index))
+	} else {
+		it.doc = NO_MORE_DOCS
+	}
+	return it.doc, nil
+}
+
+func (it *sparseNumericIterator) AdvanceExact(target int) (bool, error) {
+	it.index = it.f.search(it.index, target)
+	it.doc = target
+	return it.index < it.f.Count && int(it.f.docIDs.Get(it.index)) == target, nil
+}
+
+func (it *sparseNumericIterator) Cost() int64 {
+	return int64(it.f.Count)
+}
+
+func (it *sparseNumericIterator) LongValue() int64 {
+	return it.f.minValue + it.f.deltas.Get(it.index)
+}
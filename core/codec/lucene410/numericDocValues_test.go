@@ -0,0 +1,159 @@
+package lucene410
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNumericDocValuesRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	full := make([]interface{}, 1000)
+	for i := range full {
+		full[i] = r.Int63n(1000) - 500
+	}
+	half := make([]interface{}, 1000)
+	for i := range half {
+		if i%2 == 0 {
+			half[i] = int64(i)
+		}
+	}
+	sparse := make([]interface{}, 10000)
+	for i := 3; i < len(sparse); i += 1000 {
+		sparse[i] = int64(math.MaxInt64 - i)
+	}
+	sparse[len(sparse)-1] = int64(math.MinInt64)
+	dense := make([]interface{}, len(sparse))
+	for i := range dense {
+		dense[i] = int64(math.MaxInt64 - i)
+	}
+
+	fields := []struct {
+		format int
+		values []interface{}
+	}{
+		{NUMERIC_DENSE, full},
+		{NUMERIC_DENSE, half},
+		{NUMERIC_SPARSE, sparse},
+		{NUMERIC_DENSE, dense},
+		{NUMERIC_SPARSE, make([]interface{}, 100)},
+		{NUMERIC_DENSE, nil},
+	}
+
+	dir := store.NewRAMDirectory()
+	meta, err := dir.CreateOutput("dv.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := dir.CreateOutput("dv.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int64
+	for _, f := range fields {
+		values := f.values
+		start := data.FilePointer()
+		if err = WriteNumericField(meta, data, len(values), func() func() (interface{}, bool) {
+			i := 0
+			return func() (interface{}, bool) {
+				if i == len(values) {
+					return nil, false
+				}
+				i++
+				return values[i-1], true
+			}
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, data.FilePointer()-start)
+	}
+	if err = meta.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = data.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sizes[2]*100 > sizes[3] {
+		t.Errorf("Expected sparse field to be much smaller than %v, got %v", sizes[3], sizes[2])
+	}
+
+	in, err := dir.OpenInput("dv.meta", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	dataIn, err := dir.OpenInput("dv.data", store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataIn.Close()
+	for n, f := range fields {
+		dv, err := ReadNumericField(in, dataIn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var docs []int
+		for i, v := range f.values {
+			var want int64
+			if v != nil {
+				want = v.(int64)
+				docs = append(docs, i)
+			}
+			if got := dv.Values(i); got != want {
+				t.Fatalf("Field %v: expected %v for doc %v, got %v", n, want, i, got)
+			}
+			if dv.DocsWithField.At(i) != (v != nil) {
+				t.Fatalf("Field %v: expected doc %v to have a value: %v", n, i, v != nil)
+			}
+		}
+		if dv.Count != len(docs) {
+			t.Fatalf("Field %v: expected %v docs with value, got %v", n, len(docs), dv.Count)
+		}
+
+		it := dv.Iterator()
+		for _, doc := range docs {
+			if got, _ := it.NextDoc(); got != doc {
+				t.Fatalf("Field %v: expected next doc %v, got %v", n, doc, got)
+			}
+			if it.LongValue() != f.values[doc].(int64) {
+				t.Fatalf("Field %v: expected %v for doc %v, got %v", n, f.values[doc], doc, it.LongValue())
+			}
+		}
+		if got, _ := it.NextDoc(); got != NO_MORE_DOCS {
+			t.Fatalf("Field %v: expected no more docs, got %v", n, got)
+		}
+
+		// skip around with AdvanceExact and Advance
+		it = dv.Iterator()
+		for target := r.Intn(10); target < len(f.values); target += 1 + r.Intn(10) {
+			if target%2 == 0 {
+				ok, _ := it.AdvanceExact(target)
+				if ok != (f.values[target] != nil) || it.DocId() != target {
+					t.Fatalf("Field %v: expected exact doc %v to have a value: %v, got %v on %v",
+						n, target, f.values[target] != nil, ok, it.DocId())
+				}
+				if ok && it.LongValue() != f.values[target].(int64) {
+					t.Fatalf("Field %v: expected %v for doc %v, got %v", n, f.values[target], target, it.LongValue())
+				}
+				continue
+			}
+			want := NO_MORE_DOCS
+			for i := target; i < len(f.values); i++ {
+				if f.values[i] != nil {
+					want = i
+					break
+				}
+			}
+			got, _ := it.Advance(target)
+			if got != want {
+				t.Fatalf("Field %v: expected to advance from %v to %v, got %v", n, target, want, got)
+			}
+			if got == NO_MORE_DOCS {
+				break
+			}
+			target = got
+		}
+	}
+}
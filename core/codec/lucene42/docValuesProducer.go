@@ -237,6 +237,15 @@ func (dvp *Lucene42DocValuesProducer) loadNumeric(field *FieldInfo) (v NumericDo
 	return
 }
 
+/* All the documents have a value, as missing values are not supported. */
+func (dvp *Lucene42DocValuesProducer) NumericIterator(field *FieldInfo) (NumericDocValuesIterator, error) {
+	v, err := dvp.Numeric(field)
+	if err != nil {
+		return nil, err
+	}
+	return NewNumericDocValuesIterator(v, nil, dvp.maxDoc), nil
+}
+
 func (dvp *Lucene42DocValuesProducer) Binary(field *FieldInfo) (v BinaryDocValues, err error) {
 	panic("not implemented yet")
 	return nil, nil
@@ -252,6 +261,11 @@ func (dvp *Lucene42DocValuesProducer) SortedSet(field *FieldInfo) (v SortedSetDo
 	return nil, nil
 }
 
+/* Returns the memory used by the loaded doc values. */
+func (dvp *Lucene42DocValuesProducer) RamBytesUsed() int64 {
+	return atomic.LoadInt64(&dvp.ramBytesUsed)
+}
+
 func (dvp *Lucene42DocValuesProducer) Close() error {
 	if dvp == nil {
 		return nil
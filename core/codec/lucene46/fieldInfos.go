@@ -97,9 +97,12 @@ var Lucene46FieldInfosReader = func(dir store.Directory,
 		if fieldNumber, err = input.ReadVInt(); err != nil {
 			return
 		}
-		assert2(fieldNumber >= 0,
-			"invalid field number for field: %v, fieldNumber=%v (resource=%v)",
-			name, fieldNumber, input)
+		if fieldNumber < 0 {
+			err = errors.New(fmt.Sprintf(
+				"invalid field number for field: %v, fieldNumber=%v (resource=%v)",
+				name, fieldNumber, input))
+			return
+		}
 		if bits, err = input.ReadByte(); err != nil {
 			return
 		}
@@ -109,7 +112,7 @@ var Lucene46FieldInfosReader = func(dir store.Directory,
 		storePayloads = (bits & FI_STORE_PAYLOADS) != 0
 		switch {
 		case !isIndexed:
-			//
+			indexOptions = INDEX_OPT_DOCS_ONLY // ignored
 		case (bits & FI_OMIT_TERM_FREQ_AND_POSITIONS) != 0:
 			indexOptions = INDEX_OPT_DOCS_ONLY
 		case (bits & FI_OMIT_POSITIONS) != 0:
@@ -136,6 +139,11 @@ var Lucene46FieldInfosReader = func(dir store.Directory,
 		if attributes, err = input.ReadStringStringMap(); err != nil {
 			return
 		}
+		// NewFieldInfo() and NewFieldInfos() panic on inconsistencies
+		if err = checkFieldInfo(input, infos, name, fieldNumber, isIndexed,
+			omitNorms, storePayloads, indexOptions, docValuesType, normsType, dvGen); err != nil {
+			return
+		}
 		infos = append(infos, NewFieldInfo(name, isIndexed, fieldNumber,
 			storeTermVector, omitNorms, storePayloads, indexOptions,
 			docValuesType, normsType, dvGen, attributes))
@@ -155,6 +163,32 @@ var Lucene46FieldInfosReader = func(dir store.Directory,
 	return fis, nil
 }
 
+func checkFieldInfo(input store.IndexInput, infos []*FieldInfo,
+	name string, number int32, indexed, omitNorms, storePayloads bool,
+	indexOptions IndexOptions, docValuesType, normsType DocValuesType, dvGen int64) error {
+
+	for _, prev := range infos {
+		if prev.Number == number || prev.Name == name {
+			return errors.New(fmt.Sprintf(
+				"duplicate field: %v (%v) and %v (%v) (resource=%v)",
+				prev.Name, prev.Number, name, number, input))
+		}
+	}
+	switch {
+	case indexed && omitNorms && int(normsType) != 0:
+		return errors.New(fmt.Sprintf(
+			"field %v omits norms but has norms of type %v (resource=%v)", name, normsType, input))
+	case indexed && storePayloads && indexOptions < INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS:
+		return errors.New(fmt.Sprintf(
+			"field %v stores payloads without positions (resource=%v)", name, input))
+	case dvGen != -1 && int(docValuesType) == 0:
+		return errors.New(fmt.Sprintf(
+			"field %v has doc values generation %v without doc values (resource=%v)",
+			name, dvGen, input))
+	}
+	return nil
+}
+
 func getDocValuesType(input store.IndexInput, b byte) (t DocValuesType, err error) {
 	switch b {
 	case 0:
@@ -0,0 +1,114 @@
+package lucene46_test
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/codec"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	"github.com/balzaczyy/golucene/core/codec/lucene46"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"strings"
+	"testing"
+)
+
+/* Returns the bytes of the files of a small index, by extension. */
+func sampleFiles(f *testing.F) map[string][]byte {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+	dir := store.NewRAMDirectory()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()).SetUseCompoundFile(false)
+	w, err := index.NewIndexWriter(dir, conf)
+	if err != nil {
+		f.Fatal(err)
+	}
+	d := docu.NewDocument()
+	d.Add(docu.NewStringField("id", "1", docu.STORE_YES))
+	if err = w.AddDocument(d.Fields()); err != nil {
+		f.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		f.Fatal(err)
+	}
+	names, err := dir.ListAll()
+	if err != nil {
+		f.Fatal(err)
+	}
+	ans := make(map[string][]byte)
+	for _, name := range names {
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			in, err := dir.OpenInput(name, store.IO_CONTEXT_DEFAULT)
+			if err != nil {
+				f.Fatal(err)
+			}
+			data := make([]byte, in.Length())
+			if err = in.ReadBytes(data); err != nil {
+				f.Fatal(err)
+			}
+			in.Close()
+			ans[name[i+1:]] = data
+		}
+	}
+	return ans
+}
+
+/*
+Rewrites a file to its version before checksums, i.e. with version 0
+in its header and without footer, so that the fuzzer can reach the
+decoding of its content.
+*/
+func withoutChecksum(data []byte, codecName string) []byte {
+	n := codec.HeaderLength(codecName)
+	ans := append([]byte(nil), data[:len(data)-codec.FOOTER_LENGTH]...)
+	copy(ans[n-4:n], []byte{0, 0, 0, 0})
+	return ans
+}
+
+/* Writes data as the file name of a new directory. */
+func newDirectory(t *testing.T, name string, data []byte) store.Directory {
+	dir := store.NewRAMDirectory()
+	out, err := dir.CreateOutput(name, store.IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = out.WriteBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+/*
+Feeds arbitrary bytes to the field infos reader, which must reject
+corrupted files with an error, but never panic.
+*/
+func FuzzFieldInfosReader(f *testing.F) {
+	data := sampleFiles(f)[lucene46.FI_EXTENSION]
+	f.Add(data)
+	f.Add(withoutChecksum(data, lucene46.FI_CODEC_NAME))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := newDirectory(t, "_0."+lucene46.FI_EXTENSION, data)
+		lucene46.Lucene46FieldInfosReader(dir, "_0", "", store.IO_CONTEXT_READONCE)
+	})
+}
+
+/*
+Feeds arbitrary bytes to the segment info reader, which must reject
+corrupted files with an error, but never panic.
+*/
+func FuzzSegmentInfoReader(f *testing.F) {
+	data := sampleFiles(f)[lucene46.SI_EXTENSION]
+	f.Add(data)
+	f.Add(withoutChecksum(data, lucene46.SI_CODEC_NAME))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dir := newDirectory(t, "_0."+lucene46.SI_EXTENSION, data)
+		lucene46.NewLucene46SegmentInfoFormat().Read(dir, "_0", store.IO_CONTEXT_READONCE)
+	})
+}
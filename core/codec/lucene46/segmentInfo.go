@@ -88,6 +88,12 @@ func (f *Lucene46SegmentInfoFormat) Read(dir store.Directory,
 	if files, err = input.ReadStringSet(); err != nil {
 		return
 	}
+	for file := range files { // SetFiles() panics on invalid names
+		if !CODEC_FILE_PATTERN.MatchString(file) {
+			return nil, errors.New(fmt.Sprintf(
+				"invalid codec filename '%v' (resource=%v)", file, input))
+		}
+	}
 
 	if codecVersion >= SI_VERSION_CHECKSUM {
 		_, err = codec.CheckFooter(input)
@@ -98,11 +104,11 @@ func (f *Lucene46SegmentInfoFormat) Read(dir store.Directory,
 		return
 	}
 
-	si = NewSegmentInfo(dir, version, segName, docCount, isCompoundFile, nil, diagnostics)
-	si.SetFiles(files)
+	ans := NewSegmentInfo(dir, version, segName, docCount, isCompoundFile, nil, diagnostics)
+	ans.SetFiles(files)
 
 	success = true
-	return si, nil
+	return ans, nil
 }
 
 func asInt(n int32, err error) (int, error) {
@@ -186,6 +186,17 @@ func (nc *NormsConsumer) AddNumericField(field *FieldInfo,
 	return nil
 }
 
+func (nc *NormsConsumer) AddBinaryField(field *FieldInfo,
+	iter func() func() ([]byte, bool)) error {
+	panic("not supported")
+}
+
+func (nc *NormsConsumer) AddSortedField(field *FieldInfo,
+	values func() func() ([]byte, bool),
+	docToOrd func() func() (interface{}, bool)) error {
+	panic("not supported")
+}
+
 type Longs []int64
 
 func (a Longs) Len() int           { return len(a) }
@@ -263,10 +274,22 @@ func (m *NormMap) add(l int64) bool {
 
 /* Gets the ordinal for a previously added item. */
 func (m *NormMap) ord(l int64) int {
-	panic("niy")
+	if l >= math.MinInt8 && l <= math.MaxInt8 {
+		return int(m.singleByteRange[int(l+128)])
+	}
+	return int(m.other[l])
 }
 
 /* Retrieves the ordinal table for previously added items. */
 func (m *NormMap) decodeTable() []int64 {
-	panic("niy")
+	decode := make([]int64, m.size)
+	for i, s := range m.singleByteRange {
+		if s >= 0 {
+			decode[s] = int64(i) - 128
+		}
+	}
+	for l, s := range m.other {
+		decode[s] = l
+	}
+	return decode
 }
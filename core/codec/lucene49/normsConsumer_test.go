@@ -0,0 +1,34 @@
+package lucene49
+
+import (
+	"testing"
+)
+
+func TestNormMap(t *testing.T) {
+	m := newNormMap()
+	values := []int64{5, -128, 127, 1000, 5, -70000, 0, 1000}
+	added := []bool{true, true, true, true, false, true, true, false}
+	for i, v := range values {
+		if ok := m.add(v); ok != added[i] {
+			t.Errorf("Expected add(%v) to return %v, but got %v", v, added[i], ok)
+		}
+	}
+	if m.size != 6 {
+		t.Fatalf("Expected 6 unique values, but %v", m.size)
+	}
+	decode := m.decodeTable()
+	if len(decode) != m.size {
+		t.Fatalf("Expected a table of %v values, but %v", m.size, len(decode))
+	}
+	for i, v := range values {
+		if ord := m.ord(v); decode[ord] != v {
+			t.Errorf("Expected value %v (#%v) to decode from ord %v, but got %v", v, i, ord, decode[ord])
+		}
+	}
+	// ordinals follow the order in which values were first added
+	for i, v := range []int64{5, -128, 127, 1000, -70000, 0} {
+		if ord := m.ord(v); ord != i {
+			t.Errorf("Expected ord %v for %v, but got %v", i, v, ord)
+		}
+	}
+}
@@ -221,6 +221,10 @@ func int32ToInt(n int32, err error) (int, error) {
 	return int(n), err
 }
 
+func (np *NormsProducer) NumericIterator(field *FieldInfo) (NumericDocValuesIterator, error) {
+	panic("not supported")
+}
+
 func (np *NormsProducer) Binary(field *FieldInfo) (BinaryDocValues, error) {
 	panic("not supported")
 }
@@ -233,6 +237,11 @@ func (np *NormsProducer) SortedSet(field *FieldInfo) (SortedSetDocValues, error)
 	panic("not supported")
 }
 
+/* Returns the memory used by the loaded norms. */
+func (np *NormsProducer) RamBytesUsed() int64 {
+	return atomic.LoadInt64(&np.ramBytesUsed)
+}
+
 func (np *NormsProducer) Close() error {
 	return np.data.Close()
 }
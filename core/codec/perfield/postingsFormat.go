@@ -6,6 +6,7 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
 	"io"
+	"sort"
 	"strconv"
 )
 
@@ -232,6 +233,27 @@ func (r *PerFieldPostingsReader) Terms(field string) Terms {
 	return nil
 }
 
+/* Returns the memory used by the readers of each format. */
+func (r *PerFieldPostingsReader) RamBytesUsed() int64 {
+	var size int64
+	for _, p := range r.formats {
+		if a, ok := p.(util.Accountable); ok {
+			size += a.RamBytesUsed()
+		}
+	}
+	return size
+}
+
+func (r *PerFieldPostingsReader) ChildResources() []util.Accountable {
+	var ans []util.Accountable
+	for _, name := range sortedKeys(r.formats) {
+		if a, ok := r.formats[name].(util.Accountable); ok {
+			ans = append(ans, util.NamedAccountableOf(fmt.Sprintf("format '%v'", name), a))
+		}
+	}
+	return ans
+}
+
 func (r *PerFieldPostingsReader) Close() error {
 	fps := make([]FieldsProducer, 0)
 	for _, v := range r.formats {
@@ -243,3 +265,19 @@ func (r *PerFieldPostingsReader) Close() error {
 	}
 	return util.Close(items...)
 }
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]FieldsProducer:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]DocValuesProducer:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
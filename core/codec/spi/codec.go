@@ -39,6 +39,9 @@ type Codec interface {
 	NormsFormat() NormsFormat
 	// Encodes/decodes live docs
 	LiveDocsFormat() LiveDocsFormat
+	// Encodes/decodes vector values, or nil if vectors are not
+	// supported
+	KnnVectorsFormat() KnnVectorsFormat
 }
 
 type CodecImpl struct {
@@ -102,6 +105,11 @@ func (codec *CodecImpl) LiveDocsFormat() LiveDocsFormat {
 	return codec.liveDocsFormat
 }
 
+/* Returns nil; codecs supporting vectors override it. */
+func (codec *CodecImpl) KnnVectorsFormat() KnnVectorsFormat {
+	return nil
+}
+
 /*
 returns the codec's name. Subclass can override to provide more
 detail (such as parameters.)
@@ -110,34 +118,38 @@ func (codec *CodecImpl) String() string {
 	return codec.name
 }
 
-var allCodecs = make(map[string]Codec)
+var allCodecs = util.NewNamedSPILoader("Codec")
 
 // workaround Lucene Java's SPI mechanism
 func RegisterCodec(codecs ...Codec) {
 	for _, codec := range codecs {
 		fmt.Printf("Found codec: %v\n", codec.Name())
-		allCodecs[codec.Name()] = codec
+		allCodecs.Register(codec.Name(), codec)
 	}
 }
 
 // looks up a codec by name
 func LoadCodec(name string) Codec {
-	c, ok := allCodecs[name]
-	if !ok {
-		fmt.Println("Unknown codec:", name)
-		fmt.Println("Available codecs:", allCodecs)
-		assert(ok)
-	}
+	c, err := LookupCodec(name)
+	assert2(err == nil, "%v", err)
 	return c
 }
 
+/*
+Looks up a codec by name, e.g. the one recorded in a segment, and
+returns an error if no linked in package registered it.
+*/
+func LookupCodec(name string) (Codec, error) {
+	c, err := allCodecs.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.(Codec), nil
+}
+
 // returns a list of all available codec names
 func AvailableCodecs() []string {
-	ans := make([]string, 0, len(allCodecs))
-	for name, _ := range allCodecs {
-		ans = append(ans, name)
-	}
-	return ans
+	return allCodecs.AvailableServices()
 }
 
 // Expert: returns the default codec used for newly created IndexWriterConfig(s).
@@ -212,6 +224,8 @@ type LiveDocsFormat interface {
 	NewLiveDocs(size int) util.MutableBits
 	// Creates a new MutableBits of the same bits set and size of existing.
 	// NewLiveDocs(existing util.Bits) (util.MutableBits, error)
+	// Read live docs bits.
+	ReadLiveDocs(dir store.Directory, info *SegmentCommitInfo, ctx store.IOContext) (util.Bits, error)
 	// Persist live docs bits. Use SegmentCommitInfo.nextDelGen() to
 	// determine the generation of the deletes file you should write to.
 	WriteLiveDocs(bits util.MutableBits, dir store.Directory,
@@ -2,6 +2,8 @@ package spi
 
 import (
 	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
 	"io"
 )
 
@@ -36,26 +38,39 @@ type DocValuesFormat interface {
 	FieldsProducer(state SegmentReadState) (r DocValuesProducer, err error)
 }
 
-var allDocValuesFormats = map[string]DocValuesFormat{}
+var allDocValuesFormats = util.NewNamedSPILoader("DocValuesFormat")
 
 // workaround Lucene Java's SPI mechanism
 func RegisterDocValuesFormat(formats ...DocValuesFormat) {
 	for _, format := range formats {
-		allDocValuesFormats[format.Name()] = format
+		allDocValuesFormats.Register(format.Name(), format)
 	}
 }
 
+/* Looks up a format by name, and returns an error if none is registered. */
+func LookupDocValuesFormat(name string) (DocValuesFormat, error) {
+	v, err := allDocValuesFormats.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return v.(DocValuesFormat), nil
+}
+
+/* Returns a list of all available format names. */
+func AvailableDocValuesFormats() []string {
+	return allDocValuesFormats.AvailableServices()
+}
+
+/*
+Returns the producer of the doc values written by the format of the
+given name, registered with RegisterDocValuesFormat().
+*/
 func LoadDocValuesProducer(name string, state SegmentReadState) (fp DocValuesProducer, err error) {
-	panic("not implemented yet")
-	// switch name {
-	// case "Lucene42":
-	// 	return newLucene42DocValuesProducer(state, LUCENE42_DV_DATA_CODEC, LUCENE42_DV_DATA_EXTENSION,
-	// 		LUCENE42_DV_METADATA_CODEC, LUCENE42_DV_METADATA_EXTENSION)
-	// case "Lucene45":
-	// 	return newLucene45DocValuesProducer(state, LUCENE45_DV_DATA_CODEC, LUCENE45_DV_DATA_EXTENSION,
-	// 		LUCENE45_DV_META_CODEC, LUCENE45_DV_META_EXTENSION)
-	// }
-	// panic(fmt.Sprintf("Service '%v' not found.", name))
+	format, err := LookupDocValuesFormat(name)
+	if err != nil {
+		return nil, err
+	}
+	return format.FieldsProducer(state)
 }
 
 // codecs/DocValuesConsumer.java
@@ -78,6 +93,14 @@ type DocValuesConsumer interface {
 	io.Closer
 	// Writes numeric docvalues for a field.
 	AddNumericField(*FieldInfo, func() func() (interface{}, bool)) error
+	// Writes binary docvalues for a field, nil for a document without
+	// value.
+	AddBinaryField(*FieldInfo, func() func() ([]byte, bool)) error
+	// Writes pre-sorted binary docvalues for a field: the unique values
+	// in sorted order, and the ordinal of the value of each document,
+	// nil for a document without value.
+	AddSortedField(*FieldInfo, func() func() ([]byte, bool),
+		func() func() (interface{}, bool)) error
 }
 
 // codecs/DocvaluesProducer.java
@@ -86,6 +109,8 @@ type DocValuesConsumer interface {
 type DocValuesProducer interface {
 	io.Closer
 	Numeric(field *FieldInfo) (v NumericDocValues, err error)
+	// Returns an iterator over the documents having a numeric value.
+	NumericIterator(field *FieldInfo) (v NumericDocValuesIterator, err error)
 	Binary(field *FieldInfo) (v BinaryDocValues, err error)
 	Sorted(field *FieldInfo) (v SortedDocValues, err error)
 	SortedSet(field *FieldInfo) (v SortedSetDocValues, err error)
@@ -96,6 +121,81 @@ type DocValuesProducer interface {
 // }
 type NumericDocValues func(docID int) int64
 
+// index/DocValuesIterator.java
+
+/*
+Iterates over the documents having a value in a doc values field, so
+that fields present in only a small fraction of the documents can be
+consumed without testing every document of the segment.
+
+For now, only numeric fields provide iterators, with
+DocValuesProducer.NumericIterator().
+*/
+type DocValuesIterator interface {
+	DocIdSetIterator
+	// Advances to exactly target and returns true if it has a value.
+	// Unlike Advance(), the iterator is on target afterwards even if
+	// it has no value. target must be greater than the current doc ID,
+	// and less than maxDoc.
+	AdvanceExact(target int) (bool, error)
+}
+
+// index/NumericDocValues.java
+
+/* Iterator over the values of a numeric doc values field. */
+type NumericDocValuesIterator interface {
+	DocValuesIterator
+	// Returns the value of the current document, which must have one.
+	LongValue() int64
+}
+
+/*
+Returns an iterator over the documents of a dense numeric field,
+which have a value if docsWithField is nil or has them set.
+*/
+func NewNumericDocValuesIterator(values NumericDocValues,
+	docsWithField util.Bits, maxDoc int) NumericDocValuesIterator {
+	return &denseNumericIterator{values, docsWithField, maxDoc, -1}
+}
+
+type denseNumericIterator struct {
+	values        NumericDocValues
+	docsWithField util.Bits
+	maxDoc        int
+	doc           int
+}
+
+func (it *denseNumericIterator) DocId() int {
+	return it.doc
+}
+
+func (it *denseNumericIterator) NextDoc() (int, error) {
+	return it.Advance(it.doc + 1)
+}
+
+func (it *denseNumericIterator) Advance(target int) (int, error) {
+	for it.doc = target; it.doc < it.maxDoc; it.doc++ {
+		if it.docsWithField == nil || it.docsWithField.At(it.doc) {
+			return it.doc, nil
+		}
+	}
+	it.doc = NO_MORE_DOCS
+	return it.doc, nil
+}
+
+func (it *denseNumericIterator) AdvanceExact(target int) (bool, error) {
+	it.doc = target
+	return it.docsWithField == nil || it.docsWithField.At(target), nil
+}
+
+func (it *denseNumericIterator) Cost() int64 {
+	return int64(it.maxDoc)
+}
+
+func (it *denseNumericIterator) LongValue() int64 {
+	return it.values(it.doc)
+}
+
 /* A per-document []byte */
 type BinaryDocValues interface {
 	// Lookup the value for document. The returned BytesRef may be
@@ -111,6 +211,17 @@ type SortedDocValues interface {
 	ValueCount() int
 }
 
+/*
+When returned by NextOrd() it means there are no more ordinals for
+the document.
+*/
+const NO_MORE_ORDS = -1
+
+/*
+A per-document set of presorted []byte values. Per-document values
+must be deduplicated and sorted; ordinals index the unique values of
+the segment, in sorted order.
+*/
 type SortedSetDocValues interface {
 	NextOrd() int64
 	SetDocument(docID int)
@@ -0,0 +1,62 @@
+package spi
+
+import (
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/hnsw"
+	"io"
+)
+
+// codecs/KnnVectorsFormat.java
+
+/*
+Encodes/decodes the vector values of the fields, for nearest neighbor
+search. The dimension and the similarity of the vectors of a field
+are recorded in its FieldInfo.
+*/
+type KnnVectorsFormat interface {
+	// Returns a KnnVectorsWriter to write the vectors of a segment.
+	VectorsWriter(state *SegmentWriteState) (w KnnVectorsWriter, err error)
+	// Returns a KnnVectorsReader to read the vectors of a segment.
+	VectorsReader(state SegmentReadState) (r KnnVectorsReader, err error)
+}
+
+// codecs/KnnVectorsWriter.java
+
+type KnnVectorsWriter interface {
+	io.Closer
+	// Writes the vectors of a field, one per document of the segment,
+	// nil for the documents without vector. As with DocValuesConsumer,
+	// the implementation is free to iterate over them multiple times.
+	AddField(field *FieldInfo, iter func() func() ([]float32, bool)) error
+}
+
+// codecs/KnnVectorsReader.java
+
+type KnnVectorsReader interface {
+	io.Closer
+	// Returns the vectors of the field, or nil if it has none.
+	VectorValues(field *FieldInfo) (VectorValues, error)
+}
+
+// index/VectorValues.java
+
+/*
+The vectors of a field in a segment, identified by their ordinal
+from 0 to Size()-1, in the order of their documents.
+*/
+type VectorValues interface {
+	Size() int
+	Dimension() int
+	Similarity() VectorSimilarity
+	// Returns the vector of the ordinal, which must not be modified.
+	VectorValue(ord int) []float32
+	// Returns the document of the ordinal.
+	Doc(ord int) int
+	// Returns the (approximately) k nearest documents to the target,
+	// nearest first, as neighbors whose Node is the document. ef, at
+	// least k, is the size of the queue of the search: the larger, the
+	// better the recall, but the slower. Only documents accepted by
+	// acceptDocs (all if nil) are returned.
+	Search(target []float32, k, ef int, acceptDocs util.Bits) ([]hnsw.Neighbor, error)
+}
@@ -0,0 +1,26 @@
+package spi
+
+import (
+	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// index/MergeState.java
+
+/*
+Holds the state of a merge, as seen by the codec writers of the newly
+merged segment: the merged field infos, and the readers, live docs
+and size of each segment being merged.
+*/
+type MergeState struct {
+	// SegmentInfo of the newly merged segment.
+	SegmentInfo *model.SegmentInfo
+	// FieldInfos of the newly merged segment.
+	FieldInfos model.FieldInfos
+	// Stored fields reader of each merged segment.
+	StoredFieldsReaders []StoredFieldsReader
+	// Live docs of each merged segment, nil if it has no deletions.
+	LiveDocs []util.Bits
+	// Number of documents of each merged segment, including deleted ones.
+	MaxDocs []int
+}
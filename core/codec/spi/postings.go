@@ -3,6 +3,7 @@ package spi
 import (
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
 	"io"
 )
 
@@ -49,23 +50,32 @@ func (pf *PostingsFormatImpl) String() string {
 	return fmt.Sprintf("PostingsFormat(name=%v)", pf.name)
 }
 
-var allPostingsFormats = map[string]PostingsFormat{}
+var allPostingsFormats = util.NewNamedSPILoader("PostingsFormat")
 
 // workaround Lucene Java's SPI mechanism
 func RegisterPostingsFormat(formats ...PostingsFormat) {
 	for _, format := range formats {
 		fmt.Printf("Found postings format: %v\n", format.Name())
-		allPostingsFormats[format.Name()] = format
+		allPostingsFormats.Register(format.Name(), format)
 	}
 }
 
 /* looks up a format by name */
 func LoadPostingsFormat(name string) PostingsFormat {
-	v, ok := allPostingsFormats[name]
-	assert2(ok, "Service '%v' not found.", name)
+	v, err := LookupPostingsFormat(name)
+	assert2(err == nil, "%v", err)
 	return v
 }
 
+/* Looks up a format by name, and returns an error if none is registered. */
+func LookupPostingsFormat(name string) (PostingsFormat, error) {
+	v, err := allPostingsFormats.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return v.(PostingsFormat), nil
+}
+
 func assert(ok bool) {
 	if !ok {
 		panic("assert fail")
@@ -80,11 +90,7 @@ func assert2(ok bool, msg string, args ...interface{}) {
 
 /* Returns a list of all available format names. */
 func AvailablePostingsFormats() []string {
-	ans := make([]string, 0, len(allPostingsFormats))
-	for name, _ := range allPostingsFormats {
-		ans = append(ans, name)
-	}
-	return ans
+	return allPostingsFormats.AvailableServices()
 }
 
 // codecs/FieldsConsumer.java
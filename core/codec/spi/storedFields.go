@@ -1,6 +1,7 @@
 package spi
 
 import (
+	"github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/index/model"
 	"io"
 )
@@ -65,3 +66,123 @@ type StoredFieldsWriter interface {
 	// described in LUCENE-1282.
 	Finish(fis model.FieldInfos, numDocs int) error
 }
+
+/*
+Implemented by StoredFieldsWriters merging segments faster than
+document by document, e.g. by copying their encoded data as is.
+*/
+type StoredFieldsMerger interface {
+	// Merges the stored fields of the segments of the state, calls
+	// Finish(), and returns the number of documents merged.
+	Merge(state *MergeState) (int, error)
+}
+
+/*
+Merges the stored fields of the segments of the state into the
+writer, with its own Merge() if it is a StoredFieldsMerger, document
+by document otherwise, and returns the number of documents merged.
+*/
+func MergeStoredFields(w StoredFieldsWriter, state *MergeState) (int, error) {
+	if m, ok := w.(StoredFieldsMerger); ok {
+		return m.Merge(state)
+	}
+	docCount := 0
+	for i := range state.StoredFieldsReaders {
+		n, err := CopyStoredFields(w, state, i)
+		if err != nil {
+			return 0, err
+		}
+		docCount += n
+	}
+	return docCount, w.Finish(state.FieldInfos, docCount)
+}
+
+/*
+Copies the live documents of the i-th segment of the state into the
+writer, decoding and re-encoding each of them, and returns their
+number. Field numbers are mapped to the merged field infos.
+*/
+func CopyStoredFields(w StoredFieldsWriter, state *MergeState, i int) (int, error) {
+	reader, liveDocs := state.StoredFieldsReaders[i], state.LiveDocs[i]
+	visitor := &mergeVisitor{w: w, fieldInfos: state.FieldInfos}
+	docCount := 0
+	for docID := 0; docID < state.MaxDocs[i]; docID++ {
+		if liveDocs != nil && !liveDocs.At(docID) {
+			continue
+		}
+		if err := w.StartDocument(); err != nil {
+			return 0, err
+		}
+		if err := reader.VisitDocument(docID, visitor); err != nil {
+			return 0, err
+		}
+		if err := w.FinishDocument(); err != nil {
+			return 0, err
+		}
+		docCount++
+	}
+	return docCount, nil
+}
+
+// codecs/StoredFieldsWriter.java#MergeVisitor
+
+/* Writes the visited stored fields of a document to a writer. */
+type mergeVisitor struct {
+	w          StoredFieldsWriter
+	fieldInfos model.FieldInfos
+}
+
+func (v *mergeVisitor) write(fi *model.FieldInfo, value *storedValue) error {
+	merged := v.fieldInfos.FieldInfoByName(fi.Name)
+	value.name = fi.Name
+	return v.w.WriteField(merged, value)
+}
+
+func (v *mergeVisitor) BinaryField(fi *model.FieldInfo, value []byte) error {
+	return v.write(fi, &storedValue{binaryValue: value})
+}
+
+func (v *mergeVisitor) StringField(fi *model.FieldInfo, value string) error {
+	return v.write(fi, &storedValue{stringValue: value})
+}
+
+func (v *mergeVisitor) IntField(fi *model.FieldInfo, value int) error {
+	return v.write(fi, &storedValue{numericValue: int32(value)})
+}
+
+func (v *mergeVisitor) LongField(fi *model.FieldInfo, value int64) error {
+	return v.write(fi, &storedValue{numericValue: value})
+}
+
+func (v *mergeVisitor) FloatField(fi *model.FieldInfo, value float32) error {
+	return v.write(fi, &storedValue{numericValue: value})
+}
+
+func (v *mergeVisitor) DoubleField(fi *model.FieldInfo, value float64) error {
+	return v.write(fi, &storedValue{numericValue: value})
+}
+
+func (v *mergeVisitor) NeedsField(fi *model.FieldInfo) (StoredFieldVisitorStatus, error) {
+	return STORED_FIELD_VISITOR_STATUS_YES, nil
+}
+
+/* A stored-only field value, as read from a merged segment. */
+type storedValue struct {
+	name         string
+	binaryValue  []byte
+	stringValue  string
+	numericValue interface{}
+}
+
+func (f *storedValue) Name() string                        { return f.name }
+func (f *storedValue) FieldType() model.IndexableFieldType { return nil }
+func (f *storedValue) Boost() float32                      { return 1 }
+func (f *storedValue) BinaryValue() []byte                 { return f.binaryValue }
+func (f *storedValue) StringValue() string                 { return f.stringValue }
+func (f *storedValue) ReaderValue() io.RuneReader          { return nil }
+func (f *storedValue) NumericValue() interface{}           { return f.numericValue }
+func (f *storedValue) VectorValue() []float32              { return nil }
+
+func (f *storedValue) TokenStream(analysis.Analyzer, analysis.TokenStream) (analysis.TokenStream, error) {
+	panic("stored fields of merged segments are not indexed")
+}
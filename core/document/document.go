@@ -64,6 +64,21 @@ func (doc *Document) Get(name string) string {
 	return ""
 }
 
+/*
+Returns the []byte value of the first binary field with the given
+name in this document, or nil if there is none.
+*/
+func (doc *Document) GetBinaryValue(name string) []byte {
+	for _, field := range doc.fields {
+		if field.Name() == name {
+			if v := field.BinaryValue(); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
 // document/DocumentStoredFieldVisitor.java
 /*
 A StoredFieldVisitor that creates a Document containing all
@@ -86,9 +101,8 @@ func NewDocumentStoredFieldVisitor() *DocumentStoredFieldVisitor {
 }
 
 func (visitor *DocumentStoredFieldVisitor) BinaryField(fi *FieldInfo, value []byte) error {
-	panic("not implemented yet")
-	// visitor.doc.add(newStoredField(fieldInfo.name, value))
-	// return nil
+	visitor.doc.Add(NewStoredFieldFromBytes(fi.Name, value))
+	return nil
 }
 
 func (visitor *DocumentStoredFieldVisitor) StringField(fi *FieldInfo, value string) error {
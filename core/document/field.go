@@ -38,6 +38,18 @@ func NewFieldFromReader(name string, reader io.RuneReader, ft *FieldType) *Field
 	return &Field{ft, name, reader, 1.0, nil}
 }
 
+/*
+Create field with TokenStream value. The field must be indexed and
+tokenized, and cannot be stored.
+*/
+func NewFieldFromTokenStream(name string, tokenStream analysis.TokenStream, ft *FieldType) *Field {
+	assert2(name != "", "name cannot be empty")
+	assert2(tokenStream != nil, "tokenStream cannot be nil")
+	assert2(ft.Indexed() && ft.Tokenized(), "TokenStream fields must be indexed and tokenized")
+	assert2(!ft.Stored(), "TokenStream fields cannot be stored")
+	return &Field{ft, name, nil, 1.0, tokenStream}
+}
+
 // Create field with String value
 func NewFieldFromString(name, value string, ft *FieldType) *Field {
 	assert2(name != "", "name cannot be empty")
@@ -47,12 +59,29 @@ func NewFieldFromString(name, value string, ft *FieldType) *Field {
 	return &Field{_type: ft, _name: name, _data: value, _boost: 1}
 }
 
+/*
+Create field with binary value.
+
+NOTE: the provided []byte is not copied so be sure not to change it
+until you're done with this field.
+*/
+func NewFieldFromBytes(name string, value []byte, ft *FieldType) *Field {
+	assert2(name != "", "name cannot be empty")
+	assert2(value != nil, "value cannot be nil")
+	assert2(!ft.indexed, "Fields with BytesRef values cannot be indexed")
+	return &Field{_type: ft, _name: name, _data: value, _boost: 1}
+}
+
 func (f *Field) StringValue() string {
 	switch f._data.(type) {
 	case string:
 		return f._data.(string)
 	case int:
 		return strconv.Itoa(f._data.(int))
+	case int64:
+		return strconv.FormatInt(f._data.(int64), 10)
+	case []byte, []float32:
+		return "" // binary and vector fields have no string value
 	default:
 		log.Println("Unknown type", f._data)
 		panic("not implemented yet")
@@ -96,6 +125,13 @@ func (f *Field) BinaryValue() []byte {
 	return nil
 }
 
+func (f *Field) VectorValue() []float32 {
+	if v, ok := f._data.([]float32); ok {
+		return v
+	}
+	return nil
+}
+
 func (f *Field) String() string {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "%v<%v:", f._type, f._name)
@@ -163,6 +199,11 @@ func (ts *StringTokenStream) setValue(value string) {
 	ts.value = value
 }
 
+func (ts *StringTokenStream) Reset() error {
+	ts.used = false
+	return nil
+}
+
 func (ts *StringTokenStream) IncrementToken() (bool, error) {
 	if ts.used {
 		return false, nil
@@ -219,7 +260,7 @@ String value is indexed as a single token. For example, this might be
 used for a 'country' field or an 'id' field, or any field that you
 intend to use for sorting or access through the field cache.
 */
-func newStringField(name, value string, stored Store) *Field {
+func NewStringField(name, value string, stored Store) *Field {
 	return NewFieldFromString(name, value, map[Store]*FieldType{
 		STORE_YES: STRING_FIELD_TYPE_STORED,
 		STORE_NO:  STRING_FIELD_TYPE_NOT_STORED,
@@ -294,6 +335,118 @@ Create a stored-only field with the given binary value.
 NOTE: the provided byte[] is not copied so be sure
 not to change it until you're done with this field.
 */
-// func newStoredField(name string, value []byte) *StoredField {
-// 	return &StoredField{newStringField(name, value, STORED_FIELD_TYPE)}
-// }
+func NewStoredFieldFromBytes(name string, value []byte) *StoredField {
+	return &StoredField{NewFieldFromBytes(name, value, STORED_FIELD_TYPE)}
+}
+
+// document/NumericDocValuesField.java
+
+// Type for numeric doc values.
+var NUMERIC_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ans := newFieldType()
+	ans.SetDocValueType(model.DOC_VALUES_TYPE_NUMERIC)
+	ans.Freeze()
+	return ans
+}()
+
+/*
+Field that stores a per-document int64 value for scoring, sorting or
+value retrieval. A document without the field has no value, which
+NumericDocValuesIterator() of the reader skips.
+*/
+type NumericDocValuesField struct {
+	*Field
+}
+
+func NewNumericDocValuesField(name string, value int64) *NumericDocValuesField {
+	assert2(name != "", "name cannot be empty")
+	return &NumericDocValuesField{&Field{_type: NUMERIC_DOC_VALUES_FIELD_TYPE,
+		_name: name, _data: value, _boost: 1}}
+}
+
+// document/BinaryDocValuesField.java
+
+// Type for straight bytes doc values.
+var BINARY_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ans := newFieldType()
+	ans.SetDocValueType(model.DOC_VALUES_TYPE_BINARY)
+	ans.Freeze()
+	return ans
+}()
+
+/*
+Field that stores a per-document []byte value. The values are stored
+directly with no sharing, and are compressed as chosen by the doc
+values format of the field (see lucene410.NewLucene410DocValuesFormat).
+A document without the field reads an empty value.
+
+NOTE: the provided []byte is not copied so be sure not to change it
+until you're done with this field.
+*/
+type BinaryDocValuesField struct {
+	*Field
+}
+
+func NewBinaryDocValuesField(name string, value []byte) *BinaryDocValuesField {
+	return &BinaryDocValuesField{NewFieldFromBytes(name, value, BINARY_DOC_VALUES_FIELD_TYPE)}
+}
+
+// document/SortedDocValuesField.java
+
+// Type for sorted bytes doc values.
+var SORTED_DOC_VALUES_FIELD_TYPE = func() *FieldType {
+	ans := newFieldType()
+	ans.SetDocValueType(model.DOC_VALUES_TYPE_SORTED)
+	ans.Freeze()
+	return ans
+}()
+
+/*
+Field that stores a per-document []byte value, indexed for sorting.
+The unique values of a segment are deduplicated and sorted byte-wise,
+and each document refers to its value by ordinal. If you also need to
+store the value, you should add a separate StoredField instance.
+
+NOTE: the provided []byte is not copied so be sure not to change it
+until you're done with this field.
+*/
+type SortedDocValuesField struct {
+	*Field
+}
+
+func NewSortedDocValuesField(name string, bytes []byte) *SortedDocValuesField {
+	return &SortedDocValuesField{NewFieldFromBytes(name, bytes, SORTED_DOC_VALUES_FIELD_TYPE)}
+}
+
+// document/KnnVectorField.java
+
+/*
+A field holding a dense vector of float32 values, indexed for nearest
+neighbor search with search.KnnVectorQuery. All vectors of a field
+must have the same dimension and similarity, which is checked when
+the document is added. The vector is not stored.
+*/
+type VectorField struct {
+	*Field
+}
+
+/*
+Creates a vector field, whose dimension is the length of the vector.
+
+NOTE: the provided []float32 is not copied so be sure not to change
+it until you're done with this field.
+*/
+func NewVectorField(name string, vector []float32, similarity model.VectorSimilarity) *VectorField {
+	assert2(name != "", "name cannot be empty")
+	assert2(len(vector) > 0, "vector cannot be empty")
+	return &VectorField{&Field{_type: NewVectorFieldType(len(vector), similarity),
+		_name: name, _data: vector, _boost: 1}}
+}
+
+/* Returns a frozen type of the vector fields of the given dimension and similarity. */
+func NewVectorFieldType(dimension int, similarity model.VectorSimilarity) *FieldType {
+	ans := newFieldType()
+	ans.SetVectorDimensionsAndSimilarity(dimension, similarity)
+	ans.Freeze()
+	return ans
+}
@@ -34,6 +34,8 @@ type FieldType struct {
 	frozen                   bool
 	numericPrecisionStep     int
 	_docValueType            model.DocValuesType
+	vectorDimension          int
+	vectorSimilarity         model.VectorSimilarity
 }
 
 // Create a new mutable FieldType with all of the properties from <code>ref</code>
@@ -50,10 +52,17 @@ func NewFieldTypeFrom(ref *FieldType) *FieldType {
 	ft._indexOptions = ref._indexOptions
 	ft._docValueType = ref._docValueType
 	ft.numericType = ref.numericType
+	ft.vectorDimension = ref.vectorDimension
+	ft.vectorSimilarity = ref.vectorSimilarity
 	// Do not copy frozen!
 	return ft
 }
 
+/* Create a new mutable FieldType with default properties. */
+func NewFieldType() *FieldType {
+	return newFieldType()
+}
+
 // Create a new FieldType with default properties.
 func newFieldType() *FieldType {
 	return &FieldType{
@@ -72,6 +81,10 @@ func (ft *FieldType) SetIndexed(v bool) { ft.checkIfFrozen(); ft.indexed = v }
 func (ft *FieldType) Stored() bool      { return ft.stored }
 func (ft *FieldType) SetStored(v bool)  { ft.checkIfFrozen(); ft.stored = v }
 func (ft *FieldType) Tokenized() bool   { return ft._tokenized }
+func (ft *FieldType) SetTokenized(v bool) {
+	ft.checkIfFrozen()
+	ft._tokenized = v
+}
 
 func (ft *FieldType) StoreTermVectors() bool       { return ft.storeTermVectors }
 func (ft *FieldType) SetStoreTermVectors(v bool)   { ft.checkIfFrozen(); ft.storeTermVectors = v }
@@ -92,10 +105,47 @@ func (ft *FieldType) SetStoreTermVectorPayloads(v bool) {
 }
 
 func (ft *FieldType) OmitNorms() bool                   { return ft._omitNorms }
+func (ft *FieldType) SetOmitNorms(v bool)               { ft.checkIfFrozen(); ft._omitNorms = v }
 func (ft *FieldType) IndexOptions() model.IndexOptions  { return ft._indexOptions }
 func (ft *FieldType) NumericType() NumericType          { return ft.numericType }
 func (ft *FieldType) DocValueType() model.DocValuesType { return ft._docValueType }
 
+func (ft *FieldType) SetIndexOptions(v model.IndexOptions) {
+	ft.checkIfFrozen()
+	ft._indexOptions = v
+}
+
+/* Sets the type of the doc values of the field, or 0 to disable them. */
+func (ft *FieldType) SetDocValueType(v model.DocValuesType) {
+	ft.checkIfFrozen()
+	ft._docValueType = v
+}
+
+func (ft *FieldType) VectorDimension() int                     { return ft.vectorDimension }
+func (ft *FieldType) VectorSimilarity() model.VectorSimilarity { return ft.vectorSimilarity }
+
+/*
+Enables the indexing of vector values of the given dimension, for
+nearest neighbor search with the given similarity.
+*/
+func (ft *FieldType) SetVectorDimensionsAndSimilarity(dimension int,
+	similarity model.VectorSimilarity) {
+
+	ft.checkIfFrozen()
+	assert2(dimension > 0, "vector dimension must be positive")
+	ft.vectorDimension = dimension
+	ft.vectorSimilarity = similarity
+}
+
+/*
+Prevents future changes. Note, it is recommended that this is called
+once the FieldTypes's properties have been set, to prevent unintentional
+state changes.
+*/
+func (ft *FieldType) Freeze() {
+	ft.frozen = true
+}
+
 // Prints a Field for human consumption.
 func (ft *FieldType) String() string {
 	var buf bytes.Buffer
@@ -138,5 +188,11 @@ func (ft *FieldType) String() string {
 		}
 		fmt.Fprintf(&buf, "docValueType=%v", ft.DocValueType())
 	}
+	if ft.vectorDimension != 0 {
+		if buf.Len() > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, "vectorDimension=%v,vectorSimilarity=%v", ft.vectorDimension, ft.vectorSimilarity)
+	}
 	return buf.String()
 }
@@ -0,0 +1,477 @@
+package index
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+)
+
+// index/AssertingAtomicReader.java
+
+/*
+Wraps the reader, so that its Fields, Terms, TermsEnum and DocsEnum
+validate that they are used correctly, and panic on misuse instead of
+returning undefined results:
+
+  - no use of the reader, or of the enums it returned, after it is
+    closed;
+  - no NextDoc() or Advance() after NO_MORE_DOCS, no Advance() to a
+    target before the current doc, and no Freq() outside a doc;
+  - no Term(), DocFreq(), Docs(), ... on an unpositioned TermsEnum,
+    and no Next() after it is exhausted;
+  - doc IDs returned in increasing order, within [0, maxDoc).
+
+The checks have a cost, so the wrapper is meant to be enabled when
+debugging, by searching the wrapped reader instead of the original.
+Closing the wrapper closes the wrapped reader.
+*/
+func WrapAsserting(r IndexReader) IndexReader {
+	if ar, ok := r.(AtomicReader); ok {
+		return NewAssertingAtomicReader(ar)
+	}
+	return newAssertingCompositeReader(r)
+}
+
+func assertingFailure(format string, args ...interface{}) {
+	panic("Asserting: " + fmt.Sprintf(format, args...))
+}
+
+/* An AtomicReader which checks the contracts of its enums. */
+type AssertingAtomicReader struct {
+	*AtomicReaderImpl
+	in         AtomicReader
+	closeInner bool // false for leaves of a composite, which closes the inner readers
+}
+
+func NewAssertingAtomicReader(in AtomicReader) *AssertingAtomicReader {
+	ans := &AssertingAtomicReader{in: in, closeInner: true}
+	ans.AtomicReaderImpl = newAtomicReader(ans)
+	return ans
+}
+
+/* Panics if the wrapper, or its wrapped reader, is closed. */
+func (r *AssertingAtomicReader) checkOpen(op string) {
+	defer func() {
+		if e := recover(); e != nil {
+			assertingFailure("%v called on %v after it was closed", op, r)
+		}
+	}()
+	r.ensureOpen()
+	r.in.ensureOpen()
+}
+
+func (r *AssertingAtomicReader) NumDocs() int {
+	return r.in.NumDocs()
+}
+
+func (r *AssertingAtomicReader) MaxDoc() int {
+	return r.in.MaxDoc()
+}
+
+func (r *AssertingAtomicReader) checkDocId(op string, docID int) {
+	if docID < 0 || docID >= r.in.MaxDoc() {
+		assertingFailure("%v called with docID=%v outside of [0, %v)", op, docID, r.in.MaxDoc())
+	}
+}
+
+func (r *AssertingAtomicReader) VisitDocument(docID int, visitor StoredFieldVisitor) error {
+	r.checkOpen("VisitDocument")
+	r.checkDocId("VisitDocument", docID)
+	return r.in.VisitDocument(docID, visitor)
+}
+
+func (r *AssertingAtomicReader) doClose() error {
+	if r.closeInner {
+		return r.in.Close()
+	}
+	return nil
+}
+
+func (r *AssertingAtomicReader) Fields() Fields {
+	r.checkOpen("Fields")
+	fields := r.in.Fields()
+	if fields == nil {
+		return nil
+	}
+	return &assertingFields{fields, r}
+}
+
+func (r *AssertingAtomicReader) LiveDocs() util.Bits {
+	r.checkOpen("LiveDocs")
+	return r.in.LiveDocs()
+}
+
+func (r *AssertingAtomicReader) NormValues(field string) (NumericDocValues, error) {
+	r.checkOpen("NormValues")
+	return r.in.NormValues(field)
+}
+
+func (r *AssertingAtomicReader) VectorValues(field string) (VectorValues, error) {
+	r.checkOpen("VectorValues")
+	return r.in.VectorValues(field)
+}
+
+func (r *AssertingAtomicReader) NumericDocValues(field string) (NumericDocValues, error) {
+	r.checkOpen("NumericDocValues")
+	return r.in.NumericDocValues(field)
+}
+
+func (r *AssertingAtomicReader) NumericDocValuesIterator(field string) (NumericDocValuesIterator, error) {
+	r.checkOpen("NumericDocValuesIterator")
+	return r.in.NumericDocValuesIterator(field)
+}
+
+func (r *AssertingAtomicReader) BinaryDocValues(field string) (BinaryDocValues, error) {
+	r.checkOpen("BinaryDocValues")
+	return r.in.BinaryDocValues(field)
+}
+
+func (r *AssertingAtomicReader) SortedDocValues(field string) (SortedDocValues, error) {
+	r.checkOpen("SortedDocValues")
+	return r.in.SortedDocValues(field)
+}
+
+func (r *AssertingAtomicReader) SortedSetDocValues(field string) (SortedSetDocValues, error) {
+	r.checkOpen("SortedSetDocValues")
+	return r.in.SortedSetDocValues(field)
+}
+
+/* Returns the field infos of the wrapped reader, or nil if unknown. */
+func (r *AssertingAtomicReader) FieldInfos() FieldInfos {
+	if fr, ok := r.in.(interface {
+		FieldInfos() FieldInfos
+	}); ok {
+		return fr.FieldInfos()
+	}
+	return FieldInfos{}
+}
+
+func (r *AssertingAtomicReader) String() string {
+	return fmt.Sprintf("AssertingAtomicReader(%v)", r.in)
+}
+
+type assertingFields struct {
+	Fields
+	reader *AssertingAtomicReader
+}
+
+func (f *assertingFields) Terms(field string) Terms {
+	f.reader.checkOpen("Fields.Terms")
+	terms := f.Fields.Terms(field)
+	if terms == nil {
+		return nil
+	}
+	return &assertingTerms{terms, f.reader}
+}
+
+type assertingTerms struct {
+	Terms
+	reader *AssertingAtomicReader
+}
+
+func (t *assertingTerms) Iterator(reuse TermsEnum) TermsEnum {
+	t.reader.checkOpen("Terms.Iterator")
+	// TODO: should we give this thing a random to be super-evil,
+	// and randomly *not* unwrap?
+	if ate, ok := reuse.(*assertingTermsEnum); ok {
+		reuse = ate.TermsEnum
+	}
+	return &assertingTermsEnum{TermsEnum: t.Terms.Iterator(reuse), reader: t.reader}
+}
+
+func (t *assertingTerms) Intersect(compiled *automaton.CompiledAutomaton, startTerm []byte) TermsEnum {
+	t.reader.checkOpen("Terms.Intersect")
+	assert2(startTerm == nil || compiled.Run(startTerm),
+		"Asserting: Terms.Intersect called with startTerm not accepted by the automaton")
+	return &assertingTermsEnum{TermsEnum: t.Terms.Intersect(compiled, startTerm), reader: t.reader}
+}
+
+/* Returns the statistics of the wrapped terms, if known. */
+func (t *assertingTerms) Size() int64 {
+	if ts, ok := t.Terms.(TermsStatistics); ok {
+		return ts.Size()
+	}
+	return -1
+}
+
+func (t *assertingTerms) Min() []byte {
+	if ts, ok := t.Terms.(TermsStatistics); ok {
+		return ts.Min()
+	}
+	return nil
+}
+
+func (t *assertingTerms) Max() []byte {
+	if ts, ok := t.Terms.(TermsStatistics); ok {
+		return ts.Max()
+	}
+	return nil
+}
+
+type termsEnumState int
+
+const (
+	TERMS_ENUM_INITIAL = termsEnumState(iota)
+	TERMS_ENUM_POSITIONED
+	TERMS_ENUM_UNPOSITIONED
+)
+
+type assertingTermsEnum struct {
+	TermsEnum
+	reader *AssertingAtomicReader
+	state  termsEnumState
+}
+
+func (te *assertingTermsEnum) checkPositioned(op string) {
+	te.reader.checkOpen("TermsEnum." + op)
+	if te.state != TERMS_ENUM_POSITIONED {
+		assertingFailure("TermsEnum.%v called on unpositioned TermsEnum", op)
+	}
+}
+
+func (te *assertingTermsEnum) setPositioned(ok bool) {
+	if ok {
+		te.state = TERMS_ENUM_POSITIONED
+	} else {
+		te.state = TERMS_ENUM_UNPOSITIONED
+	}
+}
+
+func (te *assertingTermsEnum) Next() ([]byte, error) {
+	te.reader.checkOpen("TermsEnum.Next")
+	if te.state == TERMS_ENUM_UNPOSITIONED {
+		assertingFailure("TermsEnum.Next called on unpositioned TermsEnum")
+	}
+	term, err := te.TermsEnum.Next()
+	if err != nil {
+		return nil, err
+	}
+	te.setPositioned(term != nil)
+	return term, nil
+}
+
+func (te *assertingTermsEnum) SeekExact(text []byte) (bool, error) {
+	te.reader.checkOpen("TermsEnum.SeekExact")
+	ok, err := te.TermsEnum.SeekExact(text)
+	if err != nil {
+		return false, err
+	}
+	te.setPositioned(ok)
+	return ok, nil
+}
+
+func (te *assertingTermsEnum) SeekCeil(text []byte) SeekStatus {
+	te.reader.checkOpen("TermsEnum.SeekCeil")
+	status := te.TermsEnum.SeekCeil(text)
+	te.setPositioned(status != SEEK_STATUS_END)
+	return status
+}
+
+func (te *assertingTermsEnum) SeekExactByPosition(ord int64) error {
+	te.reader.checkOpen("TermsEnum.SeekExactByPosition")
+	err := te.TermsEnum.SeekExactByPosition(ord)
+	te.setPositioned(err == nil)
+	return err
+}
+
+func (te *assertingTermsEnum) SeekExactFromLast(text []byte, state TermState) error {
+	te.reader.checkOpen("TermsEnum.SeekExactFromLast")
+	assert2(text != nil, "Asserting: TermsEnum.SeekExactFromLast called with nil term")
+	err := te.TermsEnum.SeekExactFromLast(text, state)
+	te.setPositioned(err == nil)
+	return err
+}
+
+func (te *assertingTermsEnum) Term() []byte {
+	te.checkPositioned("Term")
+	return te.TermsEnum.Term()
+}
+
+func (te *assertingTermsEnum) Ord() int64 {
+	te.checkPositioned("Ord")
+	return te.TermsEnum.Ord()
+}
+
+func (te *assertingTermsEnum) DocFreq() (int, error) {
+	te.checkPositioned("DocFreq")
+	return te.TermsEnum.DocFreq()
+}
+
+func (te *assertingTermsEnum) TotalTermFreq() (int64, error) {
+	te.checkPositioned("TotalTermFreq")
+	return te.TermsEnum.TotalTermFreq()
+}
+
+func (te *assertingTermsEnum) TermState() (TermState, error) {
+	te.checkPositioned("TermState")
+	return te.TermsEnum.TermState()
+}
+
+func (te *assertingTermsEnum) Docs(liveDocs util.Bits, reuse DocsEnum) (DocsEnum, error) {
+	return te.DocsByFlags(liveDocs, reuse, DOCS_ENUM_FLAG_FREQS)
+}
+
+func (te *assertingTermsEnum) DocsByFlags(liveDocs util.Bits, reuse DocsEnum, flags int) (DocsEnum, error) {
+	te.checkPositioned("DocsByFlags")
+	// TODO: should we give this thing a random to be super-evil,
+	// and randomly *not* unwrap?
+	if ade, ok := reuse.(*assertingDocsEnum); ok {
+		reuse = ade.DocsEnum
+	}
+	docs, err := te.TermsEnum.DocsByFlags(liveDocs, reuse, flags)
+	if err != nil {
+		return nil, err
+	}
+	assert2(docs != nil, "Asserting: TermsEnum.DocsByFlags returned nil")
+	return &assertingDocsEnum{DocsEnum: docs, reader: te.reader, doc: -1}, nil
+}
+
+func (te *assertingTermsEnum) DocsAndPositions(liveDocs util.Bits, reuse DocsAndPositionsEnum) (DocsAndPositionsEnum, error) {
+	te.checkPositioned("DocsAndPositions")
+	return te.TermsEnum.DocsAndPositions(liveDocs, reuse)
+}
+
+func (te *assertingTermsEnum) DocsAndPositionsByFlags(liveDocs util.Bits, reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+	te.checkPositioned("DocsAndPositionsByFlags")
+	return te.TermsEnum.DocsAndPositionsByFlags(liveDocs, reuse, flags)
+}
+
+func (te *assertingTermsEnum) String() string {
+	return fmt.Sprintf("AssertingTermsEnum(%v)", te.TermsEnum)
+}
+
+type docsEnumState int
+
+const (
+	DOCS_ENUM_START = docsEnumState(iota)
+	DOCS_ENUM_ITERATING
+	DOCS_ENUM_FINISHED
+)
+
+/* Wraps a DocsEnum with additional checks */
+type assertingDocsEnum struct {
+	DocsEnum
+	reader *AssertingAtomicReader
+	state  docsEnumState
+	doc    int
+}
+
+func (de *assertingDocsEnum) checkDoc(op string, nextDoc int) int {
+	if nextDoc == NO_MORE_DOCS {
+		de.state = DOCS_ENUM_FINISHED
+	} else {
+		if nextDoc <= de.doc {
+			assertingFailure("DocsEnum.%v went backwards (from %v to %v) %v", op, de.doc, nextDoc, de.DocsEnum)
+		}
+		if nextDoc >= de.reader.in.MaxDoc() {
+			assertingFailure("DocsEnum.%v returned doc %v beyond maxDoc %v", op, nextDoc, de.reader.in.MaxDoc())
+		}
+		de.state = DOCS_ENUM_ITERATING
+	}
+	if actual := de.DocsEnum.DocId(); actual != nextDoc {
+		assertingFailure("DocsEnum.%v returned %v, but DocId() is %v", op, nextDoc, actual)
+	}
+	de.doc = nextDoc
+	return nextDoc
+}
+
+func (de *assertingDocsEnum) NextDoc() (int, error) {
+	de.reader.checkOpen("DocsEnum.NextDoc")
+	if de.state == DOCS_ENUM_FINISHED {
+		assertingFailure("DocsEnum.NextDoc called after NO_MORE_DOCS")
+	}
+	nextDoc, err := de.DocsEnum.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	return de.checkDoc("NextDoc", nextDoc), nil
+}
+
+func (de *assertingDocsEnum) Advance(target int) (int, error) {
+	de.reader.checkOpen("DocsEnum.Advance")
+	if de.state == DOCS_ENUM_FINISHED {
+		assertingFailure("DocsEnum.Advance called after NO_MORE_DOCS")
+	}
+	if target <= de.doc {
+		assertingFailure("DocsEnum.Advance target must be > DocId(), got %v but DocId()=%v", target, de.doc)
+	}
+	advanced, err := de.DocsEnum.Advance(target)
+	if err != nil {
+		return 0, err
+	}
+	if advanced < target {
+		assertingFailure("DocsEnum.Advance backwards: target=%v but returned %v", target, advanced)
+	}
+	return de.checkDoc("Advance", advanced), nil
+}
+
+func (de *assertingDocsEnum) DocId() int {
+	if actual := de.DocsEnum.DocId(); actual != de.doc {
+		assertingFailure("DocsEnum.DocId is %v, but %v was last returned", actual, de.doc)
+	}
+	return de.doc
+}
+
+func (de *assertingDocsEnum) Freq() (int, error) {
+	de.reader.checkOpen("DocsEnum.Freq")
+	if de.state == DOCS_ENUM_START {
+		assertingFailure("DocsEnum.Freq called before NextDoc()/Advance()")
+	}
+	if de.state == DOCS_ENUM_FINISHED {
+		assertingFailure("DocsEnum.Freq called after NO_MORE_DOCS")
+	}
+	freq, err := de.DocsEnum.Freq()
+	if err == nil && freq <= 0 {
+		assertingFailure("DocsEnum.Freq returned %v for doc %v", freq, de.doc)
+	}
+	return freq, err
+}
+
+func (de *assertingDocsEnum) Cost() int64 {
+	cost := de.DocsEnum.Cost()
+	if cost < 0 {
+		assertingFailure("DocsEnum.Cost returned %v", cost)
+	}
+	return cost
+}
+
+func (de *assertingDocsEnum) String() string {
+	return fmt.Sprintf("AssertingDocsEnum(%v)", de.DocsEnum)
+}
+
+/* A composite reader whose leaves are AssertingAtomicReaders. */
+type assertingCompositeReader struct {
+	*BaseCompositeReader
+	in     IndexReader
+	leaves []*AssertingAtomicReader
+}
+
+func newAssertingCompositeReader(in IndexReader) *assertingCompositeReader {
+	ans := &assertingCompositeReader{in: in}
+	var subs []IndexReader
+	for _, ctx := range in.Leaves() {
+		leaf := NewAssertingAtomicReader(ctx.Reader().(AtomicReader))
+		leaf.closeInner = false
+		ans.leaves = append(ans.leaves, leaf)
+		subs = append(subs, leaf)
+	}
+	ans.BaseCompositeReader = newBaseCompositeReader(ans, subs)
+	return ans
+}
+
+func (r *assertingCompositeReader) doClose() error {
+	// close the wrappers, so that their use afterwards is detected
+	for _, leaf := range r.leaves {
+		if err := leaf.decRef(); err != nil {
+			return err
+		}
+	}
+	return r.in.Close()
+}
+
+func (r *assertingCompositeReader) String() string {
+	return fmt.Sprintf("AssertingCompositeReader(%v)", r.in)
+}
@@ -0,0 +1,67 @@
+package index
+
+import (
+	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"strings"
+	"testing"
+)
+
+func assertPanics(t *testing.T, desc string, f func()) {
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("%v should panic", desc)
+		} else if !strings.Contains(e.(string), "Asserting") {
+			t.Errorf("%v panicked with unexpected message: %v", desc, e)
+		}
+	}()
+	f()
+}
+
+func TestAssertingWrappers(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := store.NewAssertingDirectory(d)
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := WrapAsserting(r)
+	leaf := reader.Leaves()[0].Reader().(AtomicReader)
+
+	termsEnum := leaf.Fields().Terms("content").Iterator(nil)
+	assertPanics(t, "Term() on unpositioned TermsEnum", func() { termsEnum.Term() })
+	term, err := termsEnum.Next()
+	if err != nil || term == nil {
+		t.Fatalf("expected a term, got %v (%v)", term, err)
+	}
+	docs, err := termsEnum.Docs(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPanics(t, "Freq() before NextDoc()", func() { docs.Freq() })
+	count := 0
+	for doc, err := docs.NextDoc(); doc != NO_MORE_DOCS; doc, err = docs.NextDoc() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+	}
+	if count == 0 {
+		t.Error("expected docs for the first term")
+	}
+	assertPanics(t, "NextDoc() after NO_MORE_DOCS", func() { docs.NextDoc() })
+
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertPanics(t, "Fields() after Close()", func() { leaf.Fields() })
+
+	if err = dir.Close(); err != nil {
+		t.Fatal(err)
+	}
+	assertPanics(t, "ListAll() after Close()", func() { dir.ListAll() })
+}
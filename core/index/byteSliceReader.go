@@ -97,5 +97,16 @@ func (r *ByteSliceReader) nextSlice() {
 }
 
 func (r *ByteSliceReader) ReadBytes(buf []byte) error {
-	panic("not implemented yet")
+	for len(buf) > 0 {
+		if numLeft := r.limit - r.upto; numLeft < len(buf) {
+			// Read entire slice
+			buf = buf[copy(buf, r.buffer[r.upto:r.limit]):]
+			r.nextSlice()
+		} else {
+			// This slice is the last one
+			r.upto += copy(buf, r.buffer[r.upto:r.upto+len(buf)])
+			break
+		}
+	}
+	return nil
 }
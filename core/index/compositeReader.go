@@ -5,6 +5,7 @@ import (
 	"container/list"
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/util"
 	"reflect"
 )
 
@@ -126,15 +127,15 @@ type CompositeReaderContextBuilder struct {
 	leafDocBase int
 }
 
-func newCompositeReaderContextBuilder(r CompositeReader) CompositeReaderContextBuilder {
-	return CompositeReaderContextBuilder{reader: r, leaves: list.New()}
+func newCompositeReaderContextBuilder(r CompositeReader) *CompositeReaderContextBuilder {
+	return &CompositeReaderContextBuilder{reader: r, leaves: list.New()}
 }
 
-func (b CompositeReaderContextBuilder) build() *CompositeReaderContext {
+func (b *CompositeReaderContextBuilder) build() *CompositeReaderContext {
 	return b.build4(nil, b.reader, 0, 0).(*CompositeReaderContext)
 }
 
-func (b CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
+func (b *CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
 	reader IndexReader, ord, docBase int) IndexReaderContext {
 	// log.Printf("Building context from %v(parent: %v, %v-%v)", reader, parent, ord, docBase)
 	if ar, ok := reader.(AtomicReader); ok {
@@ -158,7 +159,7 @@ func (b CompositeReaderContextBuilder) build4(parent *CompositeReaderContext,
 	newDocBase := 0
 	for i, r := range sequentialSubReaders {
 		children[i] = b.build4(newParent, r, i, newDocBase)
-		newDocBase = r.MaxDoc()
+		newDocBase += r.MaxDoc()
 	}
 	// assert newDocBase == cr.maxDoc()
 	return newParent
@@ -243,7 +244,16 @@ func (r *BaseCompositeReader) VisitDocument(docID int, visitor StoredFieldVisito
 }
 
 func (r *BaseCompositeReader) DocFreq(term *Term) (int, error) {
-	panic("not implemented yet")
+	r.ensureOpen()
+	total := 0 // sum freqs in subreaders
+	for _, sub := range r.subReaders {
+		n, err := sub.DocFreq(term)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
 }
 
 func (r *BaseCompositeReader) TotalTermFreq(term *Term) int64 {
@@ -262,6 +272,25 @@ func (r *BaseCompositeReader) SumTotalTermFreq(field string) int64 {
 	panic("not implemented yet")
 }
 
+/* Returns the memory used by the accountable sub-readers, e.g. segment readers. */
+func (r *BaseCompositeReader) RamBytesUsed() int64 {
+	var size int64
+	for _, a := range r.ChildResources() {
+		size += a.RamBytesUsed()
+	}
+	return size
+}
+
+func (r *BaseCompositeReader) ChildResources() []util.Accountable {
+	var ans []util.Accountable
+	for _, sub := range r.subReaders {
+		if a, ok := sub.(util.Accountable); ok {
+			ans = append(ans, a)
+		}
+	}
+	return ans
+}
+
 func (r *BaseCompositeReader) readerIndex(docID int) int {
 	if docID < 0 || docID >= r.maxDoc {
 		panic(fmt.Sprintf("docID must be [0, %v] (got docID=%v)", r.maxDoc, docID))
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"hash/fnv"
+	"time"
+)
+
+/*
+Returns the sub-index, in [0,n), a document is written to, out of the
+n sub-indexes of a CompositeIndexWriter.
+*/
+type DocumentRouter func(doc []IndexableField, n int) (int, error)
+
+func routingValue(doc []IndexableField, field string) (IndexableField, error) {
+	for _, f := range doc {
+		if f.Name() == field {
+			return f, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("document has no routing field: %v", field))
+}
+
+/*
+Routes documents by the hash of the string value of the given field,
+e.g. their ID, so that a document is always routed to the same
+sub-index.
+*/
+func HashRouter(field string) DocumentRouter {
+	return func(doc []IndexableField, n int) (int, error) {
+		f, err := routingValue(doc, field)
+		if err != nil {
+			return 0, err
+		}
+		h := fnv.New32a()
+		h.Write([]byte(f.StringValue()))
+		return int(h.Sum32() % uint32(n)), nil
+	}
+}
+
+/*
+Routes documents by the time in the given field, in buckets of the
+given width from start, e.g. one sub-index per day. The time is
+either a string in RFC 3339 format, or a number of milliseconds since
+the epoch. Documents before start go to the first sub-index, and
+those after the last bucket to the last one.
+*/
+func TimeRouter(field string, start time.Time, width time.Duration) DocumentRouter {
+	assert2(width > 0, "width must be > 0 (got %v)", width)
+	return func(doc []IndexableField, n int) (int, error) {
+		f, err := routingValue(doc, field)
+		if err != nil {
+			return 0, err
+		}
+		var t time.Time
+		switch v := f.NumericValue().(type) {
+		case int64:
+			t = time.Unix(0, v*int64(time.Millisecond))
+		case int32:
+			t = time.Unix(0, int64(v)*int64(time.Millisecond))
+		default:
+			if t, err = time.Parse(time.RFC3339, f.StringValue()); err != nil {
+				return 0, errors.New(fmt.Sprintf("invalid time in field %v: %v", field, err))
+			}
+		}
+		bucket := int64(0)
+		if t.After(start) {
+			bucket = int64(t.Sub(start) / width)
+		}
+		if bucket >= int64(n) {
+			bucket = int64(n - 1)
+		}
+		return int(bucket), nil
+	}
+}
+
+/*
+Writes documents to one of several sub-indexes, e.g. shards or time
+partitions, as chosen by a DocumentRouter. The sub-indexes are read
+together with OpenCompositeReader().
+*/
+type CompositeIndexWriter struct {
+	router  DocumentRouter
+	writers []*IndexWriter
+}
+
+func NewCompositeIndexWriter(router DocumentRouter, writers ...*IndexWriter) *CompositeIndexWriter {
+	assert2(len(writers) > 0, "at least one writer is required")
+	return &CompositeIndexWriter{router, writers}
+}
+
+/* Returns the writers of the sub-indexes. */
+func (w *CompositeIndexWriter) Writers() []*IndexWriter {
+	return w.writers
+}
+
+func (w *CompositeIndexWriter) route(doc []IndexableField) (*IndexWriter, error) {
+	i, err := w.router(doc, len(w.writers))
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(w.writers) {
+		return nil, errors.New(fmt.Sprintf("document routed to sub-index %v out of %v", i, len(w.writers)))
+	}
+	return w.writers[i], nil
+}
+
+/* Adds a document to the sub-index it is routed to. */
+func (w *CompositeIndexWriter) AddDocument(doc []IndexableField) error {
+	iw, err := w.route(doc)
+	if err != nil {
+		return err
+	}
+	return iw.AddDocument(doc)
+}
+
+/* Commits every sub-index, returning the first error. */
+func (w *CompositeIndexWriter) Commit() error {
+	var firstErr error
+	for _, iw := range w.writers {
+		if err := iw.Commit(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+/* Closes every sub-index, returning the first error. */
+func (w *CompositeIndexWriter) Close() error {
+	var firstErr error
+	for _, iw := range w.writers {
+		if err := iw.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+/* Returns a reader of the last commit of every sub-index. */
+func (w *CompositeIndexWriter) OpenReader() (*MultiReader, error) {
+	dirs := make([]store.Directory, len(w.writers))
+	for i, iw := range w.writers {
+		dirs[i] = iw.Directory()
+	}
+	return OpenCompositeReader(dirs...)
+}
+
+/* Returns a reader of the last commit of the indexes in the directories, in order. */
+func OpenCompositeReader(dirs ...store.Directory) (*MultiReader, error) {
+	readers := make([]IndexReader, 0, len(dirs))
+	for _, dir := range dirs {
+		r, err := OpenDirectoryReader(dir)
+		if err != nil {
+			for _, r := range readers {
+				util.CloseWhileSuppressingError(r)
+			}
+			return nil, err
+		}
+		readers = append(readers, r)
+	}
+	return NewMultiReader(readers...), nil
+}
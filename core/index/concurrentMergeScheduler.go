@@ -37,7 +37,10 @@ largest merges are paused until one of the smaller merges completes.
 
 If more than MaxMergeCount() merges are requested then this class
 will forcefully throttle the incoming goroutines by pausing until one
-or more merges complete.
+or more merges complete. IndexWriter also checks this before indexing
+each document, so indexing cannot outrun merging. With
+SetRejectWhenStalled(true), MergeStalledError is returned instead of
+pausing.
 */
 type ConcurrentMergeScheduler struct {
 	sync.Locker
@@ -61,6 +64,12 @@ type ConcurrentMergeScheduler struct {
 
 	suppressErrors bool
 
+	// Number of merges handed to workers and not yet finished, guarded
+	// by stall's lock
+	mergeCount        int
+	stall             *sync.Cond
+	rejectWhenStalled bool
+
 	chRequest            chan *MergeJob
 	chSync               chan *sync.WaitGroup
 	concurrentMergeCount int32 // atomic
@@ -70,6 +79,7 @@ type ConcurrentMergeScheduler struct {
 func NewConcurrentMergeScheduler() *ConcurrentMergeScheduler {
 	cms := &ConcurrentMergeScheduler{
 		Locker:    &sync.Mutex{},
+		stall:     sync.NewCond(&sync.Mutex{}),
 		chRequest: make(chan *MergeJob),
 		chSync:    make(chan *sync.WaitGroup),
 	}
@@ -109,6 +119,7 @@ func (cms *ConcurrentMergeScheduler) process(job *MergeJob) {
 	atomic.AddInt32(&cms.concurrentMergeCount, 1)
 	defer func() {
 		atomic.AddInt32(&cms.concurrentMergeCount, -1)
+		cms.mergeDone()
 	}()
 
 	if cms.verbose() {
@@ -128,6 +139,13 @@ func (cms *ConcurrentMergeScheduler) process(job *MergeJob) {
 			cms.handleMergeError(err)
 		}
 	}
+
+	// The merge may have cascaded into new ones, e.g. for a forced
+	// merge; they are handed to the workers as any other merge, which
+	// must not be done by this worker, as it may be the only one
+	if job.writer.hasPendingMerges() {
+		go cms.Merge(job.writer, MERGE_FINISHED, true)
+	}
 }
 
 // Sets the maximum number of merge goroutines and simultaneous
@@ -140,7 +158,11 @@ func (cms *ConcurrentMergeScheduler) SetMaxMergesAndRoutines(maxMergeCount, maxR
 
 	oldCount := cms.maxRoutineCount
 	cms.maxRoutineCount = maxRoutineCount
+
+	cms.stall.L.Lock()
 	cms.maxMergeCount = maxMergeCount
+	cms.stall.Broadcast()
+	cms.stall.L.Unlock()
 
 	cms.Lock()
 	defer cms.Unlock()
@@ -149,6 +171,58 @@ func (cms *ConcurrentMergeScheduler) SetMaxMergesAndRoutines(maxMergeCount, maxR
 	}
 }
 
+/*
+If true, indexing routines are not paused when merging has fallen too
+far behind; MergeStalledError is returned instead, and the operation
+may be retried once merges catch up. Default is false.
+*/
+func (cms *ConcurrentMergeScheduler) SetRejectWhenStalled(reject bool) {
+	cms.stall.L.Lock()
+	defer cms.stall.L.Unlock()
+	cms.rejectWhenStalled = reject
+	cms.stall.Broadcast()
+}
+
+/*
+Blocks the calling routine while maxMergeCount merges are already
+outstanding, or returns MergeStalledError if SetRejectWhenStalled()
+is set.
+*/
+func (cms *ConcurrentMergeScheduler) WaitIfStalled() error {
+	cms.stall.L.Lock()
+	defer cms.stall.L.Unlock()
+
+	for cms.mergeCount >= cms.maxMergeCount {
+		// This means merging has fallen too far behind: we have
+		// already accepted maxMergeCount merges. Note that only
+		// maxRoutineCount of those will actually be running. We stall
+		// this producer routine to prevent creation of new segments,
+		// until merging has caught up:
+		if cms.rejectWhenStalled {
+			return MergeStalledError(fmt.Sprintf(
+				"merging has fallen behind (%v merges outstanding); retry later", cms.mergeCount))
+		}
+		if cms.verbose() {
+			cms.message("    too many merges; stalling...")
+		}
+		cms.stall.Wait()
+	}
+	return nil
+}
+
+func (cms *ConcurrentMergeScheduler) mergeStarted() {
+	cms.stall.L.Lock()
+	defer cms.stall.L.Unlock()
+	cms.mergeCount++
+}
+
+func (cms *ConcurrentMergeScheduler) mergeDone() {
+	cms.stall.L.Lock()
+	defer cms.stall.L.Unlock()
+	cms.mergeCount--
+	cms.stall.Broadcast()
+}
+
 /*
 Returns true if verbosing is enabled. This method is usually used in
 conjunction with message(), like that:
@@ -212,22 +286,27 @@ func (cms *ConcurrentMergeScheduler) Merge(writer *IndexWriter,
 
 	// Iterate, pulling from the IndexWriter's queue of
 	// pending merges, until it's empty:
-	for merge := writer.nextMerge(); merge != nil; merge = writer.nextMerge() {
-		if atomic.LoadInt32(&cms.concurrentMergeCount) >= int32(cms.maxMergeCount) {
-			// This means merging has fallen too far behind: we
-			// have already created maxMergeCount threads, and
-			// now there's at least one more merge pending.
-			// Note that only maxThreadCount of
-			// those created merge threads will actually be
-			// running; the rest will be paused (see
-			// updateMergeThreads).  We stall this producer
-			// thread to prevent creation of new segments,
-			// until merging has caught up:
+	for writer.hasPendingMerges() {
+		if err := cms.WaitIfStalled(); err != nil {
+			// Leave the remaining merges pending in the writer; they are
+			// picked up by a later call once merging catches up
 			if cms.verbose() {
-				cms.message("    too many merges; stalling...")
+				cms.message("    too many merges; leaving remaining merges pending")
 			}
+			break
+		}
+		merge := writer.nextMerge()
+		if merge == nil {
+			break
+		}
+		cms.mergeStarted()
+		job := &MergeJob{time.Now(), writer, merge}
+		if cms.rejectWhenStalled {
+			// don't block the producer waiting for a free worker
+			go func() { cms.chRequest <- job }()
+		} else {
+			cms.chRequest <- job
 		}
-		cms.chRequest <- &MergeJob{time.Now(), writer, merge}
 	}
 	if cms.verbose() {
 		cms.message("  no more merges pending; now return")
@@ -0,0 +1,42 @@
+package index
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeStall(t *testing.T) {
+	cms := NewConcurrentMergeScheduler()
+	defer cms.Close()
+	cms.SetMaxMergesAndRoutines(2, 1)
+
+	cms.mergeStarted()
+	if err := cms.WaitIfStalled(); err != nil {
+		t.Fatalf("Expected no stall below maxMergeCount, but %v", err)
+	}
+	cms.mergeStarted()
+
+	cms.SetRejectWhenStalled(true)
+	if _, ok := cms.WaitIfStalled().(MergeStalledError); !ok {
+		t.Fatal("Expected MergeStalledError when merges fall behind")
+	}
+
+	cms.SetRejectWhenStalled(false)
+	done := make(chan error)
+	go func() { done <- cms.WaitIfStalled() }()
+	select {
+	case <-done:
+		t.Fatal("Expected producer to be stalled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cms.mergeDone()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected producer to resume once a merge finished")
+	}
+}
@@ -2,6 +2,7 @@ package index
 
 import (
 	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
 	"github.com/balzaczyy/golucene/core/util"
 )
 
@@ -63,6 +64,12 @@ Default value for calling checkIntegrity() before merging segments
 */
 const DEFAULT_CHECK_INTEGRITY_AT_MERGE = false
 
+/* Default value for whether calls to IndexWriter.Close() include a commit. */
+const DEFAULT_COMMIT_ON_CLOSE = true
+
+/* Default value for whether IndexWriter.Close() waits for running merges. */
+const DEFAULT_WAIT_FOR_MERGES_ON_CLOSE = true
+
 /*
 Holds all the configuration that is used to create an IndexWriter. Once
 IndexWriter has been created with this object, changes to this object will not
@@ -100,6 +107,17 @@ func (conf *IndexWriterConfig) SetInfoStream(infoStream util.InfoStream) *IndexW
 	return conf
 }
 
+/*
+Flushes and merges are recorded as spans of the tracer. Must not be
+nil, but NO_TRACING may be used to disable tracing.
+*/
+func (conf *IndexWriterConfig) SetTracer(tracer util.Tracer) *IndexWriterConfig {
+	assert2(tracer != nil, "Cannot set Tracer implementation to nil. "+
+		"To disable tracing use NO_TRACING.")
+	conf.tracer = tracer
+	return conf
+}
+
 /*
 Creates a new config that with defaults that match the specified
 Version as well as the default Analyzer. If matchVersion is >= 3.2,
@@ -116,6 +134,12 @@ func NewIndexWriterConfig(matchVersion util.Version, analyzer analysis.Analyzer)
 	}
 }
 
+/* Specifies OpenMode of the index. Only takes effect when IndexWriter is first created. */
+func (conf *IndexWriterConfig) SetOpenMode(openMode OpenMode) *IndexWriterConfig {
+	conf.openMode = openMode
+	return conf
+}
+
 /*
 Expert: allows an optional IndexDeletionPolicy implementation to be
 specified. You can use this to control when prior commits are deleted
@@ -157,6 +181,19 @@ func (conf *IndexWriterConfig) SetSimilarity(similarity Similarity) *IndexWriter
 	return conf
 }
 
+/*
+Set the Codec.
+
+NOTE: the codec cannot be nil.
+
+Only takes effect when IndexWriter is first created.
+*/
+func (conf *IndexWriterConfig) SetCodec(codec Codec) *IndexWriterConfig {
+	assert2(codec != nil, "codec must not be nil")
+	conf.codec = codec
+	return conf
+}
+
 /*
 Expert: sets the merge scheduler used by this writer. The default is
 ConcurentMergeScheduler.
@@ -201,6 +238,11 @@ func (conf *IndexWriterConfig) SetMaxBufferedDocs(maxBufferedDocs int) *IndexWri
 	return conf
 }
 
+func (conf *IndexWriterConfig) SetRAMBufferSizeMB(ramBufferSizeMB float64) *IndexWriterConfig {
+	conf.LiveIndexWriterConfigImpl.SetRAMBufferSizeMB(ramBufferSizeMB)
+	return conf
+}
+
 func (conf *IndexWriterConfig) SetMergedSegmentWarmer(mergeSegmentWarmer IndexReaderWarmer) *IndexWriterConfig {
 	conf.LiveIndexWriterConfigImpl.SetMergedSegmentWarmer(mergeSegmentWarmer)
 	return conf
@@ -216,6 +258,32 @@ func (conf *IndexWriterConfig) SetUseCompoundFile(useCompoundFile bool) *IndexWr
 	return conf
 }
 
+/*
+Sets if calls IndexWriter.Close() should first commit before closing.
+Use true to match behavior of Lucene 4.x. If false, Close() discards
+all changes since the last commit, as Rollback() does, which gives the
+fastest shutdown but relies on the application to commit explicitly.
+
+Only takes effect when IndexWriter is first created.
+*/
+func (conf *IndexWriterConfig) SetCommitOnClose(commitOnClose bool) *IndexWriterConfig {
+	conf.commitOnClose = commitOnClose
+	return conf
+}
+
+/*
+Sets if IndexWriter.Close() should wait for running and pending
+merges to finish before committing. If false, merges are aborted
+instead, trading a faster shutdown for merge work that must be redone
+later. The committed index is consistent either way.
+
+Only takes effect when IndexWriter is first created.
+*/
+func (conf *IndexWriterConfig) SetWaitForMergesOnClose(waitForMerges bool) *IndexWriterConfig {
+	conf.waitForMergesOnClose = waitForMerges
+	return conf
+}
+
 func (conf *IndexWriterConfig) String() string {
 	panic("not implemented yet")
 }
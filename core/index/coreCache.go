@@ -0,0 +1,115 @@
+package index
+
+import (
+	"io"
+	"sync"
+)
+
+/*
+Cache of a value per segment core, e.g. a custom facet structure or
+an array of features loaded from doc values, shared by all readers of
+the segment, e.g. reopened with new deletes.
+
+An entry is dropped once the core is closed, i.e. when the last reader
+of its segment is closed, as after the segment was merged away, so
+that the cache does not keep its values forever. A value implementing
+io.Closer is then closed, as it is by Close().
+
+Only the cores of SegmentReaders are cached: the values of other
+readers, e.g. filtering the fields of a segment, are loaded on each
+call to Get().
+*/
+type CoreCache struct {
+	sync.Mutex
+	load   func(r AtomicReader) (interface{}, error)
+	values map[*SegmentCoreReaders]interface{}
+}
+
+/* Creates a cache of the values returned by load for each segment. */
+func NewCoreCache(load func(r AtomicReader) (interface{}, error)) *CoreCache {
+	assert(load != nil)
+	return &CoreCache{
+		load:   load,
+		values: make(map[*SegmentCoreReaders]interface{}),
+	}
+}
+
+/* Returns the value of the reader's segment, loading it on first use. */
+func (c *CoreCache) Get(r AtomicReader) (interface{}, error) {
+	sr, ok := r.(*SegmentReader)
+	if !ok {
+		return c.load(r)
+	}
+	c.Lock()
+	v, ok := c.values[sr.core]
+	c.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	// loaded without the lock, so that other segments are not blocked
+	v, err := c.load(r)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	if prev, ok := c.values[sr.core]; ok {
+		// loaded concurrently
+		closeValue(v)
+		return prev, nil
+	}
+	c.values[sr.core] = v
+	sr.AddCoreClosedListener(c)
+	return v, nil
+}
+
+/* Returns the number of segments cached. */
+func (c *CoreCache) Size() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.values)
+}
+
+/* Drops the value of a closed core. */
+func (c *CoreCache) OnClose(ownerCoreCacheKey interface{}) {
+	c.Lock()
+	core := ownerCoreCacheKey.(*SegmentCoreReaders)
+	v, ok := c.values[core]
+	delete(c.values, core)
+	c.Unlock()
+	if ok {
+		closeValue(v)
+	}
+}
+
+/*
+Drops all values, closing those implementing io.Closer, and stops
+listening to the cores. The cache can still be used afterwards.
+*/
+func (c *CoreCache) Close() error {
+	c.Lock()
+	values := c.values
+	c.values = make(map[*SegmentCoreReaders]interface{})
+	c.Unlock()
+	var err error
+	for core, v := range values {
+		core.removeCoreClosedListener(c)
+		if closer, ok := v.(io.Closer); ok {
+			if err2 := closer.Close(); err2 != nil {
+				err = mergeError(err, err2)
+			}
+		}
+	}
+	return err
+}
+
+/*
+Closes the value if it implements io.Closer. Errors are dropped as
+there is nobody to report them to when a core is closed.
+*/
+func closeValue(v interface{}) {
+	if closer, ok := v.(io.Closer); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+type closeCounter struct {
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestCoreCache(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads := 0
+	cache := NewCoreCache(func(r AtomicReader) (interface{}, error) {
+		loads++
+		return &closeCounter{}, nil
+	})
+	leaf := r.Leaves()[0].Reader().(AtomicReader)
+	v, err := cache.Get(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2, err := cache.Get(leaf); err != nil || v2 != v {
+		t.Fatalf("expect the cached value, got %v (%v)", v2, err)
+	}
+	if loads != 1 || cache.Size() != 1 {
+		t.Fatalf("expect 1 load and 1 cached segment, got %v and %v", loads, cache.Size())
+	}
+
+	// the values of wrappers are not cached
+	filtered := NewFieldFilterAtomicReader(leaf, AllowFields("content"))
+	if _, err := cache.Get(filtered); err != nil || loads != 2 || cache.Size() != 1 {
+		t.Fatalf("expect a load without caching, got %v loads and %v cached (%v)", loads, cache.Size(), err)
+	}
+
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("expect the closed segment to be evicted, got %v cached", cache.Size())
+	}
+	if closed := v.(*closeCounter).closed; closed != 1 {
+		t.Errorf("expect the evicted value to be closed once, got %v", closed)
+	}
+}
+
+func TestCoreCacheWithoutCompoundFile(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCoreCache(func(r AtomicReader) (interface{}, error) {
+		return &closeCounter{}, nil
+	})
+	if _, err = cache.Get(r.Leaves()[0].Reader().(AtomicReader)); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Size() != 0 {
+		t.Errorf("expect the closed segment to be evicted, got %v cached", cache.Size())
+	}
+}
@@ -1,6 +1,7 @@
 package index
 
 import (
+	"errors"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
@@ -69,6 +70,9 @@ func (c *DefaultIndexingChain) flush(state *SegmentWriteState) (err error) {
 	if err = c.writeDocValues(state); err != nil {
 		return
 	}
+	if err = c.writeVectors(state); err != nil {
+		return
+	}
 
 	// it's possible all docs hit non-aboritng errors...
 	if err = c.initStoredFieldsWriter(); err != nil {
@@ -110,11 +114,13 @@ func (c *DefaultIndexingChain) writeDocValues(state *SegmentWriteState) (err err
 	docCount := state.SegmentInfo.DocCount()
 	var dvConsumer DocValuesConsumer
 	var success = false
-	if success {
-		err = util.Close(dvConsumer)
-	} else {
-		util.CloseWhileSuppressingError(dvConsumer)
-	}
+	defer func() {
+		if success {
+			err = util.Close(dvConsumer)
+		} else {
+			util.CloseWhileSuppressingError(dvConsumer)
+		}
+	}()
 
 	for _, perField := range c.fieldHash {
 		for perField != nil {
@@ -141,6 +147,41 @@ func (c *DefaultIndexingChain) writeDocValues(state *SegmentWriteState) (err err
 	return nil
 }
 
+/* Writes all buffered vectors (called from flush()) */
+func (c *DefaultIndexingChain) writeVectors(state *SegmentWriteState) (err error) {
+	if !state.FieldInfos.HasVectorValues {
+		return nil
+	}
+	var writer KnnVectorsWriter
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(writer)
+		} else {
+			util.CloseWhileSuppressingError(writer)
+		}
+	}()
+
+	format := state.SegmentInfo.Codec().(Codec).KnnVectorsFormat()
+	assert(format != nil)
+	if writer, err = format.VectorsWriter(state); err != nil {
+		return
+	}
+	for _, fi := range state.FieldInfos.Values {
+		if fi.VectorDimension() == 0 {
+			continue
+		}
+		perField := c.perField(fi.Name)
+		assert(perField != nil && perField.vectors != nil)
+		if err = perField.vectors.flush(state, writer); err != nil {
+			return
+		}
+		perField.vectors = nil
+	}
+	success = true
+	return nil
+}
+
 /*
 Catch up for all docs before us that had no stored fields, or hit
 non-aborting errors before writing stored fields.
@@ -355,13 +396,13 @@ func (c *DefaultIndexingChain) processField(field IndexableField,
 			fp.fieldGen = fieldGen
 		}
 	} else {
-		panic("not implemented yet")
+		verifyFieldType(fieldName, fieldType)
 	}
 
 	// Add stored fields:
 	if fieldType.Stored() {
 		if fp == nil {
-			panic("not implemented yet")
+			fp = c.getOrAddField(fieldName, fieldType, false)
 		}
 		if fieldType.Stored() {
 			if err := func() error {
@@ -383,16 +424,101 @@ func (c *DefaultIndexingChain) processField(field IndexableField,
 		}
 	}
 
+	if fieldType.VectorDimension() > 0 {
+		if fp == nil {
+			fp = c.getOrAddField(fieldName, fieldType, false)
+		}
+		if err := c.indexVector(fp, field); err != nil {
+			return 0, err
+		}
+	}
+
 	if dvType := fieldType.DocValueType(); int(dvType) != 0 {
 		if fp == nil {
-			panic("not implemented yet")
+			fp = c.getOrAddField(fieldName, fieldType, false)
+		}
+		if err := c.indexDocValue(fp, dvType, field); err != nil {
+			return 0, err
 		}
-		panic("not implemented yet")
 	}
 
 	return fieldCount, nil
 }
 
+/* Called from processDocument to index one field's doc values. */
+func (c *DefaultIndexingChain) indexDocValue(fp *PerField, dvType DocValuesType, field IndexableField) error {
+	// This will return an error if the caller tried to change the DV
+	// type for the field:
+	if err := c.fieldInfos.SetDocValuesType(fp.fieldInfo, dvType); err != nil {
+		return err
+	}
+
+	docId := c.docState.docID
+	switch dvType {
+	case DOC_VALUES_TYPE_NUMERIC:
+		var value int64
+		switch v := field.NumericValue().(type) {
+		case int64:
+			value = v
+		case int32:
+			value = int64(v)
+		default:
+			return errors.New(fmt.Sprintf(
+				"field '%v' has numeric doc values, but no integer value", fp.fieldInfo.Name))
+		}
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newNumericDocValuesWriter(fp.fieldInfo, c.bytesUsed, true)
+		}
+		fp.docValuesWriter.(*NumericDocValuesWriter).addValue(docId, value)
+	case DOC_VALUES_TYPE_BINARY:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newBinaryDocValuesWriter(fp.fieldInfo, c.bytesUsed)
+		}
+		fp.docValuesWriter.(*BinaryDocValuesWriter).addValue(docId, field.BinaryValue())
+	case DOC_VALUES_TYPE_SORTED:
+		if fp.docValuesWriter == nil {
+			fp.docValuesWriter = newSortedDocValuesWriter(fp.fieldInfo, c.bytesUsed)
+		}
+		return fp.docValuesWriter.(*SortedDocValuesWriter).addValue(docId, field.BinaryValue())
+	default:
+		panic("not implemented yet")
+	}
+	return nil
+}
+
+/* Buffers the vector of the field, after checking its dimension. */
+func (c *DefaultIndexingChain) indexVector(fp *PerField, field IndexableField) error {
+	fieldType := field.FieldType()
+	if n := len(field.VectorValue()); n != fieldType.VectorDimension() {
+		return errors.New(fmt.Sprintf(
+			"vector of field '%v' has %v dimensions, but its type has %v",
+			fp.fieldInfo.Name, n, fieldType.VectorDimension()))
+	}
+	if c.docWriter.codec.KnnVectorsFormat() == nil {
+		return errors.New(fmt.Sprintf("codec %v does not support vectors (field '%v')",
+			c.docWriter.codec.Name(), fp.fieldInfo.Name))
+	}
+	if err := c.fieldInfos.SetVectorProperties(fp.fieldInfo,
+		fieldType.VectorDimension(), fieldType.VectorSimilarity()); err != nil {
+		return err
+	}
+	if fp.vectors == nil {
+		fp.vectors = newVectorValuesWriter(fp.fieldInfo, c.bytesUsed)
+	}
+	return fp.vectors.addValue(c.docState.docID, field.VectorValue())
+}
+
+func verifyFieldType(name string, ft IndexableFieldType) {
+	assert2(!ft.StoreTermVectors(),
+		"cannot store term vectors for a field that is not indexed (field=\"%v\")", name)
+	assert2(!ft.StoreTermVectorPositions(),
+		"cannot store term vector positions for a field that is not indexed (field=\"%v\")", name)
+	assert2(!ft.StoreTermVectorOffsets(),
+		"cannot store term vector offsets for a field that is not indexed (field=\"%v\")", name)
+	assert2(!ft.StoreTermVectorPayloads(),
+		"cannot store term vector payloads for a field that is not indexed (field=\"%v\")", name)
+}
+
 /*
 Returns a previously created PerField, or nil if this field name
 wasn't seen yet.
@@ -460,6 +586,9 @@ type PerField struct {
 	// non-nil if this field ever had doc values in this segment:
 	docValuesWriter DocValuesWriter
 
+	// non-nil if this field ever had vectors in this segment:
+	vectors *VectorValuesWriter
+
 	// We use this to know when a PerField is seen for the first time
 	// in the current document.
 	fieldGen int64
@@ -76,6 +76,11 @@ func newDocumentsWriterDeleteQueueWith(globalBufferedUpdates *BufferedUpdates, g
 	}
 }
 
+/* Returns the number of delete terms buffered globally. */
+func (q *DocumentsWriterDeleteQueue) numGlobalTermDeletes() int {
+	return int(atomic.LoadInt32(&q.globalBufferedUpdates.numTermDeletes))
+}
+
 /* Invariant for document update */
 func (q *DocumentsWriterDeleteQueue) add(term *Term, slice *DeleteSlice) {
 	panic("not implemented yet")
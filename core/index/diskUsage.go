@@ -0,0 +1,186 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/store"
+	"sort"
+	"strings"
+)
+
+/* Describes the index file extensions, by what they hold. */
+var INDEX_FILE_EXTENSIONS = map[string]string{
+	"si":  "segment info",
+	"fnm": "fields",
+	"fdt": "stored fields data",
+	"fdx": "stored fields index",
+	"tim": "term dictionary",
+	"tip": "term index",
+	"doc": "postings (frequencies)",
+	"pos": "postings (positions)",
+	"pay": "postings (payloads/offsets)",
+	"nvd": "norms data",
+	"nvm": "norms metadata",
+	"dvd": "doc values data",
+	"dvm": "doc values metadata",
+	"tvx": "term vectors index",
+	"tvd": "term vectors data",
+	"tvf": "term vectors fields",
+	"liv": "live docs",
+	"del": "deleted docs",
+	"cfs": "compound file",
+	"cfe": "compound file entries",
+	"gen": "commit generation",
+}
+
+/* The size of one index file. */
+type FileUsage struct {
+	Name        string
+	SizeInBytes int64
+}
+
+/* Disk usage of the files of one segment. */
+type SegmentDiskUsage struct {
+	Name        string
+	DocCount    int
+	DelCount    int
+	Files       []FileUsage // sorted by name
+	SizeInBytes int64
+}
+
+/*
+Disk usage breakdown of the index in a directory, by segment and by
+file extension, to show whether e.g. stored fields, postings or doc
+values dominate the index size. Files of compound segments are
+accounted as the compound file.
+*/
+type IndexDiskUsage struct {
+	// Name of the commit point (segments_N) the usage is computed for
+	SegmentsFileName string
+	Segments         []*SegmentDiskUsage
+	// Total size by file extension, e.g. "fdt"
+	ByExtension map[string]int64
+	// Files in the directory not referenced by any segment, e.g. the
+	// commit point itself, or files of older commits
+	OtherFiles       []FileUsage
+	TotalSizeInBytes int64
+}
+
+/* Returns the extension of the file name, without the '.', or "" if none. */
+func FileExtension(filename string) string {
+	if idx := strings.LastIndex(filename, "."); idx != -1 {
+		return filename[idx+1:]
+	}
+	return ""
+}
+
+/* Computes the disk usage of the latest commit of the index in the directory. */
+func ComputeIndexDiskUsage(dir store.Directory) (*IndexDiskUsage, error) {
+	sis := &SegmentInfos{}
+	if err := sis.ReadAll(dir); err != nil {
+		return nil, err
+	}
+
+	ans := &IndexDiskUsage{
+		SegmentsFileName: sis.SegmentsFileName(),
+		ByExtension:      make(map[string]int64),
+	}
+	referenced := make(map[string]bool)
+	for _, info := range sis.Segments {
+		su := &SegmentDiskUsage{
+			Name:     info.Info.Name,
+			DocCount: info.Info.DocCount(),
+			DelCount: info.DelCount(),
+		}
+		files := info.Files()
+		sort.Strings(files)
+		for _, name := range files {
+			size, err := dir.FileLength(name)
+			if err != nil {
+				return nil, err
+			}
+			referenced[name] = true
+			su.Files = append(su.Files, FileUsage{name, size})
+			su.SizeInBytes += size
+			ans.ByExtension[FileExtension(name)] += size
+		}
+		ans.Segments = append(ans.Segments, su)
+		ans.TotalSizeInBytes += su.SizeInBytes
+	}
+
+	all, err := dir.ListAll()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(all)
+	for _, name := range all {
+		if referenced[name] || name == WRITE_LOCK_NAME {
+			continue
+		}
+		size, err := dir.FileLength(name)
+		if err != nil {
+			return nil, err
+		}
+		ans.OtherFiles = append(ans.OtherFiles, FileUsage{name, size})
+		ans.TotalSizeInBytes += size
+	}
+	return ans, nil
+}
+
+type extensionUsage struct {
+	ext  string
+	size int64
+}
+
+type byDescendingSize []extensionUsage
+
+func (a byDescendingSize) Len() int      { return len(a) }
+func (a byDescendingSize) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byDescendingSize) Less(i, j int) bool {
+	if a[i].size != a[j].size {
+		return a[i].size > a[j].size
+	}
+	return a[i].ext < a[j].ext
+}
+
+/* Returns a human readable report, largest extensions first. */
+func (u *IndexDiskUsage) String() string {
+	var exts []extensionUsage
+	for ext, size := range u.ByExtension {
+		exts = append(exts, extensionUsage{ext, size})
+	}
+	sort.Sort(byDescendingSize(exts))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%v: %v segments, %v\n", u.SegmentsFileName, len(u.Segments), sizeString(u.TotalSizeInBytes))
+	for _, e := range exts {
+		pct := 0.0
+		if u.TotalSizeInBytes > 0 {
+			pct = 100 * float64(e.size) / float64(u.TotalSizeInBytes)
+		}
+		fmt.Fprintf(&buf, "  %-4v %-28v %12v %6.2f%%\n", e.ext, INDEX_FILE_EXTENSIONS[e.ext], sizeString(e.size), pct)
+	}
+	for _, su := range u.Segments {
+		fmt.Fprintf(&buf, "  segment %v: docCount=%v delCount=%v %v\n",
+			su.Name, su.DocCount, su.DelCount, sizeString(su.SizeInBytes))
+		for _, f := range su.Files {
+			fmt.Fprintf(&buf, "    %-24v %12v\n", f.Name, sizeString(f.SizeInBytes))
+		}
+	}
+	for _, f := range u.OtherFiles {
+		fmt.Fprintf(&buf, "  other %-24v %12v\n", f.Name, sizeString(f.SizeInBytes))
+	}
+	return buf.String()
+}
+
+func sizeString(size int64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.2f GB", float64(size)/1024/1024/1024)
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
+	case size >= 1024:
+		return fmt.Sprintf("%.2f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%v bytes", size)
+}
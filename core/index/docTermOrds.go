@@ -0,0 +1,114 @@
+package index
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// index/DocTermOrds.java
+
+/*
+Returns the sorted set values of a field of the reader, from its
+SORTED or SORTED_SET doc values if it has some, or else by
+uninverting its indexed terms starting with prefix, if any, with
+NewDocTermOrds(). The prefix is ignored for doc values, whose callers
+must skip the values not starting with it.
+
+Returns nil if the field has neither doc values nor indexed terms.
+*/
+func SortedSetOrUninverted(r AtomicReader, field string, prefix []byte) (SortedSetDocValues, error) {
+	if fr, ok := r.(interface {
+		FieldInfos() FieldInfos
+	}); ok {
+		if info := fr.FieldInfos().FieldInfoByName(field); info != nil {
+			switch info.DocValuesType() {
+			case DOC_VALUES_TYPE_SORTED_SET:
+				return r.SortedSetDocValues(field)
+			case DOC_VALUES_TYPE_SORTED:
+				v, err := r.SortedDocValues(field)
+				if err != nil || v == nil {
+					return nil, err
+				}
+				return SingletonSortedSetDocValues(v), nil
+			}
+		}
+	}
+	if r.Terms(field) == nil {
+		return nil, nil
+	}
+	return NewDocTermOrds(r, field, prefix)
+}
+
+/*
+SortedSetDocValues of the indexed terms of a field, optionally only
+those starting with a prefix, uninverted from its postings: the ords
+of each document are the positions of its terms in the sorted terms.
+
+It takes 4 bytes per term occurrence on top of the terms themselves,
+which makes doc values preferable for fields of many terms per document.
+*/
+type DocTermOrds struct {
+	terms [][]byte
+	docs  [][]int32 // doc -> sorted ords
+	ords  []int32   // ords left of the current doc
+}
+
+/* Uninverts the terms of field starting with prefix, or all if nil. */
+func NewDocTermOrds(r AtomicReader, field string, prefix []byte) (*DocTermOrds, error) {
+	ans := &DocTermOrds{docs: make([][]int32, r.MaxDoc())}
+	terms := r.Terms(field)
+	if terms == nil {
+		return ans, nil
+	}
+	termsEnum := terms.Iterator(nil)
+	var docs DocsEnum
+	// scans up to the prefix, as not all terms dictionaries can SeekCeil()
+	term, err := termsEnum.Next()
+	for ; term != nil; term, err = termsEnum.Next() {
+		if !bytes.HasPrefix(term, prefix) {
+			if bytes.Compare(term, prefix) < 0 {
+				continue
+			}
+			break
+		}
+		ord := int32(len(ans.terms))
+		ans.terms = append(ans.terms, append([]byte(nil), term...))
+		if docs, err = termsEnum.DocsByFlags(r.LiveDocs(), docs, 0); err != nil {
+			return nil, err
+		}
+		for doc, err := docs.NextDoc(); doc != NO_MORE_DOCS; doc, err = docs.NextDoc() {
+			if err != nil {
+				return nil, err
+			}
+			// terms come in order, so that ords are sorted per doc
+			ans.docs[doc] = append(ans.docs[doc], ord)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (v *DocTermOrds) SetDocument(docID int) {
+	v.ords = v.docs[docID]
+}
+
+func (v *DocTermOrds) NextOrd() int64 {
+	if len(v.ords) == 0 {
+		return NO_MORE_ORDS
+	}
+	ans := v.ords[0]
+	v.ords = v.ords[1:]
+	return int64(ans)
+}
+
+func (v *DocTermOrds) LookupOrd(ord int64) []byte {
+	return v.terms[ord]
+}
+
+func (v *DocTermOrds) ValueCount() int64 {
+	return int64(len(v.terms))
+}
@@ -60,11 +60,10 @@ func (w *NumericDocValuesWriter) addValue(docId int, value int64) {
 }
 
 func (w *NumericDocValuesWriter) docsWithFieldBytesUsed() int64 {
-	// size of the []int64 + some overhead
 	if w.docsWithField == nil {
 		return 0
 	}
-	return util.SizeOf(w.docsWithField.Bits()) + 64
+	return w.docsWithField.RamBytesUsed()
 }
 
 func (w *NumericDocValuesWriter) updateBytesUsed() {
@@ -80,10 +79,9 @@ func (w *NumericDocValuesWriter) flush(state *SegmentWriteState,
 
 	maxDoc := state.SegmentInfo.DocCount()
 	values := w.pending.Build()
-	dvConsumer.AddNumericField(w.fieldInfo, func() func() (interface{}, bool) {
+	return dvConsumer.AddNumericField(w.fieldInfo, func() func() (interface{}, bool) {
 		return newNumericIterator(maxDoc, values, w.docsWithField)
 	})
-	return nil
 }
 
 /* Iterates over the values we have in ram */
@@ -116,3 +114,224 @@ func newNumericIterator(maxDoc int,
 		return value, true
 	}
 }
+
+// index/BinaryDocValuesWriter.java
+
+/* Buffers up pending []byte per doc, then flushes when segment flushes. */
+type BinaryDocValuesWriter struct {
+	bytes         []byte
+	lengths       packed.PackedLongValuesBuilder
+	iwBytesUsed   util.Counter
+	bytesUsed     int64
+	docsWithField *util.FixedBitSet
+	fieldInfo     *FieldInfo
+}
+
+func newBinaryDocValuesWriter(fieldInfo *FieldInfo, iwBytesUsed util.Counter) *BinaryDocValuesWriter {
+	ans := &BinaryDocValuesWriter{
+		fieldInfo:     fieldInfo,
+		iwBytesUsed:   iwBytesUsed,
+		docsWithField: util.NewFixedBitSetOf(64),
+		lengths:       packed.DeltaPackedBuilder(packed.PackedInts.COMPACT),
+	}
+	ans.updateBytesUsed()
+	return ans
+}
+
+func (w *BinaryDocValuesWriter) addValue(docId int, value []byte) {
+	assert2(int64(docId) >= w.lengths.Size(),
+		"DocValuesField '%v' appears more than once in this document (only one value is allowed per field)",
+		w.fieldInfo.Name)
+	assert2(value != nil, "field=%v: nil value not allowed", w.fieldInfo.Name)
+
+	// Fill in any holes
+	for i := int(w.lengths.Size()); i < docId; i++ {
+		w.lengths.Add(0)
+	}
+
+	w.lengths.Add(int64(len(value)))
+	w.bytes = append(w.bytes, value...)
+	w.docsWithField = util.EnsureFixedBitSet(w.docsWithField, docId)
+	w.docsWithField.Set(docId)
+
+	w.updateBytesUsed()
+}
+
+func (w *BinaryDocValuesWriter) updateBytesUsed() {
+	newBytesUsed := int64(cap(w.bytes)) + w.lengths.RamBytesUsed() +
+		w.docsWithField.RamBytesUsed()
+	w.iwBytesUsed.AddAndGet(newBytesUsed - w.bytesUsed)
+	w.bytesUsed = newBytesUsed
+}
+
+func (w *BinaryDocValuesWriter) finish(numDoc int) {}
+
+func (w *BinaryDocValuesWriter) flush(state *SegmentWriteState,
+	dvConsumer DocValuesConsumer) error {
+
+	maxDoc := state.SegmentInfo.DocCount()
+	lengths := w.lengths.Build()
+	return dvConsumer.AddBinaryField(w.fieldInfo, func() func() ([]byte, bool) {
+		return newBinaryIterator(maxDoc, w.bytes, lengths, w.docsWithField)
+	})
+}
+
+/* Iterates over the values we have in ram; nil for a doc without value. */
+func newBinaryIterator(maxDoc int, bytes []byte,
+	lengths packed.PackedLongValues,
+	docsWithField *util.FixedBitSet) func() ([]byte, bool) {
+
+	upto, size, offset := 0, int(lengths.Size()), 0
+	iter := lengths.Iterator()
+	return func() ([]byte, bool) {
+		if upto >= maxDoc {
+			return nil, false
+		}
+		var value []byte
+		if upto < size {
+			length, _ := iter()
+			if docsWithField.At(upto) {
+				value = bytes[offset : offset+int(length.(int64))]
+			}
+			offset += int(length.(int64))
+		}
+		upto++
+		return value, true
+	}
+}
+
+// index/SortedDocValuesWriter.java
+
+const EMPTY_ORD int64 = -1
+
+/*
+Buffers up pending []byte per doc, deref and sorting via int ord,
+then flushes when segment flushes.
+*/
+type SortedDocValuesWriter struct {
+	hash        *util.BytesRefHash
+	pending     packed.PackedLongValuesBuilder
+	iwBytesUsed util.Counter
+	bytesUsed   int64 // this currently only tracks differences in 'pending'
+	fieldInfo   *FieldInfo
+}
+
+func newSortedDocValuesWriter(fieldInfo *FieldInfo, iwBytesUsed util.Counter) *SortedDocValuesWriter {
+	ans := &SortedDocValuesWriter{
+		fieldInfo:   fieldInfo,
+		iwBytesUsed: iwBytesUsed,
+		hash: util.NewBytesRefHash(
+			util.NewByteBlockPool(util.NewDirectTrackingAllocator(iwBytesUsed)),
+			util.DEFAULT_CAPACITY,
+			util.NewDirectBytesStartArray(util.DEFAULT_CAPACITY, iwBytesUsed)),
+		pending: packed.DeltaPackedBuilder(packed.PackedInts.COMPACT),
+	}
+	ans.bytesUsed = ans.pending.RamBytesUsed()
+	ans.iwBytesUsed.AddAndGet(ans.bytesUsed)
+	return ans
+}
+
+func (w *SortedDocValuesWriter) addValue(docId int, value []byte) error {
+	assert2(int64(docId) >= w.pending.Size(),
+		"DocValuesField '%v' appears more than once in this document (only one value is allowed per field)",
+		w.fieldInfo.Name)
+	assert2(value != nil, "field '%v': nil value not allowed", w.fieldInfo.Name)
+	assert2(len(value) <= util.BYTE_BLOCK_SIZE-2,
+		"DocValuesField '%v' is too large, must be <= %v",
+		w.fieldInfo.Name, util.BYTE_BLOCK_SIZE-2)
+
+	// Fill in any holes
+	for int(w.pending.Size()) < docId {
+		w.pending.Add(EMPTY_ORD)
+	}
+
+	return w.addOneValue(value)
+}
+
+func (w *SortedDocValuesWriter) finish(maxDoc int) {
+	for int(w.pending.Size()) < maxDoc {
+		w.pending.Add(EMPTY_ORD)
+	}
+	w.updateBytesUsed()
+}
+
+func (w *SortedDocValuesWriter) addOneValue(value []byte) error {
+	termId, err := w.hash.Add(value)
+	if err != nil {
+		return err
+	}
+	if termId < 0 {
+		termId = -termId - 1
+	} else {
+		// reserve additional space for each unique value:
+		// 1. when indexing, when hash is 50% full, rehash() suddenly needs 2*size ints.
+		//    TODO: can this same OOM happen in THPF?
+		// 2. when flushing, we need 1 int per value (slot in the ordMap).
+		w.iwBytesUsed.AddAndGet(2 * util.NUM_BYTES_INT)
+	}
+
+	w.pending.Add(int64(termId))
+	w.updateBytesUsed()
+	return nil
+}
+
+func (w *SortedDocValuesWriter) updateBytesUsed() {
+	newBytesUsed := w.pending.RamBytesUsed()
+	w.iwBytesUsed.AddAndGet(newBytesUsed - w.bytesUsed)
+	w.bytesUsed = newBytesUsed
+}
+
+func (w *SortedDocValuesWriter) flush(state *SegmentWriteState,
+	dvConsumer DocValuesConsumer) error {
+
+	maxDoc := state.SegmentInfo.DocCount()
+	assert(w.pending.Size() == int64(maxDoc))
+	valueCount := w.hash.Size()
+	ords := w.pending.Build()
+
+	sortedValues := w.hash.Sort(util.UTF8SortedAsUnicodeLess)
+	ordMap := make([]int, valueCount)
+	for ord := 0; ord < valueCount; ord++ {
+		ordMap[sortedValues[ord]] = ord
+	}
+
+	return dvConsumer.AddSortedField(w.fieldInfo,
+		// ord -> value
+		func() func() ([]byte, bool) {
+			return newSortedValuesIterator(sortedValues[:valueCount], w.hash)
+		},
+		// doc -> ord
+		func() func() (interface{}, bool) {
+			return newOrdsIterator(ordMap, ords)
+		})
+}
+
+/* Iterates over the unique values we have in ram, in sorted order. */
+func newSortedValuesIterator(sortedValues []int, hash *util.BytesRefHash) func() ([]byte, bool) {
+	ordUpto, scratch := 0, util.NewEmptyBytesRef()
+	return func() ([]byte, bool) {
+		if ordUpto >= len(sortedValues) {
+			return nil, false
+		}
+		hash.Get(sortedValues[ordUpto], scratch)
+		ordUpto++
+		return scratch.ToBytes(), true
+	}
+}
+
+/* Iterates over the ords of the docs; nil for a doc without value. */
+func newOrdsIterator(ordMap []int, ords packed.PackedLongValues) func() (interface{}, bool) {
+	docUpto, size := 0, int(ords.Size())
+	iter := ords.Iterator()
+	return func() (interface{}, bool) {
+		if docUpto >= size {
+			return nil, false
+		}
+		v, _ := iter()
+		docUpto++
+		if ord := v.(int64); ord != EMPTY_ORD {
+			return int64(ordMap[ord]), true
+		}
+		return nil, true
+	}
+}
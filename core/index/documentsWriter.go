@@ -2,6 +2,7 @@ package index
 
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/index/model"
@@ -9,6 +10,7 @@ import (
 	"github.com/balzaczyy/golucene/core/util"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // index/DocumentsWriter.java
@@ -403,10 +405,24 @@ func (dw *DocumentsWriter) doFlush(flushingDWPT *DocumentsWriterPerThread) (bool
 					}()
 
 					// flush concurrently without locking
+					_, span := dw.config.Tracer().Start(context.Background(), "DocumentsWriter.flush")
+					if span.IsRecording() {
+						span.SetAttribute("segment", flushingDWPT.segmentInfo.Name)
+						span.SetAttribute("docs", flushingDocsInRAM)
+					}
+					start := time.Now()
 					newSegment, err := flushingDWPT.flush()
+					span.End(err)
 					if err != nil {
 						return err
 					}
+					if newSegment != nil {
+						size, err := newSegment.segmentInfo.SizeInBytes()
+						if err != nil {
+							return err
+						}
+						dw.writer.stats.recordFlush(size, time.Since(start))
+					}
 					dw.ticketQueue.addSegment(ticket, newSegment)
 					dwptSuccess = true
 					return nil
@@ -176,12 +176,13 @@ func (dwpt *DocumentsWriterPerThread) testPoint(msg string) {
 Anything that will add N docs to the index should reserve first to
 make sure it's allowed.
 */
-func (dwpt *DocumentsWriterPerThread) reserveDoc() {
-	if atomic.AddInt64(dwpt.pendingNumDocs, 1) > int64(actualMaxDocs) {
+func (dwpt *DocumentsWriterPerThread) reserveDoc() error {
+	if n := atomic.AddInt64(dwpt.pendingNumDocs, 1); n > int64(actualMaxDocs) {
 		// reserve failed
 		atomic.AddInt64(dwpt.pendingNumDocs, -1)
-		panic(fmt.Sprintf("number of documents in the index cannot exceed %v", actualMaxDocs))
+		return &TooManyDocsError{n, actualMaxDocs}
 	}
+	return nil
 }
 
 func (dwpt *DocumentsWriterPerThread) updateDocument(doc []IndexableField,
@@ -201,7 +202,10 @@ func (dwpt *DocumentsWriterPerThread) updateDocument(doc []IndexableField,
 	// will actually "lose" more than one document, so the counter will
 	// be "wrong" in that case, but it's very hard to fix (we can't
 	// easily distinguish aborting vs non-aborting errors):
-	dwpt.reserveDoc()
+	if err := dwpt.reserveDoc(); err != nil {
+		dwpt.docState.clear()
+		return err
+	}
 	if err := func() error {
 		var success = false
 		defer func() {
@@ -0,0 +1,232 @@
+package index
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// test-framework/index/FieldFilterAtomicReader.java
+
+/*
+Wraps the reader, so that the fields rejected by accept are hidden
+from its users, as if they were not indexed: their stored values,
+postings, norms, doc values and term vectors are not returned, and
+queries on them match nothing. This allows enforcing field-level
+permissions, e.g. per tenant, below the query layer, by searching the
+wrapped reader.
+
+Closing the wrapper closes the wrapped reader.
+*/
+func WrapFieldFilter(r IndexReader, accept func(field string) bool) IndexReader {
+	if ar, ok := r.(AtomicReader); ok {
+		return NewFieldFilterAtomicReader(ar, accept)
+	}
+	return newFieldFilterCompositeReader(r, accept)
+}
+
+/* Returns a filter accepting only the given fields. */
+func AllowFields(fields ...string) func(field string) bool {
+	allowed := make(map[string]bool)
+	for _, field := range fields {
+		allowed[field] = true
+	}
+	return func(field string) bool { return allowed[field] }
+}
+
+/* Returns a filter accepting all fields but the given ones. */
+func DenyFields(fields ...string) func(field string) bool {
+	denied := make(map[string]bool)
+	for _, field := range fields {
+		denied[field] = true
+	}
+	return func(field string) bool { return !denied[field] }
+}
+
+/* An AtomicReader hiding the fields rejected by a filter. */
+type FieldFilterAtomicReader struct {
+	*AtomicReaderImpl
+	in         AtomicReader
+	accept     func(field string) bool
+	closeInner bool // false for leaves of a composite, which closes the inner readers
+}
+
+func NewFieldFilterAtomicReader(in AtomicReader, accept func(field string) bool) *FieldFilterAtomicReader {
+	ans := &FieldFilterAtomicReader{in: in, accept: accept, closeInner: true}
+	ans.AtomicReaderImpl = newAtomicReader(ans)
+	return ans
+}
+
+func (r *FieldFilterAtomicReader) NumDocs() int {
+	return r.in.NumDocs()
+}
+
+func (r *FieldFilterAtomicReader) MaxDoc() int {
+	return r.in.MaxDoc()
+}
+
+func (r *FieldFilterAtomicReader) VisitDocument(docID int, visitor StoredFieldVisitor) error {
+	return r.in.VisitDocument(docID, &fieldFilterVisitor{visitor, r.accept})
+}
+
+func (r *FieldFilterAtomicReader) doClose() error {
+	if r.closeInner {
+		return r.in.Close()
+	}
+	return nil
+}
+
+func (r *FieldFilterAtomicReader) Fields() Fields {
+	fields := r.in.Fields()
+	if fields == nil {
+		return nil
+	}
+	return &fieldFilterFields{fields, r.accept}
+}
+
+/* Returns the term vectors of the accepted fields, or nil if the wrapped reader has none. */
+func (r *FieldFilterAtomicReader) TermVectors(docID int) (Fields, error) {
+	tv, ok := r.in.(interface {
+		TermVectors(docID int) (Fields, error)
+	})
+	if !ok {
+		return nil, nil
+	}
+	fields, err := tv.TermVectors(docID)
+	if fields == nil || err != nil {
+		return nil, err
+	}
+	return &fieldFilterFields{fields, r.accept}, nil
+}
+
+func (r *FieldFilterAtomicReader) LiveDocs() util.Bits {
+	return r.in.LiveDocs()
+}
+
+func (r *FieldFilterAtomicReader) NormValues(field string) (NumericDocValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.NormValues(field)
+}
+
+func (r *FieldFilterAtomicReader) VectorValues(field string) (VectorValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.VectorValues(field)
+}
+
+func (r *FieldFilterAtomicReader) NumericDocValues(field string) (NumericDocValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.NumericDocValues(field)
+}
+
+func (r *FieldFilterAtomicReader) NumericDocValuesIterator(field string) (NumericDocValuesIterator, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.NumericDocValuesIterator(field)
+}
+
+func (r *FieldFilterAtomicReader) BinaryDocValues(field string) (BinaryDocValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.BinaryDocValues(field)
+}
+
+func (r *FieldFilterAtomicReader) SortedDocValues(field string) (SortedDocValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.SortedDocValues(field)
+}
+
+func (r *FieldFilterAtomicReader) SortedSetDocValues(field string) (SortedSetDocValues, error) {
+	if !r.accept(field) {
+		return nil, nil
+	}
+	return r.in.SortedSetDocValues(field)
+}
+
+/* Returns the field infos of the accepted fields of the wrapped reader. */
+func (r *FieldFilterAtomicReader) FieldInfos() FieldInfos {
+	fr, ok := r.in.(interface {
+		FieldInfos() FieldInfos
+	})
+	if !ok {
+		return FieldInfos{}
+	}
+	var infos []*FieldInfo
+	for _, fi := range fr.FieldInfos().Values {
+		if r.accept(fi.Name) {
+			infos = append(infos, fi)
+		}
+	}
+	return NewFieldInfos(infos)
+}
+
+func (r *FieldFilterAtomicReader) String() string {
+	return fmt.Sprintf("FieldFilterAtomicReader(%v)", r.in)
+}
+
+type fieldFilterFields struct {
+	Fields
+	accept func(field string) bool
+}
+
+func (f *fieldFilterFields) Terms(field string) Terms {
+	if !f.accept(field) {
+		return nil
+	}
+	return f.Fields.Terms(field)
+}
+
+type fieldFilterVisitor struct {
+	StoredFieldVisitor
+	accept func(field string) bool
+}
+
+func (v *fieldFilterVisitor) NeedsField(fi *FieldInfo) (StoredFieldVisitorStatus, error) {
+	if !v.accept(fi.Name) {
+		return STORED_FIELD_VISITOR_STATUS_NO, nil
+	}
+	return v.StoredFieldVisitor.NeedsField(fi)
+}
+
+/* A composite reader whose leaves are FieldFilterAtomicReaders. */
+type fieldFilterCompositeReader struct {
+	*BaseCompositeReader
+	in     IndexReader
+	leaves []*FieldFilterAtomicReader
+}
+
+func newFieldFilterCompositeReader(in IndexReader, accept func(field string) bool) *fieldFilterCompositeReader {
+	ans := &fieldFilterCompositeReader{in: in}
+	var subs []IndexReader
+	for _, ctx := range in.Leaves() {
+		leaf := NewFieldFilterAtomicReader(ctx.Reader().(AtomicReader), accept)
+		leaf.closeInner = false
+		ans.leaves = append(ans.leaves, leaf)
+		subs = append(subs, leaf)
+	}
+	ans.BaseCompositeReader = newBaseCompositeReader(ans, subs)
+	return ans
+}
+
+func (r *fieldFilterCompositeReader) doClose() error {
+	for _, leaf := range r.leaves {
+		if err := leaf.decRef(); err != nil {
+			return err
+		}
+	}
+	return r.in.Close()
+}
+
+func (r *fieldFilterCompositeReader) String() string {
+	return fmt.Sprintf("FieldFilterCompositeReader(%v)", r.in)
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+func TestFieldFilterReader(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	bat := NewTerm("content", "bat")
+	if n, err := r.DocFreq(bat); err != nil || n != 8 {
+		t.Fatalf("expect 8 docs with bat, got %v (%v)", n, err)
+	}
+	if doc, err := r.Document(0); err != nil || doc.Get("title") == "" {
+		t.Fatalf("expect a stored title, got %v (%v)", doc, err)
+	}
+
+	// closing a wrapper would close the original reader
+	noContent := newFieldFilterCompositeReader(r, DenyFields("content"))
+	if n, err := noContent.DocFreq(bat); err != nil || n != 0 {
+		t.Errorf("expect content to be hidden, got %v docs (%v)", n, err)
+	}
+	leaf := noContent.Leaves()[0].Reader().(*FieldFilterAtomicReader)
+	if leaf.Terms("content") != nil || leaf.FieldInfos().FieldInfoByName("content") != nil {
+		t.Error("expect no terms nor field info for content")
+	}
+	if doc, err := noContent.Document(0); err != nil || doc.Get("title") == "" {
+		t.Errorf("expect title to be visible, got %v (%v)", doc, err)
+	}
+
+	onlyContent := newFieldFilterCompositeReader(r, AllowFields("content"))
+	if n, err := onlyContent.DocFreq(bat); err != nil || n != 8 {
+		t.Errorf("expect 8 docs with bat, got %v (%v)", n, err)
+	}
+	if doc, err := onlyContent.Document(0); err != nil || doc.Get("title") != "" {
+		t.Errorf("expect title to be hidden, got %v (%v)", doc, err)
+	}
+}
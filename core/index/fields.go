@@ -26,8 +26,7 @@ func (mf MultiFields) Terms(field string) Terms {
 
 	// Gather all sub-readers that share this field
 	for i, v := range mf.subs {
-		terms := v.Terms(field)
-		if terms.Iterator != nil {
+		if terms := v.Terms(field); terms != nil {
 			subs2 = append(subs2, terms)
 			slices2 = append(slices2, mf.subSlices[i])
 		}
@@ -62,7 +61,7 @@ func GetMultiFields(r IndexReader) Fields {
 				continue
 			}
 			fields = append(fields, f)
-			slices = append(slices, ReaderSlice{ctx.DocBase, r.MaxDoc(), len(fields)})
+			slices = append(slices, ReaderSlice{ctx.DocBase, ctx.Reader().MaxDoc(), len(fields) - 1})
 		}
 		// log.Printf("Found %v fields in %v slices.", len(fields), len(slices))
 		switch len(fields) {
@@ -79,7 +78,7 @@ func GetMultiFields(r IndexReader) Fields {
 func GetMultiTerms(r IndexReader, field string) Terms {
 	// log.Printf("Loading field '%v' from %v", field, r)
 	fields := GetMultiFields(r)
-	if fields.Terms == nil {
+	if fields == nil {
 		return nil
 	}
 	return fields.Terms(field)
@@ -124,7 +124,8 @@ func newIndexFileDeleter(directory store.Directory, policy IndexDeletionPolicy,
 		for _, filename := range files {
 			if !strings.HasSuffix(filename, WRITE_LOCK_NAME) &&
 				filename != INDEX_FILENAME_SEGMENTS_GEN &&
-				(m.MatchString(filename) || strings.HasPrefix(filename, util.SEGMENTS)) {
+				(m.MatchString(filename) || strings.HasPrefix(filename, util.SEGMENTS) ||
+					strings.HasPrefix(filename, INDEX_FILENAME_PENDING_SEGMENTS)) {
 
 				// Add this file to refCounts with initial count 0:
 				fd.refCount(filename)
@@ -353,7 +354,8 @@ func (fd *IndexFileDeleter) refresh(segmentName string) error {
 			strings.HasPrefix(filename, prefix2)) &&
 			!strings.HasSuffix(filename, WRITE_LOCK_NAME) &&
 			!hasRef && filename != INDEX_FILENAME_SEGMENTS_GEN &&
-			(m.MatchString(filename) || strings.HasPrefix(filename, INDEX_FILENAME_SEGMENTS)) {
+			(m.MatchString(filename) || strings.HasPrefix(filename, INDEX_FILENAME_SEGMENTS) ||
+				strings.HasPrefix(filename, INDEX_FILENAME_PENDING_SEGMENTS)) {
 
 			// Unreferenced file, so remove it
 			if fd.infoStream.IsEnabled("IFD") {
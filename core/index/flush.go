@@ -162,7 +162,7 @@ func (p *FlushByRamOrCountsPolicy) onDelete(control *DocumentsWriterFlushControl
 func (p *FlushByRamOrCountsPolicy) onInsert(control *DocumentsWriterFlushControl, state *ThreadState) {
 	if p.flushOnDocCount() && state.dwpt.numDocsInRAM >= p.indexWriterConfig.MaxBufferedDocs() {
 		// flush this state by num docs
-		control.setFlushPending(state)
+		control._setFlushPending(state)
 	} else if p.flushOnRAM() { // flush by RAM
 		limit := int64(p.indexWriterConfig.RAMBufferSizeMB() * 1024 * 1024)
 		totalRam := control._activeBytes + control.deleteBytesUsed() // safe w/o sync
@@ -180,7 +180,7 @@ func (p *FlushByRamOrCountsPolicy) onInsert(control *DocumentsWriterFlushControl
 /* Marks the mos tram consuming active DWPT flush pending */
 func (p *FlushByRamOrCountsPolicy) markLargestWriterPending(control *DocumentsWriterFlushControl,
 	perThreadState *ThreadState, currentBytesPerThread int64) {
-	control.setFlushPending(p.findLargestNonPendingWriter(control, perThreadState))
+	control._setFlushPending(p.findLargestNonPendingWriter(control, perThreadState))
 }
 
 /* Returns true if this FLushPolicy flushes on IndexWriterConfig.MaxBufferedDocs(), otherwise false */
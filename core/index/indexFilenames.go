@@ -3,4 +3,7 @@ package index
 const (
 	INDEX_FILENAME_SEGMENTS     = "segments"
 	INDEX_FILENAME_SEGMENTS_GEN = "segments.gen"
+	// Name of pending index segment file; it is renamed to segments_N
+	// once the commit is complete and durable
+	INDEX_FILENAME_PENDING_SEGMENTS = "pending_segments"
 )
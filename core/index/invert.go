@@ -56,8 +56,11 @@ func (st *FieldInvertState) setAttributeSource(attributeSource *util.AttributeSo
 		st.termAttribute = attributeSource.Get("TermToBytesRefAttribute").(TermToBytesRefAttribute)
 		st.posIncrAttribute = attributeSource.Add("PositionIncrementAttribute").(PositionIncrementAttribute)
 		st.offsetAttribute = attributeSource.Add("OffsetAttribute").(OffsetAttribute)
-		// st.payloadAttribute = attributeSource.Get("PayloadAttribute").(PayloadAttribute)
-		st.payloadAttribute = nil
+		if attributeSource.Has("PayloadAttribute") {
+			st.payloadAttribute = attributeSource.Get("PayloadAttribute").(PayloadAttribute)
+		} else {
+			st.payloadAttribute = nil
+		}
 	}
 }
 
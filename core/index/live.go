@@ -23,12 +23,17 @@ type LiveIndexWriterConfig interface {
 	Similarity() Similarity
 	Codec() Codec
 	MergePolicy() MergePolicy
+	MergedSegmentWarmer() IndexReaderWarmer
+	setMergedSegmentWarmer(IndexReaderWarmer)
 	indexingChain() IndexingChain
 	RAMPerThreadHardLimitMB() int
 	flushPolicy() FlushPolicy
 	InfoStream() util.InfoStream
+	Tracer() util.Tracer
 	indexerThreadPool() *DocumentsWriterPerThreadPool
 	UseCompoundFile() bool
+	CommitOnClose() bool
+	WaitForMergesOnClose() bool
 }
 
 type LiveIndexWriterConfigImpl struct {
@@ -69,6 +74,9 @@ type LiveIndexWriterConfigImpl struct {
 	// InfoStream for debugging messages.
 	infoStream util.InfoStream
 
+	// Tracer of flushes and merges.
+	tracer util.Tracer
+
 	// MergePolicy for selecting merges.
 	mergePolicy MergePolicy
 
@@ -94,6 +102,12 @@ type LiveIndexWriterConfigImpl struct {
 
 	// True if merging should check integrity of segments before merge
 	checkIntegrityAtMerge bool // volatile
+
+	// True if calls to IndexWriter.Close() should first do a commit.
+	commitOnClose bool
+
+	// True if IndexWriter.Close() should wait for running merges.
+	waitForMergesOnClose bool
 }
 
 // used by IndexWriterConfig
@@ -119,12 +133,15 @@ func newLiveIndexWriterConfig(analyzer analysis.Analyzer,
 		_indexingChain:          defaultIndexingChain,
 		codec:                   DefaultCodec(),
 		infoStream:              util.DefaultInfoStream(),
+		tracer:                  util.DefaultTracer(),
 		mergePolicy:             NewTieredMergePolicy(),
 		_flushPolicy:            newFlushByRamOrCountsPolicy(),
 		readerPooling:           DEFAULT_READER_POOLING,
 		_indexerThreadPool:      NewDocumentsWriterPerThreadPool(DEFAULT_MAX_THREAD_STATES),
 		perRoutineHardLimitMB:   DEFAULT_RAM_PER_THREAD_HARD_LIMIT_MB,
 		checkIntegrityAtMerge:   DEFAULT_CHECK_INTEGRITY_AT_MERGE,
+		commitOnClose:           DEFAULT_COMMIT_ON_CLOSE,
+		waitForMergesOnClose:    DEFAULT_WAIT_FOR_MERGES_ON_CLOSE,
 	}
 }
 
@@ -204,6 +221,33 @@ func (conf *LiveIndexWriterConfigImpl) SetMergePolicy(mergePolicy MergePolicy) *
 	return conf
 }
 
+/*
+Determines the amount of RAM that may be used for buffering added
+documents and deletions before they are flushed to the Directory.
+Generally for faster indexing performance it's best to flush by RAM
+usage instead of document count and use as large a RAM buffer as you
+can.
+
+Pass in DISABLE_AUTO_FLUSH to flush by document count only
+(MaxBufferedDocs must be enabled then). Note that if flushing by
+document count is also enabled, then the flush will be triggered by
+whichever comes first.
+
+The maximum RAM limit is inherently determined by RAMPerThreadHardLimitMB.
+
+Takes effect immediately, but only the next time a document is added,
+updated or deleted.
+*/
+func (conf *LiveIndexWriterConfigImpl) SetRAMBufferSizeMB(ramBufferSizeMB float64) *LiveIndexWriterConfigImpl {
+	assert2(ramBufferSizeMB == DISABLE_AUTO_FLUSH || ramBufferSizeMB > 0,
+		"ramBufferSize should be > 0.0 MB when enabled")
+	assert2(ramBufferSizeMB != DISABLE_AUTO_FLUSH || conf.maxBufferedDocs != DISABLE_AUTO_FLUSH,
+		"at least one of ramBufferSize and maxBufferedDocs must be enabled")
+	conf.ramBufferSizeMB = ramBufferSizeMB
+	return conf
+}
+
+/* Returns the value set by SetRAMBufferSizeMB() if enabled. */
 func (conf *LiveIndexWriterConfigImpl) RAMBufferSizeMB() float64 {
 	return conf.ramBufferSizeMB
 }
@@ -214,10 +258,19 @@ Sets the merged segment warmer.
 Take effect on the next merge.
 */
 func (conf *LiveIndexWriterConfigImpl) SetMergedSegmentWarmer(mergeSegmentWarmer IndexReaderWarmer) *LiveIndexWriterConfigImpl {
-	conf.mergedSegmentWarmer = mergeSegmentWarmer
+	conf.setMergedSegmentWarmer(mergeSegmentWarmer)
 	return conf
 }
 
+func (conf *LiveIndexWriterConfigImpl) setMergedSegmentWarmer(mergeSegmentWarmer IndexReaderWarmer) {
+	conf.mergedSegmentWarmer = mergeSegmentWarmer
+}
+
+/* Returns the current merged segment warmer. */
+func (conf *LiveIndexWriterConfigImpl) MergedSegmentWarmer() IndexReaderWarmer {
+	return conf.mergedSegmentWarmer
+}
+
 /*
 Sets the termsIndeDivisor passed to any readers that IndexWriter
 opens, for example when applying deletes or creating a near-real-time
@@ -278,6 +331,11 @@ func (conf *LiveIndexWriterConfigImpl) InfoStream() util.InfoStream {
 	return conf.infoStream
 }
 
+/* Returns the Tracer of flushes and merges. */
+func (conf *LiveIndexWriterConfigImpl) Tracer() util.Tracer {
+	return conf.tracer
+}
+
 /*
 Sets if the IndexWriter should pack newly written segments in a
 compound file. Default is true.
@@ -297,6 +355,16 @@ func (conf *LiveIndexWriterConfigImpl) UseCompoundFile() bool {
 	return conf.useCompoundFile
 }
 
+/* Returns true if IndexWriter.Close() should first commit before closing. */
+func (conf *LiveIndexWriterConfigImpl) CommitOnClose() bool {
+	return conf.commitOnClose
+}
+
+/* Returns true if IndexWriter.Close() should wait for running merges to finish. */
+func (conf *LiveIndexWriterConfigImpl) WaitForMergesOnClose() bool {
+	return conf.waitForMergesOnClose
+}
+
 func (conf *LiveIndexWriterConfigImpl) String() string {
 	return fmt.Sprintf(`matchVersion=%v
 analyzer=%v
@@ -321,6 +389,8 @@ readerPooling=%v
 perThreadHardLimitMB=%v
 useCompoundFile=%v
 checkIntegrityAtMerge=%v
+commitOnClose=%v
+waitForMergesOnClose=%v
 `, conf.matchVersion, reflect.TypeOf(conf.analyzer),
 		conf.ramBufferSizeMB, conf.maxBufferedDocs,
 		conf.maxBufferedDeleteTerms, reflect.TypeOf(conf.mergedSegmentWarmer),
@@ -332,5 +402,6 @@ checkIntegrityAtMerge=%v
 		reflect.TypeOf(conf.infoStream), conf.mergePolicy,
 		conf.indexerThreadPool, conf.readerPooling,
 		conf.perRoutineHardLimitMB, conf.useCompoundFile,
-		conf.checkIntegrityAtMerge)
+		conf.checkIntegrityAtMerge, conf.commitOnClose,
+		conf.waitForMergesOnClose)
 }
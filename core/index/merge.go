@@ -3,10 +3,12 @@ package index
 import (
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/store"
 	// "github.com/balzaczyy/golucene/core/util"
 	"io"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -136,8 +138,9 @@ type MergeSpecifier interface {
 	FindForcedMerges(*SegmentInfos, int,
 		map[*SegmentCommitInfo]bool, *IndexWriter) (MergeSpecification, error)
 	// Determine what set of merge operations is necessary in order to
-	// expunge all deletes from the index.
-	// FindForcedDeletesMerges(segmentinfos *SegmentInfos) (spec MergeSpecification, err error)
+	// expunge all deletes from the index. IndexWriter calls this when
+	// its ForceMergeDeletes() method is called.
+	FindForcedDeletesMerges(*SegmentInfos, *IndexWriter) (MergeSpecification, error)
 }
 
 /*
@@ -185,14 +188,51 @@ pending deletes, is in the same dir as the writer, and matches the
 current compound file setting)
 */
 func (mp *MergePolicyImpl) isMerged(infos *SegmentInfos,
-	info *SegmentCommitInfo, w *IndexWriter) bool {
-	panic("not implemented yet")
+	info *SegmentCommitInfo, w *IndexWriter) (bool, error) {
 	assert(w != nil)
 	hasDeletions := w.readerPool.numDeletedDocs(info) > 0
-	return !hasDeletions &&
-		!info.Info.HasSeparateNorms() &&
-		info.Info.Dir == w.directory &&
-		(mp.noCFSRatio > 0 && mp.noCFSRatio < 1 || mp.maxCFSSegmentSize < math.MaxInt64)
+	if hasDeletions || info.Info.HasSeparateNorms() || info.Info.Dir != w.directory {
+		return false, nil
+	}
+	useCFS, err := mp.useCompoundFile(infos, info, w)
+	if err != nil {
+		return false, err
+	}
+	return useCFS == info.Info.IsCompoundFile(), nil
+}
+
+/*
+Returns true if a new segment (regardless of its origin) should use
+the compound file format. The default implementation returns true
+iff the size of the given mergedInfo is less or equal to
+maxCFSSegmentSize and the size is less or equal to the
+TotalIndexSize * noCFSRatio, otherwise false.
+*/
+func (mp *MergePolicyImpl) useCompoundFile(infos *SegmentInfos,
+	mergedInfo *SegmentCommitInfo, w *IndexWriter) (bool, error) {
+
+	if mp.noCFSRatio == 0 {
+		return false, nil
+	}
+	mergedInfoSize, err := mp.SizeSPI.Size(mergedInfo, w)
+	if err != nil {
+		return false, err
+	}
+	if float64(mergedInfoSize) > mp.maxCFSSegmentSize {
+		return false, nil
+	}
+	if mp.noCFSRatio >= 1 {
+		return true, nil
+	}
+	var totalSize int64
+	for _, info := range infos.Segments {
+		n, err := mp.SizeSPI.Size(info, w)
+		if err != nil {
+			return false, err
+		}
+		totalSize += n
+	}
+	return float64(mergedInfoSize) <= mp.noCFSRatio*float64(totalSize), nil
 }
 
 /*
@@ -202,8 +242,8 @@ compound file is enabled. Set to 1.0 to always use CFS regardless or
 merge size.
 */
 func (mp *MergePolicyImpl) SetNoCFSRatio(noCFSRatio float64) {
-	assert2(noCFSRatio >= 0 && noCFSRatio <= 1, fmt.Sprintf(
-		"noCFSRatio must be 0.0 to 1.0 inclusive; got %v", noCFSRatio))
+	assert2(noCFSRatio >= 0 && noCFSRatio <= 1,
+		"noCFSRatio must be 0.0 to 1.0 inclusive; got %v", noCFSRatio)
 	mp.noCFSRatio = noCFSRatio
 }
 
@@ -214,7 +254,7 @@ math.Inf(1) (default) and noCFSRatio to 1.0 to always use CFS
 regardless of merge size.
 */
 func (mp *MergePolicyImpl) SetMaxCFSSegmentSizeMB(v float64) {
-	assert2(v >= 0, fmt.Sprintf("maxCFSSegmentSizeMB must be >=0 (got %v)", v))
+	assert2(v >= 0, "maxCFSSegmentSizeMB must be >=0 (got %v)", v)
 	v *= 1024 * 1024
 	if v > float64(math.MaxInt64) {
 		mp.maxCFSSegmentSize = math.MaxInt64
@@ -276,6 +316,14 @@ type OneMerge struct {
 	// accounting for deletions.
 	totalDocCount int
 	aborted       bool
+
+	// Estimated size in bytes of the merged segment, set by
+	// registerMerge().
+	estimatedMergeBytes int64
+
+	info    *SegmentCommitInfo // used by IndexWriter
+	readers []*SegmentReader   // used by IndexWriter
+	err     error              // used by IndexWriter
 }
 
 func NewOneMerge(segments []*SegmentCommitInfo) *OneMerge {
@@ -288,6 +336,7 @@ func NewOneMerge(segments []*SegmentCommitInfo) *OneMerge {
 		count += info.Info.DocCount()
 	}
 	return &OneMerge{
+		Locker:         &sync.Mutex{},
 		maxNumSegments: -1,
 		segments:       segments2,
 		totalDocCount:  count,
@@ -300,6 +349,50 @@ func (m *OneMerge) abort() {
 	m.aborted = true
 }
 
+/* Returns true if this merge was aborted. */
+func (m *OneMerge) isAborted() bool {
+	m.Lock()
+	defer m.Unlock()
+	return m.aborted
+}
+
+/* Returns MergeAbortedError if this merge was aborted. */
+func (m *OneMerge) checkAborted() error {
+	if m.isAborted() {
+		return MergeAbortedError(fmt.Sprintf("merge is aborted: %v", m.segString()))
+	}
+	return nil
+}
+
+/* Returns a readable description of the current merge state. */
+func (m *OneMerge) segString() string {
+	var parts []string
+	for _, info := range m.segments {
+		parts = append(parts, info.StringOf(info.Info.Dir, 0))
+	}
+	ans := strings.Join(parts, " ")
+	if m.info != nil {
+		ans += fmt.Sprintf(" into %v", m.info.Info.Name)
+	}
+	if m.maxNumSegments != -1 {
+		ans += fmt.Sprintf(" [maxNumSegments=%v]", m.maxNumSegments)
+	}
+	if m.isAborted() {
+		ans += " [ABORTED]"
+	}
+	return ans
+}
+
+/* Returns the MergeInfo of this merge, to create its IOContext. */
+func (m *OneMerge) storeMergeInfo() *store.MergeInfo {
+	return &store.MergeInfo{
+		TotalDocCount:       m.totalDocCount,
+		EstimatedMergeBytes: m.estimatedMergeBytes,
+		IsExternal:          false,
+		MergeMaxNumSegments: m.maxNumSegments,
+	}
+}
+
 /*
 A MergeSpecification instance provides the information necessary to
 perform multiple merges. It simply contains a list of OneMerge
@@ -318,6 +411,27 @@ func (err MergeAbortedError) Error() string {
 	return string(err)
 }
 
+/*
+Returned by IndexWriter when merging has fallen too far behind and
+the MergeScheduler is configured to reject, rather than block,
+incoming operations. The operation can be retried later.
+*/
+type MergeStalledError string
+
+func (err MergeStalledError) Error() string {
+	return string(err)
+}
+
+/*
+Optionally implemented by a MergeScheduler that throttles indexing
+when merging falls behind. IndexWriter calls WaitIfStalled() before
+indexing each document; it blocks while too many merges are
+outstanding, or returns MergeStalledError.
+*/
+type MergeThrottler interface {
+	WaitIfStalled() error
+}
+
 // index/TieredMergePolicy.java
 
 // Default noCFSRatio. If a merge's size is >= 10% of the index, then
@@ -362,6 +476,7 @@ type TieredMergePolicy struct {
 	floorSegmentBytes           int64
 	segsPerTier                 float64
 	forceMergeDeletesPctAllowed float64
+	deletesPctAllowed           float64
 	reclaimDeletesWeight        float64
 }
 
@@ -373,6 +488,7 @@ func NewTieredMergePolicy() *TieredMergePolicy {
 		floorSegmentBytes:           2 * 1024 * 1024,
 		segsPerTier:                 10,
 		forceMergeDeletesPctAllowed: 10,
+		deletesPctAllowed:           33,
 		reclaimDeletesWeight:        2,
 	}
 	res.MergePolicyImpl = newMergePolicyImpl(res, DEFAULT_NO_CFS_RATIO, DEFAULT_MAX_CFS_SEGMENT_SIZE)
@@ -385,7 +501,7 @@ merging. For explicit merging (e.g., forceMerge or forceMergeDeletes
 was called), see SetMaxMergeAtonceExplicit(). Default is 10.
 */
 func (tmp *TieredMergePolicy) SetMaxMergeAtOnce(v int) *TieredMergePolicy {
-	assert2(v >= 2, fmt.Sprintf("maxMergeAtonce must be > 1 (got %v)", v))
+	assert2(v >= 2, "maxMergeAtonce must be > 1 (got %v)", v)
 	tmp.maxMergeAtOnce = v
 	return tmp
 }
@@ -395,7 +511,7 @@ Maximum number of segments to be merged at a time, during forceMerge
 or forceMergeDeletes. Default is 30.
 */
 func (tmp *TieredMergePolicy) SetMaxMergeAtOnceExplicit(v int) *TieredMergePolicy {
-	assert2(v >= 2, fmt.Sprintf("maxMergeAtonceExplicit must be > 1 (got %v)", v))
+	assert2(v >= 2, "maxMergeAtonceExplicit must be > 1 (got %v)", v)
 	tmp.maxMergeAtOnceExplicit = v
 	return tmp
 }
@@ -407,7 +523,7 @@ summing sizes of to-be-merged segments(compensating for percent
 deleted docs). Default is 5 GB.
 */
 func (tmp *TieredMergePolicy) SetMaxMergedSegmentMB(v float64) *TieredMergePolicy {
-	assert2(v >= 0, fmt.Sprintf("maxMergedSegmentMB must be >= 0 (got %v)", v))
+	assert2(v >= 0, "maxMergedSegmentMB must be >= 0 (got %v)", v)
 	v *= 1024 * 1024
 	tmp.maxMergedSegmentBytes = math.MaxInt64
 	if v < math.MaxInt64 {
@@ -424,7 +540,7 @@ merging takes place; a value of 3.0 is probably nearly too high. A
 value of 0.0 means deletions don't impact merge selection.
 */
 func (tmp *TieredMergePolicy) SetReclaimDeletesWeight(v float64) *TieredMergePolicy {
-	assert2(v >= 0, fmt.Sprintf("reclaimDeletesWeight must be >= 0 (got %v)", v))
+	assert2(v >= 0, "reclaimDeletesWeight must be >= 0 (got %v)", v)
 	tmp.reclaimDeletesWeight = v
 	return tmp
 }
@@ -436,7 +552,7 @@ frequent flushing of tiny segments from allowing a long tail in the
 index. Default is 2 MB.
 */
 func (tmp *TieredMergePolicy) SetFloorSegmentMB(v float64) *TieredMergePolicy {
-	assert2(v > 0, fmt.Sprintf("floorSegmentMB must be > 0 (got %v)", v))
+	assert2(v > 0, "floorSegmentMB must be > 0 (got %v)", v)
 	v *= 1024 * 1024
 	tmp.floorSegmentBytes = math.MaxInt64
 	if v < math.MaxInt64 {
@@ -450,11 +566,25 @@ When forceMergeDeletes is called, we only merge away a segment if its
 delete percentage is over this threshold. Default is 10%.
 */
 func (tmp *TieredMergePolicy) SetForceMergeDeletesPctAllowed(v float64) *TieredMergePolicy {
-	assert2(v >= 0 && v <= 100, fmt.Sprintf("forceMergeDeletesPctAllowed must be between 0 and 100 inclusive (got %v)", v))
+	assert2(v >= 0 && v <= 100, "forceMergeDeletesPctAllowed must be between 0 and 100 inclusive (got %v)", v)
 	tmp.forceMergeDeletesPctAllowed = v
 	return tmp
 }
 
+/*
+Controls the maximum percentage of deleted documents that is tolerated
+in a segment during normal merging. Segments over this threshold are
+merged away on their own, even if they are too large to be selected
+otherwise, to keep disk usage bounded for update-heavy workloads.
+Lower values mean more merging. Must be between 20 and 50; default is
+33%.
+*/
+func (tmp *TieredMergePolicy) SetDeletesPctAllowed(v float64) *TieredMergePolicy {
+	assert2(v >= 20 && v <= 50, "deletesPctAllowed must be between 20 and 50 inclusive (got %v)", v)
+	tmp.deletesPctAllowed = v
+	return tmp
+}
+
 /*
 Sets the allowed number of segments per tier. Smaller values mean
 more merging but fewer segments.
@@ -463,7 +593,7 @@ NOTE: this value should be >= the SetMaxMergeAtOnce otherwise you'll
 force too much merging to occur.
 */
 func (tmp *TieredMergePolicy) SetSegmentsPerTier(v float64) *TieredMergePolicy {
-	assert2(v >= 2, fmt.Sprintf("segmentsPerTier must be >= 2 (got %v)", v))
+	assert2(v >= 2, "segmentsPerTier must be >= 2 (got %v)", v)
 	tmp.segsPerTier = v
 	return tmp
 }
@@ -484,7 +614,7 @@ func (a *BySizeDescendingSegments) Less(i, j int) bool {
 	sz2, err = a.spi.Size(a.values[j], a.writer)
 	assert(err == nil)
 	if sz1 != sz2 {
-		return sz1 < sz2
+		return sz1 > sz2
 	}
 	return a.values[i].Info.Name < a.values[j].Info.Name
 }
@@ -553,6 +683,18 @@ func (tmp *TieredMergePolicy) FindMerges(mergeTrigger MergeTrigger,
 
 	minSegmentBytes = tmp.floorSize(minSegmentBytes)
 
+	// First reclaim deletes: segments with too many deleted documents
+	// are rewritten on their own
+	for _, info := range tmp.segmentsOverDeletesPct(infosSorted, tmp.deletesPctAllowed, w) {
+		if tmp.verbose(w) {
+			tmp.message(w, "  add merge to reclaim deletes: %v", w.readerPool.segmentToString(info))
+		}
+		spec = append(spec, NewOneMerge([]*SegmentCommitInfo{info}))
+	}
+	if spec != nil {
+		return spec, nil
+	}
+
 	// Compute max allowed segs in the index
 	levelSize := minSegmentBytes
 	bytesLeft := totIndexBytes
@@ -642,8 +784,130 @@ func (tmp *TieredMergePolicy) FindMerges(mergeTrigger MergeTrigger,
 
 func (tmp *TieredMergePolicy) FindForcedMerges(infos *SegmentInfos,
 	maxSegmentCount int, segmentsToMerge map[*SegmentCommitInfo]bool,
-	w *IndexWriter) (MergeSpecification, error) {
-	panic("not implemented yet")
+	w *IndexWriter) (spec MergeSpecification, err error) {
+
+	if tmp.verbose(w) {
+		tmp.message(w, "findForcedMerges maxSegmentCount=%v infos=%v segmentsToMerge=%v",
+			maxSegmentCount, w.readerPool.segmentsToString(infos.Segments), len(segmentsToMerge))
+	}
+
+	var eligible []*SegmentCommitInfo
+	forceMergeRunning := false
+	merging := w.MergingSegments()
+	segmentIsOriginal := false
+	for _, info := range infos.Segments {
+		if isOriginal, ok := segmentsToMerge[info]; ok {
+			segmentIsOriginal = isOriginal
+			if _, ok := merging[info]; !ok {
+				eligible = append(eligible, info)
+			} else {
+				forceMergeRunning = true
+			}
+		}
+	}
+
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+
+	if maxSegmentCount > 1 && len(eligible) <= maxSegmentCount {
+		return nil, nil
+	}
+	if maxSegmentCount == 1 && len(eligible) == 1 {
+		if !segmentIsOriginal {
+			return nil, nil
+		}
+		merged, err := tmp.isMerged(infos, eligible[0], w)
+		if err != nil || merged {
+			return nil, err
+		}
+	}
+
+	sort.Sort(&BySizeDescendingSegments{eligible, w, tmp})
+
+	if tmp.verbose(w) {
+		tmp.message(w, "eligible=%v", w.readerPool.segmentsToString(eligible))
+		tmp.message(w, "forceMergeRunning=%v", forceMergeRunning)
+	}
+
+	end := len(eligible)
+
+	// Do full merges, first, backwards:
+	for end >= tmp.maxMergeAtOnceExplicit+maxSegmentCount-1 {
+		merge := NewOneMerge(eligible[end-tmp.maxMergeAtOnceExplicit : end])
+		if tmp.verbose(w) {
+			tmp.message(w, "add merge=%v", w.readerPool.segmentsToString(merge.segments))
+		}
+		spec = append(spec, merge)
+		end -= tmp.maxMergeAtOnceExplicit
+	}
+
+	if spec == nil && !forceMergeRunning {
+		// Do final merge
+		numToMerge := end - maxSegmentCount + 1
+		merge := NewOneMerge(eligible[end-numToMerge : end])
+		if tmp.verbose(w) {
+			tmp.message(w, "add final merge=%v", merge.segString())
+		}
+		spec = append(spec, merge)
+	}
+	return spec, nil
+}
+
+func (tmp *TieredMergePolicy) FindForcedDeletesMerges(infos *SegmentInfos,
+	w *IndexWriter) (spec MergeSpecification, err error) {
+
+	if tmp.verbose(w) {
+		tmp.message(w, "findForcedDeletesMerges infos=%v forceMergeDeletesPctAllowed=%v",
+			w.readerPool.segmentsToString(infos.Segments), tmp.forceMergeDeletesPctAllowed)
+	}
+	eligible := tmp.segmentsOverDeletesPct(infos.Segments, tmp.forceMergeDeletesPctAllowed, w)
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+	sort.Sort(&BySizeDescendingSegments{eligible, w, tmp})
+
+	if tmp.verbose(w) {
+		tmp.message(w, "eligible=%v", w.readerPool.segmentsToString(eligible))
+	}
+
+	for start := 0; start < len(eligible); {
+		// Don't enforce max merged size here: app is explicitly calling
+		// forceMergeDeletes, and knows this may take a long time /
+		// produce big segments (like forceMerge):
+		end := start + tmp.maxMergeAtOnceExplicit
+		if end > len(eligible) {
+			end = len(eligible)
+		}
+		merge := NewOneMerge(eligible[start:end])
+		if tmp.verbose(w) {
+			tmp.message(w, "add merge=%v", w.readerPool.segmentsToString(merge.segments))
+		}
+		spec = append(spec, merge)
+		start = end
+	}
+	return spec, nil
+}
+
+/*
+Returns the segments, not already being merged, whose percentage of
+deleted documents is over pctAllowed.
+*/
+func (tmp *TieredMergePolicy) segmentsOverDeletesPct(infos []*SegmentCommitInfo,
+	pctAllowed float64, w *IndexWriter) []*SegmentCommitInfo {
+
+	merging := w.MergingSegments()
+	var ans []*SegmentCommitInfo
+	for _, info := range infos {
+		if _, ok := merging[info]; ok || info.Info.DocCount() == 0 {
+			continue
+		}
+		pctDeletes := 100 * float64(w.readerPool.numDeletedDocs(info)) / float64(info.Info.DocCount())
+		if pctDeletes > pctAllowed {
+			ans = append(ans, info)
+		}
+	}
+	return ans
 }
 
 func (tmp *TieredMergePolicy) floorSize(bytes int64) int64 {
@@ -662,9 +926,9 @@ func (tmp *TieredMergePolicy) message(w *IndexWriter, message string, args ...in
 }
 
 func (tmp *TieredMergePolicy) String() string {
-	return fmt.Sprintf("[TieredMergePolicy: maxMergeAtOnce=%v, maxMergeAtOnceExplicit=%v, maxMergedSegmentMB=%v, floorSegmentMB=%v, forceMergeDeletesPctAllowed=%v, segmentPerTier=%v, maxCFSSegmentSizeMB=%v, noCFSRatio=%v",
+	return fmt.Sprintf("[TieredMergePolicy: maxMergeAtOnce=%v, maxMergeAtOnceExplicit=%v, maxMergedSegmentMB=%v, floorSegmentMB=%v, forceMergeDeletesPctAllowed=%v, deletesPctAllowed=%v, segmentPerTier=%v, maxCFSSegmentSizeMB=%v, noCFSRatio=%v",
 		tmp.maxMergeAtOnce, tmp.maxMergeAtOnceExplicit, tmp.maxMergedSegmentBytes/1024/1024,
-		tmp.floorSegmentBytes/1024/1024, tmp.forceMergeDeletesPctAllowed, tmp.segsPerTier,
+		tmp.floorSegmentBytes/1024/1024, tmp.forceMergeDeletesPctAllowed, tmp.deletesPctAllowed, tmp.segsPerTier,
 		tmp.maxCFSSegmentSize/1024/1024, tmp.noCFSRatio)
 }
 
@@ -793,14 +1057,265 @@ than or equal to the specified maxNumSegments.
 */
 func (mp *LogMergePolicy) isMergedBy(infos *SegmentInfos,
 	maxNumSegments int, segmentsToMerge map[*SegmentCommitInfo]bool,
-	w *IndexWriter) bool {
-	panic("not implemented yet")
+	w *IndexWriter) (bool, error) {
+
+	numToMerge := 0
+	var mergeInfo *SegmentCommitInfo
+	segmentIsOriginal := false
+	for i := 0; i < len(infos.Segments) && numToMerge <= maxNumSegments; i++ {
+		info := infos.Segments[i]
+		if isOriginal, ok := segmentsToMerge[info]; ok {
+			segmentIsOriginal = isOriginal
+			numToMerge++
+			mergeInfo = info
+		}
+	}
+	if numToMerge > maxNumSegments {
+		return false, nil
+	}
+	if numToMerge != 1 || !segmentIsOriginal {
+		return true, nil
+	}
+	return mp.isMerged(infos, mergeInfo, w)
+}
+
+/*
+Returns the merges necessary to merge the index, up to the segment
+last (exclusive), down to maxNumSegments segments. Segments larger
+than maxMergeSizeForForcedMerge are left alone, and the ones in
+between are merged mergeFactor at a time.
+*/
+func (mp *LogMergePolicy) findForcedMergesSizeLimit(infos *SegmentInfos,
+	maxNumSegments, last int, w *IndexWriter) (spec MergeSpecification, err error) {
+
+	segments := infos.Segments
+	start := last - 1
+	for start >= 0 {
+		info := segments[start]
+		size, err := mp.SizeSPI.Size(info, w)
+		if err != nil {
+			return nil, err
+		}
+		if size > mp.maxMergeSizeForForcedMerge {
+			mp.message(fmt.Sprintf("findForcedMergesSizeLimit: skip segment=%v: size is > maxMergeSize (%v)",
+				info, mp.maxMergeSizeForForcedMerge), w)
+			// need to skip that segment + add a merge for the 'right'
+			// segments, unless there is only 1 which is merged.
+			mergeRight := last-start-1 > 1
+			if !mergeRight && start != last-1 {
+				merged, err := mp.isMerged(infos, segments[start+1], w)
+				if err != nil {
+					return nil, err
+				}
+				mergeRight = !merged
+			}
+			if mergeRight {
+				// there is more than 1 segment to the right of this one,
+				// or a mergeable single segment.
+				spec = append(spec, NewOneMerge(segments[start+1:last]))
+			}
+			last = start
+		} else if last-start == mp.mergeFactor {
+			// mergeFactor eligible segments were found, add them as a merge.
+			spec = append(spec, NewOneMerge(segments[start:last]))
+			last = start
+		}
+		start--
+	}
+
+	// Add any left-over segments, unless there is just 1 already
+	// fully merged
+	if last > 0 {
+		start++
+		mergeLeft := start+1 < last
+		if !mergeLeft {
+			merged, err := mp.isMerged(infos, segments[start], w)
+			if err != nil {
+				return nil, err
+			}
+			mergeLeft = !merged
+		}
+		if mergeLeft {
+			spec = append(spec, NewOneMerge(segments[start:last]))
+		}
+	}
+	return spec, nil
+}
+
+/*
+Returns the merges necessary to merge the index, up to the segment
+last (exclusive), down to maxNumSegments segments.
+*/
+func (mp *LogMergePolicy) findForcedMergesMaxNumSegments(infos *SegmentInfos,
+	maxNumSegments, last int, w *IndexWriter) (spec MergeSpecification, err error) {
+
+	segments := infos.Segments
+
+	// First, enroll all "full" merges (size mergeFactor) to
+	// potentially be run concurrently:
+	for last-maxNumSegments+1 >= mp.mergeFactor {
+		spec = append(spec, NewOneMerge(segments[last-mp.mergeFactor:last]))
+		last -= mp.mergeFactor
+	}
+
+	// Only if there are no full merges pending do we add a final
+	// partial (< mergeFactor segments) merge:
+	if len(spec) > 0 {
+		return spec, nil
+	}
+	if maxNumSegments == 1 {
+		// Since we must merge down to 1 segment, the choice is simple:
+		if last == 1 {
+			merged, err := mp.isMerged(infos, segments[0], w)
+			if err != nil || merged {
+				return nil, err
+			}
+		}
+		return MergeSpecification{NewOneMerge(segments[:last])}, nil
+	}
+	if last > maxNumSegments {
+		// Take care to pick a partial merge that is least cost, but
+		// does not make the index too lopsided. If we always just
+		// picked the partial tail then we could produce a highly
+		// lopsided index over time:
+
+		// We must merge this many segments to leave maxNumSegments in
+		// the index (from when forceMerge was first kicked off):
+		finalMergeSize := last - maxNumSegments + 1
+
+		// Consider all possible starting points:
+		sizes := make([]int64, last)
+		for i := range sizes {
+			if sizes[i], err = mp.SizeSPI.Size(segments[i], w); err != nil {
+				return nil, err
+			}
+		}
+		var bestSize int64
+		bestStart := 0
+		for i := 0; i < last-finalMergeSize+1; i++ {
+			var sumSize int64
+			for j := 0; j < finalMergeSize; j++ {
+				sumSize += sizes[j+i]
+			}
+			if i == 0 || sumSize < 2*sizes[i-1] && sumSize < bestSize {
+				bestStart = i
+				bestSize = sumSize
+			}
+		}
+		spec = append(spec, NewOneMerge(segments[bestStart:bestStart+finalMergeSize]))
+	}
+	return spec, nil
 }
 
+/*
+Returns the merges necessary to merge the index down to a specified
+number of segments. This respects the maxMergeSizeForForcedMerge
+setting. By default, and assuming maxNumSegments=1, only one segment
+will be left in the index, where that segment has no deletions
+pending nor separate norms, and it is in compound file format if the
+current useCompoundFile setting is true. This method returns multiple
+merges (mergeFactor at a time) so the MergeScheduler in use may make
+use of concurrency.
+*/
 func (mp *LogMergePolicy) FindForcedMerges(infos *SegmentInfos,
-	maxSegmentCount int, segmentsToMerge map[*SegmentCommitInfo]bool,
+	maxNumSegments int, segmentsToMerge map[*SegmentCommitInfo]bool,
 	w *IndexWriter) (MergeSpecification, error) {
-	panic("not implemented yet")
+
+	assert(maxNumSegments > 0)
+	mp.message(fmt.Sprintf("findForcedMerges: maxNumSegs=%v segsToMerge=%v",
+		maxNumSegments, len(segmentsToMerge)), w)
+
+	// If the segments are already merged (e.g. there's only 1
+	// segment), or there are <maxNumSegments:
+	if merged, err := mp.isMergedBy(infos, maxNumSegments, segmentsToMerge, w); err != nil || merged {
+		if merged {
+			mp.message("already merged; skip", w)
+		}
+		return nil, err
+	}
+
+	// Find the newest (rightmost) segment that needs to be merged
+	// (other segments may have been flushed since merging started):
+	last := len(infos.Segments)
+	for last > 0 {
+		last--
+		if _, ok := segmentsToMerge[infos.Segments[last]]; ok {
+			last++
+			break
+		}
+	}
+
+	if last == 0 {
+		mp.message("last == 0; skip", w)
+		return nil, nil
+	}
+
+	// There is only one segment already, and it is merged
+	if maxNumSegments == 1 && last == 1 {
+		if merged, err := mp.isMerged(infos, infos.Segments[0], w); err != nil || merged {
+			if merged {
+				mp.message("already 1 seg; skip", w)
+			}
+			return nil, err
+		}
+	}
+
+	// Check if there are any segments above the threshold
+	for _, info := range infos.Segments[:last] {
+		size, err := mp.SizeSPI.Size(info, w)
+		if err != nil {
+			return nil, err
+		}
+		if size > mp.maxMergeSizeForForcedMerge {
+			return mp.findForcedMergesSizeLimit(infos, maxNumSegments, last, w)
+		}
+	}
+	return mp.findForcedMergesMaxNumSegments(infos, maxNumSegments, last, w)
+}
+
+/*
+Finds merges necessary to force-merge all deletes from the index. We
+simply merge adjacent segments that have deletes, up to mergeFactor
+at a time.
+*/
+func (mp *LogMergePolicy) FindForcedDeletesMerges(infos *SegmentInfos,
+	w *IndexWriter) (spec MergeSpecification, err error) {
+
+	segments := infos.Segments
+	numSegments := len(segments)
+	mp.message(fmt.Sprintf("findForcedDeleteMerges: %v segments", numSegments), w)
+
+	firstSegmentWithDeletions := -1
+	for i, info := range segments {
+		if delCount := w.readerPool.numDeletedDocs(info); delCount > 0 {
+			mp.message(fmt.Sprintf("  segment %v has deletions", info.Info.Name), w)
+			if firstSegmentWithDeletions == -1 {
+				firstSegmentWithDeletions = i
+			} else if i-firstSegmentWithDeletions == mp.mergeFactor {
+				// We've seen mergeFactor segments in a row with
+				// deletions, so force a merge now:
+				mp.message(fmt.Sprintf("  add merge %v to %v inclusive",
+					firstSegmentWithDeletions, i-1), w)
+				spec = append(spec, NewOneMerge(segments[firstSegmentWithDeletions:i]))
+				firstSegmentWithDeletions = i
+			}
+		} else if firstSegmentWithDeletions != -1 {
+			// End of a sequence of segments with deletions, so, merge
+			// those past segments even if it's fewer than mergeFactor
+			// segments
+			mp.message(fmt.Sprintf("  add merge %v to %v inclusive",
+				firstSegmentWithDeletions, i-1), w)
+			spec = append(spec, NewOneMerge(segments[firstSegmentWithDeletions:i]))
+			firstSegmentWithDeletions = -1
+		}
+	}
+
+	if firstSegmentWithDeletions != -1 {
+		mp.message(fmt.Sprintf("  add merge %v to %v inclusive",
+			firstSegmentWithDeletions, numSegments-1), w)
+		spec = append(spec, NewOneMerge(segments[firstSegmentWithDeletions:numSegments]))
+	}
+	return spec, nil
 }
 
 type SegmentInfoAndLevel struct {
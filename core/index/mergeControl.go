@@ -116,6 +116,30 @@ func (mc *MergeControl) waitForMerges() {
 	}
 }
 
+/* Waits until none of the given merges is still pending or running. */
+func (mc *MergeControl) waitForMergesOf(spec MergeSpecification) {
+	mc.Lock() // synchronized
+	defer mc.Unlock()
+
+	for mc.anyMergeOutstanding(spec) {
+		mc.mergeSignal.Wait()
+	}
+}
+
+func (mc *MergeControl) anyMergeOutstanding(spec MergeSpecification) bool {
+	for _, merge := range spec {
+		if _, ok := mc.runningMerges[merge]; ok {
+			return true
+		}
+		for e := mc.pendingMerges.Front(); e != nil; e = e.Next() {
+			if e.Value.(*OneMerge) == merge {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // L3696
 /*
 Does finishing for a merge, which is fast but holds the synchronized
@@ -126,7 +150,6 @@ Note: it must be externally synchronized or used internally.
 func (mc *MergeControl) mergeFinish(merge *OneMerge) {
 	// forceMerge, addIndexes or abortAllmerges may be waiting on
 	// merges to finish
-	// notifyAll()
 
 	// It's possible we are called twice, eg if there was an error
 	// inside mergeInit()
@@ -138,7 +161,5 @@ func (mc *MergeControl) mergeFinish(merge *OneMerge) {
 	}
 
 	delete(mc.runningMerges, merge)
-	if len(mc.runningMerges) == 0 {
-		mc.mergeSignal.Signal()
-	}
+	mc.mergeSignal.Broadcast()
 }
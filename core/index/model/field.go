@@ -54,6 +54,9 @@ type IndexableField interface {
 	/** Non-null if this field has a numeric value */
 	NumericValue() interface{}
 
+	/** Non-null if this field has a vector value */
+	VectorValue() []float32
+
 	// Creates the TokenStream used for indexing this field.  If appropriate,
 	// implementations should use the given Analyzer to create the TokenStreams.
 	TokenStream(analysis.Analyzer, analysis.TokenStream) (analysis.TokenStream, error)
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 )
 
 type FieldInfo struct {
@@ -100,8 +101,16 @@ func (info *FieldInfo) update(indexed, storeTermVector, omitNorms, storePayloads
 	info.checkConsistency()
 }
 
+/* Records that the field has payloads, if it indexes positions. */
+func (info *FieldInfo) SetStorePayloads() {
+	if info.indexed && info.indexOptions >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+		info.storePayloads = true
+	}
+	info.checkConsistency()
+}
+
 func (info *FieldInfo) SetDocValueType(v DocValuesType) {
-	assert2(int(info.docValueType) != 0 && info.docValueType != v,
+	assert2(int(info.docValueType) == 0 || info.docValueType == v,
 		"cannot change DocValues type from %v to %v for field '%v'",
 		info.docValueType, v, info.Name)
 	info.docValueType = v
@@ -153,6 +162,33 @@ func (info *FieldInfo) HasPayloads() bool { return info.storePayloads }
 /* Returns true if any term vectors exist for this field. */
 func (info *FieldInfo) HasVectors() bool { return info.storeTermVector }
 
+/*
+Codec attributes recording the dimension and the similarity of the
+vector values of the field, so that they are kept in the field infos
+of the segment.
+*/
+const (
+	VECTOR_DIMENSION_ATTRIBUTE  = "vectorDimension"
+	VECTOR_SIMILARITY_ATTRIBUTE = "vectorSimilarity"
+)
+
+/* Returns the dimension of the vector values of the field, or 0 if it has none. */
+func (info *FieldInfo) VectorDimension() int {
+	n, _ := strconv.Atoi(info.Attribute(VECTOR_DIMENSION_ATTRIBUTE))
+	return n
+}
+
+/* Returns the similarity of the vector values of the field. */
+func (info *FieldInfo) VectorSimilarity() VectorSimilarity {
+	n, _ := strconv.Atoi(info.Attribute(VECTOR_SIMILARITY_ATTRIBUTE))
+	return VectorSimilarity(n)
+}
+
+func (info *FieldInfo) setVectorProperties(dimension int, similarity VectorSimilarity) {
+	info.PutAttribute(VECTOR_DIMENSION_ATTRIBUTE, strconv.Itoa(dimension))
+	info.PutAttribute(VECTOR_SIMILARITY_ATTRIBUTE, strconv.Itoa(int(similarity)))
+}
+
 /*
 Puts a codec attribute value.
 
@@ -276,4 +312,13 @@ type IndexableFieldType interface {
 	 * will be indexed into docValues.
 	 */
 	DocValueType() DocValuesType
+
+	/**
+	 * The dimension of the field's vector value: if positive then the
+	 * value is indexed for nearest neighbor search.
+	 */
+	VectorDimension() int
+
+	/** The similarity of the field's vector values. */
+	VectorSimilarity() VectorSimilarity
 }
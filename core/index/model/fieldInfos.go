@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -15,6 +16,8 @@ type FieldInfos struct {
 	HasVectors   bool
 	HasNorms     bool
 	HasDocValues bool
+	// True if any field has vector values, for nearest neighbor search
+	HasVectorValues bool
 
 	byNumber map[int32]*FieldInfo
 	byName   map[string]*FieldInfo
@@ -44,6 +47,7 @@ func NewFieldInfos(infos []*FieldInfo) FieldInfos {
 		self.HasNorms = self.HasNorms || info.normType != 0
 		self.HasDocValues = self.HasDocValues || info.docValueType != 0
 		self.HasPayloads = self.HasPayloads || info.storePayloads
+		self.HasVectorValues = self.HasVectorValues || info.VectorDimension() > 0
 	}
 
 	sort.Sort(Int32Slice(numbers))
@@ -81,8 +85,9 @@ hasOffsets = %v
 hasVectors = %v
 hasNorms = %v
 hasDocValues = %v
+hasVectorValues = %v
 %v`, fis.HasFreq, fis.HasProx, fis.HasPayloads, fis.HasOffsets,
-		fis.HasVectors, fis.HasNorms, fis.HasDocValues, fis.Values)
+		fis.HasVectors, fis.HasNorms, fis.HasDocValues, fis.HasVectorValues, fis.Values)
 }
 
 type FieldNumbers struct {
@@ -92,6 +97,9 @@ type FieldNumbers struct {
 	// We use this to enforce that a given field never changes DV type,
 	// even across segments / IndexWriter sessions:
 	docValuesType map[string]DocValuesType
+	// Same for the dimension and similarity of vector values:
+	vectorDimension  map[string]int
+	vectorSimilarity map[string]VectorSimilarity
 	// TODO: we should similarly catch an attempt to turn norms back on
 	// after they were already ommitted; today we silently discard the
 	// norm but this is badly trappy
@@ -104,14 +112,62 @@ func NewFieldNumbers() *FieldNumbers {
 		nameToNumber:                make(map[string]int),
 		numberToName:                make(map[int]string),
 		docValuesType:               make(map[string]DocValuesType),
+		vectorDimension:             make(map[string]int),
+		vectorSimilarity:            make(map[string]VectorSimilarity),
 		lowestUnassignedFieldNumber: -1,
 	}
 }
 
 func (fn *FieldNumbers) AddOrGet(info *FieldInfo) int {
+	if dimension := info.VectorDimension(); dimension > 0 {
+		fn.setVectorProperties(info.Name, dimension, info.VectorSimilarity())
+	}
 	return fn.addOrGet(info.Name, int(info.Number), info.docValueType)
 }
 
+/*
+Records the dimension and similarity of the vector values of the
+field, or returns an error if they differ from the ones seen before.
+*/
+func (fn *FieldNumbers) setVectorProperties(name string,
+	dimension int, similarity VectorSimilarity) error {
+
+	fn.Lock()
+	defer fn.Unlock()
+
+	if current, ok := fn.vectorDimension[name]; !ok {
+		fn.vectorDimension[name] = dimension
+		fn.vectorSimilarity[name] = similarity
+	} else if current != dimension {
+		return errors.New(fmt.Sprintf(
+			"cannot change vector dimension from %v to %v for field '%v'",
+			current, dimension, name))
+	} else if current := fn.vectorSimilarity[name]; current != similarity {
+		return errors.New(fmt.Sprintf(
+			"cannot change vector similarity from %v to %v for field '%v'",
+			current, similarity, name))
+	}
+	return nil
+}
+
+/*
+Records the doc values type of the field, or returns an error if it
+differs from the one seen before.
+*/
+func (fn *FieldNumbers) setDocValuesType(name string, dv DocValuesType) error {
+	fn.Lock()
+	defer fn.Unlock()
+
+	if current, ok := fn.docValuesType[name]; !ok || current == 0 {
+		fn.docValuesType[name] = dv
+	} else if current != dv {
+		return errors.New(fmt.Sprintf(
+			"cannot change DocValues type from %v to %v for field '%v'",
+			current, dv, name))
+	}
+	return nil
+}
+
 /*
 Returns the global field number for the given field name. If the name
 does not exist yet it tries to add it with the given preferred field
@@ -191,7 +247,58 @@ func (b *FieldInfosBuilder) AddOrUpdate(name string, fieldType IndexableFieldTyp
 	// should be updated by maybe FreqProxTermsWriterPerField:
 	return b.addOrUpdateInternal(name, -1, fieldType.Indexed(), false,
 		fieldType.OmitNorms(), false,
-		fieldType.IndexOptions(), fieldType.DocValueType(), DocValuesType(0))
+		fieldType.IndexOptions(), DocValuesType(0), DocValuesType(0))
+}
+
+/*
+Adds a field as described by a FieldInfo of another segment, e.g. one
+being merged, or updates the field already added with its settings.
+*/
+func (b *FieldInfosBuilder) Add(fi *FieldInfo) *FieldInfo {
+	indexOptions := fi.indexOptions
+	if !fi.indexed {
+		indexOptions = INDEX_OPT_DOCS_ONLY // ignored, but must be set
+	}
+	// IMPORTANT - reuse the field number if possible for consistent
+	// field numbers across segments
+	ans := b.addOrUpdateInternal(fi.Name, int(fi.Number), fi.indexed,
+		fi.storeTermVector, fi.omitNorms, fi.storePayloads,
+		indexOptions, fi.docValueType, fi.normType)
+	if dimension := fi.VectorDimension(); dimension > 0 {
+		ans.setVectorProperties(dimension, fi.VectorSimilarity())
+	}
+	return ans
+}
+
+/*
+Records that the field has vector values of the given dimension and
+similarity, or returns an error if its vectors had other ones, in
+this segment or another one of the index.
+*/
+func (b *FieldInfosBuilder) SetVectorProperties(fi *FieldInfo,
+	dimension int, similarity VectorSimilarity) error {
+
+	if err := b.globalFieldNumbers.setVectorProperties(fi.Name, dimension, similarity); err != nil {
+		return err
+	}
+	fi.setVectorProperties(dimension, similarity)
+	return nil
+}
+
+/*
+Records that the field has doc values of the given type, or returns
+an error if it had another type, in this segment or another one of
+the index.
+*/
+func (b *FieldInfosBuilder) SetDocValuesType(fi *FieldInfo, dv DocValuesType) error {
+	if fi.DocValuesType() == dv {
+		return nil
+	}
+	if err := b.globalFieldNumbers.setDocValuesType(fi.Name, dv); err != nil {
+		return err
+	}
+	fi.SetDocValueType(dv)
+	return nil
 }
 
 func (b *FieldInfosBuilder) addOrUpdateInternal(name string,
@@ -200,7 +307,15 @@ func (b *FieldInfosBuilder) addOrUpdateInternal(name string,
 	docValues DocValuesType, normType DocValuesType) *FieldInfo {
 
 	if fi, ok := b.byName[name]; ok {
-		panic("not implemented yet")
+		fi.update(isIndexed, storeTermVector, omitNorms, storePayloads, indexOptions)
+		if docValues != 0 && fi.docValueType == 0 {
+			// the global field numbers keep the type from changing
+			fi.docValueType = docValues
+		}
+		if !fi.omitNorms && normType != 0 {
+			fi.normType = normType
+		}
+		fi.checkConsistency()
 		return fi
 	} else {
 		// This field wasn't yet added to this in-RAM segment's
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// index/VectorSimilarityFunction.java
+
+/*
+Similarity between two vectors, as a non-negative score. The
+similarity of a vector field is part of its type, and is used both to
+build its HNSW graph and to score the nearest neighbor searches.
+*/
+type VectorSimilarity int
+
+const (
+	// Cosine of the angle between the vectors, mapped to [0,1].
+	VECTOR_SIMILARITY_COSINE = VectorSimilarity(iota)
+	// Dot product mapped to [0,1]. The vectors are not normalized, so
+	// this is only meaningful for unit vectors, for which it is the
+	// same as the cosine, but cheaper to compute.
+	VECTOR_SIMILARITY_DOT_PRODUCT
+)
+
+/* Returns the similarity of the vectors, which must have the same dimension. */
+func (s VectorSimilarity) Compare(v1, v2 []float32) (float32, error) {
+	if len(v1) != len(v2) {
+		return 0, errors.New(fmt.Sprintf(
+			"vector dimensions differ: %v != %v", len(v1), len(v2)))
+	}
+	var dot float64
+	switch s {
+	case VECTOR_SIMILARITY_COSINE:
+		var norm1, norm2 float64
+		for i, v := range v1 {
+			dot += float64(v) * float64(v2[i])
+			norm1 += float64(v) * float64(v)
+			norm2 += float64(v2[i]) * float64(v2[i])
+		}
+		if norm1 == 0 || norm2 == 0 {
+			return 0, nil
+		}
+		dot /= math.Sqrt(norm1 * norm2)
+	case VECTOR_SIMILARITY_DOT_PRODUCT:
+		for i, v := range v1 {
+			dot += float64(v) * float64(v2[i])
+		}
+	default:
+		return 0, errors.New(fmt.Sprintf("unknown vector similarity: %v", int(s)))
+	}
+	return float32(math.Max((1+dot)/2, 0)), nil
+}
+
+func (s VectorSimilarity) String() string {
+	switch s {
+	case VECTOR_SIMILARITY_COSINE:
+		return "COSINE"
+	case VECTOR_SIMILARITY_DOT_PRODUCT:
+		return "DOT_PRODUCT"
+	}
+	return fmt.Sprintf("VectorSimilarity(%v)", int(s))
+}
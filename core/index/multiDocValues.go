@@ -0,0 +1,411 @@
+package index
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"reflect"
+)
+
+// index/MultiDocValues.java
+
+/*
+A wrapper for CompositeIndexReader providing access to DocValues.
+
+NOTE: for multi readers, you'll get better performance by gathering
+the sub readers using IndexReader.Context() to get the atomic leaves
+and then operate per-AtomicReader, instead of using this class.
+
+NOTE: the sorted values returned for a composite reader use global
+ordinals, i.e. ordinals of the union of the values of all leaves,
+which are mapped from the ordinals of each leaf by an OrdinalMap. The
+map is built when the values are requested, so callers should hold
+on to the returned values, e.g. for the lifetime of the reader,
+rather than requesting them per query.
+*/
+
+/*
+Returns a SortedDocValues for a reader's docvalues (potentially
+merging on-the-fly), or nil if no leaf has sorted values for the
+field.
+*/
+func GetSortedValues(r IndexReader, field string) (SortedDocValues, error) {
+	leaves := r.Leaves()
+	if len(leaves) == 1 {
+		return leaves[0].Reader().(AtomicReader).SortedDocValues(field)
+	}
+
+	anyReal := false
+	values := make([]SortedDocValues, len(leaves))
+	subs := make([]SortedSetDocValues, len(leaves))
+	starts := make([]int, len(leaves)+1)
+	for i, ctx := range leaves {
+		v, err := ctx.Reader().(AtomicReader).SortedDocValues(field)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = EMPTY_SORTED_DOC_VALUES
+		} else {
+			anyReal = true
+		}
+		values[i] = v
+		subs[i] = SingletonSortedSetDocValues(v)
+		starts[i] = ctx.DocBase
+	}
+	starts[len(leaves)] = r.MaxDoc()
+
+	if !anyReal {
+		return nil, nil
+	}
+	mapping, err := NewOrdinalMap(r, subs)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSortedDocValues{values, starts, mapping}, nil
+}
+
+/*
+Returns a SortedSetDocValues for a reader's docvalues (potentially
+doing extremely slow things), or nil if no leaf has sorted set values
+for the field.
+*/
+func GetSortedSetValues(r IndexReader, field string) (SortedSetDocValues, error) {
+	leaves := r.Leaves()
+	if len(leaves) == 1 {
+		return leaves[0].Reader().(AtomicReader).SortedSetDocValues(field)
+	}
+
+	anyReal := false
+	values := make([]SortedSetDocValues, len(leaves))
+	starts := make([]int, len(leaves)+1)
+	for i, ctx := range leaves {
+		v, err := ctx.Reader().(AtomicReader).SortedSetDocValues(field)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = SingletonSortedSetDocValues(EMPTY_SORTED_DOC_VALUES)
+		} else {
+			anyReal = true
+		}
+		values[i] = v
+		starts[i] = ctx.DocBase
+	}
+	starts[len(leaves)] = r.MaxDoc()
+
+	if !anyReal {
+		return nil, nil
+	}
+	mapping, err := NewOrdinalMap(r, values)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSortedSetDocValues{values: values, docStarts: starts, mapping: mapping}, nil
+}
+
+// index/MultiDocValues.OrdinalMap
+
+/*
+Maps per-segment ordinals to/from global ordinal space.
+
+For each global ordinal, the first segment holding the value and the
+value's ordinal in that segment are kept, to look the value up; for
+each segment, the global ordinal of each of its ordinals is kept as a
+delta, globalOrd - segmentOrd, which is non-negative and grows
+slowly, so that it packs into few bits.
+*/
+type OrdinalMap struct {
+	// cache key of whoever asked for this awful thing
+	owner interface{}
+	// globalOrd -> (globalOrd - segmentOrd) where segmentOrd is the
+	// the ordinal in the first segment that contains this term
+	globalOrdDeltas packed.PackedLongValues
+	// globalOrd -> first segment container
+	firstSegments packed.PackedLongValues
+	// for every segment, segmentOrd -> (globalOrd - segmentOrd)
+	ordDeltas    []packed.PackedLongValues
+	ramBytesUsed int64
+}
+
+/*
+Creates an ordinal map that allows mapping ords to/from a merged
+space from subs. The sorted values of the subs are merged once, in
+O(total number of values * log(number of subs)).
+*/
+func NewOrdinalMap(owner interface{}, subs []SortedSetDocValues) (*OrdinalMap, error) {
+	return NewOrdinalMapWith(owner, subs, packed.PackedInts.DEFAULT)
+}
+
+/*
+Creates an ordinal map, packing the per-segment deltas with the given
+acceptable overhead ratio. The global ordinal side is always packed
+with COMPACT since it is only used to look values up, which is slow
+anyway.
+*/
+func NewOrdinalMapWith(owner interface{}, subs []SortedSetDocValues,
+	acceptableOverheadRatio float32) (*OrdinalMap, error) {
+
+	globalOrdDeltas := packed.PackedBuilder(packed.PackedInts.COMPACT)
+	firstSegments := packed.PackedBuilder(packed.PackedInts.COMPACT)
+	ordDeltas := make([]packed.PackedLongValuesBuilder, len(subs))
+	for i, _ := range ordDeltas {
+		ordDeltas[i] = packed.PackedBuilder(acceptableOverheadRatio)
+	}
+
+	// merge the sorted values of the subs
+	queue := make(ordinalQueue, 0, len(subs))
+	for i, sub := range subs {
+		if sub.ValueCount() > 0 {
+			queue = append(queue, &ordinalCursor{sub, i, 0, sub.LookupOrd(0)})
+		}
+	}
+	heap.Init(&queue)
+
+	var globalOrd int64
+	for len(queue) > 0 {
+		// all subs with the smallest value are on top
+		term := append([]byte(nil), queue[0].term...)
+		firstSegmentIndex, globalOrdDelta := len(subs), int64(-1)
+		for len(queue) > 0 && bytes.Equal(queue[0].term, term) {
+			top := queue[0]
+			delta := globalOrd - top.ord
+			// We compute the least segment where the term occurs. In
+			// case the first segment contains most (or better all)
+			// values, this will help save significant memory
+			if top.index < firstSegmentIndex {
+				firstSegmentIndex, globalOrdDelta = top.index, delta
+			}
+			ordDeltas[top.index].Add(delta)
+			if top.ord++; top.ord < top.values.ValueCount() {
+				top.term = top.values.LookupOrd(top.ord)
+				heap.Fix(&queue, 0)
+			} else {
+				heap.Pop(&queue)
+			}
+		}
+		// for each unique term, just mark the first segment index/delta
+		// where it occurs
+		assert(firstSegmentIndex < len(subs))
+		firstSegments.Add(int64(firstSegmentIndex))
+		globalOrdDeltas.Add(globalOrdDelta)
+		globalOrd++
+	}
+
+	ans := &OrdinalMap{
+		owner:           owner,
+		globalOrdDeltas: globalOrdDeltas.Build(),
+		firstSegments:   firstSegments.Build(),
+		ordDeltas:       make([]packed.PackedLongValues, len(subs)),
+	}
+	ans.ramBytesUsed = util.ShallowSizeOfInstance(reflect.TypeOf(ans)) +
+		ans.globalOrdDeltas.RamBytesUsed() + ans.firstSegments.RamBytesUsed() +
+		util.ShallowSizeOf(ans.ordDeltas)
+	for i, b := range ordDeltas {
+		ans.ordDeltas[i] = b.Build()
+		ans.ramBytesUsed += ans.ordDeltas[i].RamBytesUsed()
+	}
+	return ans, nil
+}
+
+/* Given a segment number and segment ordinal, returns the corresponding global ordinal. */
+func (m *OrdinalMap) GlobalOrd(segmentIndex int, segmentOrd int64) int64 {
+	return segmentOrd + m.ordDeltas[segmentIndex].Get(segmentOrd)
+}
+
+/*
+Given global ordinal, returns the ordinal of the first segment which
+contains this ordinal (the corresponding to the segment return
+FirstSegmentNumber()).
+*/
+func (m *OrdinalMap) FirstSegmentOrd(globalOrd int64) int64 {
+	return globalOrd - m.globalOrdDeltas.Get(globalOrd)
+}
+
+/* Given a global ordinal, returns the index of the first segment that contains this term. */
+func (m *OrdinalMap) FirstSegmentNumber(globalOrd int64) int {
+	return int(m.firstSegments.Get(globalOrd))
+}
+
+/* Returns the total number of unique terms in global ord space. */
+func (m *OrdinalMap) ValueCount() int64 {
+	return m.globalOrdDeltas.Size()
+}
+
+/* Returns the cache key of whoever asked for the map. */
+func (m *OrdinalMap) Owner() interface{} {
+	return m.owner
+}
+
+func (m *OrdinalMap) RamBytesUsed() int64 {
+	return m.ramBytesUsed
+}
+
+func (m *OrdinalMap) String() string {
+	return fmt.Sprintf("OrdinalMap(segments=%v, valueCount=%v, ram=%v)",
+		len(m.ordDeltas), m.ValueCount(), m.ramBytesUsed)
+}
+
+/* Position of a sub in the merge of the sorted values of all subs. */
+type ordinalCursor struct {
+	values SortedSetDocValues
+	index  int
+	ord    int64
+	term   []byte
+}
+
+type ordinalQueue []*ordinalCursor
+
+func (q ordinalQueue) Len() int      { return len(q) }
+func (q ordinalQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q ordinalQueue) Less(i, j int) bool {
+	if cmp := bytes.Compare(q[i].term, q[j].term); cmp != 0 {
+		return cmp < 0
+	}
+	return q[i].index < q[j].index
+}
+func (q *ordinalQueue) Push(x interface{}) { *q = append(*q, x.(*ordinalCursor)) }
+func (q *ordinalQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	ans := old[n-1]
+	*q = old[:n-1]
+	return ans
+}
+
+// index/MultiDocValues.MultiSortedDocValues
+
+/*
+Implements SortedDocValues over n subs, using an OrdinalMap. Ords are
+global ordinals.
+*/
+type MultiSortedDocValues struct {
+	values    []SortedDocValues
+	docStarts []int
+	mapping   *OrdinalMap
+}
+
+/* Returns the OrdinalMap mapping the ordinals of the subs to global ordinals. */
+func (v *MultiSortedDocValues) Mapping() *OrdinalMap {
+	return v.mapping
+}
+
+func (v *MultiSortedDocValues) Ord(docID int) int {
+	subIndex := subIndex(docID, v.docStarts)
+	segmentOrd := v.values[subIndex].Ord(docID - v.docStarts[subIndex])
+	if segmentOrd == -1 {
+		return -1
+	}
+	return int(v.mapping.GlobalOrd(subIndex, int64(segmentOrd)))
+}
+
+func (v *MultiSortedDocValues) LookupOrd(ord int) []byte {
+	subIndex := v.mapping.FirstSegmentNumber(int64(ord))
+	segmentOrd := v.mapping.FirstSegmentOrd(int64(ord))
+	return v.values[subIndex].LookupOrd(int(segmentOrd))
+}
+
+func (v *MultiSortedDocValues) ValueCount() int {
+	return int(v.mapping.ValueCount())
+}
+
+func (v *MultiSortedDocValues) Get(docID int) []byte {
+	if ord := v.Ord(docID); ord != -1 {
+		return v.LookupOrd(ord)
+	}
+	return nil
+}
+
+// index/MultiDocValues.MultiSortedSetDocValues
+
+/*
+Implements MultiSortedSetDocValues over n subs, using an OrdinalMap.
+Ords are global ordinals.
+*/
+type MultiSortedSetDocValues struct {
+	values          []SortedSetDocValues
+	docStarts       []int
+	mapping         *OrdinalMap
+	currentSubIndex int
+}
+
+/* Returns the OrdinalMap mapping the ordinals of the subs to global ordinals. */
+func (v *MultiSortedSetDocValues) Mapping() *OrdinalMap {
+	return v.mapping
+}
+
+func (v *MultiSortedSetDocValues) NextOrd() int64 {
+	segmentOrd := v.values[v.currentSubIndex].NextOrd()
+	if segmentOrd == NO_MORE_ORDS {
+		return segmentOrd
+	}
+	return v.mapping.GlobalOrd(v.currentSubIndex, segmentOrd)
+}
+
+func (v *MultiSortedSetDocValues) SetDocument(docID int) {
+	v.currentSubIndex = subIndex(docID, v.docStarts)
+	v.values[v.currentSubIndex].SetDocument(docID - v.docStarts[v.currentSubIndex])
+}
+
+func (v *MultiSortedSetDocValues) LookupOrd(ord int64) []byte {
+	subIndex := v.mapping.FirstSegmentNumber(ord)
+	segmentOrd := v.mapping.FirstSegmentOrd(ord)
+	return v.values[subIndex].LookupOrd(segmentOrd)
+}
+
+func (v *MultiSortedSetDocValues) ValueCount() int64 {
+	return v.mapping.ValueCount()
+}
+
+// index/SingletonSortedSetDocValues.java
+
+/*
+Exposes multi-valued view over a single-valued instance.
+
+This can be used if you want to have one multi-valued implementation
+against e.g. GetSortedSetValues() that works for single or
+multi-valued types.
+*/
+func SingletonSortedSetDocValues(in SortedDocValues) SortedSetDocValues {
+	return &singletonSortedSetDocValues{in: in, currentOrd: NO_MORE_ORDS}
+}
+
+type singletonSortedSetDocValues struct {
+	in         SortedDocValues
+	currentOrd int64
+}
+
+func (v *singletonSortedSetDocValues) NextOrd() int64 {
+	ord := v.currentOrd
+	v.currentOrd = NO_MORE_ORDS
+	return ord
+}
+
+func (v *singletonSortedSetDocValues) SetDocument(docID int) {
+	v.currentOrd = int64(v.in.Ord(docID))
+	if v.currentOrd == -1 {
+		v.currentOrd = NO_MORE_ORDS
+	}
+}
+
+func (v *singletonSortedSetDocValues) LookupOrd(ord int64) []byte {
+	// cast is ok: single-valued cannot exceed int range
+	return v.in.LookupOrd(int(ord))
+}
+
+func (v *singletonSortedSetDocValues) ValueCount() int64 {
+	return int64(v.in.ValueCount())
+}
+
+type emptySortedDocValues struct{}
+
+func (v emptySortedDocValues) Get(docID int) []byte { return nil }
+func (v emptySortedDocValues) Ord(docID int) int    { return -1 }
+func (v emptySortedDocValues) LookupOrd(int) []byte { panic("no values") }
+func (v emptySortedDocValues) ValueCount() int      { return 0 }
+
+/* An empty SortedDocValues which returns -1 for every document */
+var EMPTY_SORTED_DOC_VALUES SortedDocValues = emptySortedDocValues{}
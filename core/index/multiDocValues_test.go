@@ -0,0 +1,79 @@
+package index
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"testing"
+)
+
+/* In-memory SortedSetDocValues: sorted unique values, and ords per doc. */
+type fakeSortedSetDocValues struct {
+	values [][]byte
+	docs   [][]int64
+	ords   []int64
+}
+
+func newFakeSortedSetDocValues(values []string, docs ...[]int64) *fakeSortedSetDocValues {
+	ans := &fakeSortedSetDocValues{docs: docs}
+	for _, v := range values {
+		ans.values = append(ans.values, []byte(v))
+	}
+	return ans
+}
+
+func (v *fakeSortedSetDocValues) SetDocument(docID int) { v.ords = v.docs[docID] }
+func (v *fakeSortedSetDocValues) LookupOrd(ord int64) []byte {
+	return v.values[ord]
+}
+func (v *fakeSortedSetDocValues) ValueCount() int64 { return int64(len(v.values)) }
+func (v *fakeSortedSetDocValues) NextOrd() int64 {
+	if len(v.ords) == 0 {
+		return NO_MORE_ORDS
+	}
+	ans := v.ords[0]
+	v.ords = v.ords[1:]
+	return ans
+}
+
+func TestOrdinalMap(t *testing.T) {
+	subs := []SortedSetDocValues{
+		newFakeSortedSetDocValues([]string{"blue", "red"}, []int64{0, 1}, []int64{1}),
+		newFakeSortedSetDocValues(nil, []int64{}),
+		newFakeSortedSetDocValues([]string{"green", "red", "yellow"}, []int64{2}, []int64{0, 1}),
+	}
+	m, err := NewOrdinalMap(nil, subs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ValueCount() != 4 {
+		t.Fatalf("Expected 4 global values, got %v", m.ValueCount())
+	}
+	for _, c := range []struct {
+		segment     int
+		ord, global int64
+	}{{0, 0, 0}, {0, 1, 2}, {2, 0, 1}, {2, 1, 2}, {2, 2, 3}} {
+		if got := m.GlobalOrd(c.segment, c.ord); got != c.global {
+			t.Errorf("Expected ord %v of segment %v to map to %v, got %v", c.ord, c.segment, c.global, got)
+		}
+	}
+	// red is in both segments, and looked up in the first
+	if m.FirstSegmentNumber(2) != 0 || m.FirstSegmentOrd(2) != 1 {
+		t.Errorf("Expected red in segment 0 at ord 1, got %v at %v", m.FirstSegmentNumber(2), m.FirstSegmentOrd(2))
+	}
+	if m.FirstSegmentNumber(3) != 2 || m.FirstSegmentOrd(3) != 2 {
+		t.Errorf("Expected yellow in segment 2 at ord 2, got %v at %v", m.FirstSegmentNumber(3), m.FirstSegmentOrd(3))
+	}
+
+	dv := &MultiSortedSetDocValues{values: subs, docStarts: []int{0, 2, 3, 5}, mapping: m}
+	var got []string
+	for doc := 0; doc < 5; doc++ {
+		dv.SetDocument(doc)
+		for ord := dv.NextOrd(); ord != NO_MORE_ORDS; ord = dv.NextOrd() {
+			got = append(got, string(dv.LookupOrd(ord)))
+		}
+		got = append(got, "|")
+	}
+	if s := fmt.Sprint(got); s != "[blue red | red | | yellow | green red |]" {
+		t.Errorf("Unexpected values per doc: %v", s)
+	}
+}
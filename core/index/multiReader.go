@@ -0,0 +1,45 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+)
+
+// index/MultiReader.java
+
+/*
+A CompositeReader which reads multiple indexes, appending their
+content, e.g. the DirectoryReaders of several sub-indexes. The
+document IDs of each sub-reader are shifted by the total MaxDoc() of
+the sub-readers before it.
+
+Closing a MultiReader closes its sub-readers, which must not be used
+by any other reader.
+*/
+type MultiReader struct {
+	*BaseCompositeReader
+}
+
+func NewMultiReader(subReaders ...IndexReader) *MultiReader {
+	ans := &MultiReader{}
+	ans.BaseCompositeReader = newBaseCompositeReader(ans, subReaders)
+	return ans
+}
+
+func (r *MultiReader) doClose() error {
+	var firstErr error
+	for _, sub := range r.getSequentialSubReaders() {
+		// try to close each reader, even if an error is returned
+		func() {
+			defer func() {
+				if err := recover(); err != nil && firstErr == nil {
+					firstErr = errors.New(fmt.Sprintf("%v", err))
+				}
+			}()
+			if err := sub.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	return firstErr
+}
@@ -0,0 +1,256 @@
+package index
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// index/MultiTermsEnum.java
+
+/*
+Exposes TermsEnum API, merged from TermsEnum API of sub-segments.
+This does a merge sort, by term text, of the sub-readers.
+
+Only Next() is supported for now, as needed by Terms.Intersect():
+the sub-enums cannot be positioned by seeking, and positions are not
+merged.
+*/
+type multiTermsEnum struct {
+	*TermsEnumImpl
+	queue   termsEnumQueue
+	current []*termsEnumWithSlice // the subs positioned on the current term
+	term    []byte
+}
+
+type termsEnumWithSlice struct {
+	TermsEnum
+	slice ReaderSlice
+	term  []byte
+}
+
+func newMultiTermsEnum(subs []TermsEnum, slices []ReaderSlice) *multiTermsEnum {
+	ans := new(multiTermsEnum)
+	ans.TermsEnumImpl = NewTermsEnumImpl(ans)
+	// the subs are all advanced by the first Next()
+	for i, sub := range subs {
+		ans.current = append(ans.current, &termsEnumWithSlice{TermsEnum: sub, slice: slices[i]})
+	}
+	return ans
+}
+
+/* Advances the sub-enum, and adds it to the queue unless exhausted. */
+func (e *multiTermsEnum) push(entry *termsEnumWithSlice) (err error) {
+	if entry.term, err = entry.Next(); err != nil {
+		return err
+	}
+	if entry.term != nil {
+		heap.Push(&e.queue, entry)
+	}
+	return nil
+}
+
+func (e *multiTermsEnum) Next() ([]byte, error) {
+	for _, entry := range e.current {
+		if err := e.push(entry); err != nil {
+			return nil, err
+		}
+	}
+	e.current = e.current[:0]
+	if len(e.queue) == 0 {
+		e.term = nil
+		return nil, nil
+	}
+	// gather equal top fields
+	e.term = e.queue[0].term
+	for len(e.queue) > 0 && bytes.Equal(e.queue[0].term, e.term) {
+		e.current = append(e.current, heap.Pop(&e.queue).(*termsEnumWithSlice))
+	}
+	return e.term, nil
+}
+
+func (e *multiTermsEnum) Term() []byte { return e.term }
+
+func (e *multiTermsEnum) DocFreq() (int, error) {
+	sum := 0
+	for _, entry := range e.current {
+		df, err := entry.DocFreq()
+		if err != nil {
+			return 0, err
+		}
+		sum += df
+	}
+	return sum, nil
+}
+
+func (e *multiTermsEnum) TotalTermFreq() (int64, error) {
+	var sum int64
+	for _, entry := range e.current {
+		v, err := entry.TotalTermFreq()
+		if err != nil {
+			return 0, err
+		}
+		if v == -1 {
+			return -1, nil
+		}
+		sum += v
+	}
+	return sum, nil
+}
+
+func (e *multiTermsEnum) DocsByFlags(liveDocs util.Bits, reuse DocsEnum, flags int) (DocsEnum, error) {
+	ans := new(multiDocsEnum)
+	ans.doc = -1
+	for _, entry := range e.current {
+		var subLiveDocs util.Bits
+		if liveDocs != nil {
+			subLiveDocs = &bitsSlice{liveDocs, entry.slice.start, entry.slice.length}
+		}
+		sub, err := entry.DocsByFlags(subLiveDocs, nil, flags)
+		if err != nil {
+			return nil, err
+		}
+		ans.subs = append(ans.subs, docsEnumWithSlice{sub, entry.slice})
+	}
+	// the docs of the subs are merged in the order of their slices
+	sort.Sort(bySliceStart(ans.subs))
+	return ans, nil
+}
+
+func (e *multiTermsEnum) DocsAndPositionsByFlags(liveDocs util.Bits,
+	reuse DocsAndPositionsEnum, flags int) (DocsAndPositionsEnum, error) {
+	return nil, errors.New("positions of merged terms are not supported (not implemented yet)")
+}
+
+func (e *multiTermsEnum) SeekCeil(text []byte) SeekStatus {
+	panic("not supported")
+}
+
+func (e *multiTermsEnum) SeekExactByPosition(ord int64) error {
+	panic("not supported")
+}
+
+func (e *multiTermsEnum) Ord() int64 {
+	panic("not supported")
+}
+
+func (e *multiTermsEnum) String() string {
+	return fmt.Sprintf("MultiTermsEnum(%v)", e.current)
+}
+
+/* Sub-enums ordered by their current term, then by slice. */
+type termsEnumQueue []*termsEnumWithSlice
+
+func (q termsEnumQueue) Len() int { return len(q) }
+func (q termsEnumQueue) Less(i, j int) bool {
+	if cmp := bytes.Compare(q[i].term, q[j].term); cmp != 0 {
+		return cmp < 0
+	}
+	return q[i].slice.start < q[j].slice.start
+}
+func (q termsEnumQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *termsEnumQueue) Push(x interface{}) { *q = append(*q, x.(*termsEnumWithSlice)) }
+func (q *termsEnumQueue) Pop() interface{} {
+	n := len(*q)
+	ans := (*q)[n-1]
+	*q = (*q)[:n-1]
+	return ans
+}
+
+// index/MultiDocsEnum.java
+
+/* Exposes DocsEnum, merged from DocsEnum API of sub-segments. */
+type multiDocsEnum struct {
+	subs []docsEnumWithSlice
+	upto int
+	doc  int
+}
+
+type docsEnumWithSlice struct {
+	DocsEnum
+	slice ReaderSlice
+}
+
+type bySliceStart []docsEnumWithSlice
+
+func (a bySliceStart) Len() int           { return len(a) }
+func (a bySliceStart) Less(i, j int) bool { return a[i].slice.start < a[j].slice.start }
+func (a bySliceStart) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func (e *multiDocsEnum) DocId() int { return e.doc }
+
+func (e *multiDocsEnum) Freq() (int, error) {
+	return e.subs[e.upto].Freq()
+}
+
+func (e *multiDocsEnum) NextDoc() (int, error) {
+	for ; e.upto < len(e.subs); e.upto++ {
+		doc, err := e.subs[e.upto].NextDoc()
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			e.doc = e.subs[e.upto].slice.start + doc
+			return e.doc, nil
+		}
+	}
+	e.doc = NO_MORE_DOCS
+	return e.doc, nil
+}
+
+func (e *multiDocsEnum) Advance(target int) (int, error) {
+	for ; e.upto < len(e.subs); e.upto++ {
+		sub := e.subs[e.upto]
+		if target >= sub.slice.start+sub.slice.length {
+			continue // target is after this sub
+		}
+		var doc int
+		var err error
+		if target > sub.slice.start {
+			doc, err = sub.Advance(target - sub.slice.start)
+		} else {
+			doc, err = sub.NextDoc()
+		}
+		if err != nil {
+			return 0, err
+		}
+		if doc != NO_MORE_DOCS {
+			e.doc = sub.slice.start + doc
+			return e.doc, nil
+		}
+	}
+	e.doc = NO_MORE_DOCS
+	return e.doc, nil
+}
+
+func (e *multiDocsEnum) Cost() int64 {
+	var sum int64
+	for _, sub := range e.subs {
+		sum += sub.Cost()
+	}
+	return sum
+}
+
+func (e *multiDocsEnum) String() string {
+	return fmt.Sprintf("MultiDocsEnum(%v)", e.subs)
+}
+
+// index/BitsSlice.java
+
+/* Exposes a slice of an existing Bits as a new Bits. */
+type bitsSlice struct {
+	parent        util.Bits
+	start, length int
+}
+
+func (b *bitsSlice) At(doc int) bool {
+	assert2(doc >= 0 && doc < b.length, "doc %v is out of bounds [0, %v)", doc, b.length)
+	return b.parent.At(doc + b.start)
+}
+
+func (b *bitsSlice) Length() int { return b.length }
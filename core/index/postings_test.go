@@ -0,0 +1,227 @@
+package index
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	docu "github.com/balzaczyy/golucene/core/document"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+const postingsTestDocs = 1500
+
+type testToken struct {
+	posInc     int
+	start, end int
+	payload    []byte
+}
+
+/* Returns the tokens of term x in doc i; 3750 positions in all. */
+func postingsTestTokens(i int) []testToken {
+	tokens := make([]testToken, 1+i%4)
+	for j := range tokens {
+		start := 10*j + i%3
+		tokens[j] = testToken{1 + j%2, start, start + 1 + j%2, nil}
+		if (i+j)%3 != 0 {
+			tokens[j].payload = []byte{byte(i), byte(j), byte(i >> 8)}[:1+(i+j)%3]
+		}
+	}
+	return tokens
+}
+
+/* Emits x for each token, and y after the tokens of every 5th doc. */
+type postingsTestTokenStream struct {
+	*analysis.TokenStreamImpl
+	termAtt    CharTermAttribute
+	posIncAtt  PositionIncrementAttribute
+	offsetAtt  OffsetAttribute
+	payloadAtt PayloadAttribute
+	tokens     []testToken
+	withY      bool
+	upto       int
+}
+
+func newPostingsTestTokenStream(i int, payloads bool) *postingsTestTokenStream {
+	ans := &postingsTestTokenStream{
+		TokenStreamImpl: analysis.NewTokenStream(),
+		tokens:          postingsTestTokens(i),
+		withY:           i%5 == 0,
+	}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(OffsetAttribute)
+	if payloads {
+		ans.payloadAtt = ans.Attributes().Add("PayloadAttribute").(PayloadAttribute)
+	}
+	return ans
+}
+
+func (ts *postingsTestTokenStream) Reset() error {
+	ts.upto = 0
+	return nil
+}
+
+func (ts *postingsTestTokenStream) IncrementToken() (bool, error) {
+	if ts.upto > len(ts.tokens) || ts.upto == len(ts.tokens) && !ts.withY {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	if ts.upto == len(ts.tokens) {
+		ts.termAtt.AppendString("y")
+		ts.offsetAtt.SetOffset(100, 101)
+	} else {
+		token := ts.tokens[ts.upto]
+		ts.termAtt.AppendString("x")
+		ts.posIncAtt.SetPositionIncrement(token.posInc)
+		ts.offsetAtt.SetOffset(token.start, token.end)
+		if ts.payloadAtt != nil {
+			ts.payloadAtt.SetPayload(token.payload)
+		}
+	}
+	ts.upto++
+	return true, nil
+}
+
+func newPostingsTestReader(t *testing.T) IndexReader {
+	posType := docu.NewFieldTypeFrom(docu.TEXT_FIELD_TYPE_NOT_STORED)
+	posType.SetIndexOptions(INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS)
+	allType := docu.NewFieldTypeFrom(docu.TEXT_FIELD_TYPE_NOT_STORED)
+	allType.SetIndexOptions(INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS)
+
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	for i := 0; i < postingsTestDocs; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewFieldFromTokenStream("pos", newPostingsTestTokenStream(i, false), posType))
+		d.Add(docu.NewFieldFromTokenStream("all", newPostingsTestTokenStream(i, true), allType))
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+/* Checks the next positions of doc i, and its offsets and payloads if asked. */
+func checkPostingsTestPositions(t *testing.T, field string, i int,
+	e DocsAndPositionsEnum, offsets, payloads bool) {
+
+	freq, err := e.Freq()
+	tokens := postingsTestTokens(i)
+	if err != nil || freq != len(tokens) {
+		t.Fatalf("%v: expect freq %v for doc %v, got %v (%v)", field, len(tokens), i, freq, err)
+	}
+	position := -1
+	for j, token := range tokens {
+		position += token.posInc
+		if pos, err := e.NextPosition(); err != nil || pos != position {
+			t.Fatalf("%v: expect position %v of doc %v at %v, got %v (%v)", field, j, i, position, pos, err)
+		}
+		if offsets {
+			start, _ := e.StartOffset()
+			end, _ := e.EndOffset()
+			if start != token.start || end != token.end {
+				t.Fatalf("%v: expect offsets [%v,%v) of doc %v/%v, got [%v,%v)",
+					field, token.start, token.end, i, j, start, end)
+			}
+		}
+		if payloads {
+			if payload, err := e.Payload(); err != nil || !bytes.Equal(payload, token.payload) {
+				t.Fatalf("%v: expect payload %v of doc %v/%v, got %v (%v)",
+					field, token.payload, i, j, payload, err)
+			}
+		}
+	}
+}
+
+func TestPackedPostingsWithSkipData(t *testing.T) {
+	r := newPostingsTestReader(t)
+	defer r.Close()
+	leaf := r.Leaves()[0].Reader().(AtomicReader)
+
+	for _, test := range []struct {
+		field             string
+		flags             int
+		offsets, payloads bool
+	}{
+		{"pos", 0, false, false},
+		// positions only, skipping over the offsets and payloads
+		{"all", 0, false, false},
+		{"all", DOCS_POSITIONS_ENUM_FLAG_OFF_SETS, true, false},
+		{"all", DOCS_POSITIONS_ENUM_FLAG_PAYLOADS, false, true},
+		{"all", DOCS_POSITIONS_ENUM_FLAG_OFF_SETS | DOCS_POSITIONS_ENUM_FLAG_PAYLOADS, true, true},
+	} {
+		termsEnum := leaf.Terms(test.field).Iterator(nil)
+		if ok, err := termsEnum.SeekExact([]byte("x")); !ok || err != nil {
+			t.Fatalf("%v: expect term x, got %v (%v)", test.field, ok, err)
+		}
+		if n, err := termsEnum.TotalTermFreq(); err != nil || n != 3750 {
+			t.Fatalf("%v: expect 3750 positions, got %v (%v)", test.field, n, err)
+		}
+
+		// every doc, reading the positions of some
+		e, err := termsEnum.DocsAndPositionsByFlags(nil, nil, test.flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < postingsTestDocs; i++ {
+			if doc, err := e.NextDoc(); err != nil || doc != i {
+				t.Fatalf("%v: expect doc %v, got %v (%v)", test.field, i, doc, err)
+			}
+			if i%7 == 0 || i > 1400 {
+				checkPostingsTestPositions(t, test.field, i, e, test.offsets, test.payloads)
+			}
+		}
+		if doc, err := e.NextDoc(); err != nil || doc != NO_MORE_DOCS {
+			t.Fatalf("%v: expect no more docs, got %v (%v)", test.field, doc, err)
+		}
+
+		// skipping over whole doc and position blocks
+		if e, err = termsEnum.DocsAndPositionsByFlags(nil, e, test.flags); err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range []int{3, 130, 131, 600, 1100, 1101, 1380, 1499} {
+			if doc, err := e.Advance(target); err != nil || doc != target {
+				t.Fatalf("%v: expect to advance to %v, got %v (%v)", test.field, target, doc, err)
+			}
+			checkPostingsTestPositions(t, test.field, target, e, test.offsets, test.payloads)
+		}
+		if doc, err := e.Advance(postingsTestDocs); err != nil || doc != NO_MORE_DOCS {
+			t.Fatalf("%v: expect no more docs, got %v (%v)", test.field, doc, err)
+		}
+	}
+
+	// docs only, with and without freqs
+	termsEnum := leaf.Terms("all").Iterator(nil)
+	if ok, err := termsEnum.SeekExact([]byte("y")); !ok || err != nil {
+		t.Fatalf("expect term y, got %v (%v)", ok, err)
+	}
+	for _, flags := range []int{0, DOCS_ENUM_FLAG_FREQS} {
+		e, err := termsEnum.DocsByFlags(nil, nil, flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, target := range []int{0, 5, 640, 645, 1200, 1495} {
+			if doc, err := e.Advance(target); err != nil || doc != target {
+				t.Fatalf("expect to advance to %v, got %v (%v)", target, doc, err)
+			}
+			if flags != 0 {
+				if freq, err := e.Freq(); err != nil || freq != 1 {
+					t.Fatalf("expect freq 1 of doc %v, got %v (%v)", target, freq, err)
+				}
+			}
+		}
+		if doc, err := e.NextDoc(); err != nil || doc != NO_MORE_DOCS {
+			t.Fatalf("expect no more docs, got %v (%v)", doc, err)
+		}
+	}
+}
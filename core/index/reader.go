@@ -88,12 +88,21 @@ type IndexReaderImpl struct {
 
 func newIndexReader(spi IndexReaderImplSPI) *IndexReaderImpl {
 	return &IndexReaderImpl{
-		IndexReaderImplSPI: spi,
-		refCount:           1,
-		parentReaders:      make(map[IndexReader]bool),
+		IndexReaderImplSPI:    spi,
+		refCount:              1,
+		parentReaders:         make(map[IndexReader]bool),
+		readerClosedListeners: make(map[ReaderClosedListener]bool),
 	}
 }
 
+/* Expert: adds a listener, invoked once this reader is closed. */
+func (r *IndexReaderImpl) addReaderClosedListener(listener ReaderClosedListener) {
+	r.ensureOpen()
+	r.readerClosedListenersLock.Lock()
+	defer r.readerClosedListenersLock.Unlock()
+	r.readerClosedListeners[listener] = true
+}
+
 func (r *IndexReaderImpl) decRef() error {
 	// only check refcount here (don't call ensureOpen()), so we can
 	// still close the reader if it was made invalid by a child:
@@ -244,6 +253,24 @@ type ARFieldsReader interface {
 	 *  were indexed. The returned instance should only be
 	 *  used by a single thread. */
 	NormValues(field string) (ndv NumericDocValues, err error)
+	// Returns NumericDocValues for this field, or nil if no
+	// NumericDocValues were indexed for this field.
+	NumericDocValues(field string) (NumericDocValues, error)
+	// Returns an iterator over the documents having a value in the
+	// NumericDocValues of this field, or nil if none were indexed.
+	NumericDocValuesIterator(field string) (NumericDocValuesIterator, error)
+	// Returns BinaryDocValues for this field, or nil if no
+	// BinaryDocValues were indexed for this field.
+	BinaryDocValues(field string) (BinaryDocValues, error)
+	// Returns SortedDocValues for this field, or nil if no
+	// SortedDocValues were indexed for this field.
+	SortedDocValues(field string) (SortedDocValues, error)
+	// Returns SortedSetDocValues for this field, or nil if no
+	// SortedSetDocValues were indexed for this field.
+	SortedSetDocValues(field string) (SortedSetDocValues, error)
+	// Returns the VectorValues of this field, or nil if no vectors
+	// were indexed for this field.
+	VectorValues(field string) (VectorValues, error)
 }
 
 type AtomicReader interface {
@@ -27,7 +27,12 @@ func (pool *ReaderPool) infoIsLive(info *SegmentCommitInfo) bool {
 func (pool *ReaderPool) drop(info *SegmentCommitInfo) error {
 	pool.Lock()
 	defer pool.Unlock()
-	panic("not implemented yet")
+	if rld, ok := pool.readerMap[info]; ok {
+		assert(info == rld.info)
+		delete(pool.readerMap, info)
+		return rld.dropReaders()
+	}
+	return nil
 }
 
 func (pool *ReaderPool) release(rld *ReadersAndUpdates) error {
@@ -0,0 +1,356 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Creates, lists and deletes the directories of named indexes. */
+type IndexDirectoryFactory interface {
+	// Returns the names of the existing indexes.
+	List() ([]string, error)
+	// Opens the directory of an index, creating it if needed.
+	Open(name string) (store.Directory, error)
+	// Deletes an index, whose directory is closed.
+	Delete(name string) error
+}
+
+/* Keeps each index in a sub-directory of root. */
+type FSDirectoryFactory struct {
+	root string
+}
+
+func NewFSDirectoryFactory(root string) *FSDirectoryFactory {
+	return &FSDirectoryFactory{root}
+}
+
+func (f *FSDirectoryFactory) List() ([]string, error) {
+	infos, err := ioutil.ReadDir(f.root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ans []string
+	for _, info := range infos {
+		if info.IsDir() {
+			ans = append(ans, info.Name())
+		}
+	}
+	return ans, nil
+}
+
+func (f *FSDirectoryFactory) Open(name string) (store.Directory, error) {
+	path := filepath.Join(f.root, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return store.OpenFSDirectory(path)
+}
+
+func (f *FSDirectoryFactory) Delete(name string) error {
+	return os.RemoveAll(filepath.Join(f.root, name))
+}
+
+/*
+When RolloverManager creates a new index, and drops old ones. A zero
+value disables the corresponding threshold.
+*/
+type RolloverPolicy struct {
+	// Roll over when the active index is at least this old
+	MaxAge time.Duration
+	// Roll over when this many documents were added to the active index
+	MaxDocs int
+	// Roll over when the files of the active index, i.e. its flushed
+	// documents, take at least this many bytes
+	MaxSize int64
+	// Drop an index when its last document is at least this old, i.e.
+	// when the index after it was created at least this long ago
+	Retention time.Duration
+}
+
+const rolloverTimeLayout = "20060102T150405.000000000Z"
+
+type timedIndex struct {
+	name    string
+	created time.Time
+	dir     store.Directory
+	// readers opened by OpenReader() and not closed yet; guarded by the
+	// lock of the RolloverManager
+	readers int
+	// expired, and deleted once its last reader is closed
+	dropped bool
+}
+
+/*
+Manages a series of time-bucketed indexes, e.g. of logs: documents are
+added to the newest, active, index, until it is rolled over to a new
+one, and old indexes are dropped once expired. Indexes are named after
+a prefix and their creation time, and read together with OpenReader().
+
+MaybeRollover() and ApplyRetention() are meant to be called on a
+schedule, e.g. every minute, with the current time. Indexes left by a
+previous RolloverManager with the same prefix are kept, and a new
+active index is created.
+
+An expired index still read by a reader of OpenReader() is only
+deleted once that reader is closed.
+*/
+type RolloverManager struct {
+	sync.Mutex
+	factory   IndexDirectoryFactory
+	prefix    string
+	newConfig func() *IndexWriterConfig
+	policy    RolloverPolicy
+
+	indexes []*timedIndex // oldest first, the last one is active
+	writer  *IndexWriter
+	docs    int
+}
+
+func NewRolloverManager(factory IndexDirectoryFactory, prefix string,
+	newConfig func() *IndexWriterConfig, policy RolloverPolicy, now time.Time) (*RolloverManager, error) {
+
+	m := &RolloverManager{
+		factory:   factory,
+		prefix:    prefix + "-",
+		newConfig: newConfig,
+		policy:    policy,
+	}
+	names, err := factory.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !strings.HasPrefix(name, m.prefix) {
+			continue
+		}
+		created, err := time.Parse(rolloverTimeLayout, name[len(m.prefix):])
+		if err != nil {
+			continue // not ours
+		}
+		dir, err := factory.Open(name)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		m.indexes = append(m.indexes, &timedIndex{name: name, created: created, dir: dir})
+	}
+	if err = m.rollover(now); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+/* Adds a document to the active index. */
+func (m *RolloverManager) AddDocument(doc []IndexableField) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.writer.AddDocument(doc); err != nil {
+		return err
+	}
+	m.docs++
+	return nil
+}
+
+/* Commits the active index, making its documents visible to new readers. */
+func (m *RolloverManager) Commit() error {
+	m.Lock()
+	defer m.Unlock()
+	return m.writer.Commit()
+}
+
+/* Rolls over to a new index if the active one reached a threshold of the policy. */
+func (m *RolloverManager) MaybeRollover(now time.Time) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	active := m.indexes[len(m.indexes)-1]
+	ok := m.policy.MaxAge > 0 && now.Sub(active.created) >= m.policy.MaxAge ||
+		m.policy.MaxDocs > 0 && m.docs >= m.policy.MaxDocs
+	if !ok && m.policy.MaxSize > 0 {
+		size, err := directorySize(active.dir)
+		if err != nil {
+			return false, err
+		}
+		ok = size >= m.policy.MaxSize
+	}
+	if !ok {
+		return false, nil
+	}
+	return true, m.rollover(now)
+}
+
+/*
+Creates a new index, and commits and closes the active one. If the
+new index cannot be created, the active one is kept.
+*/
+func (m *RolloverManager) Rollover(now time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	return m.rollover(now)
+}
+
+func (m *RolloverManager) rollover(now time.Time) error {
+	if n := len(m.indexes); n > 0 && !now.After(m.indexes[n-1].created) {
+		return errors.New(fmt.Sprintf("cannot roll over at %v, before the active index was created", now))
+	}
+	name := m.prefix + now.UTC().Format(rolloverTimeLayout)
+	dir, err := m.factory.Open(name)
+	if err != nil {
+		return err
+	}
+	writer, err := NewIndexWriter(dir, m.newConfig())
+	if err == nil {
+		// so that the new index can be read right away
+		if err = writer.Commit(); err != nil {
+			writer.Close()
+		}
+	}
+	if err == nil && m.writer != nil {
+		// the active index stays usable if its commit fails
+		if err = m.writer.Commit(); err != nil {
+			writer.Close()
+		}
+	}
+	if err != nil {
+		dir.Close()
+		m.factory.Delete(name)
+		return err
+	}
+	old := m.writer
+	m.indexes = append(m.indexes, &timedIndex{name: name, created: now, dir: dir})
+	m.writer, m.docs = writer, 0
+	if old != nil {
+		// committed above, so nothing is lost if closing fails
+		return old.Close()
+	}
+	return nil
+}
+
+/*
+Drops the expired indexes, returning their names. The active index
+never expires. An index still read by a reader of OpenReader() is
+deleted once that reader is closed; if this deletion fails, the index
+is left to be dropped again by the next RolloverManager.
+*/
+func (m *RolloverManager) ApplyRetention(now time.Time) (dropped []string, err error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.policy.Retention <= 0 {
+		return nil, nil
+	}
+	for len(m.indexes) > 1 && now.Sub(m.indexes[1].created) >= m.policy.Retention {
+		index := m.indexes[0]
+		if index.readers == 0 {
+			if err = m.delete(index); err != nil {
+				return
+			}
+		}
+		index.dropped = true
+		m.indexes = m.indexes[1:]
+		dropped = append(dropped, index.name)
+	}
+	return
+}
+
+func (m *RolloverManager) delete(index *timedIndex) error {
+	if err := index.dir.Close(); err != nil {
+		return err
+	}
+	return m.factory.Delete(index.name)
+}
+
+/* Returns the names of the indexes, oldest first, the last one being active. */
+func (m *RolloverManager) Indexes() []string {
+	m.Lock()
+	defer m.Unlock()
+	ans := make([]string, len(m.indexes))
+	for i, index := range m.indexes {
+		ans[i] = index.name
+	}
+	return ans
+}
+
+/*
+Returns a reader of the last commit of every index, oldest first. The
+indexes are not deleted while the reader is open, even if expired.
+*/
+func (m *RolloverManager) OpenReader() (*MultiReader, error) {
+	m.Lock()
+	defer m.Unlock()
+	dirs := make([]store.Directory, len(m.indexes))
+	for i, index := range m.indexes {
+		dirs[i] = index.dir
+	}
+	r, err := OpenCompositeReader(dirs...)
+	if err != nil {
+		return nil, err
+	}
+	indexes := append([]*timedIndex(nil), m.indexes...)
+	for _, index := range indexes {
+		index.readers++
+	}
+	r.addReaderClosedListener(&rolloverReaderListener{m, indexes})
+	return r, nil
+}
+
+/* Releases the indexes of a reader, deleting the dropped ones no longer read. */
+type rolloverReaderListener struct {
+	m       *RolloverManager
+	indexes []*timedIndex
+}
+
+func (l *rolloverReaderListener) onClose(r IndexReader) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	for _, index := range l.indexes {
+		if index.readers--; index.readers == 0 && index.dropped {
+			l.m.delete(index) // left on disk if it fails, see ApplyRetention()
+		}
+	}
+}
+
+/* Commits and closes the active index, and closes the directories of all indexes. */
+func (m *RolloverManager) Close() error {
+	m.Lock()
+	defer m.Unlock()
+	var firstErr error
+	if m.writer != nil {
+		firstErr = m.writer.Close()
+		m.writer = nil
+	}
+	for _, index := range m.indexes {
+		if err := index.dir.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	m.indexes = nil
+	return firstErr
+}
+
+func directorySize(dir store.Directory) (int64, error) {
+	files, err := dir.ListAll()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, file := range files {
+		n, err := dir.FileLength(file)
+		if err != nil {
+			return 0, err
+		}
+		size += n
+	}
+	return size, nil
+}
@@ -556,15 +556,19 @@ func (sis *SegmentInfos) ReadAll(directory store.Directory) error {
 	return err
 }
 
+/*
+Writes the next commit to pending_segments_N. It is only renamed to
+segments_N by finishCommit(), so readers never see a partially
+written commit.
+*/
 func (sis *SegmentInfos) write(directory store.Directory) (err error) {
-	segmentsFilename := sis.nextSegmentFilename()
-
 	// Always advance the generation on write:
 	if sis.generation == -1 {
 		sis.generation = 1
 	} else {
 		sis.generation++
 	}
+	segmentsFilename := util.FileNameFromGeneration(INDEX_FILENAME_PENDING_SEGMENTS, "", sis.generation)
 
 	var segnOutput store.IndexOutput
 	var success = false
@@ -580,7 +584,7 @@ func (sis *SegmentInfos) write(directory store.Directory) (err error) {
 			// 	directory.DeleteFile(filename) // ignore error
 			// }
 
-			// Try not to leave a truncated segments_N fle in the index:
+			// Try not to leave a truncated pending_segments_N fle in the index:
 			directory.DeleteFile(segmentsFilename) // ignore error
 		}
 	}()
@@ -716,7 +720,7 @@ func (sis *SegmentInfos) rollbackCommit(dir store.Directory) {
 
 		// Must carefully compute filename from "generation" since
 		// lastGeneration isn't incremented:
-		segmentFilename := util.FileNameFromGeneration(INDEX_FILENAME_SEGMENTS, "", sis.generation)
+		segmentFilename := util.FileNameFromGeneration(INDEX_FILENAME_PENDING_SEGMENTS, "", sis.generation)
 
 		// Suppress so we keep throwing the original error in our caller
 		util.DeleteFilesIgnoringErrors(dir, segmentFilename)
@@ -813,25 +817,28 @@ func (sis *SegmentInfos) finishCommit(dir store.Directory) (fileName string, err
 		return
 	}
 
-	// NOTE: if we crash here, we have left a segments_N file in the
-	// directory in a possibly corrupt state (if some bytes made it to
-	// stable storage and others didn't). But, the segments_N file
-	// includes checksum at the end, which should catch this case. So
-	// when a reader tries to read it, it will return an index corrupt
-	// error, which should cause the retry logic in SegmentInfos to
-	// kick in and load the last good (previous) segments_N-1 file.
+	// NOTE: if we crash here, we have left a pending_segments_N file
+	// in the directory in a possibly corrupt state (if some bytes made
+	// it to stable storage and others didn't). It is never read, and
+	// IndexFileDeleter removes it on the next open. Only once it is
+	// durable, it is atomically renamed to segments_N, so a power
+	// failure can't leave a partially written commit visible.
 
+	pendingFileName := util.FileNameFromGeneration(INDEX_FILENAME_PENDING_SEGMENTS, "", sis.generation)
 	fileName = util.FileNameFromGeneration(INDEX_FILENAME_SEGMENTS, "", sis.generation)
 	if err = func() error {
 		var success = false
 		defer func() {
 			if !success {
-				dir.DeleteFile(fileName)
+				dir.DeleteFile(pendingFileName)
 				// suppress error so we keep returning the original error
 			}
 		}()
 
-		err := dir.Sync([]string{fileName})
+		if err := dir.Sync([]string{pendingFileName}); err != nil {
+			return err
+		}
+		err := dir.RenameFile(pendingFileName, fileName)
 		success = err == nil
 		return err
 	}(); err != nil {
@@ -881,6 +888,15 @@ func (sis *SegmentInfos) rollbackSegmentInfos(infos []*SegmentCommitInfo) {
 	}
 }
 
+/* Returns the sum of the doc counts of all segments, deleted ones included. */
+func (sis *SegmentInfos) totalDocCount() int64 {
+	var ans int64
+	for _, info := range sis.Segments {
+		ans += int64(info.Info.DocCount())
+	}
+	return ans
+}
+
 func (sis *SegmentInfos) Clear() {
 	for i, _ := range sis.Segments {
 		sis.Segments[i] = nil
@@ -894,5 +910,62 @@ Remove the provided SegmentCommitInfo.
 WARNING: O(N) cost
 */
 func (sis *SegmentInfos) remove(si *SegmentCommitInfo) {
-	panic("not implemented yet")
+	if i := sis.indexOf(si); i >= 0 {
+		copy(sis.Segments[i:], sis.Segments[i+1:])
+		sis.Segments[len(sis.Segments)-1] = nil
+		sis.Segments = sis.Segments[:len(sis.Segments)-1]
+	}
+}
+
+/*
+Returns the position of the provided SegmentCommitInfo, or -1 if it
+is not in the list.
+
+WARNING: O(N) cost
+*/
+func (sis *SegmentInfos) indexOf(si *SegmentCommitInfo) int {
+	for i, info := range sis.Segments {
+		if info == si {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+Replaces all segments of the merge with its merged segment, at the
+position of the first one, or drops them all if dropSegment is true.
+*/
+func (sis *SegmentInfos) applyMergeChanges(merge *OneMerge, dropSegment bool) {
+	mergedAway := make(map[*SegmentCommitInfo]bool)
+	for _, info := range merge.segments {
+		mergedAway[info] = true
+	}
+	inserted := false
+	newSegIdx := 0
+	for _, info := range sis.Segments {
+		if mergedAway[info] {
+			if !inserted && !dropSegment {
+				sis.Segments[newSegIdx] = merge.info
+				inserted = true
+				newSegIdx++
+			}
+		} else {
+			sis.Segments[newSegIdx] = info
+			newSegIdx++
+		}
+	}
+	for i := newSegIdx; i < len(sis.Segments); i++ {
+		sis.Segments[i] = nil
+	}
+	sis.Segments = sis.Segments[:newSegIdx]
+
+	// Either we found place to insert segment, or, we did not, but only
+	// because all segments we merged became deleted while we are
+	// merging, in which case it should be the case that the new
+	// segment is also all deleted, we insert it at the beginning if it
+	// should not be dropped:
+	if !inserted && !dropSegment {
+		sis.Segments = append([]*SegmentCommitInfo{merge.info}, sis.Segments...)
+	}
 }
@@ -0,0 +1,768 @@
+package index
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// index/SegmentMerger.java
+
+/*
+Combines the segments of several readers into a single new segment,
+written with the codec of its SegmentInfo, e.g. by AddIndexes().
+
+Each source segment is decoded by its own reader, whatever the
+formats it was written with, e.g. by an older codec, and re-encoded
+with the formats of the new segment: stored fields are merged by
+MergeStoredFields(), which only copies them as is if the formats
+match, and postings, norms, doc values and vectors are re-encoded
+term by term and document by document. Deleted documents are dropped,
+and the live ones renumbered in the order of the readers.
+
+Only numeric, binary and sorted doc values can be merged yet, and no
+term vectors.
+*/
+type SegmentMerger struct {
+	readers           []AtomicReader
+	directory         store.Directory
+	segmentInfo       *SegmentInfo
+	codec             Codec
+	context           store.IOContext
+	termIndexInterval int
+	infoStream        util.InfoStream
+	fieldInfosBuilder *FieldInfosBuilder
+	fieldInfos        FieldInfos
+
+	docMaps  [][]int // per reader, new doc of each doc, -1 if deleted
+	docCount int
+}
+
+func newSegmentMerger(readers []AtomicReader, segmentInfo *SegmentInfo,
+	infoStream util.InfoStream, dir store.Directory, termIndexInterval int,
+	fieldNumbers *FieldNumbers, context store.IOContext) *SegmentMerger {
+
+	ans := &SegmentMerger{
+		readers:           readers,
+		directory:         dir,
+		segmentInfo:       segmentInfo,
+		codec:             segmentInfo.Codec().(Codec),
+		context:           context,
+		termIndexInterval: termIndexInterval,
+		infoStream:        infoStream,
+		fieldInfosBuilder: NewFieldInfosBuilder(fieldNumbers),
+		docMaps:           make([][]int, len(readers)),
+	}
+	for i, r := range readers {
+		liveDocs := r.LiveDocs()
+		docMap := make([]int, r.MaxDoc())
+		for doc := range docMap {
+			if liveDocs != nil && !liveDocs.At(doc) {
+				docMap[doc] = -1
+			} else {
+				docMap[doc] = ans.docCount
+				ans.docCount++
+			}
+		}
+		ans.docMaps[i] = docMap
+	}
+	return ans
+}
+
+/* Returns true if the readers have live documents to merge. */
+func (m *SegmentMerger) shouldMerge() bool {
+	return m.docCount > 0
+}
+
+/*
+Merges the fields, stored fields, postings, norms, doc values and
+vectors of the readers into the new segment, and returns its field
+infos.
+*/
+func (m *SegmentMerger) merge() (FieldInfos, error) {
+	assert2(m.shouldMerge(), "Merge would result in 0 document segment")
+	if err := m.mergeFieldInfos(); err != nil {
+		return FieldInfos{}, err
+	}
+	m.segmentInfo.SetDocCount(m.docCount)
+
+	if err := m.mergeStoredFields(); err != nil {
+		return FieldInfos{}, err
+	}
+	if err := m.mergeTerms(); err != nil {
+		return FieldInfos{}, err
+	}
+	if m.fieldInfos.HasNorms {
+		if err := m.mergeNorms(); err != nil {
+			return FieldInfos{}, err
+		}
+	}
+	if m.fieldInfos.HasDocValues {
+		if err := m.mergeDocValues(); err != nil {
+			return FieldInfos{}, err
+		}
+	}
+	if m.fieldInfos.HasVectorValues {
+		if err := m.mergeVectors(); err != nil {
+			return FieldInfos{}, err
+		}
+	}
+
+	// write the merged infos
+	infosWriter := m.codec.FieldInfosFormat().FieldInfosWriter()
+	if err := infosWriter(m.directory, m.segmentInfo.Name, "", m.fieldInfos, m.context); err != nil {
+		return FieldInfos{}, err
+	}
+	return m.fieldInfos, nil
+}
+
+func (m *SegmentMerger) mergeFieldInfos() error {
+	for _, r := range m.readers {
+		fr, ok := r.(interface {
+			FieldInfos() FieldInfos
+		})
+		if !ok {
+			return errors.New(fmt.Sprintf("cannot merge %v: unknown field infos", r))
+		}
+		for _, fi := range fr.FieldInfos().Values {
+			if fi.HasVectors() {
+				return errors.New(fmt.Sprintf(
+					"cannot merge term vectors of field '%v' (not implemented yet)", fi.Name))
+			}
+			m.fieldInfosBuilder.Add(fi)
+		}
+	}
+	m.fieldInfos = m.fieldInfosBuilder.Finish()
+	return nil
+}
+
+func (m *SegmentMerger) mergeStoredFields() (err error) {
+	state := &MergeState{
+		SegmentInfo:         m.segmentInfo,
+		FieldInfos:          m.fieldInfos,
+		StoredFieldsReaders: make([]StoredFieldsReader, len(m.readers)),
+		LiveDocs:            make([]util.Bits, len(m.readers)),
+		MaxDocs:             make([]int, len(m.readers)),
+	}
+	for i, r := range m.readers {
+		if sr, ok := r.(*SegmentReader); ok {
+			state.StoredFieldsReaders[i] = sr.FieldsReader()
+		} else {
+			state.StoredFieldsReaders[i] = &readerStoredFields{r}
+		}
+		state.LiveDocs[i] = r.LiveDocs()
+		state.MaxDocs[i] = r.MaxDoc()
+	}
+
+	w, err := m.codec.StoredFieldsFormat().FieldsWriter(m.directory, m.segmentInfo, m.context)
+	if err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(w)
+		} else {
+			util.CloseWhileSuppressingError(w)
+		}
+	}()
+	numDocs, err := MergeStoredFields(w, state)
+	if err != nil {
+		return err
+	}
+	assert2(numDocs == m.docCount, "merged %v stored documents, expected %v", numDocs, m.docCount)
+	success = true
+	return nil
+}
+
+/* Reads the stored fields of a reader other than a SegmentReader. */
+type readerStoredFields struct {
+	reader AtomicReader
+}
+
+func (r *readerStoredFields) VisitDocument(n int, visitor StoredFieldVisitor) error {
+	return r.reader.VisitDocument(n, visitor)
+}
+
+func (r *readerStoredFields) Clone() StoredFieldsReader {
+	return r
+}
+
+func (r *readerStoredFields) Close() error {
+	return nil
+}
+
+func (m *SegmentMerger) mergeTerms() (err error) {
+	state := NewSegmentWriteState(m.infoStream, m.directory, m.segmentInfo,
+		m.fieldInfos, m.termIndexInterval, nil, m.context)
+	consumer, err := m.codec.PostingsFormat().FieldsConsumer(state)
+	if err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(consumer)
+		} else {
+			util.CloseWhileSuppressingError(consumer)
+		}
+	}()
+
+	// same order as a flush
+	var names []string
+	for _, fi := range m.fieldInfos.Values {
+		if fi.IsIndexed() {
+			names = append(names, fi.Name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err = m.mergeField(consumer, m.fieldInfos.FieldInfoByName(name)); err != nil {
+			return err
+		}
+	}
+	success = true
+	return nil
+}
+
+/* The terms of a field in a reader being merged, and its current term. */
+type mergeTermsEnum struct {
+	TermsEnum
+	reader int
+	term   []byte
+}
+
+/*
+Re-encodes the postings of the field in the readers, merging their
+terms in order, with the index options of the merged field, which
+may be lower than those of a segment.
+*/
+func (m *SegmentMerger) mergeField(consumer FieldsConsumer, fi *FieldInfo) error {
+	var enums []*mergeTermsEnum
+	for i, r := range m.readers {
+		terms := r.Terms(fi.Name)
+		if terms == nil {
+			continue
+		}
+		te := terms.Iterator(nil)
+		term, err := te.Next()
+		if err != nil {
+			return err
+		}
+		if term != nil {
+			enums = append(enums, &mergeTermsEnum{te, i, term})
+		}
+	}
+	if len(enums) == 0 {
+		return nil
+	}
+
+	termsConsumer, err := consumer.AddField(fi)
+	if err != nil {
+		return err
+	}
+	indexOptions := fi.IndexOptions()
+	writeTermFreq := indexOptions >= INDEX_OPT_DOCS_AND_FREQS
+	writePositions := indexOptions >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS
+	writeOffsets := indexOptions >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS_AND_OFFSETS
+
+	visitedDocs := util.NewFixedBitSetOf(m.docCount)
+	sumTotalTermFreq, sumDocFreq := int64(0), int64(0)
+	var docs DocsEnum
+	var postings DocsAndPositionsEnum
+	var matches []*mergeTermsEnum
+	for len(enums) > 0 {
+		// the smallest term, and the readers having it, in order
+		matches = matches[:0]
+		for _, e := range enums {
+			if len(matches) == 0 {
+				matches = append(matches, e)
+			} else if c := bytes.Compare(e.term, matches[0].term); c < 0 {
+				matches = append(matches[:0], e)
+			} else if c == 0 {
+				matches = append(matches, e)
+			}
+		}
+		// copied, as enums may reuse their term
+		term := append([]byte(nil), matches[0].term...)
+
+		postingsConsumer, err := termsConsumer.StartTerm(term)
+		if err != nil {
+			return err
+		}
+		docFreq, totalTermFreq := 0, int64(0)
+		for _, e := range matches {
+			docMap, liveDocs := m.docMaps[e.reader], m.readers[e.reader].LiveDocs()
+			var de DocsEnum
+			if writePositions {
+				flags := 0
+				if writeOffsets {
+					flags |= DOCS_POSITIONS_ENUM_FLAG_OFF_SETS
+				}
+				if fi.HasPayloads() {
+					flags |= DOCS_POSITIONS_ENUM_FLAG_PAYLOADS
+				}
+				if postings, err = e.DocsAndPositionsByFlags(liveDocs, postings, flags); err != nil {
+					return err
+				}
+				de = postings
+			} else {
+				flags := 0
+				if writeTermFreq {
+					flags = DOCS_ENUM_FLAG_FREQS
+				}
+				if docs, err = e.DocsByFlags(liveDocs, docs, flags); err != nil {
+					return err
+				}
+				de = docs
+			}
+
+			for {
+				doc, err := de.NextDoc()
+				if err != nil {
+					return err
+				}
+				if doc == NO_MORE_DOCS {
+					break
+				}
+				newDoc := docMap[doc]
+				assert(newDoc >= 0)
+				freq := -1
+				if writeTermFreq {
+					if freq, err = de.Freq(); err != nil {
+						return err
+					}
+					totalTermFreq += int64(freq)
+				}
+				visitedDocs.Set(newDoc)
+				if err = postingsConsumer.StartDoc(newDoc, freq); err != nil {
+					return err
+				}
+				if writePositions {
+					if err = copyPositions(postingsConsumer, postings, freq, writeOffsets, fi.HasPayloads()); err != nil {
+						return err
+					}
+				}
+				if err = postingsConsumer.FinishDoc(); err != nil {
+					return err
+				}
+				docFreq++
+			}
+		}
+		if docFreq > 0 {
+			err = termsConsumer.FinishTerm(term, codec.NewTermStats(docFreq,
+				map[bool]int64{true: totalTermFreq, false: -1}[writeTermFreq]))
+			if err != nil {
+				return err
+			}
+			sumTotalTermFreq += totalTermFreq
+			sumDocFreq += int64(docFreq)
+		}
+
+		// move the matching enums to their next term
+		n := 0
+		for _, e := range enums {
+			if bytes.Equal(e.term, term) {
+				if e.term, err = e.Next(); err != nil {
+					return err
+				}
+			}
+			if e.term != nil {
+				enums[n] = e
+				n++
+			}
+		}
+		enums = enums[:n]
+	}
+
+	return termsConsumer.Finish(
+		map[bool]int64{true: sumTotalTermFreq, false: -1}[writeTermFreq],
+		sumDocFreq, visitedDocs.Cardinality())
+}
+
+/* Copies the positions, and offsets and payloads if written, of the current doc. */
+func copyPositions(consumer codec.PostingsConsumer, postings DocsAndPositionsEnum,
+	freq int, writeOffsets, writePayloads bool) error {
+
+	for i := 0; i < freq; i++ {
+		position, err := postings.NextPosition()
+		if err != nil {
+			return err
+		}
+		startOffset, endOffset := -1, -1
+		if writeOffsets {
+			if startOffset, err = postings.StartOffset(); err != nil {
+				return err
+			}
+			if endOffset, err = postings.EndOffset(); err != nil {
+				return err
+			}
+		}
+		var payload []byte
+		if writePayloads {
+			if payload, err = postings.Payload(); err != nil {
+				return err
+			}
+		}
+		if err = consumer.AddPosition(position, payload, startOffset, endOffset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *SegmentMerger) mergeNorms() (err error) {
+	state := NewSegmentWriteState(m.infoStream, m.directory, m.segmentInfo,
+		m.fieldInfos, m.termIndexInterval, nil, m.context)
+	consumer, err := m.codec.NormsFormat().NormsConsumer(state)
+	if err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(consumer)
+		} else {
+			util.CloseWhileSuppressingError(consumer)
+		}
+	}()
+
+	for _, fi := range m.fieldInfos.Values {
+		if !fi.HasNorms() {
+			continue
+		}
+		norms := make([]NumericDocValues, len(m.readers))
+		for i, r := range m.readers {
+			if norms[i], err = r.NormValues(fi.Name); err != nil {
+				return err
+			}
+		}
+		err = consumer.AddNumericField(fi, func() func() (interface{}, bool) {
+			return m.liveValues(norms)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	success = true
+	return nil
+}
+
+/*
+Iterates over the values of the live docs of the readers, in the
+order of the merged segment; 0 for a reader without values.
+*/
+func (m *SegmentMerger) liveValues(values []NumericDocValues) func() (interface{}, bool) {
+	reader, doc := 0, 0
+	return func() (interface{}, bool) {
+		for reader < len(m.readers) {
+			if doc == len(m.docMaps[reader]) {
+				reader, doc = reader+1, 0
+				continue
+			}
+			d := doc
+			doc++
+			if m.docMaps[reader][d] < 0 {
+				continue // deleted
+			}
+			if values[reader] == nil {
+				return int64(0), true
+			}
+			return values[reader](d), true
+		}
+		return nil, false
+	}
+}
+
+func (m *SegmentMerger) mergeDocValues() (err error) {
+	state := NewSegmentWriteState(m.infoStream, m.directory, m.segmentInfo,
+		m.fieldInfos, m.termIndexInterval, nil, m.context)
+	consumer, err := m.codec.DocValuesFormat().FieldsConsumer(state)
+	if err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(consumer)
+		} else {
+			util.CloseWhileSuppressingError(consumer)
+		}
+	}()
+
+	for _, fi := range m.fieldInfos.Values {
+		switch fi.DocValuesType() {
+		case 0:
+			continue
+		case DOC_VALUES_TYPE_NUMERIC:
+			values := make([]NumericDocValues, len(m.readers))
+			docsWithField := make([]util.Bits, len(m.readers))
+			for i, r := range m.readers {
+				if values[i], err = r.NumericDocValues(fi.Name); err != nil {
+					return err
+				}
+				if docsWithField[i], err = numericDocsWithField(r, fi.Name); err != nil {
+					return err
+				}
+			}
+			err = consumer.AddNumericField(fi, func() func() (interface{}, bool) {
+				return m.liveNumericValues(values, docsWithField)
+			})
+		case DOC_VALUES_TYPE_BINARY:
+			values := make([]BinaryDocValues, len(m.readers))
+			for i, r := range m.readers {
+				if values[i], err = r.BinaryDocValues(fi.Name); err != nil {
+					return err
+				}
+			}
+			err = consumer.AddBinaryField(fi, func() func() ([]byte, bool) {
+				return m.liveBinaryValues(values)
+			})
+		case DOC_VALUES_TYPE_SORTED:
+			values := make([]SortedDocValues, len(m.readers))
+			for i, r := range m.readers {
+				if values[i], err = r.SortedDocValues(fi.Name); err != nil {
+					return err
+				}
+			}
+			err = m.mergeSortedField(consumer, fi, values)
+		default:
+			err = errors.New(fmt.Sprintf(
+				"cannot merge doc values of field '%v' (not implemented yet)", fi.Name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	success = true
+	return nil
+}
+
+/* Returns the docs of the reader having a numeric value in field, or nil if none. */
+func numericDocsWithField(r AtomicReader, field string) (util.Bits, error) {
+	it, err := r.NumericDocValuesIterator(field)
+	if err != nil || it == nil {
+		return nil, err
+	}
+	ans := util.NewFixedBitSetOf(r.MaxDoc())
+	doc, err := it.NextDoc()
+	for ; err == nil && doc != NO_MORE_DOCS; doc, err = it.NextDoc() {
+		ans.Set(doc)
+	}
+	return ans, err
+}
+
+/*
+Iterates over the numeric values of the live docs of the readers, in
+the order of the merged segment; nil for a doc without value.
+*/
+func (m *SegmentMerger) liveNumericValues(values []NumericDocValues,
+	docsWithField []util.Bits) func() (interface{}, bool) {
+
+	reader, doc := 0, 0
+	return func() (interface{}, bool) {
+		for reader < len(m.readers) {
+			if doc == len(m.docMaps[reader]) {
+				reader, doc = reader+1, 0
+				continue
+			}
+			d := doc
+			doc++
+			if m.docMaps[reader][d] < 0 {
+				continue // deleted
+			}
+			if docsWithField[reader] == nil || !docsWithField[reader].At(d) {
+				return nil, true
+			}
+			return values[reader](d), true
+		}
+		return nil, false
+	}
+}
+
+/*
+Merges the sorted values of the readers with an OrdinalMap; the values
+only held by deleted docs are dropped.
+*/
+func (m *SegmentMerger) mergeSortedField(consumer DocValuesConsumer,
+	fi *FieldInfo, values []SortedDocValues) error {
+
+	subs := make([]SortedSetDocValues, len(values))
+	for i, v := range values {
+		if v == nil {
+			values[i] = EMPTY_SORTED_DOC_VALUES
+		}
+		subs[i] = SingletonSortedSetDocValues(values[i])
+	}
+	mapping, err := NewOrdinalMap(m, subs)
+	if err != nil {
+		return err
+	}
+
+	// global ord -> ord in the merged segment, of the values of live docs
+	live := make([]bool, mapping.ValueCount())
+	for i, v := range values {
+		for doc, newDoc := range m.docMaps[i] {
+			if ord := v.Ord(doc); newDoc >= 0 && ord != -1 {
+				live[mapping.GlobalOrd(i, int64(ord))] = true
+			}
+		}
+	}
+	newOrds := make([]int64, len(live))
+	var globalOrds []int64
+	for globalOrd, ok := range live {
+		if ok {
+			newOrds[globalOrd] = int64(len(globalOrds))
+			globalOrds = append(globalOrds, int64(globalOrd))
+		}
+	}
+
+	return consumer.AddSortedField(fi,
+		func() func() ([]byte, bool) {
+			upto := 0
+			return func() ([]byte, bool) {
+				if upto == len(globalOrds) {
+					return nil, false
+				}
+				globalOrd := globalOrds[upto]
+				upto++
+				segmentOrd := mapping.FirstSegmentOrd(globalOrd)
+				return values[mapping.FirstSegmentNumber(globalOrd)].LookupOrd(int(segmentOrd)), true
+			}
+		},
+		func() func() (interface{}, bool) {
+			return m.liveOrds(values, mapping, newOrds)
+		})
+}
+
+/*
+Iterates over the ords in the merged segment of the sorted values of
+the live docs of the readers; nil for a doc without value.
+*/
+func (m *SegmentMerger) liveOrds(values []SortedDocValues,
+	mapping *OrdinalMap, newOrds []int64) func() (interface{}, bool) {
+
+	reader, doc := 0, 0
+	return func() (interface{}, bool) {
+		for reader < len(m.readers) {
+			if doc == len(m.docMaps[reader]) {
+				reader, doc = reader+1, 0
+				continue
+			}
+			d := doc
+			doc++
+			if m.docMaps[reader][d] < 0 {
+				continue // deleted
+			}
+			ord := values[reader].Ord(d)
+			if ord == -1 {
+				return nil, true
+			}
+			return newOrds[mapping.GlobalOrd(reader, int64(ord))], true
+		}
+		return nil, false
+	}
+}
+
+/*
+Iterates over the binary values of the live docs of the readers, in
+the order of the merged segment; nil for a reader without values.
+*/
+func (m *SegmentMerger) liveBinaryValues(values []BinaryDocValues) func() ([]byte, bool) {
+	reader, doc := 0, 0
+	return func() ([]byte, bool) {
+		for reader < len(m.readers) {
+			if doc == len(m.docMaps[reader]) {
+				reader, doc = reader+1, 0
+				continue
+			}
+			d := doc
+			doc++
+			if m.docMaps[reader][d] < 0 {
+				continue // deleted
+			}
+			if values[reader] == nil {
+				return nil, true
+			}
+			return values[reader].Get(d), true
+		}
+		return nil, false
+	}
+}
+
+func (m *SegmentMerger) mergeVectors() (err error) {
+	format := m.codec.KnnVectorsFormat()
+	if format == nil {
+		return errors.New(fmt.Sprintf("codec %v does not support vectors", m.codec.Name()))
+	}
+	state := NewSegmentWriteState(m.infoStream, m.directory, m.segmentInfo,
+		m.fieldInfos, m.termIndexInterval, nil, m.context)
+	writer, err := format.VectorsWriter(state)
+	if err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if success {
+			err = util.Close(writer)
+		} else {
+			util.CloseWhileSuppressingError(writer)
+		}
+	}()
+
+	for _, fi := range m.fieldInfos.Values {
+		if fi.VectorDimension() == 0 {
+			continue
+		}
+		vectors := make([]VectorValues, len(m.readers))
+		for i, r := range m.readers {
+			if vectors[i], err = r.VectorValues(fi.Name); err != nil {
+				return err
+			}
+		}
+		err = writer.AddField(fi, func() func() ([]float32, bool) {
+			return m.liveVectors(vectors)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	success = true
+	return nil
+}
+
+/*
+Iterates over the vectors of the live docs of the readers, in the
+order of the merged segment; nil for a doc without vector.
+*/
+func (m *SegmentMerger) liveVectors(vectors []VectorValues) func() ([]float32, bool) {
+	reader, doc, ord := 0, 0, 0
+	return func() ([]float32, bool) {
+		for reader < len(m.readers) {
+			if doc == len(m.docMaps[reader]) {
+				reader, doc, ord = reader+1, 0, 0
+				continue
+			}
+			d := doc
+			doc++
+			var v []float32
+			if values := vectors[reader]; values != nil &&
+				ord < values.Size() && values.Doc(ord) == d {
+				v = values.VectorValue(ord)
+				ord++
+			}
+			if m.docMaps[reader][d] < 0 {
+				continue // deleted
+			}
+			return v, true
+		}
+		return nil, false
+	}
+}
@@ -0,0 +1,63 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+func TestAddIndexesOfOlderCodec(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	srcCodec := src.Leaves()[0].Reader().(*SegmentReader).SegmentInfos().Info.Codec()
+
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	if err = w.AddDocument(newIdDoc(0).Fields()); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.AddIndexes(src, src); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if n := r.MaxDoc(); n != 1+2*src.MaxDoc() {
+		t.Fatalf("expect %v docs, got %v", 1+2*src.MaxDoc(), n)
+	}
+	leaves := r.Leaves()
+	merged := leaves[len(leaves)-1].Reader().(*SegmentReader)
+	if c := merged.SegmentInfos().Info.Codec(); c == srcCodec {
+		t.Errorf("expect the merged segment re-encoded, got codec %v", c)
+	}
+	want, err := src.DocFreq(NewTerm("content", "bat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.DocFreq(NewTerm("content", "bat")); err != nil || got != 2*want {
+		t.Errorf("expect docFreq %v, got %v (%v)", 2*want, got, err)
+	}
+	srcDoc, err := src.Document(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := merged.Document(src.MaxDoc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Get("title") != srcDoc.Get("title") {
+		t.Errorf("expect title %v, got %v", srcDoc.Get("title"), doc.Get("title"))
+	}
+}
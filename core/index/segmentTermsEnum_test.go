@@ -3,6 +3,8 @@ package index
 import (
 	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
 	"github.com/balzaczyy/golucene/core/store"
+	"io/ioutil"
+	"os"
 	"testing"
 )
 
@@ -37,3 +39,129 @@ func TestInitTerm(t *testing.T) {
 		t.Error("SeekExact should return true.")
 	}
 }
+
+func TestTermsEnumNext(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := r.Context().Leaves()[0].reader.Fields().Terms("content")
+
+	var all []string
+	termsEnum := terms.Iterator(nil)
+	for {
+		term, err := termsEnum.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if term == nil {
+			break
+		}
+		if len(term) != len(termsEnum.Term()) {
+			t.Errorf("Term() should hold the current term %q, but was %q", term, termsEnum.Term())
+		}
+		if n := len(all); n > 0 && all[n-1] >= string(term) {
+			t.Errorf("Terms should be sorted, but %q came after %q", term, all[n-1])
+		}
+		all = append(all, string(term))
+	}
+	if len(all) == 0 || all[0] == "" {
+		t.Fatalf("Expected the terms of the content field, but got %v", all)
+	}
+
+	// every enumerated term can be sought, and next continues from it
+	for i, term := range all {
+		termsEnum = terms.Iterator(nil)
+		ok, err := termsEnum.SeekExact([]byte(term))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("SeekExact(%q) should return true.", term)
+		}
+		next, err := termsEnum.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i+1 < len(all) && string(next) != all[i+1] {
+			t.Errorf("Expected %q after %q, but got %q", all[i+1], term, next)
+		} else if i+1 == len(all) && next != nil {
+			t.Errorf("Expected no term after %q, but got %q", term, next)
+		}
+	}
+}
+
+func TestTermsEnumSeekExactPrintsNothing(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := r.Context().Leaves()[0].reader.Fields().Terms("content")
+
+	stdout := os.Stdout
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = pw
+	for _, term := range []string{"bat", "zzz"} {
+		_, err = terms.Iterator(nil).SeekExact([]byte(term))
+		if err != nil {
+			break
+		}
+	}
+	os.Stdout = stdout
+	pw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed, _ := ioutil.ReadAll(pr)
+	if len(printed) > 0 {
+		t.Errorf("SeekExact should not print, but printed:\n%s", printed)
+	}
+}
+
+func TestTermsEnumSeekExactInOrder(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := r.Context().Leaves()[0].reader.Fields().Terms("content")
+
+	var all []string
+	termsEnum := terms.Iterator(nil)
+	for {
+		term, err := termsEnum.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if term == nil {
+			break
+		}
+		all = append(all, string(term))
+	}
+
+	// reuses the seek state of the previous term each time
+	termsEnum = terms.Iterator(nil)
+	for _, term := range all {
+		ok, err := termsEnum.SeekExact([]byte(term))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("SeekExact(%q) should return true.", term)
+		}
+	}
+}
@@ -5,12 +5,16 @@ import (
 )
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	// docu "github.com/balzaczyy/golucene/core/document"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sync"
 	"sync/atomic"
 )
 
@@ -74,37 +78,24 @@ func NewSegmentReader(si *SegmentCommitInfo,
 
 	codec := si.Info.Codec().(Codec)
 	if si.HasDeletions() {
-		panic("not supported yet")
+		// NOTE: the bitvector is stored using the regular directory, not cfs
+		if r.liveDocs, err = codec.LiveDocsFormat().ReadLiveDocs(si.Info.Dir, si, store.IO_CONTEXT_READONCE); err != nil {
+			return nil, err
+		}
 	} else {
 		assert(si.DelCount() == 0)
 	}
 	r.numDocs = si.Info.DocCount() - si.DelCount()
 
-	if r.fieldInfos.HasDocValues {
-		r.initDocValuesProducers(codec)
+	if r.fieldInfos.HasDocValues && si.HasFieldUpdates() {
+		// the doc values of the segment are read by the core, until
+		// doc values updates are ported
+		panic("not implemented yet")
 	}
 	success = true
 	return r, nil
 }
 
-/* initialize the per-field DocValuesProducer */
-func (r *SegmentReader) initDocValuesProducers(codec Codec) error {
-	// var dir store.Directory
-	// if r.core.cfsReader != nil {
-	// 	dir = r.core.cfsReader
-	// } else {
-	// 	dir = r.si.Info.Dir
-	// }
-	// dvFormat := codec.DocValuesFormat()
-
-	// termsIndexDivisor := r.core.termsIndexDivisor
-	if !r.si.HasFieldUpdates() {
-		panic("not implemented yet")
-	}
-
-	panic("not implemented yet")
-}
-
 /* Reads the most recent FieldInfos of the given segment info. */
 func ReadFieldInfos(info *SegmentCommitInfo) (fis FieldInfos, err error) {
 	var dir store.Directory
@@ -144,7 +135,6 @@ func (r *SegmentReader) LiveDocs() util.Bits {
 }
 
 func (r *SegmentReader) doClose() error {
-	panic("not implemented yet")
 	r.core.decRef()
 	return nil
 }
@@ -216,6 +206,17 @@ func (r *SegmentReader) Directory() store.Directory {
 	return r.si.Info.Dir
 }
 
+/* Returns the memory used by the core readers of the segment. */
+func (r *SegmentReader) RamBytesUsed() int64 {
+	r.ensureOpen()
+	return util.ShallowSizeOfInstance(reflect.TypeOf(r)) + r.core.RamBytesUsed()
+}
+
+func (r *SegmentReader) ChildResources() []util.Accountable {
+	r.ensureOpen()
+	return r.core.ChildResources()
+}
+
 func (r *SegmentReader) CoreCacheKey() interface{} {
 	return r.core
 }
@@ -230,17 +231,51 @@ func (r *SegmentReader) TermInfosIndexDivisor() int {
 
 func (r *SegmentReader) NumericDocValues(field string) (v NumericDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	fi := r.docValuesFieldInfo(field, DOC_VALUES_TYPE_NUMERIC)
+	if fi == nil {
+		return nil, nil
+	}
+	return r.core.dvProducer.Numeric(fi)
+}
+
+func (r *SegmentReader) NumericDocValuesIterator(field string) (v NumericDocValuesIterator, err error) {
+	r.ensureOpen()
+	fi := r.docValuesFieldInfo(field, DOC_VALUES_TYPE_NUMERIC)
+	if fi == nil {
+		return nil, nil
+	}
+	return r.core.dvProducer.NumericIterator(fi)
 }
 
 func (r *SegmentReader) BinaryDocValues(field string) (v BinaryDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	fi := r.docValuesFieldInfo(field, DOC_VALUES_TYPE_BINARY)
+	if fi == nil {
+		return nil, nil
+	}
+	return r.core.dvProducer.Binary(fi)
+}
+
+/*
+Returns the info of the field if it has doc values of the given type,
+nil otherwise.
+*/
+func (r *SegmentReader) docValuesFieldInfo(field string, dvType DocValuesType) *FieldInfo {
+	if fi := r.fieldInfos.FieldInfoByName(field); fi != nil && fi.DocValuesType() == dvType {
+		assert(r.core.dvProducer != nil)
+		return fi
+	}
+	// Field does not exist, or has no doc values of this type
+	return nil
 }
 
 func (r *SegmentReader) SortedDocValues(field string) (v SortedDocValues, err error) {
 	r.ensureOpen()
-	panic("not implemented yet")
+	fi := r.docValuesFieldInfo(field, DOC_VALUES_TYPE_SORTED)
+	if fi == nil {
+		return nil, nil
+	}
+	return r.core.dvProducer.Sorted(fi)
 }
 
 func (r *SegmentReader) SortedSetDocValues(field string) (v SortedSetDocValues, err error) {
@@ -253,8 +288,41 @@ func (r *SegmentReader) NormValues(field string) (v NumericDocValues, err error)
 	return r.core.normValues(r.fieldInfos, field)
 }
 
+func (r *SegmentReader) VectorValues(field string) (VectorValues, error) {
+	r.ensureOpen()
+	fi := r.fieldInfos.FieldInfoByName(field)
+	if fi == nil || fi.VectorDimension() == 0 || r.core.vectorsReader == nil {
+		return nil, nil
+	}
+	return r.core.vectorsReader.VectorValues(fi)
+}
+
+/*
+Called when the shared core for a SegmentReader is closed, i.e. when
+all the readers sharing it, e.g. reopened with new deletes, were
+closed, as after the segment was merged away.
+*/
 type CoreClosedListener interface {
-	onClose(r interface{})
+	/*
+		Invoked when the shared core of the original SegmentReader has
+		closed, with its CoreCacheKey().
+	*/
+	OnClose(ownerCoreCacheKey interface{})
+}
+
+/*
+Expert: adds a listener called when the core of this reader, shared
+with the other readers of the segment, is closed.
+*/
+func (r *SegmentReader) AddCoreClosedListener(listener CoreClosedListener) {
+	r.ensureOpen()
+	r.core.addCoreClosedListener(listener)
+}
+
+/* Expert: removes a listener added by AddCoreClosedListener(). */
+func (r *SegmentReader) RemoveCoreClosedListener(listener CoreClosedListener) {
+	r.ensureOpen()
+	r.core.removeCoreClosedListener(listener)
 }
 
 // index/SegmentCoreReaders.java
@@ -264,6 +332,8 @@ type SegmentCoreReaders struct {
 
 	fields        FieldsProducer
 	normsProducer DocValuesProducer
+	dvProducer    DocValuesProducer
+	vectorsReader KnnVectorsReader
 
 	termsIndexDivisor int
 
@@ -283,9 +353,8 @@ type SegmentCoreReaders struct {
 	fieldsReaderLocal func() StoredFieldsReader
 	normsLocal        func() map[string]interface{}
 
-	addListener    chan CoreClosedListener
-	removeListener chan CoreClosedListener
-	notifyListener chan bool
+	coreClosedListeners     []CoreClosedListener
+	coreClosedListenersLock sync.Mutex
 }
 
 func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *SegmentCommitInfo,
@@ -297,6 +366,7 @@ func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *Segmen
 
 	self = &SegmentCoreReaders{
 		refCount: 1,
+		owner:    owner,
 		normsLocal: func() map[string]interface{} {
 			return make(map[string]interface{})
 		},
@@ -305,42 +375,6 @@ func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *Segmen
 		return self.fieldsReaderOrig.Clone()
 	}
 
-	// fmt.Println("Initializing listeners...")
-	self.addListener = make(chan CoreClosedListener)
-	self.removeListener = make(chan CoreClosedListener)
-	self.notifyListener = make(chan bool)
-	// TODO re-enable later
-	go func() { // ensure listners are synchronized
-		coreClosedListeners := make([]CoreClosedListener, 0)
-		isRunning := true
-		var listener CoreClosedListener
-		for isRunning {
-			// fmt.Println("Listening for events...")
-			select {
-			case listener = <-self.addListener:
-				coreClosedListeners = append(coreClosedListeners, listener)
-			case listener = <-self.removeListener:
-				n := len(coreClosedListeners)
-				for i, v := range coreClosedListeners {
-					if v == listener {
-						newListeners := make([]CoreClosedListener, 0, n-1)
-						newListeners = append(newListeners, coreClosedListeners[0:i]...)
-						newListeners = append(newListeners, coreClosedListeners[i+1:]...)
-						coreClosedListeners = newListeners
-						break
-					}
-				}
-			case <-self.notifyListener:
-				fmt.Println("Shutting down SegmentCoreReaders...")
-				isRunning = false
-				for _, v := range coreClosedListeners {
-					v.onClose(self)
-				}
-			}
-		}
-		fmt.Println("Listeners are done.")
-	}()
-
 	var success = false
 	ans := self
 	defer func() {
@@ -392,6 +426,22 @@ func newSegmentCoreReaders(owner *SegmentReader, dir store.Directory, si *Segmen
 		assert(self.normsProducer != nil)
 	}
 
+	if fieldInfos.HasDocValues {
+		if self.dvProducer, err = codec.DocValuesFormat().FieldsProducer(segmentReadState); err != nil {
+			return nil, err
+		}
+	}
+
+	if fieldInfos.HasVectorValues {
+		vectorsFormat := codec.KnnVectorsFormat()
+		if vectorsFormat == nil {
+			return nil, errors.New(fmt.Sprintf("codec %v does not support vectors", codec.Name()))
+		}
+		if self.vectorsReader, err = vectorsFormat.VectorsReader(segmentReadState); err != nil {
+			return nil, err
+		}
+	}
+
 	// fmt.Println("Obtaining StoredFieldsReader...")
 	if self.fieldsReaderOrig, err = si.Info.Codec().(Codec).StoredFieldsFormat().FieldsReader(cfsDir, si.Info, fieldInfos, context); err != nil {
 		return nil, err
@@ -424,12 +474,78 @@ func (r *SegmentCoreReaders) normValues(infos FieldInfos,
 	return
 }
 
+func (r *SegmentCoreReaders) String() string {
+	return fmt.Sprintf("SegmentCoreReaders(%v)", r.owner.SegmentName())
+}
+
+func (r *SegmentCoreReaders) RamBytesUsed() int64 {
+	var size int64
+	for _, a := range r.ChildResources() {
+		size += a.RamBytesUsed()
+	}
+	return size
+}
+
+/* Returns the readers of the postings, norms, doc values, stored fields and term vectors, if accountable. */
+func (r *SegmentCoreReaders) ChildResources() []util.Accountable {
+	var ans []util.Accountable
+	for _, res := range []struct {
+		name   string
+		reader interface{}
+	}{
+		{"postings", r.fields},
+		{"norms", r.normsProducer},
+		{"doc values", r.dvProducer},
+		{"stored fields", r.fieldsReaderOrig},
+		{"term vectors", r.termVectorsReaderOrig},
+	} {
+		if a, ok := res.reader.(util.Accountable); ok {
+			ans = append(ans, util.NamedAccountableOf(res.name, a))
+		}
+	}
+	return ans
+}
+
 func (r *SegmentCoreReaders) decRef() {
 	if atomic.AddInt32(&r.refCount, -1) == 0 {
 		fmt.Println("--- closing core readers")
-		util.Close( /*self.termVectorsLocal, self.fieldsReaderLocal,  r.normsLocal,*/
-			r.fields, r.termVectorsReaderOrig, r.fieldsReaderOrig,
-			r.cfsReader, r.normsProducer)
-		r.notifyListener <- true
+		defer r.notifyCoreClosedListeners()
+		closers := []io.Closer{ /*self.termVectorsLocal, self.fieldsReaderLocal,  r.normsLocal,*/
+			r.fields, r.termVectorsReaderOrig, r.fieldsReaderOrig, r.normsProducer, r.dvProducer, r.vectorsReader}
+		if r.cfsReader != nil { // not a compound file
+			closers = append(closers, r.cfsReader)
+		}
+		util.Close(closers...)
+	}
+}
+
+func (r *SegmentCoreReaders) addCoreClosedListener(listener CoreClosedListener) {
+	r.coreClosedListenersLock.Lock()
+	defer r.coreClosedListenersLock.Unlock()
+	r.coreClosedListeners = append(r.coreClosedListeners, listener)
+}
+
+func (r *SegmentCoreReaders) removeCoreClosedListener(listener CoreClosedListener) {
+	r.coreClosedListenersLock.Lock()
+	defer r.coreClosedListenersLock.Unlock()
+	for i, v := range r.coreClosedListeners {
+		if v == listener {
+			r.coreClosedListeners = append(r.coreClosedListeners[:i:i], r.coreClosedListeners[i+1:]...)
+			break
+		}
+	}
+}
+
+/*
+Notifies the listeners once the core is closed, outside of the lock,
+so that they may use the reader's API, e.g. to remove themselves.
+*/
+func (r *SegmentCoreReaders) notifyCoreClosedListeners() {
+	r.coreClosedListenersLock.Lock()
+	listeners := r.coreClosedListeners
+	r.coreClosedListeners = nil
+	r.coreClosedListenersLock.Unlock()
+	for _, listener := range listeners {
+		listener.OnClose(r)
 	}
 }
@@ -0,0 +1,95 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"hash/fnv"
+	"io"
+	"sync/atomic"
+)
+
+/*
+Spreads documents over several IndexWriters, e.g. one per directory,
+so that a collection can grow past MAX_DOCS documents, the limit of a
+single index. Search the shards together with a search.ShardSearcher.
+
+New documents are added round robin, skipping shards which are full.
+Updated documents are routed by the hash of their term, so that a
+document must always be added with UpdateDocument() and the same
+term, e.g. its ID, for a later update to replace it.
+
+It is safe for concurrent use, as IndexWriter is.
+*/
+type ShardedIndexWriter struct {
+	writers []*IndexWriter
+	next    uint32
+}
+
+/* Creates a writer adding documents to the given shards, in order. */
+func NewShardedIndexWriter(writers ...*IndexWriter) *ShardedIndexWriter {
+	assert2(len(writers) > 0, "at least one shard is required")
+	return &ShardedIndexWriter{writers: writers}
+}
+
+/* Returns the writers of the shards. */
+func (w *ShardedIndexWriter) Shards() []*IndexWriter {
+	return w.writers
+}
+
+/*
+Adds a document to the next shard which is not full, and returns a
+TooManyDocsError if all of them are.
+*/
+func (w *ShardedIndexWriter) AddDocument(doc []IndexableField) error {
+	start := int(atomic.AddUint32(&w.next, 1)-1) % len(w.writers)
+	for i := 0; i < len(w.writers); i++ {
+		err := w.writers[(start+i)%len(w.writers)].AddDocument(doc)
+		if _, full := err.(*TooManyDocsError); !full {
+			return err
+		}
+	}
+	limit := int64(len(w.writers)) * int64(actualMaxDocs)
+	return &TooManyDocsError{Docs: limit + 1, Limit: int(limit)}
+}
+
+/*
+Updates the document identified by term in the shard the term hashes
+to. It returns a TooManyDocsError if that shard is full, as the
+document cannot move to another one.
+*/
+func (w *ShardedIndexWriter) UpdateDocument(term *Term, doc []IndexableField, analyzer analysis.Analyzer) error {
+	return w.writers[w.ShardOf(term)].UpdateDocument(term, doc, analyzer)
+}
+
+/* Returns the index of the shard holding the documents of term. */
+func (w *ShardedIndexWriter) ShardOf(term *Term) int {
+	h := fnv.New32a()
+	h.Write([]byte(term.Field))
+	h.Write([]byte{0})
+	h.Write(term.Bytes)
+	return int(h.Sum32() % uint32(len(w.writers)))
+}
+
+/*
+Commits all shards, one after the other, and returns the first error.
+Each shard commits on its own, so that a failure may leave some
+shards committed and others not.
+*/
+func (w *ShardedIndexWriter) Commit() error {
+	for _, writer := range w.writers {
+		if err := writer.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Closes all shards, even if some fail to. */
+func (w *ShardedIndexWriter) Close() error {
+	closers := make([]io.Closer, len(w.writers))
+	for i, writer := range w.writers {
+		closers[i] = writer
+	}
+	return util.Close(closers...)
+}
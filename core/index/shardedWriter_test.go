@@ -0,0 +1,102 @@
+package index
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"testing"
+)
+
+type constantSimilarity struct{}
+
+func (s constantSimilarity) ComputeNorm(fs *FieldInvertState) int64 { return 1 }
+
+func newTestWriter(t *testing.T, dir store.Directory) *IndexWriter {
+	return newTestWriterWithCodec(t, dir, DefaultCodec())
+}
+
+func newTestWriterWithCodec(t *testing.T, dir store.Directory, codec Codec) *IndexWriter {
+	if DefaultSimilarity == nil {
+		DefaultSimilarity = func() Similarity { return constantSimilarity{} }
+	}
+	w, err := NewIndexWriter(dir, NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()).SetCodec(codec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func newIdDoc(id int) *docu.Document {
+	d := docu.NewDocument()
+	d.Add(docu.NewStringField("id", fmt.Sprintf("%v", id), docu.STORE_YES))
+	return d
+}
+
+func TestMaxDocs(t *testing.T) {
+	defer func(n int) { actualMaxDocs = n }(actualMaxDocs)
+	actualMaxDocs = 3
+
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	for i := 0; i < 3; i++ {
+		if err := w.AddDocument(newIdDoc(i).Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.AddDocument(newIdDoc(3).Fields())
+	if e, ok := err.(*TooManyDocsError); !ok || e.Docs != 4 || e.Limit != 3 {
+		t.Errorf("Expected TooManyDocsError for the 4th doc, got %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// an index past the limit cannot be opened for writing
+	actualMaxDocs = 2
+	_, err = NewIndexWriter(dir, NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	if e, ok := err.(*TooManyDocsError); !ok || e.Docs != 3 {
+		t.Errorf("Expected TooManyDocsError opening 3 docs, got %v", err)
+	}
+	if dir.MakeLock(WRITE_LOCK_NAME).IsLocked() {
+		t.Error("Expected write lock to be released")
+	}
+}
+
+func TestShardedIndexWriter(t *testing.T) {
+	defer func(n int) { actualMaxDocs = n }(actualMaxDocs)
+	actualMaxDocs = 3
+
+	dirs := []store.Directory{store.NewRAMDirectory(), store.NewRAMDirectory()}
+	w := NewShardedIndexWriter(newTestWriter(t, dirs[0]), newTestWriter(t, dirs[1]))
+	for i := 0; i < 6; i++ {
+		if err := w.AddDocument(newIdDoc(i).Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.AddDocument(newIdDoc(6).Fields()); err == nil {
+		t.Error("Expected TooManyDocsError with all shards full")
+	} else if _, ok := err.(*TooManyDocsError); !ok {
+		t.Errorf("Expected TooManyDocsError, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := w.ShardOf(NewTerm("id", "1")), w.ShardOf(NewTerm("id", "1")); a != b || a < 0 || a > 1 {
+		t.Errorf("Expected the same shard for the same term, got %v and %v", a, b)
+	}
+
+	for _, dir := range dirs {
+		r, err := OpenDirectoryReader(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.MaxDoc() != 3 {
+			t.Errorf("Expected full shards of 3 docs, got %v", r.MaxDoc())
+		}
+		r.Close()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	// "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
 	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
 	// "sort"
 )
 
@@ -153,6 +154,14 @@ func (mt *MultiTerms) Iterator(reuse TermsEnum) TermsEnum {
 	panic("not implemented yet")
 }
 
+func (mt *MultiTerms) Intersect(compiled *automaton.CompiledAutomaton, startTerm []byte) TermsEnum {
+	subs := make([]TermsEnum, len(mt.subs))
+	for i, terms := range mt.subs {
+		subs[i] = terms.Intersect(compiled, startTerm)
+	}
+	return newMultiTermsEnum(subs, mt.subSlices)
+}
+
 func (mt *MultiTerms) DocCount() int {
 	sum := 0
 	for _, terms := range mt.subs {
@@ -0,0 +1,185 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"sync"
+)
+
+/*
+Optional statistics of Terms, known without iterating the terms,
+e.g. from the terms dictionary header.
+*/
+type TermsStatistics interface {
+	// Returns the number of terms, or -1 if unknown
+	Size() int64
+	// Returns the smallest term, or nil if unknown
+	Min() []byte
+	// Returns the largest term, or nil if unknown
+	Max() []byte
+}
+
+/*
+High-level statistics of the terms of a field, aggregated across
+segments, for query planning; e.g. to skip wildcard expansion on a
+field with 50M terms.
+*/
+type FieldTermsStats struct {
+	Field string
+	// Number of segments with terms for the field
+	Segments int
+	// Sum of the number of terms of each segment. As segments share
+	// terms, this is an upper bound of the number of unique terms in
+	// the index, and at least MaxSegmentTermCount; -1 if unknown.
+	TermCount int64
+	// Largest number of terms of a single segment, -1 if unknown
+	MaxSegmentTermCount int64
+	DocCount            int
+	SumDocFreq          int64
+	SumTotalTermFreq    int64
+	// Smallest and largest terms across segments, nil if unknown
+	MinTerm, MaxTerm []byte
+}
+
+func (s *FieldTermsStats) String() string {
+	return fmt.Sprintf("%v: segments=%v termCount=%v maxSegmentTermCount=%v docCount=%v sumDocFreq=%v sumTotalTermFreq=%v min=%q max=%q",
+		s.Field, s.Segments, s.TermCount, s.MaxSegmentTermCount, s.DocCount,
+		s.SumDocFreq, s.SumTotalTermFreq, s.MinTerm, s.MaxTerm)
+}
+
+/* Statistics of one field in one segment. */
+type segmentTermsStats struct {
+	size                     int64
+	docCount                 int
+	sumDocFreq, sumTotalFreq int64
+	min, max                 []byte
+}
+
+func newSegmentTermsStats(terms Terms) *segmentTermsStats {
+	ans := &segmentTermsStats{
+		size:         -1,
+		docCount:     terms.DocCount(),
+		sumDocFreq:   terms.SumDocFreq(),
+		sumTotalFreq: terms.SumTotalTermFreq(),
+	}
+	if ts, ok := terms.(TermsStatistics); ok {
+		ans.size = ts.Size()
+		ans.min = ts.Min()
+		ans.max = ts.Max()
+	}
+	return ans
+}
+
+/*
+Cache of FieldTermsStats. Statistics are kept per segment core, which
+is shared by all readers of the segment, so only the statistics of
+new segments are computed after the reader is reopened.
+
+Entries are never evicted on their own; call Clear() or Evict() when
+segments are merged away.
+*/
+type TermsStatsCache struct {
+	sync.Mutex
+	cache map[interface{}]map[string]*segmentTermsStats
+}
+
+func NewTermsStatsCache() *TermsStatsCache {
+	return &TermsStatsCache{cache: make(map[interface{}]map[string]*segmentTermsStats)}
+}
+
+/* Returns the statistics of the field, aggregated across the segments of the reader. */
+func (c *TermsStatsCache) FieldStats(r IndexReader, field string) *FieldTermsStats {
+	ans := &FieldTermsStats{Field: field, DocCount: -1, SumDocFreq: -1, SumTotalTermFreq: -1}
+	first := true
+	for _, ctx := range r.Leaves() {
+		stats := c.segmentStats(ctx.Reader().(AtomicReader), field)
+		if stats == nil {
+			continue
+		}
+		ans.Segments++
+		if first {
+			ans.TermCount, ans.MaxSegmentTermCount = stats.size, stats.size
+			ans.DocCount, ans.SumDocFreq, ans.SumTotalTermFreq = stats.docCount, stats.sumDocFreq, stats.sumTotalFreq
+			ans.MinTerm, ans.MaxTerm = stats.min, stats.max
+			first = false
+			continue
+		}
+		ans.TermCount = sumKnown(ans.TermCount, stats.size)
+		if ans.MaxSegmentTermCount != -1 && stats.size != -1 && stats.size > ans.MaxSegmentTermCount {
+			ans.MaxSegmentTermCount = stats.size
+		} else if stats.size == -1 {
+			ans.MaxSegmentTermCount = -1
+		}
+		ans.DocCount = int(sumKnown(int64(ans.DocCount), int64(stats.docCount)))
+		ans.SumDocFreq = sumKnown(ans.SumDocFreq, stats.sumDocFreq)
+		ans.SumTotalTermFreq = sumKnown(ans.SumTotalTermFreq, stats.sumTotalFreq)
+		if ans.MinTerm != nil && (stats.min == nil || bytes.Compare(stats.min, ans.MinTerm) < 0) {
+			ans.MinTerm = stats.min
+		}
+		if ans.MaxTerm != nil && (stats.max == nil || bytes.Compare(stats.max, ans.MaxTerm) > 0) {
+			ans.MaxTerm = stats.max
+		}
+	}
+	if first {
+		ans.DocCount, ans.SumDocFreq, ans.SumTotalTermFreq = 0, 0, 0
+	}
+	return ans
+}
+
+/* Returns -1 if either is unknown */
+func sumKnown(a, b int64) int64 {
+	if a == -1 || b == -1 {
+		return -1
+	}
+	return a + b
+}
+
+/* Segments share their core across reopens; other readers are keyed by themselves. */
+func coreKey(r AtomicReader) interface{} {
+	if sr, ok := r.(*SegmentReader); ok {
+		return sr.CoreCacheKey()
+	}
+	return r
+}
+
+func (c *TermsStatsCache) segmentStats(r AtomicReader, field string) *segmentTermsStats {
+	key := coreKey(r)
+	c.Lock()
+	defer c.Unlock()
+	fields, ok := c.cache[key]
+	if !ok {
+		fields = make(map[string]*segmentTermsStats)
+		c.cache[key] = fields
+	}
+	if stats, ok := fields[field]; ok {
+		return stats
+	}
+	var stats *segmentTermsStats
+	if terms := r.Terms(field); terms != nil {
+		stats = newSegmentTermsStats(terms)
+	}
+	fields[field] = stats
+	return stats
+}
+
+/* Drops the cached statistics of the segment of the given reader. */
+func (c *TermsStatsCache) Evict(r AtomicReader) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.cache, coreKey(r))
+}
+
+func (c *TermsStatsCache) Clear() {
+	c.Lock()
+	defer c.Unlock()
+	c.cache = make(map[interface{}]map[string]*segmentTermsStats)
+}
+
+/* Default cache used by FieldStats() */
+var DefaultTermsStatsCache = NewTermsStatsCache()
+
+/* Returns the statistics of the field in the reader, using DefaultTermsStatsCache. */
+func FieldStats(r IndexReader, field string) *FieldTermsStats {
+	return DefaultTermsStatsCache.FieldStats(r, field)
+}
@@ -0,0 +1,536 @@
+/*
+Package translog provides an optional operations log that sits next
+to an index and records add, update and delete operations as they
+are applied to an IndexWriter. Operations buffered in the writer are
+lost on a crash until the next commit; replaying the log on open
+brings the index back to the last acknowledged operation.
+
+A typical application indexes a document, then records it:
+
+	if err := w.UpdateDocument(index.NewTerm("id", id), doc, analyzer); err != nil {
+		return err
+	}
+	seqNo, err := tl.Update(id, source)
+
+and marks the log after a successful commit, so that committed
+operations are trimmed:
+
+	if err := w.Commit(); err != nil {
+		return err
+	}
+	err = tl.MarkCommitted(seqNo)
+
+On open, operations that were not committed are passed back to the
+application with Replay(). Documents are recorded as an opaque
+source chosen by the application, together with the value of its ID
+field for updates and deletes; the log never interprets them.
+*/
+package translog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/* Type of a recorded operation. */
+type OpType byte
+
+const (
+	OP_ADD = OpType(iota + 1)
+	OP_UPDATE
+	OP_DELETE
+)
+
+func (t OpType) String() string {
+	switch t {
+	case OP_ADD:
+		return "ADD"
+	case OP_UPDATE:
+		return "UPDATE"
+	case OP_DELETE:
+		return "DELETE"
+	}
+	return fmt.Sprintf("OpType(%v)", int(t))
+}
+
+/* An operation recorded in the translog. */
+type Operation struct {
+	// Sequence number, increasing with each recorded operation
+	SeqNo int64
+	Type  OpType
+	// Value of the ID field of the document; "" for OP_ADD
+	Id string
+	// Application provided document source; nil for OP_DELETE
+	Source []byte
+}
+
+/* When recorded operations are made durable. */
+type Durability int
+
+const (
+	// Sync to stable storage before each operation is acknowledged.
+	DURABILITY_REQUEST = Durability(iota)
+	// Sync only when Sync(), MarkCommitted() or Close() is called.
+	DURABILITY_ASYNC
+)
+
+const (
+	FILE_PREFIX     = "translog-"
+	FILE_EXTENSION  = ".tlog"
+	CHECKPOINT_FILE = "translog.ckp"
+
+	// Maximum size of an encoded operation; larger lengths read back
+	// are taken as a corrupt record rather than allocated.
+	MAX_OPERATION_SIZE = 64 << 20
+)
+
+/*
+An append-only operations log. Operations are written to generation
+files in a directory; a new generation is started on each open and
+each MarkCommitted(), and generations holding only committed
+operations are deleted.
+
+Each record is written as its length, the encoded operation and a
+CRC32 checksum, so a record torn by a crash is detected and ignored
+on replay.
+*/
+type Translog struct {
+	sync.Locker
+	path       string
+	durability Durability
+
+	file *os.File
+	out  *bufio.Writer
+	gen  int64
+
+	// maximum sequence number per generation, used for trimming
+	maxSeqNos      map[int64]int64
+	nextSeqNo      int64
+	committedSeqNo int64
+
+	closed bool
+	// first write or sync failure; the tail of the current generation
+	// is unknown after it, so no more operations are recorded
+	tragedy error
+}
+
+/*
+Opens the translog in the given directory, creating it if needed.
+Operations recorded by a previous instance are kept for Replay().
+*/
+func Open(path string, durability Durability) (*Translog, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	tl := &Translog{
+		Locker:         &sync.Mutex{},
+		path:           path,
+		durability:     durability,
+		maxSeqNos:      make(map[int64]int64),
+		committedSeqNo: -1,
+	}
+	var err error
+	if tl.committedSeqNo, err = readCheckpoint(path); err != nil {
+		return nil, err
+	}
+	tl.nextSeqNo = tl.committedSeqNo + 1
+
+	gens, err := tl.generations()
+	if err != nil {
+		return nil, err
+	}
+	for _, gen := range gens {
+		maxSeqNo := int64(-1)
+		if err = tl.readGeneration(gen, func(op *Operation) error {
+			maxSeqNo = op.SeqNo
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		tl.maxSeqNos[gen] = maxSeqNo
+		if maxSeqNo >= tl.nextSeqNo {
+			tl.nextSeqNo = maxSeqNo + 1
+		}
+		tl.gen = gen
+	}
+
+	// never append to an existing generation: its tail may be torn
+	if err = tl.rollGeneration(); err != nil {
+		return nil, err
+	}
+	return tl, nil
+}
+
+/* Records the addition of a document, returning its sequence number. */
+func (tl *Translog) Add(source []byte) (int64, error) {
+	return tl.record(OP_ADD, "", source)
+}
+
+/* Records the update of the document with the given id. */
+func (tl *Translog) Update(id string, source []byte) (int64, error) {
+	return tl.record(OP_UPDATE, id, source)
+}
+
+/* Records the deletion of the document with the given id. */
+func (tl *Translog) Delete(id string) (int64, error) {
+	return tl.record(OP_DELETE, id, nil)
+}
+
+func (tl *Translog) record(typ OpType, id string, source []byte) (int64, error) {
+	tl.Lock()
+	defer tl.Unlock()
+
+	if tl.closed {
+		return -1, errors.New("this Translog is closed")
+	}
+	if tl.tragedy != nil {
+		return -1, errors.New(fmt.Sprintf(
+			"this Translog failed to record an operation: %v", tl.tragedy))
+	}
+	// leaves room for the seqNo, type and lengths
+	if size := len(id) + len(source); size > MAX_OPERATION_SIZE-32 {
+		return -1, errors.New(fmt.Sprintf(
+			"operation of %v bytes exceeds the maximum of %v", size, MAX_OPERATION_SIZE))
+	}
+	op := &Operation{tl.nextSeqNo, typ, id, source}
+	err := writeOperation(tl.out, op)
+	if err == nil && tl.durability == DURABILITY_REQUEST {
+		err = tl.sync()
+	}
+	if err != nil {
+		// the operation may be partially written: a later one with the
+		// same seqNo would follow a torn record, and be lost on replay
+		tl.tragedy = err
+		return -1, err
+	}
+	// the seqNo is only acknowledged once the operation is durable
+	tl.nextSeqNo++
+	tl.maxSeqNos[tl.gen] = op.SeqNo
+	return op.SeqNo, nil
+}
+
+/* Ensures all recorded operations are moved to stable storage. */
+func (tl *Translog) Sync() error {
+	tl.Lock()
+	defer tl.Unlock()
+	if tl.closed {
+		return errors.New("this Translog is closed")
+	}
+	return tl.sync()
+}
+
+func (tl *Translog) sync() error {
+	if err := tl.out.Flush(); err != nil {
+		return err
+	}
+	return tl.file.Sync()
+}
+
+/*
+Records that all operations up to and including seqNo are part of
+the last commit of the index. They are no longer replayed, and
+generations holding only such operations are deleted.
+*/
+func (tl *Translog) MarkCommitted(seqNo int64) error {
+	tl.Lock()
+	defer tl.Unlock()
+
+	if tl.closed {
+		return errors.New("this Translog is closed")
+	}
+	if seqNo >= tl.nextSeqNo {
+		return fmt.Errorf("seqNo %v was not recorded yet (next=%v)", seqNo, tl.nextSeqNo)
+	}
+	if seqNo <= tl.committedSeqNo {
+		return nil
+	}
+	if err := tl.rollGeneration(); err != nil {
+		return err
+	}
+	if err := writeCheckpoint(tl.path, seqNo); err != nil {
+		return err
+	}
+	tl.committedSeqNo = seqNo
+
+	for gen, maxSeqNo := range tl.maxSeqNos {
+		if gen != tl.gen && maxSeqNo <= seqNo {
+			if err := os.Remove(tl.fileName(gen)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			delete(tl.maxSeqNos, gen)
+		}
+	}
+	return nil
+}
+
+/* Returns the sequence number of the last commit, or -1 if none. */
+func (tl *Translog) CommittedSeqNo() int64 {
+	tl.Lock()
+	defer tl.Unlock()
+	return tl.committedSeqNo
+}
+
+/*
+Passes each recorded operation that is not committed yet to fn, in
+sequence number order. A torn record at the end of a generation, as
+left by a crash, ends that generation.
+*/
+func (tl *Translog) Replay(fn func(op *Operation) error) error {
+	tl.Lock()
+	defer tl.Unlock()
+
+	if tl.closed {
+		return errors.New("this Translog is closed")
+	}
+	if err := tl.out.Flush(); err != nil {
+		return err
+	}
+	gens, err := tl.generations()
+	if err != nil {
+		return err
+	}
+	for _, gen := range gens {
+		if err = tl.readGeneration(gen, func(op *Operation) error {
+			if op.SeqNo <= tl.committedSeqNo {
+				return nil
+			}
+			return fn(op)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tl *Translog) Close() error {
+	tl.Lock()
+	defer tl.Unlock()
+
+	if tl.closed {
+		return nil
+	}
+	tl.closed = true
+	err := tl.sync()
+	if err2 := tl.file.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (tl *Translog) String() string {
+	return fmt.Sprintf("Translog(path=%v, gen=%v, committedSeqNo=%v, nextSeqNo=%v)",
+		tl.path, tl.gen, tl.committedSeqNo, tl.nextSeqNo)
+}
+
+func (tl *Translog) fileName(gen int64) string {
+	return filepath.Join(tl.path, FILE_PREFIX+strconv.FormatInt(gen, 10)+FILE_EXTENSION)
+}
+
+/* Starts a new generation file, syncing and closing the current one. */
+func (tl *Translog) rollGeneration() error {
+	if tl.file != nil {
+		if err := tl.sync(); err != nil {
+			return err
+		}
+		if err := tl.file.Close(); err != nil {
+			return err
+		}
+	}
+	gen := tl.gen + 1
+	file, err := os.OpenFile(tl.fileName(gen), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err = syncDir(tl.path); err != nil {
+		file.Close()
+		return err
+	}
+	tl.file, tl.out, tl.gen = file, bufio.NewWriter(file), gen
+	tl.maxSeqNos[gen] = -1
+	return nil
+}
+
+/* Returns the existing generations in increasing order. */
+func (tl *Translog) generations() ([]int64, error) {
+	infos, err := ioutil.ReadDir(tl.path)
+	if err != nil {
+		return nil, err
+	}
+	var gens []int64
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, FILE_PREFIX) || !strings.HasSuffix(name, FILE_EXTENSION) {
+			continue
+		}
+		gen, err := strconv.ParseInt(name[len(FILE_PREFIX):len(name)-len(FILE_EXTENSION)], 10, 64)
+		if err != nil {
+			continue
+		}
+		gens = append(gens, gen)
+	}
+	sort.Sort(int64s(gens))
+	return gens, nil
+}
+
+func (tl *Translog) readGeneration(gen int64, fn func(op *Operation) error) error {
+	file, err := os.Open(tl.fileName(gen))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	in := bufio.NewReader(file)
+	for {
+		op, err := readOperation(in)
+		if err == io.EOF || err == io.ErrUnexpectedEOF || err == errCorruptRecord {
+			return nil // end of generation, or a torn record
+		} else if err != nil {
+			return err
+		}
+		if err = fn(op); err != nil {
+			return err
+		}
+	}
+}
+
+type int64s []int64
+
+func (a int64s) Len() int           { return len(a) }
+func (a int64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int64s) Less(i, j int) bool { return a[i] < a[j] }
+
+// Record and checkpoint format
+
+var errCorruptRecord = errors.New("corrupt translog record")
+
+func writeOperation(out io.Writer, op *Operation) error {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(op.SeqNo))])
+	buf.WriteByte(byte(op.Type))
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(op.Id)))])
+	buf.WriteString(op.Id)
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], uint64(len(op.Source)))])
+	buf.Write(op.Source)
+
+	var header, footer [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(buf.Len()))
+	binary.BigEndian.PutUint32(footer[:], crc32.ChecksumIEEE(buf.Bytes()))
+	if _, err := out.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err := out.Write(footer[:])
+	return err
+}
+
+func readOperation(in io.Reader) (*Operation, error) {
+	var header, footer [4]byte
+	if _, err := io.ReadFull(in, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MAX_OPERATION_SIZE {
+		return nil, errCorruptRecord
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(in, payload); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(in, footer[:]); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(footer[:]) {
+		return nil, errCorruptRecord
+	}
+
+	buf := bytes.NewReader(payload)
+	seqNo, err := binary.ReadUvarint(buf)
+	if err != nil {
+		return nil, errCorruptRecord
+	}
+	typ, err := buf.ReadByte()
+	if err != nil {
+		return nil, errCorruptRecord
+	}
+	id, err := readBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	source, err := readBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	if len(source) == 0 {
+		source = nil
+	}
+	return &Operation{int64(seqNo), OpType(typ), string(id), source}, nil
+}
+
+func readBytes(buf *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(buf)
+	if err != nil || n > uint64(buf.Len()) {
+		return nil, errCorruptRecord
+	}
+	ans := make([]byte, n)
+	buf.Read(ans)
+	return ans, nil
+}
+
+func readCheckpoint(path string) (int64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, CHECKPOINT_FILE))
+	if os.IsNotExist(err) {
+		return -1, nil
+	} else if err != nil {
+		return -1, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+/* Atomically replaces the checkpoint by writing a temp file and renaming it. */
+func writeCheckpoint(path string, committedSeqNo int64) error {
+	tmp := filepath.Join(path, CHECKPOINT_FILE+".tmp")
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(strconv.FormatInt(committedSeqNo, 10))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err2 := file.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, filepath.Join(path, CHECKPOINT_FILE)); err != nil {
+		return err
+	}
+	return syncDir(path)
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	// not all platforms support syncing a directory
+	dir.Sync()
+	return nil
+}
@@ -0,0 +1,169 @@
+package translog
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func replayAll(t *testing.T, tl *Translog) []*Operation {
+	var ops []*Operation
+	if err := tl.Replay(func(op *Operation) error {
+		ops = append(ops, op)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
+
+func TestReplay(t *testing.T) {
+	path, err := ioutil.TempDir("", "translog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	tl, err := Open(path, DURABILITY_REQUEST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.Add([]byte("doc0"))
+	seqNo, _ := tl.Update("1", []byte("doc1"))
+	tl.Delete("2")
+	if err = tl.MarkCommitted(seqNo); err != nil {
+		t.Fatal(err)
+	}
+	tl.Update("3", []byte("doc3"))
+	tl.Delete("1")
+	if err = tl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate a record torn by a crash
+	tl, err = Open(path, DURABILITY_ASYNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.Add([]byte("doc4"))
+	tl.out.Write([]byte{0, 0, 0, 42, 1, 2})
+	tl.out.Flush()
+	tl.file.Close()
+
+	tl, err = Open(path, DURABILITY_ASYNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	if tl.CommittedSeqNo() != 1 {
+		t.Errorf("Expected committed seqNo 1, but %v", tl.CommittedSeqNo())
+	}
+
+	ops := replayAll(t, tl)
+	expected := []Operation{
+		{2, OP_DELETE, "2", nil},
+		{3, OP_UPDATE, "3", []byte("doc3")},
+		{4, OP_DELETE, "1", nil},
+		{5, OP_ADD, "", []byte("doc4")},
+	}
+	if len(ops) != len(expected) {
+		t.Fatalf("Expected %v operations, but %v", len(expected), len(ops))
+	}
+	for i, op := range ops {
+		if op.SeqNo != expected[i].SeqNo || op.Type != expected[i].Type ||
+			op.Id != expected[i].Id || string(op.Source) != string(expected[i].Source) {
+			t.Errorf("Expected %v, but %v", expected[i], *op)
+		}
+	}
+
+	seqNo, _ = tl.Add([]byte("doc6"))
+	if seqNo != 6 {
+		t.Errorf("Expected seqNo 6, but %v", seqNo)
+	}
+	if err = tl.MarkCommitted(seqNo); err != nil {
+		t.Fatal(err)
+	}
+	if ops = replayAll(t, tl); len(ops) != 0 {
+		t.Errorf("Expected no operations after commit, but %v", len(ops))
+	}
+	gens, _ := tl.generations()
+	if len(gens) != 1 {
+		t.Errorf("Expected committed generations to be trimmed, but %v", gens)
+	}
+}
+
+func TestFailedSync(t *testing.T) {
+	path, err := ioutil.TempDir("", "translog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	tl, err := Open(path, DURABILITY_REQUEST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tl.Add([]byte("doc0")); err != nil {
+		t.Fatal(err)
+	}
+	// make the next sync fail
+	tl.file.Close()
+	if _, err = tl.Add([]byte("doc1")); err == nil {
+		t.Fatal("Expected the sync to fail")
+	}
+	if tl.nextSeqNo != 1 {
+		t.Errorf("Expected seqNo 1 not to be acknowledged, but next is %v", tl.nextSeqNo)
+	}
+	// even once the file is writable again, the failed operation may be
+	// in it: reusing its seqNo is unsafe
+	file, err := os.OpenFile(tl.fileName(tl.gen), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tl.file, tl.out = file, bufio.NewWriter(file)
+	if _, err = tl.Add([]byte("doc2")); err == nil {
+		t.Error("Expected no more operations to be recorded")
+	}
+
+	if tl, err = Open(path, DURABILITY_REQUEST); err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	if ops := replayAll(t, tl); len(ops) != 1 || string(ops[0].Source) != "doc0" {
+		t.Errorf("Expected only doc0 to be replayed, but %v", ops)
+	}
+}
+
+func TestOversizedRecord(t *testing.T) {
+	path, err := ioutil.TempDir("", "translog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	tl, err := Open(path, DURABILITY_ASYNC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.Add([]byte("doc0"))
+	// a corrupt length must not be allocated
+	tl.out.Write([]byte{0xff, 0xff, 0xff, 0xf0, 1, 2})
+	tl.out.Flush()
+	tl.file.Close()
+
+	if tl, err = Open(path, DURABILITY_ASYNC); err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	if ops := replayAll(t, tl); len(ops) != 1 || string(ops[0].Source) != "doc0" {
+		t.Errorf("Expected only doc0 to be replayed, but %v", ops)
+	}
+	if _, err = tl.Add(make([]byte, MAX_OPERATION_SIZE)); err == nil {
+		t.Error("Expected an oversized operation to be rejected")
+	}
+	if _, err = tl.Add([]byte("doc1")); err != nil {
+		t.Errorf("Expected a rejected operation not to fail the translog, but %v", err)
+	}
+}
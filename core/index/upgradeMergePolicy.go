@@ -0,0 +1,116 @@
+package index
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// index/UpgradeIndexMergePolicy.java
+
+/*
+This MergePolicy is used for upgrading all existing segments of an
+index when calling IndexWriter.ForceMerge(). All other methods
+delegate to the base MergePolicy given to the constructor. This
+allows for an as-cheap-as possible upgrade of an older index by only
+upgrading segments that are created by previous Lucene versions.
+forceMerge does no longer really merge; it is just used to "forceMerge"
+older segment versions away.
+
+In general one would use IndexUpgrader, but for a fully customizeable
+upgrade, you can use this like any other MergePolicy and call
+IndexWriter.ForceMerge():
+
+	iwc := NewIndexWriterConfig(util.VERSION_LATEST, analyzer)
+	iwc.SetMergePolicy(NewUpgradeIndexMergePolicy(iwc.MergePolicy()))
+	w := NewIndexWriter(dir, iwc)
+	w.ForceMerge(1)
+	w.Close()
+
+Warning: This merge policy may reorder documents if the index was
+partially upgraded before calling forceMerge (e.g., documents were
+added). If your application relies on "monotonicity" of doc IDs
+(which means that the order in which the documents were added to the
+index is preserved), do a forceMerge(1) instead. Please note, the
+delegate MergePolicy may also reorder documents.
+*/
+type UpgradeIndexMergePolicy struct {
+	// Wrapped MergePolicy.
+	MergePolicy
+}
+
+/* Wrap the given MergePolicy and intercept forceMerge requests to only upgrade segments written with previous Lucene versions. */
+func NewUpgradeIndexMergePolicy(base MergePolicy) *UpgradeIndexMergePolicy {
+	return &UpgradeIndexMergePolicy{base}
+}
+
+/*
+Returns true if the given segment should be upgraded. The default
+implementation returns true for all segments not written by the
+current version.
+*/
+func (mp *UpgradeIndexMergePolicy) ShouldUpgradeSegment(si *SegmentCommitInfo) bool {
+	return !util.VERSION_LATEST.Equals(si.Info.Version())
+}
+
+func (mp *UpgradeIndexMergePolicy) FindForcedMerges(infos *SegmentInfos,
+	maxSegmentCount int, segmentsToMerge map[*SegmentCommitInfo]bool,
+	w *IndexWriter) (MergeSpecification, error) {
+
+	// first find all old segments
+	oldSegments := make(map[*SegmentCommitInfo]bool)
+	for _, si := range infos.Segments {
+		if v, ok := segmentsToMerge[si]; ok && mp.ShouldUpgradeSegment(si) {
+			oldSegments[si] = v
+		}
+	}
+
+	if mp.verbose(w) {
+		mp.message(w, "findForcedMerges: segmentsToUpgrade=%v", len(oldSegments))
+	}
+
+	if len(oldSegments) == 0 {
+		return nil, nil
+	}
+
+	spec, err := mp.MergePolicy.FindForcedMerges(infos, maxSegmentCount, oldSegments, w)
+	if err != nil {
+		return nil, err
+	}
+
+	// remove all segments that are in merge specification from
+	// oldSegments, the resulting set contains all segments that are
+	// left over and will be merged to one additional segment:
+	for _, om := range spec {
+		for _, si := range om.segments {
+			delete(oldSegments, si)
+		}
+	}
+
+	if len(oldSegments) > 0 {
+		if mp.verbose(w) {
+			mp.message(w, "findForcedMerges: %v does not want to merge all old segments, merge remaining ones into new segment: %v",
+				mp.MergePolicy, len(oldSegments))
+		}
+		var newInfos []*SegmentCommitInfo
+		for _, si := range infos.Segments {
+			if _, ok := oldSegments[si]; ok {
+				newInfos = append(newInfos, si)
+			}
+		}
+		spec = append(spec, NewOneMerge(newInfos))
+	}
+	return spec, nil
+}
+
+func (mp *UpgradeIndexMergePolicy) String() string {
+	return fmt.Sprintf("[UpgradeIndexMergePolicy->%v]", mp.MergePolicy)
+}
+
+func (mp *UpgradeIndexMergePolicy) verbose(w *IndexWriter) bool {
+	return w != nil && w.infoStream.IsEnabled("UPGMP")
+}
+
+func (mp *UpgradeIndexMergePolicy) message(w *IndexWriter, message string, args ...interface{}) {
+	w.infoStream.Message("UPGMP", message, args...)
+}
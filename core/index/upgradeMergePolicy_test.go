@@ -0,0 +1,128 @@
+package index
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/codec/lucene46"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"testing"
+)
+
+type noForcedMergePolicy struct {
+	*MergePolicyImpl
+}
+
+func (mp *noForcedMergePolicy) FindMerges(MergeTrigger, *SegmentInfos, *IndexWriter) (MergeSpecification, error) {
+	return nil, nil
+}
+
+func (mp *noForcedMergePolicy) FindForcedMerges(*SegmentInfos, int,
+	map[*SegmentCommitInfo]bool, *IndexWriter) (MergeSpecification, error) {
+	return nil, nil
+}
+
+func (mp *noForcedMergePolicy) FindForcedDeletesMerges(*SegmentInfos, *IndexWriter) (MergeSpecification, error) {
+	return nil, nil
+}
+
+func TestUpgradeIndexMergePolicy(t *testing.T) {
+	newInfo := func(name string, version util.Version) *SegmentCommitInfo {
+		return NewSegmentCommitInfo(NewSegmentInfo(nil, version, name, 10, false, nil, nil), 0, -1, -1, -1)
+	}
+	old1 := newInfo("_0", util.VERSION_4_0)
+	current := newInfo("_1", util.VERSION_LATEST)
+	old2 := newInfo("_2", util.VERSION_45)
+	infos := &SegmentInfos{Segments: []*SegmentCommitInfo{old1, current, old2}}
+	toMerge := map[*SegmentCommitInfo]bool{old1: true, current: true, old2: true}
+
+	base := new(noForcedMergePolicy)
+	base.MergePolicyImpl = NewDefaultMergePolicyImpl(base)
+	mp := NewUpgradeIndexMergePolicy(base)
+
+	spec, err := mp.FindForcedMerges(infos, 1, toMerge, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec) != 1 {
+		t.Fatalf("Expected one merge, but %v", len(spec))
+	}
+	segments := spec[0].segments
+	if len(segments) != 2 || segments[0] != old1 || segments[1] != old2 {
+		t.Errorf("Expected old segments to be merged, but %v", segments)
+	}
+
+	spec, err = mp.FindForcedMerges(infos, 1, map[*SegmentCommitInfo]bool{current: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec != nil {
+		t.Errorf("Expected no merge for up-to-date segments, but %v", spec)
+	}
+}
+
+/* Rewrites the .si file of the segment as if written by the given version. */
+func rewriteSegmentVersion(t *testing.T, dir store.Directory, info *SegmentCommitInfo, version util.Version) {
+	old := info.Info
+	si := NewSegmentInfo(dir, version, old.Name, old.DocCount(), old.IsCompoundFile(), old.Codec(), old.Diagnostics())
+	si.SetFiles(old.Files())
+	fis, err := ReadFieldInfos(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dir.DeleteFile(util.SegmentFileName(old.Name, "", lucene46.SI_EXTENSION)); err != nil {
+		t.Fatal(err)
+	}
+	writer := old.Codec().(Codec).SegmentInfoFormat().SegmentInfoWriter()
+	if err = writer.Write(dir, si, fis, store.IO_CONTEXT_DEFAULT); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgradeIndex(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	addIdDocs(t, w, 0, 5)
+	addIdDocs(t, w, 5, 10)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	infos := readTestInfos(t, dir)
+	if len(infos.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, but %v", len(infos.Segments))
+	}
+	rewriteSegmentVersion(t, dir, infos.Segments[0], util.VERSION_45)
+	current := infos.Segments[1].Info.Name
+
+	conf := NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMergePolicy(NewUpgradeIndexMergePolicy(conf.MergePolicy()))
+	w, err := NewIndexWriter(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := w.segmentInfos.Segments[0].Info.Version(); !v.Equals(util.VERSION_45) {
+		t.Fatalf("Expected the first segment to be read as 4.5, but %v", v)
+	}
+	if err = w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the old segment is rewritten
+	infos = readTestInfos(t, dir)
+	if len(infos.Segments) != 2 {
+		t.Fatalf("Expected 2 segments after upgrade, but %v", len(infos.Segments))
+	}
+	for _, info := range infos.Segments {
+		if !info.Info.Version().Equals(util.VERSION_LATEST) {
+			t.Errorf("Expected segment %v to be upgraded, but %v", info.Info.Name, info.Info.Version())
+		}
+	}
+	if infos.Segments[0].Info.Name == current || infos.Segments[1].Info.Name != current {
+		t.Errorf("Expected only the old segment to be merged, but %v", infos.Segments)
+	}
+	checkIdDocs(t, dir, 10)
+}
@@ -0,0 +1,54 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// index/VectorValuesWriter.java
+
+/* Buffers up the vectors of a field, then flushes when segment flushes. */
+type VectorValuesWriter struct {
+	fieldInfo   *FieldInfo
+	iwBytesUsed util.Counter
+	docs        []int
+	vectors     [][]float32
+}
+
+func newVectorValuesWriter(fieldInfo *FieldInfo, iwBytesUsed util.Counter) *VectorValuesWriter {
+	return &VectorValuesWriter{fieldInfo: fieldInfo, iwBytesUsed: iwBytesUsed}
+}
+
+func (w *VectorValuesWriter) addValue(docID int, vector []float32) error {
+	if n := len(w.docs); n > 0 && w.docs[n-1] == docID {
+		return errors.New(fmt.Sprintf(
+			"VectorField '%v' appears more than once in this document (only one value is allowed per field)",
+			w.fieldInfo.Name))
+	}
+	w.docs = append(w.docs, docID)
+	w.vectors = append(w.vectors, append([]float32(nil), vector...))
+	w.iwBytesUsed.AddAndGet(int64(4*len(vector)) + util.NUM_BYTES_OBJECT_REF + util.NUM_BYTES_INT)
+	return nil
+}
+
+func (w *VectorValuesWriter) flush(state *SegmentWriteState, writer KnnVectorsWriter) error {
+	maxDoc := state.SegmentInfo.DocCount()
+	return writer.AddField(w.fieldInfo, func() func() ([]float32, bool) {
+		doc, upto := 0, 0
+		return func() ([]float32, bool) {
+			if doc == maxDoc {
+				return nil, false
+			}
+			d := doc
+			doc++
+			if upto < len(w.docs) && w.docs[upto] == d {
+				upto++
+				return w.vectors[upto-1], true
+			}
+			return nil, true
+		}
+	})
+}
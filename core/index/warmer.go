@@ -1,9 +1,23 @@
 package index
 
 import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/util"
+	"time"
 )
 
+/*
+An IndexReaderWarmer calling a function, e.g. to load the doc values
+of some fields, prime caches or run sample queries on the newly
+merged segment.
+*/
+type IndexReaderWarmerFunc func(reader AtomicReader) error
+
+func (f IndexReaderWarmerFunc) Warm(reader AtomicReader) error {
+	return f(reader)
+}
+
 // index/SimpleMergedSegmentWarmer.java
 
 /*
@@ -19,6 +33,57 @@ func NewSimpleMergedSegmentWarmer(infoStream util.InfoStream) *SimpleMergedSegme
 	return &SimpleMergedSegmentWarmer{infoStream}
 }
 
-func (warmer *SimpleMergedSegmentWarmer) warm(reader AtomicReader) error {
-	panic("not implemented yet")
+func (warmer *SimpleMergedSegmentWarmer) Warm(reader AtomicReader) (err error) {
+	startTime := time.Now()
+	var indexedCount, docValuesCount, normsCount int
+	var infos FieldInfos
+	if fr, ok := reader.(interface {
+		FieldInfos() FieldInfos
+	}); ok {
+		infos = fr.FieldInfos()
+	}
+	for _, info := range infos.Values {
+		if info.IsIndexed() {
+			reader.Terms(info.Name)
+			indexedCount++
+			if info.HasNorms() {
+				if _, err = reader.NormValues(info.Name); err != nil {
+					return
+				}
+				normsCount++
+			}
+		}
+		if info.HasDocValues() {
+			switch info.DocValuesType() {
+			case DOC_VALUES_TYPE_NUMERIC:
+				_, err = reader.NumericDocValues(info.Name)
+			case DOC_VALUES_TYPE_BINARY:
+				_, err = reader.BinaryDocValues(info.Name)
+			case DOC_VALUES_TYPE_SORTED:
+				_, err = reader.SortedDocValues(info.Name)
+			case DOC_VALUES_TYPE_SORTED_SET:
+				_, err = reader.SortedSetDocValues(info.Name)
+			default:
+				panic(fmt.Sprintf("unknown doc values type %v", info.DocValuesType()))
+			}
+			if err != nil {
+				return
+			}
+			docValuesCount++
+		}
+	}
+
+	if reader.MaxDoc() > 0 {
+		if _, err = reader.Document(0); err != nil {
+			return
+		}
+	}
+	// TODO warm term vectors once SegmentReader supports them
+
+	if warmer.infoStream.IsEnabled("SMSW") {
+		warmer.infoStream.Message("SMSW",
+			"Finished warming segment: %v, indexed=%v, docValues=%v, norms=%v, time=%v",
+			reader, indexedCount, docValuesCount, normsCount, time.Now().Sub(startTime))
+	}
+	return nil
 }
@@ -0,0 +1,86 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/store"
+	"strings"
+	"testing"
+)
+
+type recordingInfoStream struct {
+	messages []string
+}
+
+func (is *recordingInfoStream) Message(component, message string, args ...interface{}) {
+	is.messages = append(is.messages, component+" "+fmt.Sprintf(message, args...))
+}
+
+func (is *recordingInfoStream) IsEnabled(component string) bool { return true }
+func (is *recordingInfoStream) Close() error                    { return nil }
+
+func TestSimpleMergedSegmentWarmer(t *testing.T) {
+	d, err := store.OpenFSDirectory("../search/testdata/win8/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	leaf := r.Leaves()[0].Reader().(AtomicReader)
+
+	infoStream := new(recordingInfoStream)
+	if err = NewSimpleMergedSegmentWarmer(infoStream).Warm(leaf); err != nil {
+		t.Fatal(err)
+	}
+	if len(infoStream.messages) != 1 || !strings.HasPrefix(infoStream.messages[0], "SMSW Finished warming segment") {
+		t.Errorf("expect the warming to be reported, got %v", infoStream.messages)
+	}
+
+	var warmed []AtomicReader
+	var warmer IndexReaderWarmer = IndexReaderWarmerFunc(func(reader AtomicReader) error {
+		warmed = append(warmed, reader)
+		_, err := reader.Document(0)
+		return err
+	})
+	if err = warmer.Warm(leaf); err != nil || len(warmed) != 1 || warmed[0] != leaf {
+		t.Errorf("expect the function to warm the segment, got %v (%v)", warmed, err)
+	}
+}
+
+func TestWarmMergedSegment(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	var warmed []int
+	w.SetMergedSegmentWarmer(IndexReaderWarmerFunc(func(reader AtomicReader) error {
+		warmed = append(warmed, reader.MaxDoc())
+		_, err := reader.Document(reader.MaxDoc() - 1)
+		return err
+	}))
+	addIdDocs(t, w, 0, 5)
+	addIdDocs(t, w, 5, 10)
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(warmed) != 1 || warmed[0] != 10 {
+		t.Fatalf("Expected the merged segment of 10 docs to be warmed, but %v", warmed)
+	}
+
+	// a failed warming aborts the merge
+	w.SetMergedSegmentWarmer(IndexReaderWarmerFunc(func(reader AtomicReader) error {
+		return errors.New("warming failed")
+	}))
+	addIdDocs(t, w, 10, 15)
+	if err := w.ForceMerge(1); err == nil || !strings.Contains(err.Error(), "warming failed") {
+		t.Fatalf("Expected the warming error, but %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if infos := readTestInfos(t, dir); len(infos.Segments) != 2 {
+		t.Fatalf("Expected the segments to be kept, but %v", infos.Segments)
+	}
+	checkIdDocs(t, dir, 15)
+}
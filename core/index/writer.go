@@ -2,6 +2,7 @@ package index
 
 import (
 	"container/list"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
@@ -133,13 +134,31 @@ func (cc *ClosingControl) close(f func() (ok bool, err error)) error {
 
 /*
 Hard limit on maximum number of documents that may be added to the
-index. If you try to add more than this, you'll hit panic.
+index. If you try to add more than this, you'll get a
+TooManyDocsError. Larger collections must be split over several
+indexes, e.g. with a ShardedIndexWriter.
 */
 const MAX_DOCS = math.MaxInt32 - 128
 
 /* test only */
 var actualMaxDocs = MAX_DOCS
 
+/*
+Returned when adding documents to an index, or opening it, would take
+its number of documents past MAX_DOCS, as doc IDs would overflow.
+*/
+type TooManyDocsError struct {
+	// The number of documents the index would hold.
+	Docs int64
+	// The maximum number of documents of an index.
+	Limit int
+}
+
+func (e *TooManyDocsError) Error() string {
+	return fmt.Sprintf("number of documents in the index cannot exceed %v (would be %v)",
+		e.Limit, e.Docs)
+}
+
 const UNBOUNDED_MAX_MERGE_SEGMENTS = -1
 
 /* Name of the write lock in the index. */
@@ -148,6 +167,12 @@ const WRITE_LOCK_NAME = "write.lock"
 /* Source of a segment which results from a flush. */
 const SOURCE_FLUSH = "flush"
 
+/* Source of a segment which results from a merge of other segments. */
+const SOURCE_MERGE = "merge"
+
+/* Source of a segment which results from AddIndexes(). */
+const SOURCE_ADDINDEXES_READERS = "addIndexes(IndexReader...)"
+
 /*
 Absolute hard maximum length for a term, in bytes once encoded as
 UTF8. If a term arrives from the analyzer longer than this length,
@@ -210,13 +235,16 @@ type IndexWriter struct {
 
 	writeLock store.Lock
 
-	mergeScheduler  MergeScheduler
-	mergeExceptions []*OneMerge
-	didMessageState bool
+	mergeScheduler      MergeScheduler
+	mergeExceptions     []*OneMerge // guarded by MergeControl's lock
+	mergeMaxNumSegments int
+	didMessageState     bool
 
 	flushCount        int32 // atomic
 	flushDeletesCount int32 // atomic
 
+	stats writerStats // see Stats()
+
 	readerPool            *ReaderPool
 	bufferedUpdatesStream *BufferedUpdatesStream
 
@@ -397,6 +425,14 @@ func NewIndexWriter(d store.Directory, conf *IndexWriterConfig) (w *IndexWriter,
 
 	ans.rollbackSegments = ans.segmentInfos.createBackupSegmentInfos()
 
+	// reserve the documents of the index, refusing one already past
+	// the limit, e.g. combined by hand, whose doc IDs would overflow
+	numDocs := ans.segmentInfos.totalDocCount()
+	if numDocs > int64(actualMaxDocs) {
+		return nil, &TooManyDocsError{numDocs, actualMaxDocs}
+	}
+	ans.pendingNumDocs = numDocs
+
 	// start with previous field numbers, but new FieldInfos
 	ans.globalFieldNumberMap, err = ans.fieldNumberMap()
 	if err != nil {
@@ -484,6 +520,11 @@ func (w *IndexWriter) messageState() {
 Commits all changes to an index, wait for pending merges to complete,
 and closes all associate files.
 
+If IndexWriterConfig.CommitOnClose() is false, all changes since the
+last commit are discarded instead, just like Rollback(). If
+IndexWriterConfig.WaitForMergesOnClose() is false, running merges are
+aborted rather than waited for before the commit.
+
 Note that:
 	1. If you called prepare Commit but failed to call commit, this
 	method will panic and the IndexWriter will not be closed.
@@ -498,6 +539,12 @@ NOTE: You must ensure no other threads are still making changes at
 the same time that this method is invoked.
 */
 func (w *IndexWriter) Close() error {
+	if !w.config.CommitOnClose() {
+		if w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "now rollback at close: commitOnClose=false")
+		}
+		return w.Rollback()
+	}
 	assert2(w.pendingCommit == nil,
 		"cannot close: prepareCommit was already called with no corresponding call to commit")
 	// Ensure that only one goroutine actaully gets to do the closing
@@ -511,12 +558,17 @@ func (w *IndexWriter) Close() error {
 			}
 		}()
 		if w.infoStream.IsEnabled("IW") {
-			w.infoStream.Message("IW", "now flush at close")
+			w.infoStream.Message("IW", "now flush at close waitForMerges=%v",
+				w.config.WaitForMergesOnClose())
 		}
 		if err = w.flush(true, true); err != nil {
 			return
 		}
-		w.waitForMerges()
+		if w.config.WaitForMergesOnClose() {
+			w.waitForMerges()
+		} else {
+			w.abortAllMerges()
+		}
 		if err = w.commitInternal(w.config.MergePolicy()); err != nil {
 			return
 		}
@@ -529,6 +581,26 @@ func (w *IndexWriter) Directory() store.Directory {
 	return w.directory
 }
 
+/*
+Returns the memory used by the documents and deletes buffered in RAM,
+including the segments being flushed.
+*/
+func (w *IndexWriter) RamBytesUsed() int64 {
+	w.ensureOpen()
+	fc := w.docWriter.flushControl
+	return fc.netBytes() + fc.deleteBytesUsed()
+}
+
+func (w *IndexWriter) ChildResources() []util.Accountable {
+	w.ensureOpen()
+	fc := w.docWriter.flushControl
+	return []util.Accountable{
+		util.NamedAccountable("buffered documents", fc.activeBytes()),
+		util.NamedAccountable("flushing documents", fc.flushBytes()),
+		util.NamedAccountable("buffered deletes", fc.deleteBytesUsed()),
+	}
+}
+
 // L1201
 /*
 Adds a document to this index.
@@ -594,6 +666,13 @@ func (w *IndexWriter) UpdateDocument(term *Term, doc []IndexableField, analyzer
 		}
 	}()
 
+	if throttler, ok := w.mergeScheduler.(MergeThrottler); ok {
+		// don't let indexing outrun merging
+		if err := throttler.WaitIfStalled(); err != nil {
+			return err
+		}
+	}
+
 	ok, err := w.docWriter.updateDocument(doc, analyzer, term)
 	if err != nil {
 		return err
@@ -608,11 +687,123 @@ func (w *IndexWriter) UpdateDocument(term *Term, doc []IndexableField, analyzer
 	return nil
 }
 
+/*
+Merges the provided indexes into this index, as a single new segment.
+
+The readers are left open, and their deleted documents dropped. Each
+of their segments may have been written by any codec, e.g. an older
+one: their documents are read back and re-encoded with the codec of
+this writer, so that indexes written with different formats can
+always be combined. Doc values and term vectors cannot be merged yet.
+
+The new segment is not written as a compound file. As with
+AddDocument(), it becomes visible to readers after the next commit.
+*/
+func (w *IndexWriter) AddIndexes(readers ...IndexReader) error {
+	w.ensureOpen()
+
+	var leaves []AtomicReader
+	numDocs := 0
+	for _, r := range readers {
+		for _, ctx := range r.Context().Leaves() {
+			leaves = append(leaves, ctx.Reader().(AtomicReader))
+		}
+		numDocs += r.NumDocs()
+	}
+	// make sure adding the new documents won't exceed the limit
+	if err := w.reserveDocs(numDocs); err != nil {
+		return err
+	}
+	var success = false
+	defer func() {
+		if !success {
+			atomic.AddInt64(&w.pendingNumDocs, -int64(numDocs))
+		}
+	}()
+
+	if err := w.flush(false, true); err != nil {
+		return err
+	}
+	name := w.newSegmentName()
+	context := store.NewIOContextForMerge(&store.MergeInfo{
+		TotalDocCount:       numDocs,
+		EstimatedMergeBytes: -1,
+		IsExternal:          true,
+		MergeMaxNumSegments: -1,
+	})
+	trackingDir := store.NewTrackingDirectoryWrapper(w.directory)
+	info := NewSegmentInfo(w.directory, util.VERSION_LATEST, name, -1, false, w.codec, nil)
+	start := time.Now()
+
+	merger := newSegmentMerger(leaves, info, w.infoStream, trackingDir,
+		w.config.TermIndexInterval(), w.globalFieldNumberMap, context)
+	if !merger.shouldMerge() {
+		success = true
+		return nil
+	}
+	fieldInfos, err := merger.merge()
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]bool)
+	trackingDir.EachCreatedFiles(func(name string) {
+		files[name] = true
+	})
+	info.SetFiles(files)
+	setDiagnostics(info, SOURCE_ADDINDEXES_READERS)
+
+	// Have codec write SegmentInfo.
+	err = w.codec.SegmentInfoFormat().SegmentInfoWriter().Write(trackingDir, info, fieldInfos, context)
+	if err != nil {
+		return err
+	}
+	// the .si file is tracked too
+	trackingDir.EachCreatedFiles(func(name string) {
+		files[name] = true
+	})
+	info.SetFiles(files)
+
+	newSegment := NewSegmentCommitInfo(info, 0, -1, -1, -1)
+	size, err := newSegment.SizeInBytes()
+	if err != nil {
+		return err
+	}
+	w.stats.recordMerge(size, time.Since(start))
+
+	w.Lock() // synchronized
+	defer w.Unlock()
+	w.ClosingControl.ensureOpen(false)
+	w.segmentInfos.Segments = append(w.segmentInfos.Segments, newSegment)
+	if err = w._checkpoint(); err != nil {
+		return err
+	}
+	success = true
+	return nil
+}
+
+/*
+Anything that will add N docs to the index should reserve first to
+make sure it's allowed.
+*/
+func (w *IndexWriter) reserveDocs(numDocs int) error {
+	if n := atomic.AddInt64(&w.pendingNumDocs, int64(numDocs)); n > int64(actualMaxDocs) {
+		// reserve failed
+		atomic.AddInt64(&w.pendingNumDocs, -int64(numDocs))
+		return &TooManyDocsError{n, actualMaxDocs}
+	}
+	return nil
+}
+
 func (w *IndexWriter) newSegmentName() string {
 	// Cannot synchronize on IndexWriter because that causes deadlook
 	// Ian: but why?
 	w.Lock()
 	defer w.Unlock()
+	return w._newSegmentName()
+}
+
+func (w *IndexWriter) _newSegmentName() string {
 	// Important to increment changeCount so that the segmentInfos is
 	// written on close. Otherwise we could close, re-open and
 	// re-return the same segment name that was previously returned
@@ -664,31 +855,183 @@ started. If other routines are still adding documents and flushing
 segments, those newly created segments will not be merged unless you
 call forceMerge again.
 
-NOTE: if you call CloseAndWait() with false, which aborts all running
-merges, then any routine still running this method might hit a
-MergeAbortedError.
+NOTE: if you call Rollback(), or Close() with WaitForMergesOnClose()
+false, which abort all running merges, then any routine still running
+this method might hit a MergeAbortedError.
 */
-func (w *IndexWriter) forceMerge(maxNumSegments int) error {
-	return w.forceMergeAndWait(maxNumSegments, true)
+func (w *IndexWriter) ForceMerge(maxNumSegments int) error {
+	return w.ForceMergeAndWait(maxNumSegments, true)
 }
 
 /*
-Just like forceMerge(), except you can specify whether the call
+Just like ForceMerge(), except you can specify whether the call
 should block until all merging completes. This is only meaningful
 with  a Mergecheduler that is able to run merges in background
 routines.
 */
-func (w *IndexWriter) forceMergeAndWait(maxNumSegments int, doWait bool) error {
-	panic("not implemented yet")
+func (w *IndexWriter) ForceMergeAndWait(maxNumSegments int, doWait bool) error {
+	w.ensureOpen()
+
+	assert2(maxNumSegments >= 1, "maxNumSegments must be >= 1; got %v", maxNumSegments)
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "forceMerge: index now %v", w.segString())
+		w.infoStream.Message("IW", "now flush at forceMerge")
+	}
+
+	if err := w.flush(true, true); err != nil {
+		return err
+	}
+
+	func() {
+		w.Lock() // synchronized
+		defer w.Unlock()
+
+		w.resetMergeExceptions()
+		w.segmentsToMerge = make(map[*SegmentCommitInfo]bool)
+		for _, info := range w.segmentInfos.Segments {
+			w.segmentsToMerge[info] = true
+		}
+		w.mergeMaxNumSegments = maxNumSegments
+
+		// Now mark all pending & running merges for forced merge:
+		w.MergeControl.Lock()
+		defer w.MergeControl.Unlock()
+		for e := w.pendingMerges.Front(); e != nil; e = e.Next() {
+			e.Value.(*OneMerge).maxNumSegments = maxNumSegments
+		}
+		for merge, _ := range w.runningMerges {
+			merge.maxNumSegments = maxNumSegments
+			if merge.info != nil {
+				w.segmentsToMerge[merge.info] = true
+			}
+		}
+	}()
+
+	if err := w.maybeMerge(w.config.MergePolicy(), MERGE_TRIGGER_EXPLICIT, maxNumSegments); err != nil {
+		return err
+	}
+
+	if doWait {
+		return w.waitForForcedMerges()
+	}
+
+	// NOTE: in the ConcurrentMergeScheduler case, when doWait is false,
+	// we can return immediately while background routines accomplish
+	// the merging
+	return nil
 }
 
-// Returns true if any merges in pendingMerges or runningMerges
-// are maxNumSegments merges.
+/*
+Waits until no merge of ForceMerge() is pending or running, and
+returns the error of the first one which failed, if any.
+*/
+func (w *IndexWriter) waitForForcedMerges() error {
+	w.MergeControl.Lock() // synchronized
+	defer w.MergeControl.Unlock()
+
+	for {
+		if w.tragedy != nil {
+			return errors.New(fmt.Sprintf(
+				"this writer hit an unrecoverable error; cannot complete forceMerge: %v", w.tragedy))
+		}
+		for _, merge := range w.mergeExceptions {
+			if merge.maxNumSegments != -1 {
+				return errors.New(fmt.Sprintf("background merge hit error: %v: %v",
+					merge.segString(), merge.err))
+			}
+		}
+		if !w.maxNumSegmentsMergePending() {
+			break
+		}
+		w.mergeSignal.Wait()
+	}
+
+	// If close is called while we are still running, fail so the
+	// calling routine will know merging did not complete
+	w.ensureOpen()
+	return nil
+}
+
+/*
+Returns true if any merges in pendingMerges or runningMerges are
+maxNumSegments merges. Requires MergeControl's lock.
+*/
 func (w *IndexWriter) maxNumSegmentsMergePending() bool {
-	w.Lock() // synchronized
-	defer w.Unlock()
+	for e := w.pendingMerges.Front(); e != nil; e = e.Next() {
+		if e.Value.(*OneMerge).maxNumSegments != -1 {
+			return true
+		}
+	}
+	for merge, _ := range w.runningMerges {
+		if merge.maxNumSegments != -1 {
+			return true
+		}
+	}
+	return false
+}
 
-	panic("not implemented yet")
+/*
+Forces merging of all segments that have deleted documents. The
+actual merges to be executed are determined by the MergePolicy. For
+example, the default TieredMergePolicy will only pick a segment if
+the percentage of deleted docs is over 10%.
+
+This is often a horribly costly operation; rarely is it warranted.
+
+If doWait is false, the merges are only registered and handed to the
+MergeScheduler, and this method returns immediately.
+*/
+func (w *IndexWriter) ForceMergeDeletes(doWait bool) error {
+	w.ensureOpen()
+
+	if err := w.flush(true, true); err != nil {
+		return err
+	}
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "forceMergeDeletes: index now %v", w.segString())
+	}
+
+	spec, err := func() (MergeSpecification, error) {
+		w.Lock() // synchronized
+		defer w.Unlock()
+
+		spec, err := w.config.MergePolicy().FindForcedDeletesMerges(w.segmentInfos, w)
+		if err != nil {
+			return nil, err
+		}
+		for _, merge := range spec {
+			if _, err = w.registerMerge(merge); err != nil {
+				return nil, err
+			}
+		}
+		return spec, nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	if err = w.mergeScheduler.Merge(w, MERGE_TRIGGER_EXPLICIT, spec != nil); err != nil {
+		return err
+	}
+
+	if spec != nil && doWait {
+		w.waitForMergesOf(spec)
+		// the merges may have run in background routines, which only
+		// log their errors
+		for _, merge := range spec {
+			if merge.err != nil {
+				return errors.New(fmt.Sprintf("background merge hit error: %v: %v",
+					merge.segString(), merge.err))
+			}
+		}
+	}
+
+	// NOTE: in the ConcurrentMergeScheduler case, when doWait is false,
+	// we can return immediately while background routines accomplish
+	// the merging
+	return nil
 }
 
 func (w *IndexWriter) maybeMerge(mergePolicy MergePolicy,
@@ -707,6 +1050,11 @@ func (w *IndexWriter) updatePendingMerges(mergePolicy MergePolicy,
 
 	w.Lock() // synchronized
 	defer w.Unlock()
+	return w._updatePendingMerges(mergePolicy, trigger, maxNumSegments)
+}
+
+func (w *IndexWriter) _updatePendingMerges(mergePolicy MergePolicy,
+	trigger MergeTrigger, maxNumSegments int) (found bool, err error) {
 
 	// in case infoStream was disabled on init, but then enabled at some
 	// point, try again to log the config here:
@@ -751,7 +1099,7 @@ func (w *IndexWriter) updatePendingMerges(mergePolicy MergePolicy,
 			}
 		}
 	}
-	return true, nil
+	return found, nil
 }
 
 /*
@@ -772,6 +1120,8 @@ merge requested by the MergePolicy.
 func (w *IndexWriter) nextMerge() *OneMerge {
 	w.Lock() // synchronized
 	defer w.Unlock()
+	w.MergeControl.Lock()
+	defer w.MergeControl.Unlock()
 
 	if w.pendingMerges.Len() == 0 {
 		return nil
@@ -785,6 +1135,8 @@ func (w *IndexWriter) nextMerge() *OneMerge {
 
 // Expert: returns true if there are merges waiting to be scheduled.
 func (w *IndexWriter) hasPendingMerges() bool {
+	w.MergeControl.Lock() // synchronized
+	defer w.MergeControl.Unlock()
 	return w.pendingMerges.Len() > 0
 }
 
@@ -835,11 +1187,14 @@ func (w *IndexWriter) rollbackInternal() (ok bool, err error) {
 			}
 		}()
 
+		// Must not hold IW's lock while aborting the merges: the running
+		// ones take it to finish
+		w.abortAllMerges()
 		func() {
 			w.Lock()
 			defer w.Unlock()
-
-			w.abortAllMerges()
+			w.MergeControl.Lock()
+			defer w.MergeControl.Unlock()
 			w.stopMerges = true
 		}()
 
@@ -1010,9 +1365,22 @@ func (w *IndexWriter) publishFlushedSegment(newSegment *SegmentCommitInfo,
 }
 
 func (w *IndexWriter) resetMergeExceptions() {
-	w.Lock() // synchronized
-	defer w.Unlock()
-	panic("not implemented yet")
+	w.MergeControl.Lock() // synchronized
+	defer w.MergeControl.Unlock()
+	w.mergeExceptions = nil
+}
+
+/* Records the merge as failed, for ForceMerge() to report its error. */
+func (w *IndexWriter) addMergeException(merge *OneMerge) {
+	w.MergeControl.Lock() // synchronized
+	defer w.MergeControl.Unlock()
+	assert(merge.err != nil)
+	for _, m := range w.mergeExceptions {
+		if m == merge {
+			return
+		}
+	}
+	w.mergeExceptions = append(w.mergeExceptions, merge)
 }
 
 /*
@@ -1417,8 +1785,83 @@ func (w *IndexWriter) _applyAllDeletesAndUpdates() error {
 Merges the indicated segments, replacing them in the stack with a
 single segment.
 */
-func (w *IndexWriter) merge(merge *OneMerge) error {
-	panic("not implemented yet")
+func (w *IndexWriter) merge(merge *OneMerge) (err error) {
+	_, span := w.config.Tracer().Start(context.Background(), "IndexWriter.merge")
+	if span.IsRecording() {
+		span.SetAttribute("segments", len(merge.segments))
+		span.SetAttribute("docs", merge.totalDocCount)
+	}
+	defer func() { span.End(err) }()
+
+	mergePolicy := w.config.MergePolicy()
+	start := time.Now()
+	var success = false
+	defer func() {
+		w.Lock() // synchronized
+		defer w.Unlock()
+
+		if !success && merge.info != nil && w.segmentInfos.indexOf(merge.info) == -1 {
+			if err2 := w.deleter.refresh(merge.info.Info.Name); err == nil {
+				err = err2
+			}
+		}
+
+		// This merge (and, generally, any change to the segments) may
+		// now enable new merges, so we call merge policy & update
+		// pending merges. It is done before the merge is marked as
+		// finished, so that ForceMerge() cannot see the forced merges
+		// done in between.
+		if success && !merge.isAborted() &&
+			(merge.maxNumSegments != -1 || !w._closed && !w._closing) {
+			if _, err2 := w._updatePendingMerges(mergePolicy, MERGE_FINISHED, merge.maxNumSegments); err == nil {
+				err = err2
+			}
+		}
+
+		w.MergeControl.Lock()
+		defer w.MergeControl.Unlock()
+		w.mergeFinish(merge)
+	}()
+
+	if err = w.mergeInit(merge); err == nil {
+		if w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "now merge\n  merge=%v\n  index=%v",
+				w.readerPool.segmentsToString(merge.segments), w.segString())
+		}
+		err = w.mergeMiddle(merge)
+	}
+	if err != nil {
+		return w.handleMergeError(err, merge)
+	}
+	success = true
+
+	if merge.info != nil && !merge.isAborted() && w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "merge time %v for %v docs",
+			time.Since(start), merge.info.Info.DocCount())
+	}
+	return nil
+}
+
+/*
+Records the error of a merge, so that ForceMerge() waiting on it sees
+the root cause. Aborted merges are not errors.
+*/
+func (w *IndexWriter) handleMergeError(err error, merge *OneMerge) error {
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "handleMergeError: merge=%v err=%v", merge.segString(), err)
+	}
+
+	// Set the error on the merge, so if forceMerge is waiting on us it
+	// sees the root cause:
+	merge.err = err
+	w.addMergeException(merge)
+
+	if _, ok := err.(MergeAbortedError); ok {
+		// We can ignore this error (it happens when Rollback() is
+		// called, or Close() does not wait for merges)
+		return nil
+	}
+	return err
 }
 
 /*
@@ -1426,9 +1869,313 @@ Checks whether this merge involves any segments already participating
 in a merge. If not, this merge is "registered", meaning we record
 that its semgents are now participating in a merge, and true is
 returned. Else (the merge conflicts) false is returned.
+
+Requires IW's lock.
 */
 func (w *IndexWriter) registerMerge(merge *OneMerge) (bool, error) {
-	panic("not implemented yet")
+	w.MergeControl.Lock() // synchronized
+	defer w.MergeControl.Unlock()
+
+	if merge.registerDone {
+		return true, nil
+	}
+	assert(len(merge.segments) > 0)
+
+	if w.stopMerges {
+		merge.abort()
+		return false, MergeAbortedError(fmt.Sprintf("merge is aborted: %v", merge.segString()))
+	}
+
+	var estimatedMergeBytes int64
+	for _, info := range merge.segments {
+		if _, ok := w.mergingSegments[info]; ok {
+			if w.infoStream.IsEnabled("IW") {
+				w.infoStream.Message("IW", "reject merge %v: segment %v is already marked for merge",
+					w.readerPool.segmentsToString(merge.segments), w.readerPool.segmentToString(info))
+			}
+			return false, nil
+		}
+		if w.segmentInfos.indexOf(info) == -1 {
+			if w.infoStream.IsEnabled("IW") {
+				w.infoStream.Message("IW", "reject merge %v: segment %v does not exist in live infos",
+					w.readerPool.segmentsToString(merge.segments), w.readerPool.segmentToString(info))
+			}
+			return false, nil
+		}
+		if _, ok := w.segmentsToMerge[info]; ok {
+			merge.maxNumSegments = w.mergeMaxNumSegments
+		}
+		if docCount := info.Info.DocCount(); docCount > 0 {
+			delCount := w.readerPool.numDeletedDocs(info)
+			assert(delCount <= docCount)
+			delRatio := float64(delCount) / float64(docCount)
+			size, err := info.SizeInBytes()
+			if err != nil {
+				return false, err
+			}
+			estimatedMergeBytes += int64(float64(size) * (1 - delRatio))
+		}
+	}
+
+	w.pendingMerges.PushBack(merge)
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "add merge to pendingMerges: %v [total %v pending]",
+			w.readerPool.segmentsToString(merge.segments), w.pendingMerges.Len())
+	}
+
+	merge.estimatedMergeBytes = estimatedMergeBytes
+
+	// OK it does not conflict; now record that this merge is running
+	// (while synchronized) to avoid race condition where two
+	// conflicting merges from different routines, start
+	for _, info := range merge.segments {
+		w.mergingSegments[info] = true
+	}
+
+	// Merge is now registered
+	merge.registerDone = true
+	return true, nil
+}
+
+/*
+Does initial setup for a merge, which is fast but holds IW's lock:
+applies the buffered deletes of its segments, and creates the
+SegmentInfo of the merged segment.
+*/
+func (w *IndexWriter) mergeInit(merge *OneMerge) error {
+	w.Lock() // synchronized
+	defer w.Unlock()
+
+	if w.tragedy != nil {
+		return errors.New(fmt.Sprintf(
+			"this writer hit an unrecoverable error; cannot merge: %v", w.tragedy))
+	}
+	assert(merge.registerDone)
+
+	if merge.info != nil {
+		// mergeInit already done
+		return nil
+	}
+	if err := merge.checkAborted(); err != nil {
+		return err
+	}
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "now apply deletes for %v merging segments", len(merge.segments))
+	}
+
+	// Lock order: IW -> BD
+	result, err := w.bufferedUpdatesStream.applyDeletesAndUpdates(w.readerPool, merge.segments)
+	if err != nil {
+		return err
+	}
+	if result.anyDeletes {
+		if err = w._checkpoint(); err != nil {
+			return err
+		}
+	}
+
+	// Bind a new segment name here so even with ConcurrentMergePolicy
+	// we keep deterministic segment names.
+	si := NewSegmentInfo(w.directory, util.VERSION_LATEST, w._newSegmentName(), -1, false, w.codec, nil)
+	setDiagnosticsAndDetails(si, SOURCE_MERGE, map[string]string{
+		"mergeMaxNumSegments": strconv.Itoa(merge.maxNumSegments),
+		"mergeFactor":         strconv.Itoa(len(merge.segments)),
+	})
+	merge.info = NewSegmentCommitInfo(si, 0, -1, -1, -1)
+	merge.info.SetBufferedUpdatesGen(result.gen)
+
+	// Lock order: IW -> BD
+	w.bufferedUpdatesStream.prune(w.segmentInfos)
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "merge seg=%v %v", si.Name, w.readerPool.segmentsToString(merge.segments))
+	}
+	return nil
+}
+
+/*
+Does the actual (time-consuming) work of the merge, without holding
+IW's lock: merges the live documents of its segments into the new
+segment with a SegmentMerger, then commits the merge. As with
+AddIndexes(), the merged segment is not written as a compound file.
+*/
+func (w *IndexWriter) mergeMiddle(merge *OneMerge) (err error) {
+	if err = merge.checkAborted(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	context := store.NewIOContextForMerge(merge.storeMergeInfo())
+	trackingDir := store.NewTrackingDirectoryWrapper(w.directory)
+
+	defer func() {
+		// Readers are already closed by commitMerge if we didn't hit an
+		// error
+		if err != nil {
+			w.closeMergeReaders(merge)
+		}
+	}()
+
+	// Open the readers of the segments, without their terms indexes,
+	// which merging does not need:
+	readers := make([]AtomicReader, 0, len(merge.segments))
+	for _, info := range merge.segments {
+		reader, err := NewSegmentReader(info, -1, context)
+		if err != nil {
+			return err
+		}
+		merge.readers = append(merge.readers, reader)
+		readers = append(readers, reader)
+	}
+
+	merger := newSegmentMerger(readers, merge.info.Info, w.infoStream, trackingDir,
+		w.config.TermIndexInterval(), w.globalFieldNumberMap, context)
+	if err = merge.checkAborted(); err != nil {
+		return err
+	}
+
+	if !merger.shouldMerge() {
+		// all the documents of the segments were deleted
+		if w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "merge away fully deleted segments")
+		}
+		merge.info.Info.SetDocCount(0)
+		merge.info.Info.SetFiles(make(map[string]bool))
+		_, err = w.commitMerge(merge)
+		return err
+	}
+
+	fieldInfos, err := merger.merge()
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]bool)
+	trackingDir.EachCreatedFiles(func(name string) {
+		files[name] = true
+	})
+	merge.info.Info.SetFiles(files)
+
+	// Have codec write SegmentInfo.
+	err = w.codec.SegmentInfoFormat().SegmentInfoWriter().Write(trackingDir, merge.info.Info, fieldInfos, context)
+	if err != nil {
+		return err
+	}
+	// the .si file is tracked too
+	trackingDir.EachCreatedFiles(func(name string) {
+		files[name] = true
+	})
+	merge.info.Info.SetFiles(files)
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "merged segment size=%v docs=%v", w.readerPool.segmentToString(merge.info),
+			merge.info.Info.DocCount())
+	}
+
+	if err = w.warmMergedSegment(merge, context); err != nil {
+		return err
+	}
+
+	committed, err := w.commitMerge(merge)
+	if err != nil || !committed {
+		return err
+	}
+	size, err := merge.info.SizeInBytes()
+	if err != nil {
+		return err
+	}
+	w.stats.recordMerge(size, time.Since(start))
+	return nil
+}
+
+/*
+Replaces the segments of the merge with the merged segment, or drops
+them all if it has no documents left. Returns false if the merge was
+aborted in the meantime, in which case its files are deleted.
+*/
+func (w *IndexWriter) commitMerge(merge *OneMerge) (bool, error) {
+	w.Lock() // synchronized
+	defer w.Unlock()
+
+	if w.tragedy != nil {
+		return false, errors.New(fmt.Sprintf(
+			"this writer hit an unrecoverable error; cannot complete merge: %v", w.tragedy))
+	}
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "commitMerge: %v index=%v", merge.segString(), w.segString())
+	}
+
+	assert(merge.registerDone)
+
+	// If merge was explicitly aborted, or, if rollback() had been
+	// called since our merge started (which results in an unqualified
+	// deleter.refresh() call that will remove any index file that
+	// current segments does not reference), we abort this merge
+	if merge.isAborted() {
+		if w.infoStream.IsEnabled("IW") {
+			w.infoStream.Message("IW", "commitMerge: skip: it was aborted")
+		}
+		// Close the readers of the merge before trying to delete any
+		// of its files
+		err := w.closeMergeReaders(merge)
+		w.deleter.deleteNewFiles(merge.info.Files())
+		return false, err
+	}
+
+	dropSegment := merge.info.Info.DocCount() == 0 && !w.keepFullyDeletedSegments
+	w.segmentInfos.applyMergeChanges(merge, dropSegment)
+
+	// Now deduct the deleted docs that we just reclaimed from this
+	// merge:
+	delDocCount := merge.totalDocCount - merge.info.Info.DocCount()
+	assert(delDocCount >= 0)
+	atomic.AddInt64(&w.pendingNumDocs, -int64(delDocCount))
+
+	if dropSegment {
+		assert(w.segmentInfos.indexOf(merge.info) == -1)
+		w.deleter.deleteNewFiles(merge.info.Files())
+	}
+
+	// Must close before checkpoint, otherwise IFD won't be able to
+	// delete the held-open files from the merge readers:
+	err := w.closeMergeReaders(merge)
+	for _, info := range merge.segments {
+		err = mergeError(err, w.readerPool.drop(info))
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Must note the change to segmentInfos so any commits in-flight
+	// don't lose it (IFD will incRef/protect the new files we
+	// created):
+	if err = w._checkpoint(); err != nil {
+		return false, err
+	}
+
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "after commitMerge: %v", w.segString())
+	}
+
+	if merge.maxNumSegments != -1 && !dropSegment {
+		// cascade the forceMerge:
+		if _, ok := w.segmentsToMerge[merge.info]; !ok {
+			w.segmentsToMerge[merge.info] = false
+		}
+	}
+	return true, nil
+}
+
+/* Closes the readers opened by mergeMiddle(). */
+func (w *IndexWriter) closeMergeReaders(merge *OneMerge) (err error) {
+	for _, reader := range merge.readers {
+		err = mergeError(err, reader.Close())
+	}
+	merge.readers = nil
+	return err
 }
 
 func setDiagnostics(info *SegmentInfo, source string) {
@@ -1771,5 +2518,40 @@ merged segment.
 type IndexReaderWarmer interface {
 	// Invoked on the AtomicReader for the newly merged segment, before
 	// that segment is made visible to near-real-time readers.
-	warm(reader AtomicReader) error
+	Warm(reader AtomicReader) error
+}
+
+/*
+Sets the warmer of newly merged segments, or nil to disable warming.
+Takes effect on the next merge.
+*/
+func (w *IndexWriter) SetMergedSegmentWarmer(warmer IndexReaderWarmer) {
+	w.config.setMergedSegmentWarmer(warmer)
+}
+
+/*
+Warms a reader on the newly merged segment with the merged segment
+warmer, if any, before the merge commits, so that the first searches
+of near-real-time readers opened after a big merge do not pay for
+loading its data structures. Called by mergeMiddle() once the merged
+segment is written.
+*/
+func (w *IndexWriter) warmMergedSegment(merge *OneMerge, context store.IOContext) error {
+	warmer := w.config.MergedSegmentWarmer()
+	if warmer == nil {
+		return nil
+	}
+	startTime := time.Now()
+	reader, err := NewSegmentReader(merge.info, DEFAULT_TERMS_INDEX_DIVISOR, context)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err = warmer.Warm(reader); err != nil {
+		return err
+	}
+	if w.infoStream.IsEnabled("IW") {
+		w.infoStream.Message("IW", "merged segment %v warmed in %v", reader, time.Now().Sub(startTime))
+	}
+	return nil
 }
@@ -0,0 +1,105 @@
+package index
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+/*
+A snapshot of the activity and buffers of an IndexWriter, returned by
+IndexWriter.Stats(), e.g. to export as metrics for capacity planning
+instead of parsing its InfoStream.
+
+Flush and merge counters are cumulative since the writer was opened.
+Their time is the wall time spent writing the new segments, which may
+overlap when several threads flush concurrently.
+*/
+type IndexWriterStats struct {
+	// Segments flushed from the RAM buffer, and their size on disk.
+	Flushes      int64
+	FlushedBytes int64
+	FlushTime    time.Duration
+
+	// Segments merged, e.g. by AddIndexes(), and their size on disk.
+	Merges      int64
+	MergedBytes int64
+	MergeTime   time.Duration
+
+	// Memory used by the documents and deletes buffered in RAM,
+	// including the segments being flushed; see RamBytesUsed().
+	RAMBytesUsed int64
+	// Documents buffered in RAM, not flushed yet.
+	BufferedDocs int
+	// Delete terms buffered, not applied to the segments yet.
+	PendingDeletes int
+	// Segments of the index, including those not committed yet.
+	SegmentCount int
+}
+
+/* Returns the bytes flushed per second of flush time, or 0. */
+func (s IndexWriterStats) FlushBytesPerSec() float64 {
+	return perSec(float64(s.FlushedBytes), s.FlushTime)
+}
+
+/* Returns the megabytes merged per second of merge time, or 0. */
+func (s IndexWriterStats) MergeMBPerSec() float64 {
+	return perSec(float64(s.MergedBytes)/1024/1024, s.MergeTime)
+}
+
+func perSec(v float64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return v / d.Seconds()
+}
+
+func (s IndexWriterStats) String() string {
+	return fmt.Sprintf("flushes=%v (%v bytes, %.0f bytes/sec) merges=%v (%v bytes, %.3f MB/sec) "+
+		"ramBytesUsed=%v bufferedDocs=%v pendingDeletes=%v segments=%v",
+		s.Flushes, s.FlushedBytes, s.FlushBytesPerSec(),
+		s.Merges, s.MergedBytes, s.MergeMBPerSec(),
+		s.RAMBytesUsed, s.BufferedDocs, s.PendingDeletes, s.SegmentCount)
+}
+
+/* Cumulative flush and merge counters of a writer. */
+type writerStats struct {
+	flushes, flushedBytes, flushNanos int64 // atomic
+	merges, mergedBytes, mergeNanos   int64 // atomic
+}
+
+func (s *writerStats) recordFlush(bytes int64, d time.Duration) {
+	atomic.AddInt64(&s.flushes, 1)
+	atomic.AddInt64(&s.flushedBytes, bytes)
+	atomic.AddInt64(&s.flushNanos, int64(d))
+}
+
+func (s *writerStats) recordMerge(bytes int64, d time.Duration) {
+	atomic.AddInt64(&s.merges, 1)
+	atomic.AddInt64(&s.mergedBytes, bytes)
+	atomic.AddInt64(&s.mergeNanos, int64(d))
+}
+
+/*
+Returns a snapshot of the flush and merge throughput of the writer,
+and of its buffers and segments.
+*/
+func (w *IndexWriter) Stats() IndexWriterStats {
+	w.ensureOpen()
+	ans := IndexWriterStats{
+		Flushes:      atomic.LoadInt64(&w.stats.flushes),
+		FlushedBytes: atomic.LoadInt64(&w.stats.flushedBytes),
+		FlushTime:    time.Duration(atomic.LoadInt64(&w.stats.flushNanos)),
+		Merges:       atomic.LoadInt64(&w.stats.merges),
+		MergedBytes:  atomic.LoadInt64(&w.stats.mergedBytes),
+		MergeTime:    time.Duration(atomic.LoadInt64(&w.stats.mergeNanos)),
+		RAMBytesUsed: w.RamBytesUsed(),
+		BufferedDocs: int(atomic.LoadInt32(&w.docWriter.numDocsInRAM)),
+		PendingDeletes: int(atomic.LoadInt32(&w.bufferedUpdatesStream.numTerms)) +
+			w.docWriter.deleteQueue.numGlobalTermDeletes(),
+	}
+	w.Lock()
+	defer w.Unlock()
+	ans.SegmentCount = len(w.segmentInfos.Segments)
+	return ans
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+func TestWriterStats(t *testing.T) {
+	w := newTestWriter(t, store.NewRAMDirectory())
+	defer w.Close()
+	for i := 0; i < 5; i++ {
+		if err := w.AddDocument(newIdDoc(i).Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := w.Stats()
+	if s.BufferedDocs != 5 || s.RAMBytesUsed <= 0 || s.Flushes != 0 {
+		t.Errorf("expect 5 buffered docs and no flush, got %v", s)
+	}
+
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	s = w.Stats()
+	if s.BufferedDocs != 0 || s.Flushes != 1 || s.FlushedBytes <= 0 || s.SegmentCount != 1 {
+		t.Errorf("expect a single flushed segment, got %v", s)
+	}
+
+	d, err := store.OpenFSDirectory("../search/testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if err = w.AddIndexes(src); err != nil {
+		t.Fatal(err)
+	}
+	s = w.Stats()
+	if s.Merges != 1 || s.MergedBytes <= 0 || s.SegmentCount != 2 {
+		t.Errorf("expect a merged segment, got %v", s)
+	}
+}
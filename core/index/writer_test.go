@@ -0,0 +1,620 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/codec/lucene410"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	docu "github.com/balzaczyy/golucene/core/document"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/* Adds the docs with ids in [from, to), and commits them as a segment. */
+func addIdDocs(t *testing.T, w *IndexWriter, from, to int) {
+	for i := from; i < to; i++ {
+		if err := w.AddDocument(newIdDoc(i).Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+/* Checks that the index holds the docs with ids in [0, numDocs). */
+func checkIdDocs(t *testing.T, dir store.Directory, numDocs int) {
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.NumDocs() != numDocs {
+		t.Fatalf("Expected %v docs, but %v", numDocs, r.NumDocs())
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < r.MaxDoc(); i++ {
+		d, err := r.Document(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[d.Get("id")] = true
+	}
+	for i := 0; i < numDocs; i++ {
+		if id := fmt.Sprintf("%v", i); !seen[id] {
+			t.Errorf("Expected doc %v to be kept", id)
+		}
+	}
+}
+
+func readTestInfos(t *testing.T, dir store.Directory) *SegmentInfos {
+	infos := &SegmentInfos{}
+	if err := infos.ReadAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	return infos
+}
+
+func TestForceMerge(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	for i := 0; i < 3; i++ {
+		addIdDocs(t, w, 5*i, 5*i+5)
+	}
+	if n := len(readTestInfos(t, dir).Segments); n != 3 {
+		t.Fatalf("Expected 3 segments, but %v", n)
+	}
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := readTestInfos(t, dir)
+	if len(infos.Segments) != 1 {
+		t.Fatalf("Expected 1 segment after forceMerge, but %v", len(infos.Segments))
+	}
+	if source := infos.Segments[0].Info.Diagnostics()["source"]; source != SOURCE_MERGE {
+		t.Errorf("Expected a merged segment, but source=%v", source)
+	}
+	checkIdDocs(t, dir, 15)
+}
+
+/* Fails the analysis of its field, so that the document is deleted. */
+type failingTokenStream struct {
+	*analysis.TokenStreamImpl
+}
+
+func newFailingTokenStream() *failingTokenStream {
+	ans := &failingTokenStream{analysis.NewTokenStream()}
+	ans.Attributes().Add("CharTermAttribute")
+	return ans
+}
+
+func (ts *failingTokenStream) IncrementToken() (bool, error) {
+	return false, errors.New("analysis failed")
+}
+
+func TestForceMergeDeletes(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	var ids []string
+	for i := 0; i < 10; i++ {
+		d := newIdDoc(i)
+		if i%3 != 0 {
+			if err := w.AddDocument(d.Fields()); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, fmt.Sprintf("%v", i))
+			continue
+		}
+		// the doc is kept in the segment, but marked as deleted
+		d.Add(docu.NewFieldFromTokenStream("body", newFailingTokenStream(), docu.TEXT_FIELD_TYPE_NOT_STORED))
+		if err := w.AddDocument(d.Fields()); err == nil {
+			t.Fatalf("Expected the analysis of doc %v to fail", i)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	infos := readTestInfos(t, dir)
+	if len(infos.Segments) != 1 || infos.Segments[0].DelCount() != 4 {
+		t.Fatalf("Expected a segment with 4 deletions, but %v", infos.Segments)
+	}
+
+	if err := w.ForceMergeDeletes(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	infos = readTestInfos(t, dir)
+	if len(infos.Segments) != 1 {
+		t.Fatalf("Expected 1 segment, but %v", infos.Segments)
+	}
+	if info := infos.Segments[0]; info.HasDeletions() || info.Info.DocCount() != len(ids) {
+		t.Fatalf("Expected the deletions to be merged away, but %v", info)
+	}
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.NumDocs() != len(ids) || r.MaxDoc() != len(ids) {
+		t.Fatalf("Expected %v docs, but numDocs=%v maxDoc=%v", len(ids), r.NumDocs(), r.MaxDoc())
+	}
+	for i, id := range ids {
+		d, err := r.Document(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Get("id") != id {
+			t.Errorf("Expected doc %v to be %v, but %v", i, id, d.Get("id"))
+		}
+	}
+}
+
+func TestMergeVectors(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	expected := make(map[string]float32)
+	for i := 0; i < 10; i++ {
+		d := newIdDoc(i)
+		switch {
+		case i%3 == 1:
+			// the doc is kept in the segment, but marked as deleted
+			d.Add(docu.NewVectorField("vector", []float32{float32(i), 1, 0}, VECTOR_SIMILARITY_COSINE))
+			if err := w.AddDocument(d.Fields()); err == nil {
+				t.Fatalf("Expected the vector dimension of doc %v to be rejected", i)
+			}
+			continue
+		case i != 5: // doc 5 has no vector
+			d.Add(docu.NewVectorField("vector", []float32{float32(i), 1}, VECTOR_SIMILARITY_COSINE))
+			expected[fmt.Sprintf("%v", i)] = float32(i)
+		}
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if i == 4 {
+			if err := w.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if leaves := r.Leaves(); len(leaves) != 1 || r.MaxDoc() != 7 {
+		t.Fatalf("Expected 1 segment of 7 docs, but %v segments of %v docs", len(leaves), r.MaxDoc())
+	}
+	values, err := r.Leaves()[0].Reader().(AtomicReader).VectorValues("vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values == nil || values.Size() != len(expected) || values.Dimension() != 2 {
+		t.Fatalf("Expected %v vectors of 2 dimensions, but %v", len(expected), values)
+	}
+	for ord := 0; ord < values.Size(); ord++ {
+		d, err := r.Document(values.Doc(ord))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := values.VectorValue(ord); v[0] != expected[d.Get("id")] {
+			t.Errorf("Expected the vector of doc %v to be [%v 1], but %v", d.Get("id"), expected[d.Get("id")], v)
+		}
+	}
+}
+
+/* Returns the value of the binary doc values field of each doc, by id. */
+func binaryValuesById(t *testing.T, r DirectoryReader, field string) map[string]string {
+	ans := make(map[string]string)
+	for _, leaf := range r.Leaves() {
+		values, err := leaf.Reader().(AtomicReader).BinaryDocValues(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values == nil {
+			t.Fatalf("Expected binary doc values for field %v", field)
+		}
+		for doc := 0; doc < leaf.Reader().MaxDoc(); doc++ {
+			d, err := r.Document(leaf.DocBase + doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ans[d.Get("id")] = string(values.Get(doc))
+		}
+	}
+	return ans
+}
+
+func TestBinaryDocValues(t *testing.T) {
+	formats := map[string]DocValuesFormat{
+		"fixed":    lucene410.NewLucene410DocValuesFormat(lucene410.BINARY_FIXED_UNCOMPRESSED),
+		"variable": lucene410.NewLucene410DocValuesFormat(lucene410.BINARY_VARIABLE_UNCOMPRESSED),
+		"prefix":   lucene410.NewLucene410DocValuesFormat(lucene410.BINARY_PREFIX_COMPRESSED),
+		"lz4":      lucene410.NewLucene410DocValuesFormat(lucene410.BINARY_LZ4_COMPRESSED),
+	}
+	codec := lucene410.NewLucene410CodecWithDocValuesFormat(func(field string) DocValuesFormat {
+		return formats[field]
+	})
+
+	dir := store.NewRAMDirectory()
+	w := newTestWriterWithCodec(t, dir, codec)
+	expected := make(map[string]map[string]string)
+	for field := range formats {
+		expected[field] = make(map[string]string)
+	}
+	for i := 0; i < 1000; i++ {
+		d := newIdDoc(i)
+		id := fmt.Sprintf("%v", i)
+		expected["fixed"][id] = fmt.Sprintf("%04d", i)
+		d.Add(docu.NewBinaryDocValuesField("fixed", []byte(expected["fixed"][id])))
+		for _, field := range []string{"variable", "prefix", "lz4"} {
+			if i%10 != 7 { // otherwise the doc has no value, read as empty
+				expected[field][id] = fmt.Sprintf("/%v/path/%v/to/doc/%v", field, i/10, i)
+				d.Add(docu.NewBinaryDocValuesField(field, []byte(expected[field][id])))
+			} else {
+				expected[field][id] = ""
+			}
+		}
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if i == 499 {
+			if err := w.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(segments int) {
+		r, err := OpenDirectoryReader(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if n := len(r.Leaves()); n != segments {
+			t.Fatalf("Expected %v segments, but %v", segments, n)
+		}
+		for field, values := range expected {
+			if actual := binaryValuesById(t, r, field); !reflect.DeepEqual(actual, values) {
+				t.Errorf("Expected the values of field %v to be %v, but %v", field, values, actual)
+			}
+		}
+	}
+	check(2)
+
+	// merged with the codec, read back with the registered one
+	w = newTestWriterWithCodec(t, dir, codec)
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(1)
+}
+
+/*
+Returns the numeric doc values of the docs having one, by id, read
+with the iterator of each segment; the costs of the iterators are
+summed up.
+*/
+func numericValuesById(t *testing.T, r DirectoryReader, field string) (map[string]int64, int64) {
+	ans, cost := make(map[string]int64), int64(0)
+	for _, leaf := range r.Leaves() {
+		reader := leaf.Reader().(AtomicReader)
+		values, err := reader.NumericDocValues(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		it, err := reader.NumericDocValuesIterator(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values == nil || it == nil {
+			t.Fatalf("Expected numeric doc values for field %v", field)
+		}
+		cost += it.Cost()
+		doc, err := it.NextDoc()
+		for ; err == nil && doc != NO_MORE_DOCS; doc, err = it.NextDoc() {
+			d, err := r.Document(leaf.DocBase + doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := values(doc); v != it.LongValue() {
+				t.Errorf("Expected the value of doc %v to be %v, but %v", doc, it.LongValue(), v)
+			}
+			ans[d.Get("id")] = it.LongValue()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ans, cost
+}
+
+func TestNumericDocValues(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	expected := map[string]map[string]int64{
+		"dense":   make(map[string]int64),
+		"missing": make(map[string]int64),
+		"sparse":  make(map[string]int64),
+	}
+	for i := 0; i < 1000; i++ {
+		d := newIdDoc(i)
+		id := fmt.Sprintf("%v", i)
+		expected["dense"][id] = int64(i*i) - 5000
+		d.Add(docu.NewNumericDocValuesField("dense", expected["dense"][id]))
+		if i%10 != 7 {
+			expected["missing"][id] = int64(i % 100)
+			d.Add(docu.NewNumericDocValuesField("missing", expected["missing"][id]))
+		}
+		if i == 123 || i == 877 { // one doc of each segment
+			expected["sparse"][id] = -int64(i)
+			d.Add(docu.NewNumericDocValuesField("sparse", expected["sparse"][id]))
+		}
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if i == 499 {
+			if err := w.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(segments int) {
+		r, err := OpenDirectoryReader(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if n := len(r.Leaves()); n != segments {
+			t.Fatalf("Expected %v segments, but %v", segments, n)
+		}
+		for field, values := range expected {
+			actual, cost := numericValuesById(t, r, field)
+			if !reflect.DeepEqual(actual, values) {
+				t.Errorf("Expected the values of field %v to be %v, but %v", field, values, actual)
+			}
+			// only the docs with a value are visited by sparse iterators
+			if field == "sparse" && cost != 2 {
+				t.Errorf("Expected the sparse field to be iterated over 2 docs, but %v", cost)
+			}
+		}
+
+		// the last doc with a value is reached without visiting the others
+		reader := r.Leaves()[len(r.Leaves())-1].Reader().(AtomicReader)
+		values, err := reader.NumericDocValues("sparse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		target := reader.MaxDoc() - 1
+		for ; target >= 0 && values(target) == 0; target-- {
+		}
+		it, err := reader.NumericDocValuesIterator("sparse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := it.AdvanceExact(target - 1); ok || err != nil {
+			t.Errorf("Expected no value for doc %v, but %v (%v)", target-1, ok, err)
+		}
+		if ok, err := it.AdvanceExact(target); !ok || err != nil || it.LongValue() != values(target) {
+			t.Errorf("Expected %v for doc %v, but %v (%v)", values(target), target, ok, err)
+		}
+		if doc, err := it.NextDoc(); doc != NO_MORE_DOCS || err != nil {
+			t.Errorf("Expected no more docs, but %v (%v)", doc, err)
+		}
+	}
+	check(2)
+
+	w = newTestWriter(t, dir)
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(1)
+}
+
+func TestSortedDocValues(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	expected := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		d := newIdDoc(i)
+		id := fmt.Sprintf("%v", i)
+		if i%7 != 3 { // otherwise the doc has no value
+			expected[id] = fmt.Sprintf("v%03d", (i*13)%50)
+			d.Add(docu.NewSortedDocValuesField("sorted", []byte(expected[id])))
+		}
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if i == 149 {
+			// kept in the segment, but marked as deleted
+			d = newIdDoc(1000)
+			d.Add(docu.NewSortedDocValuesField("sorted", []byte("deleted")))
+			d.Add(docu.NewFieldFromTokenStream("body", newFailingTokenStream(), docu.TEXT_FIELD_TYPE_NOT_STORED))
+			if err := w.AddDocument(d.Fields()); err == nil {
+				t.Fatal("Expected the analysis of the doc to fail")
+			}
+			if err := w.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(segments, valueCount int) {
+		r, err := OpenDirectoryReader(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		if n := len(r.Leaves()); n != segments {
+			t.Fatalf("Expected %v segments, but %v", segments, n)
+		}
+		actual := make(map[string]string)
+		for _, leaf := range r.Leaves() {
+			values, err := leaf.Reader().(AtomicReader).SortedDocValues("sorted")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if values == nil {
+				t.Fatal("Expected sorted doc values")
+			}
+			for ord := 1; ord < values.ValueCount(); ord++ {
+				if prev, v := string(values.LookupOrd(ord-1)), string(values.LookupOrd(ord)); prev >= v {
+					t.Errorf("Expected the values to be sorted, but %v before %v", prev, v)
+				}
+			}
+			liveDocs := leaf.Reader().(AtomicReader).LiveDocs()
+			for doc := 0; doc < leaf.Reader().MaxDoc(); doc++ {
+				if liveDocs != nil && !liveDocs.At(doc) {
+					continue
+				}
+				d, err := r.Document(leaf.DocBase + doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ord := values.Ord(doc); ord != -1 {
+					actual[d.Get("id")] = string(values.LookupOrd(ord))
+				} else if values.Get(doc) != nil {
+					t.Errorf("Expected no value for doc %v, but %v", d.Get("id"), values.Get(doc))
+				}
+			}
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected the values to be %v, but %v", expected, actual)
+		}
+		values, err := GetSortedValues(r, "sorted")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := values.ValueCount(); n != valueCount {
+			t.Errorf("Expected %v unique values, but %v", valueCount, n)
+		}
+	}
+	// the value of the deleted doc is still in its segment
+	check(2, 51)
+
+	w = newTestWriter(t, dir)
+	if err := w.ForceMerge(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	check(1, 50)
+}
+
+func TestDocValuesTypeCannotChange(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	defer w.Close()
+	d := newIdDoc(0)
+	d.Add(docu.NewBinaryDocValuesField("dv", []byte("a")))
+	if err := w.AddDocument(d.Fields()); err != nil {
+		t.Fatal(err)
+	}
+	d = newIdDoc(1)
+	ft := docu.NewFieldTypeFrom(docu.STRING_FIELD_TYPE_NOT_STORED)
+	ft.SetDocValueType(DOC_VALUES_TYPE_NUMERIC)
+	d.Add(docu.NewFieldFromString("dv", "b", ft))
+	if err := w.AddDocument(d.Fields()); err == nil {
+		t.Error("Expected the doc values type of the field to be checked")
+	}
+}
+
+func TestDocsOnlyPostings(t *testing.T) {
+	dir := store.NewRAMDirectory()
+	w := newTestWriter(t, dir)
+	// two instances of the field per doc, with the same or distinct terms
+	for _, tags := range [][2]string{{"a", "a"}, {"a", "b"}, {"b", "b"}, {"a", "c"}} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("tag", tags[0], docu.STORE_NO))
+		d.Add(docu.NewStringField("tag", tags[1], docu.STORE_NO))
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(r.Leaves()); n != 4 {
+		t.Fatalf("Expected 4 segments, but %v", n)
+	}
+	// the docFreq of the composite reader sums those of its segments
+	for term, expected := range map[string]int{"a": 3, "b": 2, "c": 1, "d": 0} {
+		if n, err := r.DocFreq(NewTerm("tag", term)); err != nil || n != expected {
+			t.Errorf("Expected docFreq %v of %v, but %v (%v)", expected, term, n, err)
+		}
+	}
+	leaf := r.Leaves()[0].Reader().(AtomicReader)
+	termsEnum := leaf.Terms("tag").Iterator(nil)
+	if ok, err := termsEnum.SeekExact([]byte("a")); !ok || err != nil {
+		t.Fatalf("Expected term a, but %v (%v)", ok, err)
+	}
+	if n, err := termsEnum.TotalTermFreq(); err != nil || n != -1 {
+		t.Errorf("Expected no term freqs, but %v (%v)", n, err)
+	}
+	// closing the reader closes its segments
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTermVectorsSettingsChanged(t *testing.T) {
+	w := newTestWriter(t, store.NewRAMDirectory())
+	defer w.Rollback()
+
+	ft := docu.NewFieldTypeFrom(docu.TEXT_FIELD_TYPE_NOT_STORED)
+	ft.SetStoreTermVectors(true)
+	d := docu.NewDocument()
+	d.Add(docu.NewTextFieldFromString("body", "quick fox", docu.STORE_NO))
+	d.Add(docu.NewFieldFromString("body", "lazy dog", ft))
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("Expected adding a doc changing the term vectors settings of a field to fail")
+		} else if msg := fmt.Sprint(e); !strings.Contains(msg, "storeTermVectors changed for field='body'") {
+			t.Errorf("Unexpected failure: %v", msg)
+		}
+	}()
+	w.AddDocument(d.Fields())
+}
@@ -0,0 +1,531 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+	"sort"
+	"time"
+)
+
+/*
+The definition of an aggregation, e.g. the terms of a field, or the
+average of a value, which creates the aggregators computing it. A
+bucket aggregation can have sub-aggregations, computed over the
+documents of each of its buckets, so that aggregations form a tree,
+e.g. the average price per month per category:
+
+	NewTermsAggregation("categories", "category", 10).
+		Add(NewDateHistogramAggregation("months", timestamp, DATE_INTERVAL_MONTH).
+			Add(NewMetricAggregation("price", METRIC_AVG, price)))
+
+The whole tree is computed in a single pass over the matching
+documents by an AggregationsCollector.
+*/
+type Aggregation interface {
+	Name() string
+	newAggregator() Aggregator
+}
+
+/*
+Computes an aggregation over the documents collected from the
+segments of a reader, in order. Its result is available after the
+last segment.
+*/
+type Aggregator interface {
+	SetNextReader(ctx *index.AtomicReaderContext) error
+	Collect(doc int) error
+	Result() AggregationResult
+}
+
+/* The result of an aggregation, e.g. a *MetricResult or *BucketsResult. */
+type AggregationResult interface {
+	String() string
+}
+
+/*
+Collector computing a set of aggregations over the matching
+documents, in a single pass.
+*/
+type AggregationsCollector struct {
+	aggs        []Aggregation
+	aggregators []Aggregator
+	err         error // deferred from SetNextReader
+}
+
+func NewAggregationsCollector(aggs ...Aggregation) *AggregationsCollector {
+	ans := &AggregationsCollector{aggs: aggs, aggregators: make([]Aggregator, len(aggs))}
+	for i, agg := range aggs {
+		ans.aggregators[i] = agg.newAggregator()
+	}
+	return ans
+}
+
+func (c *AggregationsCollector) SetScorer(s Scorer) {}
+
+func (c *AggregationsCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	for _, a := range c.aggregators {
+		if err := a.SetNextReader(ctx); err != nil && c.err == nil {
+			c.err = err
+		}
+	}
+}
+
+func (c *AggregationsCollector) Collect(doc int) error {
+	if c.err != nil {
+		return c.err
+	}
+	for _, a := range c.aggregators {
+		if err := a.Collect(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *AggregationsCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+/* Returns the result of the aggregation of the given name, or nil. */
+func (c *AggregationsCollector) Result(name string) AggregationResult {
+	for i, agg := range c.aggs {
+		if agg.Name() == name {
+			return c.aggregators[i].Result()
+		}
+	}
+	return nil
+}
+
+// Metrics
+
+/* What a MetricAggregation computes from the values of the documents. */
+type Metric int
+
+const (
+	METRIC_COUNT = Metric(iota) // number of documents having a value
+	METRIC_SUM
+	METRIC_AVG
+	METRIC_MIN
+	METRIC_MAX
+)
+
+func (m Metric) String() string {
+	switch m {
+	case METRIC_COUNT:
+		return "count"
+	case METRIC_SUM:
+		return "sum"
+	case METRIC_AVG:
+		return "avg"
+	case METRIC_MIN:
+		return "min"
+	case METRIC_MAX:
+		return "max"
+	}
+	return fmt.Sprintf("Metric(%v)", int(m))
+}
+
+/*
+Aggregation computing a metric of the numeric values of a
+FieldValueSource, e.g. the average price. Documents without a value
+are ignored.
+*/
+type MetricAggregation struct {
+	name   string
+	metric Metric
+	source FieldValueSource
+}
+
+func NewMetricAggregation(name string, metric Metric, source FieldValueSource) *MetricAggregation {
+	return &MetricAggregation{name, metric, source}
+}
+
+func (a *MetricAggregation) Name() string {
+	return a.name
+}
+
+func (a *MetricAggregation) newAggregator() Aggregator {
+	return &metricAggregator{MetricAggregation: a, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+/*
+The metric of the values of the documents of a bucket. The value of
+an average, minimum or maximum without documents is NaN.
+*/
+type MetricResult struct {
+	Name   string
+	Metric Metric
+	Value  float64
+}
+
+func (r *MetricResult) String() string {
+	return fmt.Sprintf("%v=%v", r.Name, r.Value)
+}
+
+type metricAggregator struct {
+	*MetricAggregation
+	values   FieldValues
+	count    int
+	sum      float64
+	min, max float64
+}
+
+func (a *metricAggregator) SetNextReader(ctx *index.AtomicReaderContext) (err error) {
+	a.values, err = a.source.Values(ctx)
+	return
+}
+
+func (a *metricAggregator) Collect(doc int) error {
+	v, err := a.values(doc)
+	if err != nil || v == nil {
+		return err
+	}
+	f, err := toFloat64(v)
+	if err != nil {
+		return err
+	}
+	a.count++
+	a.sum += f
+	a.min = math.Min(a.min, f)
+	a.max = math.Max(a.max, f)
+	return nil
+}
+
+func (a *metricAggregator) Result() AggregationResult {
+	ans := &MetricResult{Name: a.name, Metric: a.metric, Value: math.NaN()}
+	switch a.metric {
+	case METRIC_COUNT:
+		ans.Value = float64(a.count)
+	case METRIC_SUM:
+		ans.Value = a.sum
+	default:
+		if a.count > 0 {
+			switch a.metric {
+			case METRIC_AVG:
+				ans.Value = a.sum / float64(a.count)
+			case METRIC_MIN:
+				ans.Value = a.min
+			case METRIC_MAX:
+				ans.Value = a.max
+			}
+		}
+	}
+	return ans
+}
+
+// Buckets
+
+/* The documents of a bucket, and the results of the sub-aggregations over them. */
+type AggregationBucket struct {
+	// e.g. a string for terms, or a time.Time for a date histogram
+	Key          interface{}
+	Count        int
+	Aggregations []AggregationResult
+	names        []string // of the sub-aggregations
+}
+
+/* Returns the result of the sub-aggregation of the given name, or nil. */
+func (b *AggregationBucket) Result(name string) AggregationResult {
+	for i, n := range b.names {
+		if n == name {
+			return b.Aggregations[i]
+		}
+	}
+	return nil
+}
+
+func (b *AggregationBucket) String() string {
+	var buf bytes.Buffer
+	switch k := b.Key.(type) {
+	case time.Time:
+		buf.WriteString(k.Format(time.RFC3339))
+	default:
+		fmt.Fprint(&buf, k)
+	}
+	fmt.Fprintf(&buf, " (%v)", b.Count)
+	if len(b.Aggregations) > 0 {
+		fmt.Fprint(&buf, b.Aggregations)
+	}
+	return buf.String()
+}
+
+/* The buckets of a bucket aggregation. */
+type BucketsResult struct {
+	Name    string
+	Buckets []*AggregationBucket
+}
+
+func (r *BucketsResult) String() string {
+	return fmt.Sprintf("%v%v", r.Name, r.Buckets)
+}
+
+/*
+The aggregators of the sub-aggregations of a bucket, created with the
+bucket on its first document, so that a bucket aggregation only
+computes the sub-aggregations of buckets having documents.
+*/
+type bucketAggregators struct {
+	count int
+	subs  []Aggregator
+}
+
+/* The buckets of a bucket aggregator, and their sub-aggregators. */
+type bucketsAggregator struct {
+	subAggs []Aggregation
+	ctx     *index.AtomicReaderContext
+	buckets []*bucketAggregators
+}
+
+func (a *bucketsAggregator) newBucket() (*bucketAggregators, error) {
+	ans := &bucketAggregators{subs: make([]Aggregator, len(a.subAggs))}
+	for i, sub := range a.subAggs {
+		ans.subs[i] = sub.newAggregator()
+		if a.ctx != nil {
+			if err := ans.subs[i].SetNextReader(a.ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	a.buckets = append(a.buckets, ans)
+	return ans, nil
+}
+
+/*
+Moves the sub-aggregators of all buckets to the next segment, which
+costs a FieldValueSource.Values() per bucket and segment.
+*/
+func (a *bucketsAggregator) setNextReader(ctx *index.AtomicReaderContext) error {
+	a.ctx = ctx
+	for _, b := range a.buckets {
+		for _, sub := range b.subs {
+			if err := sub.SetNextReader(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (a *bucketsAggregator) collect(b *bucketAggregators, doc int) error {
+	b.count++
+	for _, sub := range b.subs {
+		if err := sub.Collect(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *bucketsAggregator) bucket(key interface{}, b *bucketAggregators) *AggregationBucket {
+	ans := &AggregationBucket{Key: key, Count: b.count, Aggregations: make([]AggregationResult, len(b.subs))}
+	for i, sub := range b.subs {
+		ans.Aggregations[i] = sub.Result()
+		ans.names = append(ans.names, a.subAggs[i].Name())
+	}
+	return ans
+}
+
+/*
+Bucket aggregation of the size most frequent values of a field, from
+its sorted doc values or its indexed terms, as TermsAggregationCollector.
+A document is in the bucket of each of its values.
+*/
+type TermsAggregation struct {
+	name, field string
+	size        int
+	subAggs     []Aggregation
+}
+
+func NewTermsAggregation(name, field string, size int) *TermsAggregation {
+	assert2(size > 0, "size must be > 0 (got %v)", size)
+	return &TermsAggregation{name: name, field: field, size: size}
+}
+
+func (a *TermsAggregation) Name() string {
+	return a.name
+}
+
+/* Adds a sub-aggregation, computed per bucket. */
+func (a *TermsAggregation) Add(sub Aggregation) *TermsAggregation {
+	a.subAggs = append(a.subAggs, sub)
+	return a
+}
+
+func (a *TermsAggregation) newAggregator() Aggregator {
+	return &termsAggregator{
+		TermsAggregation: a,
+		buckets:          &bucketsAggregator{subAggs: a.subAggs},
+		byTerm:           make(map[string]*bucketAggregators),
+	}
+}
+
+type termsAggregator struct {
+	*TermsAggregation
+	buckets *bucketsAggregator
+	byTerm  map[string]*bucketAggregators
+	values  SortedSetDocValues
+	byOrd   []*bucketAggregators // of the current segment
+}
+
+func (a *termsAggregator) SetNextReader(ctx *index.AtomicReaderContext) (err error) {
+	a.values, err = index.SortedSetOrUninverted(ctx.Reader().(index.AtomicReader), a.field, nil)
+	if err != nil {
+		return err
+	}
+	a.byOrd = nil
+	if a.values != nil {
+		a.byOrd = make([]*bucketAggregators, a.values.ValueCount())
+	}
+	return a.buckets.setNextReader(ctx)
+}
+
+func (a *termsAggregator) Collect(doc int) error {
+	if a.values == nil {
+		return nil
+	}
+	a.values.SetDocument(doc)
+	for ord := a.values.NextOrd(); ord != NO_MORE_ORDS; ord = a.values.NextOrd() {
+		b := a.byOrd[ord]
+		if b == nil {
+			term := string(a.values.LookupOrd(ord))
+			if b = a.byTerm[term]; b == nil {
+				var err error
+				if b, err = a.buckets.newBucket(); err != nil {
+					return err
+				}
+				a.byTerm[term] = b
+			}
+			a.byOrd[ord] = b
+		}
+		if err := a.buckets.collect(b, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Returns the buckets by decreasing count, then increasing term. */
+func (a *termsAggregator) Result() AggregationResult {
+	ans := &BucketsResult{Name: a.name}
+	for term, b := range a.byTerm {
+		ans.Buckets = append(ans.Buckets, a.buckets.bucket(term, b))
+	}
+	sort.Sort(bucketsByCount(ans.Buckets))
+	if len(ans.Buckets) > a.size {
+		ans.Buckets = ans.Buckets[:a.size]
+	}
+	return ans
+}
+
+type bucketsByCount []*AggregationBucket
+
+func (s bucketsByCount) Len() int      { return len(s) }
+func (s bucketsByCount) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bucketsByCount) Less(i, j int) bool {
+	if s[i].Count != s[j].Count {
+		return s[i].Count > s[j].Count
+	}
+	return s[i].Key.(string) < s[j].Key.(string)
+}
+
+/*
+Bucket aggregation of the calendar intervals of millisecond
+timestamps, as DateHistogramCollector, whose buckets are keyed by the
+time.Time of their start. Documents without a timestamp are in no
+bucket.
+*/
+type DateHistogramAggregation struct {
+	name     string
+	source   FieldValueSource
+	interval DateInterval
+	location *time.Location
+	offset   time.Duration
+	subAggs  []Aggregation
+}
+
+func NewDateHistogramAggregation(name string, source FieldValueSource, interval DateInterval) *DateHistogramAggregation {
+	return &DateHistogramAggregation{name: name, source: source, interval: interval, location: time.UTC}
+}
+
+func (a *DateHistogramAggregation) Name() string {
+	return a.name
+}
+
+/* Sets the location of the intervals; UTC by default. */
+func (a *DateHistogramAggregation) SetLocation(loc *time.Location) *DateHistogramAggregation {
+	assert(loc != nil)
+	a.location = loc
+	return a
+}
+
+/* Sets the offset of the start of the intervals; 0 by default. */
+func (a *DateHistogramAggregation) SetOffset(offset time.Duration) *DateHistogramAggregation {
+	a.offset = offset
+	return a
+}
+
+/* Adds a sub-aggregation, computed per bucket. */
+func (a *DateHistogramAggregation) Add(sub Aggregation) *DateHistogramAggregation {
+	a.subAggs = append(a.subAggs, sub)
+	return a
+}
+
+func (a *DateHistogramAggregation) newAggregator() Aggregator {
+	return &dateHistogramAggregator{
+		DateHistogramAggregation: a,
+		buckets:                  &bucketsAggregator{subAggs: a.subAggs},
+		byStart:                  make(map[int64]*bucketAggregators),
+	}
+}
+
+type dateHistogramAggregator struct {
+	*DateHistogramAggregation
+	buckets *bucketsAggregator
+	byStart map[int64]*bucketAggregators // unix nanos of the interval start
+	values  FieldValues
+}
+
+func (a *dateHistogramAggregator) SetNextReader(ctx *index.AtomicReaderContext) (err error) {
+	if a.values, err = a.source.Values(ctx); err != nil {
+		return err
+	}
+	return a.buckets.setNextReader(ctx)
+}
+
+func (a *dateHistogramAggregator) Collect(doc int) error {
+	v, err := a.values(doc)
+	if err != nil || v == nil {
+		return err
+	}
+	t, err := timestampOf(v)
+	if err != nil {
+		return err
+	}
+	start := a.interval.round(t.In(a.location).Add(-a.offset)).UnixNano()
+	b := a.byStart[start]
+	if b == nil {
+		if b, err = a.buckets.newBucket(); err != nil {
+			return err
+		}
+		a.byStart[start] = b
+	}
+	return a.buckets.collect(b, doc)
+}
+
+/* Returns the buckets by increasing start. */
+func (a *dateHistogramAggregator) Result() AggregationResult {
+	starts := make([]int64, 0, len(a.byStart))
+	for start := range a.byStart {
+		starts = append(starts, start)
+	}
+	sort.Sort(int64s(starts))
+	ans := &BucketsResult{Name: a.name, Buckets: make([]*AggregationBucket, len(starts))}
+	for i, start := range starts {
+		key := time.Unix(0, start).In(a.location).Add(a.offset)
+		ans.Buckets[i] = a.buckets.bucket(key, a.byStart[start])
+	}
+	return ans
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+)
+
+// search/AutomatonQuery.java
+
+/*
+A Query that will match terms against a finite-state machine.
+
+This query will match documents that contain terms accepted by a given
+finite-state machine. The automaton can be constructed with the
+automaton API directly, or more conveniently with a RegexpQuery or a
+PrefixQuery.
+
+When the query is executed, it will create an equivalent DFA of the
+finite-state machine, and will enumerate the term dictionary in an
+intelligent way to reduce the number of comparisons.
+*/
+type AutomatonQuery struct {
+	*MultiTermQuery
+	compiled *automaton.CompiledAutomaton
+	// term containing the field, and possibly some pattern structure
+	term *index.Term
+}
+
+/*
+Creates a new AutomatonQuery from an Automaton. term contains the
+field, and possibly some pattern structure; it is only used for
+ToString().
+*/
+func NewAutomatonQuery(term *index.Term, a *automaton.Automaton) *AutomatonQuery {
+	ans := newAutomatonQuery(term, a)
+	ans.MultiTermQuery = newMultiTermQuery(term.Field, ans)
+	return ans
+}
+
+/* Creates the query, without its MultiTermQuery, set by sub types. */
+func newAutomatonQuery(term *index.Term, a *automaton.Automaton) *AutomatonQuery {
+	return &AutomatonQuery{
+		compiled: automaton.NewCompiledAutomaton(a),
+		term:     term,
+	}
+}
+
+func (q *AutomatonQuery) TermsEnum(terms Terms) TermsEnum {
+	return terms.Intersect(q.compiled, nil)
+}
+
+func (q *AutomatonQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteString(":")
+	}
+	fmt.Fprintf(&buf, "{AutomatonQuery %v}", string(q.term.Bytes))
+	return withBoost(&buf, q.Boost())
+}
+
+/* Appends the boost to buf, unless it is 1, and returns its content. */
+func withBoost(buf *bytes.Buffer, boost float32) string {
+	if boost != 1.0 {
+		fmt.Fprintf(buf, "^%v", boost)
+	}
+	return buf.String()
+}
+
+// search/RegexpQuery.java
+
+/*
+A fast regular expression query based on the automaton package.
+
+Comparisons are fast: the term dictionary is enumerated in an
+intelligent way, to avoid comparisons, much like a PrefixQuery does.
+The syntax is that of automaton.RegExp, e.g. "ba[a-z]+" or "(cat|dog)".
+*/
+type RegexpQuery struct {
+	*AutomatonQuery
+}
+
+/* Constructs a query for terms matching term, a regular expression. */
+func NewRegexpQuery(term *index.Term) *RegexpQuery {
+	re := automaton.NewRegExp(string(term.Bytes))
+	ans := &RegexpQuery{newAutomatonQuery(term, re.ToAutomaton())}
+	ans.MultiTermQuery = newMultiTermQuery(term.Field, ans)
+	return ans
+}
+
+func (q *RegexpQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteString(":")
+	}
+	fmt.Fprintf(&buf, "/%v/", string(q.term.Bytes))
+	return withBoost(&buf, q.Boost())
+}
+
+// search/PrefixQuery.java
+
+/*
+A Query that matches documents containing terms with a specified
+prefix. A PrefixQuery is built by QueryParser for input like app*.
+*/
+type PrefixQuery struct {
+	*AutomatonQuery
+}
+
+/* Constructs a query for terms starting with prefix. */
+func NewPrefixQuery(prefix *index.Term) *PrefixQuery {
+	ans := &PrefixQuery{newAutomatonQuery(prefix, automaton.MakePrefix(string(prefix.Bytes)))}
+	ans.MultiTermQuery = newMultiTermQuery(prefix.Field, ans)
+	return ans
+}
+
+/* Returns the prefix of this query. */
+func (q *PrefixQuery) Prefix() *index.Term {
+	return q.term
+}
+
+func (q *PrefixQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.term.Field != field {
+		buf.WriteString(q.term.Field)
+		buf.WriteString(":")
+	}
+	fmt.Fprintf(&buf, "%v*", string(q.term.Bytes))
+	return withBoost(&buf, q.Boost())
+}
@@ -32,6 +32,14 @@ func NewBooleanClause(query Query, occur Occur) *BooleanClause {
 	}
 }
 
+func (c *BooleanClause) Query() Query {
+	return c.query
+}
+
+func (c *BooleanClause) Occur() Occur {
+	return c.occur
+}
+
 func (c *BooleanClause) IsProhibited() bool {
 	return c.occur == MUST_NOT
 }
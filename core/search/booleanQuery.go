@@ -2,6 +2,7 @@ package search
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/util"
 )
@@ -27,6 +28,15 @@ func NewBooleanQueryDisableCoord(disableCoord bool) *BooleanQuery {
 	return ans
 }
 
+/* Returns a shallow copy, with its own list of clauses. */
+func (q *BooleanQuery) clone() *BooleanQuery {
+	ans := NewBooleanQueryDisableCoord(q.disableCoord)
+	ans.clauses = append([]*BooleanClause(nil), q.clauses...)
+	ans.minNrShouldMatch = q.minNrShouldMatch
+	ans.SetBoost(q.Boost())
+	return ans
+}
+
 func (q *BooleanQuery) Add(query Query, occur Occur) {
 	q.AddClause(NewBooleanClause(query, occur))
 }
@@ -36,6 +46,26 @@ func (q *BooleanQuery) AddClause(clause *BooleanClause) {
 	q.clauses = append(q.clauses, clause)
 }
 
+/*
+Specifies a minimum number of the optional clauses which must be
+satisfied. By default no optional clauses are necessary for a match
+(unless there are no required clauses). If this method is used, then
+the specified number of clauses is required.
+*/
+func (q *BooleanQuery) SetMinimumNumberShouldMatch(min int) {
+	q.minNrShouldMatch = min
+}
+
+/* Gets the minimum number of the optional clauses which must be satisfied. */
+func (q *BooleanQuery) MinimumNumberShouldMatch() int {
+	return q.minNrShouldMatch
+}
+
+/* Returns the clauses of this query; they must not be modified. */
+func (q *BooleanQuery) Clauses() []*BooleanClause {
+	return q.clauses
+}
+
 type BooleanWeight struct {
 	owner        *BooleanQuery
 	similarity   Similarity
@@ -95,17 +125,81 @@ func (w *BooleanWeight) Normalize(norm, topLevelBoost float32) {
 }
 
 func (w *BooleanWeight) Explain(context *index.AtomicReaderContext, doc int) (Explanation, error) {
-	panic("not implemented yet")
+	minShouldMatch := w.owner.minNrShouldMatch
+	sumExpl := newEmptyComplexExplanation()
+	sumExpl.description = "sum of:"
+	coord, shouldMatchCount := 0, 0
+	var sum float32
+	fail := false
+	for i, subWeight := range w.weights {
+		c := w.owner.clauses[i]
+		// no scorer is created, as a non-matching clause explains why
+		e, err := subWeight.Explain(context, doc)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsMatch() {
+			if !c.IsProhibited() {
+				sumExpl.addDetail(e)
+				sum += e.Value()
+				coord++
+			} else {
+				r := newExplanation(0, fmt.Sprintf("match on prohibited clause (%v)", c.query))
+				r.addDetail(e)
+				sumExpl.addDetail(r)
+				fail = true
+			}
+			if c.occur == SHOULD {
+				shouldMatchCount++
+			}
+		} else if c.IsRequired() {
+			r := newExplanation(0, fmt.Sprintf("no match on required clause (%v)", c.query))
+			r.addDetail(e)
+			sumExpl.addDetail(r)
+			fail = true
+		}
+	}
+	if fail {
+		sumExpl.match = false
+		sumExpl.value = 0
+		sumExpl.description = "Failure to meet condition(s) of required/prohibited clause(s)"
+		return sumExpl, nil
+	} else if shouldMatchCount < minShouldMatch {
+		sumExpl.match = false
+		sumExpl.value = 0
+		sumExpl.description = fmt.Sprintf("Failure to match minimum number of optional clauses: %v", minShouldMatch)
+		return sumExpl, nil
+	}
+
+	sumExpl.match = coord > 0
+	sumExpl.value = sum
+
+	coordFactor := float32(1)
+	if !w.disableCoord {
+		coordFactor = w.coord(coord, w.maxCoord)
+	}
+	if coordFactor == 1 {
+		return sumExpl, nil // eliminate wrapper
+	}
+	ans := newComplexExplanation(sumExpl.IsMatch(), sum*coordFactor, "product of:")
+	ans.addDetail(sumExpl)
+	ans.addDetail(newExplanation(coordFactor, fmt.Sprintf("coord(%v/%v)", coord, w.maxCoord)))
+	return ans, nil
 }
 
 func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 	scoreDocsInOrder bool, acceptDocs util.Bits) (BulkScorer, error) {
 
-	if scoreDocsInOrder || w.owner.minNrShouldMatch > 1 {
-		panic("not implemented yet")
+	if !w.IsScoresDocsOutOfOrder() || scoreDocsInOrder {
+		// in-order scoring, e.g. leap-frogging the required clauses
+		scorer, err := w.Scorer(context, acceptDocs)
+		if scorer == nil || err != nil {
+			return nil, err
+		}
+		return newDefaultScorer(scorer), nil
 	}
 
-	var prohibited, optional []BulkScorer
+	var required, prohibited, optional []BulkScorer
 	for i, subWeight := range w.weights {
 		c := w.owner.clauses[i]
 		subScorer, err := subWeight.BulkScorer(context, false, acceptDocs)
@@ -117,7 +211,50 @@ func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 				return nil, nil
 			}
 		} else if c.IsRequired() {
-			panic("not implemented yet")
+			required = append(required, subScorer)
+		} else if c.IsProhibited() {
+			prohibited = append(prohibited, subScorer)
+		} else {
+			optional = append(optional, subScorer)
+		}
+	}
+
+	if len(required) == 0 && len(optional) == 0 {
+		// no required and optional clauses.
+		return nil, nil
+	}
+
+	return newBooleanScorer(w, w.disableCoord, w.owner.minNrShouldMatch,
+		required, optional, prohibited, w.maxCoord), nil
+}
+
+/* Returns true if all clauses are required. */
+func (w *BooleanWeight) isPureConjunction() bool {
+	for _, c := range w.owner.clauses {
+		if !c.IsRequired() {
+			return false
+		}
+	}
+	return len(w.owner.clauses) > 0
+}
+
+/* Returns an in-order scorer, as BooleanScorer2 in Lucene. */
+func (w *BooleanWeight) Scorer(context *index.AtomicReaderContext,
+	acceptDocs util.Bits) (Scorer, error) {
+
+	var required, prohibited, optional []Scorer
+	for i, subWeight := range w.weights {
+		c := w.owner.clauses[i]
+		subScorer, err := subWeight.Scorer(context, acceptDocs)
+		if err != nil {
+			return nil, err
+		}
+		if subScorer == nil {
+			if c.IsRequired() {
+				return nil, nil
+			}
+		} else if c.IsRequired() {
+			required = append(required, subScorer)
 		} else if c.IsProhibited() {
 			prohibited = append(prohibited, subScorer)
 		} else {
@@ -125,26 +262,127 @@ func (w *BooleanWeight) BulkScorer(context *index.AtomicReaderContext,
 		}
 	}
 
-	return newBooleanScorer(w, w.disableCoord, w.owner.minNrShouldMatch, optional, prohibited, w.maxCoord), nil
+	// scorer simplifications:
+	minShouldMatch := w.owner.minNrShouldMatch
+	if len(optional) == minShouldMatch {
+		// any optional clauses are in fact required
+		required = append(required, optional...)
+		optional = nil
+		minShouldMatch = 0
+	}
+
+	if len(required) == 0 && len(optional) == 0 {
+		// no required and optional clauses.
+		return nil, nil
+	} else if len(optional) < minShouldMatch {
+		// either >1 req scorer, or there are 0 req scorers and at least 1
+		// optional scorer. Therefore if there are not enough optional scorers
+		// no documents will be matched by the query
+		return nil, nil
+	}
+
+	// three cases: conjunction, disjunction, or mix
+
+	// pure conjunction
+	if len(optional) == 0 {
+		return w.excl(w.req(required, w.disableCoord), prohibited), nil
+	}
+
+	// pure disjunction
+	if len(required) == 0 {
+		return w.excl(w.opt(optional, minShouldMatch, w.disableCoord), prohibited), nil
+	}
+
+	// conjunction-disjunction mix:
+	// we create the required and optional pieces with coord disabled, and
+	// then combine the two: if minNrShouldMatch > 0, then it's a
+	// conjunction: because the optional side must match. otherwise it's
+	// required + optional, factoring the number of optional terms into
+	// the coord calculation
+	ans := newReqOptSumScorer(w, w.excl(w.req(required, true), prohibited),
+		w.opt(optional, minShouldMatch, true))
+	ans.optRequired = minShouldMatch > 0
+	if !w.disableCoord {
+		ans.coord, ans.reqCount = w.coords(), len(required)
+	}
+	return ans, nil
+}
+
+/* Returns the coord factors, by number of matching clauses. */
+func (w *BooleanWeight) coords() []float32 {
+	ans := make([]float32, w.maxCoord+1)
+	for i := range ans {
+		ans[i] = w.coord(i, w.maxCoord)
+	}
+	return ans
+}
+
+func (w *BooleanWeight) req(required []Scorer, disableCoord bool) Scorer {
+	if len(required) == 1 {
+		if !disableCoord && w.maxCoord > 1 {
+			return &boostedScorer{required[0], w.coord(1, w.maxCoord)}
+		}
+		return required[0]
+	}
+	coord := float32(1)
+	if !disableCoord {
+		coord = w.coord(len(required), w.maxCoord)
+	}
+	return newConjunctionScorer(w, required, coord)
+}
+
+func (w *BooleanWeight) excl(main Scorer, prohibited []Scorer) Scorer {
+	switch len(prohibited) {
+	case 0:
+		return main
+	case 1:
+		return newReqExclScorer(w, main, prohibited[0])
+	default:
+		coord := make([]float32, len(prohibited)+1)
+		for i := range coord {
+			coord[i] = 1
+		}
+		return newReqExclScorer(w, main, newDisjunctionSumScorer(w, prohibited, coord, 0))
+	}
+}
+
+func (w *BooleanWeight) opt(optional []Scorer, minShouldMatch int, disableCoord bool) Scorer {
+	if len(optional) == 1 {
+		if !disableCoord && w.maxCoord > 1 {
+			return &boostedScorer{optional[0], w.coord(1, w.maxCoord)}
+		}
+		return optional[0]
+	}
+	var coord []float32
+	if disableCoord {
+		coord = make([]float32, len(optional)+1)
+		for i := range coord {
+			coord[i] = 1
+		}
+	} else {
+		coord = w.coords()
+	}
+	return newDisjunctionSumScorer(w, optional, coord, minShouldMatch)
 }
 
 func (w *BooleanWeight) IsScoresDocsOutOfOrder() bool {
+	if w.isPureConjunction() {
+		// ConjunctionScorer (in-order) will be used by BulkScorer()
+		return false
+	}
 	if w.owner.minNrShouldMatch > 1 {
 		// BS2 (in-order) will be used by scorer()
 		return false
 	}
-	optionalCount := 0
+	requiredCount := 0
 	for _, c := range w.owner.clauses {
 		if c.IsRequired() {
-			// BS2 (in-order) will be used by scorer()
-			return false
-		} else if !c.IsProhibited() {
-			optionalCount++
+			requiredCount++
 		}
 	}
-
-	if optionalCount == w.owner.minNrShouldMatch {
-		return false // BS2 (in-order) will be used, as this means conjunction
+	if requiredCount > MAX_REQUIRED_CLAUSES {
+		// BS2 (in-order) will be used by scorer()
+		return false
 	}
 
 	// scorer() will return an out-of-order scorer if requested.
@@ -156,12 +394,16 @@ func (q *BooleanQuery) CreateWeight(searcher *IndexSearcher) (Weight, error) {
 }
 
 func (q *BooleanQuery) Rewrite(reader index.IndexReader) Query {
-	if q.minNrShouldMatch == 0 && len(q.clauses) == 1 {
-		panic("not implemented yet")
+	if q.minNrShouldMatch == 0 && len(q.clauses) == 1 { // optimize 1-clause queries
+		// Queries cannot be cloned to carry the boost over, so a boosted
+		// clause is kept wrapped, which scores the same.
+		if c := q.clauses[0]; !c.IsProhibited() && q.Boost() == 1 { // just return clause
+			return c.query.Rewrite(reader) // rewrite first
+		}
 	}
 
 	var clone *BooleanQuery // recursively rewrite
-	for _, c := range q.clauses {
+	for i, c := range q.clauses {
 		if query := c.query.Rewrite(reader); query != c.query {
 			// clause rewrote: must clone
 			if clone == nil {
@@ -169,9 +411,9 @@ func (q *BooleanQuery) Rewrite(reader index.IndexReader) Query {
 				// initialize it if a rewritten clause differs from the
 				// original clause (and hasn't been initialized already). If
 				// nothing difers, the clone isn't needlessly created
-				panic("not implemented yet")
+				clone = q.clone()
 			}
-			panic("not implemented yet")
+			clone.clauses[i] = NewBooleanClause(query, c.occur)
 		}
 	}
 	if clone != nil {
@@ -216,11 +458,11 @@ func (q *BooleanQuery) ToString(field string) string {
 	}
 
 	if q.minNrShouldMatch > 0 {
-		panic("not implemented yet")
+		fmt.Fprintf(&buf, "~%v", q.minNrShouldMatch)
 	}
 
 	if q.Boost() != 1 {
-		panic("not implemented yet")
+		fmt.Fprintf(&buf, "^%v", q.Boost())
 	}
 
 	return buf.String()
@@ -89,6 +89,7 @@ func (t *BucketTable) newCollector(mask int) Collector {
 
 type SubScorer struct {
 	scorer     BulkScorer
+	required   bool
 	prohibited bool
 	collector  Collector
 	next       *SubScorer
@@ -98,10 +99,10 @@ type SubScorer struct {
 func newSubScorer(scorer BulkScorer, required, prohibited bool,
 	collector Collector, next *SubScorer) *SubScorer {
 
-	assert2(!required, "this scorer cannot handle required=true")
 	return &SubScorer{
 		scorer:     scorer,
 		more:       true,
+		required:   required,
 		prohibited: prohibited,
 		collector:  collector,
 		next:       next,
@@ -111,11 +112,18 @@ func newSubScorer(scorer BulkScorer, required, prohibited bool,
 /* Any time a prohibited clause matches we set bit 0: */
 const PROHIBITED_MASK = 1
 
+/*
+Each required clause gets a bit of its own, starting right after
+PROHIBITED_MASK, so at most 30 required clauses can be handled.
+*/
+const MAX_REQUIRED_CLAUSES = 30
+
 type BooleanScorer struct {
 	*BulkScorerImpl
 	scorers          *SubScorer
 	bucketTable      *BucketTable
 	coordFactors     []float32
+	requiredMask     int
 	minNrShouldMatch int
 	end              int
 	current          *Bucket
@@ -124,9 +132,11 @@ type BooleanScorer struct {
 
 func newBooleanScorer(weight *BooleanWeight,
 	disableCoord bool, minNrShouldMatch int,
-	optionalScorers, prohibitedScorers []BulkScorer,
+	requiredScorers, optionalScorers, prohibitedScorers []BulkScorer,
 	maxCoord int) *BooleanScorer {
 
+	assert2(len(requiredScorers) <= MAX_REQUIRED_CLAUSES,
+		"this scorer cannot handle more than %v required clauses", MAX_REQUIRED_CLAUSES)
 	ans := &BooleanScorer{
 		bucketTable:      newBucketTable(),
 		minNrShouldMatch: minNrShouldMatch,
@@ -134,6 +144,13 @@ func newBooleanScorer(weight *BooleanWeight,
 	}
 	ans.BulkScorerImpl = newBulkScorer(ans)
 
+	for i, scorer := range requiredScorers {
+		mask := PROHIBITED_MASK << uint(i+1)
+		ans.requiredMask |= mask
+		ans.scorers = newSubScorer(scorer, true, false,
+			ans.bucketTable.newCollector(mask), ans.scorers)
+	}
+
 	for _, scorer := range optionalScorers {
 		ans.scorers = newSubScorer(scorer, false, false,
 			ans.bucketTable.newCollector(0), ans.scorers)
@@ -144,7 +161,10 @@ func newBooleanScorer(weight *BooleanWeight,
 			ans.bucketTable.newCollector(PROHIBITED_MASK), ans.scorers)
 	}
 
-	ans.coordFactors = make([]float32, len(optionalScorers)+1)
+	// required clauses count towards coord as well, but never towards
+	// minNrShouldMatch:
+	ans.minNrShouldMatch += len(requiredScorers)
+	ans.coordFactors = make([]float32, len(requiredScorers)+len(optionalScorers)+1)
 	for i, _ := range ans.coordFactors {
 		if disableCoord {
 			ans.coordFactors[i] = 1
@@ -166,7 +186,8 @@ func (s *BooleanScorer) ScoreAndCollectUpto(collector Collector, max int) (more
 
 		for s.current != nil { // more queued
 			// check prohibited & required
-			if (s.current.bits & PROHIBITED_MASK) == 0 {
+			if (s.current.bits&PROHIBITED_MASK) == 0 &&
+				(s.current.bits&s.requiredMask) == s.requiredMask {
 
 				if s.current.doc >= max {
 					panic("not implemented yet")
@@ -234,7 +255,7 @@ func (s *FakeScorer) DocId() int               { return s.doc }
 func (s *FakeScorer) Freq() (int, error)       { return s.freq, nil }
 func (s *FakeScorer) NextDoc() (int, error)    { panic("FakeScorer doesn't support nextDoc()") }
 func (s *FakeScorer) Score() (float32, error)  { return s.score, nil }
+func (s *FakeScorer) Cost() int64              { return 1 }
 
-// func (s *FakeScorer) Cost() int64             { return 1 }
 // func (s *FakeScorer) Weight() Weight          { panic("not supported") }
 // func (s *FakeScorer) Children() []ChildScorer { panic("not supported") }
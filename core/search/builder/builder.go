@@ -0,0 +1,334 @@
+/*
+Package builder provides a fluent API to assemble query trees, e.g.
+
+	q := builder.Bool().
+		Must(builder.Term("content", "bat")).
+		Should(builder.Phrase("title", "bat", "recycling").Boost(2)).
+		Filter(builder.Range("date").Gte("2014").Lt("2015")).
+		Query()
+
+Each builder only accepts the options of its kind of query, and
+clauses are builders too, so that a tree is checked by the compiler
+as it is written. Options shared by all builders, e.g. Boost(), are
+implemented once with a type parameter, and return the concrete
+builder so that calls can be chained. Queries are only created by Query(), each call
+returning a new tree. An existing search.Query can be added with
+Wrap().
+*/
+package builder
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+/* Builds a query, e.g. a clause of a boolean query. */
+type QueryBuilder interface {
+	/* Returns a new query, as configured so far. */
+	Query() search.Query
+}
+
+/* Options shared by all builders, returning the builder B itself. */
+type boosted[B any] struct {
+	self  B
+	boost float32
+}
+
+func newBoosted[B any](self B) boosted[B] {
+	return boosted[B]{self, 1}
+}
+
+/* Multiplies the score of the query by boost. */
+func (b *boosted[B]) Boost(boost float32) B {
+	b.boost = boost
+	return b.self
+}
+
+/* Sets the boost of the query, unless it is the default, 1. */
+func (b *boosted[B]) apply(q search.Query) search.Query {
+	if b.boost != 1 {
+		q.SetBoost(b.boost)
+	}
+	return q
+}
+
+// Term
+
+/* Builds a TermQuery. */
+type TermBuilder struct {
+	boosted[*TermBuilder]
+	field, text string
+}
+
+/* Matches documents containing text in field. */
+func Term(field, text string) *TermBuilder {
+	ans := &TermBuilder{field: field, text: text}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+func (b *TermBuilder) Query() search.Query {
+	return b.apply(search.NewTermQuery(index.NewTerm(b.field, b.text)))
+}
+
+// Phrase
+
+/* Builds a PhraseQuery. */
+type PhraseBuilder struct {
+	boosted[*PhraseBuilder]
+	field string
+	terms []string
+	slop  int
+}
+
+/* Matches documents containing the terms in order and next to each other. */
+func Phrase(field string, terms ...string) *PhraseBuilder {
+	assert(len(terms) > 0)
+	ans := &PhraseBuilder{field: field, terms: terms}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+/*
+Allows sloppy matches, as search.PhraseQuery.SetSlop(): the slop is
+the number of moves of terms out of position, so that swapping two
+terms takes a slop of 2.
+*/
+func (b *PhraseBuilder) Slop(slop int) *PhraseBuilder {
+	assert(slop >= 0)
+	b.slop = slop
+	return b
+}
+
+func (b *PhraseBuilder) Query() search.Query {
+	q := search.NewPhraseQuery()
+	for _, term := range b.terms {
+		q.Add(index.NewTerm(b.field, term))
+	}
+	q.SetSlop(b.slop)
+	return b.apply(q)
+}
+
+// Prefix and regexp
+
+/* Builds a PrefixQuery. */
+type PrefixBuilder struct {
+	boosted[*PrefixBuilder]
+	field, prefix string
+}
+
+/* Matches documents containing terms starting with prefix in field. */
+func Prefix(field, prefix string) *PrefixBuilder {
+	ans := &PrefixBuilder{field: field, prefix: prefix}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+func (b *PrefixBuilder) Query() search.Query {
+	return b.apply(search.NewPrefixQuery(index.NewTerm(b.field, b.prefix)))
+}
+
+/* Builds a RegexpQuery. */
+type RegexpBuilder struct {
+	boosted[*RegexpBuilder]
+	field, regexp string
+}
+
+/* Matches documents containing terms matching regexp in field. */
+func Regexp(field, regexp string) *RegexpBuilder {
+	ans := &RegexpBuilder{field: field, regexp: regexp}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+func (b *RegexpBuilder) Query() search.Query {
+	return b.apply(search.NewRegexpQuery(index.NewTerm(b.field, b.regexp)))
+}
+
+// Range
+
+/*
+Builds a TermRangeQuery. Terms are compared as bytes, so that numbers
+and dates should be indexed with a fixed width, e.g. "2014-03-01".
+*/
+type RangeBuilder struct {
+	boosted[*RangeBuilder]
+	field                      string
+	lower, upper               []byte
+	includeLower, includeUpper bool
+}
+
+/* Matches documents containing terms of field in a range, open by default. */
+func Range(field string) *RangeBuilder {
+	ans := &RangeBuilder{field: field}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+/* Matches terms greater than term. */
+func (b *RangeBuilder) Gt(term string) *RangeBuilder {
+	b.lower, b.includeLower = []byte(term), false
+	return b
+}
+
+/* Matches terms greater than or equal to term. */
+func (b *RangeBuilder) Gte(term string) *RangeBuilder {
+	b.lower, b.includeLower = []byte(term), true
+	return b
+}
+
+/* Matches terms less than term. */
+func (b *RangeBuilder) Lt(term string) *RangeBuilder {
+	b.upper, b.includeUpper = []byte(term), false
+	return b
+}
+
+/* Matches terms less than or equal to term. */
+func (b *RangeBuilder) Lte(term string) *RangeBuilder {
+	b.upper, b.includeUpper = []byte(term), true
+	return b
+}
+
+func (b *RangeBuilder) Query() search.Query {
+	return b.apply(search.NewTermRangeQuery(b.field,
+		b.lower, b.upper, b.includeLower, b.includeUpper))
+}
+
+// Bool
+
+/* Builds a BooleanQuery. */
+type BoolBuilder struct {
+	boosted[*BoolBuilder]
+	clauses      []boolClause
+	disableCoord bool
+}
+
+type boolClause struct {
+	query  QueryBuilder
+	occur  search.Occur
+	filter bool
+}
+
+/* Matches documents according to the clauses added. */
+func Bool() *BoolBuilder {
+	ans := &BoolBuilder{}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+/* Adds clauses which must match, and are scored. */
+func (b *BoolBuilder) Must(clauses ...QueryBuilder) *BoolBuilder {
+	return b.add(search.MUST, false, clauses)
+}
+
+/*
+Adds clauses which should match, and are scored. At least one of them
+must match if there are no Must() nor Filter() clauses.
+*/
+func (b *BoolBuilder) Should(clauses ...QueryBuilder) *BoolBuilder {
+	return b.add(search.SHOULD, false, clauses)
+}
+
+/* Adds clauses which must not match. */
+func (b *BoolBuilder) MustNot(clauses ...QueryBuilder) *BoolBuilder {
+	return b.add(search.MUST_NOT, false, clauses)
+}
+
+/*
+Adds clauses which must match, but do not contribute to the score:
+they are added as required constant score queries with a zero boost.
+As other required clauses, they are counted in the coord factor,
+unless it is disabled.
+*/
+func (b *BoolBuilder) Filter(clauses ...QueryBuilder) *BoolBuilder {
+	return b.add(search.MUST, true, clauses)
+}
+
+func (b *BoolBuilder) add(occur search.Occur, filter bool, clauses []QueryBuilder) *BoolBuilder {
+	for _, c := range clauses {
+		assert(c != nil)
+		b.clauses = append(b.clauses, boolClause{c, occur, filter})
+	}
+	return b
+}
+
+/* Disables the coord factor, e.g. for clauses of synonyms. */
+func (b *BoolBuilder) DisableCoord() *BoolBuilder {
+	b.disableCoord = true
+	return b
+}
+
+func (b *BoolBuilder) Query() search.Query {
+	q := search.NewBooleanQueryDisableCoord(b.disableCoord)
+	for _, c := range b.clauses {
+		clause := c.query.Query()
+		if _, ok := c.query.(wrapped); c.filter && !ok {
+			clause = filterQuery(clause)
+		} else if c.filter {
+			clause = search.NewConstantScoreQuery(clause)
+			clause.SetBoost(0)
+		}
+		q.Add(clause, c.occur)
+	}
+	return b.apply(q)
+}
+
+/*
+Returns a query matching the docs of q with a zero score. Multi-term
+queries, e.g. ranges, are rewritten to a filter, as their terms need
+not be scored.
+*/
+func filterQuery(q search.Query) search.Query {
+	if mtq, ok := q.(interface {
+		SetRewriteMethod(search.RewriteMethod)
+	}); ok {
+		mtq.SetRewriteMethod(search.CONSTANT_SCORE_FILTER_REWRITE)
+	}
+	ans := search.NewConstantScoreQuery(q)
+	ans.SetBoost(0)
+	return ans
+}
+
+// Constant score
+
+/* Builds a ConstantScoreQuery. */
+type ConstantScoreBuilder struct {
+	boosted[*ConstantScoreBuilder]
+	query QueryBuilder
+}
+
+/* Matches the documents of query, all with the boost as score. */
+func ConstantScore(query QueryBuilder) *ConstantScoreBuilder {
+	ans := &ConstantScoreBuilder{query: query}
+	ans.boosted = newBoosted(ans)
+	return ans
+}
+
+func (b *ConstantScoreBuilder) Query() search.Query {
+	return b.apply(search.NewConstantScoreQuery(b.query.Query()))
+}
+
+// Wrap
+
+type wrapped struct {
+	query search.Query
+}
+
+/*
+Adds an existing query to a tree, e.g. one not supported by the
+builders yet. The same query is returned by each call to Query().
+*/
+func Wrap(query search.Query) QueryBuilder {
+	assert(query != nil)
+	return wrapped{query}
+}
+
+func (w wrapped) Query() search.Query {
+	return w.query
+}
+
+func assert(ok bool) {
+	if !ok {
+		panic("assert fail")
+	}
+}
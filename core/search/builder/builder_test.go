@@ -0,0 +1,101 @@
+package builder
+
+import (
+	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+/* Returns a function searching the top hits of the sample index. */
+func sampleSearcher(t *testing.T) (searchTop func(QueryBuilder) search.TopDocs, close func() error) {
+	d, err := store.OpenFSDirectory("../testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := search.NewIndexSearcher(r)
+	return func(q QueryBuilder) search.TopDocs {
+		docs, err := ss.SearchTop(q.Query(), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return docs
+	}, r.Close
+}
+
+func TestBoolFilter(t *testing.T) {
+	searchTop, close := sampleSearcher(t)
+	defer close()
+
+	bat := searchTop(Term("content", "bat"))
+	br := searchTop(Prefix("content", "br"))
+	if br.TotalHits == 0 || br.TotalHits == bat.TotalHits {
+		t.Fatalf("expect some docs with br* terms, got %v", br.TotalHits)
+	}
+	scores := make(map[int]float32)
+	for _, hit := range bat.ScoreDocs {
+		scores[hit.Doc] = hit.Score
+	}
+
+	// the filter restricts the docs, not their scores
+	filtered := searchTop(Bool().
+		Must(Term("content", "bat")).
+		Filter(Range("content").Gte("br").Lt("bs")))
+	if filtered.TotalHits != br.TotalHits {
+		t.Errorf("expect %v hits, got %v", br.TotalHits, filtered.TotalHits)
+	}
+	for _, hit := range filtered.ScoreDocs {
+		if hit.Score != scores[hit.Doc] {
+			t.Errorf("expect score %v of doc %v, got %v", scores[hit.Doc], hit.Doc, hit.Score)
+		}
+	}
+
+	excluded := searchTop(Bool().
+		Should(Term("content", "bat")).
+		MustNot(Wrap(search.NewPrefixQuery(index.NewTerm("content", "br")))))
+	if excluded.TotalHits != bat.TotalHits-br.TotalHits {
+		t.Errorf("expect %v hits, got %v", bat.TotalHits-br.TotalHits, excluded.TotalHits)
+	}
+}
+
+func TestPhraseSlop(t *testing.T) {
+	searchTop, close := sampleSearcher(t)
+	defer close()
+
+	exact := searchTop(Phrase("title", "fruit", "bat"))
+	if exact.TotalHits == 0 {
+		t.Fatal("expect some docs with the phrase")
+	}
+	// as with PhraseQuery, swapping two terms takes a slop of 2
+	for slop, expected := range []int{0, 0, exact.TotalHits} {
+		swapped := searchTop(Phrase("title", "bat", "fruit").Slop(slop))
+		if swapped.TotalHits != expected {
+			t.Errorf("expect %v hits with slop %v, got %v", expected, slop, swapped.TotalHits)
+		}
+	}
+
+	q := Phrase("title", "fruit", "bat").Slop(2).Boost(3).Query()
+	if pq, ok := q.(*search.PhraseQuery); !ok || pq.Slop() != 2 || pq.Boost() != 3 {
+		t.Errorf("expect a phrase query with slop 2 and boost 3, got %v", q)
+	}
+}
+
+func TestRangeString(t *testing.T) {
+	for _, c := range []struct {
+		q        QueryBuilder
+		expected string
+	}{
+		{Range("date").Gte("2014").Lt("2015"), "date:[2014 TO 2015}"},
+		{Range("date").Gt("2014").Boost(2), "date:{2014 TO *}^2"},
+		{Range("date").Lte("2015"), "date:{* TO 2015]"},
+	} {
+		if s := c.q.Query().ToString(""); s != c.expected {
+			t.Errorf("expect %v, got %v", c.expected, s)
+		}
+	}
+}
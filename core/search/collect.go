@@ -26,6 +26,14 @@ func newShardedScoreDoc(doc int, score float32, shardIndex int) *ScoreDoc {
 	return &ScoreDoc{score, doc, shardIndex}
 }
 
+/*
+Returns the position of the index of the hit among the shards merged
+by MergeShards(), or -1 if not merged.
+*/
+func (d *ScoreDoc) ShardIndex() int {
+	return d.shardIndex
+}
+
 func (d *ScoreDoc) String() string {
 	return fmt.Sprintf("doc=%v score=%v shardIndex=%v", d.Doc, d.Score, d.shardIndex)
 }
@@ -50,10 +58,43 @@ func (pq *PriorityQueue) updateTop() interface{} {
 	return pq.items[0]
 }
 
+// search/TotalHits.java
+
+/* How TopDocs.TotalHits relates to the actual number of hits. */
+type TotalHitsRelation int
+
+const (
+	// TotalHits is the exact number of hits
+	TOTAL_HITS_RELATION_EQUAL_TO = TotalHitsRelation(iota)
+	// TotalHits is a lower bound of the number of hits, as documents
+	// which could not make it to the top hits were skipped
+	TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO
+)
+
+func (r TotalHitsRelation) String() string {
+	switch r {
+	case TOTAL_HITS_RELATION_EQUAL_TO:
+		return "EQUAL_TO"
+	case TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO:
+		return "GREATER_THAN_OR_EQUAL_TO"
+	}
+	return fmt.Sprintf("TotalHitsRelation(%v)", int(r))
+}
+
 type TopDocs struct {
-	TotalHits int
-	ScoreDocs []*ScoreDoc
-	maxScore  float64
+	TotalHits         int
+	TotalHitsRelation TotalHitsRelation
+	ScoreDocs         []*ScoreDoc
+	maxScore          float64
+}
+
+/*
+Implemented by scorers which can skip the documents scoring less than
+a minimum, e.g. with WAND. Collectors set the minimum once such
+documents cannot make it to the top hits anymore.
+*/
+type MinCompetitiveScoreAware interface {
+	SetMinCompetitiveScore(minScore float32)
 }
 
 type Collector interface {
@@ -195,9 +236,13 @@ type TopScoreDocCollector struct {
 	pqTop   *ScoreDoc
 	docBase int
 	scorer  Scorer
+
+	totalHitsThreshold  int
+	totalHitsRelation   TotalHitsRelation
+	minCompetitiveScore float32
 }
 
-func newTocScoreDocCollector(numHits int) *TopScoreDocCollector {
+func newTocScoreDocCollector(numHits, totalHitsThreshold int) *TopScoreDocCollector {
 	docs := make([]interface{}, numHits)
 	for i, _ := range docs {
 		docs[i] = newScoreDoc(math.MaxInt32, -math.MaxFloat32)
@@ -215,14 +260,18 @@ func newTocScoreDocCollector(numHits int) *TopScoreDocCollector {
 
 	pqTop := heap.Pop(pq).(*ScoreDoc)
 	heap.Push(pq, pqTop)
-	c := &TopScoreDocCollector{pqTop: pqTop}
+	c := &TopScoreDocCollector{
+		pqTop:               pqTop,
+		totalHitsThreshold:  totalHitsThreshold,
+		minCompetitiveScore: -math.MaxFloat32,
+	}
 	c.abstractTopDocsCollector = newTopDocsCollector(c, pq)
 	return c
 }
 
 func (c *TopScoreDocCollector) newTopDocs(results []*ScoreDoc, start int) TopDocs {
 	if results == nil {
-		return TopDocs{0, []*ScoreDoc{}, math.NaN()}
+		return TopDocs{c.TotalHits, c.totalHitsRelation, []*ScoreDoc{}, math.NaN()}
 	}
 
 	// We need to compute maxScore in order to set it in TopDocs. If start == 0,
@@ -240,7 +289,7 @@ func (c *TopScoreDocCollector) newTopDocs(results []*ScoreDoc, start int) TopDoc
 		maxScore = float64(heap.Pop(pq).(ScoreDoc).Score)
 	}
 
-	return TopDocs{c.TotalHits, results, maxScore}
+	return TopDocs{c.TotalHits, c.totalHitsRelation, results, maxScore}
 }
 
 func (c *TopScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) {
@@ -249,34 +298,67 @@ func (c *TopScoreDocCollector) SetNextReader(ctx *index.AtomicReaderContext) {
 
 func (c *TopScoreDocCollector) SetScorer(scorer Scorer) {
 	c.scorer = scorer
+	// set the current minimum on the new scorer, if any
+	c.minCompetitiveScore = -math.MaxFloat32
+	c.updateMinCompetitiveScore(c.pqTop.Score)
+}
+
+/*
+Once more than totalHitsThreshold hits were counted, lets the scorer
+skip the documents scoring less than minScore, if it can, in which
+case the hits are no longer all counted.
+*/
+func (c *TopScoreDocCollector) updateMinCompetitiveScore(minScore float32) {
+	if c.TotalHits <= c.totalHitsThreshold || c.pqTop.Score == -math.MaxFloat32 ||
+		minScore <= c.minCompetitiveScore {
+		return
+	}
+	if s, ok := c.scorer.(MinCompetitiveScoreAware); ok {
+		s.SetMinCompetitiveScore(minScore)
+		c.minCompetitiveScore = minScore
+		c.totalHitsRelation = TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO
+	}
 }
 
-func NewTopScoreDocCollector(numHits int, after *ScoreDoc, docsScoredInOrder bool) TopDocsCollector {
+/*
+Returns a collector of the top numHits hits by score, which counts
+all hits up to totalHitsThreshold, and may then skip the documents
+which cannot make it to the top hits, if the scorer supports it (see
+MinCompetitiveScoreAware). TopDocs.TotalHitsRelation tells whether
+all hits were counted. A threshold of math.MaxInt32 counts all hits.
+*/
+func NewTopScoreDocCollectorWithThreshold(numHits int, after *ScoreDoc,
+	docsScoredInOrder bool, totalHitsThreshold int) TopDocsCollector {
+
+	assert2(totalHitsThreshold >= 0, "totalHitsThreshold must be >= 0, got %v", totalHitsThreshold)
 	if numHits < 0 {
 		panic("numHits must be > 0; please use TotalHitCountCollector if you just need the total hit count")
 	}
-
 	if docsScoredInOrder {
 		if after == nil {
-			return newInOrderTopScoreDocCollector(numHits)
+			return newInOrderTopScoreDocCollector(numHits, totalHitsThreshold)
 		}
 		panic("not implemented yet")
 		// TODO support paging
 	} else {
 		if after == nil {
-			return newOutOfOrderTopScoreDocCollector(numHits)
+			return newOutOfOrderTopScoreDocCollector(numHits, totalHitsThreshold)
 		}
 		panic("not implemented yet")
 	}
 }
 
+func NewTopScoreDocCollector(numHits int, after *ScoreDoc, docsScoredInOrder bool) TopDocsCollector {
+	return NewTopScoreDocCollectorWithThreshold(numHits, after, docsScoredInOrder, math.MaxInt32)
+}
+
 // Assumes docs are scored in order.
 type InOrderTopScoreDocCollector struct {
 	*TopScoreDocCollector
 }
 
-func newInOrderTopScoreDocCollector(numHits int) *InOrderTopScoreDocCollector {
-	return &InOrderTopScoreDocCollector{newTocScoreDocCollector(numHits)}
+func newInOrderTopScoreDocCollector(numHits, totalHitsThreshold int) *InOrderTopScoreDocCollector {
+	return &InOrderTopScoreDocCollector{newTocScoreDocCollector(numHits, totalHitsThreshold)}
 }
 
 func (c *InOrderTopScoreDocCollector) Collect(doc int) (err error) {
@@ -294,14 +376,21 @@ func (c *InOrderTopScoreDocCollector) Collect(doc int) (err error) {
 		// Since docs are returned in-order (i.e., increasing doc Id), a document
 		// with equal score to pqTop.score cannot compete since HitQueue favors
 		// documents with lower doc Ids. Therefore reject those docs too.
+		c.updateMinCompetitiveScore(nextUp(c.pqTop.Score))
 		return
 	}
 	c.pqTop.Doc = doc + c.docBase
 	c.pqTop.Score = float32(score)
 	c.pqTop = c.pq.updateTop().(*ScoreDoc)
+	c.updateMinCompetitiveScore(nextUp(c.pqTop.Score))
 	return
 }
 
+/* Returns the smallest float32 greater than f. */
+func nextUp(f float32) float32 {
+	return math.Nextafter32(f, math.MaxFloat32)
+}
+
 func (c *InOrderTopScoreDocCollector) AcceptsDocsOutOfOrder() bool {
 	return false
 }
@@ -310,9 +399,9 @@ type OutOfOrderTopScoreDocCollector struct {
 	*TopScoreDocCollector
 }
 
-func newOutOfOrderTopScoreDocCollector(numHits int) *OutOfOrderTopScoreDocCollector {
+func newOutOfOrderTopScoreDocCollector(numHits, totalHitsThreshold int) *OutOfOrderTopScoreDocCollector {
 	return &OutOfOrderTopScoreDocCollector{
-		TopScoreDocCollector: newTocScoreDocCollector(numHits),
+		TopScoreDocCollector: newTocScoreDocCollector(numHits, totalHitsThreshold),
 	}
 }
 
@@ -328,16 +417,20 @@ func (c *OutOfOrderTopScoreDocCollector) Collect(doc int) (err error) {
 	c.TotalHits++
 	if score < c.pqTop.Score {
 		// Doesn't compete w/ bottom entry in queue
+		c.updateMinCompetitiveScore(c.pqTop.Score)
 		return nil
 	}
 	doc += c.docBase
 	if score == c.pqTop.Score && doc > c.pqTop.Doc {
 		// Break tie in score by doc ID:
+		c.updateMinCompetitiveScore(c.pqTop.Score)
 		return nil
 	}
 	c.pqTop.Doc = doc
 	c.pqTop.Score = score
 	c.pqTop = c.pq.updateTop().(*ScoreDoc)
+	// docs out of order may tie with pqTop and still compete
+	c.updateMinCompetitiveScore(c.pqTop.Score)
 	return nil
 }
 
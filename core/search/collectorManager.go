@@ -0,0 +1,184 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+	"sort"
+	"sync"
+)
+
+// search/CollectorManager.java
+
+/*
+Creates the collectors of a search, and reduces their results, so that
+a search can be run by several goroutines, each collecting a slice of
+the segments with its own collector, without sharing state.
+*/
+type CollectorManager interface {
+	// Returns a new collector, for a slice of the segments.
+	NewCollector() (Collector, error)
+	// Merges the results of the collectors, once they have all been
+	// run, into the result of the search.
+	Reduce(collectors []Collector) (interface{}, error)
+}
+
+/*
+Collects the top hits by score of each slice, reduced to the TopDocs
+of the whole index. totalHitsThreshold applies to each slice (see
+NewTopScoreDocCollectorWithThreshold()).
+*/
+type TopScoreDocCollectorManager struct {
+	numHits            int
+	totalHitsThreshold int
+}
+
+func NewTopScoreDocCollectorManager(numHits, totalHitsThreshold int) *TopScoreDocCollectorManager {
+	return &TopScoreDocCollectorManager{numHits, totalHitsThreshold}
+}
+
+func (m *TopScoreDocCollectorManager) NewCollector() (Collector, error) {
+	// accepts docs in any order, as the weight is not known
+	return NewTopScoreDocCollectorWithThreshold(m.numHits, nil, false, m.totalHitsThreshold), nil
+}
+
+/* Returns the merged TopDocs of the collectors. */
+func (m *TopScoreDocCollectorManager) Reduce(collectors []Collector) (interface{}, error) {
+	shards := make([]TopDocs, len(collectors))
+	for i, c := range collectors {
+		shards[i] = c.(TopDocsCollector).TopDocs()
+	}
+	return MergeTopDocs(m.numHits, shards...), nil
+}
+
+// search/TopDocs.java#merge
+
+type scoreDocsByScore []*ScoreDoc
+
+func (a scoreDocsByScore) Len() int      { return len(a) }
+func (a scoreDocsByScore) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a scoreDocsByScore) Less(i, j int) bool {
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	return a[i].Doc < a[j].Doc
+}
+
+/*
+Returns the top topN hits of the given TopDocs, whose doc IDs must be
+of the same reader, e.g. of different slices of its segments. Ties
+are broken by doc ID. The total hits are summed, and are a lower
+bound if any of the TopDocs is.
+*/
+func MergeTopDocs(topN int, shards ...TopDocs) TopDocs {
+	var ans TopDocs
+	var hits []*ScoreDoc
+	for _, shard := range shards {
+		ans.TotalHits += shard.TotalHits
+		if shard.TotalHitsRelation == TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO {
+			ans.TotalHitsRelation = TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO
+		}
+		hits = append(hits, shard.ScoreDocs...)
+	}
+	sort.Sort(scoreDocsByScore(hits))
+	if len(hits) > topN {
+		hits = hits[:topN]
+	}
+	ans.ScoreDocs = hits
+	ans.maxScore = math.NaN()
+	if len(hits) > 0 {
+		ans.maxScore = float64(hits[0].Score)
+	}
+	return ans
+}
+
+/*
+Sets the maximum number of goroutines searching the segments of the
+index, each with its own collector, in SearchCollectorManager() and
+Search(). 1, the default, searches all segments with a single
+collector in the calling goroutine.
+*/
+func (ss *IndexSearcher) SetConcurrency(n int) {
+	assert2(n >= 1, "concurrency must be >= 1, got %v", n)
+	ss.concurrency = n
+}
+
+/* Returns the slices of segments searched concurrently, one per segment. */
+func (ss *IndexSearcher) slices() [][]*index.AtomicReaderContext {
+	ans := make([][]*index.AtomicReaderContext, len(ss.leafContexts))
+	for i, leaf := range ss.leafContexts {
+		ans[i] = []*index.AtomicReaderContext{leaf}
+	}
+	return ans
+}
+
+/*
+Searches with the collectors of the manager, applying the filter if
+non-nil, and returns their reduced result. Slices of the segments are
+searched concurrently, as set by SetConcurrency().
+*/
+func (ss *IndexSearcher) SearchCollectorManager(q Query, f Filter, m CollectorManager) (ans interface{}, err error) {
+	return ss.SearchCollectorManagerContext(context.Background(), q, f, m)
+}
+
+/*
+Same as SearchCollectorManager(), with the spans of the search started
+under the span of ctx, if any.
+*/
+func (ss *IndexSearcher) SearchCollectorManagerContext(ctx context.Context, q Query, f Filter, m CollectorManager) (ans interface{}, err error) {
+	ctx, span := ss.startSpan(ctx, "IndexSearcher.search", q)
+	defer func() { span.End(err) }()
+	w, err := ss.spi.CreateNormalizedWeight(ctx, ss.spi.WrapFilter(q, f))
+	if err != nil {
+		return nil, err
+	}
+	return ss.searchWithManager(ctx, w, m)
+}
+
+func (ss *IndexSearcher) searchWithManager(ctx context.Context, w Weight, m CollectorManager) (interface{}, error) {
+	slices := ss.slices()
+	if ss.concurrency <= 1 || len(slices) <= 1 {
+		c, err := m.NewCollector()
+		if err != nil {
+			return nil, err
+		}
+		if err = ss.spi.SearchLWC(ctx, ss.leafContexts, w, c); err != nil {
+			return nil, err
+		}
+		return m.Reduce([]Collector{c})
+	}
+
+	collectors := make([]Collector, len(slices))
+	for i := range slices {
+		c, err := m.NewCollector()
+		if err != nil {
+			return nil, err
+		}
+		collectors[i] = c
+	}
+	errs := make([]error, len(slices))
+	sem := make(chan bool, ss.concurrency)
+	var wg sync.WaitGroup
+	for i, slice := range slices {
+		wg.Add(1)
+		sem <- true
+		go func(i int, slice []*index.AtomicReaderContext) {
+			defer func() {
+				if e := recover(); e != nil {
+					errs[i] = fmt.Errorf("searching slice %v: %v", i, e)
+				}
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = ss.spi.SearchLWC(ctx, slice, w, collectors[i])
+		}(i, slice)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m.Reduce(collectors)
+}
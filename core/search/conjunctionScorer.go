@@ -0,0 +1,145 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"sort"
+)
+
+// search/ConjunctionScorer.java
+
+type docsAndFreqs struct {
+	scorer Scorer
+	cost   int64
+	doc    int
+}
+
+func newDocsAndFreqs(scorer Scorer) *docsAndFreqs {
+	return &docsAndFreqs{scorer: scorer, cost: scorer.Cost(), doc: -1}
+}
+
+type byCost []*docsAndFreqs
+
+func (a byCost) Len() int           { return len(a) }
+func (a byCost) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byCost) Less(i, j int) bool { return a[i].cost < a[j].cost }
+
+/*
+Scorer for conjunctions, sets of queries, all of which are required.
+
+Sub-scorers are intersected by leap-frogging, led by the cheapest one
+according to DocIdSetIterator.Cost(), i.e. the one expected to match
+the fewest documents, so that the others are only advanced to its
+candidates. Custom scorers join the optimization by returning a
+sensible estimate from Cost().
+*/
+type ConjunctionScorer struct {
+	*abstractScorer
+	lastDoc      int
+	docsAndFreqs []*docsAndFreqs
+	lead         *docsAndFreqs
+	coord        float32
+}
+
+func newConjunctionScorer(weight Weight, scorers []Scorer, coord float32) *ConjunctionScorer {
+	ans := &ConjunctionScorer{
+		lastDoc:      -1,
+		docsAndFreqs: make([]*docsAndFreqs, len(scorers)),
+		coord:        coord,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	for i, scorer := range scorers {
+		ans.docsAndFreqs[i] = newDocsAndFreqs(scorer)
+	}
+	// Sort the array the first time to allow the least frequent
+	// DocsEnum to lead the matching.
+	sort.Stable(byCost(ans.docsAndFreqs))
+	ans.lead = ans.docsAndFreqs[0] // least frequent DocsEnum leads the intersection
+	return ans
+}
+
+func (s *ConjunctionScorer) doNext(doc int) (int, error) {
+	var err error
+	for {
+		// doc may already be NO_MORE_DOCS here, but we don't check
+		// explicitly since all scorers should advance to NO_MORE_DOCS,
+		// match, then return that value.
+		matched := true
+		for _, df := range s.docsAndFreqs[1:] {
+			// invariant: df.doc <= doc at this point. df.doc may already
+			// be equal to doc if we broke on the previous iteration and
+			// the advance on the lead scorer exactly matched.
+			if df.doc < doc {
+				if df.doc, err = df.scorer.Advance(doc); err != nil {
+					return 0, err
+				}
+				if df.doc > doc {
+					// DocsEnum beyond the current doc - advance lead to the
+					// new highest doc.
+					doc = df.doc
+					matched = false
+					break
+				}
+			}
+		}
+		if matched {
+			// success - all DocsEnums are on the same doc
+			return doc, nil
+		}
+		// advance head for next iteration
+		if doc, err = s.lead.scorer.Advance(doc); err != nil {
+			return 0, err
+		}
+		s.lead.doc = doc
+	}
+}
+
+func (s *ConjunctionScorer) Advance(target int) (doc int, err error) {
+	if s.lead.doc, err = s.lead.scorer.Advance(target); err != nil {
+		return 0, err
+	}
+	s.lastDoc, err = s.doNext(s.lead.doc)
+	return s.lastDoc, err
+}
+
+func (s *ConjunctionScorer) DocId() int {
+	return s.lastDoc
+}
+
+func (s *ConjunctionScorer) NextDoc() (doc int, err error) {
+	if s.lead.doc == NO_MORE_DOCS {
+		s.lastDoc = NO_MORE_DOCS
+		return s.lastDoc, nil
+	}
+	if s.lead.doc, err = s.lead.scorer.NextDoc(); err != nil {
+		return 0, err
+	}
+	s.lastDoc, err = s.doNext(s.lead.doc)
+	return s.lastDoc, err
+}
+
+func (s *ConjunctionScorer) Score() (float32, error) {
+	// TODO: sum into a double and cast to float if we ever send required clauses to BS1
+	var sum float32
+	for _, df := range s.docsAndFreqs {
+		score, err := df.scorer.Score()
+		if err != nil {
+			return 0, err
+		}
+		sum += score
+	}
+	return sum * s.coord, nil
+}
+
+func (s *ConjunctionScorer) Freq() (int, error) {
+	return len(s.docsAndFreqs), nil
+}
+
+/* Returns the cost of the lead, an upper bound of the matches. */
+func (s *ConjunctionScorer) Cost() int64 {
+	return s.lead.scorer.Cost()
+}
+
+func (s *ConjunctionScorer) String() string {
+	return fmt.Sprintf("ConjunctionScorer(%v)", s.weight)
+}
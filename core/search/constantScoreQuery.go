@@ -0,0 +1,234 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/ConstantScoreQuery.java
+
+/*
+A query that wraps another query or a filter and simply returns a
+constant score equal to the query boost for every document that
+matches the filter or query. For queries, it therefore simply strips
+of all scores and returns a constant one.
+*/
+type ConstantScoreQuery struct {
+	*AbstractQuery
+	filter Filter
+	query  Query
+}
+
+/*
+Strips off scores from the passed in Query. The hits will get a
+constant score dependent on the boost factor of this query.
+*/
+func NewConstantScoreQuery(query Query) *ConstantScoreQuery {
+	assert2(query != nil, "Query may not be nil")
+	ans := &ConstantScoreQuery{query: query}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Wraps a Filter as a Query. The hits will get a constant score
+dependent on the boost factor of this query.
+*/
+func NewConstantScoreQueryFromFilter(filter Filter) *ConstantScoreQuery {
+	assert2(filter != nil, "Filter may not be nil")
+	ans := &ConstantScoreQuery{filter: filter}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Returns the encapsulated filter, or nil if a query is wrapped. */
+func (q *ConstantScoreQuery) Filter() Filter {
+	return q.filter
+}
+
+/* Returns the encapsulated query, or nil if a filter is wrapped. */
+func (q *ConstantScoreQuery) Query() Query {
+	return q.query
+}
+
+func (q *ConstantScoreQuery) Rewrite(r index.IndexReader) Query {
+	if q.query != nil {
+		if rewritten := q.query.Rewrite(r); rewritten != q.query {
+			ans := NewConstantScoreQuery(rewritten)
+			ans.SetBoost(q.Boost())
+			return ans
+		}
+	}
+	return q
+}
+
+func (q *ConstantScoreQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	ans := &constantWeight{owner: q}
+	if q.query != nil {
+		w, err := q.query.CreateWeight(ss)
+		if err != nil {
+			return nil, err
+		}
+		ans.innerWeight = w
+	}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+func (q *ConstantScoreQuery) ToString(field string) string {
+	var inner interface{} = q.filter
+	if q.query != nil {
+		inner = q.query.ToString(field)
+	}
+	ans := fmt.Sprintf("ConstantScore(%v)", inner)
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
+
+type constantWeight struct {
+	*WeightImpl
+	owner       *ConstantScoreQuery
+	innerWeight Weight
+	queryNorm   float32
+	queryWeight float32
+}
+
+func (w *constantWeight) ValueForNormalization() float32 {
+	// we calculate sumOfSquaredWeights of the inner weight, but ignore
+	// it (just to initialize everything)
+	if w.innerWeight != nil {
+		w.innerWeight.ValueForNormalization()
+	}
+	w.queryWeight = w.owner.Boost()
+	return w.queryWeight * w.queryWeight
+}
+
+func (w *constantWeight) Normalize(norm, topLevelBoost float32) {
+	w.queryNorm = norm * topLevelBoost
+	w.queryWeight = w.owner.Boost() * w.queryNorm
+	// we normalize the inner weight, but ignore it (just to initialize
+	// everything)
+	if w.innerWeight != nil {
+		w.innerWeight.Normalize(norm, topLevelBoost)
+	}
+}
+
+func (w *constantWeight) IsScoresDocsOutOfOrder() bool {
+	return w.innerWeight != nil && w.innerWeight.IsScoresDocsOutOfOrder()
+}
+
+func (w *constantWeight) BulkScorer(ctx *index.AtomicReaderContext,
+	scoreDocsInOrder bool, acceptDocs util.Bits) (BulkScorer, error) {
+
+	if w.owner.filter != nil {
+		return w.WeightImpl.BulkScorer(ctx, scoreDocsInOrder, acceptDocs)
+	}
+	// the wrapped query may only score docs in bulk, e.g. a disjunction
+	bs, err := w.innerWeight.BulkScorer(ctx, scoreDocsInOrder, acceptDocs)
+	if bs == nil || err != nil {
+		return nil, err
+	}
+	ans := &constantBulkScorer{bulkScorer: bs, weight: w}
+	ans.BulkScorerImpl = newBulkScorer(ans)
+	return ans, nil
+}
+
+func (w *constantWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	var disi DocIdSetIterator
+	if w.owner.filter != nil {
+		dis, err := w.owner.filter.DocIdSet(ctx, acceptDocs)
+		if dis == nil || err != nil {
+			return nil, err
+		}
+		if disi, err = dis.Iterator(); disi == nil || err != nil {
+			return nil, err
+		}
+	} else {
+		scorer, err := w.innerWeight.Scorer(ctx, acceptDocs)
+		if scorer == nil || err != nil {
+			return nil, err
+		}
+		disi = scorer
+	}
+	return newConstantScorer(disi, w), nil
+}
+
+func (w *constantWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	exists := false
+	if w.innerWeight != nil {
+		// not its scorer, which may only score docs in bulk
+		e, err := w.innerWeight.Explain(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		exists = e.IsMatch()
+	} else {
+		cs, err := w.Scorer(ctx, ctx.Reader().(index.AtomicReader).LiveDocs())
+		if err != nil {
+			return nil, err
+		}
+		if cs != nil {
+			got, err := cs.Advance(doc)
+			if err != nil {
+				return nil, err
+			}
+			exists = got == doc
+		}
+	}
+	if !exists {
+		return newComplexExplanation(false, 0,
+			fmt.Sprintf("%v doesn't match id %v", w.owner.ToString(""), doc)), nil
+	}
+	ans := newComplexExplanation(true, w.queryWeight,
+		fmt.Sprintf("%v, product of:", w.owner.ToString("")))
+	ans.addDetail(newExplanation(w.owner.Boost(), "boost"))
+	ans.addDetail(newExplanation(w.queryNorm, "queryNorm"))
+	return ans, nil
+}
+
+/* Scores the docs of an iterator, e.g. of a filter, with a constant. */
+type constantScorer struct {
+	DocIdSetIterator
+	*abstractScorer
+	score float32
+}
+
+func newConstantScorer(disi DocIdSetIterator, w *constantWeight) *constantScorer {
+	ans := &constantScorer{DocIdSetIterator: disi, score: w.queryWeight}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans
+}
+
+func (s *constantScorer) Score() (float32, error) {
+	assert(s.DocId() != NO_MORE_DOCS)
+	return s.score, nil
+}
+
+func (s *constantScorer) Freq() (int, error) {
+	return 1, nil
+}
+
+/* Scores the docs of the bulk scorer of the wrapped query with a constant. */
+type constantBulkScorer struct {
+	*BulkScorerImpl
+	bulkScorer BulkScorer
+	weight     *constantWeight
+}
+
+func (s *constantBulkScorer) ScoreAndCollectUpto(c Collector, max int) (bool, error) {
+	return s.bulkScorer.ScoreAndCollectUpto(&constantCollector{c, s.weight}, max)
+}
+
+/* Passes the collector a constant scorer instead of the wrapped query's. */
+type constantCollector struct {
+	Collector
+	weight *constantWeight
+}
+
+func (c *constantCollector) SetScorer(s Scorer) {
+	c.Collector.SetScorer(newConstantScorer(s, c.weight))
+}
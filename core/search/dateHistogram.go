@@ -0,0 +1,210 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"sort"
+	"time"
+)
+
+/* Calendar interval of the buckets of a DateHistogramCollector. */
+type DateInterval int
+
+const (
+	DATE_INTERVAL_HOUR = DateInterval(iota)
+	DATE_INTERVAL_DAY
+	DATE_INTERVAL_MONTH
+)
+
+func (i DateInterval) String() string {
+	switch i {
+	case DATE_INTERVAL_HOUR:
+		return "hour"
+	case DATE_INTERVAL_DAY:
+		return "day"
+	case DATE_INTERVAL_MONTH:
+		return "month"
+	}
+	return fmt.Sprintf("DateInterval(%v)", int(i))
+}
+
+/*
+Returns the start of the interval containing t, in t's location. Days
+and months start at local midnight, whatever their length in hours,
+e.g. 23 or 25 on daylight saving changes, and hours at the local
+hour, e.g. twice 1:00 when clocks go back from 2:00 to 1:00.
+*/
+func (i DateInterval) round(t time.Time) time.Time {
+	switch i {
+	case DATE_INTERVAL_HOUR:
+		// not time.Date(), which cannot tell apart repeated hours
+		return t.Add(-time.Duration(t.Minute())*time.Minute -
+			time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case DATE_INTERVAL_DAY:
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case DATE_INTERVAL_MONTH:
+		y, m, _ := t.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+	panic(fmt.Sprintf("unknown interval: %v", i))
+}
+
+/* Returns the start of the interval following the one starting at start. */
+func (i DateInterval) next(start time.Time) time.Time {
+	switch i {
+	case DATE_INTERVAL_HOUR:
+		return start.Add(time.Hour)
+	case DATE_INTERVAL_DAY:
+		return start.AddDate(0, 0, 1)
+	case DATE_INTERVAL_MONTH:
+		return start.AddDate(0, 1, 0)
+	}
+	panic(fmt.Sprintf("unknown interval: %v", i))
+}
+
+/* Number of matching documents in an interval starting at Key. */
+type DateBucket struct {
+	Key   time.Time
+	Count int
+}
+
+func (b *DateBucket) String() string {
+	return fmt.Sprintf("%v (%v)", b.Key.Format(time.RFC3339), b.Count)
+}
+
+/*
+Collector counting the matching documents per calendar interval of
+their timestamp, e.g. to chart hits over time. Timestamps are the
+milliseconds since the epoch of a FieldValueSource, e.g. a numeric
+doc values field; documents without one are counted as missing.
+
+Intervals are computed in a location, UTC by default, so that days
+and months start at local midnight. An offset shifts the start of
+every interval, e.g. 6 hours for days from 6:00 to 6:00.
+*/
+type DateHistogramCollector struct {
+	source   FieldValueSource
+	interval DateInterval
+	location *time.Location
+	offset   time.Duration
+	fillGaps bool
+	counts   map[int64]int // unix nanos of the interval start -> count
+	missing  int
+
+	values FieldValues
+	err    error // deferred from SetNextReader
+}
+
+func NewDateHistogramCollector(source FieldValueSource, interval DateInterval) *DateHistogramCollector {
+	return &DateHistogramCollector{
+		source:   source,
+		interval: interval,
+		location: time.UTC,
+		counts:   make(map[int64]int),
+	}
+}
+
+/* Sets the location of the intervals; UTC by default. */
+func (c *DateHistogramCollector) SetLocation(loc *time.Location) *DateHistogramCollector {
+	assert(loc != nil)
+	c.location = loc
+	return c
+}
+
+/* Sets the offset of the start of the intervals; 0 by default. */
+func (c *DateHistogramCollector) SetOffset(offset time.Duration) *DateHistogramCollector {
+	c.offset = offset
+	return c
+}
+
+/*
+Whether Buckets() returns empty buckets between the first and the
+last non-empty ones, e.g. for a chart with a point per interval;
+false by default.
+*/
+func (c *DateHistogramCollector) SetFillGaps(fillGaps bool) *DateHistogramCollector {
+	c.fillGaps = fillGaps
+	return c
+}
+
+func (c *DateHistogramCollector) SetScorer(s Scorer) {}
+
+func (c *DateHistogramCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	var err error
+	if c.values, err = c.source.Values(ctx); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *DateHistogramCollector) Collect(doc int) error {
+	if c.err != nil {
+		return c.err
+	}
+	v, err := c.values(doc)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		c.missing++
+		return nil
+	}
+	t, err := timestampOf(v)
+	if err != nil {
+		return err
+	}
+	c.counts[c.interval.round(t.In(c.location).Add(-c.offset)).UnixNano()]++
+	return nil
+}
+
+/* Returns the time of a value in milliseconds since the epoch. */
+func timestampOf(v interface{}) (time.Time, error) {
+	var millis int64
+	if n, ok := v.(int64); ok {
+		millis = n // exact, unlike float64 beyond 2^53
+	} else {
+		f, err := toFloat64(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		millis = int64(f)
+	}
+	return time.Unix(0, millis*int64(time.Millisecond)), nil
+}
+
+func (c *DateHistogramCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+/* Returns the buckets by increasing start, in the collector's location. */
+func (c *DateHistogramCollector) Buckets() []*DateBucket {
+	starts := make([]int64, 0, len(c.counts))
+	for start := range c.counts {
+		starts = append(starts, start)
+	}
+	sort.Sort(int64s(starts))
+
+	var ans []*DateBucket
+	for i, start := range starts {
+		t := time.Unix(0, start).In(c.location)
+		if c.fillGaps && i > 0 {
+			gap := c.interval.next(time.Unix(0, starts[i-1]).In(c.location))
+			for ; gap.Before(t); gap = c.interval.next(gap) {
+				ans = append(ans, &DateBucket{gap.Add(c.offset), 0})
+			}
+		}
+		ans = append(ans, &DateBucket{t.Add(c.offset), c.counts[start]})
+	}
+	return ans
+}
+
+/* Returns the number of matching documents without a timestamp. */
+func (c *DateHistogramCollector) Missing() int {
+	return c.missing
+}
+
+type int64s []int64
+
+func (a int64s) Len() int           { return len(a) }
+func (a int64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int64s) Less(i, j int) bool { return a[i] < a[j] }
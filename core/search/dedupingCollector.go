@@ -0,0 +1,155 @@
+package search
+
+import (
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+	"sort"
+)
+
+/*
+Collector keeping only the best scoring hit per value of a key field,
+e.g. a canonical URL, so that near-duplicate documents are not listed
+several times. Ties are broken by the lower doc id.
+
+The keys of each segment come from its SORTED or SORTED_SET doc
+values, or else are uninverted from its indexed terms; a document
+having several values is keyed by its first one. They are mapped to
+global ordinals once, when the collector is created for a reader, so
+that the best hit of a key is tracked in a single array across all
+segments. Documents without a key are never deduplicated.
+*/
+type DedupingCollector struct {
+	numHits int
+	leaves  []SortedSetDocValues
+	mapping *index.OrdinalMap // nil for a single segment
+
+	bestDocs   []int // per global ord, -1 if not matched
+	bestScores []float32
+	unkeyed    []*ScoreDoc
+	totalHits  int
+
+	segment int
+	docBase int
+	values  SortedSetDocValues
+	scorer  Scorer
+}
+
+/*
+Creates a collector of the numHits best scoring hits of the reader
+searched, with distinct values of field.
+*/
+func NewDedupingCollector(r index.IndexReader, field string, numHits int) (*DedupingCollector, error) {
+	assert2(numHits > 0, "numHits must be > 0 (got %v)", numHits)
+	leaves := r.Leaves()
+	ans := &DedupingCollector{
+		numHits: numHits,
+		leaves:  make([]SortedSetDocValues, len(leaves)),
+	}
+	for i, ctx := range leaves {
+		v, err := index.SortedSetOrUninverted(ctx.Reader().(index.AtomicReader), field, nil)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = index.SingletonSortedSetDocValues(index.EMPTY_SORTED_DOC_VALUES)
+		}
+		ans.leaves[i] = v
+	}
+	valueCount := int64(0)
+	if len(leaves) == 1 {
+		valueCount = ans.leaves[0].ValueCount()
+	} else if len(leaves) > 1 {
+		var err error
+		if ans.mapping, err = index.NewOrdinalMap(r, ans.leaves); err != nil {
+			return nil, err
+		}
+		valueCount = ans.mapping.ValueCount()
+	}
+	ans.bestDocs = make([]int, valueCount)
+	for i := range ans.bestDocs {
+		ans.bestDocs[i] = -1
+	}
+	ans.bestScores = make([]float32, valueCount)
+	return ans, nil
+}
+
+func (c *DedupingCollector) SetScorer(s Scorer) {
+	c.scorer = s
+}
+
+func (c *DedupingCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	c.segment, c.docBase, c.values = ctx.Ord, ctx.DocBase, c.leaves[ctx.Ord]
+}
+
+func (c *DedupingCollector) Collect(doc int) error {
+	score, err := c.scorer.Score()
+	if err != nil {
+		return err
+	}
+	c.totalHits++
+	c.values.SetDocument(doc)
+	ord := c.values.NextOrd()
+	doc += c.docBase
+	if ord == NO_MORE_ORDS {
+		c.unkeyed = append(c.unkeyed, newScoreDoc(doc, score))
+		return nil
+	}
+	if c.mapping != nil {
+		ord = c.mapping.GlobalOrd(c.segment, ord)
+	}
+	if best := c.bestDocs[ord]; best < 0 || score > c.bestScores[ord] ||
+		score == c.bestScores[ord] && doc < best {
+		c.bestDocs[ord], c.bestScores[ord] = doc, score
+	}
+	return nil
+}
+
+func (c *DedupingCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+/* Returns the number of matching documents, duplicates included. */
+func (c *DedupingCollector) TotalHits() int {
+	return c.totalHits
+}
+
+/*
+Returns the best hit of each key, and the hits without a key, by
+decreasing score, up to the collector's numHits. Their TotalHits is
+the number of distinct hits, i.e. of keys matched plus the hits
+without a key.
+*/
+func (c *DedupingCollector) TopDocs() TopDocs {
+	hits := append([]*ScoreDoc(nil), c.unkeyed...)
+	for ord, doc := range c.bestDocs {
+		if doc >= 0 {
+			hits = append(hits, newScoreDoc(doc, c.bestScores[ord]))
+		}
+	}
+	total := len(hits)
+	sort.Sort(scoreDocsByScore(hits))
+	if len(hits) > c.numHits {
+		hits = hits[:c.numHits]
+	}
+	maxScore := math.NaN()
+	if len(hits) > 0 {
+		maxScore = float64(hits[0].Score)
+	}
+	return TopDocs{total, TOTAL_HITS_RELATION_EQUAL_TO, hits, maxScore}
+}
+
+/*
+Finds the top n hits for query, where all hits are filtered by filter
+if non-nil, with distinct values of field; see DedupingCollector.
+*/
+func (ss *IndexSearcher) SearchDeduped(q Query, f Filter, field string, n int) (TopDocs, error) {
+	c, err := NewDedupingCollector(ss.reader, field, n)
+	if err != nil {
+		return TopDocs{}, err
+	}
+	if err = ss.SearchCollector(q, f, c); err != nil {
+		return TopDocs{}, err
+	}
+	return c.TopDocs(), nil
+}
@@ -0,0 +1,222 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/DisjunctionScorer.java
+// search/DisjunctionSumScorer.java
+
+/*
+A Scorer for OR like queries, which keeps its sub-scorers in a heap
+ordered by their current doc, and scores the sum of the scores of the
+sub-scorers on the current doc, multiplied by the coord factor of
+their number.
+
+Docs matched by less than minShouldMatch sub-scorers are skipped,
+which is how a BooleanQuery with a minimum number of optional clauses
+is scored in order.
+*/
+type DisjunctionSumScorer struct {
+	*abstractScorer
+	subScorers     []Scorer
+	numScorers     int
+	coord          []float32
+	minShouldMatch int
+	doc            int
+	// the number of sub-scorers on doc, or -1 if not computed yet
+	freq  int
+	score float64
+}
+
+/*
+Creates a disjunction of at least two sub-scorers; coord is indexed by
+the number of sub-scorers matching a doc, and must have len(subScorers)+1
+entries.
+*/
+func newDisjunctionSumScorer(weight Weight, subScorers []Scorer,
+	coord []float32, minShouldMatch int) *DisjunctionSumScorer {
+
+	assert2(len(subScorers) > 1, "There must be at least 2 subScorers")
+	ans := &DisjunctionSumScorer{
+		subScorers:     append([]Scorer(nil), subScorers...),
+		numScorers:     len(subScorers),
+		coord:          coord,
+		minShouldMatch: minShouldMatch,
+		doc:            -1,
+		freq:           -1,
+	}
+	ans.abstractScorer = newScorer(ans, weight)
+	ans.heapify()
+	return ans
+}
+
+/* Organize subScorers into a min heap with scorers generating the earliest document on top. */
+func (s *DisjunctionSumScorer) heapify() {
+	for i := (s.numScorers >> 1) - 1; i >= 0; i-- {
+		s.heapAdjust(i)
+	}
+}
+
+/*
+The subtree of subScorers at root is a min heap except possibly for
+its root element. Bubble the root down as required to make the
+subtree a heap.
+*/
+func (s *DisjunctionSumScorer) heapAdjust(root int) {
+	scorer := s.subScorers[root]
+	doc := scorer.DocId()
+	i := root
+	for i <= (s.numScorers>>1)-1 {
+		lchild := (i << 1) + 1
+		lscorer := s.subScorers[lchild]
+		ldoc := lscorer.DocId()
+		rdoc, rchild := NO_MORE_DOCS, (i<<1)+2
+		var rscorer Scorer
+		if rchild < s.numScorers {
+			rscorer = s.subScorers[rchild]
+			rdoc = rscorer.DocId()
+		}
+		if ldoc < doc {
+			if rdoc < ldoc {
+				s.subScorers[i], s.subScorers[rchild] = rscorer, scorer
+				i = rchild
+			} else {
+				s.subScorers[i], s.subScorers[lchild] = lscorer, scorer
+				i = lchild
+			}
+		} else if rdoc < doc {
+			s.subScorers[i], s.subScorers[rchild] = rscorer, scorer
+			i = rchild
+		} else {
+			return
+		}
+	}
+}
+
+/* Remove the root Scorer from subScorers and re-establish it as a heap. */
+func (s *DisjunctionSumScorer) heapRemoveRoot() {
+	if s.numScorers == 1 {
+		s.subScorers[0] = nil
+		s.numScorers = 0
+	} else {
+		s.subScorers[0] = s.subScorers[s.numScorers-1]
+		s.subScorers[s.numScorers-1] = nil
+		s.numScorers--
+		s.heapAdjust(0)
+	}
+}
+
+func (s *DisjunctionSumScorer) DocId() int {
+	return s.doc
+}
+
+func (s *DisjunctionSumScorer) NextDoc() (doc int, err error) {
+	assert(s.doc != NO_MORE_DOCS)
+	for {
+		if doc, err = s.subScorers[0].NextDoc(); err != nil {
+			return 0, err
+		}
+		if doc, err = s.afterNext(doc, s.doc+1); doc != -1 || err != nil {
+			return
+		}
+	}
+}
+
+func (s *DisjunctionSumScorer) Advance(target int) (doc int, err error) {
+	assert(s.doc != NO_MORE_DOCS)
+	for {
+		if doc, err = s.subScorers[0].Advance(target); err != nil {
+			return 0, err
+		}
+		if doc, err = s.afterNext(doc, target); doc != -1 || err != nil {
+			return
+		}
+		if s.doc >= target {
+			// skipped a doc matching too few sub-scorers
+			return s.NextDoc()
+		}
+	}
+}
+
+/*
+Restores the heap once its root moved to doc, and positions on the
+new root if it is at least target and matches enough sub-scorers.
+Returns -1 if the root must be moved again.
+*/
+func (s *DisjunctionSumScorer) afterNext(doc, target int) (int, error) {
+	if doc != NO_MORE_DOCS {
+		s.heapAdjust(0)
+	} else if s.heapRemoveRoot(); s.numScorers == 0 {
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	if doc = s.subScorers[0].DocId(); doc < target || doc == s.doc {
+		return -1, nil
+	}
+	s.doc, s.freq = doc, -1
+	if s.minShouldMatch > 1 {
+		if err := s.visitScorers(); err != nil {
+			return 0, err
+		}
+		if s.freq < s.minShouldMatch {
+			// too few matches: move on from doc
+			return -1, nil
+		}
+	}
+	return doc, nil
+}
+
+/* Computes the freq and score of the current doc. */
+func (s *DisjunctionSumScorer) visitScorers() error {
+	s.freq, s.score = 0, 0
+	return s.visit(0)
+}
+
+func (s *DisjunctionSumScorer) visit(root int) error {
+	if root < s.numScorers && s.subScorers[root].DocId() == s.doc {
+		score, err := s.subScorers[root].Score()
+		if err != nil {
+			return err
+		}
+		s.freq++
+		s.score += float64(score)
+		if err = s.visit((root << 1) + 1); err != nil {
+			return err
+		}
+		return s.visit((root << 1) + 2)
+	}
+	return nil
+}
+
+/* Returns the score of the current document matching the query. */
+func (s *DisjunctionSumScorer) Score() (float32, error) {
+	if s.freq < 0 {
+		if err := s.visitScorers(); err != nil {
+			return 0, err
+		}
+	}
+	return float32(s.score) * s.coord[s.freq], nil
+}
+
+/* Returns the number of sub-scorers matching the current doc. */
+func (s *DisjunctionSumScorer) Freq() (int, error) {
+	if s.freq < 0 {
+		if err := s.visitScorers(); err != nil {
+			return 0, err
+		}
+	}
+	return s.freq, nil
+}
+
+func (s *DisjunctionSumScorer) Cost() (sum int64) {
+	for _, sub := range s.subScorers[:s.numScorers] {
+		sum += sub.Cost()
+	}
+	return
+}
+
+func (s *DisjunctionSumScorer) String() string {
+	return fmt.Sprintf("DisjunctionSumScorer(%v)", s.weight)
+}
@@ -0,0 +1,304 @@
+package search
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+)
+
+// facet/range/DoubleRange.java
+
+/* A range of values, with Min inclusive and Max exclusive. */
+type Range struct {
+	Label    string
+	Min, Max float64
+}
+
+func NewRange(label string, min, max float64) *Range {
+	assert2(min <= max, "invalid range %v: [%v,%v)", label, min, max)
+	return &Range{label, min, max}
+}
+
+func (r *Range) Accept(v float64) bool {
+	return r.Min <= v && v < r.Max
+}
+
+func (r *Range) String() string {
+	return fmt.Sprintf("%v [%v,%v)", r.Label, r.Min, r.Max)
+}
+
+/*
+Returns the consecutive distance ranges split at the given bounds, in
+kilometers, e.g. 1, 5, 10 gives "<1km", "1-5km", "5-10km" and
+">=10km".
+*/
+func NewDistanceRanges(bounds ...float64) []*Range {
+	assert2(len(bounds) > 0, "at least one bound is required")
+	ans := make([]*Range, 0, len(bounds)+1)
+	ans = append(ans, NewRange(fmt.Sprintf("<%vkm", bounds[0]), 0, bounds[0]))
+	for i := 1; i < len(bounds); i++ {
+		ans = append(ans, NewRange(fmt.Sprintf("%v-%vkm", bounds[i-1], bounds[i]), bounds[i-1], bounds[i]))
+	}
+	last := bounds[len(bounds)-1]
+	return append(ans, NewRange(fmt.Sprintf(">=%vkm", last), last, math.Inf(1)))
+}
+
+// facet/range/DoubleRangeFacetCounts.java
+
+/* Number of matching documents in a range. */
+type RangeFacetCount struct {
+	*Range
+	Count int
+}
+
+func (c *RangeFacetCount) String() string {
+	return fmt.Sprintf("%v (%v)", c.Label, c.Count)
+}
+
+/*
+Collector counting the matching documents whose value, e.g. a
+distance from NewDistanceSource(), falls in each of the given ranges.
+Ranges may overlap, in which case a document is counted in every
+range containing its value. Documents without a value are counted as
+missing.
+*/
+type RangeFacetCollector struct {
+	source  FieldValueSource
+	ranges  []*Range
+	counts  []int
+	missing int
+
+	values FieldValues
+	err    error // deferred from SetNextReader
+}
+
+func NewRangeFacetCollector(source FieldValueSource, ranges ...*Range) *RangeFacetCollector {
+	return &RangeFacetCollector{
+		source: source,
+		ranges: ranges,
+		counts: make([]int, len(ranges)),
+	}
+}
+
+/* Counts the matching documents by distance from the given point. */
+func NewDistanceFacetCollector(lat, lon FieldValueSource,
+	queryLat, queryLon float64, ranges ...*Range) *RangeFacetCollector {
+
+	return NewRangeFacetCollector(NewDistanceSource(lat, lon, queryLat, queryLon), ranges...)
+}
+
+func (c *RangeFacetCollector) SetScorer(s Scorer) {}
+
+func (c *RangeFacetCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	var err error
+	if c.values, err = c.source.Values(ctx); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *RangeFacetCollector) Collect(doc int) error {
+	if c.err != nil {
+		return c.err
+	}
+	v, err := c.values(doc)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		c.missing++
+		return nil
+	}
+	f, err := toFloat64(v)
+	if err != nil {
+		return err
+	}
+	for i, r := range c.ranges {
+		if r.Accept(f) {
+			c.counts[i]++
+		}
+	}
+	return nil
+}
+
+func (c *RangeFacetCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+/* Returns the count of each range, in the order they were given. */
+func (c *RangeFacetCollector) Counts() []*RangeFacetCount {
+	ans := make([]*RangeFacetCount, len(c.ranges))
+	for i, r := range c.ranges {
+		ans[i] = &RangeFacetCount{r, c.counts[i]}
+	}
+	return ans
+}
+
+/* Returns the number of matching documents without a value. */
+func (c *RangeFacetCollector) Missing() int {
+	return c.missing
+}
+
+// search/FieldDoc.java
+
+/* A hit with the value it was sorted by, or nil if it has none. */
+type FieldDoc struct {
+	*ScoreDoc
+	Value interface{}
+}
+
+func (d *FieldDoc) String() string {
+	return fmt.Sprintf("%v value=%v", d.ScoreDoc, d.Value)
+}
+
+/* Hits sorted by value. */
+type TopFieldDocs struct {
+	TotalHits int
+	FieldDocs []*FieldDoc
+}
+
+// search/TopFieldCollector.java
+
+/*
+Collector keeping the top numHits matching documents ordered by the
+numeric value of a FieldValueSource, ascending unless reversed, e.g.
+the nearest documents with NewDistanceSource(). Ties are broken by
+doc ID, and documents without a value come last in either order.
+*/
+type ValueSortCollector struct {
+	source  FieldValueSource
+	numHits int
+	reverse bool
+	scores  bool
+	queue   fieldDocQueue
+
+	totalHits int
+	scorer    Scorer
+	docBase   int
+	values    FieldValues
+	err       error // deferred from SetNextReader
+}
+
+func NewValueSortCollector(source FieldValueSource, numHits int, reverse bool) *ValueSortCollector {
+	assert2(numHits > 0, "numHits must be > 0 (got %v)", numHits)
+	ans := &ValueSortCollector{source: source, numHits: numHits, reverse: reverse}
+	ans.queue.reverse = reverse
+	return ans
+}
+
+/* Collects the numHits nearest documents from the given point. */
+func NewDistanceSortCollector(lat, lon FieldValueSource,
+	queryLat, queryLon float64, numHits int) *ValueSortCollector {
+
+	return NewValueSortCollector(NewDistanceSource(lat, lon, queryLat, queryLon), numHits, false)
+}
+
+/* Whether scores are computed for each hit; false by default. */
+func (c *ValueSortCollector) SetScores(scores bool) *ValueSortCollector {
+	c.scores = scores
+	return c
+}
+
+func (c *ValueSortCollector) SetScorer(s Scorer) {
+	c.scorer = s
+}
+
+func (c *ValueSortCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	c.docBase = ctx.DocBase
+	var err error
+	if c.values, err = c.source.Values(ctx); err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *ValueSortCollector) Collect(doc int) (err error) {
+	if c.err != nil {
+		return c.err
+	}
+	c.totalHits++
+	hit := &fieldDocEntry{doc: c.docBase + doc}
+	v, err := c.values(doc)
+	if err != nil {
+		return err
+	}
+	if v != nil {
+		if hit.key, err = toFloat64(v); err != nil {
+			return err
+		}
+		hit.value, hit.hasValue = v, true
+	}
+	if len(c.queue.items) == c.numHits {
+		if !c.queue.worse(c.queue.items[0], hit) {
+			return nil // not competitive
+		}
+		if hit.score, err = c.score(); err != nil {
+			return err
+		}
+		c.queue.items[0] = hit
+		heap.Fix(&c.queue, 0)
+		return nil
+	}
+	if hit.score, err = c.score(); err != nil {
+		return err
+	}
+	heap.Push(&c.queue, hit)
+	return nil
+}
+
+func (c *ValueSortCollector) score() (float32, error) {
+	if !c.scores {
+		return 0, nil
+	}
+	return c.scorer.Score()
+}
+
+/* Ties are broken by doc ID, so docs must be collected in order. */
+func (c *ValueSortCollector) AcceptsDocsOutOfOrder() bool {
+	return false
+}
+
+/* Returns the top hits, best first. Can only be called once. */
+func (c *ValueSortCollector) TopFieldDocs() TopFieldDocs {
+	ans := make([]*FieldDoc, len(c.queue.items))
+	for i := len(ans) - 1; i >= 0; i-- {
+		hit := heap.Pop(&c.queue).(*fieldDocEntry)
+		ans[i] = &FieldDoc{newScoreDoc(hit.doc, hit.score), hit.value}
+	}
+	return TopFieldDocs{c.totalHits, ans}
+}
+
+type fieldDocEntry struct {
+	doc      int
+	score    float32
+	value    interface{}
+	key      float64
+	hasValue bool
+}
+
+/* Heap with the worst hit on top. */
+type fieldDocQueue struct {
+	items   []*fieldDocEntry
+	reverse bool
+}
+
+func (q *fieldDocQueue) Len() int           { return len(q.items) }
+func (q *fieldDocQueue) Less(i, j int) bool { return q.worse(q.items[i], q.items[j]) }
+func (q *fieldDocQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *fieldDocQueue) Push(x interface{}) { q.items = append(q.items, x.(*fieldDocEntry)) }
+func (q *fieldDocQueue) Pop() interface{} {
+	n := len(q.items)
+	ans := q.items[n-1]
+	q.items = q.items[:n-1]
+	return ans
+}
+
+/* Returns true if a comes after b. */
+func (q *fieldDocQueue) worse(a, b *fieldDocEntry) bool {
+	switch {
+	case a.hasValue != b.hasValue:
+		return !a.hasValue
+	case a.hasValue && a.key != b.key:
+		return (a.key > b.key) != q.reverse
+	}
+	return a.doc > b.doc
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// search/KnnVectorQuery.java#DocAndScoreQuery
+
+/*
+Matches a fixed set of top level documents, found when the Weight of
+the owner query was created, with their precomputed score times the
+normalized boost of the query, like a constant score query would.
+*/
+type docAndScoreWeight struct {
+	*WeightImpl
+	owner       Query
+	docs        []int // top level doc ids, in order
+	scores      []float32
+	scoreDesc   string // explains the precomputed scores
+	queryWeight float32
+}
+
+func newDocAndScoreWeight(owner Query, hits []*ScoreDoc, scoreDesc string) *docAndScoreWeight {
+	ans := &docAndScoreWeight{
+		owner:     owner,
+		docs:      make([]int, len(hits)),
+		scores:    make([]float32, len(hits)),
+		scoreDesc: scoreDesc,
+	}
+	// in doc order, so that scorers can iterate them
+	sort.Sort(byDoc(hits))
+	for i, hit := range hits {
+		ans.docs[i], ans.scores[i] = hit.Doc, hit.Score
+	}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans
+}
+
+func (w *docAndScoreWeight) ValueForNormalization() float32 {
+	boost := w.owner.Boost()
+	return boost * boost
+}
+
+func (w *docAndScoreWeight) Normalize(norm, topLevelBoost float32) {
+	w.queryWeight = w.owner.Boost() * norm * topLevelBoost
+}
+
+func (w *docAndScoreWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+/* Returns the range of hits within the segment. */
+func (w *docAndScoreWeight) segment(ctx *index.AtomicReaderContext) (from, to int) {
+	from = sort.SearchInts(w.docs, ctx.DocBase)
+	to = sort.SearchInts(w.docs, ctx.DocBase+ctx.Reader().MaxDoc())
+	return
+}
+
+func (w *docAndScoreWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	from, to := w.segment(ctx)
+	if from == to {
+		return nil, nil
+	}
+	ans := &docAndScoreScorer{
+		weight:     w,
+		docBase:    ctx.DocBase,
+		docs:       w.docs[from:to],
+		scores:     w.scores[from:to],
+		acceptDocs: acceptDocs,
+		upto:       -1,
+	}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans, nil
+}
+
+func (w *docAndScoreWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	from, to := w.segment(ctx)
+	for i := from; i < to; i++ {
+		if w.docs[i] == ctx.DocBase+doc {
+			ans := newComplexExplanation(true, w.scores[i]*w.queryWeight,
+				fmt.Sprintf("%v, product of:", w.owner))
+			ans.addDetail(newExplanation(w.scores[i], w.scoreDesc))
+			ans.addDetail(newExplanation(w.queryWeight, "queryWeight"))
+			return ans, nil
+		}
+	}
+	return newComplexExplanation(false, 0, fmt.Sprintf(
+		"%v doesn't match id %v", w.owner, doc)), nil
+}
+
+type byDoc []*ScoreDoc
+
+func (a byDoc) Len() int           { return len(a) }
+func (a byDoc) Less(i, j int) bool { return a[i].Doc < a[j].Doc }
+func (a byDoc) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+type docAndScoreScorer struct {
+	*abstractScorer
+	weight     *docAndScoreWeight
+	docBase    int
+	docs       []int
+	scores     []float32
+	acceptDocs util.Bits
+	upto       int
+}
+
+func (s *docAndScoreScorer) DocId() int {
+	if s.upto < 0 {
+		return -1
+	} else if s.upto >= len(s.docs) {
+		return NO_MORE_DOCS
+	}
+	return s.docs[s.upto] - s.docBase
+}
+
+func (s *docAndScoreScorer) NextDoc() (int, error) {
+	for s.upto++; s.upto < len(s.docs); s.upto++ {
+		if s.acceptDocs == nil || s.acceptDocs.At(s.docs[s.upto]-s.docBase) {
+			break
+		}
+	}
+	return s.DocId(), nil
+}
+
+func (s *docAndScoreScorer) Advance(target int) (doc int, err error) {
+	for doc = s.DocId(); doc < target; {
+		if doc, err = s.NextDoc(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *docAndScoreScorer) Score() (float32, error) {
+	return s.scores[s.upto] * s.weight.queryWeight, nil
+}
+
+func (s *docAndScoreScorer) Freq() (int, error) { return 1, nil }
+func (s *docAndScoreScorer) Cost() int64        { return int64(len(s.docs)) }
+
+func (s *docAndScoreScorer) String() string {
+	return fmt.Sprintf("scorer(%v)", s.weight.owner)
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/DocIdSet.java
+
+/*
+A DocIdSet contains a set of doc ids. Implementing classes must only
+implement Iterator() to provide access to the set.
+*/
+type DocIdSet interface {
+	util.Accountable
+	// Provides a DocIdSetIterator to access the set. This may return
+	// nil if there are no docs that match.
+	Iterator() (DocIdSetIterator, error)
+	// Optionally provides a Bits interface for random access to
+	// matching documents. Returns nil if this DocIdSet does not
+	// support random access.
+	Bits() util.Bits
+	// This method is a hint for CachingWrapperFilter, if this DocIdSet
+	// should be cached without copying it. The default is to return
+	// false. If you have an own DocIdSet implementation that does its
+	// iteration very effective and fast without doing disk I/O,
+	// override this method and return true.
+	IsCacheable() bool
+}
+
+type emptyDocIdSet struct{}
+
+func (s emptyDocIdSet) Iterator() (DocIdSetIterator, error) { return nil, nil }
+func (s emptyDocIdSet) Bits() util.Bits                     { return nil }
+func (s emptyDocIdSet) IsCacheable() bool                   { return true }
+func (s emptyDocIdSet) RamBytesUsed() int64                 { return 0 }
+
+/* An empty DocIdSet instance */
+var EMPTY_DOC_ID_SET DocIdSet = emptyDocIdSet{}
+
+// search/FilteredDocIdSet.java
+
+/*
+A DocIdSet which only accepts the docs of the wrapped set which are
+also accepted by the acceptDocs, e.g. the live docs of the segment.
+*/
+type bitsFilteredDocIdSet struct {
+	DocIdSet
+	acceptDocs util.Bits
+}
+
+/*
+Convenience wrapper method: If acceptDocs == nil it returns the
+original set without wrapping.
+*/
+func wrapBitsFilteredDocIdSet(set DocIdSet, acceptDocs util.Bits) DocIdSet {
+	if set == nil || acceptDocs == nil {
+		return set
+	}
+	return &bitsFilteredDocIdSet{set, acceptDocs}
+}
+
+func (s *bitsFilteredDocIdSet) IsCacheable() bool {
+	return false
+}
+
+func (s *bitsFilteredDocIdSet) Bits() util.Bits {
+	if bits := s.DocIdSet.Bits(); bits != nil {
+		return &filteredBits{bits, s.acceptDocs}
+	}
+	return nil
+}
+
+func (s *bitsFilteredDocIdSet) Iterator() (DocIdSetIterator, error) {
+	it, err := s.DocIdSet.Iterator()
+	if it == nil || err != nil {
+		return nil, err
+	}
+	return &filteredDocIdSetIterator{it, s.acceptDocs, -1}, nil
+}
+
+type filteredBits struct {
+	bits, acceptDocs util.Bits
+}
+
+func (b *filteredBits) At(doc int) bool {
+	return b.bits.At(doc) && b.acceptDocs.At(doc)
+}
+
+func (b *filteredBits) Length() int {
+	return b.bits.Length()
+}
+
+// search/FilteredDocIdSetIterator.java
+
+type filteredDocIdSetIterator struct {
+	it         DocIdSetIterator
+	acceptDocs util.Bits
+	doc        int
+}
+
+func (it *filteredDocIdSetIterator) DocId() int {
+	return it.doc
+}
+
+func (it *filteredDocIdSetIterator) next(doc int, err error) (int, error) {
+	for ; doc != NO_MORE_DOCS && err == nil; doc, err = it.it.NextDoc() {
+		if it.acceptDocs.At(doc) {
+			break
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	it.doc = doc
+	return doc, nil
+}
+
+func (it *filteredDocIdSetIterator) NextDoc() (int, error) {
+	return it.next(it.it.NextDoc())
+}
+
+func (it *filteredDocIdSetIterator) Advance(target int) (int, error) {
+	return it.next(it.it.Advance(target))
+}
+
+func (it *filteredDocIdSetIterator) Cost() int64 {
+	return it.it.Cost()
+}
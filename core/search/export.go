@@ -0,0 +1,148 @@
+package search
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+)
+
+/* A matching document streamed by ExportCollector. */
+type ExportedDoc struct {
+	// Top-level doc ID
+	Doc int
+	// Score of the document, or 0 if scores are not requested
+	Score float32
+	// Selected values by name; documents without a value have no entry
+	Values map[string]interface{}
+}
+
+/*
+Collector streaming every matching document, with its score and
+selected values (e.g. doc values), to a callback in batches. Unlike
+TopDocsCollector, no priority queue is kept, so it can be used to
+build scroll/export APIs over result sets of any size.
+
+Documents are delivered in the order they are collected. Finish()
+must be called after the search, to deliver the last partial batch.
+*/
+type ExportCollector struct {
+	fields    []fetchField
+	batchSize int
+	fn        func(batch []*ExportedDoc) error
+	onFinish  func()
+	scores    bool
+
+	scorer Scorer
+	leaf   *index.AtomicReaderContext
+	values []FieldValues
+	err    error // deferred from SetNextReader
+
+	batch []*ExportedDoc
+	count int
+}
+
+/* Returns an ExportCollector calling fn with batches of at most batchSize documents. */
+func NewExportCollector(batchSize int, fn func(batch []*ExportedDoc) error) *ExportCollector {
+	assert2(batchSize > 0, "batchSize must be > 0 (got %v)", batchSize)
+	return &ExportCollector{batchSize: batchSize, fn: fn}
+}
+
+/*
+Returns an ExportCollector sending batches of at most batchSize
+documents to ch. ch is closed by Finish().
+*/
+func NewExportChannelCollector(batchSize int, ch chan<- []*ExportedDoc) *ExportCollector {
+	ans := NewExportCollector(batchSize, func(batch []*ExportedDoc) error {
+		ch <- batch
+		return nil
+	})
+	ans.onFinish = func() { close(ch) }
+	return ans
+}
+
+/* Adds a value to be exported as the given name for each document. */
+func (c *ExportCollector) AddField(name string, source FieldValueSource) *ExportCollector {
+	c.fields = append(c.fields, fetchField{name, source})
+	return c
+}
+
+/* Whether scores are computed for each document; false by default. */
+func (c *ExportCollector) SetScores(scores bool) *ExportCollector {
+	c.scores = scores
+	return c
+}
+
+/* Returns the number of documents collected so far. */
+func (c *ExportCollector) Count() int {
+	return c.count
+}
+
+func (c *ExportCollector) SetScorer(s Scorer) {
+	c.scorer = s
+}
+
+func (c *ExportCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	c.leaf = ctx
+	if c.values == nil {
+		c.values = make([]FieldValues, len(c.fields))
+	}
+	for i, field := range c.fields {
+		var err error
+		if c.values[i], err = field.source.Values(ctx); err != nil && c.err == nil {
+			c.err = err
+		}
+	}
+}
+
+func (c *ExportCollector) Collect(doc int) (err error) {
+	if c.err != nil {
+		return c.err
+	}
+	exported := &ExportedDoc{
+		Doc:    c.leaf.DocBase + doc,
+		Values: make(map[string]interface{}),
+	}
+	if c.scores {
+		if exported.Score, err = c.scorer.Score(); err != nil {
+			return err
+		}
+	}
+	for i, field := range c.fields {
+		v, err := c.values[i](doc)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			exported.Values[field.name] = v
+		}
+	}
+	c.batch = append(c.batch, exported)
+	c.count++
+	if len(c.batch) >= c.batchSize {
+		return c.flush()
+	}
+	return nil
+}
+
+/* Values are looked up per document, so any order is fine. */
+func (c *ExportCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+func (c *ExportCollector) flush() error {
+	if len(c.batch) == 0 {
+		return nil
+	}
+	// the batch is handed over; never reused
+	batch := c.batch
+	c.batch = nil
+	return c.fn(batch)
+}
+
+/* Delivers the last partial batch, if any. */
+func (c *ExportCollector) Finish() error {
+	err := c.flush()
+	if c.onFinish != nil {
+		c.onFinish()
+		c.onFinish = nil
+	}
+	return err
+}
@@ -0,0 +1,293 @@
+package search
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+	"sort"
+	"strconv"
+)
+
+/*
+Per-segment values of a FieldValueSource. doc is relative to the
+segment. nil is returned for documents without a value.
+*/
+type FieldValues func(doc int) (interface{}, error)
+
+/*
+Source of per-document values returned alongside the hits by the
+FetchPhase, e.g. a doc values field, or a value computed from other
+sources such as the distance of the document from a query point.
+*/
+type FieldValueSource interface {
+	// Returns the values of the given segment.
+	Values(ctx *index.AtomicReaderContext) (FieldValues, error)
+	String() string
+}
+
+type numericDocValuesSource string
+
+/* Returns the int64 values of the numeric doc values field. */
+func NewNumericDocValuesSource(field string) FieldValueSource {
+	return numericDocValuesSource(field)
+}
+
+func (s numericDocValuesSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	ndv, err := ctx.Reader().(index.AtomicReader).NumericDocValues(string(s))
+	if err != nil || ndv == nil {
+		return noValues, err
+	}
+	return func(doc int) (interface{}, error) {
+		return ndv(doc), nil
+	}, nil
+}
+
+func (s numericDocValuesSource) String() string {
+	return fmt.Sprintf("numeric(%v)", string(s))
+}
+
+type binaryDocValuesSource string
+
+/* Returns the []byte values of the binary doc values field. */
+func NewBinaryDocValuesSource(field string) FieldValueSource {
+	return binaryDocValuesSource(field)
+}
+
+func (s binaryDocValuesSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	bdv, err := ctx.Reader().(index.AtomicReader).BinaryDocValues(string(s))
+	if err != nil || bdv == nil {
+		return noValues, err
+	}
+	return func(doc int) (interface{}, error) {
+		// the returned slice may be reused
+		return append([]byte(nil), bdv.Get(doc)...), nil
+	}, nil
+}
+
+func (s binaryDocValuesSource) String() string {
+	return fmt.Sprintf("binary(%v)", string(s))
+}
+
+type storedFieldSource string
+
+/*
+Returns the string value of the stored field. Prefer doc values
+sources, which do not load the whole stored document of each hit.
+*/
+func NewStoredFieldSource(field string) FieldValueSource {
+	return storedFieldSource(field)
+}
+
+func (s storedFieldSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	r := ctx.Reader()
+	return func(doc int) (interface{}, error) {
+		d, err := r.Document(doc)
+		if err != nil {
+			return nil, err
+		}
+		if v := d.Get(string(s)); v != "" {
+			return v, nil
+		}
+		return nil, nil
+	}, nil
+}
+
+func (s storedFieldSource) String() string {
+	return fmt.Sprintf("stored(%v)", string(s))
+}
+
+func noValues(doc int) (interface{}, error) { return nil, nil }
+
+/* A computed value, e.g. the result of an expression over other sources. */
+type ExpressionSource struct {
+	name    string
+	fn      func(args []interface{}) (interface{}, error)
+	sources []FieldValueSource
+}
+
+/*
+Returns the value computed by fn from the values of the given
+sources of each document, in order.
+*/
+func NewExpressionSource(name string,
+	fn func(args []interface{}) (interface{}, error),
+	sources ...FieldValueSource) *ExpressionSource {
+
+	return &ExpressionSource{name, fn, sources}
+}
+
+func (s *ExpressionSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	values := make([]FieldValues, len(s.sources))
+	for i, source := range s.sources {
+		var err error
+		if values[i], err = source.Values(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return func(doc int) (interface{}, error) {
+		args := make([]interface{}, len(values))
+		for i, v := range values {
+			var err error
+			if args[i], err = v(doc); err != nil {
+				return nil, err
+			}
+		}
+		return s.fn(args)
+	}, nil
+}
+
+func (s *ExpressionSource) String() string {
+	return fmt.Sprintf("%v%v", s.name, s.sources)
+}
+
+/* Mean radius of the earth, in kilometers. */
+const EARTH_MEAN_RADIUS_KM = 6371.0087714
+
+/*
+Returns the distance in kilometers (haversine) of each document from
+the given point. lat and lon provide the location of the document in
+degrees, as float64, int64 or string values. Documents without a
+location get no value.
+*/
+func NewDistanceSource(lat, lon FieldValueSource, queryLat, queryLon float64) *ExpressionSource {
+	name := fmt.Sprintf("distance(%v,%v)", queryLat, queryLon)
+	return NewExpressionSource(name, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		docLat, err := toFloat64(args[0])
+		if err != nil {
+			return nil, err
+		}
+		docLon, err := toFloat64(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return Haversine(queryLat, queryLon, docLat, docLon), nil
+	}, lat, lon)
+}
+
+/* Returns the great circle distance in kilometers between two points given in degrees. */
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const toRad = math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EARTH_MEAN_RADIUS_KM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
+
+/* A hit with its stored fields and computed values. */
+type FetchedHit struct {
+	*ScoreDoc
+	// Stored fields of the hit, or nil if not loaded
+	Document *docu.Document
+	// Computed values by name; hits without a value have no entry
+	Fields map[string]interface{}
+}
+
+type fetchField struct {
+	name   string
+	source FieldValueSource
+}
+
+/*
+Loads the stored fields and computed values of the top hits of a
+search. Hits are visited in doc ID order, segment by segment, so that
+each source is set up once per segment.
+*/
+type FetchPhase struct {
+	searcher   *IndexSearcher
+	loadStored bool
+	fields     []fetchField
+}
+
+func NewFetchPhase(searcher *IndexSearcher) *FetchPhase {
+	return &FetchPhase{searcher: searcher, loadStored: true}
+}
+
+/* Whether the stored fields of each hit are loaded; true by default. */
+func (f *FetchPhase) SetLoadStoredFields(loadStored bool) *FetchPhase {
+	f.loadStored = loadStored
+	return f
+}
+
+/* Adds a value to be returned as the given name for each hit. */
+func (f *FetchPhase) AddField(name string, source FieldValueSource) *FetchPhase {
+	f.fields = append(f.fields, fetchField{name, source})
+	return f
+}
+
+/* Fetches the given hits, e.g. TopDocs.ScoreDocs, in the same order. */
+func (f *FetchPhase) Fetch(hits []*ScoreDoc) ([]*FetchedHit, error) {
+	ans := make([]*FetchedHit, len(hits))
+	order := make([]int, len(hits))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(&byDocId{hits, order})
+
+	leaves := f.searcher.leafContexts
+	values := make([]FieldValues, len(f.fields))
+	leaf := -1
+	for _, i := range order {
+		hit := hits[i]
+		if n := index.SubIndex(hit.Doc, leaves); n != leaf {
+			leaf = n
+			for j, field := range f.fields {
+				var err error
+				if values[j], err = field.source.Values(leaves[leaf]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		doc := hit.Doc - leaves[leaf].DocBase
+
+		fetched := &FetchedHit{ScoreDoc: hit, Fields: make(map[string]interface{})}
+		if f.loadStored {
+			var err error
+			if fetched.Document, err = leaves[leaf].Reader().Document(doc); err != nil {
+				return nil, err
+			}
+		}
+		for j, field := range f.fields {
+			v, err := values[j](doc)
+			if err != nil {
+				return nil, err
+			}
+			if v != nil {
+				fetched.Fields[field.name] = v
+			}
+		}
+		ans[i] = fetched
+	}
+	return ans, nil
+}
+
+type byDocId struct {
+	hits  []*ScoreDoc
+	order []int
+}
+
+func (a *byDocId) Len() int      { return len(a.order) }
+func (a *byDocId) Swap(i, j int) { a.order[i], a.order[j] = a.order[j], a.order[i] }
+func (a *byDocId) Less(i, j int) bool {
+	return a.hits[a.order[i]].Doc < a.hits[a.order[j]].Doc
+}
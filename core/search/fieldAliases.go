@@ -0,0 +1,192 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"sync"
+)
+
+/* A concrete field an alias resolves to, and the boost of its matches. */
+type AliasTarget struct {
+	Field string
+	Boost float32
+}
+
+/*
+Query-time field aliases. An alias maps to one or more concrete
+fields, each with a boost, so that the schema can evolve (e.g. fields
+are renamed, or split into several) without breaking stored queries
+or clients that still refer to the old name. An alias may also be a
+virtual field which never exists in the index, e.g. "all" resolving
+to "title^2" and "body".
+
+A query on an alias is expanded to a disjunction (with coord
+disabled) of the same query on each of its target fields. Aliases may
+refer to other aliases; boosts multiply along the way.
+
+A target with a boost of 0 is excluded, with all the fields it
+resolves to, and no query is built on it: redefining an alias with
+some boosts set to 0, e.g. per tenant, switches fields off at query
+time without rebuilding the parsers using the aliases.
+
+FieldAliases is safe for concurrent use: aliases may be redefined
+while other goroutines build queries with them. A query is built from
+the definitions at the time each of its fields is resolved.
+*/
+type FieldAliases struct {
+	lock    sync.RWMutex
+	aliases map[string][]AliasTarget
+}
+
+func NewFieldAliases() *FieldAliases {
+	return &FieldAliases{aliases: make(map[string][]AliasTarget)}
+}
+
+/*
+Defines (or redefines) the alias to resolve to the given fields. A
+target with a boost of 0 is excluded.
+*/
+func (fa *FieldAliases) Add(alias string, targets ...AliasTarget) {
+	assert2(len(targets) > 0, "alias %v must resolve to at least one field", alias)
+	for _, t := range targets {
+		assert2(t.Boost >= 0, "boost of %v in alias %v must be >= 0 (got %v)", t.Field, alias, t.Boost)
+	}
+	targets = append([]AliasTarget(nil), targets...)
+	fa.lock.Lock()
+	defer fa.lock.Unlock()
+	fa.aliases[alias] = targets
+}
+
+/* Removes the alias, if defined. */
+func (fa *FieldAliases) Remove(alias string) {
+	fa.lock.Lock()
+	defer fa.lock.Unlock()
+	delete(fa.aliases, alias)
+}
+
+func (fa *FieldAliases) IsAlias(field string) bool {
+	fa.lock.RLock()
+	defer fa.lock.RUnlock()
+	_, ok := fa.aliases[field]
+	return ok
+}
+
+/*
+Returns the concrete fields the given field resolves to. A field
+which is not an alias resolves to itself with boost 1. Targets with a
+boost of 0 are left out, so that an alias may resolve to no field at
+all. Returns an error if the alias definitions are cyclic.
+*/
+func (fa *FieldAliases) Resolve(field string) ([]AliasTarget, error) {
+	fa.lock.RLock()
+	defer fa.lock.RUnlock()
+	var ans []AliasTarget
+	err := fa.resolve(field, 1, make(map[string]bool), &ans)
+	return ans, err
+}
+
+func (fa *FieldAliases) resolve(field string, boost float32,
+	visiting map[string]bool, ans *[]AliasTarget) error {
+
+	targets, ok := fa.aliases[field]
+	if !ok {
+		*ans = append(*ans, AliasTarget{field, boost})
+		return nil
+	}
+	if visiting[field] {
+		return fmt.Errorf("cyclic field alias: %v", field)
+	}
+	visiting[field] = true
+	defer delete(visiting, field)
+	for _, t := range targets {
+		if t.Boost == 0 {
+			continue // excluded, with the fields it resolves to
+		}
+		if err := fa.resolve(t.Field, boost*t.Boost, visiting, ans); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Builds the query for the given field by calling fn for each concrete
+field it resolves to. Nil queries returned by fn (e.g. when all terms
+are stop words) are skipped, as are excluded targets, for which fn
+is not called. The boost of each query is multiplied by the boost of
+its target. If there is a single target, its query is returned as is;
+otherwise a BooleanQuery of SHOULD clauses. nil is returned if there
+is no query.
+*/
+func (fa *FieldAliases) Expand(field string, fn func(field string) Query) (Query, error) {
+	targets, err := fa.Resolve(field)
+	if err != nil {
+		return nil, err
+	}
+	var queries []Query
+	for _, t := range targets {
+		if q := fn(t.Field); q != nil {
+			q.SetBoost(q.Boost() * t.Boost)
+			queries = append(queries, q)
+		}
+	}
+	switch len(queries) {
+	case 0:
+		return nil, nil
+	case 1:
+		return queries[0], nil
+	}
+	bq := NewBooleanQueryDisableCoord(true)
+	for _, q := range queries {
+		bq.Add(q, SHOULD)
+	}
+	return bq, nil
+}
+
+/*
+Rewrites the query so that term queries on aliases are replaced by
+queries on the concrete fields, or by an empty BooleanQuery, matching
+no document, if all the fields are excluded. BooleanQuery is rewritten
+clause by clause; other queries are returned unchanged.
+*/
+func (fa *FieldAliases) Rewrite(q Query) (Query, error) {
+	switch query := q.(type) {
+	case *TermQuery:
+		if !fa.IsAlias(query.term.Field) {
+			return q, nil
+		}
+		ans, err := fa.Expand(query.term.Field, func(field string) Query {
+			ans := NewTermQuery(index.NewTermFromBytes(field, query.term.Bytes))
+			ans.SetBoost(query.Boost())
+			return ans
+		})
+		if ans == nil && err == nil {
+			ans = NewBooleanQuery()
+		}
+		return ans, err
+	case *BooleanQuery:
+		var clone *BooleanQuery
+		for i, c := range query.clauses {
+			rewritten, err := fa.Rewrite(c.query)
+			if err != nil {
+				return nil, err
+			}
+			if rewritten == c.query && clone == nil {
+				continue
+			}
+			if clone == nil {
+				clone = NewBooleanQueryDisableCoord(query.disableCoord)
+				clone.SetBoost(query.Boost())
+				clone.minNrShouldMatch = query.minNrShouldMatch
+				for _, prev := range query.clauses[:i] {
+					clone.AddClause(prev)
+				}
+			}
+			clone.Add(rewritten, c.occur)
+		}
+		if clone != nil {
+			return clone, nil
+		}
+	}
+	return q, nil
+}
@@ -0,0 +1,232 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/FilteredQuery.java
+
+/*
+A query that applies a filter to the results of another query.
+
+Note: the bits are retrieved from the filter each time this query is
+used in a search - use a CachingWrapperFilter to avoid regenerating
+the bits every time.
+
+Only the leap-frog strategy is supported: the query scorer and the
+filter iterator are advanced in turn, led by the cheaper of the two.
+*/
+type FilteredQuery struct {
+	*AbstractQuery
+	query  Query
+	filter Filter
+}
+
+/*
+Constructs a new query which applies a filter to the results of the
+original query. Filter.DocIdSet() will be called every time this
+query is used in a search.
+*/
+func NewFilteredQuery(query Query, filter Filter) *FilteredQuery {
+	assert(query != nil && filter != nil)
+	ans := &FilteredQuery{query: query, filter: filter}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Returns this FilteredQuery's (unfiltered) Query */
+func (q *FilteredQuery) Query() Query {
+	return q.query
+}
+
+/* Returns this FilteredQuery's filter */
+func (q *FilteredQuery) Filter() Filter {
+	return q.filter
+}
+
+/* Returns a new FilteredQuery wrapping the rewritten query, if it rewrites. */
+func (q *FilteredQuery) Rewrite(r index.IndexReader) Query {
+	if queryRewritten := q.query.Rewrite(r); queryRewritten != q.query {
+		// rewrite to a new FilteredQuery wrapping the rewritten query
+		rewritten := NewFilteredQuery(queryRewritten, q.filter)
+		rewritten.SetBoost(q.Boost())
+		return rewritten
+	}
+	// nothing to rewrite, we are done!
+	return q
+}
+
+func (q *FilteredQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	weight, err := q.query.CreateWeight(ss)
+	if err != nil {
+		return nil, err
+	}
+	ans := &FilteredWeight{owner: q, weight: weight}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+func (q *FilteredQuery) ToString(field string) string {
+	ans := fmt.Sprintf("filtered(%v)->%v", q.query.ToString(field), q.filter)
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
+
+type FilteredWeight struct {
+	*WeightImpl
+	owner  *FilteredQuery
+	weight Weight
+}
+
+func (w *FilteredWeight) ValueForNormalization() float32 {
+	boost := w.owner.Boost()
+	return w.weight.ValueForNormalization() * boost * boost // boost sub-weight
+}
+
+func (w *FilteredWeight) Normalize(norm, topLevelBoost float32) {
+	w.weight.Normalize(norm, topLevelBoost*w.owner.Boost()) // incorporate boost
+}
+
+func (w *FilteredWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *FilteredWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	inner, err := w.weight.Explain(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	set, err := w.owner.filter.DocIdSet(ctx, ctx.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	var it DocIdSetIterator
+	if set != nil {
+		if it, err = set.Iterator(); err != nil {
+			return nil, err
+		}
+	}
+	if it != nil {
+		var target int
+		if target, err = it.Advance(doc); err != nil {
+			return nil, err
+		}
+		if target == doc {
+			return inner, nil
+		}
+	}
+	ans := newExplanation(0, "failure to match filter: "+fmt.Sprint(w.owner.filter))
+	ans.addDetail(inner)
+	return ans, nil
+}
+
+func (w *FilteredWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	set, err := w.owner.filter.DocIdSet(ctx, acceptDocs)
+	if set == nil || err != nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+	filterIter, err := set.Iterator()
+	if filterIter == nil || err != nil {
+		// this means the filter does not accept any documents.
+		return nil, err
+	}
+	// acceptDocs were applied by the filter
+	scorer, err := w.weight.Scorer(ctx, nil)
+	if scorer == nil || err != nil {
+		return nil, err
+	}
+	return newLeapFrogScorer(w, scorer, filterIter), nil
+}
+
+/*
+Scorer that uses a leap-frog approach to intersect the filter and the
+query scorer, led by the one with the lower cost.
+*/
+type leapFrogScorer struct {
+	*abstractScorer
+	scorer    Scorer
+	primary   DocIdSetIterator
+	secondary DocIdSetIterator
+	doc       int
+}
+
+func newLeapFrogScorer(w Weight, scorer Scorer, filterIter DocIdSetIterator) *leapFrogScorer {
+	ans := &leapFrogScorer{scorer: scorer, primary: filterIter, secondary: scorer, doc: -1}
+	if scorer.Cost() < filterIter.Cost() {
+		ans.primary, ans.secondary = scorer, filterIter
+	}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans
+}
+
+func (s *leapFrogScorer) advanceToNextCommonDoc(primaryDoc int) (int, error) {
+	secondaryDoc := s.secondary.DocId()
+	var err error
+	for {
+		if secondaryDoc < primaryDoc {
+			if secondaryDoc, err = s.secondary.Advance(primaryDoc); err != nil {
+				return 0, err
+			}
+		}
+		if secondaryDoc == primaryDoc {
+			s.doc = primaryDoc
+			return s.doc, nil
+		}
+		if primaryDoc, err = s.primary.Advance(secondaryDoc); err != nil {
+			return 0, err
+		}
+		if primaryDoc == NO_MORE_DOCS {
+			s.doc = NO_MORE_DOCS
+			return s.doc, nil
+		}
+	}
+}
+
+func (s *leapFrogScorer) NextDoc() (int, error) {
+	doc, err := s.primary.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	if doc == NO_MORE_DOCS {
+		s.doc = NO_MORE_DOCS
+		return s.doc, nil
+	}
+	return s.advanceToNextCommonDoc(doc)
+}
+
+func (s *leapFrogScorer) Advance(target int) (int, error) {
+	if target > s.doc {
+		doc, err := s.primary.Advance(target)
+		if err != nil {
+			return 0, err
+		}
+		if doc == NO_MORE_DOCS {
+			s.doc = NO_MORE_DOCS
+			return s.doc, nil
+		}
+		return s.advanceToNextCommonDoc(doc)
+	}
+	return s.NextDoc()
+}
+
+func (s *leapFrogScorer) DocId() int {
+	return s.doc
+}
+
+func (s *leapFrogScorer) Score() (float32, error) {
+	return s.scorer.Score()
+}
+
+func (s *leapFrogScorer) Freq() (int, error) {
+	return s.scorer.Freq()
+}
+
+func (s *leapFrogScorer) Cost() int64 {
+	return s.primary.Cost()
+}
@@ -1,4 +1,297 @@
 package search
 
+import (
+	"context"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"sort"
+	"sync"
+)
+
+// search/Filter.java
+
+/*
+Abstract base class for restricting which documents may be returned
+during searching.
+*/
 type Filter interface {
+	// Creates a DocIdSet enumerating the documents that should be
+	// permitted in search results. NOTE: nil can be returned if no
+	// documents are accepted by this Filter.
+	//
+	// Note: this method will be called once per segment in the index
+	// during searching. The returned DocIdSet must refer to document
+	// IDs for that segment, not for the top-level reader.
+	//
+	// acceptDocs are the Bits that represent the allowable docs to
+	// match (typically deleted docs but possibly filtering other
+	// documents).
+	DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error)
+}
+
+// search/QueryWrapperFilter.java
+
+/*
+Constrains search results to only match those which also match a
+provided query.
+
+This could be used, for example, with a NumericRangeQuery on a
+suitably formatted date field to implement date filtering. One could
+re-use a single CachingWrapperFilter(QueryWrapperFilter) that matches,
+e.g., only documents modified within the last week. This would only
+need to be reconstructed once per day.
+*/
+type QueryWrapperFilter struct {
+	query Query
+}
+
+/* Constructs a filter which only matches documents matching query. */
+func NewQueryWrapperFilter(query Query) *QueryWrapperFilter {
+	assert(query != nil)
+	return &QueryWrapperFilter{query}
+}
+
+/* Returns the inner Query */
+func (f *QueryWrapperFilter) Query() Query {
+	return f.query
+}
+
+func (f *QueryWrapperFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error) {
+	// get a private context that is used to rewrite, createWeight and score eventually
+	ss := NewIndexSearcher(ctx.Reader())
+	privateContext := ss.leafContexts[0]
+	weight, err := ss.CreateNormalizedWeight(context.Background(), f.query)
+	if err != nil {
+		return nil, err
+	}
+	return &scorerDocIdSet{weight, privateContext, acceptDocs}, nil
+}
+
+func (f *QueryWrapperFilter) String() string {
+	return fmt.Sprintf("QueryWrapperFilter(%v)", f.query)
+}
+
+/* Lazily iterates the docs matched by a Weight */
+type scorerDocIdSet struct {
+	weight     Weight
+	ctx        *index.AtomicReaderContext
+	acceptDocs util.Bits
+}
+
+func (s *scorerDocIdSet) Iterator() (DocIdSetIterator, error) {
+	scorer, err := s.weight.Scorer(s.ctx, s.acceptDocs)
+	if scorer == nil || err != nil {
+		return nil, err
+	}
+	return scorer, nil
+}
+
+func (s *scorerDocIdSet) Bits() util.Bits     { return nil }
+func (s *scorerDocIdSet) IsCacheable() bool   { return false }
+func (s *scorerDocIdSet) RamBytesUsed() int64 { return 0 }
+
+// search/CachingWrapperFilter.java
+
+/*
+Wraps another Filter's result and caches it. The purpose is to allow
+filters to simply filter, and then wrap with this class to add
+caching.
+
+The docs of each segment are cached in the smallest of three
+representations, chosen from their density by CompactDocIdSet(): a
+FixedBitSet for dense sets, and a RoaringDocIdSet or an
+EliasFanoDocIdSet, whose memory usage is proportional to the number
+of docs rather than to maxDoc, for sparse ones.
+
+Entries are keyed by segment core, so that they survive reopens, and
+are cached before deletions are applied; acceptDocs are applied on
+top of the cached set.
+*/
+type CachingWrapperFilter struct {
+	sync.Mutex
+	filter Filter
+	cache  map[interface{}]DocIdSet
+
+	// for testing
+	hitCount, missCount int
+}
+
+/* Wraps another filter's result and caches it. */
+func NewCachingWrapperFilter(filter Filter) *CachingWrapperFilter {
+	return &CachingWrapperFilter{filter: filter, cache: make(map[interface{}]DocIdSet)}
+}
+
+/* Gets the contained filter. */
+func (f *CachingWrapperFilter) Filter() Filter {
+	return f.filter
+}
+
+/*
+Provide the DocIdSet to be cached, using the DocIdSet provided by the
+wrapped Filter. Sets which are not cacheable, e.g. lazily computed
+from a query, are copied into a compact representation by
+CompactDocIdSet(). Returns EMPTY_DOC_ID_SET if the set is empty.
+*/
+func (f *CachingWrapperFilter) docIdSetToCache(docIdSet DocIdSet, maxDoc int) (DocIdSet, error) {
+	if docIdSet == nil {
+		return EMPTY_DOC_ID_SET, nil
+	}
+	if docIdSet.IsCacheable() {
+		if _, ok := docIdSet.(*util.FixedBitSet); !ok {
+			return docIdSet, nil
+		}
+		// a bit set may be made smaller
+	}
+	it, err := docIdSet.Iterator()
+	if err != nil {
+		return nil, err
+	}
+	if it == nil {
+		return EMPTY_DOC_ID_SET, nil
+	}
+	return CompactDocIdSet(it, maxDoc)
+}
+
+func (f *CachingWrapperFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error) {
+	reader := ctx.Reader().(index.AtomicReader)
+	var key interface{} = reader
+	if sr, ok := reader.(*index.SegmentReader); ok {
+		key = sr.CoreCacheKey()
+	}
+
+	f.Lock()
+	docIdSet, ok := f.cache[key]
+	if ok {
+		f.hitCount++
+	} else {
+		f.missCount++
+	}
+	f.Unlock()
+
+	if !ok {
+		set, err := f.filter.DocIdSet(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		if docIdSet, err = f.docIdSetToCache(set, reader.MaxDoc()); err != nil {
+			return nil, err
+		}
+		assert(docIdSet.IsCacheable())
+		f.Lock()
+		if _, ok = f.cache[key]; !ok {
+			if sr, isSegment := reader.(*index.SegmentReader); isSegment {
+				// drops the set once the segment is merged away
+				sr.AddCoreClosedListener(f)
+			}
+		}
+		f.cache[key] = docIdSet
+		f.Unlock()
+	}
+
+	if docIdSet == EMPTY_DOC_ID_SET {
+		return nil, nil
+	}
+	return wrapBitsFilteredDocIdSet(docIdSet, acceptDocs), nil
+}
+
+/* Drops the cached set of a closed segment core. */
+func (f *CachingWrapperFilter) OnClose(ownerCoreCacheKey interface{}) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.cache, ownerCoreCacheKey)
+}
+
+/* Drops the cached set of the segment of the given reader. */
+func (f *CachingWrapperFilter) Evict(r index.AtomicReader) {
+	var key interface{} = r
+	if sr, ok := r.(*index.SegmentReader); ok {
+		key = sr.CoreCacheKey()
+	}
+	f.Lock()
+	defer f.Unlock()
+	delete(f.cache, key)
+}
+
+/* Returns the total memory used by the cached sets. */
+func (f *CachingWrapperFilter) RamBytesUsed() int64 {
+	f.Lock()
+	defer f.Unlock()
+	var total int64
+	for _, set := range f.cache {
+		total += set.RamBytesUsed()
+	}
+	return total
+}
+
+/* Returns the cached set of each segment. */
+func (f *CachingWrapperFilter) ChildResources() []util.Accountable {
+	f.Lock()
+	defer f.Unlock()
+	var ans []util.Accountable
+	for key, set := range f.cache {
+		ans = append(ans, util.NamedAccountableOf(fmt.Sprintf("segment '%v'", key), set))
+	}
+	sort.Sort(accountablesByName(ans))
+	return ans
+}
+
+type accountablesByName []util.Accountable
+
+func (a accountablesByName) Len() int           { return len(a) }
+func (a accountablesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a accountablesByName) Less(i, j int) bool { return fmt.Sprint(a[i]) < fmt.Sprint(a[j]) }
+
+func (f *CachingWrapperFilter) String() string {
+	return fmt.Sprintf("CachingWrapperFilter(%v)", f.filter)
+}
+
+/*
+Copies the docs of the iterator into the smallest of an
+IntArrayDocIdSet or a FixedBitSet, a RoaringDocIdSet and an
+EliasFanoDocIdSet. Dense sets are kept as a bit set, which also
+supports random access; for sparse sets the compressed
+representations take a fraction of the maxDoc/8 bytes of the bit set,
+which is never allocated for the sparsest ones.
+*/
+func CompactDocIdSet(it DocIdSetIterator, maxDoc int) (DocIdSet, error) {
+	builder := util.NewDocIdSetBuilder(maxDoc)
+	roaring := util.NewRoaringDocIdSetBuilder(maxDoc)
+	cardinality := 0
+	for {
+		doc, err := it.NextDoc()
+		if err != nil {
+			return nil, err
+		}
+		if doc == NO_MORE_DOCS {
+			break
+		}
+		builder.Add(doc)
+		roaring.Add(doc)
+		cardinality++
+	}
+	if cardinality == 0 {
+		return EMPTY_DOC_ID_SET, nil
+	}
+
+	var ans DocIdSet = builder.Build()
+	if set := roaring.Build(); set.RamBytesUsed() < ans.RamBytesUsed() {
+		ans = set
+	}
+	if packed.EliasFanoSufficientlySmallerThanBitSet(int64(cardinality), int64(maxDoc)) {
+		set := packed.NewEliasFanoDocIdSet(cardinality, maxDoc-1)
+		it, err := ans.Iterator()
+		if err == nil {
+			err = set.EncodeFromDisi(it)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if set.RamBytesUsed() < ans.RamBytesUsed() {
+			ans = set
+		}
+	}
+	return ans, nil
 }
@@ -0,0 +1,173 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+)
+
+/* How the scores of a query are normalized before being combined. */
+type ScoreNormalization int
+
+const (
+	// Raw scores.
+	SCORE_NORMALIZATION_NONE = ScoreNormalization(iota)
+	// (score - min) / (max - min), so that scores are in [0,1]; all
+	// scores are 1 if they are equal.
+	SCORE_NORMALIZATION_MIN_MAX
+	// score / sqrt(sum of squared scores).
+	SCORE_NORMALIZATION_L2
+)
+
+/* Normalizes the scores in place. */
+func (n ScoreNormalization) Normalize(scores []float32) {
+	if len(scores) == 0 {
+		return
+	}
+	switch n {
+	case SCORE_NORMALIZATION_NONE:
+	case SCORE_NORMALIZATION_MIN_MAX:
+		min, max := scores[0], scores[0]
+		for _, s := range scores {
+			min, max = float32(math.Min(float64(min), float64(s))), float32(math.Max(float64(max), float64(s)))
+		}
+		for i, s := range scores {
+			if max > min {
+				scores[i] = (s - min) / (max - min)
+			} else {
+				scores[i] = 1
+			}
+		}
+	case SCORE_NORMALIZATION_L2:
+		var sum float64
+		for _, s := range scores {
+			sum += float64(s) * float64(s)
+		}
+		if sum > 0 {
+			norm := float32(math.Sqrt(sum))
+			for i, s := range scores {
+				scores[i] = s / norm
+			}
+		}
+	default:
+		panic(fmt.Sprintf("unknown score normalization: %v", int(n)))
+	}
+}
+
+func (n ScoreNormalization) String() string {
+	switch n {
+	case SCORE_NORMALIZATION_NONE:
+		return "none"
+	case SCORE_NORMALIZATION_MIN_MAX:
+		return "min_max"
+	case SCORE_NORMALIZATION_L2:
+		return "l2"
+	}
+	return fmt.Sprintf("ScoreNormalization(%v)", int(n))
+}
+
+/*
+A query that ranks documents by a linear combination of the scores
+of a lexical query, e.g. a full-text query, and of a vector query,
+e.g. a KnnVectorQuery:
+
+	score = lexicalWeight * norm(lexical score) + vectorWeight * norm(vector score)
+
+Both queries are run when the Weight is created, and only their top
+window hits are combined, with a score of 0 for the query which did
+not match; a query with a weight of 0 is not run at all.
+
+Scores are normalized over these hits, with min-max by default, as
+lexical scores are unbounded while vector similarities are not, and
+raw scores would not weigh against each other as configured.
+*/
+type HybridQuery struct {
+	*AbstractQuery
+	lexical, vector             Query
+	lexicalWeight, vectorWeight float32
+	normalization               ScoreNormalization
+	window                      int
+}
+
+/*
+Combines the top window hits of the lexical and the vector queries,
+with equal weights and min-max normalization.
+*/
+func NewHybridQuery(lexical, vector Query, window int) *HybridQuery {
+	assert2(window > 0, "window must be at least 1, got: %v", window)
+	ans := &HybridQuery{
+		lexical:       lexical,
+		vector:        vector,
+		lexicalWeight: 1,
+		vectorWeight:  1,
+		normalization: SCORE_NORMALIZATION_MIN_MAX,
+		window:        window,
+	}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Sets the weights of the normalized lexical and vector scores. */
+func (q *HybridQuery) SetWeights(lexical, vector float32) *HybridQuery {
+	assert2(lexical >= 0 && vector >= 0, "weights must not be negative: %v, %v", lexical, vector)
+	q.lexicalWeight, q.vectorWeight = lexical, vector
+	return q
+}
+
+func (q *HybridQuery) SetNormalization(normalization ScoreNormalization) *HybridQuery {
+	q.normalization = normalization
+	return q
+}
+
+func (q *HybridQuery) Rewrite(r index.IndexReader) Query {
+	lexical, vector := q.lexical.Rewrite(r), q.vector.Rewrite(r)
+	if lexical == q.lexical && vector == q.vector {
+		return q
+	}
+	ans := NewHybridQuery(lexical, vector, q.window).
+		SetWeights(q.lexicalWeight, q.vectorWeight).
+		SetNormalization(q.normalization)
+	ans.SetBoost(q.Boost())
+	return ans
+}
+
+func (q *HybridQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	scores := make(map[int]float32)
+	for _, sub := range []struct {
+		query  Query
+		weight float32
+	}{{q.lexical, q.lexicalWeight}, {q.vector, q.vectorWeight}} {
+		if sub.weight == 0 {
+			continue // its hits would not be scored anyway
+		}
+		docs, err := ss.SearchTop(sub.query, q.window)
+		if err != nil {
+			return nil, err
+		}
+		subScores := make([]float32, len(docs.ScoreDocs))
+		for i, hit := range docs.ScoreDocs {
+			subScores[i] = hit.Score
+		}
+		q.normalization.Normalize(subScores)
+		for i, hit := range docs.ScoreDocs {
+			scores[hit.Doc] += sub.weight * subScores[i]
+		}
+	}
+	hits := make([]*ScoreDoc, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, newScoreDoc(doc, score))
+	}
+	return newDocAndScoreWeight(q, hits, fmt.Sprintf(
+		"%v * %v lexical score + %v * %v vector score",
+		q.lexicalWeight, q.normalization, q.vectorWeight, q.normalization)), nil
+}
+
+func (q *HybridQuery) ToString(field string) string {
+	ans := fmt.Sprintf("hybrid(%v^%v, %v^%v, %v)",
+		q.lexical.ToString(field), q.lexicalWeight,
+		q.vector.ToString(field), q.vectorWeight, q.normalization)
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
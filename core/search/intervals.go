@@ -0,0 +1,834 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// search/intervals/IntervalsSource.java
+
+/*
+A source of intervals, i.e. ranges of positions, in the documents of
+a field: the positions of a term, of a phrase, of terms within a
+given distance of each other, etc. Sources are built with the
+Interval* functions, combined, and matched by an IntervalQuery.
+
+Intervals are minimal: a source never returns an interval which
+contains another of its intervals in the same document. For example,
+in "a a b", IntervalOrdered(IntervalTerm("a"), IntervalTerm("b"))
+returns [1,2] only.
+*/
+type IntervalsSource interface {
+	// Returns the intervals of the field in the segment, or nil if the
+	// source cannot match any document there.
+	intervals(field string, ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error)
+	// Adds the terms of the source, for scoring.
+	terms(ans map[string]bool)
+	String() string
+}
+
+/* An interval of positions, both inclusive. */
+type interval struct {
+	start, end int
+	// number of positions within the interval which are not covered
+	// by the intervals it was made of
+	gaps int
+}
+
+/*
+Iterates the documents where a source has intervals. The intervals of
+a document are computed when the iterator is positioned on it.
+*/
+type intervalIterator struct {
+	approximation DocIdSetIterator // candidate documents
+	compute       func(doc int) ([]interval, error)
+	intervals     []interval
+}
+
+func (it *intervalIterator) DocId() int {
+	return it.approximation.DocId()
+}
+
+func (it *intervalIterator) NextDoc() (int, error) {
+	doc, err := it.approximation.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	return it.matches(doc)
+}
+
+func (it *intervalIterator) Advance(target int) (int, error) {
+	doc, err := it.approximation.Advance(target)
+	if err != nil {
+		return 0, err
+	}
+	return it.matches(doc)
+}
+
+/* Moves to the first candidate from doc with intervals. */
+func (it *intervalIterator) matches(doc int) (int, error) {
+	for doc != NO_MORE_DOCS {
+		var err error
+		if it.intervals, err = it.compute(doc); err != nil {
+			return 0, err
+		}
+		if len(it.intervals) > 0 {
+			return doc, nil
+		}
+		if doc, err = it.approximation.NextDoc(); err != nil {
+			return 0, err
+		}
+	}
+	it.intervals = nil
+	return NO_MORE_DOCS, nil
+}
+
+func (it *intervalIterator) Cost() int64 {
+	return it.approximation.Cost()
+}
+
+/* Returns the intervals of all sources, or nil if any has none. */
+func subIntervals(sources []IntervalsSource, field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) ([]*intervalIterator, error) {
+
+	ans := make([]*intervalIterator, len(sources))
+	for i, source := range sources {
+		it, err := source.intervals(field, ctx, acceptDocs)
+		if it == nil || err != nil {
+			return nil, err
+		}
+		ans[i] = it
+	}
+	return ans, nil
+}
+
+/* Returns the intervals over the documents where all sub-iterators have intervals. */
+func newConjunctionIntervals(subs []*intervalIterator,
+	fn func(subs [][]interval) []interval) *intervalIterator {
+
+	approximation := &intervalConjunction{subs: subs, doc: -1}
+	args := make([][]interval, len(subs))
+	return &intervalIterator{
+		approximation: approximation,
+		compute: func(doc int) ([]interval, error) {
+			for i, sub := range subs {
+				args[i] = sub.intervals
+			}
+			return fn(args), nil
+		},
+	}
+}
+
+/* Documents on which all sub-iterators are positioned. */
+type intervalConjunction struct {
+	subs []*intervalIterator
+	doc  int
+}
+
+func (c *intervalConjunction) DocId() int {
+	return c.doc
+}
+
+func (c *intervalConjunction) NextDoc() (int, error) {
+	doc, err := c.subs[0].NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	return c.doNext(doc)
+}
+
+func (c *intervalConjunction) Advance(target int) (int, error) {
+	doc, err := c.subs[0].Advance(target)
+	if err != nil {
+		return 0, err
+	}
+	return c.doNext(doc)
+}
+
+/* Leapfrogs the sub-iterators until they agree on a document. */
+func (c *intervalConjunction) doNext(doc int) (int, error) {
+	var err error
+	for i := 1; i < len(c.subs) && doc != NO_MORE_DOCS; {
+		other := c.subs[i].DocId()
+		if other < doc {
+			if other, err = c.subs[i].Advance(doc); err != nil {
+				return 0, err
+			}
+		}
+		if other > doc {
+			if doc, err = c.subs[0].Advance(other); err != nil {
+				return 0, err
+			}
+			i = 1
+			continue
+		}
+		i++
+	}
+	c.doc = doc
+	return doc, nil
+}
+
+func (c *intervalConjunction) Cost() int64 {
+	ans := c.subs[0].Cost()
+	for _, sub := range c.subs[1:] {
+		if cost := sub.Cost(); cost < ans {
+			ans = cost
+		}
+	}
+	return ans
+}
+
+/* Returns the intervals which do not contain any other interval, sorted by start. */
+func minimize(intervals []interval) []interval {
+	sort.Sort(byStartAndEnd(intervals))
+	ans := intervals[:0]
+	for i, candidate := range intervals {
+		// the next one has the same start and a smaller or equal end, or
+		// a larger start; only the latter can be within the candidate
+		if i+1 < len(intervals) && intervals[i+1].start == candidate.start {
+			continue
+		}
+		// drop the previous ones containing this one
+		for n := len(ans); n > 0 && ans[n-1].end >= candidate.end; n = len(ans) {
+			ans = ans[:n-1]
+		}
+		ans = append(ans, candidate)
+	}
+	return ans
+}
+
+type byStartAndEnd []interval
+
+func (a byStartAndEnd) Len() int      { return len(a) }
+func (a byStartAndEnd) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byStartAndEnd) Less(i, j int) bool {
+	if a[i].start != a[j].start {
+		return a[i].start < a[j].start
+	}
+	return a[i].end > a[j].end
+}
+
+/* Returns the interval spanning the given ones, which must not overlap. */
+func spanning(parts []interval) interval {
+	ans := interval{parts[0].start, parts[0].end, 0}
+	covered := 0
+	for _, part := range parts {
+		if part.start < ans.start {
+			ans.start = part.start
+		}
+		if part.end > ans.end {
+			ans.end = part.end
+		}
+		covered += part.end - part.start + 1
+	}
+	ans.gaps = ans.end - ans.start + 1 - covered
+	return ans
+}
+
+func formatSources(name string, sources []IntervalsSource) string {
+	parts := make([]string, len(sources))
+	for i, source := range sources {
+		parts[i] = source.String()
+	}
+	return fmt.Sprintf("%v(%v)", name, strings.Join(parts, ","))
+}
+
+// search/intervals/TermIntervalsSource.java
+
+type termIntervalsSource string
+
+/* Returns the positions of a term, as intervals of length 1. */
+func IntervalTerm(term string) IntervalsSource {
+	return termIntervalsSource(term)
+}
+
+func (s termIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	terms := ctx.Reader().(index.AtomicReader).Terms(field)
+	if terms == nil {
+		return nil, nil
+	}
+	termsEnum := terms.Iterator(nil)
+	if ok, err := termsEnum.SeekExact([]byte(s)); !ok || err != nil {
+		return nil, err
+	}
+	postings, err := termsEnum.DocsAndPositions(acceptDocs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if postings == nil {
+		return nil, &PositionsNotIndexedError{field, "IntervalQuery"}
+	}
+	var buf []interval
+	return &intervalIterator{
+		approximation: postings,
+		compute: func(doc int) ([]interval, error) {
+			freq, err := postings.Freq()
+			if err != nil {
+				return nil, err
+			}
+			buf = buf[:0]
+			for i := 0; i < freq; i++ {
+				pos, err := postings.NextPosition()
+				if err != nil {
+					return nil, err
+				}
+				buf = append(buf, interval{pos, pos, 0})
+			}
+			return buf, nil
+		},
+	}, nil
+}
+
+func (s termIntervalsSource) terms(ans map[string]bool) {
+	ans[string(s)] = true
+}
+
+func (s termIntervalsSource) String() string {
+	return string(s)
+}
+
+// search/intervals/BlockIntervalsSource.java
+
+type blockIntervalsSource []IntervalsSource
+
+/* Returns the intervals of the terms appearing next to each other, in order. */
+func IntervalPhrase(terms ...string) IntervalsSource {
+	sources := make([]IntervalsSource, len(terms))
+	for i, term := range terms {
+		sources[i] = IntervalTerm(term)
+	}
+	return IntervalBlock(sources...)
+}
+
+/*
+Returns the intervals of the sources appearing next to each other, in
+order, i.e. each starting right after the end of the previous one.
+*/
+func IntervalBlock(sources ...IntervalsSource) IntervalsSource {
+	assert2(len(sources) > 0, "at least one source is required")
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return blockIntervalsSource(sources)
+}
+
+func (s blockIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	subs, err := subIntervals(s, field, ctx, acceptDocs)
+	if subs == nil || err != nil {
+		return nil, err
+	}
+	return newConjunctionIntervals(subs, func(subs [][]interval) (ans []interval) {
+		for _, first := range subs[0] {
+			block := first
+			for _, sub := range subs[1:] {
+				next := -1
+				for i, candidate := range sub {
+					if candidate.start == block.end+1 {
+						next = i
+						break
+					}
+				}
+				if next < 0 {
+					block.start = -1
+					break
+				}
+				block.end, block.gaps = sub[next].end, block.gaps+sub[next].gaps
+			}
+			if block.start >= 0 {
+				ans = append(ans, block)
+			}
+		}
+		return minimize(ans)
+	}), nil
+}
+
+func (s blockIntervalsSource) terms(ans map[string]bool) {
+	for _, source := range s {
+		source.terms(ans)
+	}
+}
+
+func (s blockIntervalsSource) String() string {
+	return formatSources("BLOCK", s)
+}
+
+// search/intervals/OrderedIntervalsSource.java
+
+type orderedIntervalsSource []IntervalsSource
+
+/*
+Returns the intervals in which the sources appear in order, without
+overlapping. Use IntervalMaxGaps() to limit the distance between them.
+*/
+func IntervalOrdered(sources ...IntervalsSource) IntervalsSource {
+	assert2(len(sources) > 0, "at least one source is required")
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return orderedIntervalsSource(sources)
+}
+
+func (s orderedIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	subs, err := subIntervals(s, field, ctx, acceptDocs)
+	if subs == nil || err != nil {
+		return nil, err
+	}
+	parts := make([]interval, len(subs))
+	return newConjunctionIntervals(subs, func(subs [][]interval) (ans []interval) {
+		// from each interval of the first source, takes the interval
+		// ending first after the previous one, which gives the smallest
+		// match starting there
+	candidates:
+		for _, first := range subs[0] {
+			parts[0] = first
+			for i, sub := range subs[1:] {
+				next := -1
+				for j, candidate := range sub {
+					if candidate.start > parts[i].end && (next < 0 || candidate.end < sub[next].end) {
+						next = j
+					}
+				}
+				if next < 0 {
+					continue candidates
+				}
+				parts[i+1] = sub[next]
+			}
+			ans = append(ans, spanning(parts))
+		}
+		return minimize(ans)
+	}), nil
+}
+
+func (s orderedIntervalsSource) terms(ans map[string]bool) {
+	for _, source := range s {
+		source.terms(ans)
+	}
+}
+
+func (s orderedIntervalsSource) String() string {
+	return formatSources("ORDERED", s)
+}
+
+// search/intervals/UnorderedIntervalsSource.java
+
+type unorderedIntervalsSource []IntervalsSource
+
+/*
+Returns the intervals in which all the sources appear, in any order.
+The intervals of different sources may overlap, so that e.g.
+IntervalUnordered(IntervalTerm("a"), IntervalPhrase("a", "b")) matches
+"a b". Use IntervalMaxGaps() to limit the distance between them.
+*/
+func IntervalUnordered(sources ...IntervalsSource) IntervalsSource {
+	assert2(len(sources) > 0, "at least one source is required")
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return unorderedIntervalsSource(sources)
+}
+
+func (s unorderedIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	subs, err := subIntervals(s, field, ctx, acceptDocs)
+	if subs == nil || err != nil {
+		return nil, err
+	}
+	parts := make([]interval, len(subs))
+	return newConjunctionIntervals(subs, func(subs [][]interval) (ans []interval) {
+		// from each start, takes the interval of each source ending first
+		// after it, which gives the smallest match starting there
+		var starts []int
+		for _, sub := range subs {
+			for _, v := range sub {
+				starts = append(starts, v.start)
+			}
+		}
+	candidates:
+		for _, from := range starts {
+			for i, sub := range subs {
+				next := -1
+				for j, candidate := range sub {
+					if candidate.start >= from && (next < 0 || candidate.end < sub[next].end) {
+						next = j
+					}
+				}
+				if next < 0 {
+					continue candidates
+				}
+				parts[i] = sub[next]
+			}
+			ans = append(ans, coveringWithOverlaps(parts))
+		}
+		return minimize(ans)
+	}), nil
+}
+
+/* Returns the interval spanning the given ones, which may overlap. */
+func coveringWithOverlaps(parts []interval) interval {
+	sorted := append([]interval(nil), parts...)
+	sort.Sort(byStartAndEnd(sorted))
+	ans := interval{sorted[0].start, sorted[0].end, 0}
+	for _, part := range sorted[1:] {
+		if part.start > ans.end+1 {
+			ans.gaps += part.start - ans.end - 1
+		}
+		if part.end > ans.end {
+			ans.end = part.end
+		}
+	}
+	return ans
+}
+
+func (s unorderedIntervalsSource) terms(ans map[string]bool) {
+	for _, source := range s {
+		source.terms(ans)
+	}
+}
+
+func (s unorderedIntervalsSource) String() string {
+	return formatSources("UNORDERED", s)
+}
+
+// search/intervals/MaxGapsIntervalsSource.java
+
+type maxGapsIntervalsSource struct {
+	source  IntervalsSource
+	maxGaps int
+}
+
+/*
+Returns the intervals of the source with at most maxGaps positions
+which are not covered by their parts, e.g. IntervalMaxGaps(
+IntervalOrdered(a, b), 2) matches "a x y b" but not "a x y z b".
+*/
+func IntervalMaxGaps(source IntervalsSource, maxGaps int) IntervalsSource {
+	assert2(maxGaps >= 0, "maxGaps must not be negative, got: %v", maxGaps)
+	return &maxGapsIntervalsSource{source, maxGaps}
+}
+
+func (s *maxGapsIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	sub, err := s.source.intervals(field, ctx, acceptDocs)
+	if sub == nil || err != nil {
+		return nil, err
+	}
+	return &intervalIterator{
+		approximation: sub,
+		compute: func(doc int) (ans []interval, err error) {
+			for _, v := range sub.intervals {
+				if v.gaps <= s.maxGaps {
+					ans = append(ans, v)
+				}
+			}
+			return
+		},
+	}, nil
+}
+
+func (s *maxGapsIntervalsSource) terms(ans map[string]bool) {
+	s.source.terms(ans)
+}
+
+func (s *maxGapsIntervalsSource) String() string {
+	return fmt.Sprintf("MAXGAPS/%v(%v)", s.maxGaps, s.source)
+}
+
+// search/intervals/ContainingIntervalsSource.java
+
+type containingIntervalsSource struct {
+	big, small IntervalsSource
+}
+
+/* Returns the intervals of big which contain an interval of small. */
+func IntervalContaining(big, small IntervalsSource) IntervalsSource {
+	return &containingIntervalsSource{big, small}
+}
+
+func (s *containingIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	subs, err := subIntervals([]IntervalsSource{s.big, s.small}, field, ctx, acceptDocs)
+	if subs == nil || err != nil {
+		return nil, err
+	}
+	return newConjunctionIntervals(subs, func(subs [][]interval) (ans []interval) {
+		for _, v := range subs[0] {
+			if containsAny(v, subs[1]) {
+				ans = append(ans, v)
+			}
+		}
+		return
+	}), nil
+}
+
+func (s *containingIntervalsSource) terms(ans map[string]bool) {
+	s.big.terms(ans)
+	s.small.terms(ans)
+}
+
+func (s *containingIntervalsSource) String() string {
+	return fmt.Sprintf("CONTAINING(%v,%v)", s.big, s.small)
+}
+
+func containsAny(v interval, others []interval) bool {
+	for _, other := range others {
+		if v.start <= other.start && other.end <= v.end {
+			return true
+		}
+	}
+	return false
+}
+
+// search/intervals/NotContainingIntervalsSource.java
+
+type notContainingIntervalsSource struct {
+	minuend, subtrahend IntervalsSource
+}
+
+/*
+Returns the intervals of minuend which do not contain any interval of
+subtrahend, e.g. IntervalNotContaining(IntervalMaxGaps(IntervalOrdered(
+a, b), 3), IntervalTerm("c")) matches "a x b", but not "a c b".
+*/
+func IntervalNotContaining(minuend, subtrahend IntervalsSource) IntervalsSource {
+	return &notContainingIntervalsSource{minuend, subtrahend}
+}
+
+func (s *notContainingIntervalsSource) intervals(field string,
+	ctx *index.AtomicReaderContext, acceptDocs util.Bits) (*intervalIterator, error) {
+
+	minuend, err := s.minuend.intervals(field, ctx, acceptDocs)
+	if minuend == nil || err != nil {
+		return nil, err
+	}
+	subtrahend, err := s.subtrahend.intervals(field, ctx, acceptDocs)
+	if err != nil {
+		return nil, err
+	}
+	if subtrahend == nil {
+		return minuend, nil
+	}
+	return &intervalIterator{
+		approximation: minuend,
+		compute: func(doc int) (ans []interval, err error) {
+			other := subtrahend.DocId()
+			if other < doc {
+				if other, err = subtrahend.Advance(doc); err != nil {
+					return nil, err
+				}
+			}
+			if other != doc {
+				return minuend.intervals, nil
+			}
+			for _, v := range minuend.intervals {
+				if !containsAny(v, subtrahend.intervals) {
+					ans = append(ans, v)
+				}
+			}
+			return
+		},
+	}, nil
+}
+
+func (s *notContainingIntervalsSource) terms(ans map[string]bool) {
+	s.minuend.terms(ans)
+}
+
+func (s *notContainingIntervalsSource) String() string {
+	return fmt.Sprintf("NOT_CONTAINING(%v,%v)", s.minuend, s.subtrahend)
+}
+
+// search/intervals/IntervalQuery.java
+
+/*
+A query matching the documents where an IntervalsSource has intervals
+in a field. Like a sloppy phrase query, each interval adds
+1/(1+gaps) to the frequency the document is scored with, using the
+statistics of all the terms of the source.
+
+Fields must be indexed with positions, or a PositionsNotIndexedError
+is returned by the search.
+*/
+type IntervalQuery struct {
+	*AbstractQuery
+	field  string
+	source IntervalsSource
+}
+
+func NewIntervalQuery(field string, source IntervalsSource) *IntervalQuery {
+	ans := &IntervalQuery{field: field, source: source}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *IntervalQuery) Field() string { return q.field }
+
+func (q *IntervalQuery) Source() IntervalsSource { return q.source }
+
+/*
+Returns the terms of the source, sorted, except those which prevent a
+match (e.g. the subtrahend of IntervalNotContaining()).
+*/
+func (q *IntervalQuery) Terms() []*index.Term {
+	terms := make(map[string]bool)
+	q.source.terms(terms)
+	sorted := make([]string, 0, len(terms))
+	for term := range terms {
+		sorted = append(sorted, term)
+	}
+	sort.Strings(sorted)
+	ans := make([]*index.Term, len(sorted))
+	for i, text := range sorted {
+		ans[i] = index.NewTerm(q.field, text)
+	}
+	return ans
+}
+
+func (q *IntervalQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	terms := q.Terms()
+	termStats := make([]TermStatistics, len(terms))
+	for i, term := range terms {
+		termContext, err := index.NewTermContextFromTerm(ss.TopReaderContext(), term)
+		if err != nil {
+			return nil, err
+		}
+		termStats[i] = ss.TermStatistics(term, termContext)
+	}
+	ans := &intervalWeight{
+		IntervalQuery: q,
+		similarity:    ss.similarity,
+		stats:         ss.similarity.computeWeight(q.Boost(), ss.CollectionStatistics(q.field), termStats...),
+	}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+func (q *IntervalQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.field != field {
+		fmt.Fprintf(&buf, "%v:", q.field)
+	}
+	buf.WriteString(q.source.String())
+	if q.Boost() != 1.0 {
+		fmt.Fprintf(&buf, "^%v", q.Boost())
+	}
+	return buf.String()
+}
+
+type intervalWeight struct {
+	*WeightImpl
+	*IntervalQuery
+	similarity Similarity
+	stats      SimWeight
+}
+
+func (w *intervalWeight) ValueForNormalization() float32 {
+	return w.stats.ValueForNormalization()
+}
+
+func (w *intervalWeight) Normalize(norm, topLevelBoost float32) {
+	w.stats.Normalize(norm, topLevelBoost)
+}
+
+func (w *intervalWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *intervalWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	if err := CheckPositionsIndexed(ctx, w.field, "IntervalQuery"); err != nil {
+		return nil, err
+	}
+	intervals, err := w.source.intervals(w.field, ctx, acceptDocs)
+	if intervals == nil || err != nil {
+		return nil, err
+	}
+	simScorer, err := w.similarity.simScorer(w.stats, ctx)
+	if err != nil {
+		return nil, err
+	}
+	ans := &intervalScorer{intervals: intervals, docScorer: simScorer}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans, nil
+}
+
+func (w *intervalWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	scorer, err := w.Scorer(ctx, ctx.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	if scorer != nil {
+		newDoc, err := scorer.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		if newDoc == doc {
+			freq := scorer.(*intervalScorer).sloppyFreq()
+			scoreExplanation := scorer.(*intervalScorer).docScorer.explain(doc,
+				newExplanation(freq, fmt.Sprintf("intervalFreq=%v", freq)))
+			ans := newComplexExplanation(true,
+				scoreExplanation.(*ExplanationImpl).value,
+				fmt.Sprintf("weight(%v in %v) [%v], result of:",
+					w.IntervalQuery, doc, reflect.TypeOf(w.similarity)))
+			ans.details = []Explanation{scoreExplanation}
+			return ans, nil
+		}
+	}
+	return newComplexExplanation(false, 0, "no matching intervals"), nil
+}
+
+type intervalScorer struct {
+	*abstractScorer
+	intervals *intervalIterator
+	docScorer SimScorer
+}
+
+func (s *intervalScorer) DocId() int {
+	return s.intervals.DocId()
+}
+
+func (s *intervalScorer) NextDoc() (int, error) {
+	return s.intervals.NextDoc()
+}
+
+func (s *intervalScorer) Advance(target int) (int, error) {
+	return s.intervals.Advance(target)
+}
+
+/* Returns the number of intervals in the current document. */
+func (s *intervalScorer) Freq() (int, error) {
+	return len(s.intervals.intervals), nil
+}
+
+func (s *intervalScorer) sloppyFreq() float32 {
+	var ans float32
+	for _, v := range s.intervals.intervals {
+		ans += 1 / float32(1+v.gaps)
+	}
+	return ans
+}
+
+func (s *intervalScorer) Score() (float32, error) {
+	return s.docScorer.Score(s.DocId(), s.sloppyFreq()), nil
+}
+
+func (s *intervalScorer) Cost() int64 {
+	return s.intervals.Cost()
+}
+
+func (s *intervalScorer) String() string {
+	return fmt.Sprintf("scorer(%v)", s.weight)
+}
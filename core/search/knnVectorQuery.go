@@ -0,0 +1,218 @@
+package search
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// search/KnnVectorQuery.java
+
+/*
+A query that matches the K documents whose vectors are the nearest
+to the target vector, scored by their similarity with it, using the
+similarity the field was indexed with (see document.VectorField).
+
+The search is approximate: the HNSW graph of the vectors of each
+segment is searched with a queue of size ef (see SetEf()) when the
+Weight is created. Candidates are all live documents with a vector,
+or only those accepted by the filter if there is one. Note that the
+filter is applied before selecting the top K, unlike wrapping the
+query in a FilteredQuery, which may return less than K hits.
+
+The vectors of a segment are instead all compared with the target
+when SetExact() is used, when the filter accepts no more than ef
+documents, or when the filter leaves the graph search with less than
+K hits.
+*/
+type KnnVectorQuery struct {
+	*AbstractQuery
+	field  string
+	target []float32
+	k      int
+	ef     int
+	exact  bool
+	filter Filter
+}
+
+/*
+Creates a query for the k nearest neighbors of target in field. filter
+may be nil.
+*/
+func NewKnnVectorQuery(field string, target []float32, k int, filter Filter) *KnnVectorQuery {
+	assert2(k > 0, "k must be at least 1, got: %v", k)
+	assert2(len(target) > 0, "target vector cannot be empty")
+	ans := &KnnVectorQuery{
+		field:  field,
+		target: target,
+		k:      k,
+		ef:     k,
+		filter: filter,
+	}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Sets the size of the queue of the graph search, at least K: the
+larger, the better the recall, but the slower. Default is K.
+*/
+func (q *KnnVectorQuery) SetEf(ef int) *KnnVectorQuery {
+	assert2(ef >= q.k, "ef must be at least k (%v), got: %v", q.k, ef)
+	q.ef = ef
+	return q
+}
+
+/* Whether to compare all vectors with the target instead of searching the graph. */
+func (q *KnnVectorQuery) SetExact(exact bool) *KnnVectorQuery {
+	q.exact = exact
+	return q
+}
+
+func (q *KnnVectorQuery) Field() string { return q.field }
+
+func (q *KnnVectorQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	hits, err := q.search(ss.TopReaderContext().Leaves())
+	if err != nil {
+		return nil, err
+	}
+	return newDocAndScoreWeight(q, hits, fmt.Sprintf(
+		"similarity with target, within the %v nearest", q.k)), nil
+}
+
+/* Returns the top K hits among the candidates of all segments. */
+func (q *KnnVectorQuery) search(leaves []*index.AtomicReaderContext) ([]*ScoreDoc, error) {
+	pq := make(nearestQueue, 0, q.k)
+	for _, ctx := range leaves {
+		values, err := ctx.Reader().(index.AtomicReader).VectorValues(q.field)
+		if err != nil {
+			return nil, err
+		} else if values == nil || values.Size() == 0 {
+			continue // no vectors in this segment
+		}
+		if values.Dimension() != len(q.target) {
+			return nil, errors.New(fmt.Sprintf(
+				"vector field '%v' has %v dimensions but target has %v",
+				q.field, values.Dimension(), len(q.target)))
+		}
+		accept, candidates, err := q.acceptDocs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		exact := q.exact || candidates <= q.ef
+		if !exact {
+			neighbors, err := values.Search(q.target, q.k, q.ef, accept)
+			if err != nil {
+				return nil, err
+			}
+			if exact = len(neighbors) < q.k && q.filter != nil; !exact {
+				for _, n := range neighbors {
+					pq.insert(q.k, newScoreDoc(ctx.DocBase+n.Node, n.Score))
+				}
+			}
+		}
+		if exact {
+			if err = q.exactSearch(ctx, values, accept, &pq); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pq, nil
+}
+
+/* Compares the target with all vectors of the segment accepted by accept. */
+func (q *KnnVectorQuery) exactSearch(ctx *index.AtomicReaderContext,
+	values VectorValues, accept util.Bits, pq *nearestQueue) error {
+
+	for ord, size := 0, values.Size(); ord < size; ord++ {
+		doc := values.Doc(ord)
+		if accept != nil && !accept.At(doc) {
+			continue
+		}
+		score, err := values.Similarity().Compare(q.target, values.VectorValue(ord))
+		if err != nil {
+			return err
+		}
+		pq.insert(q.k, newScoreDoc(ctx.DocBase+doc, score))
+	}
+	return nil
+}
+
+/*
+Returns the live documents of the segment accepted by the filter, or
+nil if all documents are accepted, and their number.
+*/
+func (q *KnnVectorQuery) acceptDocs(ctx *index.AtomicReaderContext) (util.Bits, int, error) {
+	reader := ctx.Reader().(index.AtomicReader)
+	if q.filter == nil {
+		return reader.LiveDocs(), reader.NumDocs(), nil
+	}
+	accept := util.NewFixedBitSetOf(reader.MaxDoc())
+	set, err := q.filter.DocIdSet(ctx, reader.LiveDocs())
+	if set == nil || err != nil {
+		return accept, 0, err
+	}
+	it, err := set.Iterator()
+	if it == nil || err != nil {
+		return accept, 0, err
+	}
+	for count := 0; ; count++ {
+		doc, err := it.NextDoc()
+		if err != nil {
+			return nil, 0, err
+		} else if doc == NO_MORE_DOCS {
+			return accept, count, nil
+		}
+		accept.Set(doc)
+	}
+}
+
+func (q *KnnVectorQuery) ToString(field string) string {
+	search := fmt.Sprintf("ef=%v", q.ef)
+	if q.exact {
+		search = "exact"
+	}
+	ans := fmt.Sprintf("knn(%v,k=%v,%v)", q.field, q.k, search)
+	if q.filter != nil {
+		ans += fmt.Sprintf("->%v", q.filter)
+	}
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
+
+/* Min-heap of the nearest hits so far; the farthest one is on top. */
+type nearestQueue []*ScoreDoc
+
+/* Adds the hit if the queue holds less than k hits or it is nearer than the farthest one. */
+func (pq *nearestQueue) insert(k int, hit *ScoreDoc) {
+	if len(*pq) < k {
+		heap.Push(pq, hit)
+	} else if pq.less((*pq)[0], hit) {
+		(*pq)[0] = hit
+		heap.Fix(pq, 0)
+	}
+}
+
+func (pq nearestQueue) less(a, b *ScoreDoc) bool {
+	if a.Score == b.Score {
+		return a.Doc > b.Doc // prefer smaller doc ids on ties
+	}
+	return a.Score < b.Score
+}
+
+func (pq nearestQueue) Len() int            { return len(pq) }
+func (pq nearestQueue) Less(i, j int) bool  { return pq.less(pq[i], pq[j]) }
+func (pq nearestQueue) Swap(i, j int)       { pq[i], pq[j] = pq[j], pq[i] }
+func (pq *nearestQueue) Push(x interface{}) { *pq = append(*pq, x.(*ScoreDoc)) }
+func (pq *nearestQueue) Pop() interface{} {
+	n := len(*pq)
+	ans := (*pq)[n-1]
+	*pq = (*pq)[:n-1]
+	return ans
+}
@@ -0,0 +1,144 @@
+package search
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"sync"
+)
+
+// search/ReferenceManager.java#RefreshListener
+
+/*
+Notified around each reopen of a searcher, by the application code
+opening a new searcher once the index changed.
+*/
+type RefreshListener interface {
+	// Called right before a refresh attempt.
+	BeforeRefresh() error
+	// Called after the attempt, didRefresh telling if a new searcher
+	// was opened.
+	AfterRefresh(didRefresh bool) error
+}
+
+// search/LiveFieldValues.java
+
+/* Marks a value deleted since the last refresh. */
+type liveFieldDeleted struct{}
+
+/*
+Tracks the values of the recently indexed documents by ID, so that a
+"get by ID" returns the freshest value of a document, even when it
+was indexed after the current searcher was opened. Documents indexed
+before are looked up with the current searcher, by running a term
+query on the ID field and reading the value of the top hit from a
+FieldValueSource.
+
+Call Add() and Delete() after each update to the index, and
+BeforeRefresh() and AfterRefresh() around each reopen of the
+searcher, which must see all the documents indexed before
+BeforeRefresh() was called, e.g. because they were committed. Values
+are then forgotten once they are visible to the new searcher, so
+that memory use is bounded by the changes between two reopens.
+*/
+type LiveFieldValues struct {
+	sync.Mutex
+	current map[string]interface{}
+	old     map[string]interface{}
+
+	searcher func() *IndexSearcher
+	idField  string
+	source   FieldValueSource
+}
+
+/*
+Creates a LiveFieldValues falling back to the searcher returned by
+searcher, for documents whose ID is indexed in idField, and whose
+value is read from source.
+*/
+func NewLiveFieldValues(searcher func() *IndexSearcher, idField string,
+	source FieldValueSource) *LiveFieldValues {
+	return &LiveFieldValues{
+		current:  make(map[string]interface{}),
+		old:      make(map[string]interface{}),
+		searcher: searcher,
+		idField:  idField,
+		source:   source,
+	}
+}
+
+func (v *LiveFieldValues) BeforeRefresh() error {
+	v.Lock()
+	defer v.Unlock()
+	v.old = v.current
+	// Start sending all updates after this point to the new map.
+	// While reopen is running, any lookup will first try this new
+	// map, then fallback to old, then to the current searcher:
+	v.current = make(map[string]interface{})
+	return nil
+}
+
+func (v *LiveFieldValues) AfterRefresh(didRefresh bool) error {
+	v.Lock()
+	defer v.Unlock()
+	// Now drop all the old values because they are now visible via
+	// the searcher that was just opened; if didRefresh is false, it's
+	// possible old has some entries in it, which is fine: it means we
+	// were actually already current.
+	v.old = make(map[string]interface{})
+	return nil
+}
+
+/* Call this after you've successfully added a document to the index. */
+func (v *LiveFieldValues) Add(id string, value interface{}) {
+	v.Lock()
+	defer v.Unlock()
+	v.current[id] = value
+}
+
+/* Call this after you've successfully deleted a document from the index. */
+func (v *LiveFieldValues) Delete(id string) {
+	v.Lock()
+	defer v.Unlock()
+	v.current[id] = liveFieldDeleted{}
+}
+
+/* Returns the (approximate) number of IDs tracked. */
+func (v *LiveFieldValues) Size() int {
+	v.Lock()
+	defer v.Unlock()
+	return len(v.current) + len(v.old)
+}
+
+/*
+Returns the current value for this ID, or nil if the ID is unknown or
+was deleted.
+*/
+func (v *LiveFieldValues) Get(id string) (interface{}, error) {
+	v.Lock()
+	value, ok := v.current[id]
+	if !ok {
+		value, ok = v.old[id]
+	}
+	v.Unlock()
+	if ok {
+		if _, deleted := value.(liveFieldDeleted); deleted {
+			return nil, nil
+		}
+		return value, nil
+	}
+	// It either does not exist in the index, or, it was already
+	// visible to the current searcher when it was reopened, so
+	// fallback to the current searcher:
+	return v.lookupFromSearcher(v.searcher(), id)
+}
+
+func (v *LiveFieldValues) lookupFromSearcher(s *IndexSearcher, id string) (interface{}, error) {
+	topDocs, err := s.Search(NewTermQuery(index.NewTerm(v.idField, id)), nil, 1)
+	if err != nil || len(topDocs.ScoreDocs) == 0 {
+		return nil, err
+	}
+	hits, err := NewFetchPhase(s).AddField(v.idField, v.source).Fetch(topDocs.ScoreDocs)
+	if err != nil {
+		return nil, err
+	}
+	return hits[0].Fields[v.idField], nil
+}
@@ -0,0 +1,520 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// search/MultiTermQuery.java
+
+/*
+An abstract Query that matches documents containing a subset of terms
+provided by a TermsEnum, e.g. those of a prefix or an automaton.
+
+This query cannot be used directly; it must be rewritten first into
+another query, as decided by its RewriteMethod.
+CONSTANT_SCORE_AUTO_REWRITE_DEFAULT is used by default.
+*/
+type MultiTermQuery struct {
+	*AbstractQuery
+	spi           MultiTermQuerySPI
+	field         string
+	rewriteMethod RewriteMethod
+}
+
+type MultiTermQuerySPI interface {
+	/* Returns the enum of the terms matched by the query in terms. */
+	TermsEnum(terms Terms) TermsEnum
+}
+
+func newMultiTermQuery(field string, self interface{}) *MultiTermQuery {
+	return &MultiTermQuery{
+		AbstractQuery: NewAbstractQuery(self),
+		spi:           self.(MultiTermQuerySPI),
+		field:         field,
+		rewriteMethod: CONSTANT_SCORE_AUTO_REWRITE_DEFAULT,
+	}
+}
+
+/* Returns the field name for this query. */
+func (q *MultiTermQuery) Field() string {
+	return q.field
+}
+
+func (q *MultiTermQuery) RewriteMethod() RewriteMethod {
+	return q.rewriteMethod
+}
+
+/* Sets the rewrite method to be used when executing the query. */
+func (q *MultiTermQuery) SetRewriteMethod(method RewriteMethod) {
+	assert(method != nil)
+	q.rewriteMethod = method
+}
+
+/*
+Rewrites the query with its rewrite method. As a query is rewritten
+without an error, an error, e.g. from the terms dictionary or because
+of too many clauses, is returned when the rewritten query is searched.
+*/
+func (q *MultiTermQuery) Rewrite(r index.IndexReader) Query {
+	ans, err := q.rewriteMethod.Rewrite(r, q)
+	if err != nil {
+		return newFailedRewriteQuery(q.value, err)
+	}
+	return ans
+}
+
+/*
+Calls collect with each term of the query, and its enum, segment by
+segment, until it returns false. The term is only valid during the
+call.
+*/
+func (q *MultiTermQuery) collectTerms(r index.IndexReader,
+	collect func(term []byte, te TermsEnum) (bool, error)) error {
+
+	for _, ctx := range r.Leaves() {
+		terms := ctx.Reader().(index.AtomicReader).Terms(q.field)
+		if terms == nil {
+			continue
+		}
+		te := q.spi.TermsEnum(terms)
+		for {
+			term, err := te.Next()
+			if err != nil {
+				return err
+			}
+			if term == nil {
+				break
+			}
+			ok, err := collect(term, te)
+			if err != nil || !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/* The result of a rewrite which failed, returning its error when searched. */
+type failedRewriteQuery struct {
+	*AbstractQuery
+	query Query
+	err   error
+}
+
+func newFailedRewriteQuery(query Query, err error) *failedRewriteQuery {
+	ans := &failedRewriteQuery{query: query, err: err}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *failedRewriteQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	return nil, q.err
+}
+
+func (q *failedRewriteQuery) ToString(field string) string {
+	return q.query.ToString(field)
+}
+
+/* Abstract type that defines how the query is rewritten. */
+type RewriteMethod interface {
+	Rewrite(r index.IndexReader, q *MultiTermQuery) (Query, error)
+}
+
+var (
+	/*
+		A rewrite method that first creates a private Filter, by visiting
+		each term in sequence and marking all docs for that term. Matching
+		documents are assigned a constant score equal to the query's boost.
+
+		This method is faster than the BooleanQuery rewrite methods when
+		the number of matched terms or matched documents is non-trivial.
+		Also, it will never hit an errant TooManyClausesError.
+	*/
+	CONSTANT_SCORE_FILTER_REWRITE = RewriteMethod(constantScoreFilterRewrite{})
+
+	/*
+		A rewrite method that first translates each term into a SHOULD
+		clause in a BooleanQuery, and keeps the scores as computed by the
+		query. Note that typically such scores are meaningless to the user,
+		and require non-trivial CPU to compute, so it's almost always better
+		to use CONSTANT_SCORE_AUTO_REWRITE_DEFAULT instead.
+
+		NOTE: This rewrite method will hit TooManyClausesError if the number
+		of terms exceeds the maximum clause count of BooleanQuery.
+	*/
+	SCORING_BOOLEAN_QUERY_REWRITE = RewriteMethod(booleanQueryRewrite{false})
+
+	/*
+		Like SCORING_BOOLEAN_QUERY_REWRITE except scores are not computed.
+		Instead, each matching document receives a constant score equal to
+		the query's boost.
+
+		NOTE: This rewrite method will hit TooManyClausesError if the number
+		of terms exceeds the maximum clause count of BooleanQuery.
+	*/
+	CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE = RewriteMethod(booleanQueryRewrite{true})
+
+	/*
+		Read-only default instance of ConstantScoreAutoRewrite, with its
+		term count cutoff and doc count percent set to their defaults.
+	*/
+	CONSTANT_SCORE_AUTO_REWRITE_DEFAULT = RewriteMethod(NewConstantScoreAutoRewrite())
+)
+
+/* Returned when a rewrite needs more clauses than a BooleanQuery allows. */
+type TooManyClausesError struct {
+	MaxClauseCount int
+}
+
+func (e *TooManyClausesError) Error() string {
+	return fmt.Sprintf("maxClauseCount is set to %v", e.MaxClauseCount)
+}
+
+type constantScoreFilterRewrite struct{}
+
+func (m constantScoreFilterRewrite) Rewrite(r index.IndexReader, q *MultiTermQuery) (Query, error) {
+	ans := NewConstantScoreQueryFromFilter(newMultiTermQueryWrapperFilter(q))
+	ans.SetBoost(q.Boost())
+	return ans, nil
+}
+
+func (m constantScoreFilterRewrite) String() string {
+	return "CONSTANT_SCORE_FILTER_REWRITE"
+}
+
+// search/ScoringRewrite.java
+
+type booleanQueryRewrite struct {
+	constantScore bool
+}
+
+func (m booleanQueryRewrite) Rewrite(r index.IndexReader, q *MultiTermQuery) (Query, error) {
+	terms := make(map[string]bool)
+	err := q.collectTerms(r, func(term []byte, te TermsEnum) (bool, error) {
+		terms[string(term)] = true
+		return len(terms) <= maxClauseCount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) > maxClauseCount {
+		return nil, &TooManyClausesError{maxClauseCount}
+	}
+	if m.constantScore {
+		ans := NewConstantScoreQuery(newTermsBooleanQuery(q, terms, 1))
+		ans.SetBoost(q.Boost())
+		return ans, nil
+	}
+	return newTermsBooleanQuery(q, terms, q.Boost()), nil
+}
+
+func (m booleanQueryRewrite) String() string {
+	if m.constantScore {
+		return "CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE"
+	}
+	return "SCORING_BOOLEAN_QUERY_REWRITE"
+}
+
+/*
+Returns a BooleanQuery without coord of a SHOULD TermQuery clause per
+term, by increasing term, boosted by boost.
+*/
+func newTermsBooleanQuery(q *MultiTermQuery, terms map[string]bool, boost float32) *BooleanQuery {
+	sorted := make([][]byte, 0, len(terms))
+	for term := range terms {
+		sorted = append(sorted, []byte(term))
+	}
+	sort.Sort(bytesSlice(sorted))
+	ans := NewBooleanQueryDisableCoord(true)
+	for _, term := range sorted {
+		tq := NewTermQuery(index.NewTermFromBytes(q.field, term))
+		tq.SetBoost(boost)
+		ans.Add(tq, SHOULD)
+	}
+	return ans
+}
+
+type bytesSlice [][]byte
+
+func (a bytesSlice) Len() int           { return len(a) }
+func (a bytesSlice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a bytesSlice) Less(i, j int) bool { return bytes.Compare(a[i], a[j]) < 0 }
+
+// search/TopTermsRewrite.java
+
+/*
+Implemented by the TermsEnum of a MultiTermQuery which ranks its
+terms, e.g. by their similarity to the term of a fuzzy query, for the
+TopTermsRewrite methods. Terms of other enums all have a boost of 1.
+*/
+type BoostedTermsEnum interface {
+	TermsEnum
+	/* Returns the boost of the current term. */
+	Boost() float32
+}
+
+/*
+A rewrite method that keeps the best size terms of the query, by
+decreasing boost and then by increasing term, into a BooleanQuery
+without coord of a SHOULD TermQuery clause per term, boosted by the
+boost of its term. It never hits a TooManyClausesError, as size is
+capped to the maximum clause count of BooleanQuery.
+*/
+type TopTermsRewrite struct {
+	size          int
+	blendDocFreqs bool
+}
+
+/*
+Keeps the best size terms, scored as TermQuery clauses with their own
+statistics.
+*/
+func NewTopTermsScoringBooleanQueryRewrite(size int) *TopTermsRewrite {
+	assert2(size > 0, "size must be at least 1, got: %v", size)
+	return &TopTermsRewrite{size, false}
+}
+
+/*
+Keeps the best size terms, scored as TermQuery clauses sharing the
+highest doc freq of the terms, as FuzzyQuery does: a rare misspelling
+would otherwise get a higher idf than the common term intended, and
+outscore it. Only the doc freq is blended; total term freqs are kept.
+*/
+func NewTopTermsBlendedFreqScoringRewrite(size int) *TopTermsRewrite {
+	assert2(size > 0, "size must be at least 1, got: %v", size)
+	return &TopTermsRewrite{size, true}
+}
+
+func (m *TopTermsRewrite) Size() int {
+	return m.size
+}
+
+type scoreTerm struct {
+	term    []byte
+	boost   float32
+	docFreq int // summed over the segments
+}
+
+type scoreTermsByBoost []*scoreTerm
+
+func (a scoreTermsByBoost) Len() int      { return len(a) }
+func (a scoreTermsByBoost) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a scoreTermsByBoost) Less(i, j int) bool {
+	if a[i].boost != a[j].boost {
+		return a[i].boost > a[j].boost
+	}
+	return bytes.Compare(a[i].term, a[j].term) < 0
+}
+
+func (m *TopTermsRewrite) Rewrite(r index.IndexReader, q *MultiTermQuery) (Query, error) {
+	terms := make(map[string]*scoreTerm)
+	err := q.collectTerms(r, func(term []byte, te TermsEnum) (bool, error) {
+		df, err := te.DocFreq()
+		if err != nil {
+			return false, err
+		}
+		st, ok := terms[string(term)]
+		if !ok {
+			st = &scoreTerm{term: append([]byte(nil), term...), boost: 1}
+			if bte, ok := te.(BoostedTermsEnum); ok {
+				st.boost = bte.Boost()
+			}
+			terms[string(term)] = st
+		}
+		st.docFreq += df
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	best := make([]*scoreTerm, 0, len(terms))
+	for _, st := range terms {
+		best = append(best, st)
+	}
+	sort.Sort(scoreTermsByBoost(best))
+	size := m.size
+	if size > maxClauseCount {
+		size = maxClauseCount
+	}
+	if len(best) > size {
+		best = best[:size]
+	}
+	maxDocFreq := -1 // not blended
+	if m.blendDocFreqs {
+		for _, st := range best {
+			if st.docFreq > maxDocFreq {
+				maxDocFreq = st.docFreq
+			}
+		}
+	}
+
+	// by increasing term, as the other rewrites
+	sort.Sort(scoreTermsByTerm(best))
+	ans := NewBooleanQueryDisableCoord(true)
+	for _, st := range best {
+		tq := NewTermQueryWithDocFreq(index.NewTermFromBytes(q.field, st.term), maxDocFreq)
+		tq.SetBoost(q.Boost() * st.boost)
+		ans.Add(tq, SHOULD)
+	}
+	return ans, nil
+}
+
+type scoreTermsByTerm []*scoreTerm
+
+func (a scoreTermsByTerm) Len() int           { return len(a) }
+func (a scoreTermsByTerm) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a scoreTermsByTerm) Less(i, j int) bool { return bytes.Compare(a[i].term, a[j].term) < 0 }
+
+func (m *TopTermsRewrite) String() string {
+	if m.blendDocFreqs {
+		return fmt.Sprintf("TopTermsBlendedFreqScoringRewrite(%v)", m.size)
+	}
+	return fmt.Sprintf("TopTermsScoringBooleanQueryRewrite(%v)", m.size)
+}
+
+// search/ConstantScoreAutoRewrite.java
+
+/*
+A rewrite method that tries to pick the best constant-score rewrite
+method based on term and document counts from the query. If both the
+number of terms and documents is small enough, then
+CONSTANT_SCORE_BOOLEAN_QUERY_REWRITE is used. Otherwise,
+CONSTANT_SCORE_FILTER_REWRITE is used.
+*/
+type ConstantScoreAutoRewrite struct {
+	termCountCutoff int
+	docCountPercent float64
+}
+
+const (
+	/* Defaults derived from rough tests with a 20.0 million doc Wikipedia index. */
+	DEFAULT_TERM_COUNT_CUTOFF = 350
+	DEFAULT_DOC_COUNT_PERCENT = 0.1
+)
+
+func NewConstantScoreAutoRewrite() *ConstantScoreAutoRewrite {
+	return &ConstantScoreAutoRewrite{DEFAULT_TERM_COUNT_CUTOFF, DEFAULT_DOC_COUNT_PERCENT}
+}
+
+/*
+If the number of terms in this query is equal to or larger than this
+setting then CONSTANT_SCORE_FILTER_REWRITE is used.
+*/
+func (m *ConstantScoreAutoRewrite) SetTermCountCutoff(count int) *ConstantScoreAutoRewrite {
+	m.termCountCutoff = count
+	return m
+}
+
+func (m *ConstantScoreAutoRewrite) TermCountCutoff() int {
+	return m.termCountCutoff
+}
+
+/*
+If the number of documents to be visited in the postings exceeds this
+specified percentage of the MaxDoc() for the index, then
+CONSTANT_SCORE_FILTER_REWRITE is used.
+*/
+func (m *ConstantScoreAutoRewrite) SetDocCountPercent(percent float64) *ConstantScoreAutoRewrite {
+	m.docCountPercent = percent
+	return m
+}
+
+func (m *ConstantScoreAutoRewrite) DocCountPercent() float64 {
+	return m.docCountPercent
+}
+
+func (m *ConstantScoreAutoRewrite) Rewrite(r index.IndexReader, q *MultiTermQuery) (Query, error) {
+	// get the enum and start visiting terms. If we exhaust the enum
+	// before hitting either of the cutoffs, we use ConstantBooleanQueryRewrite;
+	// else, ConstantFilterRewrite:
+	docCountCutoff := int(m.docCountPercent / 100 * float64(r.MaxDoc()))
+	termCountLimit := m.termCountCutoff
+	if termCountLimit > maxClauseCount {
+		termCountLimit = maxClauseCount
+	}
+
+	terms := make(map[string]bool)
+	docVisitCount, hasCutOff := 0, false
+	err := q.collectTerms(r, func(term []byte, te TermsEnum) (bool, error) {
+		terms[string(term)] = true
+		df, err := te.DocFreq()
+		if err != nil {
+			return false, err
+		}
+		// Note: we use docFreq(), which includes deleted docs, so the
+		// cutoff is an approximation
+		docVisitCount += df
+		if len(terms) >= termCountLimit || docVisitCount >= docCountCutoff {
+			hasCutOff = true
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hasCutOff {
+		return CONSTANT_SCORE_FILTER_REWRITE.Rewrite(r, q)
+	}
+	ans := NewConstantScoreQuery(newTermsBooleanQuery(q, terms, 1))
+	ans.SetBoost(q.Boost())
+	return ans, nil
+}
+
+func (m *ConstantScoreAutoRewrite) String() string {
+	return fmt.Sprintf("ConstantScoreAutoRewrite(termCountCutoff=%v, docCountPercent=%v)",
+		m.termCountCutoff, m.docCountPercent)
+}
+
+// search/MultiTermQueryWrapperFilter.java
+
+/*
+A wrapper for MultiTermQuery, that exposes its functionality as a
+Filter, marking the docs of each term of the query per segment.
+*/
+type multiTermQueryWrapperFilter struct {
+	query *MultiTermQuery
+}
+
+func newMultiTermQueryWrapperFilter(query *MultiTermQuery) *multiTermQueryWrapperFilter {
+	return &multiTermQueryWrapperFilter{query}
+}
+
+func (f *multiTermQueryWrapperFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error) {
+	reader := ctx.Reader().(index.AtomicReader)
+	terms := reader.Terms(f.query.field)
+	if terms == nil {
+		// field does not exist
+		return nil, nil
+	}
+	builder := util.NewDocIdSetBuilder(reader.MaxDoc())
+	te := f.query.spi.TermsEnum(terms)
+	var docs DocsEnum
+	for {
+		term, err := te.Next()
+		if err != nil {
+			return nil, err
+		}
+		if term == nil {
+			break
+		}
+		// we don't need freqs
+		if docs, err = te.DocsByFlags(acceptDocs, docs, 0); err != nil {
+			return nil, err
+		}
+		if err = builder.AddIterator(docs); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Build(), nil
+}
+
+func (f *multiTermQueryWrapperFilter) String() string {
+	return f.query.String()
+}
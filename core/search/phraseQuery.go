@@ -0,0 +1,433 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// search/PhraseQuery.java
+
+/*
+A Query that matches documents containing a particular sequence of
+terms. A PhraseQuery is built by QueryParser for input like "new york".
+
+This query may be combined with other terms or queries with a
+BooleanQuery.
+
+Fields must be indexed with positions, or a PositionsNotIndexedError
+is returned by the search.
+*/
+type PhraseQuery struct {
+	*AbstractQuery
+	field       string
+	terms       []*index.Term
+	positions   []int
+	maxPosition int
+	slop        int
+}
+
+/* Constructs an empty phrase query. */
+func NewPhraseQuery() *PhraseQuery {
+	ans := &PhraseQuery{}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/*
+Sets the number of other words permitted between words in query
+phrase. If zero, then this is an exact phrase search. For larger
+values this works like a WITHIN or NEAR operator.
+
+The slop is in fact an edit-distance, where the units correspond to
+moves of terms in the query phrase out of position. For example, to
+switch the order of two words requires two moves (the first move
+places the words atop one another), so to permit re-orderings of
+phrases, the slop must be at least two.
+
+More exact matches are scored higher than sloppier matches, thus
+search results are sorted by exactness.
+
+The slop is zero by default, requiring exact matches.
+*/
+func (q *PhraseQuery) SetSlop(s int) {
+	assert2(s >= 0, "slop value cannot be negative")
+	q.slop = s
+}
+
+/* Returns the slop. See SetSlop(). */
+func (q *PhraseQuery) Slop() int {
+	return q.slop
+}
+
+/*
+Adds a term to the end of the query phrase. The relative position of
+the term is the one of the previously added term plus one.
+*/
+func (q *PhraseQuery) Add(term *index.Term) {
+	position := 0
+	if n := len(q.positions); n > 0 {
+		position = q.positions[n-1] + 1
+	}
+	q.AddAt(term, position)
+}
+
+/*
+Adds a term to the end of the query phrase. The relative position of
+the term within the phrase is specified explicitly. This allows e.g.
+phrases with more than one term at the same position or phrases with
+gaps (e.g. in connection with stopwords).
+*/
+func (q *PhraseQuery) AddAt(term *index.Term, position int) {
+	if len(q.terms) == 0 {
+		q.field = term.Field
+	} else {
+		assert2(term.Field == q.field,
+			"All phrase terms must be in the same field: %v", term)
+	}
+	q.terms = append(q.terms, term)
+	q.positions = append(q.positions, position)
+	if position > q.maxPosition {
+		q.maxPosition = position
+	}
+}
+
+/* Returns the set of terms in this phrase. */
+func (q *PhraseQuery) Terms() []*index.Term {
+	return q.terms
+}
+
+/* Returns the relative positions of terms in this phrase. */
+func (q *PhraseQuery) Positions() []int {
+	return q.positions
+}
+
+func (q *PhraseQuery) Rewrite(r index.IndexReader) Query {
+	switch len(q.terms) {
+	case 0:
+		bq := NewBooleanQuery()
+		bq.SetBoost(q.Boost())
+		return bq
+	case 1:
+		tq := NewTermQuery(q.terms[0])
+		tq.SetBoost(q.Boost())
+		return tq
+	}
+	return q
+}
+
+func (q *PhraseQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	termStats := make([]TermStatistics, len(q.terms))
+	for i, term := range q.terms {
+		termContext, err := index.NewTermContextFromTerm(ss.TopReaderContext(), term)
+		if err != nil {
+			return nil, err
+		}
+		termStats[i] = ss.TermStatistics(term, termContext)
+	}
+	ans := &phraseWeight{
+		PhraseQuery: q,
+		similarity:  ss.similarity,
+		stats:       ss.similarity.computeWeight(q.Boost(), ss.CollectionStatistics(q.field), termStats...),
+	}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+/* Prints a user-readable version of this query. */
+func (q *PhraseQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.field != "" && q.field != field {
+		fmt.Fprintf(&buf, "%v:", q.field)
+	}
+
+	pieces := make([]string, q.maxPosition+1)
+	for i, term := range q.terms {
+		if s := pieces[q.positions[i]]; s != "" {
+			pieces[q.positions[i]] = s + "|" + string(term.Bytes)
+		} else {
+			pieces[q.positions[i]] = string(term.Bytes)
+		}
+	}
+	for i, s := range pieces {
+		if s == "" {
+			pieces[i] = "?"
+		}
+	}
+	fmt.Fprintf(&buf, "\"%v\"", strings.Join(pieces, " "))
+
+	if q.slop != 0 {
+		fmt.Fprintf(&buf, "~%v", q.slop)
+	}
+	if q.Boost() != 1.0 {
+		fmt.Fprintf(&buf, "^%v", q.Boost())
+	}
+	return buf.String()
+}
+
+type phraseWeight struct {
+	*WeightImpl
+	*PhraseQuery
+	similarity Similarity
+	stats      SimWeight
+}
+
+func (w *phraseWeight) ValueForNormalization() float32 {
+	return w.stats.ValueForNormalization()
+}
+
+func (w *phraseWeight) Normalize(norm, topLevelBoost float32) {
+	w.stats.Normalize(norm, topLevelBoost)
+}
+
+func (w *phraseWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+/*
+Returns a scorer over the documents where all the terms appear. The
+matches of the phrase in each of them are computed as intervals,
+whose gaps hold how sloppy each match is.
+*/
+func (w *phraseWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	if len(w.terms) == 0 {
+		return nil, nil
+	}
+	if err := CheckPositionsIndexed(ctx, w.field, "PhraseQuery"); err != nil {
+		return nil, err
+	}
+	sources := make([]IntervalsSource, len(w.terms))
+	for i, term := range w.terms {
+		sources[i] = IntervalTerm(string(term.Bytes))
+	}
+	subs, err := subIntervals(sources, w.field, ctx, acceptDocs)
+	if subs == nil || err != nil {
+		return nil, err
+	}
+	var matches func(subs [][]interval) []interval
+	if w.slop == 0 || len(w.terms) == 1 {
+		matches = exactPhraseMatches(w.positions)
+	} else {
+		matches = newSloppyPhraseMatcher(w.terms, w.positions, w.slop).matches
+	}
+	simScorer, err := w.similarity.simScorer(w.stats, ctx)
+	if err != nil {
+		return nil, err
+	}
+	ans := &intervalScorer{
+		intervals: newConjunctionIntervals(subs, matches),
+		docScorer: simScorer,
+	}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans, nil
+}
+
+func (w *phraseWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	scorer, err := w.Scorer(ctx, ctx.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return nil, err
+	}
+	if scorer != nil {
+		newDoc, err := scorer.Advance(doc)
+		if err != nil {
+			return nil, err
+		}
+		if newDoc == doc {
+			freq := scorer.(*intervalScorer).sloppyFreq()
+			scoreExplanation := scorer.(*intervalScorer).docScorer.explain(doc,
+				newExplanation(freq, fmt.Sprintf("phraseFreq=%v", freq)))
+			ans := newComplexExplanation(true,
+				scoreExplanation.(*ExplanationImpl).value,
+				fmt.Sprintf("weight(%v in %v) [%v], result of:",
+					w.PhraseQuery, doc, reflect.TypeOf(w.similarity)))
+			ans.details = []Explanation{scoreExplanation}
+			return ans, nil
+		}
+	}
+	return newComplexExplanation(false, 0, "no matching term"), nil
+}
+
+// search/ExactPhraseScorer.java
+
+/*
+Returns the matches of a phrase whose terms all appear at their
+relative positions, given the positions of each term in a document.
+Each match is reported as the position of the phrase, without gaps.
+*/
+func exactPhraseMatches(positions []int) func(subs [][]interval) []interval {
+	upto := make([]int, len(positions))
+	return func(subs [][]interval) (ans []interval) {
+		for i := range upto {
+			upto[i] = 0
+		}
+	candidates:
+		for _, first := range subs[0] {
+			phrase := first.start - positions[0]
+			for i := 1; i < len(subs); i++ {
+				// the positions of a term are sorted, so are the ones wanted
+				want, sub := phrase+positions[i], subs[i]
+				for upto[i] < len(sub) && sub[upto[i]].start < want {
+					upto[i]++
+				}
+				if upto[i] == len(sub) {
+					return
+				}
+				if sub[upto[i]].start != want {
+					continue candidates
+				}
+			}
+			ans = append(ans, interval{phrase, phrase, 0})
+		}
+		return
+	}
+}
+
+// search/SloppyPhraseScorer.java
+
+/*
+Position of a term of a sloppy phrase in a document, i.e. the
+position in the document minus the relative position in the phrase.
+*/
+type phrasePositions struct {
+	intervals []interval // positions of the term in the document
+	upto      int
+	position  int
+	offset    int // relative position in the phrase
+	ord       int // unique across all phrasePositions
+	term      string
+	repeats   bool // whether the term appears more than once in the phrase
+}
+
+/*
+Finds the matches of a phrase in a document within a given slop, as
+SloppyPhraseScorer does. The match length of a match is the number of
+moves of its terms needed to get an exact match.
+*/
+type sloppyPhraseMatcher struct {
+	pps  []*phrasePositions
+	slop int
+	end  int // current largest position
+}
+
+func newSloppyPhraseMatcher(terms []*index.Term, positions []int, slop int) *sloppyPhraseMatcher {
+	ans := &sloppyPhraseMatcher{
+		pps:  make([]*phrasePositions, len(terms)),
+		slop: slop,
+	}
+	seen := make(map[string]*phrasePositions)
+	for i, term := range terms {
+		pp := &phrasePositions{offset: positions[i], ord: i, term: string(term.Bytes)}
+		if prev, ok := seen[pp.term]; ok {
+			prev.repeats, pp.repeats = true, true
+		}
+		seen[pp.term] = pp
+		ans.pps[i] = pp
+	}
+	return ans
+}
+
+/*
+Returns the matches in the document, given the positions of each term
+in it. Each match is reported as the span of the positions of its
+terms, with its match length as gaps, so that each match adds
+1/(1+matchLength) to the frequency the document is scored with.
+*/
+func (m *sloppyPhraseMatcher) matches(subs [][]interval) (ans []interval) {
+	m.end = math.MinInt32
+	for i, pp := range m.pps {
+		pp.intervals, pp.upto = subs[i], 0
+		if !m.advance(pp) {
+			return nil
+		}
+	}
+	for _, pp := range m.pps {
+		if !m.advanceRepeats(pp) {
+			return nil
+		}
+	}
+
+	pp := m.min(nil)
+	next := m.min(pp).position
+	matchLength := m.end - pp.position
+	start, end := pp.position, m.end
+	for m.advance(pp) && m.advanceRepeats(pp) {
+		if pp.position > next {
+			// done minimizing the current match length
+			if matchLength <= m.slop {
+				ans = append(ans, interval{start, end, matchLength})
+			}
+			pp = m.min(nil)
+			next = m.min(pp).position
+			matchLength = m.end - pp.position
+			start, end = pp.position, m.end
+		} else if length := m.end - pp.position; length < matchLength {
+			matchLength = length
+			start, end = pp.position, m.end
+		}
+	}
+	if matchLength <= m.slop {
+		ans = append(ans, interval{start, end, matchLength})
+	}
+	return
+}
+
+/* Moves pp to the next position of its term, if any. */
+func (m *sloppyPhraseMatcher) advance(pp *phrasePositions) bool {
+	if pp.upto == len(pp.intervals) {
+		return false
+	}
+	pp.position = pp.intervals[pp.upto].start - pp.offset
+	pp.upto++
+	if pp.position > m.end {
+		m.end = pp.position
+	}
+	return true
+}
+
+/*
+Advances the lesser of two repeats of a term on the same position in
+the document, until no repeat of pp collides with another one.
+Returns false if a repeat runs out of positions.
+*/
+func (m *sloppyPhraseMatcher) advanceRepeats(pp *phrasePositions) bool {
+	if !pp.repeats {
+		return true
+	}
+	for other := m.collide(pp); other != nil; other = m.collide(pp) {
+		if other.position < pp.position ||
+			other.position == pp.position && other.offset > pp.offset {
+			pp = other
+		}
+		if !m.advance(pp) {
+			return false
+		}
+	}
+	return true
+}
+
+/* Returns a repeat of pp on the same position in the document, or nil. */
+func (m *sloppyPhraseMatcher) collide(pp *phrasePositions) *phrasePositions {
+	for _, other := range m.pps {
+		if other != pp && other.term == pp.term &&
+			other.position+other.offset == pp.position+pp.offset {
+			return other
+		}
+	}
+	return nil
+}
+
+/* Returns the phrasePositions first in the order of PhraseQueue, but exclude. */
+func (m *sloppyPhraseMatcher) min(exclude *phrasePositions) (ans *phrasePositions) {
+	for _, pp := range m.pps {
+		if pp != exclude && (ans == nil || pp.position < ans.position ||
+			pp.position == ans.position && (pp.offset < ans.offset ||
+				pp.offset == ans.offset && pp.ord < ans.ord)) {
+			ans = pp
+		}
+	}
+	return
+}
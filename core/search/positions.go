@@ -0,0 +1,61 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+)
+
+/*
+Returned by positional queries (e.g. phrase and span queries) run on
+a field which was indexed without positions (e.g. DOCS_ONLY), instead
+of scoring it as if the terms were adjacent.
+*/
+type PositionsNotIndexedError struct {
+	Field string
+	Query string
+}
+
+func (e *PositionsNotIndexedError) Error() string {
+	return fmt.Sprintf("field \"%v\" was indexed without position data; cannot run %v",
+		e.Field, e.Query)
+}
+
+/* Returns the FieldInfo of the field in the segment, or nil if unknown. */
+func fieldInfo(r index.IndexReader, field string) *FieldInfo {
+	if sr, ok := r.(interface {
+		FieldInfos() FieldInfos
+	}); ok {
+		return sr.FieldInfos().FieldInfoByName(field)
+	}
+	return nil
+}
+
+/*
+Returns false if the field is indexed without positions in any
+segment of the reader. Fields which are not indexed at all have no
+postings to match, and are reported as having positions.
+*/
+func HasPositions(r index.IndexReader, field string) bool {
+	for _, ctx := range r.Leaves() {
+		if fi := fieldInfo(ctx.Reader(), field); fi != nil && fi.IsIndexed() &&
+			fi.IndexOptions() < INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Returns PositionsNotIndexedError if the field is indexed without
+positions in the given segment. Positional queries call it when the
+scorer of a segment is created, before pulling positions from the
+postings. query names the query in the error, e.g. "PhraseQuery".
+*/
+func CheckPositionsIndexed(ctx *index.AtomicReaderContext, field, query string) error {
+	if fi := fieldInfo(ctx.Reader(), field); fi != nil && fi.IsIndexed() &&
+		fi.IndexOptions() < INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+		return &PositionsNotIndexedError{field, query}
+	}
+	return nil
+}
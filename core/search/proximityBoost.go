@@ -0,0 +1,140 @@
+package search
+
+import (
+	"fmt"
+)
+
+// solr/search/ExtendedDismaxQParser.java#addShingledPhraseQueries
+
+/* Boost and slop of the phrases of a given number of terms. */
+type phraseBoost struct {
+	size  int // 0 for all the terms
+	boost float32
+	slop  int
+}
+
+/*
+Adds proximity boosting to a user's query, like the pf, pf2 and pf3
+parameters of edismax: documents matching the terms of the query as
+a phrase, or matching pairs (bigrams) or triples (trigrams) of its
+consecutive terms as phrases, score higher.
+
+The terms are taken from the query in order, e.g. as built by the
+query parser for unquoted text, from its term queries and boolean
+queries, skipping prohibited clauses. Phrases are made of the terms
+of a same field. The slop of a phrase is the maximum number of other
+positions between its terms, which must appear in order.
+
+By default, only the whole phrase is added, with boost 1 and no slop.
+*/
+type ProximityBooster struct {
+	phrases []phraseBoost
+}
+
+func NewProximityBooster() *ProximityBooster {
+	return &ProximityBooster{[]phraseBoost{{0, 1, 0}}}
+}
+
+func (b *ProximityBooster) set(size int, boost float32, slop int) *ProximityBooster {
+	assert2(boost >= 0, "boost must not be negative, got: %v", boost)
+	assert2(slop >= 0, "slop must not be negative, got: %v", slop)
+	for i, phrase := range b.phrases {
+		if phrase.size == size {
+			b.phrases[i] = phraseBoost{size, boost, slop}
+			return b
+		}
+	}
+	b.phrases = append(b.phrases, phraseBoost{size, boost, slop})
+	return b
+}
+
+/* Sets the boost and slop of the phrase of all the terms (pf/ps); a boost of 0 disables it. */
+func (b *ProximityBooster) SetPhrase(boost float32, slop int) *ProximityBooster {
+	return b.set(0, boost, slop)
+}
+
+/* Sets the boost and slop of the phrases of consecutive pairs (pf2/ps2); a boost of 0 disables them. */
+func (b *ProximityBooster) SetBigrams(boost float32, slop int) *ProximityBooster {
+	return b.set(2, boost, slop)
+}
+
+/* Sets the boost and slop of the phrases of consecutive triples (pf3/ps3); a boost of 0 disables them. */
+func (b *ProximityBooster) SetTrigrams(boost float32, slop int) *ProximityBooster {
+	return b.set(3, boost, slop)
+}
+
+/*
+Returns a query requiring q, with optional phrase clauses. q is
+returned as is if there is no phrase to add, e.g. for a single term.
+*/
+func (b *ProximityBooster) Boost(q Query) Query {
+	var fields []string
+	terms := make(map[string][]string)
+	var collect func(q Query)
+	collect = func(q Query) {
+		switch v := q.(type) {
+		case *TermQuery:
+			field := v.Term().Field
+			if _, ok := terms[field]; !ok {
+				fields = append(fields, field)
+			}
+			terms[field] = append(terms[field], string(v.Term().Bytes))
+		case *BooleanQuery:
+			for _, clause := range v.Clauses() {
+				if !clause.IsProhibited() {
+					collect(clause.Query())
+				}
+			}
+		}
+	}
+	collect(q)
+
+	var clauses []Query
+	for _, field := range fields {
+		for _, phrase := range b.phrases {
+			clauses = append(clauses, phrase.queries(field, terms[field])...)
+		}
+	}
+	if len(clauses) == 0 {
+		return q
+	}
+	// phrase clauses only add to the score; documents not matching
+	// them are not penalized by the coord factor
+	ans := NewBooleanQueryDisableCoord(true)
+	ans.Add(q, MUST)
+	for _, clause := range clauses {
+		ans.Add(clause, SHOULD)
+	}
+	return ans
+}
+
+/* Returns the phrase queries of the consecutive terms. */
+func (p phraseBoost) queries(field string, terms []string) (ans []Query) {
+	size := p.size
+	if size == 0 {
+		size = len(terms)
+	}
+	if p.boost == 0 || size < 2 || size > len(terms) {
+		return nil
+	}
+	for i := 0; i+size <= len(terms); i++ {
+		sources := make([]IntervalsSource, size)
+		for j, term := range terms[i : i+size] {
+			sources[j] = IntervalTerm(term)
+		}
+		var source IntervalsSource
+		if p.slop == 0 {
+			source = IntervalBlock(sources...)
+		} else {
+			source = IntervalMaxGaps(IntervalOrdered(sources...), p.slop)
+		}
+		q := NewIntervalQuery(field, source)
+		q.SetBoost(p.boost)
+		ans = append(ans, q)
+	}
+	return
+}
+
+func (b *ProximityBooster) String() string {
+	return fmt.Sprintf("ProximityBooster%v", b.phrases)
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
+// search/ReqOptSumScorer.java
+// search/BooleanTopLevelScorers.java
+
+/*
+A Scorer for queries with a required part and an optional part.
+Delays Advance() on the optional part until a score is needed.
+
+If optRequired, e.g. when the optional clauses must match at least
+minShouldMatch times, docs are only matched by both parts. The sum of
+the scores is multiplied by coord[n], n being the number of clauses
+matching the doc, reqCount for the required part plus the number of
+optional sub-scorers on the doc; coord is nil if coord is disabled.
+*/
+type ReqOptSumScorer struct {
+	*abstractScorer
+	reqScorer   Scorer
+	optScorer   Scorer
+	optRequired bool
+	coord       []float32
+	reqCount    int
+}
+
+func newReqOptSumScorer(weight Weight, reqScorer, optScorer Scorer) *ReqOptSumScorer {
+	assert(reqScorer != nil && optScorer != nil)
+	ans := &ReqOptSumScorer{reqScorer: reqScorer, optScorer: optScorer}
+	ans.abstractScorer = newScorer(ans, weight)
+	return ans
+}
+
+func (s *ReqOptSumScorer) NextDoc() (int, error) {
+	doc, err := s.reqScorer.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	return s.doNext(doc)
+}
+
+func (s *ReqOptSumScorer) Advance(target int) (int, error) {
+	doc, err := s.reqScorer.Advance(target)
+	if err != nil {
+		return 0, err
+	}
+	return s.doNext(doc)
+}
+
+/* Leap-frogs the required and optional parts if both are required. */
+func (s *ReqOptSumScorer) doNext(doc int) (optDoc int, err error) {
+	for s.optRequired && doc != NO_MORE_DOCS {
+		if optDoc = s.optScorer.DocId(); optDoc < doc {
+			if optDoc, err = s.optScorer.Advance(doc); err != nil {
+				return 0, err
+			}
+		}
+		if optDoc == doc {
+			break
+		}
+		if doc, err = s.reqScorer.Advance(optDoc); err != nil {
+			return 0, err
+		}
+	}
+	return doc, nil
+}
+
+func (s *ReqOptSumScorer) DocId() int {
+	return s.reqScorer.DocId()
+}
+
+/*
+Returns the score of the current document matching the query.
+Initially invalid, until NextDoc() is called the first time.
+*/
+func (s *ReqOptSumScorer) Score() (float32, error) {
+	// TODO: sum into a double and cast to float if we ever send required clauses to BS1
+	curDoc := s.reqScorer.DocId()
+	score, err := s.reqScorer.Score()
+	if err != nil {
+		return 0, err
+	}
+	matches := s.reqCount
+	if s.optScorer != nil {
+		optDoc := s.optScorer.DocId()
+		if optDoc < curDoc {
+			if optDoc, err = s.optScorer.Advance(curDoc); err != nil {
+				return 0, err
+			}
+		}
+		if optDoc == NO_MORE_DOCS {
+			s.optScorer = nil
+		} else if optDoc == curDoc {
+			optScore, err := s.optScorer.Score()
+			if err != nil {
+				return 0, err
+			}
+			score += optScore
+			matches += s.optMatches()
+		}
+	}
+	if s.coord != nil {
+		score *= s.coord[matches]
+	}
+	return score, nil
+}
+
+/* Returns the number of optional sub-scorers on the current doc. */
+func (s *ReqOptSumScorer) optMatches() int {
+	if ds, ok := s.optScorer.(*DisjunctionSumScorer); ok {
+		n, _ := ds.Freq() // already computed by Score()
+		return n
+	}
+	return 1
+}
+
+func (s *ReqOptSumScorer) Freq() (int, error) {
+	// we might have deferred advance()
+	if _, err := s.Score(); err != nil {
+		return 0, err
+	}
+	if s.optScorer != nil && s.optScorer.DocId() == s.reqScorer.DocId() {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+func (s *ReqOptSumScorer) Cost() int64 {
+	return s.reqScorer.Cost()
+}
+
+func (s *ReqOptSumScorer) String() string {
+	return fmt.Sprintf("ReqOptSumScorer(%v)", s.weight)
+}
+
+/* Multiplies the score of a single clause by its coord factor. */
+type boostedScorer struct {
+	Scorer
+	boost float32
+}
+
+func (s *boostedScorer) Score() (float32, error) {
+	score, err := s.Scorer.Score()
+	return score * s.boost, err
+}
@@ -0,0 +1,71 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+)
+
+// search/QueryRescorer.java
+
+/*
+Computes new scores of the top hits of a query, e.g. with a learned
+ranking model; see RerankQuery.
+*/
+type Rescorer interface {
+	/*
+		Sets the new score of each hit. Hits have top level doc ids, and
+		their score for the reranked query.
+	*/
+	Rescore(ss *IndexSearcher, hits []*ScoreDoc) error
+	String() string
+}
+
+/*
+A query that reranks the top window hits of another query with a
+Rescorer, which is usually too costly to run on all the matching
+documents. Other documents do not match.
+
+The query is run, and its hits rescored, when the Weight is created.
+*/
+type RerankQuery struct {
+	*AbstractQuery
+	query    Query
+	rescorer Rescorer
+	window   int
+}
+
+/* Reranks the top window hits of query with rescorer. */
+func NewRerankQuery(query Query, rescorer Rescorer, window int) *RerankQuery {
+	assert2(window > 0, "window must be at least 1, got: %v", window)
+	ans := &RerankQuery{query: query, rescorer: rescorer, window: window}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *RerankQuery) Rewrite(r index.IndexReader) Query {
+	if rewritten := q.query.Rewrite(r); rewritten != q.query {
+		ans := NewRerankQuery(rewritten, q.rescorer, q.window)
+		ans.SetBoost(q.Boost())
+		return ans
+	}
+	return q
+}
+
+func (q *RerankQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	docs, err := ss.SearchTop(q.query, q.window)
+	if err != nil {
+		return nil, err
+	}
+	if err = q.rescorer.Rescore(ss, docs.ScoreDocs); err != nil {
+		return nil, err
+	}
+	return newDocAndScoreWeight(q, docs.ScoreDocs, q.rescorer.String()), nil
+}
+
+func (q *RerankQuery) ToString(field string) string {
+	ans := fmt.Sprintf("rerank(%v, %v, window=%v)", q.query.ToString(field), q.rescorer, q.window)
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+/*
+Normalizes the scores of the hits of a query in place, e.g. onto
+[0,1], so that they compare with the scores of other queries, or of
+other engines. ScoreNormalization and SigmoidNormalizer implement it.
+*/
+type ScoreNormalizer interface {
+	Normalize(scores []float32)
+}
+
+/*
+Maps each score s to 1 / (1 + exp(-(A*s + B))), i.e. onto (0,1), as
+Platt scaling. Unlike min-max, a score is normalized on its own, not
+relative to the other hits of the query, so that it is comparable
+across queries; A and B are learned offline, e.g. by fitting a
+logistic regression of relevance judgments on raw scores.
+*/
+type SigmoidNormalizer struct {
+	A, B float32
+}
+
+func (n SigmoidNormalizer) Normalize(scores []float32) {
+	for i, s := range scores {
+		scores[i] = float32(1 / (1 + math.Exp(-float64(n.A*s+n.B))))
+	}
+}
+
+func (n SigmoidNormalizer) String() string {
+	return fmt.Sprintf("sigmoid(%v*score%+v)", n.A, n.B)
+}
+
+/* A hit with its raw score, and its score normalized. */
+type NormalizedScoreDoc struct {
+	*ScoreDoc
+	NormalizedScore float32
+}
+
+/*
+Returns the hits of docs, in the same order, with their scores
+normalized by n. The raw scores of the hits are left unchanged.
+*/
+func NormalizeScores(docs TopDocs, n ScoreNormalizer) []NormalizedScoreDoc {
+	scores := make([]float32, len(docs.ScoreDocs))
+	for i, hit := range docs.ScoreDocs {
+		scores[i] = hit.Score
+	}
+	n.Normalize(scores)
+	ans := make([]NormalizedScoreDoc, len(scores))
+	for i, hit := range docs.ScoreDocs {
+		ans[i] = NormalizedScoreDoc{hit, scores[i]}
+	}
+	return ans
+}
@@ -1,20 +1,22 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	. "github.com/balzaczyy/golucene/core/codec/spi"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/util"
 	"log"
 	"math"
+	"sync"
 )
 
 /* Define service that can be overrided */
 type IndexSearcherSPI interface {
-	CreateNormalizedWeight(Query) (Weight, error)
+	CreateNormalizedWeight(context.Context, Query) (Weight, error)
 	Rewrite(Query) (Query, error)
 	WrapFilter(Query, Filter) Query
-	SearchLWC([]*index.AtomicReaderContext, Weight, Collector) error
+	SearchLWC(context.Context, []*index.AtomicReaderContext, Weight, Collector) error
 }
 
 // IndexSearcher
@@ -24,6 +26,11 @@ type IndexSearcher struct {
 	readerContext index.IndexReaderContext
 	leafContexts  []*index.AtomicReaderContext
 	similarity    Similarity
+	fieldAliases  *FieldAliases
+
+	totalHitsThreshold int
+	concurrency        int
+	tracer             util.Tracer
 }
 
 func NewIndexSearcher(r index.IndexReader) *IndexSearcher {
@@ -32,9 +39,23 @@ func NewIndexSearcher(r index.IndexReader) *IndexSearcher {
 }
 
 func NewIndexSearcherFromContext(context index.IndexReaderContext) *IndexSearcher {
+	return NewTracingIndexSearcherFromContext(context, util.DefaultTracer())
+}
+
+/*
+Creates a searcher recording its searches, their weight creation and
+the search of each segment as spans of the given tracer. Must not be
+nil, but NO_TRACING may be used to disable tracing.
+*/
+func NewTracingIndexSearcher(r index.IndexReader, tracer util.Tracer) *IndexSearcher {
+	return NewTracingIndexSearcherFromContext(r.Context(), tracer)
+}
+
+func NewTracingIndexSearcherFromContext(context index.IndexReaderContext, tracer util.Tracer) *IndexSearcher {
 	// assert2(context.isTopLevel, "IndexSearcher's ReaderContext must be topLevel for reader %v", context.reader())
+	assert2(tracer != nil, "Cannot set Tracer implementation to nil. To disable tracing use NO_TRACING.")
 	defaultSimilarity := NewDefaultSimilarity()
-	ss := &IndexSearcher{nil, context.Reader(), context, context.Leaves(), defaultSimilarity}
+	ss := &IndexSearcher{nil, context.Reader(), context, context.Leaves(), defaultSimilarity, nil, math.MaxInt32, 1, tracer}
 	ss.spi = ss
 	return ss
 }
@@ -44,16 +65,84 @@ func (ss *IndexSearcher) SetSimilarity(similarity Similarity) {
 	ss.similarity = similarity
 }
 
+/*
+Sets the field aliases resolved when queries are rewritten, so that
+queries on aliases match their concrete fields. nil disables aliasing.
+*/
+func (ss *IndexSearcher) SetFieldAliases(aliases *FieldAliases) {
+	ss.fieldAliases = aliases
+}
+
+func (ss *IndexSearcher) FieldAliases() *FieldAliases {
+	return ss.fieldAliases
+}
+
+/*
+Sets the number of hits Search() counts exactly; beyond it, documents
+which cannot make it to the top hits may be skipped, and TotalHits is
+then a lower bound. All hits are counted by default.
+*/
+func (ss *IndexSearcher) SetTotalHitsThreshold(n int) {
+	assert2(n >= 0, "totalHitsThreshold must be >= 0, got %v", n)
+	ss.totalHitsThreshold = n
+}
+
+func (ss *IndexSearcher) startSpan(ctx context.Context, name string, q Query) (context.Context, util.Span) {
+	ctx, span := ss.tracer.Start(ctx, name)
+	if span.IsRecording() {
+		span.SetAttribute("query", fmt.Sprint(q))
+	}
+	return ctx, span
+}
+
 func (ss *IndexSearcher) SearchTop(q Query, n int) (topDocs TopDocs, err error) {
 	return ss.Search(q, nil, n)
 }
 
 func (ss *IndexSearcher) Search(q Query, f Filter, n int) (topDocs TopDocs, err error) {
-	w, err := ss.spi.CreateNormalizedWeight(ss.spi.WrapFilter(q, f))
+	return ss.SearchContext(context.Background(), q, f, n)
+}
+
+/*
+Same as Search(), with the spans of the search started under the span
+of ctx, if any.
+*/
+func (ss *IndexSearcher) SearchContext(ctx context.Context, q Query, f Filter, n int) (topDocs TopDocs, err error) {
+	ctx, span := ss.startSpan(ctx, "IndexSearcher.search", q)
+	defer func() {
+		if span.IsRecording() {
+			span.SetAttribute("totalHits", topDocs.TotalHits)
+			span.SetAttribute("totalHitsRelation", topDocs.TotalHitsRelation.String())
+		}
+		span.End(err)
+	}()
+	w, err := ss.spi.CreateNormalizedWeight(ctx, ss.spi.WrapFilter(q, f))
 	if err != nil {
 		return TopDocs{}, err
 	}
-	return ss.searchWSI(w, nil, n), nil
+	return ss.searchWSI(ctx, w, nil, n)
+}
+
+/*
+Lower-level search API. Collect() is called for every matching
+document, applying the filter if non-nil.
+*/
+func (ss *IndexSearcher) SearchCollector(q Query, f Filter, c Collector) (err error) {
+	return ss.SearchCollectorContext(context.Background(), q, f, c)
+}
+
+/*
+Same as SearchCollector(), with the spans of the search started under
+the span of ctx, if any.
+*/
+func (ss *IndexSearcher) SearchCollectorContext(ctx context.Context, q Query, f Filter, c Collector) (err error) {
+	ctx, span := ss.startSpan(ctx, "IndexSearcher.search", q)
+	defer func() { span.End(err) }()
+	w, err := ss.spi.CreateNormalizedWeight(ctx, ss.spi.WrapFilter(q, f))
+	if err != nil {
+		return err
+	}
+	return ss.spi.SearchLWC(ctx, ss.leafContexts, w, c)
 }
 
 /** Expert: Low-level search implementation.  Finds the top <code>n</code>
@@ -64,9 +153,9 @@ func (ss *IndexSearcher) Search(q Query, f Filter, n int) (topDocs TopDocs, err
  * @throws BooleanQuery.TooManyClauses If a query would exceed
  *         {@link BooleanQuery#getMaxClauseCount()} clauses.
  */
-func (ss *IndexSearcher) searchWSI(w Weight, after *ScoreDoc, nDocs int) TopDocs {
+func (ss *IndexSearcher) searchWSI(ctx context.Context, w Weight, after *ScoreDoc, nDocs int) (TopDocs, error) {
 	// TODO support concurrent search
-	return ss.searchLWSI(ss.leafContexts, w, after, nDocs)
+	return ss.searchLWSI(ctx, ss.leafContexts, w, after, nDocs)
 }
 
 /** Expert: Low-level search implementation.  Finds the top <code>n</code>
@@ -77,8 +166,8 @@ func (ss *IndexSearcher) searchWSI(w Weight, after *ScoreDoc, nDocs int) TopDocs
  * @throws BooleanQuery.TooManyClauses If a query would exceed
  *         {@link BooleanQuery#getMaxClauseCount()} clauses.
  */
-func (ss *IndexSearcher) searchLWSI(leaves []*index.AtomicReaderContext,
-	w Weight, after *ScoreDoc, nDocs int) TopDocs {
+func (ss *IndexSearcher) searchLWSI(ctx context.Context, leaves []*index.AtomicReaderContext,
+	w Weight, after *ScoreDoc, nDocs int) (TopDocs, error) {
 	// single thread
 	limit := ss.reader.MaxDoc()
 	if limit == 0 {
@@ -87,36 +176,61 @@ func (ss *IndexSearcher) searchLWSI(leaves []*index.AtomicReaderContext,
 	if nDocs > limit {
 		nDocs = limit
 	}
-	collector := NewTopScoreDocCollector(nDocs, after, !w.IsScoresDocsOutOfOrder())
-	ss.spi.SearchLWC(leaves, w, collector)
-	return collector.TopDocs()
+	if ss.concurrency > 1 && after == nil && len(leaves) == len(ss.leafContexts) {
+		ans, err := ss.searchWithManager(ctx, w, NewTopScoreDocCollectorManager(nDocs, ss.totalHitsThreshold))
+		if err != nil {
+			return TopDocs{}, err
+		}
+		return ans.(TopDocs), nil
+	}
+	collector := NewTopScoreDocCollectorWithThreshold(nDocs, after,
+		!w.IsScoresDocsOutOfOrder(), ss.totalHitsThreshold)
+	if err := ss.spi.SearchLWC(ctx, leaves, w, collector); err != nil {
+		return TopDocs{}, err
+	}
+	return collector.TopDocs(), nil
 }
 
-func (ss *IndexSearcher) SearchLWC(leaves []*index.AtomicReaderContext, w Weight, c Collector) (err error) {
+func (ss *IndexSearcher) SearchLWC(ctx context.Context, leaves []*index.AtomicReaderContext, w Weight, c Collector) (err error) {
 	// TODO: should we make this
 	// threaded...?  the Collector could be sync'd?
 	// always use single thread:
-	for _, ctx := range leaves { // search each subreader
-		// TODO catch CollectionTerminatedException
-		c.SetNextReader(ctx)
-
-		scorer, err := w.BulkScorer(ctx, !c.AcceptsDocsOutOfOrder(),
-			ctx.Reader().(index.AtomicReader).LiveDocs())
-		if err != nil {
+	for _, leaf := range leaves { // search each subreader
+		if err = ss.searchLeaf(ctx, leaf, w, c); err != nil {
 			return err
 		}
-		if scorer != nil {
-			err = scorer.ScoreAndCollect(c)
-		} // TODO catch CollectionTerminatedException
 	}
 	return
 }
 
+func (ss *IndexSearcher) searchLeaf(ctx context.Context, leaf *index.AtomicReaderContext, w Weight, c Collector) (err error) {
+	_, span := ss.tracer.Start(ctx, "IndexSearcher.searchLeaf")
+	if span.IsRecording() {
+		span.SetAttribute("ord", leaf.Ord)
+		span.SetAttribute("docBase", leaf.DocBase)
+		span.SetAttribute("maxDoc", leaf.Reader().MaxDoc())
+	}
+	defer func() { span.End(err) }()
+
+	// TODO catch CollectionTerminatedException
+	c.SetNextReader(leaf)
+
+	scorer, err := w.BulkScorer(leaf, !c.AcceptsDocsOutOfOrder(),
+		leaf.Reader().(index.AtomicReader).LiveDocs())
+	if err != nil {
+		return err
+	}
+	if scorer != nil {
+		return scorer.ScoreAndCollect(c)
+	} // TODO catch CollectionTerminatedException
+	return nil
+}
+
 func (ss *IndexSearcher) WrapFilter(q Query, f Filter) Query {
 	if f == nil {
 		return q
 	}
-	panic("FilteredQuery not supported yet")
+	return NewFilteredQuery(q, f)
 }
 
 /*
@@ -127,7 +241,7 @@ good performance, should not be displayed with every hit. Computing an
 explanation is as expensive as executing the query over the entire index.
 */
 func (ss *IndexSearcher) Explain(query Query, doc int) (exp Explanation, err error) {
-	w, err := ss.spi.CreateNormalizedWeight(query)
+	w, err := ss.spi.CreateNormalizedWeight(context.Background(), query)
 	if err == nil {
 		return ss.explain(w, doc)
 	}
@@ -151,7 +265,20 @@ func (ss *IndexSearcher) explain(weight Weight, doc int) (exp Explanation, err e
 	return weight.Explain(ctx, deBasedDoc)
 }
 
-func (ss *IndexSearcher) CreateNormalizedWeight(q Query) (w Weight, err error) {
+/*
+Creates a normalized weight for a top-level Query. The query is
+rewritten by this method and Query.CreateWeight() called, afterwards
+the Weight is normalized: the Similarity's QueryNorm() of the
+Weight's ValueForNormalization() is passed to Weight.Normalize(),
+with a top-level boost of 1. A QueryNorm() of infinity or NaN, e.g.
+when no term of the query exists in the index, is replaced by 1.
+
+The returned Weight can then directly be used to get a Scorer. Its
+span is started under the span of ctx, if any.
+*/
+func (ss *IndexSearcher) CreateNormalizedWeight(ctx context.Context, q Query) (w Weight, err error) {
+	_, span := ss.startSpan(ctx, "IndexSearcher.createNormalizedWeight", q)
+	defer func() { span.End(err) }()
 	q, err = ss.spi.Rewrite(q)
 	if err != nil {
 		return nil, err
@@ -172,6 +299,12 @@ func (ss *IndexSearcher) CreateNormalizedWeight(q Query) (w Weight, err error) {
 
 func (ss *IndexSearcher) Rewrite(q Query) (Query, error) {
 	log.Printf("Rewriting '%v'...", q)
+	if ss.fieldAliases != nil {
+		var err error
+		if q, err = ss.fieldAliases.Rewrite(q); err != nil {
+			return nil, err
+		}
+	}
 	after := q.Rewrite(ss.reader)
 	for after != q {
 		q = after
@@ -293,11 +426,14 @@ type ITFIDFSimilarity interface {
 }
 
 type TFIDFSimilarity struct {
-	spi ITFIDFSimilarity
+	spi      ITFIDFSimilarity
+	maxNorms *index.CoreCache // *maxNorms of each segment
 }
 
 func newTFIDFSimilarity(spi ITFIDFSimilarity) *TFIDFSimilarity {
-	return &TFIDFSimilarity{spi}
+	return &TFIDFSimilarity{spi, index.NewCoreCache(func(r index.AtomicReader) (interface{}, error) {
+		return &maxNorms{byField: make(map[string]float32)}, nil
+	})}
 }
 
 func (ts *TFIDFSimilarity) idfExplainTerm(collectionStats CollectionStatistics, termStats TermStatistics) Explanation {
@@ -338,7 +474,37 @@ func (ts *TFIDFSimilarity) simScorer(stats SimWeight, ctx *index.AtomicReaderCon
 	if err != nil {
 		return nil, err
 	}
-	return newTFIDFSimScorer(ts, idfstats, ndv), nil
+	return newTFIDFSimScorer(ts, idfstats, ndv, ctx.Reader().(index.AtomicReader)), nil
+}
+
+/*
+The largest decoded norm of each field of a segment, which is read
+once per segment core, as norms are not updated.
+*/
+type maxNorms struct {
+	sync.Mutex
+	byField map[string]float32
+}
+
+func (ts *TFIDFSimilarity) maxNorm(reader index.AtomicReader, field string, norms NumericDocValues) (float32, error) {
+	v, err := ts.maxNorms.Get(reader)
+	if err != nil {
+		return 0, err
+	}
+	cache := v.(*maxNorms)
+	cache.Lock()
+	defer cache.Unlock()
+	if norm, ok := cache.byField[field]; ok {
+		return norm, nil
+	}
+	var maxNorm float32
+	for doc, maxDoc := 0, reader.MaxDoc(); doc < maxDoc; doc++ {
+		if norm := ts.spi.decodeNormValue(norms(doc)); norm > maxNorm {
+			maxNorm = norm
+		}
+	}
+	cache.byField[field] = maxNorm
+	return maxNorm, nil
 }
 
 type tfIDFSimScorer struct {
@@ -346,10 +512,11 @@ type tfIDFSimScorer struct {
 	stats       *idfStats
 	weightValue float32
 	norms       NumericDocValues
+	reader      index.AtomicReader
 }
 
-func newTFIDFSimScorer(owner *TFIDFSimilarity, stats *idfStats, norms NumericDocValues) *tfIDFSimScorer {
-	return &tfIDFSimScorer{owner, stats, stats.value, norms}
+func newTFIDFSimScorer(owner *TFIDFSimilarity, stats *idfStats, norms NumericDocValues, reader index.AtomicReader) *tfIDFSimScorer {
+	return &tfIDFSimScorer{owner, stats, stats.value, norms, reader}
 }
 
 func (ss *tfIDFSimScorer) Score(doc int, freq float32) float32 {
@@ -360,6 +527,26 @@ func (ss *tfIDFSimScorer) Score(doc int, freq float32) float32 {
 	return raw * ss.owner.spi.decodeNormValue(ss.norms(doc)) // normalize for field
 }
 
+/*
+Returns an upper bound of the scores of the docs of the segment whose
+freq is at most maxFreq, assuming that tf() does not decrease with
+freq. The largest norm of the field is only computed once per segment.
+*/
+func (ss *tfIDFSimScorer) maxScore(maxFreq float32) (float32, error) {
+	raw := ss.owner.spi.tf(maxFreq) * ss.weightValue
+	if ss.norms == nil {
+		return raw, nil
+	}
+	if raw <= 0 {
+		return 0, nil // norms are not negative
+	}
+	maxNorm, err := ss.owner.maxNorm(ss.reader, ss.stats.field, ss.norms)
+	if err != nil {
+		return 0, err
+	}
+	return raw * maxNorm, nil
+}
+
 func (ss *tfIDFSimScorer) explain(doc int, freq Explanation) Explanation {
 	return ss.owner.explainScore(doc, freq, ss.stats, ss.norms)
 }
@@ -388,13 +575,15 @@ func newIDFStats(field string, idf Explanation, queryBoost float32) *idfStats {
 
 func (stats *idfStats) ValueForNormalization() float32 {
 	// TODO: (sorta LUCENE-1907) make non-static class and expose this squaring via a nice method to subclasses?
-	return stats.queryWeight * stats.queryWeight // sum of squared weights
+	// from the weight before normalization, so that normalizing again does not compound
+	queryWeight := stats.idf.(*ExplanationImpl).value * stats.queryBoost
+	return queryWeight * queryWeight // sum of squared weights
 }
 
 func (stats *idfStats) Normalize(queryNorm float32, topLevelBoost float32) {
 	stats.queryNorm = queryNorm * topLevelBoost
-	stats.queryWeight *= stats.queryNorm                                 // normalize query weight
-	stats.value = stats.queryWeight * stats.idf.(*ExplanationImpl).value // idf for document
+	stats.queryWeight = stats.idf.(*ExplanationImpl).value * stats.queryBoost * stats.queryNorm // normalize query weight
+	stats.value = stats.queryWeight * stats.idf.(*ExplanationImpl).value                        // idf for document
 }
 
 func (ss *TFIDFSimilarity) explainScore(doc int, freq Explanation,
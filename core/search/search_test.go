@@ -1,10 +1,18 @@
 package search
 
 import (
+	"context"
+	"fmt"
 	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
 	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
 	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestLastCommitGeneration(t *testing.T) {
@@ -75,3 +83,1667 @@ func assertEquals(t *testing.T, a, b interface{}) {
 // 	ss.IncludeIndex("testdata/usingworldtimepro")
 // 	assertEquals(t, 17, ss.search("time"))
 // }
+
+func TestFieldAliases(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases := NewFieldAliases()
+	aliases.Add("text", AliasTarget{"content", 1})
+	aliases.Add("all", AliasTarget{"text", 2}, AliasTarget{"title", 1})
+
+	targets, err := aliases.Resolve("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 2, len(targets))
+	assertEquals(t, AliasTarget{"content", 2}, targets[0])
+	assertEquals(t, AliasTarget{"title", 1}, targets[1])
+
+	ss := NewIndexSearcher(r)
+	ss.SetFieldAliases(aliases)
+	docs, err := ss.SearchTop(NewTermQuery(index.NewTerm("text", "bat")), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 8, docs.TotalHits)
+
+	q, err := aliases.Rewrite(NewTermQuery(index.NewTerm("all", "bat")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bq, ok := q.(*BooleanQuery)
+	if !ok || len(bq.clauses) != 2 {
+		t.Fatalf("Expected disjunction over 2 fields, but %v", q)
+	}
+	assertEquals(t, float32(2), bq.clauses[0].query.Boost())
+
+	// a zero boost excludes a field, without building its query
+	aliases.Add("all", AliasTarget{"text", 0}, AliasTarget{"title", 1})
+	var built []string
+	q, err = aliases.Expand("all", func(field string) Query {
+		built = append(built, field)
+		return NewTermQuery(index.NewTerm(field, "bat"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, "[title]", fmt.Sprint(built))
+	if tq, ok := q.(*TermQuery); !ok || tq.term.Field != "title" {
+		t.Errorf("Expected a query on title only, but %v", q)
+	}
+	aliases.Add("none", AliasTarget{"content", 0})
+	docs, err = ss.SearchTop(NewTermQuery(index.NewTerm("none", "bat")), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 0, docs.TotalHits)
+
+	aliases.Add("title", AliasTarget{"all", 1})
+	if _, err = aliases.Resolve("all"); err == nil {
+		t.Error("Expected error for cyclic aliases")
+	}
+}
+
+func TestFieldAliasesConcurrently(t *testing.T) {
+	aliases := NewFieldAliases()
+	aliases.Add("all", AliasTarget{"title", 1}, AliasTarget{"body", 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				// per tenant switches of the title field
+				aliases.Add("all", AliasTarget{"title", float32(j % 2)}, AliasTarget{"body", 1})
+				aliases.Add(fmt.Sprintf("tenant%v", i), AliasTarget{"all", 1})
+				aliases.Remove(fmt.Sprintf("tenant%v", i))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				targets, err := aliases.Resolve("all")
+				if err != nil || len(targets) == 0 || targets[len(targets)-1].Field != "body" {
+					t.Errorf("Expected body to be a target, but %v, %v", targets, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFetchComputedFields(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	docs, err := ss.SearchTop(NewTermQuery(index.NewTerm("content", "bat")), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := NewStoredFieldSource("title")
+	hits, err := NewFetchPhase(ss).
+		AddField("title", title).
+		AddField("titleLength", NewExpressionSource("len", func(args []interface{}) (interface{}, error) {
+			return len(args[0].(string)), nil
+		}, title)).
+		AddField("missing", NewStoredFieldSource("nonexistent")).
+		Fetch(docs.ScoreDocs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, len(docs.ScoreDocs), len(hits))
+	for i, hit := range hits {
+		assertEquals(t, docs.ScoreDocs[i].Doc, hit.Doc)
+		assertEquals(t, hit.Document.Get("title"), hit.Fields["title"])
+		assertEquals(t, len(hit.Document.Get("title")), hit.Fields["titleLength"])
+		if _, ok := hit.Fields["missing"]; ok {
+			t.Error("Expected no value for missing field")
+		}
+	}
+
+	// Paris to London
+	if dist := Haversine(48.8566, 2.3522, 51.5074, -0.1278); dist < 343 || dist > 344 {
+		t.Errorf("Expected ~343.5km, but %v", dist)
+	}
+}
+
+func TestExportCollector(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+
+	var batches [][]*ExportedDoc
+	c := NewExportCollector(3, func(batch []*ExportedDoc) error {
+		batches = append(batches, batch)
+		return nil
+	}).SetScores(true).AddField("title", NewStoredFieldSource("title"))
+	if err = ss.SearchCollector(q, nil, c); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 8, c.Count())
+	assertEquals(t, 3, len(batches))
+	assertEquals(t, 2, len(batches[2]))
+	for _, batch := range batches {
+		for _, doc := range batch {
+			if doc.Score <= 0 {
+				t.Errorf("Expected positive score, but %v", doc.Score)
+			}
+			if _, ok := doc.Values["title"]; !ok {
+				t.Errorf("Expected title of doc %v", doc.Doc)
+			}
+		}
+	}
+
+	ch := make(chan []*ExportedDoc, 10)
+	c = NewExportChannelCollector(5, ch)
+	if err = ss.SearchCollector(q, nil, c); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for batch := range ch {
+		total += len(batch)
+	}
+	assertEquals(t, 8, total)
+}
+
+func TestConjunctionScorer(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	matches := func(q Query) map[int]bool {
+		docs, err := ss.SearchTop(q, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ans := make(map[int]bool)
+		for _, sd := range docs.ScoreDocs {
+			ans[sd.Doc] = true
+		}
+		return ans
+	}
+
+	q := NewBooleanQuery()
+	expected := matches(NewTermQuery(index.NewTerm("content", "bat")))
+	for _, text := range []string{"bat", "learn", "fruit"} {
+		tq := NewTermQuery(index.NewTerm("content", text))
+		q.Add(tq, MUST)
+		docs := matches(tq)
+		for doc := range expected {
+			if !docs[doc] {
+				delete(expected, doc)
+			}
+		}
+	}
+	if len(expected) == 0 {
+		t.Fatal("Expected overlapping terms")
+	}
+	if actual := matches(q); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %v, but %v", expected, actual)
+	}
+
+	w, err := ss.CreateNormalizedWeight(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := r.Leaves()[0]
+	scorer, err := w.Scorer(leaf, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := scorer.(*ConjunctionScorer)
+	for _, df := range cs.docsAndFreqs {
+		if df.cost < cs.Cost() {
+			t.Errorf("Expected the cheapest scorer (cost %v) to lead, but %v", df.cost, cs.Cost())
+		}
+	}
+}
+
+func TestBooleanScorer(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+	term := func(text string) Query {
+		return NewTermQuery(index.NewTerm("content", text))
+	}
+	matches := func(q Query) map[int]bool {
+		docs, err := ss.SearchTop(q, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ans := make(map[int]bool)
+		for _, sd := range docs.ScoreDocs {
+			ans[sd.Doc] = true
+		}
+		return ans
+	}
+	bat, fruit, learn := matches(term("bat")), matches(term("fruit")), matches(term("learn"))
+	the := matches(term("the"))
+	boolQuery := func(min int, clauses ...interface{}) *BooleanQuery {
+		q := NewBooleanQuery()
+		for i := 0; i < len(clauses); i += 2 {
+			q.Add(clauses[i].(Query), clauses[i+1].(Occur))
+		}
+		q.SetMinimumNumberShouldMatch(min)
+		return q
+	}
+	nested := boolQuery(0, term("bat"), SHOULD, term("fruit"), SHOULD)
+
+	for _, test := range []struct {
+		query    *BooleanQuery
+		expected func(doc int) bool
+	}{
+		{boolQuery(0, term("bat"), SHOULD, term("fruit"), SHOULD),
+			func(doc int) bool { return bat[doc] || fruit[doc] }},
+		{boolQuery(0, term("bat"), MUST, term("fruit"), SHOULD),
+			func(doc int) bool { return bat[doc] }},
+		{boolQuery(0, term("bat"), SHOULD, term("fruit"), SHOULD, term("learn"), MUST_NOT),
+			func(doc int) bool { return (bat[doc] || fruit[doc]) && !learn[doc] }},
+		{boolQuery(0, term("bat"), MUST, term("fruit"), MUST_NOT, term("the"), MUST_NOT),
+			func(doc int) bool { return bat[doc] && !fruit[doc] && !the[doc] }},
+		{boolQuery(0, nested, MUST, term("learn"), MUST),
+			func(doc int) bool { return (bat[doc] || fruit[doc]) && learn[doc] }},
+		{boolQuery(2, term("bat"), SHOULD, term("fruit"), SHOULD, term("learn"), SHOULD),
+			func(doc int) bool {
+				return bat[doc] && fruit[doc] || bat[doc] && learn[doc] || fruit[doc] && learn[doc]
+			}},
+		{boolQuery(1, term("learn"), MUST, term("bat"), SHOULD, term("fruit"), SHOULD),
+			func(doc int) bool { return learn[doc] && (bat[doc] || fruit[doc]) }},
+		{boolQuery(0, term("learn"), MUST, term("bat"), SHOULD, term("fruit"), SHOULD, term("zzz"), SHOULD),
+			func(doc int) bool { return learn[doc] }},
+	} {
+		q := test.query
+		w, err := ss.CreateNormalizedWeight(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// in-order scores, as with a filter
+		scores := make(map[int]float32)
+		for _, ctx := range r.Leaves() {
+			scorer, err := w.Scorer(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if scorer == nil {
+				continue
+			}
+			for doc, err := scorer.NextDoc(); doc != NO_MORE_DOCS; doc, err = scorer.NextDoc() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if scores[ctx.DocBase+doc], err = scorer.Score(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		for doc := 0; doc < r.MaxDoc(); doc++ {
+			if _, ok := scores[doc]; ok != test.expected(doc) {
+				t.Errorf("Expected %v to match doc %v: %v", q, doc, !ok)
+			}
+		}
+		if len(scores) == 0 {
+			t.Errorf("Expected hits for %v", q)
+		}
+
+		docs, err := ss.SearchTop(q, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, len(scores), docs.TotalHits)
+		for _, sd := range docs.ScoreDocs {
+			if math.Abs(float64(scores[sd.Doc]-sd.Score)) > 1e-5*float64(sd.Score) {
+				t.Errorf("Expected %v to score %v on doc %v, but %v", q, sd.Score, sd.Doc, scores[sd.Doc])
+			}
+			exp, err := ss.Explain(q, sd.Doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exp.IsMatch() || math.Abs(float64(exp.Value()-sd.Score)) > 1e-5*float64(sd.Score) {
+				t.Errorf("Expected explanation of %v on doc %v to match score %v, but %v", q, sd.Doc, sd.Score, exp)
+			}
+		}
+	}
+}
+
+func TestBooleanScorerRequiredClauses(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+	term := func(text string) Query {
+		return NewTermQuery(index.NewTerm("content", text))
+	}
+	bat, err := ss.SearchTop(term("bat"), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BooleanScorer (out-of-order) handles up to MAX_REQUIRED_CLAUSES
+	// required clauses, BS2 (in-order) the rest
+	for _, required := range []int{1, MAX_REQUIRED_CLAUSES, MAX_REQUIRED_CLAUSES + 1} {
+		q := NewBooleanQuery()
+		for i := 0; i < required; i++ {
+			q.Add(term("bat"), MUST)
+		}
+		q.Add(term("fruit"), SHOULD)
+		w, err := ss.CreateNormalizedWeight(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outOfOrder := w.IsScoresDocsOutOfOrder(); outOfOrder != (required <= MAX_REQUIRED_CLAUSES) {
+			t.Errorf("%v required clauses: expected out-of-order %v", required, !outOfOrder)
+		}
+		docs, err := ss.SearchTop(q, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if docs.TotalHits != bat.TotalHits {
+			t.Errorf("%v required clauses: expected %v hits, got %v", required, bat.TotalHits, docs.TotalHits)
+		}
+		matched := make(map[int]bool)
+		for _, sd := range docs.ScoreDocs {
+			matched[sd.Doc] = true
+		}
+		for _, sd := range bat.ScoreDocs {
+			if !matched[sd.Doc] {
+				t.Errorf("%v required clauses: expected doc %v to match", required, sd.Doc)
+			}
+		}
+	}
+}
+
+func TestCachingWrapperFilter(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	matches := func(q Query, f Filter) map[int]bool {
+		docs, err := ss.Search(q, f, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ans := make(map[int]bool)
+		for _, sd := range docs.ScoreDocs {
+			ans[sd.Doc] = true
+		}
+		return ans
+	}
+
+	expected := matches(NewTermQuery(index.NewTerm("content", "fruit")), nil)
+	if len(expected) == 0 || len(expected) == r.NumDocs() {
+		t.Fatalf("Expected some docs, but %v", expected)
+	}
+	filter := NewCachingWrapperFilter(NewQueryWrapperFilter(NewTermQuery(index.NewTerm("content", "fruit"))))
+	for i := 0; i < 2; i++ {
+		if actual := matches(NewTermQuery(index.NewTerm("content", "bat")), filter); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Expected %v, but %v", expected, actual)
+		}
+	}
+	if filter.missCount != 1 || filter.hitCount != 1 {
+		t.Errorf("Expected 1 miss and 1 hit, but %v and %v", filter.missCount, filter.hitCount)
+	}
+	if filter.RamBytesUsed() <= 0 {
+		t.Error("Expected cached sets")
+	}
+
+	// filtering and filtered by non-term queries
+	query := NewBooleanQuery()
+	query.Add(NewTermQuery(index.NewTerm("content", "fruit")), SHOULD)
+	query.Add(NewTermQuery(index.NewTerm("content", "the")), SHOULD)
+	allowed := NewBooleanQuery()
+	allowed.Add(NewTermQuery(index.NewTerm("content", "bat")), MUST)
+	allowed.Add(NewTermQuery(index.NewTerm("content", "learn")), MUST_NOT)
+	both := NewBooleanQuery()
+	both.Add(query, MUST)
+	both.Add(allowed, MUST)
+	expected = matches(both, nil)
+	if len(expected) == 0 {
+		t.Fatal("Expected some docs")
+	}
+	for _, f := range []Filter{NewQueryWrapperFilter(allowed), NewCachingWrapperFilter(NewQueryWrapperFilter(allowed))} {
+		if actual := matches(query, f); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("Expected %v, but %v", expected, actual)
+		}
+	}
+
+	// sparse, clustered and dense sets of 1M docs
+	maxDoc := 1 << 20
+	for _, v := range []struct {
+		step, every int
+		compressed  bool
+	}{{10007, 1, true}, {1, 64, true}, {2, 1, false}} {
+		bits := util.NewFixedBitSetOf(maxDoc)
+		for doc := 0; doc < maxDoc; doc += v.step {
+			if (doc>>16)%v.every == 0 {
+				bits.Set(doc)
+			}
+		}
+		set, err := CompactDocIdSet(util.NewFixedBitSetIterator(bits), maxDoc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, isBitSet := set.(*util.FixedBitSet)
+		if v.compressed == isBitSet || v.compressed && set.RamBytesUsed()*10 > bits.RamBytesUsed() {
+			t.Errorf("Unexpected %T of %v bytes for %v docs (bit set: %v bytes)",
+				set, set.RamBytesUsed(), bits.Cardinality(), bits.RamBytesUsed())
+		}
+
+		// iterates and advances as the bit set
+		it, err := set.Iterator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedIt := util.NewFixedBitSetIterator(bits)
+		for target := 0; ; target += 3001 {
+			doc, err := it.NextDoc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedDoc, _ := expectedIt.NextDoc()
+			if doc != expectedDoc {
+				t.Fatalf("%T: expected next doc %v, but %v", set, expectedDoc, doc)
+			}
+			if doc == NO_MORE_DOCS {
+				break
+			}
+			if target > doc {
+				doc, _ = it.Advance(target)
+				expectedDoc, _ = expectedIt.Advance(target)
+				if doc != expectedDoc {
+					t.Fatalf("%T: expected %v on advance to %v, but %v", set, expectedDoc, target, doc)
+				}
+				if doc == NO_MORE_DOCS {
+					break
+				}
+			}
+		}
+	}
+	if set, _ := CompactDocIdSet(util.NewFixedBitSetIterator(util.NewFixedBitSetOf(maxDoc)), maxDoc); set != EMPTY_DOC_ID_SET {
+		t.Errorf("Expected empty set, but %T", set)
+	}
+
+	// the sets of closed segments are dropped
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(filter.cache); n != 0 {
+		t.Errorf("Expected no cached set once the reader is closed, but %v", n)
+	}
+}
+
+func TestQueryNormalization(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	scores := func(q Query) map[int]float32 {
+		docs, err := ss.SearchTop(q, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ans := make(map[int]float32)
+		for _, sd := range docs.ScoreDocs {
+			ans[sd.Doc] = sd.Score
+		}
+		return ans
+	}
+	near := func(a, b float32) bool {
+		return math.Abs(float64(a-b)) <= 1e-5*math.Abs(float64(a))
+	}
+	term := func(text string, boost float32) Query {
+		q := NewTermQuery(index.NewTerm("content", text))
+		q.SetBoost(boost)
+		return q
+	}
+
+	// queryNorm cancels the boost of a single clause
+	single := NewBooleanQuery()
+	single.Add(term("fruit", 1), MUST)
+	single.SetBoost(3)
+	expected := scores(term("fruit", 1))
+	for doc, score := range scores(single) {
+		if !near(expected[doc], score) {
+			t.Errorf("Expected boosted clause to score %v on doc %v, but %v", expected[doc], doc, score)
+		}
+	}
+
+	conjunction := NewBooleanQuery()
+	conjunction.Add(term("bat", 3), MUST)
+	conjunction.Add(term("learn", 1), MUST)
+	disjunction := NewBooleanQuery()
+	disjunction.Add(term("bat", 1), SHOULD)
+	disjunction.Add(term("fruit", 2), SHOULD)
+	nested := NewBooleanQuery()
+	nested.Add(disjunction, SHOULD)
+	nested.Add(term("learn", 1), SHOULD)
+	nested.Add(term("missing", 1), SHOULD)
+	required := NewBooleanQuery()
+	required.Add(disjunction, MUST)
+	required.Add(term("learn", 1), SHOULD)
+	required.Add(term("the", 1), MUST_NOT)
+	for _, q := range []Query{conjunction, disjunction, nested, required} {
+		hits := scores(q)
+		if len(hits) == 0 {
+			t.Fatalf("Expected hits for %v", q)
+		}
+		for doc, score := range hits {
+			exp, err := ss.Explain(q, doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !exp.IsMatch() || !near(score, exp.Value()) {
+				t.Errorf("Expected explanation of %v on doc %v to match score %v, but %v", q, doc, score, exp)
+			}
+		}
+		for doc := 0; doc < r.MaxDoc(); doc++ {
+			if _, ok := hits[doc]; ok {
+				continue
+			}
+			exp, err := ss.Explain(q, doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp.IsMatch() {
+				t.Errorf("Expected explanation of %v on doc %v not to match, but %v", q, doc, exp)
+			}
+		}
+
+		// normalizing again does not compound
+		w, err := ss.CreateNormalizedWeight(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Normalize(ss.similarity.QueryNorm(w.ValueForNormalization()), 1)
+		for doc, score := range hits {
+			exp, err := w.Explain(r.Leaves()[0], doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !near(score, exp.Value()) {
+				t.Errorf("Expected renormalized %v to score %v on doc %v, but %v", q, score, doc, exp.Value())
+			}
+		}
+	}
+}
+
+/* Test source giving the value of a top-level doc ID. */
+type docValueSource func(doc int) interface{}
+
+func (s docValueSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	return func(doc int) (interface{}, error) {
+		return s(ctx.DocBase + doc), nil
+	}, nil
+}
+
+func (s docValueSource) String() string { return "docValueSource" }
+
+func TestDistanceFacetsAndSort(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	docs, err := ss.SearchTop(q, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 8, len(docs.ScoreDocs))
+
+	// hits are on the equator, about 1.1km apart in reverse order of
+	// their rank, except the first one which has no location
+	lons := make(map[int]float64)
+	for i, hit := range docs.ScoreDocs[1:] {
+		lons[hit.Doc] = float64(len(docs.ScoreDocs)-2-i) * 0.01
+	}
+	lat := docValueSource(func(doc int) interface{} {
+		if _, ok := lons[doc]; ok {
+			return "0"
+		}
+		return nil
+	})
+	lon := docValueSource(func(doc int) interface{} {
+		if v, ok := lons[doc]; ok {
+			return v
+		}
+		return nil
+	})
+
+	facets := NewDistanceFacetCollector(lat, lon, 0, 0, NewDistanceRanges(1, 5)...)
+	if err = ss.SearchCollector(q, nil, facets); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, "[<1km (1) 1-5km (4) >=5km (2)]", fmt.Sprint(facets.Counts()))
+	assertEquals(t, 1, facets.Missing())
+
+	nearest := NewDistanceSortCollector(lat, lon, 0, 0, 3).SetScores(true)
+	if err = ss.SearchCollector(q, nil, nearest); err != nil {
+		t.Fatal(err)
+	}
+	top := nearest.TopFieldDocs()
+	assertEquals(t, 8, top.TotalHits)
+	assertEquals(t, 3, len(top.FieldDocs))
+	for i, hit := range top.FieldDocs {
+		assertEquals(t, docs.ScoreDocs[len(docs.ScoreDocs)-1-i].Doc, hit.Doc)
+		if hit.Score <= 0 {
+			t.Errorf("Expected positive score, but %v", hit.Score)
+		}
+	}
+	if dist := top.FieldDocs[1].Value.(float64); dist < 1.1 || dist > 1.12 {
+		t.Errorf("Expected ~1.11km, but %v", dist)
+	}
+
+	// documents without a value come last either way
+	farthest := NewValueSortCollector(NewDistanceSource(lat, lon, 0, 0), 10, true)
+	if err = ss.SearchCollector(q, nil, farthest); err != nil {
+		t.Fatal(err)
+	}
+	top = farthest.TopFieldDocs()
+	assertEquals(t, 8, len(top.FieldDocs))
+	assertEquals(t, docs.ScoreDocs[1].Doc, top.FieldDocs[0].Doc)
+	assertEquals(t, docs.ScoreDocs[0].Doc, top.FieldDocs[7].Doc)
+	assertEquals(t, nil, top.FieldDocs[7].Value)
+}
+
+func TestSecureIndexSearcher(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	calls := 0
+	provider := NewCachingRoleQueryProvider(RoleQueryProviderFunc(func(user string) (Query, error) {
+		calls++
+		if user == "alice" {
+			return NewTermQuery(index.NewTerm("content", "fly")), nil
+		}
+		return nil, nil
+	}))
+	bat := NewTermQuery(index.NewTerm("content", "bat"))
+	both := NewBooleanQuery()
+	both.Add(NewTermQuery(index.NewTerm("content", "bat")), MUST)
+	both.Add(NewTermQuery(index.NewTerm("content", "fly")), MUST)
+	want, err := NewIndexSearcher(r).SearchTop(both, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.TotalHits == 0 || want.TotalHits == 8 {
+		t.Fatalf("expect some of the 8 bat docs to be about flying, got %v", want.TotalHits)
+	}
+
+	for i := 0; i < 2; i++ {
+		ss, err := NewSecureIndexSearcher(r, provider, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs, err := ss.SearchTop(bat, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, want.TotalHits, docs.TotalHits)
+		// the embedded searcher is restricted too
+		docs, err = ss.IndexSearcher.Search(bat, nil, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, want.TotalHits, docs.TotalHits)
+	}
+	assertEquals(t, 1, calls)
+
+	// non-term queries, restricted by a non-term role query
+	disjunction := func(words ...string) Query {
+		q := NewBooleanQuery()
+		for _, w := range words {
+			q.Add(NewTermQuery(index.NewTerm("content", w)), SHOULD)
+		}
+		return q
+	}
+	carol, err := NewSecureIndexSearcher(r, RoleQueryProviderFunc(func(string) (Query, error) {
+		return disjunction("fly", "learn"), nil
+	}), "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restricted := NewBooleanQuery()
+	restricted.Add(disjunction("fruit", "the"), MUST)
+	restricted.Add(disjunction("fly", "learn"), MUST)
+	if want, err = NewIndexSearcher(r).SearchTop(restricted, 10); err != nil {
+		t.Fatal(err)
+	}
+	if want.TotalHits == 0 {
+		t.Fatalf("expect some fruit docs about flying or learning")
+	}
+	docs, err := carol.SearchTop(disjunction("fruit", "the"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, want.TotalHits, docs.TotalHits)
+	exp, err := carol.Explain(disjunction("fruit", "the"), want.ScoreDocs[0].Doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exp.IsMatch() {
+		t.Errorf("expect a match for carol, got %v", exp)
+	}
+
+	ss, err := NewSecureIndexSearcher(r, provider, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs, err = ss.SearchTop(bat, 10); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 0, docs.TotalHits)
+	if exp, err = ss.Explain(bat, want.ScoreDocs[0].Doc); err != nil {
+		t.Fatal(err)
+	}
+	if exp.IsMatch() {
+		t.Errorf("expect no match for bob, got %v", exp)
+	}
+}
+
+/* Scorer over fixed scores, skipping those below the minimum competitive score. */
+type skippingScorer struct {
+	scores   []float32
+	doc      int
+	minScore float32
+}
+
+func (s *skippingScorer) DocId() int              { return s.doc }
+func (s *skippingScorer) Freq() (int, error)      { return 1, nil }
+func (s *skippingScorer) Cost() int64             { return int64(len(s.scores)) }
+func (s *skippingScorer) Score() (float32, error) { return s.scores[s.doc], nil }
+func (s *skippingScorer) Advance(target int) (int, error) {
+	for s.doc = target; s.doc < len(s.scores); s.doc++ {
+		if s.scores[s.doc] >= s.minScore {
+			return s.doc, nil
+		}
+	}
+	s.doc = NO_MORE_DOCS
+	return s.doc, nil
+}
+func (s *skippingScorer) NextDoc() (int, error) { return s.Advance(s.doc + 1) }
+func (s *skippingScorer) SetMinCompetitiveScore(minScore float32) {
+	s.minScore = minScore
+}
+
+func TestTotalHitsThreshold(t *testing.T) {
+	scores := []float32{1, 5, 2, 3, 1, 4, 2, 1, 6, 1}
+	collect := func(threshold int, skipping bool) TopDocs {
+		c := NewTopScoreDocCollectorWithThreshold(2, nil, true, threshold)
+		s := &skippingScorer{scores: scores, doc: -1}
+		c.SetNextReader(&index.AtomicReaderContext{})
+		if skipping {
+			c.SetScorer(s)
+		} else {
+			c.SetScorer(struct{ Scorer }{s})
+		}
+		for doc, _ := s.NextDoc(); doc != NO_MORE_DOCS; doc, _ = s.NextDoc() {
+			if err := c.Collect(doc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return c.TopDocs()
+	}
+	for i, v := range []struct {
+		threshold int
+		skipping  bool
+		hits      int
+		relation  TotalHitsRelation
+	}{
+		{math.MaxInt32, true, 10, TOTAL_HITS_RELATION_EQUAL_TO},
+		{10, true, 10, TOTAL_HITS_RELATION_EQUAL_TO},
+		// docs 0-2 are counted, then only those scoring more than the 2nd best
+		{2, true, 6, TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO},
+		// the scorer cannot skip, so all hits are counted
+		{2, false, 10, TOTAL_HITS_RELATION_EQUAL_TO},
+	} {
+		docs := collect(v.threshold, v.skipping)
+		if docs.TotalHits != v.hits || docs.TotalHitsRelation != v.relation {
+			t.Errorf("%v: expect %v hits (%v), got %v (%v)", i, v.hits, v.relation, docs.TotalHits, docs.TotalHitsRelation)
+		}
+		if got := fmt.Sprint(docs.ScoreDocs[0].Doc, docs.ScoreDocs[1].Doc); got != "8 1" {
+			t.Errorf("%v: expect top docs 8 1, got %v", i, got)
+		}
+	}
+
+	// term scorers cannot skip, so all hits are counted
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+	ss.SetTotalHitsThreshold(1)
+	docs, err := ss.SearchTop(NewTermQuery(index.NewTerm("content", "bat")), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 8, docs.TotalHits)
+	assertEquals(t, TOTAL_HITS_RELATION_EQUAL_TO, docs.TotalHitsRelation)
+}
+
+type recordedSpan struct {
+	name   string
+	parent *recordedSpan
+	attrs  map[string]interface{}
+	ended  bool
+	err    error
+}
+
+func (s *recordedSpan) IsRecording() bool { return true }
+func (s *recordedSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+func (s *recordedSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+type recordedSpanKey struct{}
+
+type recordingTracer struct {
+	sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, util.Span) {
+	span := &recordedSpan{name: name, attrs: make(map[string]interface{})}
+	span.parent, _ = ctx.Value(recordedSpanKey{}).(*recordedSpan)
+	t.Lock()
+	defer t.Unlock()
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+func TestTracing(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tracer := new(recordingTracer)
+	ctx, request := tracer.Start(context.Background(), "request")
+	ss := NewTracingIndexSearcher(r, tracer)
+	if _, err = ss.SearchContext(ctx, NewTermQuery(index.NewTerm("content", "bat")), nil, 5); err != nil {
+		t.Fatal(err)
+	}
+	request.End(nil)
+	var names []string
+	for _, span := range tracer.spans {
+		if !span.ended || span.err != nil {
+			t.Errorf("expect span %v to end without error, got %v", span.name, span.err)
+		}
+		names = append(names, span.name)
+	}
+	want := []string{"request", "IndexSearcher.search", "IndexSearcher.createNormalizedWeight"}
+	for range r.Leaves() {
+		want = append(want, "IndexSearcher.searchLeaf")
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expect spans %v, got %v", want, names)
+	}
+	search := tracer.spans[1]
+	if search.parent != tracer.spans[0] {
+		t.Errorf("expect the search to be traced under the request, got %v", search.parent)
+	}
+	for _, span := range tracer.spans[2:] {
+		if span.parent != search {
+			t.Errorf("expect span %v to be traced under the search, got %v", span.name, span.parent)
+		}
+	}
+	if search.attrs["query"] != "content:bat" || search.attrs["totalHits"] != 8 {
+		t.Errorf("expect query and total hits to be recorded, got %v", search.attrs)
+	}
+	if leaf := tracer.spans[3]; leaf.attrs["maxDoc"] != r.Leaves()[0].Reader().MaxDoc() {
+		t.Errorf("expect the segment to be recorded, got %v", leaf.attrs)
+	}
+}
+
+func TestTracingConcurrentSearch(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tracer := new(recordingTracer)
+	ss := NewTracingIndexSearcher(r, tracer)
+	ss.SetConcurrency(4)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	if _, err = ss.SearchCollectorManagerContext(context.Background(), q, nil, NewTopScoreDocCollectorManager(5, math.MaxInt32)); err != nil {
+		t.Fatal(err)
+	}
+	search := tracer.spans[0]
+	assertEquals(t, "IndexSearcher.search", search.name)
+	if search.parent != nil {
+		t.Errorf("expect the search to be a root span, got %v", search.parent)
+	}
+	assertEquals(t, 2+len(r.Leaves()), len(tracer.spans))
+	for _, span := range tracer.spans[1:] {
+		if span.parent != search {
+			t.Errorf("expect span %v to be traced under the search, got %v", span.name, span.parent)
+		}
+	}
+}
+
+/* Ranks each document by its doc ID, for testing. */
+type docIdRankSource struct{}
+
+func (s docIdRankSource) Values(ctx *index.AtomicReaderContext) (FieldValues, error) {
+	return func(doc int) (interface{}, error) {
+		return ctx.DocBase + doc, nil
+	}, nil
+}
+
+func (s docIdRankSource) String() string {
+	return "docid"
+}
+
+func TestStaticRankQuery(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	plain, err := ss.SearchTop(q, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rank-dominated blend orders by decreasing doc ID
+	docs, err := ss.SearchTop(NewStaticRankQuery(q, docIdRankSource{}, 1000, 1), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, plain.TotalHits, docs.TotalHits)
+	for i := 1; i < len(docs.ScoreDocs); i++ {
+		if docs.ScoreDocs[i].Doc > docs.ScoreDocs[i-1].Doc {
+			t.Errorf("Expected docs by decreasing rank, got %v", docs.ScoreDocs)
+		}
+	}
+
+	// scores are the relevance plus the rank contribution
+	srq := NewStaticRankQuery(q, docIdRankSource{}, 0.5, 10)
+	docs, err = ss.SearchTop(srq, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relevance := make(map[int]float32)
+	for _, sd := range plain.ScoreDocs {
+		relevance[sd.Doc] = sd.Score
+	}
+	for _, sd := range docs.ScoreDocs {
+		rank := float32(sd.Doc)
+		expected := relevance[sd.Doc] + 0.5*rank/(rank+10)
+		if math.Abs(float64(sd.Score-expected)) > 1e-5 {
+			t.Errorf("Expected score %v for doc %v, got %v", expected, sd.Doc, sd.Score)
+		}
+		exp, err := ss.Explain(srq, sd.Doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exp.IsMatch() || math.Abs(float64(exp.Value()-sd.Score)) > 1e-5 {
+			t.Errorf("Expected explanation of %v for doc %v, got %v", sd.Score, sd.Doc, exp)
+		}
+	}
+}
+
+func TestShardSearcher(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	single, err := NewIndexSearcher(r).SearchTop(q, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same index twice: each hit comes from both shards in turn
+	ss := NewShardSearcher(r, r)
+	assertEquals(t, int64(2*r.NumDocs()), ss.NumDocs())
+	docs, err := ss.SearchTop(q, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 2*single.TotalHits, docs.TotalHits)
+	assertEquals(t, 10, len(docs.ScoreDocs))
+	for i, hit := range docs.ScoreDocs {
+		expected := single.ScoreDocs[i/2]
+		if hit.Doc != expected.Doc || hit.Score != expected.Score || hit.ShardIndex() != i%2 {
+			t.Errorf("Expected %v of shard %v, got %v", expected, i%2, hit)
+		}
+	}
+	doc, err := ss.Document(docs.ScoreDocs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, "Bat recycling", doc.Get("title"))
+}
+
+func TestTermsFilter(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	expected, err := ss.SearchTop(NewTermQuery(index.NewTerm("content", "bat")), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// duplicated and missing terms match nothing more
+	f := NewTermsFilter(index.NewTerm("content", "bat"), index.NewTerm("nofield", "bat"),
+		index.NewTerm("content", "nosuchterm"), index.NewTerm("content", "bat"))
+	assertEquals(t, "content:bat content:nosuchterm nofield:bat", f.String())
+	docs, err := ss.SearchTop(NewFilteredQuery(NewTermQuery(index.NewTerm("content", "bat")), f), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, expected.TotalHits, docs.TotalHits)
+
+	var matched int
+	for _, ctx := range r.Leaves() {
+		set, err := f.DocIdSet(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		it, err := set.Iterator()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for doc, err := it.NextDoc(); doc != NO_MORE_DOCS; doc, err = it.NextDoc() {
+			if err != nil {
+				t.Fatal(err)
+			}
+			matched++
+		}
+	}
+	assertEquals(t, expected.TotalHits, matched)
+}
+
+func TestTermsAggregation(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	agg, err := NewTermsAggregationCollector(r, "content", "b", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ss.SearchCollector(q, nil, agg); err != nil {
+		t.Fatal(err)
+	}
+	top := agg.Top()
+	assertEquals(t, 3, len(top))
+	assertEquals(t, "bat (8)", top[0].String())
+	assertEquals(t, 0, agg.Missing())
+	if agg.Cardinality() < len(top) {
+		t.Errorf("Expected at least %v values, got %v", len(top), agg.Cardinality())
+	}
+	// each count is the number of hits also having the value
+	for i, tc := range top {
+		if tc.Term[0] != 'b' || i > 0 && tc.Count > top[i-1].Count {
+			t.Errorf("Unexpected %v after %v", tc, top[:i])
+		}
+		docs, err := ss.SearchTop(NewFilteredQuery(NewTermQuery(index.NewTerm("content", string(tc.Term))),
+			NewTermsFilter(index.NewTerm("content", "bat"))), 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, docs.TotalHits, tc.Count)
+	}
+
+	// the same index twice: the values of both leaves map to the same
+	// global ordinals
+	mr := index.NewMultiReader(r, r)
+	twice, err := NewTermsAggregationCollector(mr, "content", "b", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = NewIndexSearcher(mr).SearchCollector(q, nil, twice); err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range twice.Top() {
+		assertEquals(t, string(top[i].Term), string(tc.Term))
+		assertEquals(t, 2*top[i].Count, tc.Count)
+	}
+	assertEquals(t, agg.Cardinality(), twice.Cardinality())
+}
+
+func TestDateHistogram(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss := NewIndexSearcher(r)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	docs, err := ss.SearchTop(q, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 8, len(docs.ScoreDocs))
+
+	// the first hit has no timestamp, the others are 2014-03-31T22:30Z
+	// plus 0h, 1h, 2h, 3h, 24h, 48h and 31 days
+	base := time.Date(2014, 3, 31, 22, 30, 0, 0, time.UTC)
+	millis := make(map[int]int64)
+	for i, delta := range []time.Duration{0, 1, 2, 3, 24, 48, 31 * 24} {
+		millis[docs.ScoreDocs[i+1].Doc] = base.Add(delta*time.Hour).UnixNano() / int64(time.Millisecond)
+	}
+	source := docValueSource(func(doc int) interface{} {
+		if v, ok := millis[doc]; ok {
+			return v
+		}
+		return nil
+	})
+
+	for _, c := range []struct {
+		h        *DateHistogramCollector
+		expected string
+	}{
+		{NewDateHistogramCollector(source, DATE_INTERVAL_DAY),
+			"[2014-03-31T00:00:00Z (2) 2014-04-01T00:00:00Z (3) 2014-04-02T00:00:00Z (1) 2014-05-01T00:00:00Z (1)]"},
+		{NewDateHistogramCollector(source, DATE_INTERVAL_MONTH),
+			"[2014-03-01T00:00:00Z (2) 2014-04-01T00:00:00Z (4) 2014-05-01T00:00:00Z (1)]"},
+		// days of Paris, at UTC+2 since the 30th
+		{NewDateHistogramCollector(source, DATE_INTERVAL_DAY).SetLocation(time.FixedZone("CEST", 2*3600)),
+			"[2014-04-01T00:00:00+02:00 (4) 2014-04-02T00:00:00+02:00 (1) 2014-04-03T00:00:00+02:00 (1) 2014-05-02T00:00:00+02:00 (1)]"},
+		// days from 1:00 to 1:00
+		{NewDateHistogramCollector(source, DATE_INTERVAL_DAY).SetOffset(time.Hour),
+			"[2014-03-31T01:00:00Z (3) 2014-04-01T01:00:00Z (2) 2014-04-02T01:00:00Z (1) 2014-05-01T01:00:00Z (1)]"},
+		{NewDateHistogramCollector(source, DATE_INTERVAL_HOUR),
+			"[2014-03-31T22:00:00Z (1) 2014-03-31T23:00:00Z (1) 2014-04-01T00:00:00Z (1) 2014-04-01T01:00:00Z (1) 2014-04-01T22:00:00Z (1) 2014-04-02T22:00:00Z (1) 2014-05-01T22:00:00Z (1)]"},
+	} {
+		if err = ss.SearchCollector(q, nil, c.h); err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, c.expected, fmt.Sprint(c.h.Buckets()))
+		assertEquals(t, 1, c.h.Missing())
+	}
+
+	filled := NewDateHistogramCollector(source, DATE_INTERVAL_MONTH).SetFillGaps(true)
+	if err = ss.SearchCollector(q, nil, filled); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 3, len(filled.Buckets()))
+	filled = NewDateHistogramCollector(source, DATE_INTERVAL_DAY).SetFillGaps(true)
+	if err = ss.SearchCollector(q, nil, filled); err != nil {
+		t.Fatal(err)
+	}
+	buckets := filled.Buckets()
+	assertEquals(t, 32, len(buckets)) // from March 31st to May 1st
+	assertEquals(t, 0, buckets[10].Count)
+}
+
+func TestDateIntervalDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	// clocks went back from 3:00 to 2:00 on 2014-10-26
+	first := time.Date(2014, 10, 26, 0, 30, 0, 0, time.UTC).In(loc)  // 2:30 CEST
+	second := time.Date(2014, 10, 26, 1, 30, 0, 0, time.UTC).In(loc) // 2:30 CET
+	if h1, h2 := DATE_INTERVAL_HOUR.round(first), DATE_INTERVAL_HOUR.round(second); h1.Equal(h2) {
+		t.Errorf("Expected distinct hours, got %v and %v", h1, h2)
+	}
+	day := DATE_INTERVAL_DAY.round(second)
+	assertEquals(t, "2014-10-26T00:00:00+02:00", day.Format(time.RFC3339))
+	assertEquals(t, "2014-10-27T00:00:00+01:00", DATE_INTERVAL_DAY.next(day).Format(time.RFC3339))
+}
+
+func TestNestedAggregations(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same index twice, so that buckets span segments
+	mr := index.NewMultiReader(r, r)
+	ss := NewIndexSearcher(mr)
+	q := NewTermQuery(index.NewTerm("content", "bat"))
+	docs, err := ss.SearchTop(q, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, 16, len(docs.ScoreDocs))
+
+	// hits are in March 2014, then April, with prices 1, 2, 3...; the
+	// last hit has no timestamp
+	base := time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC)
+	millis := make(map[int]int64)
+	prices := make(map[int]float64)
+	for i, hit := range docs.ScoreDocs[:15] {
+		month := i % 2
+		millis[hit.Doc] = base.AddDate(0, month, 0).UnixNano() / int64(time.Millisecond)
+		prices[hit.Doc] = float64(i + 1)
+	}
+	timestamp := docValueSource(func(doc int) interface{} {
+		if v, ok := millis[doc]; ok {
+			return v
+		}
+		return nil
+	})
+	price := docValueSource(func(doc int) interface{} {
+		if v, ok := prices[doc]; ok {
+			return v
+		}
+		return nil
+	})
+
+	c := NewAggregationsCollector(
+		NewTermsAggregation("words", "content", 1000).
+			Add(NewDateHistogramAggregation("months", timestamp, DATE_INTERVAL_MONTH).
+				Add(NewMetricAggregation("price", METRIC_AVG, price)).
+				Add(NewMetricAggregation("max", METRIC_MAX, price))),
+		NewMetricAggregation("hits", METRIC_COUNT, timestamp))
+	if err = ss.SearchCollector(q, nil, c); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, "hits=15", c.Result("hits").String())
+	assertEquals(t, nil, c.Result("nosuchagg"))
+
+	words := c.Result("words").(*BucketsResult)
+	var bat *AggregationBucket
+	for _, b := range words.Buckets {
+		if b.Key == "bat" {
+			bat = b
+		}
+	}
+	if bat == nil {
+		t.Fatalf("Expected a bucket of bat, got %v", words)
+	}
+	assertEquals(t, 16, bat.Count)
+	// odd prices in March, even ones in April
+	assertEquals(t, "months[2014-03-01T00:00:00Z (8)[price=8 max=15] 2014-04-01T00:00:00Z (7)[price=8 max=14]]",
+		bat.Result("months").String())
+
+	// the last bucket holds a subset of the same hits
+	last := words.Buckets[len(words.Buckets)-1]
+	total := 0
+	for _, b := range last.Result("months").(*BucketsResult).Buckets {
+		total += b.Count
+		if max := b.Result("max").(*MetricResult).Value; max > 15 {
+			t.Errorf("Unexpected max price %v", max)
+		}
+	}
+	if total > last.Count || last.Count > bat.Count {
+		t.Errorf("Unexpected counts: %v", words)
+	}
+}
+
+func TestWANDQuery(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	words := []string{"bat", "recycling", "the", "belfry", "of"}
+	bq := NewBooleanQueryDisableCoord(true)
+	var clauses []Query
+	for _, w := range words {
+		bq.Add(NewTermQuery(index.NewTerm("content", w)), SHOULD)
+		clauses = append(clauses, NewTermQuery(index.NewTerm("content", w)))
+	}
+	wand := NewWANDQuery(clauses...)
+
+	ss := NewIndexSearcher(r)
+	expected, err := ss.SearchTop(bq, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := ss.SearchTop(wand, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, expected.TotalHits, all.TotalHits)
+	assertEquals(t, TOTAL_HITS_RELATION_EQUAL_TO, all.TotalHitsRelation)
+
+	ss.SetTotalHitsThreshold(3)
+	docs, err := ss.SearchTop(wand, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.TotalHits >= expected.TotalHits || docs.TotalHitsRelation != TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO {
+		t.Errorf("Expected fewer than %v hits counted, got %v (%v)", expected.TotalHits, docs.TotalHits, docs.TotalHitsRelation)
+	}
+	assertEquals(t, len(expected.ScoreDocs), len(docs.ScoreDocs))
+	for i, hit := range docs.ScoreDocs {
+		want := expected.ScoreDocs[i]
+		if hit.Doc != want.Doc || math.Abs(float64(hit.Score-want.Score)) > 1e-6 {
+			t.Errorf("Expected %v at %v, got %v", want, i, hit)
+		}
+		exp, err := ss.Explain(wand, hit.Doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(float64(exp.Value()-hit.Score)) > 1e-6 {
+			t.Errorf("Expected explanation of %v, got %v", hit.Score, exp)
+		}
+	}
+}
+
+func TestMultiTermQueryRewrite(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+
+	q := NewPrefixQuery(index.NewTerm("content", "br"))
+	q.SetBoost(2)
+	assertEquals(t, "content:br*^2", q.String())
+	search := func(method RewriteMethod) (rewritten Query, docs TopDocs) {
+		q.SetRewriteMethod(method)
+		if rewritten, err = ss.Rewrite(q); err != nil {
+			t.Fatal(err)
+		}
+		if docs, err = ss.SearchTop(q, 100); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// the default doc count cutoff is below a single doc of this small index
+	rewritten, expected := search(CONSTANT_SCORE_AUTO_REWRITE_DEFAULT)
+	if csq, ok := rewritten.(*ConstantScoreQuery); !ok || csq.Filter() == nil {
+		t.Errorf("Expected a filter rewrite, got %v", rewritten)
+	}
+	if expected.TotalHits == 0 {
+		t.Fatal("Expected hits")
+	}
+	for _, hit := range expected.ScoreDocs {
+		assertEquals(t, expected.ScoreDocs[0].Score, hit.Score)
+	}
+
+	sameDocs := func(docs TopDocs) {
+		assertEquals(t, expected.TotalHits, docs.TotalHits)
+		for i, hit := range docs.ScoreDocs {
+			assertEquals(t, expected.ScoreDocs[i].Doc, hit.Doc)
+		}
+	}
+	rewritten, docs := search(NewConstantScoreAutoRewrite().SetDocCountPercent(100))
+	if csq, ok := rewritten.(*ConstantScoreQuery); !ok || csq.Query() == nil {
+		t.Errorf("Expected a boolean rewrite, got %v", rewritten)
+	} else if bq, ok := csq.Query().(*BooleanQuery); !ok || len(bq.Clauses()) < 2 {
+		t.Errorf("Expected a boolean query of the terms, got %v", csq.Query())
+	}
+	sameDocs(docs)
+	for _, hit := range docs.ScoreDocs {
+		assertEquals(t, expected.ScoreDocs[0].Score, hit.Score)
+	}
+	exp, err := ss.Explain(q, docs.ScoreDocs[0].Doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, docs.ScoreDocs[0].Score, exp.Value())
+
+	rewritten, docs = search(NewConstantScoreAutoRewrite().SetDocCountPercent(100).SetTermCountCutoff(2))
+	if csq, ok := rewritten.(*ConstantScoreQuery); !ok || csq.Filter() == nil {
+		t.Errorf("Expected a filter rewrite, got %v", rewritten)
+	}
+	sameDocs(docs)
+
+	rewritten, docs = search(SCORING_BOOLEAN_QUERY_REWRITE)
+	if _, ok := rewritten.(*BooleanQuery); !ok {
+		t.Errorf("Expected a boolean query, got %v", rewritten)
+	}
+	assertEquals(t, expected.TotalHits, docs.TotalHits)
+
+	re := NewRegexpQuery(index.NewTerm("content", "br[a-z]*"))
+	if docs, err = ss.SearchTop(re, 100); err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, expected.TotalHits, docs.TotalHits)
+}
+
+func TestNormalizeScores(t *testing.T) {
+	docs := TopDocs{ScoreDocs: []*ScoreDoc{
+		newScoreDoc(3, 4), newScoreDoc(1, 2), newScoreDoc(2, 1),
+	}}
+	normalized := func(n ScoreNormalizer) (ans []float32) {
+		for i, hit := range NormalizeScores(docs, n) {
+			assertEquals(t, docs.ScoreDocs[i], hit.ScoreDoc)
+			ans = append(ans, hit.NormalizedScore)
+		}
+		return
+	}
+	if scores := normalized(SCORE_NORMALIZATION_MIN_MAX); !reflect.DeepEqual(scores, []float32{1, float32(1) / 3, 0}) {
+		t.Errorf("Expected min-max scores [1 0.33 0], got %v", scores)
+	}
+	assertEquals(t, float32(4), docs.ScoreDocs[0].Score) // raw scores kept
+
+	sigmoid := SigmoidNormalizer{A: 2, B: -4}
+	assertEquals(t, "sigmoid(2*score-4)", sigmoid.String())
+	scores := normalized(sigmoid)
+	assertEquals(t, float32(0.5), scores[1])
+	if !(scores[0] > 0.5 && scores[0] < 1 && scores[2] > 0 && scores[2] < 0.5) {
+		t.Errorf("Expected scores in (0,1) around 0.5, got %v", scores)
+	}
+}
+
+func TestTopTermsRewrite(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+
+	q := NewPrefixQuery(index.NewTerm("content", "br"))
+	q.SetRewriteMethod(SCORING_BOOLEAN_QUERY_REWRITE)
+	all := q.Rewrite(r).(*BooleanQuery).Clauses()
+	if len(all) < 3 {
+		t.Fatalf("Expected several br* terms, got %v", len(all))
+	}
+
+	for _, blend := range []bool{false, true} {
+		method := NewTopTermsScoringBooleanQueryRewrite(2)
+		if blend {
+			method = NewTopTermsBlendedFreqScoringRewrite(2)
+		}
+		q.SetRewriteMethod(method)
+		clauses := q.Rewrite(r).(*BooleanQuery).Clauses()
+		assertEquals(t, 2, len(clauses))
+		// all boosts are 1, so that the lowest terms are kept
+		maxDocFreq := 0
+		for i, c := range clauses {
+			tq := c.Query().(*TermQuery)
+			assertEquals(t, all[i].Query().(*TermQuery).Term().String(), tq.Term().String())
+			df, err := r.DocFreq(tq.Term())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if df > maxDocFreq {
+				maxDocFreq = df
+			}
+		}
+		for _, c := range clauses {
+			tq := c.Query().(*TermQuery)
+			if blend {
+				assertEquals(t, maxDocFreq, tq.docFreq)
+			} else {
+				assertEquals(t, -1, tq.docFreq)
+			}
+		}
+		docs, err := ss.SearchTop(q, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if docs.TotalHits == 0 {
+			t.Errorf("Expected hits with %v", method)
+		}
+	}
+}
+
+func TestTermScorerMaxScore(t *testing.T) {
+	d, err := store.OpenFSDirectory("testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := NewIndexSearcher(r)
+	sim := NewDefaultSimilarity()
+	ss.SetSimilarity(sim)
+	w, err := ss.CreateNormalizedWeight(context.Background(), NewTermQuery(index.NewTerm("content", "bat")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range ss.leafContexts {
+		for i := 0; i < 2; i++ {
+			s, err := w.Scorer(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s == nil {
+				continue
+			}
+			maxScore, err := s.(MaxScoreAware).MaxScore()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for doc, err := s.NextDoc(); doc != NO_MORE_DOCS; doc, err = s.NextDoc() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				score, err := s.Score()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if score > maxScore {
+					t.Errorf("Expected scores of at most %v, got %v for doc %v", maxScore, score, doc)
+				}
+			}
+		}
+	}
+	// the largest norm is read once per segment
+	assertEquals(t, len(ss.leafContexts), sim.maxNorms.Size())
+	for _, ctx := range ss.leafContexts {
+		v, err := sim.maxNorms.Get(ctx.Reader().(index.AtomicReader))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEquals(t, 1, len(v.(*maxNorms).byField))
+	}
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+	"sync"
+)
+
+/* Supplies the filter of the documents a user is allowed to see. */
+type RoleQueryProvider interface {
+	// Returns the filter of the documents the user may see, or nil if
+	// the user may see none.
+	RoleFilter(user string) (Filter, error)
+}
+
+/*
+A RoleQueryProvider computing the filter of a user from a query on
+its roles, e.g. a TermQuery on an "acl" field for each role.
+*/
+type RoleQueryProviderFunc func(user string) (Query, error)
+
+func (f RoleQueryProviderFunc) RoleFilter(user string) (Filter, error) {
+	q, err := f(user)
+	if q == nil || err != nil {
+		return nil, err
+	}
+	return NewQueryWrapperFilter(q), nil
+}
+
+/*
+Wraps a RoleQueryProvider, keeping a CachingWrapperFilter of the
+filter of each user, so that the documents a user may see are only
+computed once per segment. The cache of a user must be invalidated
+when its roles change.
+*/
+type CachingRoleQueryProvider struct {
+	sync.Mutex
+	provider RoleQueryProvider
+	cache    map[string]Filter
+}
+
+func NewCachingRoleQueryProvider(provider RoleQueryProvider) *CachingRoleQueryProvider {
+	return &CachingRoleQueryProvider{provider: provider, cache: make(map[string]Filter)}
+}
+
+func (p *CachingRoleQueryProvider) RoleFilter(user string) (Filter, error) {
+	p.Lock()
+	defer p.Unlock()
+	if f, ok := p.cache[user]; ok {
+		return f, nil
+	}
+	f, err := p.provider.RoleFilter(user)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		f = NewCachingWrapperFilter(f)
+	}
+	p.cache[user] = f
+	return f, nil
+}
+
+/* Drops the cached filter of the user. */
+func (p *CachingRoleQueryProvider) Invalidate(user string) {
+	p.Lock()
+	defer p.Unlock()
+	delete(p.cache, user)
+}
+
+/* Returns the memory used by the cached filters. */
+func (p *CachingRoleQueryProvider) RamBytesUsed() int64 {
+	var size int64
+	for _, a := range p.ChildResources() {
+		size += a.RamBytesUsed()
+	}
+	return size
+}
+
+/* Returns the cached filter of each user. */
+func (p *CachingRoleQueryProvider) ChildResources() []util.Accountable {
+	p.Lock()
+	defer p.Unlock()
+	var ans []util.Accountable
+	for user, f := range p.cache {
+		if a, ok := f.(util.Accountable); ok {
+			ans = append(ans, util.NamedAccountableOf(fmt.Sprintf("user '%v'", user), a))
+		}
+	}
+	sort.Sort(accountablesByName(ans))
+	return ans
+}
+
+/*
+An IndexSearcher on behalf of a user, which restricts every query it
+runs to the documents the user may see, as given by a
+RoleQueryProvider. The restriction applies to every method creating a
+Weight, i.e. searches and explanations, so that it cannot be
+forgotten by callers. A user without filter sees no document.
+*/
+type SecureIndexSearcher struct {
+	*IndexSearcher
+	user   string
+	filter Filter
+}
+
+func NewSecureIndexSearcher(r index.IndexReader, provider RoleQueryProvider, user string) (*SecureIndexSearcher, error) {
+	filter, err := provider.RoleFilter(user)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = matchNoDocsFilter{}
+	}
+	ss := &SecureIndexSearcher{NewIndexSearcher(r), user, filter}
+	ss.spi = ss
+	return ss, nil
+}
+
+func (ss *SecureIndexSearcher) User() string {
+	return ss.user
+}
+
+func (ss *SecureIndexSearcher) CreateNormalizedWeight(ctx context.Context, q Query) (Weight, error) {
+	return ss.IndexSearcher.CreateNormalizedWeight(ctx, NewFilteredQuery(q, ss.filter))
+}
+
+func (ss *SecureIndexSearcher) String() string {
+	return fmt.Sprintf("SecureIndexSearcher(%v,user=%v)", ss.reader, ss.user)
+}
+
+type matchNoDocsFilter struct{}
+
+func (f matchNoDocsFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error) {
+	return nil, nil
+}
+
+func (f matchNoDocsFilter) String() string {
+	return "MatchNoDocsFilter"
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"math"
+	"sort"
+)
+
+// search/TopDocs.java#merge
+
+/*
+Returns the top topN hits of the TopDocs of different indexes, e.g.
+the shards of a collection, setting the shard index of each hit to
+the position of its TopDocs, so that ShardIndex() tells which index
+its doc ID belongs to. Ties are broken by shard index, then doc ID.
+*/
+func MergeShards(topN int, shards ...TopDocs) TopDocs {
+	var ans TopDocs
+	var hits []*ScoreDoc
+	for i, shard := range shards {
+		ans.TotalHits += shard.TotalHits
+		if shard.TotalHitsRelation == TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO {
+			ans.TotalHitsRelation = TOTAL_HITS_RELATION_GREATER_THAN_OR_EQUAL_TO
+		}
+		for _, hit := range shard.ScoreDocs {
+			hits = append(hits, newShardedScoreDoc(hit.Doc, hit.Score, i))
+		}
+	}
+	sort.Sort(scoreDocsByShard(hits))
+	if len(hits) > topN {
+		hits = hits[:topN]
+	}
+	ans.ScoreDocs = hits
+	ans.maxScore = math.NaN()
+	if len(hits) > 0 {
+		ans.maxScore = float64(hits[0].Score)
+	}
+	return ans
+}
+
+type scoreDocsByShard []*ScoreDoc
+
+func (a scoreDocsByShard) Len() int      { return len(a) }
+func (a scoreDocsByShard) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a scoreDocsByShard) Less(i, j int) bool {
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	if a[i].shardIndex != a[j].shardIndex {
+		return a[i].shardIndex < a[j].shardIndex
+	}
+	return a[i].Doc < a[j].Doc
+}
+
+/*
+Searches several indexes as one, e.g. the shards written by an
+index.ShardedIndexWriter, whose documents together may exceed
+index.MAX_DOCS, where a MultiReader would overflow its doc IDs.
+Hits keep the doc IDs of their shard, and tell it with ShardIndex().
+
+Each shard is scored with its own term statistics, which are close to
+the global ones when documents are spread evenly over the shards.
+*/
+type ShardSearcher struct {
+	shards []*IndexSearcher
+}
+
+/* Creates a searcher of the given readers, one per shard. */
+func NewShardSearcher(readers ...index.IndexReader) *ShardSearcher {
+	assert2(len(readers) > 0, "at least one shard is required")
+	shards := make([]*IndexSearcher, len(readers))
+	for i, r := range readers {
+		shards[i] = NewIndexSearcher(r)
+	}
+	return &ShardSearcher{shards}
+}
+
+/* Returns the searchers of the shards. */
+func (s *ShardSearcher) Shards() []*IndexSearcher {
+	return s.shards
+}
+
+/* Returns the number of documents of all shards, deleted ones excluded. */
+func (s *ShardSearcher) NumDocs() int64 {
+	var ans int64
+	for _, shard := range s.shards {
+		ans += int64(shard.reader.NumDocs())
+	}
+	return ans
+}
+
+/* Finds the top n hits for query over all shards. */
+func (s *ShardSearcher) SearchTop(q Query, n int) (TopDocs, error) {
+	shardHits := make([]TopDocs, len(s.shards))
+	for i, shard := range s.shards {
+		hits, err := shard.SearchTop(q, n)
+		if err != nil {
+			return TopDocs{}, err
+		}
+		shardHits[i] = hits
+	}
+	return MergeShards(n, shardHits...), nil
+}
+
+/* Returns the stored fields of a hit returned by SearchTop(). */
+func (s *ShardSearcher) Document(hit *ScoreDoc) (*docu.Document, error) {
+	assert2(hit.shardIndex >= 0 && hit.shardIndex < len(s.shards),
+		"hit of unknown shard: %v", hit)
+	return s.shards[hit.shardIndex].reader.Document(hit.Doc)
+}
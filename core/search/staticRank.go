@@ -0,0 +1,162 @@
+package search
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// queries/function/BoostedQuery.java
+
+/*
+A query that blends the relevance of another query with a static,
+query-independent rank of its documents, e.g. a PageRank-like
+popularity stored with each document, read from a FieldValueSource:
+
+	score = relevance + weight * rank / (rank + pivot)
+
+The rank contribution saturates towards weight, so that a small
+weight only reorders documents of about equal relevance, popular ones
+first, while a large weight lets the rank dominate. Documents without
+a rank, or with a negative one, get no contribution.
+
+The rank is read for the matching documents only, segment by segment.
+Index sorting is not supported, so documents of equal blended score
+still come in doc ID order.
+*/
+type StaticRankQuery struct {
+	*AbstractQuery
+	query         Query
+	rank          FieldValueSource
+	weight, pivot float32
+}
+
+/*
+Blends the relevance of query with the rank read from source. pivot
+is the rank getting half of weight, e.g. the median rank.
+*/
+func NewStaticRankQuery(query Query, source FieldValueSource, weight, pivot float32) *StaticRankQuery {
+	assert2(weight >= 0, "weight must not be negative: %v", weight)
+	assert2(pivot > 0, "pivot must be positive: %v", pivot)
+	ans := &StaticRankQuery{query: query, rank: source, weight: weight, pivot: pivot}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+func (q *StaticRankQuery) Rewrite(r index.IndexReader) Query {
+	if rewritten := q.query.Rewrite(r); rewritten != q.query {
+		ans := NewStaticRankQuery(rewritten, q.rank, q.weight, q.pivot)
+		ans.SetBoost(q.Boost())
+		return ans
+	}
+	return q
+}
+
+func (q *StaticRankQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	weight, err := q.query.CreateWeight(ss)
+	if err != nil {
+		return nil, err
+	}
+	ans := &staticRankWeight{owner: q, weight: weight}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+/* Returns the contribution of a rank to the score. */
+func (q *StaticRankQuery) contribution(rank interface{}) (float32, error) {
+	if rank == nil {
+		return 0, nil
+	}
+	v, err := toFloat64(rank)
+	if err != nil || v <= 0 {
+		return 0, err
+	}
+	return q.weight * float32(v/(v+float64(q.pivot))), nil
+}
+
+func (q *StaticRankQuery) ToString(field string) string {
+	ans := fmt.Sprintf("static_rank(%v, %v, weight=%v, pivot=%v)",
+		q.query.ToString(field), q.rank, q.weight, q.pivot)
+	if q.Boost() != 1.0 {
+		ans += fmt.Sprintf("^%v", q.Boost())
+	}
+	return ans
+}
+
+type staticRankWeight struct {
+	*WeightImpl
+	owner  *StaticRankQuery
+	weight Weight
+}
+
+func (w *staticRankWeight) ValueForNormalization() float32 {
+	boost := w.owner.Boost()
+	return w.weight.ValueForNormalization() * boost * boost
+}
+
+func (w *staticRankWeight) Normalize(norm, topLevelBoost float32) {
+	w.weight.Normalize(norm, topLevelBoost*w.owner.Boost())
+}
+
+func (w *staticRankWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *staticRankWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	inner, err := w.weight.Explain(ctx, doc)
+	if err != nil || !inner.IsMatch() {
+		return inner, err
+	}
+	values, err := w.owner.rank.Values(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rank, err := values(doc)
+	if err != nil {
+		return nil, err
+	}
+	boost, err := w.owner.contribution(rank)
+	if err != nil {
+		return nil, err
+	}
+	ans := newComplexExplanation(true, inner.Value()+boost, "sum of:")
+	ans.addDetail(inner)
+	ans.addDetail(newExplanation(boost, fmt.Sprintf(
+		"%v * rank / (rank + %v), rank=%v from %v", w.owner.weight, w.owner.pivot, rank, w.owner.rank)))
+	return ans, nil
+}
+
+func (w *staticRankWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	scorer, err := w.weight.Scorer(ctx, acceptDocs)
+	if scorer == nil || err != nil {
+		return nil, err
+	}
+	values, err := w.owner.rank.Values(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ans := &staticRankScorer{Scorer: scorer, owner: w.owner, values: values}
+	ans.abstractScorer = newScorer(ans, w)
+	return ans, nil
+}
+
+/* Adds the rank contribution to the scores of the wrapped scorer. */
+type staticRankScorer struct {
+	Scorer
+	*abstractScorer
+	owner  *StaticRankQuery
+	values FieldValues
+}
+
+func (s *staticRankScorer) Score() (float32, error) {
+	score, err := s.Scorer.Score()
+	if err != nil {
+		return 0, err
+	}
+	rank, err := s.values(s.Scorer.DocId())
+	if err != nil {
+		return 0, err
+	}
+	boost, err := s.owner.contribution(rank)
+	return score + boost, err
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"bytes"
+	. "github.com/balzaczyy/golucene/core/index/model"
+)
+
+// search/TermRangeQuery.java
+
+/*
+A Query that matches documents within an range of terms.
+
+This query matches the documents looking for terms that fall into the
+supplied range according to the byte order of the terms. It is not
+intended for numerical ranges; unless the numbers are padded to a
+fixed width, e.g. "007", they are not sorted in their numeric order.
+
+A nil lower or upper term leaves that end of the range open.
+*/
+type TermRangeQuery struct {
+	*MultiTermQuery
+	lowerTerm    []byte
+	upperTerm    []byte
+	includeLower bool
+	includeUpper bool
+}
+
+/*
+Constructs a query selecting all terms greater/equal than lowerTerm
+but less/equal than upperTerm. Either term may be nil, for an open
+end, in which case its inclusion flag is ignored.
+*/
+func NewTermRangeQuery(field string, lowerTerm, upperTerm []byte,
+	includeLower, includeUpper bool) *TermRangeQuery {
+
+	ans := &TermRangeQuery{
+		lowerTerm:    lowerTerm,
+		upperTerm:    upperTerm,
+		includeLower: includeLower,
+		includeUpper: includeUpper,
+	}
+	ans.MultiTermQuery = newMultiTermQuery(field, ans)
+	return ans
+}
+
+/* Factory that creates a new TermRangeQuery using strings for term text. */
+func NewTermRangeQueryFromStrings(field string, lowerTerm, upperTerm *string,
+	includeLower, includeUpper bool) *TermRangeQuery {
+
+	var lower, upper []byte
+	if lowerTerm != nil {
+		lower = []byte(*lowerTerm)
+	}
+	if upperTerm != nil {
+		upper = []byte(*upperTerm)
+	}
+	return NewTermRangeQuery(field, lower, upper, includeLower, includeUpper)
+}
+
+/* Returns the lower value of this range query, nil if open. */
+func (q *TermRangeQuery) LowerTerm() []byte { return q.lowerTerm }
+
+/* Returns the upper value of this range query, nil if open. */
+func (q *TermRangeQuery) UpperTerm() []byte { return q.upperTerm }
+
+/* Returns true if the lower endpoint is inclusive */
+func (q *TermRangeQuery) IncludesLower() bool { return q.includeLower }
+
+/* Returns true if the upper endpoint is inclusive */
+func (q *TermRangeQuery) IncludesUpper() bool { return q.includeUpper }
+
+func (q *TermRangeQuery) TermsEnum(terms Terms) TermsEnum {
+	return &termRangeTermsEnum{
+		TermsEnum: terms.Iterator(nil),
+		query:     q,
+	}
+}
+
+func (q *TermRangeQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	if q.Field() != field {
+		buf.WriteString(q.Field())
+		buf.WriteString(":")
+	}
+	if q.includeLower {
+		buf.WriteString("[")
+	} else {
+		buf.WriteString("{")
+	}
+	if q.lowerTerm == nil {
+		buf.WriteString("*")
+	} else {
+		buf.Write(q.lowerTerm)
+	}
+	buf.WriteString(" TO ")
+	if q.upperTerm == nil {
+		buf.WriteString("*")
+	} else {
+		buf.Write(q.upperTerm)
+	}
+	if q.includeUpper {
+		buf.WriteString("]")
+	} else {
+		buf.WriteString("}")
+	}
+	return withBoost(&buf, q.Boost())
+}
+
+// search/TermRangeTermsEnum.java
+
+/*
+Subclass of FilteredTermEnum for enumerating all terms that match the
+specified range parameters. The terms dictionary is scanned from its
+first term, as not all terms enums can seek yet, up to the upper
+term.
+*/
+type termRangeTermsEnum struct {
+	TermsEnum
+	query *TermRangeQuery
+	done  bool
+}
+
+func (e *termRangeTermsEnum) Next() ([]byte, error) {
+	for !e.done {
+		term, err := e.TermsEnum.Next()
+		if term == nil || err != nil {
+			e.done = true
+			return nil, err
+		}
+		if lower := e.query.lowerTerm; lower != nil {
+			if c := bytes.Compare(term, lower); c < 0 || c == 0 && !e.query.includeLower {
+				continue
+			}
+		}
+		if upper := e.query.upperTerm; upper != nil {
+			if c := bytes.Compare(term, upper); c > 0 || c == 0 && !e.query.includeUpper {
+				// terms are sorted, no more matches
+				e.done = true
+				return nil, nil
+			}
+		}
+		return term, nil
+	}
+	return nil, nil
+}
@@ -7,6 +7,7 @@ import (
 	. "github.com/balzaczyy/golucene/core/index/model"
 	. "github.com/balzaczyy/golucene/core/search/model"
 	"github.com/balzaczyy/golucene/core/util"
+	"math"
 	"reflect"
 )
 
@@ -29,6 +30,10 @@ func NewTermQueryWithDocFreq(t *index.Term, docFreq int) *TermQuery {
 	return ans
 }
 
+func (q *TermQuery) Term() *index.Term {
+	return q.term
+}
+
 func (q *TermQuery) CreateWeight(ss *IndexSearcher) (w Weight, err error) {
 	ctx := ss.TopReaderContext()
 	var termState *index.TermContext
@@ -124,7 +129,20 @@ func (tw *TermWeight) Scorer(context *index.AtomicReaderContext,
 	if err != nil {
 		return nil, err
 	}
-	return newTermScorer(tw, docs, simScorer), nil
+	ans := newTermScorer(tw, docs, simScorer)
+	// each doc of the term has it at least once
+	df, err := termsEnum.DocFreq()
+	if err != nil {
+		return nil, err
+	}
+	ttf, err := termsEnum.TotalTermFreq()
+	if err != nil {
+		return nil, err
+	}
+	if ans.maxFreq = 1; ttf > 0 {
+		ans.maxFreq = ttf - int64(df) + 1
+	}
+	return ans, nil
 }
 
 func (tw *TermWeight) termsEnum(ctx *index.AtomicReaderContext) (TermsEnum, error) {
@@ -185,6 +203,7 @@ type TermScorer struct {
 	*abstractScorer
 	docsEnum  DocsEnum
 	docScorer SimScorer
+	maxFreq   int64 // bound of the freq of the term in a doc of the segment
 }
 
 func newTermScorer(w Weight, td DocsEnum, docScorer SimScorer) *TermScorer {
@@ -227,6 +246,23 @@ func (ts *TermScorer) Advance(target int) (int, error) {
 	return ts.docsEnum.Advance(target)
 }
 
+func (ts *TermScorer) Cost() int64 {
+	return ts.docsEnum.Cost()
+}
+
+/*
+Returns an upper bound of the scores of the docs of the segment, from
+the statistics of the term, or +Inf if the Similarity cannot tell.
+*/
+func (ts *TermScorer) MaxScore() (float32, error) {
+	if ms, ok := ts.docScorer.(interface {
+		maxScore(maxFreq float32) (float32, error)
+	}); ok {
+		return ms.maxScore(float32(ts.maxFreq))
+	}
+	return float32(math.Inf(1)), nil
+}
+
 func (ts *TermScorer) String() string {
 	return fmt.Sprintf("scorer(%v)", ts.weight)
 }
@@ -0,0 +1,169 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	"github.com/balzaczyy/golucene/core/index"
+	"sort"
+)
+
+// facet/sortedset/SortedSetDocValuesFacetCounts.java
+
+/* A value of a field, and the number of matching documents having it. */
+type TermCount struct {
+	Term  []byte
+	Count int
+}
+
+func (c *TermCount) String() string {
+	return fmt.Sprintf("%v (%v)", string(c.Term), c.Count)
+}
+
+/*
+Collector counting the matching documents per value of a field, to
+return the most frequent ones, e.g. the top authors or tags of the
+hits, without a taxonomy or any faceting setup.
+
+The values of each segment come from its SORTED or SORTED_SET doc
+values, or else are uninverted from its indexed terms, optionally
+only those starting with a prefix. They are mapped to global ordinals
+once, when the collector is created for a reader, so that a single
+count array covers all segments. A document having several values is
+counted for each of them.
+*/
+type TermsAggregationCollector struct {
+	prefix  []byte
+	size    int
+	leaves  []SortedSetDocValues
+	mapping *index.OrdinalMap // nil for a single segment
+	counts  []int
+	missing int
+
+	segment int
+	values  SortedSetDocValues
+}
+
+/*
+Creates a collector of the size most frequent values of field in the
+reader searched, restricted to those starting with prefix, if not
+empty.
+*/
+func NewTermsAggregationCollector(r index.IndexReader, field, prefix string,
+	size int) (*TermsAggregationCollector, error) {
+
+	assert2(size > 0, "size must be > 0 (got %v)", size)
+	leaves := r.Leaves()
+	ans := &TermsAggregationCollector{
+		prefix: []byte(prefix),
+		size:   size,
+		leaves: make([]SortedSetDocValues, len(leaves)),
+	}
+	for i, ctx := range leaves {
+		v, err := index.SortedSetOrUninverted(ctx.Reader().(index.AtomicReader), field, ans.prefix)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			v = index.SingletonSortedSetDocValues(index.EMPTY_SORTED_DOC_VALUES)
+		}
+		ans.leaves[i] = v
+	}
+	valueCount := int64(0)
+	if len(leaves) == 1 {
+		valueCount = ans.leaves[0].ValueCount()
+	} else if len(leaves) > 1 {
+		var err error
+		if ans.mapping, err = index.NewOrdinalMap(r, ans.leaves); err != nil {
+			return nil, err
+		}
+		valueCount = ans.mapping.ValueCount()
+	}
+	ans.counts = make([]int, valueCount)
+	return ans, nil
+}
+
+func (c *TermsAggregationCollector) SetScorer(s Scorer) {}
+
+func (c *TermsAggregationCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	c.segment, c.values = ctx.Ord, c.leaves[ctx.Ord]
+}
+
+func (c *TermsAggregationCollector) Collect(doc int) error {
+	c.values.SetDocument(doc)
+	ord := c.values.NextOrd()
+	if ord == NO_MORE_ORDS {
+		c.missing++
+		return nil
+	}
+	for ; ord != NO_MORE_ORDS; ord = c.values.NextOrd() {
+		if c.mapping != nil {
+			ord = c.mapping.GlobalOrd(c.segment, ord)
+		}
+		c.counts[ord]++
+	}
+	return nil
+}
+
+func (c *TermsAggregationCollector) AcceptsDocsOutOfOrder() bool {
+	return true
+}
+
+/* Returns the global ordinal's value. */
+func (c *TermsAggregationCollector) lookupOrd(ord int64) []byte {
+	if c.mapping == nil {
+		return c.leaves[0].LookupOrd(ord)
+	}
+	segment := c.mapping.FirstSegmentNumber(ord)
+	return c.leaves[segment].LookupOrd(c.mapping.FirstSegmentOrd(ord))
+}
+
+/*
+Returns the most frequent values, by decreasing count, then
+increasing value, up to the collector's size.
+*/
+func (c *TermsAggregationCollector) Top() []*TermCount {
+	var ans []*TermCount
+	for ord, count := range c.counts {
+		if count == 0 {
+			continue
+		}
+		term := c.lookupOrd(int64(ord))
+		// doc values are not restricted to the prefix
+		if bytes.HasPrefix(term, c.prefix) {
+			ans = append(ans, &TermCount{term, count})
+		}
+	}
+	sort.Sort(termCountsByCount(ans))
+	if len(ans) > c.size {
+		ans = ans[:c.size]
+	}
+	return ans
+}
+
+/* Returns the number of distinct values of the matching documents. */
+func (c *TermsAggregationCollector) Cardinality() int {
+	ans := 0
+	for ord, count := range c.counts {
+		if count > 0 && bytes.HasPrefix(c.lookupOrd(int64(ord)), c.prefix) {
+			ans++
+		}
+	}
+	return ans
+}
+
+/* Returns the number of matching documents without a value. */
+func (c *TermsAggregationCollector) Missing() int {
+	return c.missing
+}
+
+type termCountsByCount []*TermCount
+
+func (a termCountsByCount) Len() int      { return len(a) }
+func (a termCountsByCount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a termCountsByCount) Less(i, j int) bool {
+	if a[i].Count != a[j].Count {
+		return a[i].Count > a[j].Count
+	}
+	return bytes.Compare(a[i].Term, a[j].Term) < 0
+}
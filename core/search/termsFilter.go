@@ -0,0 +1,102 @@
+package search
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// queries/TermsFilter.java
+
+/*
+A filter matching the documents containing any of a set of terms,
+e.g. a list of IDs or categories, which may span several fields. It
+is a cheaper equivalent of a BooleanQuery of SHOULD TermQuery clauses
+when the scores do not matter.
+
+The docs of each segment are collected into a DocIdSetBuilder, so
+that few matches take memory proportional to their number, not to
+the size of the segment.
+*/
+type TermsFilter struct {
+	terms []*index.Term
+}
+
+/* Creates a filter of the given terms, in any order, with duplicates. */
+func NewTermsFilter(terms ...*index.Term) *TermsFilter {
+	assert2(len(terms) > 0, "You must specify at least one term")
+	sorted := append([]*index.Term(nil), terms...)
+	sort.Sort(termsByFieldAndText(sorted))
+	// dedup
+	n := 0
+	for i, t := range sorted {
+		if i == 0 || compareTerms(t, sorted[n-1]) != 0 {
+			sorted[n] = t
+			n++
+		}
+	}
+	return &TermsFilter{sorted[:n]}
+}
+
+func (f *TermsFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (DocIdSet, error) {
+	reader := ctx.Reader().(index.AtomicReader)
+	builder := util.NewDocIdSetBuilder(reader.MaxDoc())
+	var field string
+	var termsEnum TermsEnum
+	var docs DocsEnum
+	for _, t := range f.terms {
+		if termsEnum == nil || t.Field != field {
+			field, termsEnum = t.Field, nil
+			if terms := reader.Terms(field); terms != nil {
+				termsEnum = terms.Iterator(nil)
+			}
+		}
+		if termsEnum == nil {
+			continue
+		}
+		ok, err := termsEnum.SeekExact(t.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if docs, err = termsEnum.Docs(acceptDocs, docs); err != nil {
+			return nil, err
+		}
+		if err = builder.AddIterator(docs); err != nil {
+			return nil, err
+		}
+	}
+	return builder.Build(), nil
+}
+
+func (f *TermsFilter) String() string {
+	var buf bytes.Buffer
+	for i, t := range f.terms {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(t.String())
+	}
+	return buf.String()
+}
+
+type termsByFieldAndText []*index.Term
+
+func (a termsByFieldAndText) Len() int           { return len(a) }
+func (a termsByFieldAndText) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a termsByFieldAndText) Less(i, j int) bool { return compareTerms(a[i], a[j]) < 0 }
+
+/* Compares terms by field, then by bytes. */
+func compareTerms(a, b *index.Term) int {
+	if a.Field != b.Field {
+		if a.Field < b.Field {
+			return -1
+		}
+		return 1
+	}
+	return bytes.Compare(a.Bytes, b.Bytes)
+}
@@ -0,0 +1,330 @@
+package search
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+/*
+Implemented by scorers which know an upper bound of their scores in
+the current segment, e.g. TermScorer from the statistics of its term.
+*/
+type MaxScoreAware interface {
+	MaxScore() (float32, error)
+}
+
+/*
+A disjunction of queries, scoring the sum of the scores of the
+matching clauses, as a BooleanQuery of SHOULD clauses without coord,
+which skips the documents which cannot make it to the top hits once
+the collector knows the minimum competitive score (see
+TopScoreDocCollector and IndexSearcher.SetTotalHitsThreshold()).
+
+The skipping follows the weak-AND (WAND) algorithm: a document can
+only be competitive if the upper bounds of the scores of the clauses
+matching it sum up to the minimum, so that those of the clauses whose
+bounds cannot reach it on their own are advanced past it. Clauses
+whose scorers are not MaxScoreAware are never skipped.
+*/
+type WANDQuery struct {
+	*AbstractQuery
+	clauses []Query
+}
+
+func NewWANDQuery(clauses ...Query) *WANDQuery {
+	assert2(len(clauses) > 0, "at least one clause is required")
+	ans := &WANDQuery{clauses: clauses}
+	ans.AbstractQuery = NewAbstractQuery(ans)
+	return ans
+}
+
+/* Returns the clauses of the disjunction. */
+func (q *WANDQuery) Clauses() []Query {
+	return q.clauses
+}
+
+func (q *WANDQuery) Rewrite(r index.IndexReader) Query {
+	var clauses []Query
+	for i, c := range q.clauses {
+		if rewritten := c.Rewrite(r); rewritten != c && clauses == nil {
+			clauses = append(append(clauses, q.clauses[:i]...), rewritten)
+		} else if clauses != nil {
+			clauses = append(clauses, rewritten)
+		}
+	}
+	if clauses == nil {
+		return q
+	}
+	ans := NewWANDQuery(clauses...)
+	ans.SetBoost(q.Boost())
+	return ans
+}
+
+func (q *WANDQuery) CreateWeight(ss *IndexSearcher) (Weight, error) {
+	ans := &wandWeight{owner: q, weights: make([]Weight, len(q.clauses))}
+	for i, c := range q.clauses {
+		w, err := c.CreateWeight(ss)
+		if err != nil {
+			return nil, err
+		}
+		ans.weights[i] = w
+	}
+	ans.WeightImpl = newWeightImpl(ans)
+	return ans, nil
+}
+
+func (q *WANDQuery) ToString(field string) string {
+	var buf bytes.Buffer
+	buf.WriteString("wand(")
+	for i, c := range q.clauses {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(c.ToString(field))
+	}
+	buf.WriteString(")")
+	if q.Boost() != 1.0 {
+		fmt.Fprintf(&buf, "^%v", q.Boost())
+	}
+	return buf.String()
+}
+
+type wandWeight struct {
+	*WeightImpl
+	owner   *WANDQuery
+	weights []Weight
+}
+
+func (w *wandWeight) ValueForNormalization() (sum float32) {
+	for _, sub := range w.weights {
+		sum += sub.ValueForNormalization()
+	}
+	boost := w.owner.Boost()
+	return sum * boost * boost
+}
+
+func (w *wandWeight) Normalize(norm, topLevelBoost float32) {
+	for _, sub := range w.weights {
+		sub.Normalize(norm, topLevelBoost*w.owner.Boost())
+	}
+}
+
+func (w *wandWeight) IsScoresDocsOutOfOrder() bool {
+	return false
+}
+
+func (w *wandWeight) Explain(ctx *index.AtomicReaderContext, doc int) (Explanation, error) {
+	var matches []Explanation
+	var sum float32
+	for _, sub := range w.weights {
+		e, err := sub.Explain(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsMatch() {
+			matches = append(matches, e)
+			sum += e.Value()
+		}
+	}
+	if len(matches) == 0 {
+		return newComplexExplanation(false, 0, "no matching clause"), nil
+	}
+	ans := newComplexExplanation(true, sum, "sum of:")
+	for _, e := range matches {
+		ans.addDetail(e)
+	}
+	return ans, nil
+}
+
+func (w *wandWeight) Scorer(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (Scorer, error) {
+	var subs []Scorer
+	for _, sub := range w.weights {
+		s, err := sub.Scorer(ctx, acceptDocs)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			subs = append(subs, s)
+		}
+	}
+	if len(subs) == 0 {
+		return nil, nil
+	}
+	return newWANDScorer(w, subs)
+}
+
+// search/WANDScorer.java
+
+/* A clause of a WANDScorer, and the upper bound of its scores. */
+type wandClause struct {
+	scorer   Scorer
+	maxScore float64
+	doc      int
+}
+
+/*
+Disjunction scorer skipping the documents whose clauses cannot score
+the minimum competitive score. Clauses are kept in a heap by doc, from
+which they are popped in order until their upper bounds sum up to the
+minimum: the doc of the last one, the pivot, is the first candidate.
+The clauses popped before it are advanced to it, without scoring the
+docs they skip, so that only the clauses up to the pivot are visited.
+*/
+type WANDScorer struct {
+	*abstractScorer
+	clauses  wandClauseHeap
+	popped   []*wandClause // clauses popped from the heap up to the pivot
+	minScore float32
+	doc      int
+	score    float32
+	freq     int
+}
+
+func newWANDScorer(w Weight, subs []Scorer) (*WANDScorer, error) {
+	ans := &WANDScorer{doc: -1, minScore: float32(math.Inf(-1))}
+	for _, s := range subs {
+		c := &wandClause{scorer: s, maxScore: math.Inf(1), doc: -1}
+		if ms, ok := s.(MaxScoreAware); ok {
+			maxScore, err := ms.MaxScore()
+			if err != nil {
+				return nil, err
+			}
+			c.maxScore = float64(maxScore)
+		}
+		ans.clauses = append(ans.clauses, c)
+	}
+	// all clauses are on -1, so that they already form a heap
+	ans.abstractScorer = newScorer(ans, w)
+	return ans, nil
+}
+
+func (s *WANDScorer) SetMinCompetitiveScore(minScore float32) {
+	s.minScore = minScore
+}
+
+func (s *WANDScorer) DocId() int {
+	return s.doc
+}
+
+/* Returns the number of clauses matching the current doc. */
+func (s *WANDScorer) Freq() (int, error) {
+	return s.freq, nil
+}
+
+func (s *WANDScorer) Score() (float32, error) {
+	return s.score, nil
+}
+
+func (s *WANDScorer) Cost() (cost int64) {
+	for _, c := range s.clauses {
+		cost += c.scorer.Cost()
+	}
+	return
+}
+
+func (s *WANDScorer) NextDoc() (int, error) {
+	return s.Advance(s.doc + 1)
+}
+
+func (s *WANDScorer) Advance(target int) (int, error) {
+	for s.clauses[0].doc < target {
+		if err := s.clauses[0].advance(target); err != nil {
+			return 0, err
+		}
+		heap.Fix(&s.clauses, 0)
+	}
+	for {
+		if !s.popPivot() {
+			s.pushPopped()
+			s.doc = NO_MORE_DOCS
+			return s.doc, nil
+		}
+		pivotDoc := s.popped[len(s.popped)-1].doc
+		if s.popped[0].doc < pivotDoc {
+			// the clauses before the pivot cannot match a competitive doc
+			// before it on their own
+			for _, c := range s.popped {
+				if err := c.advance(pivotDoc); err != nil {
+					return 0, err
+				}
+			}
+			s.pushPopped()
+			continue
+		}
+		// all clauses up to the pivot are on it, and maybe some after
+		for len(s.clauses) > 0 && s.clauses[0].doc == pivotDoc {
+			s.popped = append(s.popped, heap.Pop(&s.clauses).(*wandClause))
+		}
+		var score float32
+		for _, c := range s.popped {
+			cs, err := c.scorer.Score()
+			if err != nil {
+				return 0, err
+			}
+			score += cs
+		}
+		if score >= s.minScore {
+			s.doc, s.score, s.freq = pivotDoc, score, len(s.popped)
+			s.pushPopped()
+			return s.doc, nil
+		}
+		for _, c := range s.popped {
+			if err := c.advance(pivotDoc + 1); err != nil {
+				return 0, err
+			}
+		}
+		s.pushPopped()
+	}
+}
+
+/*
+Pops the clauses in doc order up to the pivot, the first one at which
+the sum of the upper bounds reaches the minimum score, and returns
+whether there is one. The sum is rounded up slightly, so that float32
+rounding of the actual scores cannot exceed it.
+*/
+func (s *WANDScorer) popPivot() bool {
+	var sum float64
+	for len(s.clauses) > 0 && s.clauses[0].doc != NO_MORE_DOCS {
+		c := heap.Pop(&s.clauses).(*wandClause)
+		s.popped = append(s.popped, c)
+		if sum += c.maxScore; sum*(1+1e-6) >= float64(s.minScore) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WANDScorer) pushPopped() {
+	for _, c := range s.popped {
+		heap.Push(&s.clauses, c)
+	}
+	s.popped = s.popped[:0]
+}
+
+func (c *wandClause) advance(target int) (err error) {
+	if c.doc < target {
+		c.doc, err = c.scorer.Advance(target)
+	}
+	return
+}
+
+/* Heap of the clauses of a WANDScorer, by doc. */
+type wandClauseHeap []*wandClause
+
+func (h wandClauseHeap) Len() int            { return len(h) }
+func (h wandClauseHeap) Less(i, j int) bool  { return h[i].doc < h[j].doc }
+func (h wandClauseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *wandClauseHeap) Push(x interface{}) { *h = append(*h, x.(*wandClause)) }
+func (h *wandClauseHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	ans := old[n-1]
+	*h = old[:n-1]
+	return ans
+}
@@ -30,6 +30,22 @@ A Weight is used in the following way:
 	3. The query normlaization factor is passed to normalize(). At this
 	point the weighting is complete.
 	4. A Scorer is constructed by scorer().
+
+Scores follow the classic (Lucene 4) vector space model, queryNorm
+included. Every compound weight contributes to the normalization in
+the same way, so that the scores of multi-clause queries are
+consistent with those of their clauses:
+	- ValueForNormalization() returns the sum of the values of the
+	(non-prohibited) sub-weights, multiplied by the square of the
+	query's boost; a leaf returns the square of its query weight,
+	e.g. (idf * boost)^2 for TF-IDF.
+	- Normalize() passes the queryNorm unchanged to every sub-weight,
+	along with topLevelBoost multiplied by the query's boost.
+	- Normalize() may be called more than once, e.g. when a Weight is
+	re-normalized as part of another query; it must not compound.
+As a result, a single-term query scores the same whatever its boost,
+and boosts only weigh clauses against each other. The Similarity may
+opt out of the normalization by returning 1 from QueryNorm().
 */
 type Weight interface {
 	// An explanation of the score computation for the named document.
@@ -38,7 +54,11 @@ type Weight interface {
 	ValueForNormalization() float32
 	/** Assigns the query normalization factor and boost from parent queries to this. */
 	Normalize(norm float32, topLevelBoost float32)
-	// Scorer(*index.AtomicReaderContext, util.Bits) (Scorer, error)
+	/**
+	 * Returns a {@link Scorer} which scores documents in order, or nil
+	 * if no documents will be scored by this query.
+	 */
+	Scorer(*index.AtomicReaderContext, util.Bits) (Scorer, error)
 	/**
 	 * Returns a {@link Scorer} which scores documents in/out-of order according
 	 * to <code>scoreDocsInOrder</code>.
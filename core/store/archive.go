@@ -0,0 +1,185 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/codec"
+	"io"
+)
+
+/*
+Serializes a whole directory, e.g. a small prebuilt index, into a
+single stream, and loads it back, so that it can be shipped inside a
+binary or over the network as one artifact.
+
+Archive --> Header, NumFiles, <FileName, FileLength, Bytes>^NumFiles, Footer
+
+	Header --> CodecHeader, see codec.WriteHeader()
+	NumFiles --> VInt
+	FileName --> String
+	FileLength --> VLong
+	Footer --> CodecFooter, whose checksum covers all previous bytes
+*/
+const (
+	ARCHIVE_CODEC           = "DirectoryArchive"
+	ARCHIVE_VERSION_START   = 0
+	ARCHIVE_VERSION_CURRENT = ARCHIVE_VERSION_START
+)
+
+/* Name of the lock file, which is never archived. */
+const archiveLockName = "write.lock"
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (w nopWriteCloser) Close() error {
+	return nil
+}
+
+/*
+Writes all files of dir to w. The directory should hold a committed
+index, with no IndexWriter open on it, as files changing while being
+exported would corrupt the archive.
+*/
+func ExportDirectory(dir Directory, w io.Writer) error {
+	names, err := dir.ListAll()
+	if err != nil {
+		return err
+	}
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != archiveLockName {
+			files = append(files, name)
+		}
+	}
+
+	out := newOutputStreamIndexOutput(nopWriteCloser{w}, 0)
+	if err = codec.WriteHeader(out, ARCHIVE_CODEC, ARCHIVE_VERSION_CURRENT); err != nil {
+		return err
+	}
+	if err = out.WriteVInt(int32(len(files))); err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err = exportFile(dir, name, out); err != nil {
+			return err
+		}
+	}
+	return codec.WriteFooter(out)
+}
+
+func exportFile(dir Directory, name string, out IndexOutput) error {
+	in, err := dir.OpenInput(name, IO_CONTEXT_READONCE)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err = out.WriteString(name); err == nil {
+		if err = out.WriteVLong(in.Length()); err == nil {
+			err = out.CopyBytes(in, in.Length())
+		}
+	}
+	return err
+}
+
+/*
+Reads an archive written by ExportDirectory() from r, and writes its
+files into dir, overwriting existing ones of the same names. The
+archive is buffered in memory and its checksum verified before any
+file is written, so a truncated or corrupted archive leaves dir
+untouched.
+*/
+func ImportDirectory(r io.Reader, dir Directory) error {
+	in, err := bufferArchive(r)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if _, err = ChecksumEntireFile(in); err != nil {
+		return err
+	}
+	if _, err = codec.CheckHeader(in, ARCHIVE_CODEC, ARCHIVE_VERSION_START, ARCHIVE_VERSION_CURRENT); err != nil {
+		return err
+	}
+	numFiles, err := in.ReadVInt()
+	if err != nil {
+		return err
+	}
+	if numFiles < 0 {
+		return errors.New(fmt.Sprintf("invalid number of files: %v (resource: %v)", numFiles, in))
+	}
+	for i := int32(0); i < numFiles; i++ {
+		if err = importFile(in, dir); err != nil {
+			return err
+		}
+	}
+	if in.FilePointer() != in.Length()-codec.FOOTER_LENGTH {
+		return errors.New(fmt.Sprintf("did not read all files: read %v vs size %v (resource: %v)",
+			in.FilePointer(), in.Length()-codec.FOOTER_LENGTH, in))
+	}
+	return nil
+}
+
+func importFile(in IndexInput, dir Directory) (err error) {
+	name, err := in.ReadString()
+	if err != nil {
+		return err
+	}
+	length, err := in.ReadVLong()
+	if err != nil {
+		return err
+	}
+	if length < 0 || length > in.Length()-in.FilePointer() {
+		return errors.New(fmt.Sprintf("invalid length %v of file %v (resource: %v)", length, name, in))
+	}
+	out, err := dir.CreateOutput(name, IO_CONTEXT_DEFAULT)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = mergeError(err, out.Close())
+	}()
+	return out.CopyBytes(in, length)
+}
+
+/* Reads all of r into a RAMFile, and opens it. */
+func bufferArchive(r io.Reader) (IndexInput, error) {
+	file := NewRAMFileBuffer()
+	out := NewRAMOutputStream(file, false)
+	buf := make([]byte, DEFAULT_BUFFER_SIZE)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if err := out.WriteBytes(buf[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return newRAMInputStream("archive", file)
+}
+
+/* Loads an archive written by ExportDirectory() into a new RAMDirectory. */
+func LoadRAMDirectory(r io.Reader) (*RAMDirectory, error) {
+	dir := NewRAMDirectory()
+	if err := ImportDirectory(r, dir); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	return dir, nil
+}
+
+func mergeError(err, err2 error) error {
+	if err == nil {
+		return err2
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExportImportDirectory(t *testing.T) {
+	dir := NewRAMDirectory()
+	files := map[string]string{
+		"segments_1": "commit",
+		"_0.cfs":     string(make([]byte, 3*DEFAULT_BUFFER_SIZE+7)),
+		"_0.si":      "",
+	}
+	for name, content := range files {
+		out, err := dir.CreateOutput(name, IO_CONTEXT_DEFAULT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = out.WriteBytes([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		out.Close()
+	}
+
+	var buf bytes.Buffer
+	if err := ExportDirectory(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	loaded, err := LoadRAMDirectory(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := loaded.ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, len(names), len(files))
+	for name, content := range files {
+		in, err := loaded.OpenInput(name, IO_CONTEXT_DEFAULT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make([]byte, in.Length())
+		if err = in.ReadBytes(actual); err != nil {
+			t.Fatal(err)
+		}
+		in.Close()
+		if string(actual) != content {
+			t.Errorf("Expected %v bytes in %v, got %v", len(content), name, len(actual))
+		}
+	}
+
+	// a corrupted or truncated archive is rejected, leaving dir untouched
+	corrupted := append([]byte(nil), archive...)
+	corrupted[len(corrupted)/2] ^= 1
+	target := NewRAMDirectory()
+	if err = ImportDirectory(bytes.NewReader(corrupted), target); err == nil {
+		t.Error("Expected checksum error for corrupted archive")
+	}
+	if _, err = LoadRAMDirectory(bytes.NewReader(archive[:len(archive)-1])); err == nil {
+		t.Error("Expected error for truncated archive")
+	}
+	if names, _ = target.ListAll(); len(names) != 0 {
+		t.Errorf("Expected no file imported, got %v", names)
+	}
+}
+
+/*
+Feeds arbitrary bytes to ImportDirectory(), which must reject them
+with an error, but never panic.
+*/
+func FuzzImportDirectory(f *testing.F) {
+	dir := NewRAMDirectory()
+	out, err := dir.CreateOutput("_0.si", IO_CONTEXT_DEFAULT)
+	if err != nil {
+		f.Fatal(err)
+	}
+	out.WriteString("segment")
+	out.Close()
+	var buf bytes.Buffer
+	if err = ExportDirectory(dir, &buf); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(buf.Bytes())
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadRAMDirectory(bytes.NewReader(data))
+	})
+}
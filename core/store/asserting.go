@@ -0,0 +1,361 @@
+package store
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+A Directory wrapper which validates that it is used correctly, and
+panics on misuse instead of failing later in obscure ways:
+
+  - no operation on the directory after it is closed;
+  - no read, seek or clone of an IndexInput, nor any of its clones,
+    after the IndexInput is closed;
+  - no write to an IndexOutput after it is closed;
+  - all IndexInputs and IndexOutputs are closed when the directory
+    is closed.
+
+The checks have a cost, so the wrapper is meant to be enabled when
+debugging, by wrapping the directory before opening readers or
+writers on it.
+*/
+type AssertingDirectory struct {
+	Directory
+	sync.Mutex
+	closed    bool
+	openFiles map[interface{}]string // open inputs and outputs, by name
+}
+
+func NewAssertingDirectory(in Directory) *AssertingDirectory {
+	return &AssertingDirectory{Directory: in, openFiles: make(map[interface{}]string)}
+}
+
+func (d *AssertingDirectory) ensureOpen(op string) {
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		panic(fmt.Sprintf("AssertingDirectory: %v called after the directory was closed", op))
+	}
+}
+
+func (d *AssertingDirectory) opened(file interface{}, name string) {
+	d.Lock()
+	defer d.Unlock()
+	d.openFiles[file] = name
+}
+
+func (d *AssertingDirectory) released(file interface{}) {
+	d.Lock()
+	defer d.Unlock()
+	delete(d.openFiles, file)
+}
+
+/* Closes the directory; panics if files are still open. */
+func (d *AssertingDirectory) Close() error {
+	d.Lock()
+	if d.closed {
+		d.Unlock()
+		return nil
+	}
+	d.closed = true
+	var names []string
+	for _, name := range d.openFiles {
+		names = append(names, name)
+	}
+	d.Unlock()
+	if len(names) > 0 {
+		sort.Strings(names)
+		panic(fmt.Sprintf("AssertingDirectory: files still open on close: %v",
+			strings.Join(names, ", ")))
+	}
+	return d.Directory.Close()
+}
+
+func (d *AssertingDirectory) EnsureOpen() {
+	d.ensureOpen("EnsureOpen")
+	d.Directory.EnsureOpen()
+}
+
+func (d *AssertingDirectory) ListAll() ([]string, error) {
+	d.ensureOpen("ListAll")
+	return d.Directory.ListAll()
+}
+
+func (d *AssertingDirectory) FileExists(name string) bool {
+	d.ensureOpen("FileExists")
+	return d.Directory.FileExists(name)
+}
+
+func (d *AssertingDirectory) DeleteFile(name string) error {
+	d.ensureOpen("DeleteFile")
+	return d.Directory.DeleteFile(name)
+}
+
+func (d *AssertingDirectory) FileLength(name string) (int64, error) {
+	d.ensureOpen("FileLength")
+	return d.Directory.FileLength(name)
+}
+
+func (d *AssertingDirectory) Sync(names []string) error {
+	d.ensureOpen("Sync")
+	return d.Directory.Sync(names)
+}
+
+func (d *AssertingDirectory) RenameFile(source, dest string) error {
+	d.ensureOpen("RenameFile")
+	return d.Directory.RenameFile(source, dest)
+}
+
+func (d *AssertingDirectory) MakeLock(name string) Lock {
+	d.ensureOpen("MakeLock")
+	return d.Directory.MakeLock(name)
+}
+
+func (d *AssertingDirectory) ClearLock(name string) error {
+	d.ensureOpen("ClearLock")
+	return d.Directory.ClearLock(name)
+}
+
+func (d *AssertingDirectory) Copy(to Directory, src, dest string, ctx IOContext) error {
+	d.ensureOpen("Copy")
+	return d.Directory.Copy(to, src, dest, ctx)
+}
+
+func (d *AssertingDirectory) CreateOutput(name string, ctx IOContext) (IndexOutput, error) {
+	d.ensureOpen("CreateOutput")
+	out, err := d.Directory.CreateOutput(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	ans := &assertingIndexOutput{IndexOutput: out, dir: d, name: name}
+	d.opened(ans, name)
+	return ans, nil
+}
+
+func (d *AssertingDirectory) OpenInput(name string, ctx IOContext) (IndexInput, error) {
+	d.ensureOpen("OpenInput")
+	in, err := d.Directory.OpenInput(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	ans := &assertingIndexInput{IndexInput: in, dir: d, name: name}
+	ans.master = ans
+	d.opened(ans, name)
+	return ans, nil
+}
+
+func (d *AssertingDirectory) OpenChecksumInput(name string, ctx IOContext) (ChecksumIndexInput, error) {
+	in, err := d.OpenInput(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newBufferedChecksumIndexInput(in), nil
+}
+
+func (d *AssertingDirectory) String() string {
+	return fmt.Sprintf("AssertingDirectory(%v)", d.Directory)
+}
+
+/*
+An IndexInput which panics when used after it, or the input it was
+cloned from, is closed.
+*/
+type assertingIndexInput struct {
+	IndexInput
+	dir    *AssertingDirectory
+	name   string
+	master *assertingIndexInput // closing the master invalidates its clones and slices
+	closed bool
+}
+
+func (in *assertingIndexInput) ensureOpen(op string) {
+	in.dir.Lock()
+	defer in.dir.Unlock()
+	if in.master.closed {
+		panic(fmt.Sprintf("AssertingDirectory: %v called on IndexInput %v after it was closed", op, in.name))
+	}
+}
+
+func (in *assertingIndexInput) Close() error {
+	if in.master != in {
+		// clones and slices are not closed; the master closes the file
+		return nil
+	}
+	in.dir.Lock()
+	already := in.closed
+	in.closed = true
+	in.dir.Unlock()
+	if already {
+		return nil
+	}
+	in.dir.released(in)
+	return in.IndexInput.Close()
+}
+
+func (in *assertingIndexInput) wrap(clone IndexInput) IndexInput {
+	return &assertingIndexInput{IndexInput: clone, dir: in.dir, name: in.name, master: in.master}
+}
+
+func (in *assertingIndexInput) Clone() IndexInput {
+	in.ensureOpen("Clone")
+	return in.wrap(in.IndexInput.Clone())
+}
+
+func (in *assertingIndexInput) Slice(desc string, offset, length int64) (IndexInput, error) {
+	in.ensureOpen("Slice")
+	slice, err := in.IndexInput.Slice(desc, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return in.wrap(slice), nil
+}
+
+func (in *assertingIndexInput) Seek(pos int64) error {
+	in.ensureOpen("Seek")
+	return in.IndexInput.Seek(pos)
+}
+
+func (in *assertingIndexInput) ReadByte() (byte, error) {
+	in.ensureOpen("ReadByte")
+	return in.IndexInput.ReadByte()
+}
+
+func (in *assertingIndexInput) ReadBytes(buf []byte) error {
+	in.ensureOpen("ReadBytes")
+	return in.IndexInput.ReadBytes(buf)
+}
+
+func (in *assertingIndexInput) ReadBytesBuffered(buf []byte, useBuffer bool) error {
+	in.ensureOpen("ReadBytesBuffered")
+	return in.IndexInput.ReadBytesBuffered(buf, useBuffer)
+}
+
+func (in *assertingIndexInput) ReadShort() (int16, error) {
+	in.ensureOpen("ReadShort")
+	return in.IndexInput.ReadShort()
+}
+
+func (in *assertingIndexInput) ReadInt() (int32, error) {
+	in.ensureOpen("ReadInt")
+	return in.IndexInput.ReadInt()
+}
+
+func (in *assertingIndexInput) ReadVInt() (int32, error) {
+	in.ensureOpen("ReadVInt")
+	return in.IndexInput.ReadVInt()
+}
+
+func (in *assertingIndexInput) ReadLong() (int64, error) {
+	in.ensureOpen("ReadLong")
+	return in.IndexInput.ReadLong()
+}
+
+func (in *assertingIndexInput) ReadVLong() (int64, error) {
+	in.ensureOpen("ReadVLong")
+	return in.IndexInput.ReadVLong()
+}
+
+func (in *assertingIndexInput) ReadString() (string, error) {
+	in.ensureOpen("ReadString")
+	return in.IndexInput.ReadString()
+}
+
+func (in *assertingIndexInput) ReadStringStringMap() (map[string]string, error) {
+	in.ensureOpen("ReadStringStringMap")
+	return in.IndexInput.ReadStringStringMap()
+}
+
+func (in *assertingIndexInput) ReadStringSet() (map[string]bool, error) {
+	in.ensureOpen("ReadStringSet")
+	return in.IndexInput.ReadStringSet()
+}
+
+func (in *assertingIndexInput) String() string {
+	return fmt.Sprintf("AssertingIndexInput(%v)", in.IndexInput)
+}
+
+/* An IndexOutput which panics when written after it is closed. */
+type assertingIndexOutput struct {
+	IndexOutput
+	dir    *AssertingDirectory
+	name   string
+	closed bool
+}
+
+func (out *assertingIndexOutput) ensureOpen(op string) {
+	out.dir.Lock()
+	defer out.dir.Unlock()
+	if out.closed {
+		panic(fmt.Sprintf("AssertingDirectory: %v called on IndexOutput %v after it was closed", op, out.name))
+	}
+}
+
+func (out *assertingIndexOutput) Close() error {
+	out.dir.Lock()
+	already := out.closed
+	out.closed = true
+	out.dir.Unlock()
+	if already {
+		return nil
+	}
+	out.dir.released(out)
+	return out.IndexOutput.Close()
+}
+
+func (out *assertingIndexOutput) WriteByte(b byte) error {
+	out.ensureOpen("WriteByte")
+	return out.IndexOutput.WriteByte(b)
+}
+
+func (out *assertingIndexOutput) WriteBytes(buf []byte) error {
+	out.ensureOpen("WriteBytes")
+	return out.IndexOutput.WriteBytes(buf)
+}
+
+func (out *assertingIndexOutput) WriteInt(i int32) error {
+	out.ensureOpen("WriteInt")
+	return out.IndexOutput.WriteInt(i)
+}
+
+func (out *assertingIndexOutput) WriteVInt(i int32) error {
+	out.ensureOpen("WriteVInt")
+	return out.IndexOutput.WriteVInt(i)
+}
+
+func (out *assertingIndexOutput) WriteLong(i int64) error {
+	out.ensureOpen("WriteLong")
+	return out.IndexOutput.WriteLong(i)
+}
+
+func (out *assertingIndexOutput) WriteVLong(i int64) error {
+	out.ensureOpen("WriteVLong")
+	return out.IndexOutput.WriteVLong(i)
+}
+
+func (out *assertingIndexOutput) WriteString(s string) error {
+	out.ensureOpen("WriteString")
+	return out.IndexOutput.WriteString(s)
+}
+
+func (out *assertingIndexOutput) CopyBytes(input util.DataInput, numBytes int64) error {
+	out.ensureOpen("CopyBytes")
+	return out.IndexOutput.CopyBytes(input, numBytes)
+}
+
+func (out *assertingIndexOutput) WriteStringStringMap(m map[string]string) error {
+	out.ensureOpen("WriteStringStringMap")
+	return out.IndexOutput.WriteStringStringMap(m)
+}
+
+func (out *assertingIndexOutput) WriteStringSet(m map[string]bool) error {
+	out.ensureOpen("WriteStringSet")
+	return out.IndexOutput.WriteStringSet(m)
+}
+
+func (out *assertingIndexOutput) String() string {
+	return fmt.Sprintf("AssertingIndexOutput(%v)", out.IndexOutput)
+}
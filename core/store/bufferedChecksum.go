@@ -61,7 +61,7 @@ func (bc *BufferedChecksum) Write(p []byte) (int, error) {
 	if bc.upto+len(p) > len(bc.buffer) {
 		bc.flush()
 	}
-	copy(bc.buffer, p)
+	copy(bc.buffer[bc.upto:], p)
 	bc.upto += len(p)
 	return len(p), nil
 }
@@ -175,6 +175,10 @@ func (d *CompoundFileDirectory) Sync(names []string) error {
 	panic("not supported")
 }
 
+func (d *CompoundFileDirectory) RenameFile(source, dest string) error {
+	panic("not supported")
+}
+
 func (d *CompoundFileDirectory) MakeLock(name string) Lock {
 	panic("not supported by CFS")
 }
@@ -243,6 +243,12 @@ type Directory interface {
 	// again, so some impls might optimize for that. For other impls
 	// the operation can be a noop, for various reasons.
 	Sync(names []string) error
+	// Renames source to dest as an atomic operation, where dest does
+	// not yet exist in the directory. The rename is durable once it
+	// returns: the directory metadata is synced as well, so a crash
+	// never exposes a partially renamed file. Lucene uses this to
+	// publish a commit point (segments_N) only after it is complete.
+	RenameFile(source, dest string) error
 	OpenInput(name string, context IOContext) (in IndexInput, err error)
 	// Returns a stream reading an existing file, computing checksum as it reads
 	OpenChecksumInput(name string, ctx IOContext) (ChecksumIndexInput, error)
@@ -8,9 +8,11 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
-	// "time"
+	"sync/atomic"
+	"time"
 )
 
 type NoSuchDirectoryError struct {
@@ -38,6 +40,15 @@ type FSDirectory struct {
 	staleFiles     map[string]bool // synchronized, files written, but not yet sync'ed
 	staleFilesLock *sync.RWMutex
 	chunkSize      int
+
+	// Files we previously tried to delete, but hit an error on
+	// (probably because its still open on Windows, or a virus checker
+	// holds it open); we will retry and delete them later.
+	pendingDeletes     map[string]bool
+	pendingDeletesLock *sync.Mutex
+	// Used to enable pruning of pendingDeletes on every N ops.
+	opsSinceLastDelete int32 // atomic
+	removeFile         func(path string) error
 }
 
 // TODO support lock factory
@@ -48,6 +59,10 @@ func newFSDirectory(spi FSDirectorySPI, path string) (d *FSDirectory, err error)
 		staleFiles:     make(map[string]bool),
 		staleFilesLock: &sync.RWMutex{},
 		chunkSize:      math.MaxInt32,
+
+		pendingDeletes:     make(map[string]bool),
+		pendingDeletesLock: &sync.Mutex{},
+		removeFile:         os.Remove,
 	}
 	d.DirectoryImpl = NewDirectoryImpl(d)
 	d.BaseDirectory = NewBaseDirectory(d)
@@ -106,11 +121,29 @@ func FSDirectoryListAll(path string) (paths []string, err error) {
 
 func (d *FSDirectory) ListAll() (paths []string, err error) {
 	d.EnsureOpen()
-	return FSDirectoryListAll(d.path)
+	if paths, err = FSDirectoryListAll(d.path); err != nil {
+		return nil, err
+	}
+
+	d.pendingDeletesLock.Lock()
+	defer d.pendingDeletesLock.Unlock()
+	if len(d.pendingDeletes) == 0 {
+		return paths, nil
+	}
+	var ans []string
+	for _, name := range paths {
+		if !d.pendingDeletes[name] {
+			ans = append(ans, name)
+		}
+	}
+	return ans, nil
 }
 
 func (d *FSDirectory) FileExists(name string) bool {
 	d.EnsureOpen()
+	if d.isPendingDelete(name) {
+		return false
+	}
 	_, err := os.Stat(filepath.Join(d.path, name))
 	return err == nil || os.IsExist(err)
 }
@@ -118,6 +151,9 @@ func (d *FSDirectory) FileExists(name string) bool {
 // Returns the length in bytes of a file in the directory.
 func (d *FSDirectory) FileLength(name string) (n int64, err error) {
 	d.EnsureOpen()
+	if d.isPendingDelete(name) {
+		return 0, d.pendingDeleteError("stat", name)
+	}
 	fi, err := os.Stat(filepath.Join(d.path, name))
 	if err != nil {
 		return 0, err
@@ -125,22 +161,122 @@ func (d *FSDirectory) FileLength(name string) (n int64, err error) {
 	return fi.Size(), nil
 }
 
-// Removes an existing file in the directory.
+/*
+Removes an existing file in the directory. If the file can't be
+deleted yet (e.g. it is still open on Windows, or held by a virus
+checker), it is hidden from the directory and deletion is retried
+later, see DeletePendingFiles().
+*/
 func (d *FSDirectory) DeleteFile(name string) (err error) {
 	d.EnsureOpen()
-	if err = os.Remove(filepath.Join(d.path, name)); err == nil {
+	if d.isPendingDelete(name) {
+		return d.pendingDeleteError("remove", name)
+	}
+	if err = d.privateDeleteFile(name, false); err == nil {
 		d.staleFilesLock.Lock()
-		defer d.staleFilesLock.Unlock()
 		delete(d.staleFiles, name)
+		d.staleFilesLock.Unlock()
 	}
+	d.maybeDeletePendingFiles()
 	return
 }
 
+/*
+Sets the function used to remove files from the file system. It is a
+test hook, e.g. to simulate a virus checker refusing deletions.
+*/
+func (d *FSDirectory) SetRemoveFile(removeFile func(path string) error) {
+	d.removeFile = removeFile
+}
+
+/* Returns the names of files whose deletion is still pending. */
+func (d *FSDirectory) PendingDeletions() []string {
+	d.pendingDeletesLock.Lock()
+	defer d.pendingDeletesLock.Unlock()
+	var ans []string
+	for name, _ := range d.pendingDeletes {
+		ans = append(ans, name)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+/* Tries to delete any pending deleted files. */
+func (d *FSDirectory) DeletePendingFiles() {
+	d.pendingDeletesLock.Lock()
+	var names []string
+	for name, _ := range d.pendingDeletes {
+		names = append(names, name)
+	}
+	d.pendingDeletesLock.Unlock()
+
+	// Clone the set since we mutate it in privateDeleteFile:
+	for _, name := range names {
+		d.privateDeleteFile(name, true)
+	}
+}
+
+/* Retries pending deletes once every N operations, N being the number of pending files. */
+func (d *FSDirectory) maybeDeletePendingFiles() {
+	d.pendingDeletesLock.Lock()
+	n := len(d.pendingDeletes)
+	d.pendingDeletesLock.Unlock()
+	if n > 0 {
+		// This is a silly heuristic to try to avoid O(N^2), where N =
+		// number of files pending deletion, behaviour on Windows:
+		if count := atomic.AddInt32(&d.opsSinceLastDelete, 1); int(count) >= n {
+			atomic.AddInt32(&d.opsSinceLastDelete, -count)
+			d.DeletePendingFiles()
+		}
+	}
+}
+
+func (d *FSDirectory) privateDeleteFile(name string, isPendingDelete bool) error {
+	err := d.removeFile(filepath.Join(d.path, name))
+
+	d.pendingDeletesLock.Lock()
+	defer d.pendingDeletesLock.Unlock()
+	switch {
+	case err == nil:
+		delete(d.pendingDeletes, name)
+	case os.IsNotExist(err):
+		delete(d.pendingDeletes, name)
+		if !isPendingDelete {
+			return err
+		}
+	default:
+		// On windows, a file delete can fail because there's still an
+		// open file handle against it. We record this in pendingDeletes
+		// and try again later.
+		d.pendingDeletes[name] = true
+	}
+	return nil
+}
+
+func (d *FSDirectory) isPendingDelete(name string) bool {
+	d.pendingDeletesLock.Lock()
+	defer d.pendingDeletesLock.Unlock()
+	return d.pendingDeletes[name]
+}
+
+func (d *FSDirectory) pendingDeleteError(op, name string) error {
+	return &os.PathError{Op: op, Path: filepath.Join(d.path, name), Err: os.ErrNotExist}
+}
+
 /*
 Creates an IndexOutput for the file with the given name.
 */
 func (d *FSDirectory) CreateOutput(name string, ctx IOContext) (out IndexOutput, err error) {
 	d.EnsureOpen()
+	d.maybeDeletePendingFiles()
+	if d.isPendingDelete(name) {
+		// A pending delete on this name: try to delete it again now
+		d.privateDeleteFile(name, true)
+		if d.isPendingDelete(name) {
+			return nil, errors.New(fmt.Sprintf(
+				"file '%v' is pending delete and cannot be overwritten", name))
+		}
+	}
 	err = d.ensureCanWrite(name)
 	if err != nil {
 		return nil, err
@@ -157,7 +293,7 @@ func (d *FSDirectory) ensureCanWrite(name string) error {
 	filename := filepath.Join(d.path, name)
 	_, err = os.Stat(filename)
 	if err == nil || os.IsExist(err) {
-		err = os.Remove(filename)
+		err = d.removeFile(filename)
 		if err != nil {
 			return errors.New(fmt.Sprintf("Cannot overwrite %v/%v: %v", d.path, name, err))
 		}
@@ -178,6 +314,7 @@ func (d *FSDirectory) onIndexOutputClosed(name string) {
 
 func (d *FSDirectory) Sync(names []string) (err error) {
 	d.EnsureOpen()
+	d.maybeDeletePendingFiles()
 
 	toSync := make(map[string]bool)
 	d.staleFilesLock.RLock()
@@ -212,6 +349,27 @@ func (d *FSDirectory) Sync(names []string) (err error) {
 	return
 }
 
+func (d *FSDirectory) RenameFile(source, dest string) error {
+	d.EnsureOpen()
+	d.maybeDeletePendingFiles()
+	if d.isPendingDelete(source) {
+		return d.pendingDeleteError("rename", source)
+	}
+	if err := os.Rename(filepath.Join(d.path, source), filepath.Join(d.path, dest)); err != nil {
+		return err
+	}
+
+	d.staleFilesLock.Lock()
+	if d.staleFiles[source] {
+		delete(d.staleFiles, source)
+		d.staleFiles[dest] = true
+	}
+	d.staleFilesLock.Unlock()
+
+	// fsync the directory so the rename is durable
+	return util.Fsync(d.path, true)
+}
+
 func (d *FSDirectory) LockID() string {
 	d.EnsureOpen()
 	var digest int
@@ -225,34 +383,19 @@ func (d *FSDirectory) Close() error {
 	d.Lock() // synchronized
 	defer d.Unlock()
 	d.IsOpen = false
+	d.DeletePendingFiles()
 	return nil
 }
 
-func (d *FSDirectory) fsync(name string) error {
-	// var err, err2 error
-	// var success = false
-	// var retryCount = 0
-	// for !success && retryCount < 5 {
-	// 	retryCount++
-	// 	if err2, success = func() (error, bool) {
-	// 		file, err := os.Open(filepath.Join(d.path, name))
-	// 		if err != nil {
-	// 			return err, false
-	// 		}
-	// 		defer file.Close()
-	// 		return file.Sync(), true
-	// 	}(); err2 != nil {
-	// 		if err == nil {
-	// 			err = err2
-	// 		}
-	// 		time.Sleep(5 * time.Millisecond)
-	// 	}
-	// }
-	// return err
-
-	// Go's fsync doesn't work the same way as Java
-	// disabled since I got "fsync: Access is denied" all the time
-	return nil
+func (d *FSDirectory) fsync(name string) (err error) {
+	// retry a few times, e.g. a virus checker may hold the file open
+	for retryCount := 0; retryCount < 5; retryCount++ {
+		if err = util.Fsync(filepath.Join(d.path, name), false); err == nil {
+			return nil
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return err
 }
 
 func (d *FSDirectory) String() string {
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPendingDeletes(t *testing.T) {
+	path, err := ioutil.TempDir("", "fsdir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	d, err := NewSimpleFSDirectory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	denied := true
+	d.SetRemoveFile(func(name string) error {
+		if denied {
+			return errors.New("access denied")
+		}
+		return os.Remove(name)
+	})
+
+	out, err := d.CreateOutput("foo.bin", IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.WriteByte(1)
+	out.Close()
+
+	if err = d.DeleteFile("foo.bin"); err != nil {
+		t.Fatalf("Expected delete to be deferred, but %v", err)
+	}
+	if pending := d.PendingDeletions(); len(pending) != 1 || pending[0] != "foo.bin" {
+		t.Errorf("Expected foo.bin to be pending delete, but %v", pending)
+	}
+	if d.FileExists("foo.bin") {
+		t.Error("Expected pending delete to be hidden")
+	}
+	if files, _ := d.ListAll(); len(files) != 0 {
+		t.Errorf("Expected no files listed, but %v", files)
+	}
+	if _, err = d.FileLength("foo.bin"); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist error, but %v", err)
+	}
+	if err = d.DeleteFile("foo.bin"); !os.IsNotExist(err) {
+		t.Errorf("Expected not exist error, but %v", err)
+	}
+	if _, err = d.CreateOutput("foo.bin", IO_CONTEXT_DEFAULT); err == nil {
+		t.Error("Expected pending delete not to be overwritten")
+	}
+
+	denied = false
+	if err = d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pending := d.PendingDeletions(); len(pending) != 0 {
+		t.Errorf("Expected pending deletes to be retried, but %v", pending)
+	}
+	if _, err = os.Stat(path + "/foo.bin"); !os.IsNotExist(err) {
+		t.Errorf("Expected foo.bin to be deleted, but %v", err)
+	}
+}
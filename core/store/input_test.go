@@ -259,3 +259,36 @@ func (in *MyBufferedIndexInput) Clone() IndexInput {
 		in.length,
 	}
 }
+
+/* Corrupted counts must fail before allocating past the end of the file. */
+func TestReadCorruptedCounts(t *testing.T) {
+	dir := NewRAMDirectory()
+	defer dir.Close()
+	out, err := dir.CreateOutput("counts", IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.WriteInt(1 << 30)
+	out.WriteInt(-1)
+	out.WriteInt(1)
+	out.WriteString("a")
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in, err := dir.OpenInput("counts", IO_CONTEXT_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if _, err = in.ReadStringStringMap(); err == nil {
+		t.Error("Expected a count past EOF to fail")
+	}
+	in.Seek(4)
+	if _, err = in.ReadStringSet(); err == nil {
+		t.Error("Expected a negative count to fail")
+	}
+	in.Seek(8)
+	if s, err := in.ReadStringSet(); err != nil || !s["a"] {
+		t.Errorf("Expected set [a], but %v (%v)", s, err)
+	}
+}
@@ -203,6 +203,19 @@ func (nrt *NRTCachingDirectory) Sync(fileNames []string) (err error) {
 	return nrt.Directory.Sync(fileNames)
 }
 
+func (nrt *NRTCachingDirectory) RenameFile(source, dest string) error {
+	if NRT_VERBOSE {
+		log.Printf("nrtdir.renameFile source=%v dest=%v", source, dest)
+	}
+	// Rename is only used for commit files, so they must be in the
+	// delegate already
+	if err := nrt.unCache(source); err != nil {
+		return err
+	}
+	nrt.cache.DeleteFile(dest) // ignore IO error
+	return nrt.Directory.RenameFile(source, dest)
+}
+
 func (nrt *NRTCachingDirectory) OpenInput(name string, context IOContext) (in IndexInput, err error) {
 	nrt.Lock() // synchronized
 	defer nrt.Unlock()
@@ -136,6 +136,23 @@ func (rd *RAMDirectory) Sync(names []string) error {
 	return nil
 }
 
+func (rd *RAMDirectory) RenameFile(source, dest string) error {
+	rd.EnsureOpen()
+	rd.fileMapLock.Lock()
+	defer rd.fileMapLock.Unlock()
+	file, ok := rd.fileMap[source]
+	if !ok {
+		return errors.New(source)
+	}
+	if existing, ok := rd.fileMap[dest]; ok {
+		atomic.AddInt64(&rd.sizeInBytes, -existing.sizeInBytes)
+		existing.directory = nil
+	}
+	rd.fileMap[dest] = file
+	delete(rd.fileMap, source)
+	return nil
+}
+
 // Returns a stream reading an existing file.
 func (rd *RAMDirectory) OpenInput(name string, context IOContext) (in IndexInput, err error) {
 	rd.EnsureOpen()
@@ -346,7 +363,8 @@ type RAMInputStream struct {
 	*IndexInputImpl
 
 	file   *RAMFile
-	length int64
+	length int64 // end of the stream in the file
+	offset int64 // start of the stream in the file, if sliced
 
 	currentBuffer      []byte
 	currentBufferIndex int
@@ -375,7 +393,7 @@ func (in *RAMInputStream) Close() error {
 }
 
 func (in *RAMInputStream) Length() int64 {
-	return in.length
+	return in.length - in.offset
 }
 
 func (in *RAMInputStream) ReadByte() (byte, error) {
@@ -439,10 +457,11 @@ func (in *RAMInputStream) FilePointer() int64 {
 	if in.currentBufferIndex < 0 {
 		return 0
 	}
-	return in.bufferStart + int64(in.bufferPosition)
+	return in.bufferStart + int64(in.bufferPosition) - in.offset
 }
 
 func (in *RAMInputStream) Seek(pos int64) error {
+	pos += in.offset
 	if in.currentBuffer == nil || pos < in.bufferStart || pos >= in.bufferStart+BUFFER_SIZE {
 		in.currentBufferIndex = int(pos / BUFFER_SIZE)
 		err := in.switchCurrentBuffer(false)
@@ -455,15 +474,27 @@ func (in *RAMInputStream) Seek(pos int64) error {
 }
 
 func (in *RAMInputStream) Slice(desc string, offset, length int64) (IndexInput, error) {
-	panic("not implemented yet")
+	if offset < 0 || length < 0 || offset+length > in.Length() {
+		return nil, errors.New(fmt.Sprintf("slice() %v out of bounds: %v", desc, in))
+	}
+	ans := &RAMInputStream{
+		file:               in.file,
+		length:             in.offset + offset + length,
+		offset:             in.offset + offset,
+		currentBufferIndex: -1,
+	}
+	ans.IndexInputImpl = NewIndexInputImpl(fmt.Sprintf("%v [slice=%v]", in.IndexInputImpl.String(), desc), ans)
+	return ans, ans.Seek(0)
 }
 
 func (in *RAMInputStream) Clone() IndexInput {
-	panic("not implemented yet")
+	ans := *in
+	ans.IndexInputImpl = NewIndexInputImpl(in.IndexInputImpl.String(), &ans)
+	return &ans
 }
 
 func (in *RAMInputStream) String() string {
-	return fmt.Sprintf("%v;%v@[0-%v]", in.IndexInputImpl.String(), in.FilePointer(), in.length)
+	return fmt.Sprintf("%v;%v@[0-%v]", in.IndexInputImpl.String(), in.FilePointer(), in.Length())
 }
 
 // store/RamOutputStream.java
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"hash/crc32"
 	"testing"
 )
 
@@ -28,3 +29,84 @@ func TestIO(t *testing.T) {
 	assert2(err == nil, "%v", err)
 	assertEquals(t, s, testdata)
 }
+
+func TestChecksum(t *testing.T) {
+	filename := "b.bin"
+	var testdata []byte
+	for i := 0; i < 1000; i++ {
+		testdata = append(testdata, byte(i*31))
+	}
+
+	dir := NewRAMDirectory()
+	out, err := dir.CreateOutput(filename, IO_CONTEXT_DEFAULT)
+	assert2(err == nil, "%v", err)
+	// small writes accumulate in the checksum buffer before it flushes
+	for i := 0; i < len(testdata); i += 3 {
+		end := i + 3
+		if end > len(testdata) {
+			end = len(testdata)
+		}
+		err = out.WriteBytes(testdata[i:end])
+		assert2(err == nil, "%v", err)
+	}
+	assertEquals(t, out.Checksum(), int64(crc32.ChecksumIEEE(testdata)))
+	assert2(out.Close() == nil, "close failed")
+
+	in, err := dir.OpenChecksumInput(filename, IO_CONTEXT_DEFAULT)
+	assert2(err == nil, "%v", err)
+	defer in.Close()
+	buf := make([]byte, len(testdata))
+	err = in.ReadBytes(buf)
+	assert2(err == nil, "%v", err)
+	assertEquals(t, in.Checksum(), int64(crc32.ChecksumIEEE(testdata)))
+}
+
+func TestSliceAndClone(t *testing.T) {
+	filename := "c.bin"
+	testdata := make([]byte, 3*BUFFER_SIZE/2)
+	for i := range testdata {
+		testdata[i] = byte(i * 7)
+	}
+
+	dir := NewRAMDirectory()
+	func() {
+		out, err := dir.CreateOutput(filename, IO_CONTEXT_DEFAULT)
+		assert2(err == nil, "%v", err)
+		defer out.Close()
+		err = out.WriteBytes(testdata)
+		assert2(err == nil, "%v", err)
+	}()
+
+	in, err := dir.OpenInput(filename, IO_CONTEXT_DEFAULT)
+	assert2(err == nil, "%v", err)
+	defer in.Close()
+
+	// the slice crosses a buffer boundary
+	offset, length := int64(BUFFER_SIZE-10), int64(100)
+	slice, err := in.Slice("test", offset, length)
+	assert2(err == nil, "%v", err)
+	assertEquals(t, slice.Length(), length)
+	assertEquals(t, slice.FilePointer(), int64(0))
+	buf := make([]byte, length)
+	err = slice.ReadBytes(buf)
+	assert2(err == nil, "%v", err)
+	assertEquals(t, string(buf), string(testdata[offset:offset+length]))
+	assertEquals(t, slice.FilePointer(), length)
+
+	err = slice.Seek(50)
+	assert2(err == nil, "%v", err)
+	b, err := slice.ReadByte()
+	assert2(err == nil, "%v", err)
+	assertEquals(t, b, testdata[offset+50])
+
+	_, err = slice.Slice("too long", 1, length)
+	assertEquals(t, err != nil, true)
+
+	// a clone reads on from the same position, independently
+	clone := slice.Clone()
+	assertEquals(t, clone.FilePointer(), int64(51))
+	b, err = clone.ReadByte()
+	assert2(err == nil, "%v", err)
+	assertEquals(t, b, testdata[offset+51])
+	assertEquals(t, slice.FilePointer(), int64(51))
+}
@@ -123,6 +123,9 @@ func NewSimpleFSDirectory(path string) (d *SimpleFSDirectory, err error) {
 
 func (d *SimpleFSDirectory) OpenInput(name string, context IOContext) (IndexInput, error) {
 	d.EnsureOpen()
+	if d.isPendingDelete(name) {
+		return nil, d.pendingDeleteError("open", name)
+	}
 	fpath := filepath.Join(d.path, name)
 	// fmt.Printf("Opening %v...\n", fpath)
 	return newSimpleFSIndexInput(fmt.Sprintf("SimpleFSIndexInput(path='%v')", fpath), fpath, context)
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/codec"
 )
 
@@ -18,6 +20,11 @@ func ChecksumEntireFile(input IndexInput) (hash int64, err error) {
 	}
 	in := newBufferedChecksumIndexInput(clone)
 	assert(in.FilePointer() == 0)
+	if in.Length() < codec.FOOTER_LENGTH {
+		return 0, errors.New(fmt.Sprintf(
+			"misplaced codec footer (file truncated?): length=%v but footerLength=%v (resource=%v)",
+			in.Length(), codec.FOOTER_LENGTH, input))
+	}
 	if err = in.Seek(in.Length() - codec.FOOTER_LENGTH); err != nil {
 		return 0, err
 	}
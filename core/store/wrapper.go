@@ -42,6 +42,17 @@ func (w *TrackingDirectoryWrapper) CreateOutput(name string, ctx IOContext) (Ind
 	return w.Directory.CreateOutput(name, ctx)
 }
 
+func (w *TrackingDirectoryWrapper) RenameFile(source, dest string) error {
+	if err := w.Directory.RenameFile(source, dest); err != nil {
+		return err
+	}
+	w.Lock()
+	defer w.Unlock()
+	w.createdFilenames[dest] = true
+	delete(w.createdFilenames, source)
+	return nil
+}
+
 func (w *TrackingDirectoryWrapper) String() string {
 	return fmt.Sprintf("TrackingDirectoryWrapper(%v)", w.Directory)
 }
@@ -1,7 +1,90 @@
 package util
 
+import (
+	"fmt"
+)
+
 /* An object whose RAM usage can be computed. */
 type Accountable interface {
 	// Return the memory usage of this object in bytes. Negative values are illegal.
 	RamBytesUsed() int64
 }
+
+// util/Accountables.java
+
+/*
+Returns the nested resources of the Accountable, e.g. the caches and
+data structures whose memory usage adds up to its RamBytesUsed(), so
+that memory usage can be reported as a tree. An Accountable has child
+resources if it has a ChildResources() []Accountable method.
+*/
+func ChildResources(a Accountable) []Accountable {
+	if p, ok := a.(interface {
+		ChildResources() []Accountable
+	}); ok {
+		return p.ChildResources()
+	}
+	return nil
+}
+
+type namedAccountable struct {
+	description string
+	bytes       int64
+	children    []Accountable
+}
+
+/*
+Returns an Accountable with the given description, memory usage and
+children, e.g. to name the resources of a data structure in its
+ChildResources().
+*/
+func NamedAccountable(description string, bytes int64, children ...Accountable) Accountable {
+	return &namedAccountable{description, bytes, children}
+}
+
+/*
+Returns an Accountable with the given description, and the memory
+usage and children of a, which must not be nil.
+*/
+func NamedAccountableOf(description string, a Accountable) Accountable {
+	return &namedAccountable{description, a.RamBytesUsed(), ChildResources(a)}
+}
+
+func (a *namedAccountable) RamBytesUsed() int64 {
+	return a.bytes
+}
+
+func (a *namedAccountable) ChildResources() []Accountable {
+	return a.children
+}
+
+func (a *namedAccountable) String() string {
+	return a.description
+}
+
+/*
+Returns a string description of the memory usage of the Accountable
+and of its child resources, recursively, one per line, e.g.:
+
+	_0(4.10):c3: 2.3 KB
+	    |-- postings: 1.5 KB
+	    |-- stored fields: 812 bytes
+*/
+func AccountableToString(a Accountable) string {
+	var buf []byte
+	return string(appendAccountable(buf, a, 0))
+}
+
+func appendAccountable(dest []byte, a Accountable, depth int) []byte {
+	for i := 0; i < depth; i++ {
+		dest = append(dest, "    "...)
+	}
+	if depth > 0 {
+		dest = append(dest, "|-- "...)
+	}
+	dest = append(dest, fmt.Sprintf("%v: %v\n", a, HumanReadableUnits(a.RamBytesUsed()))...)
+	for _, child := range ChildResources(a) {
+		dest = appendAccountable(dest, child, depth+1)
+	}
+	return dest
+}
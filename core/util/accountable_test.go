@@ -0,0 +1,25 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestAccountableToString(t *testing.T) {
+	a := NamedAccountable("segment", 3072,
+		NamedAccountable("postings", 2048, NamedAccountable("field 'body'", 1000)),
+		NamedAccountable("norms", 1024))
+	want := `segment: 3.0 KB
+    |-- postings: 2.0 KB
+        |-- field 'body': 1000 bytes
+    |-- norms: 1.0 KB
+`
+	if got := AccountableToString(a); got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+	if ChildResources(NewFixedBitSetOf(64)) != nil {
+		t.Error("expect no child resources for an Accountable without ChildResources()")
+	}
+	if got := HumanReadableUnits(3 * ONE_GB / 2); got != "1.5 GB" {
+		t.Errorf("expect 1.5 GB, got %v", got)
+	}
+}
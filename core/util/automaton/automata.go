@@ -66,6 +66,11 @@ func makeString(s string) *Automaton {
 	return a
 }
 
+// Returns a new (deterministic) automaton that accepts the strings starting with the given prefix
+func MakePrefix(prefix string) *Automaton {
+	return concatenate(makeString(prefix), repeat(makeAnyChar()))
+}
+
 // L271
 /*
 Returns a new (deterministic and minimal) automaton that accepts the
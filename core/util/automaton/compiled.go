@@ -0,0 +1,108 @@
+package automaton
+
+import (
+	"fmt"
+)
+
+// util/automaton/CompiledAutomaton.java
+
+type AutomatonType int
+
+const (
+	// Automaton that accepts no strings.
+	AUTOMATON_TYPE_NONE = AutomatonType(iota)
+	// Automaton that accepts all possible strings.
+	AUTOMATON_TYPE_ALL
+	// Automaton that accepts only a single fixed string.
+	AUTOMATON_TYPE_SINGLE
+	// Catch-all for any other automata.
+	AUTOMATON_TYPE_NORMAL
+)
+
+func (t AutomatonType) String() string {
+	switch t {
+	case AUTOMATON_TYPE_NONE:
+		return "NONE"
+	case AUTOMATON_TYPE_ALL:
+		return "ALL"
+	case AUTOMATON_TYPE_SINGLE:
+		return "SINGLE"
+	case AUTOMATON_TYPE_NORMAL:
+		return "NORMAL"
+	}
+	panic("unknown automaton type")
+}
+
+/*
+Immutable class holding compiled details for a given Automaton. The
+Automaton is deterministic, has no dead states, and is over the UTF-8
+bytes of terms, so that it can be intersected with the term
+dictionary through Terms.Intersect().
+
+Simple automata are recognized by Type, so that consumers may
+special case them, e.g. a TermQuery for AUTOMATON_TYPE_SINGLE. The
+UTF-8 automaton is built whatever the type, except for
+AUTOMATON_TYPE_NONE.
+*/
+type CompiledAutomaton struct {
+	// If simplify is true, this will be the "simplified" type;
+	// else, this is NORMAL
+	Type AutomatonType
+	// For AUTOMATON_TYPE_SINGLE this is the singleton term.
+	Term []byte
+	// Matcher for quickly determining if a []byte is accepted; nil
+	// for AUTOMATON_TYPE_NONE.
+	RunAutomaton *ByteRunAutomaton
+	// Two dimensional array of transitions, indexed by state number
+	// for traversal. The state numbering is consistent with
+	// RunAutomaton.
+	Automaton *Automaton
+	// Indicates if the automaton accepts a finite set of strings.
+	Finite bool
+}
+
+/* Compiles the automaton, recognizing simple types. */
+func NewCompiledAutomaton(a *Automaton) *CompiledAutomaton {
+	return NewCompiledAutomatonWith(a, true)
+}
+
+/*
+Compiles the automaton; if simplify is false, Type is always
+AUTOMATON_TYPE_NORMAL, unless the automaton accepts no strings.
+*/
+func NewCompiledAutomatonWith(a *Automaton, simplify bool) *CompiledAutomaton {
+	if isEmpty(a) {
+		return &CompiledAutomaton{Type: AUTOMATON_TYPE_NONE, Finite: true}
+	}
+	a = determinize(a)
+	ans := &CompiledAutomaton{Type: AUTOMATON_TYPE_NORMAL}
+	if simplify {
+		if isTotal(a) {
+			ans.Type = AUTOMATON_TYPE_ALL
+		} else if s := singleton(a); s != nil {
+			ans.Type = AUTOMATON_TYPE_SINGLE
+			runes := make([]rune, len(s))
+			for i, c := range s {
+				runes[i] = rune(c)
+			}
+			ans.Term = []byte(string(runes))
+		}
+	}
+	utf8 := removeDeadStates(determinize(new(utf32ToUtf8).convert(a)))
+	ans.Finite = isFinite(utf8)
+	ans.RunAutomaton = newByteRunAutomaton(utf8, true)
+	ans.Automaton = ans.RunAutomaton.automaton
+	return ans
+}
+
+/* Returns true if the given UTF-8 term is accepted. */
+func (c *CompiledAutomaton) Run(term []byte) bool {
+	return c.RunAutomaton != nil && c.RunAutomaton.Run(term)
+}
+
+func (c *CompiledAutomaton) String() string {
+	if c.Type == AUTOMATON_TYPE_SINGLE {
+		return fmt.Sprintf("CompiledAutomaton(%v %q)", c.Type, c.Term)
+	}
+	return fmt.Sprintf("CompiledAutomaton(%v finite=%v)", c.Type, c.Finite)
+}
@@ -932,3 +932,80 @@ func totalize(a *Automaton) *Automaton {
 	ans.finishState()
 	return ans
 }
+
+/*
+Returns true if the given automaton accepts all strings. The
+automaton must be minimized.
+*/
+func isTotal(a *Automaton) bool {
+	if a.numStates() > 0 && a.IsAccept(0) && a.numTransitions(0) == 1 {
+		t := newTransition()
+		a.transition(0, 0, t)
+		return t.dest == 0 && t.min == MIN_CODE_POINT && t.max == unicode.MaxRune
+	}
+	return false
+}
+
+/*
+Returns true if the language of this automaton is finite. The
+automaton must not have any dead states.
+*/
+func isFinite(a *Automaton) bool {
+	if a.numStates() == 0 {
+		return true
+	}
+	return isFiniteFrom(newTransition(), a, 0, util.NewOpenBitSet(), util.NewOpenBitSet())
+}
+
+/*
+Checks whether there is a loop containing state. (This is sufficient
+since there are never transitions to dead states.)
+*/
+func isFiniteFrom(scratch *Transition, a *Automaton, state int, path, visited *util.OpenBitSet) bool {
+	path.Set(int64(state))
+	numTransitions := a.numTransitions(state)
+	for t := 0; t < numTransitions; t++ {
+		a.transition(state, t, scratch)
+		dest := int64(scratch.dest)
+		if path.Get(dest) || (!visited.Get(dest) && !isFiniteFrom(scratch, a, scratch.dest, path, visited)) {
+			return false
+		}
+	}
+	path.Clear(int64(state))
+	visited.Set(int64(state))
+	return true
+}
+
+/*
+If this automaton accepts a single input, returns it as a sequence of
+code points; returns nil otherwise. The automaton must be
+deterministic.
+*/
+func singleton(a *Automaton) []int {
+	assert2(a.deterministic, "input automaton must be deterministic")
+	var ans []int
+	visited := make(map[int]bool)
+	s := 0
+	t := newTransition()
+	for a.numStates() > 0 {
+		visited[s] = true
+		if !a.IsAccept(s) {
+			if a.numTransitions(s) == 1 {
+				a.transition(s, 0, t)
+				if t.min == t.max && !visited[t.dest] {
+					ans = append(ans, t.min)
+					s = t.dest
+					continue
+				}
+			}
+		} else if a.numTransitions(s) == 0 {
+			if ans == nil {
+				ans = []int{}
+			}
+			return ans
+		}
+		break
+	}
+	// Automaton accepts more than one string:
+	return nil
+}
@@ -323,7 +323,7 @@ func makeString2RE(exp1, exp2 *RegExp) *RegExp {
 		b.WriteRune(rune(exp1.c))
 	}
 	if exp2.kind == REGEXP_STRING {
-		b.WriteString(exp1.s)
+		b.WriteString(exp2.s)
 	} else {
 		assert(REGEXP_CHAR == exp2.kind)
 		b.WriteRune(rune(exp2.c))
@@ -28,3 +28,15 @@ type MutableBits interface {
 	// Sets the bit specified by index to false.
 	Clear(index int)
 }
+
+/* Bits impl of the specified length with all bits set. */
+type MatchAllBits int
+
+func (b MatchAllBits) At(index int) bool { return true }
+func (b MatchAllBits) Length() int       { return int(b) }
+
+/* Bits impl of the specified length with no bits set. */
+type MatchNoBits int
+
+func (b MatchNoBits) At(index int) bool { return false }
+func (b MatchNoBits) Length() int       { return int(b) }
@@ -0,0 +1,174 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+/*
+Tracks the resources of an application, e.g. its directories,
+writers, readers, searchers and their managers, and closes them in
+dependency order on shutdown: each resource before the ones it
+depends on, e.g. a SearcherManager before the IndexWriter its readers
+come from, and the writer before its Directory. Closing them in the
+wrong order risks deadlocks, or losing the last commit of a writer
+whose directory is already closed.
+
+A resource can only depend on resources registered before it, so
+that the dependencies never form a cycle, and the reverse order of
+registration is a valid closing order.
+
+It is safe for concurrent use.
+*/
+type CloseableRegistry struct {
+	sync.Mutex
+	entries []*closeableEntry
+	byName  map[string]*closeableEntry
+	closed  bool
+}
+
+type closeableEntry struct {
+	name      string
+	closer    io.Closer
+	dependsOn []*closeableEntry
+	// set when the resource could not be closed, so that the ones it
+	// depends on are left open
+	failed bool
+}
+
+func NewCloseableRegistry() *CloseableRegistry {
+	return &CloseableRegistry{byName: make(map[string]*closeableEntry)}
+}
+
+/*
+Registers a resource by a unique name, depending on the resources
+registered under the names dependsOn, which must already be.
+*/
+func (r *CloseableRegistry) Register(name string, closer io.Closer, dependsOn ...string) error {
+	assert2(closer != nil, "closer of '%v' must not be nil", name)
+	r.Lock()
+	defer r.Unlock()
+	if r.closed {
+		return errors.New(fmt.Sprintf("cannot register '%v': registry is closed", name))
+	}
+	if _, ok := r.byName[name]; ok {
+		return errors.New(fmt.Sprintf("'%v' is already registered", name))
+	}
+	entry := &closeableEntry{name: name, closer: closer}
+	for _, dep := range dependsOn {
+		e, ok := r.byName[dep]
+		if !ok {
+			return errors.New(fmt.Sprintf("'%v' depends on unknown '%v'", name, dep))
+		}
+		entry.dependsOn = append(entry.dependsOn, e)
+	}
+	r.entries = append(r.entries, entry)
+	r.byName[name] = entry
+	return nil
+}
+
+/*
+Removes a resource, e.g. closed by the application itself, and
+returns false if unknown. It cannot be removed while other resources
+depend on it.
+*/
+func (r *CloseableRegistry) Unregister(name string) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+	entry, ok := r.byName[name]
+	if !ok {
+		return false, nil
+	}
+	for _, e := range r.entries {
+		for _, dep := range e.dependsOn {
+			if dep == entry {
+				return false, errors.New(fmt.Sprintf("cannot unregister '%v': '%v' depends on it", name, e.name))
+			}
+		}
+	}
+	for i, e := range r.entries {
+		if e == entry {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			break
+		}
+	}
+	delete(r.byName, name)
+	return true, nil
+}
+
+/* Returns the names of the registered resources, in closing order. */
+func (r *CloseableRegistry) ClosingOrder() []string {
+	r.Lock()
+	defer r.Unlock()
+	ans := make([]string, len(r.entries))
+	for i, e := range r.entries {
+		ans[len(ans)-1-i] = e.name
+	}
+	return ans
+}
+
+/* Closes all resources, waiting for each as long as it takes. */
+func (r *CloseableRegistry) Close() error {
+	return r.CloseWithTimeout(0)
+}
+
+/*
+Closes all resources in dependency order, and returns the first
+error, with the others suppressed. A resource which fails to close,
+or does not within timeout, which applies to each resource on its
+own, is abandoned, and so are the resources it
+depends on, left open rather than closed under it. A timeout of 0
+waits as long as it takes.
+
+Later calls do nothing, and resources can no longer be registered.
+*/
+func (r *CloseableRegistry) CloseWithTimeout(timeout time.Duration) error {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return nil
+	}
+	r.closed = true
+	entries := r.entries
+	r.Unlock()
+
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.failed {
+			errs = append(errs, errors.New(fmt.Sprintf("'%v' left open: a resource depending on it failed to close", e.name)))
+		} else if err := closeWithin(e.closer, timeout); err != nil {
+			e.failed = true
+			errs = append(errs, errors.New(fmt.Sprintf("failed to close '%v': %v", e.name, err)))
+		}
+		if e.failed {
+			for _, dep := range e.dependsOn {
+				dep.failed = true
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &CompoundError{errs}
+	}
+	return nil
+}
+
+/* Closes c, returning an error if it does not return within timeout. */
+func closeWithin(c io.Closer, timeout time.Duration) error {
+	if timeout <= 0 {
+		return c.Close()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.New(fmt.Sprintf("timed out after %v", timeout))
+	}
+}
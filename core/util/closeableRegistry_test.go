@@ -0,0 +1,78 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingCloser struct {
+	name   string
+	closed *[]string
+	err    error
+	// if set, Close() hangs until it is closed
+	hang chan struct{}
+}
+
+func (c *recordingCloser) Close() error {
+	if c.hang != nil {
+		<-c.hang
+		return nil
+	}
+	*c.closed = append(*c.closed, c.name)
+	return c.err
+}
+
+func TestCloseableRegistry(t *testing.T) {
+	var closed []string
+	r := NewCloseableRegistry()
+	for _, e := range []struct {
+		name      string
+		dependsOn []string
+	}{
+		{"dir", nil},
+		{"writer", []string{"dir"}},
+		{"reader", []string{"writer"}},
+		{"other", nil},
+	} {
+		if err := r.Register(e.name, &recordingCloser{name: e.name, closed: &closed}, e.dependsOn...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Register("searcher", &recordingCloser{}, "unknown"); err == nil {
+		t.Error("Expected error for unknown dependency")
+	}
+	if _, err := r.Unregister("writer"); err == nil {
+		t.Error("Expected error unregistering a dependency")
+	}
+	if ok, err := r.Unregister("other"); !ok || err != nil {
+		t.Errorf("Expected other to be unregistered, got %v (%v)", ok, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 3 || closed[0] != "reader" || closed[1] != "writer" || closed[2] != "dir" {
+		t.Errorf("Expected [reader writer dir], got %v", closed)
+	}
+	if err := r.Register("late", &recordingCloser{}); err == nil {
+		t.Error("Expected error registering after close")
+	}
+
+	// a failing or hanging resource leaves its dependencies open
+	closed = nil
+	r = NewCloseableRegistry()
+	r.Register("dir", &recordingCloser{name: "dir", closed: &closed})
+	hang := make(chan struct{})
+	defer close(hang)
+	r.Register("writer", &recordingCloser{name: "writer", closed: &closed, hang: hang}, "dir")
+	r.Register("dir2", &recordingCloser{name: "dir2", closed: &closed})
+	r.Register("writer2", &recordingCloser{name: "writer2", closed: &closed, err: errors.New("boom")}, "dir2")
+	if err := r.CloseWithTimeout(50 * time.Millisecond); err == nil {
+		t.Error("Expected errors closing")
+	} else if ce := err.(*CompoundError); len(ce.errs) != 4 {
+		t.Errorf("Expected 4 errors, got %v", ce.errs)
+	}
+	if len(closed) != 1 || closed[0] != "writer2" {
+		t.Errorf("Expected only writer2 closed, got %v", closed)
+	}
+}
@@ -0,0 +1,169 @@
+package util
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"sort"
+)
+
+/*
+The methods of search.DocIdSet, implemented by the sets of this
+package, e.g. FixedBitSet, and returned by DocIdSetBuilder.
+*/
+type DocIdSet interface {
+	Accountable
+	Iterator() (DocIdSetIterator, error)
+	Bits() Bits
+	IsCacheable() bool
+}
+
+// util/DocIdSetBuilder.java
+
+/*
+Builds the DocIdSet of the docs matching a query or filter in a
+segment, whose size is not known in advance. Docs are first buffered
+in a sorted array, which takes 4 bytes per doc, until their density
+goes past 1/128 of maxDoc, when they are moved to a FixedBitSet, which
+takes maxDoc/8 bytes. Small results thus never allocate a bit set of
+the whole segment.
+*/
+type DocIdSetBuilder struct {
+	maxDoc    int
+	threshold int
+	buffer    []int32
+	bitSet    *FixedBitSet
+}
+
+/* Creates a builder of the docs of a segment of maxDoc docs. */
+func NewDocIdSetBuilder(maxDoc int) *DocIdSetBuilder {
+	return &DocIdSetBuilder{maxDoc: maxDoc, threshold: maxDoc >> 7}
+}
+
+/* Adds a doc, in any order. Adding a doc twice is harmless. */
+func (b *DocIdSetBuilder) Add(doc int) {
+	if b.bitSet != nil {
+		b.bitSet.Set(doc)
+		return
+	}
+	if len(b.buffer) >= b.threshold {
+		b.upgradeToBitSet()
+		b.bitSet.Set(doc)
+		return
+	}
+	b.buffer = append(b.buffer, int32(doc))
+}
+
+/* Adds all docs of the iterator, which must be unpositioned. */
+func (b *DocIdSetBuilder) AddIterator(it DocIdSetIterator) error {
+	for {
+		doc, err := it.NextDoc()
+		if err != nil {
+			return err
+		}
+		if doc == NO_MORE_DOCS {
+			return nil
+		}
+		b.Add(doc)
+	}
+}
+
+func (b *DocIdSetBuilder) upgradeToBitSet() {
+	b.bitSet = NewFixedBitSetOf(b.maxDoc)
+	for _, doc := range b.buffer {
+		b.bitSet.Set(int(doc))
+	}
+	b.buffer = nil
+}
+
+/*
+Returns the docs added, as an IntArrayDocIdSet if they are sparse, or
+as a FixedBitSet. The builder must not be used afterwards.
+*/
+func (b *DocIdSetBuilder) Build() DocIdSet {
+	if b.bitSet != nil {
+		ans := b.bitSet
+		b.bitSet = nil
+		return ans
+	}
+	docs := b.buffer
+	sort.Sort(int32Slice(docs))
+	// dedup in place
+	n := 0
+	for i, doc := range docs {
+		if i == 0 || doc != docs[n-1] {
+			docs[n] = doc
+			n++
+		}
+	}
+	b.buffer = nil
+	return &IntArrayDocIdSet{docs[:n]}
+}
+
+type int32Slice []int32
+
+func (a int32Slice) Len() int           { return len(a) }
+func (a int32Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a int32Slice) Less(i, j int) bool { return a[i] < a[j] }
+
+// util/IntArrayDocIdSet.java
+
+/* A DocIdSet of a sorted array of distinct docs, e.g. of a sparse result. */
+type IntArrayDocIdSet struct {
+	docs []int32
+}
+
+/* Returns the number of docs of the set. */
+func (s *IntArrayDocIdSet) Cardinality() int {
+	return len(s.docs)
+}
+
+func (s *IntArrayDocIdSet) RamBytesUsed() int64 {
+	return int64(24 + 4*cap(s.docs))
+}
+
+func (s *IntArrayDocIdSet) Iterator() (DocIdSetIterator, error) {
+	return &intArrayIterator{docs: s.docs, i: -1, doc: -1}, nil
+}
+
+func (s *IntArrayDocIdSet) Bits() Bits {
+	return nil
+}
+
+func (s *IntArrayDocIdSet) IsCacheable() bool {
+	return true
+}
+
+type intArrayIterator struct {
+	docs []int32
+	i    int
+	doc  int
+}
+
+func (it *intArrayIterator) DocId() int {
+	return it.doc
+}
+
+func (it *intArrayIterator) NextDoc() (int, error) {
+	return it.moveTo(it.i + 1), nil
+}
+
+func (it *intArrayIterator) Advance(target int) (int, error) {
+	from := it.i + 1
+	i := from + sort.Search(len(it.docs)-from, func(k int) bool {
+		return int(it.docs[from+k]) >= target
+	})
+	return it.moveTo(i), nil
+}
+
+func (it *intArrayIterator) moveTo(i int) int {
+	it.i = i
+	if i >= len(it.docs) {
+		it.i, it.doc = len(it.docs), NO_MORE_DOCS
+	} else {
+		it.doc = int(it.docs[i])
+	}
+	return it.doc
+}
+
+func (it *intArrayIterator) Cost() int64 {
+	return int64(len(it.docs))
+}
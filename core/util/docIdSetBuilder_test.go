@@ -0,0 +1,57 @@
+package util
+
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"testing"
+)
+
+func TestDocIdSetBuilderSparse(t *testing.T) {
+	b := NewDocIdSetBuilder(10000)
+	for _, doc := range []int{42, 7, 9000, 7, 300} {
+		b.Add(doc)
+	}
+	set, ok := b.Build().(*IntArrayDocIdSet)
+	if !ok {
+		t.Fatal("Expected an IntArrayDocIdSet")
+	}
+	if set.Cardinality() != 4 {
+		t.Errorf("Expected 4 docs, got %v", set.Cardinality())
+	}
+	it, _ := set.Iterator()
+	var docs []int
+	for doc, _ := it.NextDoc(); doc != NO_MORE_DOCS; doc, _ = it.NextDoc() {
+		docs = append(docs, doc)
+	}
+	if len(docs) != 4 || docs[0] != 7 || docs[1] != 42 || docs[2] != 300 || docs[3] != 9000 {
+		t.Errorf("Expected [7 42 300 9000], got %v", docs)
+	}
+
+	it, _ = set.Iterator()
+	if doc, _ := it.Advance(43); doc != 300 {
+		t.Errorf("Expected 300, got %v", doc)
+	}
+	if doc, _ := it.Advance(300); doc != 9000 {
+		t.Errorf("Expected 9000, got %v", doc)
+	}
+	if doc, _ := it.Advance(9001); doc != NO_MORE_DOCS {
+		t.Errorf("Expected NO_MORE_DOCS, got %v", doc)
+	}
+}
+
+func TestDocIdSetBuilderDense(t *testing.T) {
+	b := NewDocIdSetBuilder(1024)
+	bits := NewFixedBitSetOf(1024)
+	for doc := 0; doc < 1024; doc += 3 {
+		bits.Set(doc)
+	}
+	if err := b.AddIterator(NewFixedBitSetIterator(bits)); err != nil {
+		t.Fatal(err)
+	}
+	set, ok := b.Build().(*FixedBitSet)
+	if !ok {
+		t.Fatal("Expected a FixedBitSet")
+	}
+	if set.Cardinality() != bits.Cardinality() {
+		t.Errorf("Expected %v docs, got %v", bits.Cardinality(), set.Cardinality())
+	}
+}
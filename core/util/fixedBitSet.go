@@ -1,5 +1,9 @@
 package util
 
+import (
+	. "github.com/balzaczyy/golucene/core/search/model"
+)
+
 /*
 BitSet of fixed length (numBits), backed by accessible bits() []int64,
 accessed with an int index, implementing Bits and DocIdSet. Unlike
@@ -22,7 +26,16 @@ bits if possible. Also, calling length() on the returned bits may
 return a value greater than numBits.
 */
 func EnsureFixedBitSet(bits *FixedBitSet, numBits int) *FixedBitSet {
-	panic("not implemented yet")
+	if numBits < bits.numBits {
+		return bits
+	}
+	numWords := fbits2words(numBits)
+	arr := bits.bits
+	if numWords >= len(arr) {
+		arr = make([]int64, Oversize(numWords+1, NUM_BYTES_LONG))
+		copy(arr, bits.bits)
+	}
+	return &FixedBitSet{bits: arr, numBits: len(arr) << 6, numWords: len(arr)}
 }
 
 /* returns the number of 64 bit words it would take to hold numBits */
@@ -56,7 +69,12 @@ func (b *FixedBitSet) IsCacheable() bool {
 }
 
 func (b *FixedBitSet) RamBytesUsed() int64 {
-	panic("not implemented yet")
+	return AlignObjectSize(NUM_BYTES_OBJECT_HEADER+NUM_BYTES_OBJECT_REF+2*NUM_BYTES_INT) +
+		SizeOf(b.bits)
+}
+
+func (b *FixedBitSet) Iterator() (DocIdSetIterator, error) {
+	return NewFixedBitSetIterator(b), nil
 }
 
 /*
@@ -68,12 +86,67 @@ func (b *FixedBitSet) Cardinality() int {
 }
 
 func (b *FixedBitSet) At(index int) bool {
-	panic("not implemented yet")
+	assert2(index >= 0 && index < b.numBits, "index=%v, numBits=%v", index, b.numBits)
+	wordNum := index >> 6 // div 64
+	bitmask := int64(1) << uint(index&63)
+	return (b.bits[wordNum] & bitmask) != 0
 }
 
 func (b *FixedBitSet) Set(index int) {
 	assert2(index >= 0 && index < b.numBits, "index=%v, numBits=%v", index, b.numBits)
 	wordNum := index >> 6 // div 64
-	bitmask := int64(1 << uint(index))
+	bitmask := int64(1) << uint(index&63)
 	b.bits[wordNum] |= bitmask
 }
+
+/*
+Returns the index of the first set bit starting at the index
+specified. -1 is returned if there are no more set bits.
+*/
+func (b *FixedBitSet) NextSetBit(index int) int {
+	assert2(index >= 0 && index < b.numBits, "index=%v, numBits=%v", index, b.numBits)
+	i := index >> 6
+	word := int64(uint64(b.bits[i]) >> uint(index&63)) // skip all the bits to the right of index
+	if word != 0 {
+		return index + int(NumberOfTrailingZeros(word))
+	}
+	for i++; i < b.numWords; i++ {
+		if word = b.bits[i]; word != 0 {
+			return (i << 6) + int(NumberOfTrailingZeros(word))
+		}
+	}
+	return -1
+}
+
+// util/FixedBitSet.java#FixedBitSetIterator
+
+/* A DocIdSetIterator which iterates over set bits in a FixedBitSet. */
+type FixedBitSetIterator struct {
+	bits *FixedBitSet
+	doc  int
+}
+
+func NewFixedBitSetIterator(bits *FixedBitSet) *FixedBitSetIterator {
+	return &FixedBitSetIterator{bits, -1}
+}
+
+func (it *FixedBitSetIterator) DocId() int {
+	return it.doc
+}
+
+func (it *FixedBitSetIterator) NextDoc() (int, error) {
+	return it.Advance(it.doc + 1)
+}
+
+func (it *FixedBitSetIterator) Advance(target int) (int, error) {
+	if target >= it.bits.numBits {
+		it.doc = NO_MORE_DOCS
+	} else if it.doc = it.bits.NextSetBit(target); it.doc == -1 {
+		it.doc = NO_MORE_DOCS
+	}
+	return it.doc, nil
+}
+
+func (it *FixedBitSetIterator) Cost() int64 {
+	return int64(it.bits.numBits)
+}
@@ -61,6 +61,15 @@ func newBytesStoreFromInput(in util.DataInput, numBytes int64, maxBlockSize uint
 	return self, nil
 }
 
+/* Returns the memory used by the blocks of the store. */
+func (bs *BytesStore) RamBytesUsed() int64 {
+	size := util.ShallowSizeOf(bs.blocks)
+	for _, block := range bs.blocks {
+		size += util.SizeOf(block)
+	}
+	return size
+}
+
 func (bs *BytesStore) WriteByte(b byte) error {
 	if bs.nextWrite == bs.blockSize {
 		bs.current = make([]byte, bs.blockSize)
@@ -88,7 +88,7 @@ func (arc *Arc) flag(flag byte) bool {
 	return hasFlag(arc.flags, flag)
 }
 
-func (arc *Arc) isLast() bool {
+func (arc *Arc) IsLast() bool {
 	return arc.flag(FST_BIT_LAST_ARC)
 }
 
@@ -298,6 +298,23 @@ func loadFST3(in util.DataInput, outputs Outputs, maxBlockBits uint32) (fst *FST
 	return fst, err
 }
 
+var FST_BASE_RAM_BYTES_USED = util.ShallowSizeOfInstance(reflect.TypeOf(FST{}))
+
+/* Returns the memory used by the FST, i.e. its bytes and cached root arcs. */
+func (t *FST) RamBytesUsed() int64 {
+	size := FST_BASE_RAM_BYTES_USED
+	if t.bytes != nil {
+		size += t.bytes.RamBytesUsed()
+	}
+	if t.packed && t.nodeRefToAddress != nil {
+		size += t.nodeRefToAddress.RamBytesUsed()
+	}
+	if t.bytesPerArc != nil {
+		size += util.ShallowSizeOf(t.bytesPerArc)
+	}
+	return size + int64(t.cachedArcsBytesUsed)
+}
+
 func (t *FST) ramBytesUsed(arcs []*Arc) int64 {
 	var size int64
 	if arcs != nil {
@@ -352,7 +369,7 @@ func (t *FST) readRootArcs(arcs []*Arc) (err error) {
 	arc := &Arc{}
 	t.FirstArc(arc)
 	in := t.BytesReader()
-	if targetHasArcs(arc) {
+	if TargetHasArcs(arc) {
 		_, err = t.readFirstRealTargetArc(arc.target, arc, in)
 		for err == nil {
 			if arc.Label == FST_END_LABEL {
@@ -362,7 +379,7 @@ func (t *FST) readRootArcs(arcs []*Arc) (err error) {
 				break
 			}
 			arcs[arc.Label] = (&Arc{}).copyFrom(arc)
-			if arc.isLast() {
+			if arc.IsLast() {
 				break
 			}
 			_, err = t.readNextRealArc(arc, in)
@@ -571,7 +588,7 @@ func (t *FST) readLabel(in util.DataInput) (v int, err error) {
 	return v, err
 }
 
-func targetHasArcs(arc *Arc) bool {
+func TargetHasArcs(arc *Arc) bool {
 	return arc.target > 0
 }
 
@@ -775,7 +792,7 @@ func AsInt64(n int32, err error) (n2 int64, err2 error) {
 	return int64(n), err
 }
 
-func (t *FST) readFirstTargetArc(follow, arc *Arc, in BytesReader) (*Arc, error) {
+func (t *FST) ReadFirstTargetArc(follow, arc *Arc, in BytesReader) (*Arc, error) {
 	if follow.IsFinal() {
 		// insert "fake" final first arc:
 		arc.Label = FST_END_LABEL
@@ -829,10 +846,10 @@ func (t *FST) readFirstRealTargetArc(node int64, arc *Arc, in BytesReader) (ans
 	return t.readNextRealArc(arc, in)
 }
 
-func (t *FST) readNextArc(arc *Arc, in BytesReader) (*Arc, error) {
+func (t *FST) ReadNextArc(arc *Arc, in BytesReader) (*Arc, error) {
 	if arc.Label == FST_END_LABEL {
 		// this was a fake inserted "final" arc
-		assert2(arc.nextArc > 0, "cannot readNextArc when arc.isLast()=true")
+		assert2(arc.nextArc > 0, "cannot ReadNextArc when arc.IsLast()=true")
 		return t.readFirstRealTargetArc(arc.nextArc, arc, in)
 	} else {
 		return t.readNextRealArc(arc, in)
@@ -840,7 +857,7 @@ func (t *FST) readNextArc(arc *Arc, in BytesReader) (*Arc, error) {
 }
 
 /** Never returns null, but you should never call this if
- *  arc.isLast() is true. */
+ *  arc.IsLast() is true. */
 func (t *FST) readNextRealArc(arc *Arc, in BytesReader) (ans *Arc, err error) {
 	// TODO: can't assert this because we call from readFirstArc
 	// assert !flag(arc.flags, BIT_LAST_ARC);
@@ -965,7 +982,7 @@ func (t *FST) FindTargetArc(labelToMatch int, follow *Arc, arc *Arc, in BytesRea
 		return nil, nil
 	}
 
-	if !targetHasArcs(follow) {
+	if !TargetHasArcs(follow) {
 		return nil, nil
 	}
 
@@ -1037,7 +1054,7 @@ func (t *FST) FindTargetArc(labelToMatch int, follow *Arc, arc *Arc, in BytesRea
 			return arc, nil
 		} else if arc.Label > labelToMatch {
 			return nil, nil
-		} else if arc.isLast() {
+		} else if arc.IsLast() {
 			return nil, nil
 		} else {
 			if _, err = t.readNextRealArc(arc, in); err != nil {
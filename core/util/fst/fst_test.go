@@ -0,0 +1,70 @@
+package fst
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"testing"
+)
+
+/* Builds an FST mapping each of the sorted inputs to itself. */
+func buildTestFST(t *testing.T, doShareSuffix bool, inputs []string) *FST {
+	b := NewBuilder(INPUT_TYPE_BYTE1, 0, 0, doShareSuffix, false, 1<<31-1,
+		ByteSequenceOutputsSingleton(), false, packed.PackedInts.COMPACT, false, 15)
+	scratch := util.NewIntsRefBuilder()
+	for _, input := range inputs {
+		if err := b.Add(ToIntsRef([]byte(input), scratch), []byte(input)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fst, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fst
+}
+
+func TestBytesRefFSTEnumLongInput(t *testing.T) {
+	// longer than the initially allocated outputs of the enum
+	inputs := []string{"a", "abcdefghijklmnopqrstuvwxyz", "b"}
+	e := NewBytesRefFSTEnum(buildTestFST(t, false, inputs))
+	for _, input := range inputs {
+		io, err := e.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if io == nil {
+			t.Fatalf("Expected %v, but got end of enum", input)
+		}
+		if got := string(io.Input.ToBytes()); got != input {
+			t.Errorf("Expected input %v, but got %v", input, got)
+		}
+		if got := string(io.Output.([]byte)); got != input {
+			t.Errorf("Expected output %v, but got %v", input, got)
+		}
+	}
+	if io, err := e.Next(); err != nil || io != nil {
+		t.Errorf("Expected end of enum, but got %v (%v)", io, err)
+	}
+}
+
+func TestBuilderSharedSuffixes(t *testing.T) {
+	// enough distinct tails to rehash the node hash several times
+	var inputs []string
+	for i := 0; i < 500; i++ {
+		inputs = append(inputs, fmt.Sprintf("%03d-%x", i, uint32(i*2654435761)))
+	}
+	fst := buildTestFST(t, true, inputs)
+	for _, input := range inputs {
+		output, err := GetFSTOutput(fst, []byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output == nil || string(output.([]byte)) != input {
+			t.Errorf("Expected output %v, but got %v", input, output)
+		}
+	}
+	if output, err := GetFSTOutput(fst, []byte("499-")); err != nil || output != nil {
+		t.Errorf("Expected no output, but got %v (%v)", output, err)
+	}
+}
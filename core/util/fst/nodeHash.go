@@ -1,7 +1,7 @@
 package fst
 
 import (
-	// "fmt"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/util/packed"
 )
 
@@ -42,7 +42,7 @@ func (nh *NodeHash) nodesEqual(node *UnCompiledNode, address int64) (bool, error
 			return false, nil
 		}
 
-		if nh.scratchArc.isLast() {
+		if nh.scratchArc.IsLast() {
 			return arcUpto == node.NumArcs-1, nil
 		}
 		if _, err = nh.fst.readNextRealArc(nh.scratchArc, nh.in); err != nil {
@@ -96,7 +96,7 @@ func (nh *NodeHash) hashFrozen(node int64) (int64, error) {
 		if nh.scratchArc.IsFinal() {
 			h += 17
 		}
-		if nh.scratchArc.isLast() {
+		if nh.scratchArc.IsLast() {
 			break
 		}
 		if _, err = nh.fst.readNextRealArc(nh.scratchArc, nh.in); err != nil {
@@ -108,10 +108,18 @@ func (nh *NodeHash) hashFrozen(node int64) (int64, error) {
 }
 
 func hashPtr(obj interface{}) (h int64) {
-	if obj != nil && obj != NO_OUTPUT {
-		for _, b := range obj.([]byte) {
+	if obj == nil || obj == NO_OUTPUT {
+		return 0
+	}
+	switch v := obj.(type) {
+	case []byte:
+		for _, b := range v {
 			h = PRIME*h + int64(b)
 		}
+	case int64:
+		h = int64(int32(v ^ int64(uint64(v)>>32)))
+	default:
+		panic(fmt.Sprintf("unsupported output: %v", obj))
 	}
 	return
 }
@@ -139,7 +147,9 @@ func (nh *NodeHash) add(nodeIn *UnCompiledNode) (int64, error) {
 			nh.table.Set(pos, node)
 			// rehash at 2/3 occupancy:
 			if nh.count > 2*nh.table.Size()/3 {
-				panic("not implemented yet")
+				if err = nh.rehash(); err != nil {
+					return 0, err
+				}
 			}
 			return node, nil
 		} else {
@@ -158,3 +168,35 @@ func (nh *NodeHash) add(nodeIn *UnCompiledNode) (int64, error) {
 		pos = (pos + c) & nh.mask
 	}
 }
+
+/* called only by rehash */
+func (nh *NodeHash) addNew(address int64) error {
+	h, err := nh.hashFrozen(address)
+	if err != nil {
+		return err
+	}
+	pos := h & nh.mask
+	c := int64(0)
+	for nh.table.Get(pos) != 0 {
+		// quadratic probe
+		c++
+		pos = (pos + c) & nh.mask
+	}
+	nh.table.Set(pos, address)
+	return nil
+}
+
+func (nh *NodeHash) rehash() error {
+	oldTable := nh.table
+	nh.table = packed.NewPagedGrowableWriter(2*oldTable.Size(), 1<<30,
+		packed.BitsRequired(nh.count), packed.PackedInts.COMPACT)
+	nh.mask = nh.table.Size() - 1
+	for idx := int64(0); idx < oldTable.Size(); idx++ {
+		if address := oldTable.Get(idx); address != 0 {
+			if err := nh.addNew(address); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
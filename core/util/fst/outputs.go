@@ -94,7 +94,9 @@ func newNoOutputs() *NoOutputs {
 }
 
 func (o *NoOutputs) Common(output1, output2 interface{}) interface{} {
-	panic("niy")
+	assert(output1 == NO_OUTPUT)
+	assert(output2 == NO_OUTPUT)
+	return NO_OUTPUT
 }
 
 func (o *NoOutputs) Subtract(output1, output2 interface{}) interface{} {
@@ -104,7 +106,9 @@ func (o *NoOutputs) Subtract(output1, output2 interface{}) interface{} {
 }
 
 func (o *NoOutputs) Add(prefix, output interface{}) interface{} {
-	panic("not implemented yet")
+	assert(prefix == NO_OUTPUT)
+	assert(output == NO_OUTPUT)
+	return NO_OUTPUT
 }
 
 func (o *NoOutputs) merge(first, second interface{}) interface{} {
@@ -129,6 +133,14 @@ func (o *NoOutputs) outputToString(output interface{}) string {
 	return ""
 }
 
+func (o *NoOutputs) String() string {
+	return "NoOutputs"
+}
+
+func (o *NoOutputs) ramBytesUsed(output interface{}) int64 {
+	return 0
+}
+
 // fst/ByteSequenceOutputs.java
 
 /**
@@ -266,6 +278,105 @@ func (out *ByteSequenceOutputs) ramBytesUsed(output interface{}) int64 {
 	return BASE_NUM_BYTES + util.SizeOf(output.([]byte))
 }
 
+// fst/PositiveIntOutputs.java
+
+/*
+An FST Outputs implementation where each output is a non-negative
+int64 value, and the outputs of a path are summed.
+*/
+type PositiveIntOutputs struct {
+	*abstractOutputs
+}
+
+var onePositiveIntOutputs *PositiveIntOutputs
+
+func PositiveIntOutputsSingleton() *PositiveIntOutputs {
+	if onePositiveIntOutputs == nil {
+		onePositiveIntOutputs = &PositiveIntOutputs{}
+		onePositiveIntOutputs.abstractOutputs = &abstractOutputs{onePositiveIntOutputs}
+	}
+	return onePositiveIntOutputs
+}
+
+func (out *PositiveIntOutputs) Common(output1, output2 interface{}) interface{} {
+	assert(out.valid(output1))
+	assert(out.valid(output2))
+	if output1 == NO_OUTPUT || output2 == NO_OUTPUT {
+		return NO_OUTPUT
+	}
+	if n1, n2 := output1.(int64), output2.(int64); n1 < n2 {
+		return n1
+	} else {
+		return n2
+	}
+}
+
+func (out *PositiveIntOutputs) Subtract(output, inc interface{}) interface{} {
+	assert(out.valid(output))
+	assert(out.valid(inc))
+	if inc == NO_OUTPUT {
+		return output
+	}
+	n, i := output.(int64), inc.(int64)
+	assert2(n >= i, "output=%v vs inc=%v", n, i)
+	if n == i {
+		return NO_OUTPUT
+	}
+	return n - i
+}
+
+func (out *PositiveIntOutputs) Add(prefix, output interface{}) interface{} {
+	assert(out.valid(prefix))
+	assert(out.valid(output))
+	if prefix == NO_OUTPUT {
+		return output
+	} else if output == NO_OUTPUT {
+		return prefix
+	}
+	return prefix.(int64) + output.(int64)
+}
+
+func (out *PositiveIntOutputs) Write(output interface{}, o util.DataOutput) error {
+	assert(out.valid(output))
+	return o.WriteVLong(output.(int64))
+}
+
+func (out *PositiveIntOutputs) Read(in util.DataInput) (interface{}, error) {
+	n, err := in.ReadVLong()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return NO_OUTPUT, nil
+	}
+	return n, nil
+}
+
+/* Outputs are either NO_OUTPUT, or positive. */
+func (out *PositiveIntOutputs) valid(o interface{}) bool {
+	if o == NO_OUTPUT {
+		return true
+	}
+	n, ok := o.(int64)
+	return ok && n > 0
+}
+
+func (out *PositiveIntOutputs) NoOutput() interface{} {
+	return NO_OUTPUT
+}
+
+func (out *PositiveIntOutputs) outputToString(output interface{}) string {
+	return fmt.Sprintf("%v", output)
+}
+
+func (out *PositiveIntOutputs) String() string {
+	return "PositiveIntOutputs"
+}
+
+func (out *PositiveIntOutputs) ramBytesUsed(output interface{}) int64 {
+	return util.ShallowSizeOf(output)
+}
+
 // util/fst/Util.java
 
 /** Looks up the output for this input, or null if the
@@ -283,7 +394,7 @@ func GetFSTOutput(fst *FST, input []byte) (output interface{}, err error) {
 	for _, v := range input {
 		ret, err := fst.FindTargetArc(int(v), arc, arc, fstReader)
 		if ret == nil || err != nil {
-			return ret, err
+			return nil, err // not a typed nil *Arc
 		}
 		output = fst.outputs.Add(output, arc.Output)
 	}
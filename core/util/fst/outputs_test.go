@@ -0,0 +1,55 @@
+package fst
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"math"
+	"testing"
+)
+
+func TestPositiveIntOutputs(t *testing.T) {
+	inputs := []string{"bar", "barn", "baz", "foo", "fools"}
+	outputs := []int64{5, 17, 5, 1, 1 << 40}
+
+	b := NewBuilder(INPUT_TYPE_BYTE1, 0, 0, true, true, math.MaxInt32,
+		PositiveIntOutputsSingleton(), false, packed.PackedInts.COMPACT, true, 15)
+	scratch := util.NewIntsRefBuilder()
+	for i, input := range inputs {
+		if err := b.Add(ToIntsRef([]byte(input), scratch), outputs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fst, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, input := range inputs {
+		if output, err := GetFSTOutput(fst, []byte(input)); err != nil || output != outputs[i] {
+			t.Errorf("expected output %v of %v, but was %v (%v)", outputs[i], input, output, err)
+		}
+	}
+	for _, input := range []string{"", "ba", "barns", "fool"} {
+		if output, err := GetFSTOutput(fst, []byte(input)); err != nil || output != nil {
+			t.Errorf("expected %v not to be accepted, but was %v (%v)", input, output, err)
+		}
+	}
+
+	// the inputs are enumerated in order
+	e := NewBytesRefFSTEnum(fst)
+	for i, input := range inputs {
+		io, err := e.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if io == nil || string(io.Input.ToBytes()) != input {
+			t.Fatalf("expected input %v, but was %v", input, io)
+		}
+		if io.Output != outputs[i] {
+			t.Errorf("expected output %v of %v, but was %v", outputs[i], input, io.Output)
+		}
+	}
+	if io, err := e.Next(); err != nil || io != nil {
+		t.Errorf("expected no more input, but was %v (%v)", io, err)
+	}
+}
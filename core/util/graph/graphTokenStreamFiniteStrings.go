@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// util/graph/GraphTokenStreamFiniteStrings.java
+
+/* A token of the graph: its term and the nodes it connects. */
+type GraphToken struct {
+	Term     []byte
+	From, To int
+}
+
+/*
+Consumes a TokenStream and creates a graph whose nodes are positions
+and whose edges are tokens, spanning PositionLength positions each.
+The graph can then be split at its articulation points, and each
+piece enumerated as the finite set of token sequences (paths) it
+accepts. This is what query builders use to turn the output of graph
+token filters (multi-word synonyms, word splitting, etc.) into
+queries that match exactly the alternatives the graph describes.
+*/
+type GraphTokenStreamFiniteStrings struct {
+	tokens []*GraphToken
+	// outgoing tokens of each node, by token index
+	edges map[int][]int
+	// nodes without incoming tokens (gaps left by removed tokens) are
+	// linked to the previous node with an empty transition:
+	gaps map[int]int
+	// sorted list of all nodes
+	nodes []int
+	// the node every path ends at
+	end int
+}
+
+/*
+Consumes all tokens of the given stream and builds the graph. The
+stream must already be reset; it is neither ended nor closed.
+*/
+func NewGraphTokenStreamFiniteStrings(in analysis.TokenStream) (*GraphTokenStreamFiniteStrings, error) {
+	g := &GraphTokenStreamFiniteStrings{
+		edges: make(map[int][]int),
+		gaps:  make(map[int]int),
+	}
+
+	atts := in.Attributes()
+	termBytesAtt := atts.Add("TermToBytesRefAttribute").(ta.TermToBytesRefAttribute)
+	posIncAtt := atts.Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	var posLenAtt ta.PositionLengthAttribute
+	if atts.Has("PositionLengthAttribute") {
+		posLenAtt = atts.Get("PositionLengthAttribute").(ta.PositionLengthAttribute)
+	}
+	bytes := termBytesAtt.BytesRef()
+
+	incoming := make(map[int]bool)
+	pos := -1
+	ok, err := in.IncrementToken()
+	for ; ok && err == nil; ok, err = in.IncrementToken() {
+		if pos += posIncAtt.PositionIncrement(); pos < 0 {
+			pos = 0 // the first token may not have a position increment of 0
+		}
+		posLen := 1
+		if posLenAtt != nil {
+			posLen = posLenAtt.PositionLength()
+		}
+		termBytesAtt.FillBytesRef()
+		token := &GraphToken{util.DeepCopyOf(bytes).ToBytes(), pos, pos + posLen}
+		g.edges[token.From] = append(g.edges[token.From], len(g.tokens))
+		g.tokens = append(g.tokens, token)
+		incoming[token.To] = true
+		if token.To > g.end {
+			g.end = token.To
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int]bool{0: true}
+	g.nodes = []int{0}
+	for _, t := range g.tokens {
+		for _, n := range []int{t.From, t.To} {
+			if !seen[n] {
+				seen[n] = true
+				g.nodes = append(g.nodes, n)
+			}
+		}
+	}
+	sort.Ints(g.nodes)
+	for i, n := range g.nodes {
+		if i > 0 && !incoming[n] {
+			g.gaps[n] = g.nodes[i-1]
+		}
+	}
+	return g, nil
+}
+
+/* Returns all tokens of the graph, in the order they were consumed. */
+func (g *GraphTokenStreamFiniteStrings) Tokens() []*GraphToken {
+	return g.tokens
+}
+
+/* Returns the node all paths through the graph end at. */
+func (g *GraphTokenStreamFiniteStrings) End() int {
+	return g.end
+}
+
+/* Returns whether the graph has any token spanning more than one position. */
+func (g *GraphTokenStreamFiniteStrings) HasGraph() bool {
+	for _, t := range g.tokens {
+		if t.To-t.From > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns whether there is more than one path between the given node
+and the next articulation point.
+*/
+func (g *GraphTokenStreamFiniteStrings) HasSidePath(start int) bool {
+	for _, i := range g.edges[start] {
+		if g.tokens[i].To != g.nextNode(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GraphTokenStreamFiniteStrings) nextNode(node int) int {
+	i := sort.SearchInts(g.nodes, node+1)
+	if i < len(g.nodes) {
+		return g.nodes[i]
+	}
+	return node
+}
+
+/*
+Returns the articulation points of the graph, in increasing order.
+An articulation point is a node (other than the start and the end)
+that every path goes through, i.e. no token spans across it. Each
+articulation point splits the graph into pieces that can be turned
+into queries on their own.
+*/
+func (g *GraphTokenStreamFiniteStrings) ArticulationPoints() []int {
+	var points []int
+	for _, n := range g.nodes {
+		if n == 0 || n >= g.end {
+			continue
+		}
+		crossed := false
+		for _, t := range g.tokens {
+			if t.From < n && n < t.To {
+				crossed = true
+				break
+			}
+		}
+		if !crossed {
+			points = append(points, n)
+		}
+	}
+	return points
+}
+
+/* Returns the terms of all tokens leaving the given node. */
+func (g *GraphTokenStreamFiniteStrings) Terms(node int) [][]byte {
+	var terms [][]byte
+	for _, i := range g.edges[node] {
+		terms = append(terms, g.tokens[i].Term)
+	}
+	return terms
+}
+
+/* Returns all paths through the whole graph. */
+func (g *GraphTokenStreamFiniteStrings) FiniteStrings() [][]*GraphToken {
+	return g.FiniteStringsBetween(0, g.end)
+}
+
+/*
+Returns all paths between the start and the end node, as the
+sequences of tokens on each path. Gaps left by removed tokens are
+skipped.
+*/
+func (g *GraphTokenStreamFiniteStrings) FiniteStringsBetween(start, end int) [][]*GraphToken {
+	var paths [][]*GraphToken
+	var walk func(node int, path []*GraphToken)
+	walk = func(node int, path []*GraphToken) {
+		if node == end {
+			paths = append(paths, append([]*GraphToken(nil), path...))
+			return
+		}
+		if node > end {
+			return
+		}
+		for _, i := range g.edges[node] {
+			walk(g.tokens[i].To, append(path, g.tokens[i]))
+		}
+		if next := g.nextNode(node); next != node {
+			if prev, ok := g.gaps[next]; ok && prev == node {
+				walk(next, path)
+			}
+		}
+	}
+	walk(start, nil)
+	return paths
+}
@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testToken struct {
+	term           string
+	posInc, posLen int
+}
+
+/* Emits the given tokens, with their position increments and lengths. */
+type testTokenStream struct {
+	*analysis.TokenStreamImpl
+	termAtt   ta.CharTermAttribute
+	posIncAtt ta.PositionIncrementAttribute
+	posLenAtt ta.PositionLengthAttribute
+	tokens    []testToken
+	upto      int
+}
+
+func newTestTokenStream(tokens ...testToken) *testTokenStream {
+	ans := &testTokenStream{TokenStreamImpl: analysis.NewTokenStream(), tokens: tokens}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(ta.PositionLengthAttribute)
+	return ans
+}
+
+func (ts *testTokenStream) IncrementToken() (bool, error) {
+	if ts.upto == len(ts.tokens) {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	token := ts.tokens[ts.upto]
+	ts.termAtt.AppendString(token.term)
+	ts.posIncAtt.SetPositionIncrement(token.posInc)
+	ts.posLenAtt.SetPositionLength(token.posLen)
+	ts.upto++
+	return true, nil
+}
+
+func newTestGraph(t *testing.T, tokens ...testToken) *GraphTokenStreamFiniteStrings {
+	g, err := NewGraphTokenStreamFiniteStrings(newTestTokenStream(tokens...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+/* Returns each path as its terms joined with spaces. */
+func pathStrings(paths [][]*GraphToken) []string {
+	var ans []string
+	for _, path := range paths {
+		var terms []string
+		for _, token := range path {
+			terms = append(terms, string(token.Term))
+		}
+		ans = append(ans, strings.Join(terms, " "))
+	}
+	return ans
+}
+
+func TestFlatGraph(t *testing.T) {
+	g := newTestGraph(t, testToken{"fast", 1, 1}, testToken{"quick", 0, 1}, testToken{"fox", 1, 1})
+	if g.HasGraph() {
+		t.Error("expected no graph")
+	}
+	if points := g.ArticulationPoints(); !reflect.DeepEqual(points, []int{1}) {
+		t.Errorf("expected articulation points [1], got %v", points)
+	}
+	if g.HasSidePath(0) {
+		t.Error("expected no side path at 0")
+	}
+	if paths := pathStrings(g.FiniteStrings()); !reflect.DeepEqual(paths, []string{"fast fox", "quick fox"}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+	if terms := g.Terms(0); len(terms) != 2 || string(terms[0]) != "fast" || string(terms[1]) != "quick" {
+		t.Errorf("unexpected terms at 0: %q", terms)
+	}
+}
+
+func TestSynonymGraph(t *testing.T) {
+	// ny is a synonym of new york, spanning both of its positions
+	g := newTestGraph(t,
+		testToken{"ny", 1, 2}, testToken{"new", 0, 1}, testToken{"york", 1, 1}, testToken{"city", 1, 1})
+	if !g.HasGraph() {
+		t.Error("expected a graph")
+	}
+	if g.End() != 3 {
+		t.Errorf("expected end 3, got %v", g.End())
+	}
+	if points := g.ArticulationPoints(); !reflect.DeepEqual(points, []int{2}) {
+		t.Errorf("expected articulation points [2], got %v", points)
+	}
+	if !g.HasSidePath(0) {
+		t.Error("expected a side path at 0")
+	}
+	if g.HasSidePath(2) {
+		t.Error("expected no side path at 2")
+	}
+	if paths := pathStrings(g.FiniteStrings()); !reflect.DeepEqual(paths, []string{"ny city", "new york city"}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+	if paths := pathStrings(g.FiniteStringsBetween(0, 2)); !reflect.DeepEqual(paths, []string{"ny", "new york"}) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
+
+func TestGraphWithGap(t *testing.T) {
+	// a removed stop word leaves position 1 without any token
+	g := newTestGraph(t, testToken{"quick", 1, 1}, testToken{"fox", 2, 1})
+	if points := g.ArticulationPoints(); !reflect.DeepEqual(points, []int{1, 2}) {
+		t.Errorf("expected articulation points [1 2], got %v", points)
+	}
+	paths := g.FiniteStrings()
+	if strs := pathStrings(paths); !reflect.DeepEqual(strs, []string{"quick fox"}) {
+		t.Fatalf("unexpected paths %v", strs)
+	}
+	if from := paths[0][1].From; from != 2 {
+		t.Errorf("expected fox to start at 2, got %v", from)
+	}
+}
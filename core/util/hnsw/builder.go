@@ -0,0 +1,176 @@
+package hnsw
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// util/hnsw/HnswGraphBuilder.java
+
+const (
+	// Default maximum number of neighbors of a node on upper levels;
+	// nodes have twice as many on level 0.
+	DEFAULT_MAX_CONN = 16
+	// Default size of the queue of the search for the neighbors of a
+	// new node.
+	DEFAULT_BEAM_WIDTH = 100
+)
+
+/*
+Builds a Graph over the vectors, inserting them in order. The level of
+each node is drawn at random, with an exponentially decaying
+probability, so that upper levels form ever sparser graphs through
+which searches quickly get near their target.
+
+Neighbors are selected with the diversity heuristic: a candidate is
+only linked if it is nearer to the new node than to any neighbor
+selected before it, which keeps long links between clusters.
+*/
+type Builder struct {
+	vectors    VectorValues
+	similarity Similarity
+	maxConn    int
+	beamWidth  int
+	ml         float64 // level normalization factor
+	random     *rand.Rand
+	graph      *Graph
+	searcher   *searcher
+}
+
+/*
+Creates a builder; maxConn is the maximum number of neighbors of a
+node on upper levels, and beamWidth the size of the queue used to
+search the neighbors of new nodes: the larger, the better the graph,
+but the slower to build. The seed makes graphs reproducible.
+*/
+func NewBuilder(vectors VectorValues, similarity Similarity,
+	maxConn, beamWidth int, seed int64) *Builder {
+
+	assert2(maxConn > 0, "maxConn must be positive, got %v", maxConn)
+	assert2(beamWidth > 0, "beamWidth must be positive, got %v", beamWidth)
+	graph := NewGraph()
+	return &Builder{
+		vectors:    vectors,
+		similarity: similarity,
+		maxConn:    maxConn,
+		beamWidth:  beamWidth,
+		ml:         1 / math.Log(math.Max(float64(maxConn), 2)),
+		random:     rand.New(rand.NewSource(seed)),
+		graph:      graph,
+		searcher:   newSearcher(vectors, similarity, graph),
+	}
+}
+
+/* Adds all vectors not in the graph yet, and returns the graph. */
+func (b *Builder) Build() *Graph {
+	for b.graph.Size() < b.vectors.Size() {
+		b.addNode()
+	}
+	return b.graph
+}
+
+func (b *Builder) maxConnOn(level int) int {
+	if level == 0 {
+		return 2 * b.maxConn
+	}
+	return b.maxConn
+}
+
+func (b *Builder) randomLevel() int {
+	return int(-math.Log(1-b.random.Float64()) * b.ml)
+}
+
+func (b *Builder) addNode() {
+	level := b.randomLevel()
+	entry, maxLevel := b.graph.EntryNode(), b.graph.maxLevel
+	node := b.graph.addNode(level)
+	if entry == -1 {
+		return
+	}
+
+	target := b.vectors.VectorValue(node)
+	eps := []int{entry}
+	for l := maxLevel; l > level; l-- {
+		eps = []int{b.searcher.searchLevel(target, 1, l, eps, nil).top().Node}
+	}
+	if level < maxLevel {
+		maxLevel = level
+	}
+	for l := maxLevel; l >= 0; l-- {
+		candidates := sorted(b.searcher.searchLevel(target, b.beamWidth, l, eps, nil))
+		neighbors := b.diverse(candidates, b.maxConnOn(l))
+		b.graph.setNeighbors(l, node, nodesOf(neighbors))
+		for _, n := range neighbors {
+			b.link(l, n.Node, Neighbor{node, n.Score})
+		}
+		eps = nodesOf(candidates)
+	}
+}
+
+/*
+Selects up to max candidates, nearest first, which are nearer to the
+node than to the candidates selected before them.
+*/
+func (b *Builder) diverse(candidates []Neighbor, max int) (selected []Neighbor) {
+	for _, c := range candidates {
+		if len(selected) == max {
+			break
+		}
+		v := b.vectors.VectorValue(c.Node)
+		keep := true
+		for _, s := range selected {
+			if b.similarity(v, b.vectors.VectorValue(s.Node)) > c.Score {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c)
+		}
+	}
+	return
+}
+
+/*
+Adds the neighbor to the node on the level, pruning its neighbors
+if it has too many: the diverse ones are kept first, then the
+nearest of the others.
+*/
+func (b *Builder) link(level, node int, neighbor Neighbor) {
+	neighbors := append(b.graph.Neighbors(level, node), neighbor.Node)
+	max := b.maxConnOn(level)
+	if len(neighbors) <= max {
+		b.graph.setNeighbors(level, node, neighbors)
+		return
+	}
+
+	v := b.vectors.VectorValue(node)
+	candidates := make([]Neighbor, len(neighbors))
+	for i, n := range neighbors {
+		candidates[i] = Neighbor{n, b.similarity(v, b.vectors.VectorValue(n))}
+	}
+	sort.Sort(byNearest(candidates))
+	selected := b.diverse(candidates, max)
+	kept := make(map[int]bool)
+	for _, s := range selected {
+		kept[s.Node] = true
+	}
+	for _, c := range candidates {
+		if len(selected) == max {
+			break
+		}
+		if !kept[c.Node] {
+			selected = append(selected, c)
+		}
+	}
+	b.graph.setNeighbors(level, node, nodesOf(selected))
+}
+
+func nodesOf(neighbors []Neighbor) []int {
+	ans := make([]int, len(neighbors))
+	for i, n := range neighbors {
+		ans[i] = n.Node
+	}
+	return ans
+}
@@ -0,0 +1,103 @@
+package hnsw
+
+import (
+	"fmt"
+)
+
+// util/hnsw/RandomAccessVectorValues.java
+
+/*
+Vectors accessed by ordinal, from 0 to Size()-1. All vectors have
+the same Dimension().
+*/
+type VectorValues interface {
+	Size() int
+	Dimension() int
+	VectorValue(ord int) []float32
+}
+
+/* Similarity of two vectors; the higher, the nearer. */
+type Similarity func(v1, v2 []float32) float32
+
+// util/hnsw/OnHeapHnswGraph.java
+
+/*
+Hierarchical Navigable Small World graph. Every node is on level 0,
+and on each level up to its own top level; a node only has neighbors
+on the levels it is on. Searches start from the entry node, which is
+on the top level of the graph.
+*/
+type Graph struct {
+	// neighbors by node, then level
+	neighbors [][][]int
+	entry     int
+	maxLevel  int
+}
+
+/* Creates an empty graph. */
+func NewGraph() *Graph {
+	return &Graph{entry: -1, maxLevel: -1}
+}
+
+/*
+Creates a graph from the neighbors of each node on each of its
+levels, e.g. as read from the index.
+*/
+func NewGraphFrom(neighbors [][][]int, entry int) *Graph {
+	ans := &Graph{neighbors: neighbors, entry: entry, maxLevel: -1}
+	if entry >= 0 {
+		ans.maxLevel = len(neighbors[entry]) - 1
+	}
+	return ans
+}
+
+/* Returns the number of nodes. */
+func (g *Graph) Size() int { return len(g.neighbors) }
+
+/* Returns the number of levels. */
+func (g *Graph) NumLevels() int { return g.maxLevel + 1 }
+
+/* Returns the entry node of searches, or -1 if the graph is empty. */
+func (g *Graph) EntryNode() int { return g.entry }
+
+/* Returns the top level of the node. */
+func (g *Graph) NodeLevel(node int) int { return len(g.neighbors[node]) - 1 }
+
+/* Returns the neighbors of the node on the level. */
+func (g *Graph) Neighbors(level, node int) []int {
+	return g.neighbors[node][level]
+}
+
+/* Returns the nodes on the level, in order. */
+func (g *Graph) NodesOnLevel(level int) (nodes []int) {
+	for node, levels := range g.neighbors {
+		if len(levels) > level {
+			nodes = append(nodes, node)
+		}
+	}
+	return
+}
+
+/* Adds the next node, on levels 0 to level, without neighbors. */
+func (g *Graph) addNode(level int) int {
+	node := len(g.neighbors)
+	g.neighbors = append(g.neighbors, make([][]int, level+1))
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = node, level
+	}
+	return node
+}
+
+func (g *Graph) setNeighbors(level, node int, neighbors []int) {
+	g.neighbors[node][level] = neighbors
+}
+
+func (g *Graph) String() string {
+	return fmt.Sprintf("HnswGraph(size=%v, levels=%v, entry=%v)", g.Size(), g.NumLevels(), g.entry)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,95 @@
+package hnsw
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type randomVectors [][]float32
+
+func newRandomVectors(r *rand.Rand, size, dimension int) randomVectors {
+	ans := make(randomVectors, size)
+	for i := range ans {
+		ans[i] = make([]float32, dimension)
+		for j := range ans[i] {
+			ans[i][j] = r.Float32()*2 - 1
+		}
+	}
+	return ans
+}
+
+func (v randomVectors) Size() int                     { return len(v) }
+func (v randomVectors) Dimension() int                { return len(v[0]) }
+func (v randomVectors) VectorValue(ord int) []float32 { return v[ord] }
+
+/* Negated squared euclidean distance. */
+func euclidean(v1, v2 []float32) (ans float32) {
+	for i, v := range v1 {
+		ans -= (v - v2[i]) * (v - v2[i])
+	}
+	return
+}
+
+type evenBits int
+
+func (b evenBits) At(i int) bool { return i%2 == 0 }
+func (b evenBits) Length() int   { return int(b) }
+
+func TestSearchRecall(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	vectors := newRandomVectors(r, 2000, 8)
+	graph := NewBuilder(vectors, euclidean, 8, 50, 42).Build()
+	if graph.Size() != 2000 || graph.NumLevels() < 2 {
+		t.Fatalf("Unexpected graph: %v", graph)
+	}
+	for node := 0; node < graph.Size(); node++ {
+		if n := len(graph.Neighbors(0, node)); n == 0 || n > 16 {
+			t.Fatalf("Expected 1 to 16 neighbors for node %v on level 0, got %v", node, n)
+		}
+	}
+
+	const k = 10
+	for _, accept := range []evenBits{0, evenBits(2000)} {
+		found, total := 0, 0
+		for q := 0; q < 50; q++ {
+			target := newRandomVectors(r, 1, 8)[0]
+			var exact []Neighbor
+			for node, v := range vectors {
+				if accept == 0 || accept.At(node) {
+					exact = append(exact, Neighbor{node, euclidean(target, v)})
+				}
+			}
+			sort.Sort(byNearest(exact))
+			expected := make(map[int]bool)
+			for _, n := range exact[:k] {
+				expected[n.Node] = true
+			}
+
+			var acceptOrds util.Bits
+			if accept != 0 {
+				acceptOrds = accept
+			}
+			got := Search(target, k, 50, vectors, euclidean, graph, acceptOrds)
+			if len(got) != k {
+				t.Fatalf("Expected %v neighbors, got %v", k, len(got))
+			}
+			for i, n := range got {
+				if i > 0 && nearer(n, got[i-1]) {
+					t.Fatalf("Expected neighbors nearest first, got %v", got)
+				}
+				if accept != 0 && !accept.At(n.Node) {
+					t.Fatalf("Expected only accepted nodes, got %v", n.Node)
+				}
+				if expected[n.Node] {
+					found++
+				}
+			}
+			total += k
+		}
+		if recall := float64(found) / float64(total); recall < 0.9 {
+			t.Errorf("Expected recall >= 0.9 (filtered: %v), got %v", accept != 0, recall)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package hnsw
+
+import (
+	"container/heap"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// util/hnsw/HnswGraphSearcher.java
+
+/* A node of the graph, and its similarity with the search target. */
+type Neighbor struct {
+	Node  int
+	Score float32
+}
+
+/* Returns true if a is nearer than b; ties go to the smaller node. */
+func nearer(a, b Neighbor) bool {
+	if a.Score == b.Score {
+		return a.Node < b.Node
+	}
+	return a.Score > b.Score
+}
+
+/*
+Heap of neighbors, with the nearest on top if nearestFirst, or the
+farthest otherwise.
+*/
+type neighborQueue struct {
+	items        []Neighbor
+	nearestFirst bool
+}
+
+func (q *neighborQueue) Len() int { return len(q.items) }
+func (q *neighborQueue) Less(i, j int) bool {
+	if q.nearestFirst {
+		return nearer(q.items[i], q.items[j])
+	}
+	return nearer(q.items[j], q.items[i])
+}
+func (q *neighborQueue) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *neighborQueue) Push(x interface{}) { q.items = append(q.items, x.(Neighbor)) }
+func (q *neighborQueue) top() Neighbor      { return q.items[0] }
+func (q *neighborQueue) push(n Neighbor)    { heap.Push(q, n) }
+func (q *neighborQueue) pop() Neighbor      { return heap.Pop(q).(Neighbor) }
+func (q *neighborQueue) Pop() interface{} {
+	n := len(q.items)
+	ans := q.items[n-1]
+	q.items = q.items[:n-1]
+	return ans
+}
+
+type searcher struct {
+	vectors    VectorValues
+	similarity Similarity
+	graph      *Graph
+	// visited[node] == stamp if the node was visited by the current
+	// level search
+	visited []int
+	stamp   int
+}
+
+func newSearcher(vectors VectorValues, similarity Similarity, graph *Graph) *searcher {
+	return &searcher{vectors: vectors, similarity: similarity, graph: graph}
+}
+
+/*
+Returns the (up to) ef nearest nodes to target on the level, among
+those accepted (all if accept is nil), by a best-first traversal
+from the entry points. Rejected nodes are still traversed, so that
+the graph remains connected whatever the filter.
+*/
+func (s *searcher) searchLevel(target []float32, ef, level int,
+	entryPoints []int, accept util.Bits) *neighborQueue {
+
+	for len(s.visited) < s.graph.Size() {
+		s.visited = append(s.visited, 0)
+	}
+	s.stamp++
+
+	candidates := &neighborQueue{nearestFirst: true}
+	results := &neighborQueue{}
+	add := func(node int) {
+		n := Neighbor{node, s.similarity(target, s.vectors.VectorValue(node))}
+		if results.Len() >= ef && !nearer(n, results.top()) {
+			return
+		}
+		candidates.push(n)
+		if accept == nil || accept.At(node) {
+			if results.push(n); results.Len() > ef {
+				results.pop()
+			}
+		}
+	}
+	for _, ep := range entryPoints {
+		if s.visited[ep] != s.stamp {
+			s.visited[ep] = s.stamp
+			add(ep)
+		}
+	}
+	for candidates.Len() > 0 {
+		c := candidates.pop()
+		if results.Len() >= ef && nearer(results.top(), c) {
+			break // no candidate left can improve the results
+		}
+		for _, node := range s.graph.Neighbors(level, c.Node) {
+			if s.visited[node] != s.stamp {
+				s.visited[node] = s.stamp
+				add(node)
+			}
+		}
+	}
+	return results
+}
+
+type byNearest []Neighbor
+
+func (a byNearest) Len() int           { return len(a) }
+func (a byNearest) Less(i, j int) bool { return nearer(a[i], a[j]) }
+func (a byNearest) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+/* Returns the nodes of the queue, nearest first. */
+func sorted(q *neighborQueue) []Neighbor {
+	ans := append([]Neighbor(nil), q.items...)
+	sort.Sort(byNearest(ans))
+	return ans
+}
+
+/*
+Returns the topK nodes of the graph nearest to the target, nearest
+first. ef (at least topK) is the size of the queue of the search on
+level 0: the larger, the more accurate but the slower. Only nodes
+accepted by acceptOrds (all if nil) are returned.
+*/
+func Search(target []float32, topK, ef int, vectors VectorValues,
+	similarity Similarity, graph *Graph, acceptOrds util.Bits) []Neighbor {
+
+	if graph.EntryNode() == -1 {
+		return nil
+	}
+	if ef < topK {
+		ef = topK
+	}
+	s := newSearcher(vectors, similarity, graph)
+	eps := []int{graph.EntryNode()}
+	// greedy descent to the nearest node on level 1
+	for level := graph.NumLevels() - 1; level > 0; level-- {
+		eps = []int{s.searchLevel(target, 1, level, eps, nil).top().Node}
+	}
+	ans := sorted(s.searchLevel(target, ef, 0, eps, acceptOrds))
+	if len(ans) > topK {
+		ans = ans[:topK]
+	}
+	return ans
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"errors"
+	"fmt"
 )
 
 // store/DataInput.java
@@ -209,16 +210,50 @@ func (in *DataInputImpl) ReadString() (s string, err error) {
 	if err != nil {
 		return "", err
 	}
+	if length < 0 {
+		return "", errors.New(fmt.Sprintf("invalid string length: %v", length))
+	}
+	// a corrupted length must not allocate past the end of a file
+	if r, ok := in.Reader.(sizedReader); ok && int64(length) > r.Length()-r.FilePointer() {
+		return "", errors.New(fmt.Sprintf("string length %v past EOF: pos=%v length=%v",
+			length, r.FilePointer(), r.Length()))
+	}
 	bytes := make([]byte, length)
-	err = in.Reader.ReadBytes(bytes)
+	if err = in.Reader.ReadBytes(bytes); err != nil {
+		return "", err
+	}
 	return string(bytes), nil
 }
 
+/* A reader knowing its position and length, e.g. an IndexInput. */
+type sizedReader interface {
+	FilePointer() int64
+	Length() int64
+}
+
+/*
+Checks a count of the strings that follow, each taking at least a
+byte, against the rest of the input.
+*/
+func (in *DataInputImpl) checkCount(count int64) error {
+	if count < 0 {
+		return errors.New(fmt.Sprintf("invalid count: %v", count))
+	}
+	if r, ok := in.Reader.(sizedReader); ok && count > r.Length()-r.FilePointer() {
+		return errors.New(fmt.Sprintf("count %v past EOF: pos=%v length=%v",
+			count, r.FilePointer(), r.Length()))
+	}
+	return nil
+}
+
 func (in *DataInputImpl) ReadStringStringMap() (m map[string]string, err error) {
 	count, err := in.ReadInt()
 	if err != nil {
 		return nil, err
 	}
+	if err = in.checkCount(2 * int64(count)); err != nil {
+		return nil, err
+	}
 	m = make(map[string]string)
 	for i := int32(0); i < count; i++ {
 		key, err := in.ReadString()
@@ -239,6 +274,9 @@ func (in *DataInputImpl) ReadStringSet() (s map[string]bool, err error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = in.checkCount(int64(count)); err != nil {
+		return nil, err
+	}
 	s = make(map[string]bool)
 	for i := int32(0); i < count; i++ {
 		key, err := in.ReadString()
@@ -4,6 +4,8 @@ import (
 	_ "errors"
 	_ "fmt"
 	"io"
+	"os"
+	"runtime"
 )
 
 type CompoundError struct {
@@ -110,6 +112,24 @@ Ensure that any writes to the given file is written to the storage
 device that contains it.
 */
 func Fsync(fileToSync string, isDir bool) error {
-	// TODO enable fsync, now just ignored
-	return nil
+	// If the file is a directory we have to open read-only, for
+	// regular files we must open r/w for the fsync to have an effect
+	// on Windows.
+	flag := os.O_RDWR
+	if isDir {
+		flag = os.O_RDONLY
+	}
+	file, err := os.OpenFile(fileToSync, flag, 0)
+	if err == nil {
+		err = file.Sync()
+		if err2 := file.Close(); err == nil {
+			err = err2
+		}
+	}
+	if err != nil && isDir && runtime.GOOS == "windows" {
+		// Windows doesn't support opening or syncing a directory, which
+		// is fine: its metadata updates are durable anyway
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// util/NamedSPILoader.java
+
+/*
+Registry of named services of one kind, e.g. codecs or analyzers,
+standing in for Lucene Java's SPI mechanism: implementations register
+themselves by name, usually in an init() function, so that a name
+stored in the index or a configuration can be resolved to whichever
+package provides it, linked in with a blank import or a build tag.
+
+The registry is safe for concurrent use.
+*/
+type NamedSPILoader struct {
+	sync.RWMutex
+	kind     string
+	services map[string]interface{}
+}
+
+/* Creates an empty registry for services described by kind. */
+func NewNamedSPILoader(kind string) *NamedSPILoader {
+	return &NamedSPILoader{kind: kind, services: make(map[string]interface{})}
+}
+
+/*
+Registers service under name, replacing any service previously
+registered under the same name, so that the last registration wins.
+*/
+func (l *NamedSPILoader) Register(name string, service interface{}) {
+	assert2(name != "", "%v name must not be empty", l.kind)
+	assert2(service != nil, "%v '%v' must not be nil", l.kind, name)
+	l.Lock()
+	defer l.Unlock()
+	l.services[name] = service
+}
+
+/* Returns the service registered under name, or an error if none is. */
+func (l *NamedSPILoader) Lookup(name string) (interface{}, error) {
+	l.RLock()
+	defer l.RUnlock()
+	if service, ok := l.services[name]; ok {
+		return service, nil
+	}
+	return nil, errors.New(fmt.Sprintf(
+		"A %v with name '%v' does not exist. You need to link in the package providing it, e.g. with a blank import. The current registry supports the following names: %v",
+		l.kind, name, l.availableServices()))
+}
+
+/* Returns the registered names, sorted. */
+func (l *NamedSPILoader) AvailableServices() []string {
+	l.RLock()
+	defer l.RUnlock()
+	return l.availableServices()
+}
+
+func (l *NamedSPILoader) availableServices() []string {
+	ans := make([]string, 0, len(l.services))
+	for name, _ := range l.services {
+		ans = append(ans, name)
+	}
+	sort.Strings(ans)
+	return ans
+}
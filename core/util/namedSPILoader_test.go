@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestNamedSPILoader(t *testing.T) {
+	l := NewNamedSPILoader("Widget")
+	l.Register("b", 1)
+	l.Register("a", 2)
+	l.Register("b", 3) // the last registration wins
+
+	if v, err := l.Lookup("b"); err != nil || v != 3 {
+		t.Errorf("Expected 3, got %v (%v)", v, err)
+	}
+	if _, err := l.Lookup("c"); err == nil {
+		t.Error("Expected error for unknown name")
+	}
+	if names := l.AvailableServices(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Expected [a b], got %v", names)
+	}
+}
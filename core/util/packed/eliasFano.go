@@ -0,0 +1,354 @@
+package packed
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"math/bits"
+)
+
+// util/packed/EliasFanoEncoder.java
+
+const (
+	LOG2_LONG_SIZE = 6
+	// Value returned by EliasFanoDecoder when no more values are available
+	EF_NO_MORE_VALUES = -1
+)
+
+/*
+Encode a non decreasing sequence of non negative whole numbers in the
+Elias-Fano encoding that was introduced in the 1970's by Peter Elias
+and Robert Fano.
+
+The Elias-Fano encoding is a high bits / low bits representation of a
+monotonically increasing sequence of numValues > 0 natural numbers x[i]
+
+	0 <= x[0] <= x[1] <= ... <= x[numValues-2] <= x[numValues-1] <= upperBound
+
+where upperBound > 0 is an upper bound on the last value. The
+Elias-Fano encoding uses less than half a bit per encoded number more
+than the smallest representation that can encode any monotone sequence
+with the same bounds.
+
+The lower L bits of each x[i] are stored explicitly and contiguously
+in the lower-bits array, with L chosen as (log() base 2):
+
+	L = max(0, floor(log(upperBound/numValues)))
+
+The upper bits are stored in the upper-bits array as a sequence of
+unary-coded gaps (x[-1] = 0):
+
+	(x[i]/2**L) - (x[i-1]/2**L)
+
+The unary code encodes a natural number n by n 0 bits followed by a 1
+bit: 0...01. In total the upper bits take at most
+
+	2 * numValues
+
+bits, so less than 2 + L bits per encoded value are used.
+
+No index on the upper bits is kept, so advancing is linear in the
+number of values skipped, although it only decodes their upper bits.
+*/
+type EliasFanoEncoder struct {
+	numValues     int64
+	upperBound    int64
+	numLowBits    uint
+	lowerBitsMask int64
+	upperLongs    []uint64
+	lowerLongs    []uint64
+
+	lastEncoded int64
+	numEncoded  int64
+}
+
+/*
+Construct an Elias-Fano encoder. After construction, call EncodeNext()
+numValues times to encode a non decreasing sequence of non negative
+numbers, at most upperBound.
+*/
+func NewEliasFanoEncoder(numValues, upperBound int64) *EliasFanoEncoder {
+	if numValues < 0 {
+		panic(fmt.Sprintf("numValues should not be negative: %v", numValues))
+	}
+	if numValues > 0 && upperBound < 0 {
+		panic(fmt.Sprintf("upperBound should not be negative: %v when numValues > 0", upperBound))
+	}
+	if numValues == 0 {
+		upperBound = -1 // if there is no value, -1 is the best upper bound
+	}
+	ans := &EliasFanoEncoder{numValues: numValues, upperBound: upperBound}
+	if numValues > 0 { // numLowBits = max(0, floor(2log(upperBound/numValues)))
+		if lowBitsFac := upperBound / numValues; lowBitsFac > 0 {
+			ans.numLowBits = uint(bits.Len64(uint64(lowBitsFac))) - 1
+		}
+	}
+	ans.lowerBitsMask = int64(uint64(1)<<ans.numLowBits - 1)
+
+	numHighBitsClear := int64(0)
+	if upperBound > 0 {
+		numHighBitsClear = upperBound >> ans.numLowBits
+	}
+	numHighBits := numHighBitsClear + numValues
+	ans.upperLongs = make([]uint64, numLongsForBits(numHighBits))
+	ans.lowerLongs = make([]uint64, numLongsForBits(numValues*int64(ans.numLowBits)))
+	return ans
+}
+
+func numLongsForBits(numBits int64) int64 {
+	assert(numBits >= 0)
+	return int64(uint64(numBits+63) >> LOG2_LONG_SIZE)
+}
+
+/*
+Call at most numValues times to encode a non decreasing sequence of
+non negative numbers, at most upperBound.
+*/
+func (e *EliasFanoEncoder) EncodeNext(x int64) error {
+	if e.numEncoded >= e.numValues {
+		return fmt.Errorf("EncodeNext called more than %v times.", e.numValues)
+	}
+	if e.lastEncoded > x {
+		return fmt.Errorf("%v smaller than previous %v", x, e.lastEncoded)
+	}
+	if x > e.upperBound {
+		return fmt.Errorf("%v larger than upperBound %v", x, e.upperBound)
+	}
+	highValue := x >> e.numLowBits
+	e.encodeUpperBits(highValue)
+	e.encodeLowerBits(x & e.lowerBitsMask)
+	e.lastEncoded = x
+	e.numEncoded++
+	return nil
+}
+
+func (e *EliasFanoEncoder) encodeUpperBits(highValue int64) {
+	nextHighBitNum := e.numEncoded + highValue // sequence of unary gaps
+	e.upperLongs[nextHighBitNum>>LOG2_LONG_SIZE] |= uint64(1) << uint(nextHighBitNum&63)
+}
+
+func (e *EliasFanoEncoder) encodeLowerBits(lowerBits int64) {
+	if e.numLowBits == 0 {
+		return
+	}
+	bitPos := int64(e.numLowBits) * e.numEncoded
+	index := bitPos >> LOG2_LONG_SIZE
+	bitPosAtIndex := uint(bitPos & 63)
+	e.lowerLongs[index] |= uint64(lowerBits) << bitPosAtIndex
+	if bitPosAtIndex+e.numLowBits > 64 {
+		e.lowerLongs[index+1] = uint64(lowerBits) >> (64 - bitPosAtIndex)
+	}
+}
+
+/*
+Provide an indication that it is better to use an EliasFanoEncoder
+than a FixedBitSet to encode document identifiers.
+*/
+func EliasFanoSufficientlySmallerThanBitSet(numValues, upperBound int64) bool {
+	/* When (upperBound / 6) == numValues,
+	 * the number of bits per entry for the EliasFano encoding is 2 + ceil(2log(upperBound/numValues)) == 5.
+	 *
+	 * For intersecting two EliasFano sequences without index on the upper bits,
+	 * all (2 * 3 * numValues) upper bits are accessed.
+	 */
+	return upperBound > 4*64 && // prefer a bit set when it takes no more than 4 longs.
+		upperBound/7 > numValues // 6 + 1 to allow some room for the index.
+}
+
+func (e *EliasFanoEncoder) RamBytesUsed() int64 {
+	return util.AlignObjectSize(util.NUM_BYTES_OBJECT_HEADER+2*util.NUM_BYTES_OBJECT_REF+6*util.NUM_BYTES_LONG) +
+		sizeOfLongs(len(e.upperLongs)) + sizeOfLongs(len(e.lowerLongs))
+}
+
+func sizeOfLongs(n int) int64 {
+	return util.AlignObjectSize(util.NUM_BYTES_ARRAY_HEADER + util.NUM_BYTES_LONG*int64(n))
+}
+
+func (e *EliasFanoEncoder) String() string {
+	return fmt.Sprintf("EliasFanoEncoder numValues %v numEncoded %v upperBound %v lastEncoded %v numLowBits %v",
+		e.numValues, e.numEncoded, e.upperBound, e.lastEncoded, e.numLowBits)
+}
+
+// util/packed/EliasFanoDecoder.java
+
+/*
+A decoder for an EliasFanoEncoder. Values are decoded in increasing
+order, by NextValue() and AdvanceToValue().
+*/
+type EliasFanoDecoder struct {
+	efEncoder      *EliasFanoEncoder
+	efIndex        int64 // the index of the current value, -1 before the first
+	setBitForIndex int64 // the position of the high bit of the current value
+}
+
+func NewEliasFanoDecoder(efEncoder *EliasFanoEncoder) *EliasFanoDecoder {
+	return &EliasFanoDecoder{efEncoder, -1, -1}
+}
+
+/* The number of values encoded by the encoder. */
+func (d *EliasFanoDecoder) NumEncoded() int64 {
+	return d.efEncoder.numEncoded
+}
+
+/* Advances to the next set bit of the upper bits, and returns the high value. */
+func (d *EliasFanoDecoder) nextHighValue() int64 {
+	upperLongs := d.efEncoder.upperLongs
+	d.setBitForIndex++
+	i := d.setBitForIndex >> LOG2_LONG_SIZE
+	word := upperLongs[i] >> uint(d.setBitForIndex&63)
+	for word == 0 {
+		i++
+		word = upperLongs[i]
+		d.setBitForIndex = i << LOG2_LONG_SIZE
+	}
+	d.setBitForIndex += int64(bits.TrailingZeros64(word))
+	return d.setBitForIndex - d.efIndex
+}
+
+func (d *EliasFanoDecoder) lowValue() int64 {
+	e := d.efEncoder
+	if e.numLowBits == 0 {
+		return 0
+	}
+	bitPos := d.efIndex * int64(e.numLowBits)
+	index := bitPos >> LOG2_LONG_SIZE
+	bitPosAtIndex := uint(bitPos & 63)
+	value := e.lowerLongs[index] >> bitPosAtIndex
+	if bitPosAtIndex+e.numLowBits > 64 {
+		value |= e.lowerLongs[index+1] << (64 - bitPosAtIndex)
+	}
+	return int64(value) & e.lowerBitsMask
+}
+
+/*
+If another value is available after the current decoding index,
+return this value and increase the decoding index by 1. Otherwise
+return EF_NO_MORE_VALUES.
+*/
+func (d *EliasFanoDecoder) NextValue() int64 {
+	if d.efIndex+1 >= d.efEncoder.numEncoded {
+		d.efIndex = d.efEncoder.numEncoded
+		return EF_NO_MORE_VALUES
+	}
+	d.efIndex++
+	highValue := d.nextHighValue()
+	return highValue<<d.efEncoder.numLowBits | d.lowValue()
+}
+
+/*
+Given a target value, advance the decoding index to the first bigger
+or equal value and return it if it is available. Otherwise return
+EF_NO_MORE_VALUES. The lower bits are only decoded for the values with
+the same high value as the target.
+*/
+func (d *EliasFanoDecoder) AdvanceToValue(target int64) int64 {
+	highTarget := target >> d.efEncoder.numLowBits
+	for {
+		if d.efIndex+1 >= d.efEncoder.numEncoded {
+			d.efIndex = d.efEncoder.numEncoded
+			return EF_NO_MORE_VALUES
+		}
+		d.efIndex++
+		highValue := d.nextHighValue()
+		if highValue < highTarget {
+			continue
+		}
+		value := highValue<<d.efEncoder.numLowBits | d.lowValue()
+		if value >= target {
+			return value
+		}
+	}
+}
+
+// util/packed/EliasFanoDocIdSet.java
+
+/*
+A DocIdSet in Elias-Fano encoding. Compared to a FixedBitSet, it uses
+about 2+log2(maxDoc/cardinality) bits per document, so it is smaller
+for sparse sets, see EliasFanoSufficientlySmallerThanBitSet(). It does
+not support random access.
+*/
+type EliasFanoDocIdSet struct {
+	efEncoder *EliasFanoEncoder
+}
+
+/*
+Construct an EliasFanoDocIdSet. For efficient encoding, the
+parameters should be chosen as low as possible.
+
+upperBound is at least the highest non negative doc id that will be
+encoded.
+*/
+func NewEliasFanoDocIdSet(numValues, upperBound int) *EliasFanoDocIdSet {
+	return &EliasFanoDocIdSet{NewEliasFanoEncoder(int64(numValues), int64(upperBound))}
+}
+
+/* Encode the document ids from a DocIdSetIterator. */
+func (s *EliasFanoDocIdSet) EncodeFromDisi(it DocIdSetIterator) error {
+	for s.efEncoder.numEncoded < s.efEncoder.numValues {
+		x, err := it.NextDoc()
+		if err != nil {
+			return err
+		}
+		if x == NO_MORE_DOCS {
+			return fmt.Errorf("EliasFanoDocIdSet disi: %v has only %v doc ids. Cannot encode %v",
+				it, s.efEncoder.numEncoded, s.efEncoder.numValues)
+		}
+		if err = s.efEncoder.EncodeNext(int64(x)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EliasFanoDocIdSet) Iterator() (DocIdSetIterator, error) {
+	return &eliasFanoIterator{NewEliasFanoDecoder(s.efEncoder), -1}, nil
+}
+
+/* Random access is not supported. */
+func (s *EliasFanoDocIdSet) Bits() util.Bits {
+	return nil
+}
+
+func (s *EliasFanoDocIdSet) IsCacheable() bool {
+	return true
+}
+
+func (s *EliasFanoDocIdSet) RamBytesUsed() int64 {
+	return util.AlignObjectSize(util.NUM_BYTES_OBJECT_HEADER+util.NUM_BYTES_OBJECT_REF) +
+		s.efEncoder.RamBytesUsed()
+}
+
+func (s *EliasFanoDocIdSet) String() string {
+	return fmt.Sprintf("EliasFanoDocIdSet(%v)", s.efEncoder)
+}
+
+type eliasFanoIterator struct {
+	efDecoder *EliasFanoDecoder
+	curDocId  int
+}
+
+func (it *eliasFanoIterator) setCurDocId(value int64) int {
+	if value == EF_NO_MORE_VALUES {
+		it.curDocId = NO_MORE_DOCS
+	} else {
+		it.curDocId = int(value)
+	}
+	return it.curDocId
+}
+
+func (it *eliasFanoIterator) DocId() int {
+	return it.curDocId
+}
+
+func (it *eliasFanoIterator) NextDoc() (int, error) {
+	return it.setCurDocId(it.efDecoder.NextValue()), nil
+}
+
+func (it *eliasFanoIterator) Advance(target int) (int, error) {
+	return it.setCurDocId(it.efDecoder.AdvanceToValue(int64(target))), nil
+}
+
+func (it *eliasFanoIterator) Cost() int64 {
+	return it.efDecoder.NumEncoded()
+}
@@ -12,7 +12,10 @@ const MIN_PAGE_SIZE = 64
 const MAX_PAGE_SIZE = 1 << 20
 
 type PackedLongValues interface {
+	util.Accountable
 	Size() int64
+	// Get value at index.
+	Get(index int64) int64
 	Iterator() func() (interface{}, bool)
 }
 
@@ -23,6 +26,11 @@ type PackedLongValuesBuilder interface {
 	Add(int64) PackedLongValuesBuilder
 }
 
+/* Return a new builder which packs values page by page. */
+func PackedBuilder(acceptableOverheadRatio float32) PackedLongValuesBuilder {
+	return newPackedLongValuesBuilder(DEFAULT_PAGE_SIZE, acceptableOverheadRatio)
+}
+
 func DeltaPackedBuilder(acceptableOverheadRatio float32) PackedLongValuesBuilder {
 	return NewDeltaPackedLongValuesBuilder(DEFAULT_PAGE_SIZE, acceptableOverheadRatio)
 }
@@ -51,6 +59,17 @@ func (p *PackedLongValuesImpl) Size() int64 {
 	return p.size
 }
 
+func (p *PackedLongValuesImpl) Get(index int64) int64 {
+	assert(index >= 0 && index < p.size)
+	block := int(index >> uint(p.pageShift))
+	element := int(index & int64(p.pageMask))
+	return p.values[block].Get(element)
+}
+
+func (p *PackedLongValuesImpl) RamBytesUsed() int64 {
+	return p.ramBytesUsed
+}
+
 func (p *PackedLongValuesImpl) decodeBlock(block int, dest []int64) int {
 	vals := p.values[block]
 	size := vals.Size()
@@ -196,7 +215,7 @@ func (b *PackedLongValuesBuilderImpl) packFrom(values []int64,
 			bitsRequired = BitsRequired(maxValue)
 		}
 		mutable := MutableFor(len(values), bitsRequired, acceptableOverheadRatio)
-		for i := 0; i < len(values); i++ {
+		for i := 0; i < len(values); {
 			i += mutable.setBulk(i, values[i:])
 		}
 		b.values[block] = mutable
@@ -204,7 +223,11 @@ func (b *PackedLongValuesBuilderImpl) packFrom(values []int64,
 }
 
 func (b *PackedLongValuesBuilderImpl) grow(newBlockCount int) {
-	panic("niy")
+	b.ramBytesUsed -= util.ShallowSizeOf(b.values)
+	values := make([]PackedIntsReader, newBlockCount)
+	copy(values, b.values)
+	b.values = values
+	b.ramBytesUsed += util.ShallowSizeOf(b.values)
 }
 
 // util/packed/DeltaPackedLongValues.java
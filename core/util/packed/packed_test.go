@@ -59,3 +59,55 @@ func TestUnsignedBitsRequired(t *testing.T) {
 		t.Errorf("-158146830731166066 -> 64bit (got %v)", n)
 	}
 }
+
+func TestPacked64BulkUnaligned(t *testing.T) {
+	for _, bpv := range []uint32{3, 7, 13, 21} {
+		p := newPacked64(500, bpv)
+		values := make([]int64, 500)
+		for i := range values {
+			values[i] = int64(i*31) & int64(MaxValue(int(bpv)))
+		}
+		for i := 5; i < len(values); {
+			i += p.setBulk(i, values[i:])
+		}
+		actual := make([]int64, len(values))
+		for i := 5; i < len(actual); {
+			i += p.getBulk(i, actual[i:])
+		}
+		for i := 5; i < len(values); i++ {
+			if actual[i] != values[i] || p.Get(i) != values[i] {
+				t.Fatalf("bpv=%v: expected %v at %v, but got %v/%v", bpv, values[i], i, actual[i], p.Get(i))
+			}
+		}
+	}
+}
+
+func TestPackedLongValues(t *testing.T) {
+	for _, builder := range []PackedLongValuesBuilder{
+		PackedBuilder(PackedInts.COMPACT),
+		DeltaPackedBuilder(PackedInts.COMPACT),
+	} {
+		var expected []int64
+		for i := 0; i < 2500; i++ {
+			v := int64(i % 37)
+			if i%100 == 0 {
+				v = 1000 + int64(i)
+			}
+			builder.Add(v)
+			expected = append(expected, v)
+		}
+		values := builder.Build()
+		if values.Size() != int64(len(expected)) {
+			t.Fatalf("expected %v values, but got %v", len(expected), values.Size())
+		}
+		iter := values.Iterator()
+		for i, v := range expected {
+			if actual := values.Get(int64(i)); actual != v {
+				t.Fatalf("expected %v at %v, but got %v", v, i, actual)
+			}
+			if actual, _ := iter(); actual.(int64) != v {
+				t.Fatalf("expected %v at %v from the iterator, but got %v", v, i, actual)
+			}
+		}
+	}
+}
@@ -33,7 +33,7 @@ const (
 /* Aligns an object size to be the next multiple of NUM_BYTES_OBJECT_ALIGNMENT */
 func AlignObjectSize(size int64) int64 {
 	size += NUM_BYTES_OBJECT_ALIGNMENT - 1
-	return size - (size & NUM_BYTES_OBJECT_ALIGNMENT)
+	return size - (size % NUM_BYTES_OBJECT_ALIGNMENT)
 }
 
 /* Returns the size in bytes of the object. */
@@ -79,9 +79,10 @@ func ShallowSizeOf(obj interface{}) int64 {
 }
 
 func ShallowSizeOfInstance(clazz reflect.Type) int64 {
-	// TODO later
-	fmt.Printf("[TODO] ShallowSizeOfInstance(%v)\n", clazz)
-	return 0
+	for clazz.Kind() == reflect.Ptr {
+		clazz = clazz.Elem()
+	}
+	return AlignObjectSize(NUM_BYTES_OBJECT_HEADER + int64(clazz.Size()))
 }
 
 /* Return shallow size of any array */
@@ -95,10 +96,30 @@ func shallowSizeOfArray(arr interface{}) int64 {
 			reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32,
 			reflect.Float64, reflect.Complex64, reflect.Complex128:
 			// primitive type
-			size += int64(length) * int64(v.Elem().Type().Size())
+			size += int64(length) * int64(v.Type().Elem().Size())
 		default:
 			size += int64(length * NUM_BYTES_OBJECT_REF)
 		}
 	}
 	return AlignObjectSize(size)
 }
+
+const (
+	ONE_KB = 1024
+	ONE_MB = ONE_KB * ONE_KB
+	ONE_GB = ONE_KB * ONE_MB
+)
+
+/* Returns size in human-readable units (GB, MB, KB or bytes). */
+func HumanReadableUnits(bytes int64) string {
+	switch {
+	case bytes/ONE_GB > 0:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/ONE_GB)
+	case bytes/ONE_MB > 0:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/ONE_MB)
+	case bytes/ONE_KB > 0:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/ONE_KB)
+	default:
+		return fmt.Sprintf("%v bytes", bytes)
+	}
+}
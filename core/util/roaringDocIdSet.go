@@ -0,0 +1,385 @@
+package util
+
+import (
+	"fmt"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"sort"
+)
+
+// util/RoaringDocIdSet.java
+
+const (
+	ROARING_BLOCK_SIZE       = 1 << 16
+	ROARING_MAX_ARRAY_LENGTH = 1 << 12 // the maximum length for an array, beyond that point we switch to a bitset
+)
+
+/*
+DocIdSet implementation inspired from http://roaringbitmap.org/
+
+The space is divided into blocks of 2^16 bits and each block is
+encoded independently. In each block, if less than 2^12 bits are set,
+then documents are simply stored in a sorted []uint16. Otherwise a
+FixedBitSet is used, unless more than 2^16-2^12 bits are set, in which
+case the documents which are NOT set are stored in a sorted []uint16.
+
+Unlike FixedBitSet, its memory usage is proportional to the number of
+documents in the set rather than to maxDoc, as long as the set is not
+very dense. It does not support random access.
+*/
+type RoaringDocIdSet struct {
+	docIdSets    []roaringBlock // nil for empty blocks
+	cardinality  int
+	ramBytesUsed int64
+}
+
+/* A block of up to ROARING_BLOCK_SIZE docs, numbered from 0 */
+type roaringBlock interface {
+	Accountable
+	iterator() DocIdSetIterator
+}
+
+func newRoaringDocIdSet(docIdSets []roaringBlock, cardinality int) *RoaringDocIdSet {
+	ramBytesUsed := AlignObjectSize(NUM_BYTES_OBJECT_HEADER+NUM_BYTES_OBJECT_REF+NUM_BYTES_INT+NUM_BYTES_LONG) +
+		AlignObjectSize(NUM_BYTES_ARRAY_HEADER+NUM_BYTES_OBJECT_REF*int64(len(docIdSets)))
+	for _, set := range docIdSets {
+		if set != nil {
+			ramBytesUsed += set.RamBytesUsed()
+		}
+	}
+	return &RoaringDocIdSet{docIdSets, cardinality, ramBytesUsed}
+}
+
+/* Returns the number of documents in the set. */
+func (s *RoaringDocIdSet) Cardinality() int {
+	return s.cardinality
+}
+
+func (s *RoaringDocIdSet) RamBytesUsed() int64 {
+	return s.ramBytesUsed
+}
+
+func (s *RoaringDocIdSet) Iterator() (DocIdSetIterator, error) {
+	if s.cardinality == 0 {
+		return nil, nil
+	}
+	return &roaringIterator{s, -1, -1, emptyIterator{}}, nil
+}
+
+/* Random access is not supported. */
+func (s *RoaringDocIdSet) Bits() Bits {
+	return nil
+}
+
+func (s *RoaringDocIdSet) IsCacheable() bool {
+	return true
+}
+
+func (s *RoaringDocIdSet) String() string {
+	return fmt.Sprintf("RoaringDocIdSet(cardinality=%v)", s.cardinality)
+}
+
+type roaringIterator struct {
+	owner *RoaringDocIdSet
+	block int
+	doc   int
+	sub   DocIdSetIterator
+}
+
+func (it *roaringIterator) DocId() int {
+	return it.doc
+}
+
+func (it *roaringIterator) NextDoc() (int, error) {
+	subNext, err := it.sub.NextDoc()
+	if err != nil {
+		return 0, err
+	}
+	if subNext == NO_MORE_DOCS {
+		return it.firstDocFromNextBlock()
+	}
+	it.doc = (it.block << 16) | subNext
+	return it.doc, nil
+}
+
+func (it *roaringIterator) Advance(target int) (int, error) {
+	targetBlock := int(uint(target) >> 16)
+	if targetBlock != it.block {
+		it.block = targetBlock
+		if it.block >= len(it.owner.docIdSets) {
+			it.sub = nil
+			it.doc = NO_MORE_DOCS
+			return it.doc, nil
+		}
+		if it.owner.docIdSets[it.block] == nil {
+			return it.firstDocFromNextBlock()
+		}
+		it.sub = it.owner.docIdSets[it.block].iterator()
+	}
+	subNext, err := it.sub.Advance(target & 0xFFFF)
+	if err != nil {
+		return 0, err
+	}
+	if subNext == NO_MORE_DOCS {
+		return it.firstDocFromNextBlock()
+	}
+	it.doc = (it.block << 16) | subNext
+	return it.doc, nil
+}
+
+func (it *roaringIterator) firstDocFromNextBlock() (int, error) {
+	for {
+		it.block++
+		if it.block >= len(it.owner.docIdSets) {
+			it.sub = nil
+			it.doc = NO_MORE_DOCS
+			return it.doc, nil
+		}
+		if set := it.owner.docIdSets[it.block]; set != nil {
+			it.sub = set.iterator()
+			subNext, err := it.sub.NextDoc()
+			if err != nil {
+				return 0, err
+			}
+			assert(subNext != NO_MORE_DOCS)
+			it.doc = (it.block << 16) | subNext
+			return it.doc, nil
+		}
+	}
+}
+
+func (it *roaringIterator) Cost() int64 {
+	return int64(it.owner.cardinality)
+}
+
+type emptyIterator struct{}
+
+func (it emptyIterator) DocId() int               { return NO_MORE_DOCS }
+func (it emptyIterator) NextDoc() (int, error)    { return NO_MORE_DOCS, nil }
+func (it emptyIterator) Advance(int) (int, error) { return NO_MORE_DOCS, nil }
+func (it emptyIterator) Cost() int64              { return 0 }
+
+/* Sorted doc IDs of a sparse block */
+type shortArrayBlock []uint16
+
+func (b shortArrayBlock) RamBytesUsed() int64 {
+	return AlignObjectSize(NUM_BYTES_ARRAY_HEADER + NUM_BYTES_SHORT*int64(len(b)))
+}
+
+func (b shortArrayBlock) iterator() DocIdSetIterator {
+	return &shortArrayIterator{b, -1, -1}
+}
+
+type shortArrayIterator struct {
+	docIds shortArrayBlock
+	i      int
+	doc    int
+}
+
+func (it *shortArrayIterator) DocId() int {
+	return it.doc
+}
+
+func (it *shortArrayIterator) NextDoc() (int, error) {
+	if it.i++; it.i >= len(it.docIds) {
+		it.doc = NO_MORE_DOCS
+	} else {
+		it.doc = int(it.docIds[it.i])
+	}
+	return it.doc, nil
+}
+
+func (it *shortArrayIterator) Advance(target int) (int, error) {
+	from := it.i + 1
+	it.i = from + sort.Search(len(it.docIds)-from, func(j int) bool {
+		return int(it.docIds[from+j]) >= target
+	})
+	if it.i >= len(it.docIds) {
+		it.doc = NO_MORE_DOCS
+	} else {
+		it.doc = int(it.docIds[it.i])
+	}
+	return it.doc, nil
+}
+
+func (it *shortArrayIterator) Cost() int64 {
+	return int64(len(it.docIds))
+}
+
+/* A very dense block, stored as the sorted doc IDs which are NOT in the set */
+type notShortArrayBlock struct {
+	maxDoc   int
+	excluded shortArrayBlock
+}
+
+func (b *notShortArrayBlock) RamBytesUsed() int64 {
+	return AlignObjectSize(NUM_BYTES_OBJECT_HEADER+NUM_BYTES_INT+NUM_BYTES_OBJECT_REF) +
+		b.excluded.RamBytesUsed()
+}
+
+func (b *notShortArrayBlock) iterator() DocIdSetIterator {
+	return &notIterator{b.maxDoc, b.excluded.iterator(), -1, -1}
+}
+
+type notIterator struct {
+	maxDoc         int
+	it             DocIdSetIterator
+	doc            int
+	nextSkippedDoc int
+}
+
+func (it *notIterator) DocId() int {
+	return it.doc
+}
+
+func (it *notIterator) NextDoc() (int, error) {
+	return it.Advance(it.doc + 1)
+}
+
+func (it *notIterator) Advance(target int) (int, error) {
+	var err error
+	it.doc = target
+	if it.doc > it.nextSkippedDoc {
+		if it.nextSkippedDoc, err = it.it.Advance(it.doc); err != nil {
+			return 0, err
+		}
+	}
+	for {
+		if it.doc >= it.maxDoc {
+			it.doc = NO_MORE_DOCS
+			return it.doc, nil
+		}
+		assert(it.doc <= it.nextSkippedDoc)
+		if it.doc != it.nextSkippedDoc {
+			return it.doc, nil
+		}
+		it.doc++
+		if it.nextSkippedDoc, err = it.it.NextDoc(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (it *notIterator) Cost() int64 {
+	return int64(it.maxDoc)
+}
+
+/* A block of medium density, stored as a bitset */
+type bitSetBlock struct {
+	*FixedBitSet
+}
+
+func (b bitSetBlock) iterator() DocIdSetIterator {
+	return NewFixedBitSetIterator(b.FixedBitSet)
+}
+
+/* A builder of RoaringDocIdSets. */
+type RoaringDocIdSetBuilder struct {
+	maxDoc                  int
+	sets                    []roaringBlock
+	cardinality             int
+	lastDocId               int
+	currentBlock            int
+	currentBlockCardinality int
+
+	// We start by filling the buffer and when it's full we copy the
+	// content of the buffer to the FixedBitSet and put further
+	// documents in that bitset
+	buffer      []uint16
+	denseBuffer *FixedBitSet
+}
+
+func NewRoaringDocIdSetBuilder(maxDoc int) *RoaringDocIdSetBuilder {
+	return &RoaringDocIdSetBuilder{
+		maxDoc:       maxDoc,
+		sets:         make([]roaringBlock, int(uint(maxDoc+(1<<16)-1)>>16)),
+		lastDocId:    -1,
+		currentBlock: -1,
+		buffer:       make([]uint16, ROARING_MAX_ARRAY_LENGTH),
+	}
+}
+
+func (b *RoaringDocIdSetBuilder) flush() {
+	assert(b.currentBlockCardinality <= ROARING_BLOCK_SIZE)
+	if b.currentBlockCardinality <= ROARING_MAX_ARRAY_LENGTH {
+		// Use sparse encoding
+		assert(b.denseBuffer == nil)
+		if b.currentBlockCardinality > 0 {
+			docIds := make(shortArrayBlock, b.currentBlockCardinality)
+			copy(docIds, b.buffer)
+			b.sets[b.currentBlock] = docIds
+		}
+	} else {
+		assert(b.denseBuffer != nil)
+		assert(b.denseBuffer.Cardinality() == b.currentBlockCardinality)
+		if b.denseBuffer.Length() == ROARING_BLOCK_SIZE &&
+			ROARING_BLOCK_SIZE-b.currentBlockCardinality < ROARING_MAX_ARRAY_LENGTH {
+			// Doc ids are very dense, inverse the encoding
+			excludedDocs := make(shortArrayBlock, 0, ROARING_BLOCK_SIZE-b.currentBlockCardinality)
+			for doc := 0; doc < ROARING_BLOCK_SIZE; doc++ {
+				if !b.denseBuffer.At(doc) {
+					excludedDocs = append(excludedDocs, uint16(doc))
+				}
+			}
+			b.sets[b.currentBlock] = &notShortArrayBlock{ROARING_BLOCK_SIZE, excludedDocs}
+		} else {
+			// Neither sparse nor super dense, use a fixed bit set
+			b.sets[b.currentBlock] = bitSetBlock{b.denseBuffer}
+		}
+		b.denseBuffer = nil
+	}
+
+	b.cardinality += b.currentBlockCardinality
+	b.currentBlockCardinality = 0
+}
+
+/* Add a new doc-id to this builder. NOTE: doc ids must be added in order. */
+func (b *RoaringDocIdSetBuilder) Add(docId int) *RoaringDocIdSetBuilder {
+	assert2(docId > b.lastDocId, "Doc ids must be added in-order, got %v which is <= lastDocID=%v", docId, b.lastDocId)
+	block := int(uint(docId) >> 16)
+	if block != b.currentBlock {
+		// we went to a different block, let's flush what we buffered and start from fresh
+		b.flush()
+		b.currentBlock = block
+	}
+
+	if b.currentBlockCardinality < ROARING_MAX_ARRAY_LENGTH {
+		b.buffer[b.currentBlockCardinality] = uint16(docId)
+	} else {
+		if b.denseBuffer == nil {
+			// the buffer is full, let's move to a fixed bit set
+			numBits := b.maxDoc - (block << 16)
+			if numBits > ROARING_BLOCK_SIZE {
+				numBits = ROARING_BLOCK_SIZE
+			}
+			b.denseBuffer = NewFixedBitSetOf(numBits)
+			for _, doc := range b.buffer {
+				b.denseBuffer.Set(int(doc))
+			}
+		}
+		b.denseBuffer.Set(docId & 0xFFFF)
+	}
+
+	b.lastDocId = docId
+	b.currentBlockCardinality++
+	return b
+}
+
+/* Add the content of the provided DocIdSetIterator. */
+func (b *RoaringDocIdSetBuilder) AddIterator(it DocIdSetIterator) error {
+	for {
+		doc, err := it.NextDoc()
+		if err != nil {
+			return err
+		}
+		if doc == NO_MORE_DOCS {
+			return nil
+		}
+		b.Add(doc)
+	}
+}
+
+/* Build an instance. */
+func (b *RoaringDocIdSetBuilder) Build() *RoaringDocIdSet {
+	b.flush()
+	return newRoaringDocIdSet(b.sets, b.cardinality)
+}
@@ -15,3 +15,21 @@ func verifyMurmurHash3_x86_32(t *testing.T, data []byte, seed, expected uint32)
 		t.Error("Fail to do hash using MurmurHash3_x86_32")
 	}
 }
+
+func TestStartsWith(t *testing.T) {
+	for _, c := range []struct {
+		ref, prefix string
+		expected    bool
+	}{
+		{"foobar", "foo", true},
+		{"foobar", "foobar", true},
+		{"foobar", "", true},
+		{"foo", "foobar", false},
+		{"foobar", "fob", false},
+		{"", "f", false},
+	} {
+		if got := StartsWith([]byte(c.ref), []byte(c.prefix)); got != c.expected {
+			t.Errorf("Expected StartsWith(%q, %q) to be %v", c.ref, c.prefix, c.expected)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+/*
+Tracing API, recording the time spent in searches and indexing as
+spans, e.g. of OpenTelemetry through an adapter of the application,
+so that services get distributed tracing of Lucene internals.
+
+Spans are started from the Tracer of the component, e.g. of an
+IndexSearcher, as children of the span of the given context, e.g. of
+the request being served, and returned with a context holding them,
+so that the spans started from it, e.g. of the segments searched, are
+their children.
+*/
+type Tracer interface {
+	// Starts a span under the span of ctx, if any, which must be ended.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	// Returns true if the span is recorded, i.e. if its attributes are
+	// worth computing.
+	IsRecording() bool
+	// Sets an attribute, whose value is a string, a number or a bool.
+	SetAttribute(key string, value interface{})
+	// Ends the span, recording err as its status if non-nil.
+	End(err error)
+}
+
+// Instance of Tracer that records nothing.
+var NO_TRACING = NoTracing(true)
+
+type NoTracing bool
+
+func (t NoTracing) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, t
+}
+func (t NoTracing) IsRecording() bool                          { return false }
+func (t NoTracing) SetAttribute(key string, value interface{}) {}
+func (t NoTracing) End(err error)                              {}
+
+var defaultTracer Tracer = NO_TRACING
+var defaultTracerLock = &sync.Mutex{}
+
+// The default Tracer used by newly instantiated classes.
+func DefaultTracer() Tracer {
+	defaultTracerLock.Lock() // synchronized
+	defer defaultTracerLock.Unlock()
+	return defaultTracer
+}
+
+/*
+Sets the default Tracer used by newly instantiated classes. It cannot
+be nil, to disable tracing use NO_TRACING.
+*/
+func SetDefaultTracer(tracer Tracer) {
+	defaultTracerLock.Lock() // synchronized
+	defer defaultTracerLock.Unlock()
+	assert2(tracer != nil, "Cannot set Tracer default implementation to nil. To disable tracing use NO_TRACING.")
+	defaultTracer = tracer
+}
@@ -63,7 +63,7 @@ func ParseVersion(version string) (ver Version, err error) {
 			if prerelease, err = parse(parts[3], version, "prerelease"); err != nil {
 				return
 			}
-			if prerelease == 0 {
+			if prerelease < 1 || prerelease > 2 {
 				err = errors.New(fmt.Sprintf(
 					"Invalid value %v for prerelease; should be 1 or 2 (got: %v)",
 					prerelease, version))
@@ -72,6 +72,17 @@ func ParseVersion(version string) (ver Version, err error) {
 		}
 	}
 
+	// newVersion() panics on illegal versions
+	for i, n := range []int{major, minor, bugfix} {
+		if n < 0 || n > 255 {
+			return Version{}, errors.New(fmt.Sprintf(
+				"Illegal %v version: %v (got: %v)", []string{"major", "minor", "bugfix"}[i], n, version))
+		}
+	}
+	if prerelease != 0 && (minor != 0 || bugfix != 0) {
+		return Version{}, errors.New(fmt.Sprintf(
+			"Prerelease version only supported with major release (got: %v)", version))
+	}
 	return newVersion(major, minor, bugfix, prerelease), nil
 }
 
@@ -3,17 +3,25 @@ package core_test
 import (
 	"fmt"
 	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/analysis"
 	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	"github.com/balzaczyy/golucene/core/codec/spi"
 	docu "github.com/balzaczyy/golucene/core/document"
 	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
 	"github.com/balzaczyy/golucene/core/search"
 	"github.com/balzaczyy/golucene/core/store"
 	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser"
+	"github.com/balzaczyy/golucene/queryparser/classic"
 	// . "github.com/balzaczyy/golucene/test_framework"
 	// "github.com/balzaczyy/golucene/test_framework/analysis"
 	// . "github.com/balzaczyy/golucene/test_framework/util"
 	. "github.com/balzaczyy/gounit"
+	"math"
+	"math/rand"
 	"os"
+	"sort"
 	"testing"
 )
 
@@ -127,6 +135,720 @@ func isSimilar(f1, f2, delta float32) bool {
 	return diff >= 0 && diff < delta || diff < 0 && -diff < delta
 }
 
+func TestPhraseOnDocsOnlyField(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	analyzer := std.NewStandardAnalyzer()
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, analyzer))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	ft := docu.NewFieldType()
+	ft.SetIndexed(true)
+	ft.SetTokenized(true)
+	ft.SetOmitNorms(true)
+	ft.SetIndexOptions(INDEX_OPT_DOCS_ONLY)
+	ft.Freeze()
+	for _, text := range []string{"quick brown fox", "quick lazy dog"} {
+		d := docu.NewDocument()
+		d.Add(docu.NewFieldFromString("tags", text, ft))
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect no positions for tags").Assert(!search.HasPositions(reader, "tags"))
+	It(t).Should("expect positions for body").Assert(search.HasPositions(reader, "body"))
+	err = search.CheckPositionsIndexed(reader.Leaves()[0], "tags", "PhraseQuery")
+	_, ok := err.(*search.PositionsNotIndexedError)
+	It(t).Should("expect PositionsNotIndexedError, but %v", err).Assert(ok)
+
+	qp := classic.NewQueryParser(util.VERSION_LATEST, "tags", analyzer)
+	qp.SetHasPositions(func(field string) bool {
+		return search.HasPositions(reader, field)
+	})
+	_, err = qp.Parse(`"quick fox"`)
+	It(t).Should("expect error for phrase on tags").Assert(err != nil)
+
+	qp.SetDegradePhrases(true)
+	q, err := qp.Parse(`"quick fox"`)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	docs, err := search.NewIndexSearcher(reader).SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 1 hit, but %v", docs.TotalHits).Assert(docs.TotalHits == 1)
+}
+
+func TestKnnVectorQuery(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i, v := range [][]float32{{1, 0}, {0.9, 0.1}, {0, 1}, {-1, 0}, {0.7, 0.7}} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewStringField("color", []string{"red", "blue"}[i%2], docu.STORE_NO))
+		d.Add(docu.NewVectorField("vector", v, VECTOR_SIMILARITY_COSINE))
+		d.Add(docu.NewVectorField("unit", v, VECTOR_SIMILARITY_DOT_PRODUCT))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	// the dimension of a field cannot change
+	d := docu.NewDocument()
+	d.Add(docu.NewStringField("id", "5", docu.STORE_YES))
+	d.Add(docu.NewVectorField("vector", []float32{1, 0, 0}, VECTOR_SIMILARITY_COSINE))
+	err = writer.AddDocument(d.Fields())
+	It(t).Should("expect dimension change error").Assert(err != nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	ids := func(q search.Query) (ans []string) {
+		docs, err := ss.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for _, hit := range docs.ScoreDocs {
+			d, err := reader.Document(hit.Doc)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			ans = append(ans, d.Get("id"))
+		}
+		return
+	}
+
+	target := []float32{1, 0.05}
+	got := ids(search.NewKnnVectorQuery("vector", target, 3, nil))
+	It(t).Should("expect [0 1 4], but %v", got).Assert(fmt.Sprint(got) == "[0 1 4]")
+
+	// the filter is applied before selecting the top K
+	blue := search.NewQueryWrapperFilter(search.NewTermQuery(index.NewTerm("color", "blue")))
+	got = ids(search.NewKnnVectorQuery("vector", target, 3, blue))
+	It(t).Should("expect [1 3], but %v", got).Assert(fmt.Sprint(got) == "[1 3]")
+
+	docs, err := ss.SearchTop(search.NewKnnVectorQuery("unit", []float32{0, 1}, 1, nil), 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect doc 2 with score 1, but %v", docs.ScoreDocs).Assert(
+		len(docs.ScoreDocs) == 1 && docs.ScoreDocs[0].Doc == 2 && docs.ScoreDocs[0].Score == 1)
+
+	_, err = ss.SearchTop(search.NewKnnVectorQuery("vector", []float32{1, 0, 0}, 1, nil), 10)
+	It(t).Should("expect dimension mismatch error").Assert(err != nil)
+}
+
+func TestApproximateKnnVectorQuery(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	r := rand.New(rand.NewSource(1))
+	randomVector := func() []float32 {
+		v := make([]float32, 8)
+		for i := range v {
+			v[i] = r.Float32()*2 - 1
+		}
+		return v
+	}
+	for i := 0; i < 1000; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("group", []string{"common", "rare"}[i/995], docu.STORE_NO))
+		d.Add(docu.NewVectorField("vector", randomVector(), VECTOR_SIMILARITY_COSINE))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	docs := func(q search.Query) map[int]bool {
+		hits, err := ss.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ans := make(map[int]bool)
+		for _, hit := range hits.ScoreDocs {
+			ans[hit.Doc] = true
+		}
+		return ans
+	}
+
+	found := 0
+	for i := 0; i < 10; i++ {
+		target := randomVector()
+		expected := docs(search.NewKnnVectorQuery("vector", target, 10, nil).SetExact(true))
+		It(t).Should("expect 10 hits, but %v", len(expected)).Assert(len(expected) == 10)
+		for doc := range docs(search.NewKnnVectorQuery("vector", target, 10, nil).SetEf(100)) {
+			if expected[doc] {
+				found++
+			}
+		}
+	}
+	It(t).Should("expect a recall of at least 90%%, but %v%%", found).Assert(found >= 90)
+
+	// the filter accepts no more than ef docs, so they are all compared
+	// with the target
+	rare := search.NewQueryWrapperFilter(search.NewTermQuery(index.NewTerm("group", "rare")))
+	got := docs(search.NewKnnVectorQuery("vector", randomVector(), 10, rare))
+	It(t).Should("expect the 5 rare docs, but %v", got).Assert(
+		len(got) == 5 && got[995] && got[996] && got[997] && got[998] && got[999])
+
+	// the graph search is exact or falls back to the exact search when
+	// the filter leaves it with less than k hits
+	target := randomVector()
+	expected := docs(search.NewKnnVectorQuery("vector", target, 3, rare).SetExact(true))
+	got = docs(search.NewKnnVectorQuery("vector", target, 3, rare))
+	It(t).Should("expect %v, but %v", expected, got).Assert(
+		len(expected) == 3 && fmt.Sprint(got) == fmt.Sprint(expected))
+}
+
+func TestHybridQuery(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i, v := range []struct {
+		body   string
+		vector []float32
+	}{
+		{"quick brown fox", []float32{1, 0}},
+		{"fox fox fox", []float32{0, 1}},
+		{"lazy dog", []float32{1, 0.1}},
+		{"sleeping fox", []float32{-1, 0}},
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", v.body, docu.STORE_NO))
+		d.Add(docu.NewVectorField("vector", v.vector, VECTOR_SIMILARITY_COSINE))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	ids := func(q search.Query) (ans []string) {
+		docs, err := ss.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		for _, hit := range docs.ScoreDocs {
+			d, err := reader.Document(hit.Doc)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			ans = append(ans, d.Get("id"))
+		}
+		return
+	}
+
+	lexical := search.NewTermQuery(index.NewTerm("body", "fox"))
+	vector := search.NewKnnVectorQuery("vector", []float32{1, 0}, 3, nil)
+	It(t).Should("expect lexical ranking [1 3 0]").Assert(fmt.Sprint(ids(lexical)) == "[1 3 0]")
+	It(t).Should("expect vector ranking [0 2 1]").Assert(fmt.Sprint(ids(vector)) == "[0 2 1]")
+
+	// doc 0 is the nearest, and doc 1 the best lexical match: both are
+	// normalized to 1, and ties are broken by doc id
+	got := ids(search.NewHybridQuery(lexical, vector, 10))
+	It(t).Should("expect [0 1 2 3], but %v", got).Assert(fmt.Sprint(got) == "[0 1 2 3]")
+
+	got = ids(search.NewHybridQuery(lexical, vector, 10).SetWeights(1, 2))
+	It(t).Should("expect [0 2 1 3], but %v", got).Assert(fmt.Sprint(got) == "[0 2 1 3]")
+
+	// a zero weight query is not run, so only the other one matches
+	got = ids(search.NewHybridQuery(lexical, vector, 10).SetWeights(1, 0))
+	It(t).Should("expect [1 3 0], but %v", got).Assert(fmt.Sprint(got) == "[1 3 0]")
+
+	// only the top window hits of each query are combined
+	got = ids(search.NewHybridQuery(lexical, vector, 1))
+	It(t).Should("expect [0 1], but %v", got).Assert(fmt.Sprint(got) == "[0 1]")
+
+	docs, err := ss.SearchTop(search.NewHybridQuery(lexical, vector, 10).
+		SetNormalization(search.SCORE_NORMALIZATION_NONE), 1)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect doc 0 with raw scores summed, but %v", docs.ScoreDocs).Assert(
+		len(docs.ScoreDocs) == 1 && docs.ScoreDocs[0].Doc == 0 &&
+			math.Abs(float64(docs.ScoreDocs[0].Score)-(0.5+1)) < 0.001)
+}
+
+func TestIntervalQuery(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i, body := range []string{
+		"quick brown fox jumps",
+		"quick fox",
+		"fox jumps quick",
+		"quick red lazy brown fox",
+		"quick quick brown dog",
+		"brown quick fox",
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	matches := func(source search.IntervalsSource) string {
+		docs, err := ss.SearchTop(search.NewIntervalQuery("body", source), 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ids := make([]int, 0, len(docs.ScoreDocs))
+		for _, hit := range docs.ScoreDocs {
+			ids = append(ids, hit.Doc)
+		}
+		sort.Ints(ids)
+		return fmt.Sprint(ids)
+	}
+
+	quick, brown, fox := search.IntervalTerm("quick"), search.IntervalTerm("brown"), search.IntervalTerm("fox")
+	quickFox := search.IntervalMaxGaps(search.IntervalOrdered(quick, fox), 3)
+	for i, c := range []struct {
+		source search.IntervalsSource
+		want   string
+	}{
+		{fox, "[0 1 2 3 5]"},
+		{search.IntervalTerm("zebra"), "[]"},
+		{search.IntervalPhrase("quick", "brown"), "[0 4]"},
+		{search.IntervalPhrase("brown", "fox"), "[0 3]"},
+		{search.IntervalOrdered(quick, fox), "[0 1 3 5]"},
+		{search.IntervalOrdered(quick, search.IntervalTerm("zebra")), "[]"},
+		{search.IntervalMaxGaps(search.IntervalOrdered(quick, fox), 1), "[0 1 5]"},
+		// the minimal interval of doc 4 is [1,2]
+		{search.IntervalMaxGaps(search.IntervalOrdered(quick, brown), 0), "[0 4]"},
+		{search.IntervalMaxGaps(search.IntervalUnordered(quick, fox), 0), "[1 5]"},
+		{search.IntervalMaxGaps(search.IntervalUnordered(quick, fox), 1), "[0 1 2 5]"},
+		{search.IntervalOrdered(search.IntervalPhrase("quick", "brown"), fox), "[0]"},
+		{search.IntervalContaining(quickFox, brown), "[0 3]"},
+		{search.IntervalNotContaining(quickFox, brown), "[1 5]"},
+		{search.IntervalNotContaining(quickFox, search.IntervalTerm("zebra")), "[0 1 3 5]"},
+	} {
+		got := matches(c.source)
+		It(t).Should("%v: expect %v for %v, but %v", i, c.want, c.source, got).Assert(got == c.want)
+	}
+
+	// closer intervals score higher
+	q := search.NewIntervalQuery("body", search.IntervalOrdered(quick, fox))
+	docs, err := ss.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect doc 1 first, but %v", docs.ScoreDocs).Assert(docs.ScoreDocs[0].Doc == 1)
+	It(t).Should("expect doc 3 last, but %v", docs.ScoreDocs).Assert(docs.ScoreDocs[3].Doc == 3)
+	exp, err := ss.Explain(q, 3)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect explanation to match score %v, but %v", docs.ScoreDocs[3].Score, exp).Assert(
+		exp.IsMatch() && math.Abs(float64(exp.Value()-docs.ScoreDocs[3].Score)) < 0.0001)
+
+	_, err = ss.SearchTop(search.NewIntervalQuery("id", search.IntervalTerm("1")), 10)
+	_, ok := err.(*search.PositionsNotIndexedError)
+	It(t).Should("expect PositionsNotIndexedError, but %v", err).Assert(ok)
+}
+
+func TestPhraseQuery(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	analyzer := std.NewStandardAnalyzer()
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, analyzer))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i, body := range []string{
+		"quick brown fox jumps",
+		"quick fox",
+		"fox jumps quick",
+		"quick red lazy brown fox",
+		"quick quick brown dog",
+		"brown quick fox",
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	qp := classic.NewQueryParser(util.VERSION_LATEST, "body", analyzer)
+	matches := func(query string) string {
+		q, err := qp.Parse(query)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		_, ok := q.(*search.PhraseQuery)
+		It(t).Should("expect PhraseQuery for %v, but %v", query, q).Assert(ok)
+		docs, err := ss.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ids := make([]int, 0, len(docs.ScoreDocs))
+		for _, hit := range docs.ScoreDocs {
+			ids = append(ids, hit.Doc)
+		}
+		sort.Ints(ids)
+		return fmt.Sprint(ids)
+	}
+
+	for _, c := range []struct {
+		query, want string
+	}{
+		{`"quick brown"`, "[0 4]"},
+		{`"brown fox"`, "[0 3]"},
+		{`"quick fox"`, "[1 5]"},
+		{`"quick zebra"`, "[]"},
+		{`"quick fox"~1`, "[0 1 5]"},
+		{`"quick fox"~3`, "[0 1 2 3 5]"},
+		// switching two terms takes two moves
+		{`"fox quick"~1`, "[2]"},
+		{`"fox quick"~2`, "[1 2 5]"},
+		// repeated terms do not match the same position
+		{`"quick quick"`, "[4]"},
+		{`"quick quick"~2`, "[4]"},
+		// the stop word leaves a gap
+		{`"quick the fox"`, "[0]"},
+		{`"quick brown fox"~2^2`, "[0 3 5]"},
+	} {
+		got := matches(c.query)
+		It(t).Should("expect %v for %v, but %v", c.want, c.query, got).Assert(got == c.want)
+	}
+
+	// exact matches score higher than sloppy ones
+	q, err := qp.Parse(`"quick fox"~3^2`)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect slop 3 and boost 2, but %v", q).Assert(
+		q.(*search.PhraseQuery).Slop() == 3 && q.Boost() == 2)
+	docs, err := ss.SearchTop(q, 10)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	first := []int{docs.ScoreDocs[0].Doc, docs.ScoreDocs[1].Doc}
+	sort.Ints(first)
+	It(t).Should("expect docs 1 and 5 first, but %v", docs.ScoreDocs).Assert(
+		first[0] == 1 && first[1] == 5)
+	last := docs.ScoreDocs[len(docs.ScoreDocs)-1]
+	exp, err := ss.Explain(q, last.Doc)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect explanation to match score %v, but %v", last.Score, exp).Assert(
+		exp.IsMatch() && math.Abs(float64(exp.Value()-last.Score)) < 0.0001)
+
+	for _, query := range []string{`"quick fox"^`, `quick~2`} {
+		_, err = qp.Parse(query)
+		It(t).Should("expect error for %v", query).Assert(err != nil)
+	}
+
+	pq := search.NewPhraseQuery()
+	pq.Add(index.NewTerm("id", "1"))
+	pq.Add(index.NewTerm("id", "2"))
+	_, err = ss.SearchTop(pq, 10)
+	_, ok := err.(*search.PositionsNotIndexedError)
+	It(t).Should("expect PositionsNotIndexedError, but %v", err).Assert(ok)
+}
+
+func TestProximityBooster(t *testing.T) {
+	os.RemoveAll(".gltest")
+	directory, err := store.OpenFSDirectory(".gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	analyzer := std.NewStandardAnalyzer()
+	writer, err := index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, analyzer))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for _, body := range []string{
+		"fox brown quick",
+		"quick brown fox",
+		"brown fox quick",
+		"quick dog",
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	ranking := func(q search.Query) string {
+		docs, err := ss.SearchTop(q, 10)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ids := make([]int, 0, len(docs.ScoreDocs))
+		for _, hit := range docs.ScoreDocs {
+			ids = append(ids, hit.Doc)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	q, err := classic.NewQueryParser(util.VERSION_LATEST, "body", analyzer).Parse("quick brown fox")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	got := ranking(q)
+	It(t).Should("expect [0 1 2 3], but %v", got).Assert(got == "[0 1 2 3]")
+
+	boosted := search.NewProximityBooster().Boost(q)
+	It(t).Should("expect phrase clause, but %v", boosted).Assert(
+		fmt.Sprint(boosted) == "+(body:quick body:brown body:fox) body:BLOCK(quick,brown,fox)")
+	got = ranking(boosted)
+	It(t).Should("expect [1 0 2 3], but %v", got).Assert(got == "[1 0 2 3]")
+
+	// bigrams reward partial matches of the phrase
+	got = ranking(search.NewProximityBooster().SetPhrase(0, 0).SetBigrams(1, 0).Boost(q))
+	It(t).Should("expect [1 2 0 3], but %v", got).Assert(got == "[1 2 0 3]")
+
+	// "fox quick" is within a slop of 1 in doc 2
+	q, err = classic.NewQueryParser(util.VERSION_LATEST, "body", analyzer).Parse("fox quick")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	got = ranking(search.NewProximityBooster().SetPhrase(1, 1).Boost(q))
+	It(t).Should("expect [2 0 1 3], but %v", got).Assert(got == "[2 0 1 3]")
+
+	// nothing to add for a single term
+	single := search.NewTermQuery(index.NewTerm("body", "quick"))
+	It(t).Should("expect single term as is").Assert(search.NewProximityBooster().Boost(single) == search.Query(single))
+}
+
+/* Counts the hits of a slice. */
+type hitCountCollector struct {
+	count int
+}
+
+func (c *hitCountCollector) SetScorer(s search.Scorer)                    {}
+func (c *hitCountCollector) SetNextReader(ctx *index.AtomicReaderContext) {}
+func (c *hitCountCollector) AcceptsDocsOutOfOrder() bool                  { return true }
+func (c *hitCountCollector) Collect(doc int) error {
+	c.count++
+	return nil
+}
+
+type hitCountManager struct {
+	slices int
+}
+
+func (m *hitCountManager) NewCollector() (search.Collector, error) {
+	m.slices++
+	return &hitCountCollector{}, nil
+}
+
+func (m *hitCountManager) Reduce(collectors []search.Collector) (interface{}, error) {
+	total := 0
+	for _, c := range collectors {
+		total += c.(*hitCountCollector).count
+	}
+	return total, nil
+}
+
+func TestCollectorManager(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(3).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i := 0; i < 20; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		// shorter docs score higher
+		body := "fox"
+		for j := 0; j < (i*7)%11; j++ {
+			body += " dog"
+		}
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect several segments, got %v", len(reader.Leaves())).Assert(len(reader.Leaves()) > 1)
+
+	q := search.NewTermQuery(index.NewTerm("body", "fox"))
+	want, err := search.NewIndexSearcher(reader).SearchTop(q, 5)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	ss := search.NewIndexSearcher(reader)
+	ss.SetConcurrency(4)
+	got, err := ss.SearchTop(q, 5)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 20 hits, got %v", got.TotalHits).Assert(got.TotalHits == 20 && want.TotalHits == 20)
+	It(t).Should("expect the same top hits, got %v, want %v", got.ScoreDocs, want.ScoreDocs).Assert(
+		fmt.Sprint(got.ScoreDocs) == fmt.Sprint(want.ScoreDocs))
+
+	m := &hitCountManager{}
+	count, err := ss.SearchCollectorManager(search.NewTermQuery(index.NewTerm("body", "dog")), nil, m)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect a collector per segment, got %v", m.slices).Assert(m.slices == len(reader.Leaves()))
+	dogs := 0
+	for i := 0; i < 20; i++ {
+		if (i*7)%11 > 0 {
+			dogs++
+		}
+	}
+	It(t).Should("expect %v hits, got %v", dogs, count).Assert(count == dogs)
+}
+
+func TestDedupingCollector(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(3).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i := 0; i < 20; i++ {
+		d := docu.NewDocument()
+		if i < 15 {
+			d.Add(docu.NewStringField("url", fmt.Sprintf("u%v", i%5), docu.STORE_YES))
+		}
+		body := "fox"
+		for j := 0; j < (i*7)%11; j++ {
+			body += " dog"
+		}
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect several segments, got %v", len(reader.Leaves())).Assert(len(reader.Leaves()) > 1)
+
+	q := search.NewTermQuery(index.NewTerm("body", "fox"))
+	ss := search.NewIndexSearcher(reader)
+	all, err := ss.SearchTop(q, 20)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	best := make(map[string]float32)
+	for _, hit := range all.ScoreDocs {
+		doc, err := reader.Document(hit.Doc)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		if url := doc.Get("url"); url != "" && hit.Score > best[url] {
+			best[url] = hit.Score
+		}
+	}
+
+	// 5 urls, and the 5 docs without one
+	deduped, err := ss.SearchDeduped(q, nil, "url", 20)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 10 distinct hits, got %v", deduped.TotalHits).Assert(
+		deduped.TotalHits == 10 && len(deduped.ScoreDocs) == 10)
+	seen := make(map[string]bool)
+	for _, hit := range deduped.ScoreDocs {
+		doc, err := reader.Document(hit.Doc)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		url := doc.Get("url")
+		if url == "" {
+			continue
+		}
+		It(t).Should("expect a single hit of %v", url).Assert(!seen[url])
+		seen[url] = true
+		It(t).Should("expect the best hit of %v, got score %v", url, hit.Score).Assert(hit.Score == best[url])
+	}
+
+	top, err := ss.SearchDeduped(q, nil, "url", 3)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect the 3 best of 10 distinct hits, got %v", top).Assert(
+		top.TotalHits == 10 && fmt.Sprint(top.ScoreDocs) == fmt.Sprint(deduped.ScoreDocs[:3]))
+}
+
 func TestAfter(t *testing.T) {
 	// AfterSuite(t)
 }
+
+func TestLiveFieldValues(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer writer.Close()
+	add := func(id, value string) {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", id, docu.STORE_YES))
+		d.Add(docu.NewStringField("value", value, docu.STORE_YES))
+		err := writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	var searcher *search.IndexSearcher
+	reopen := func() {
+		err := writer.Commit()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		reader, err := index.OpenDirectoryReader(directory)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		if searcher != nil {
+			searcher.TopReaderContext().Reader().Close()
+		}
+		searcher = search.NewIndexSearcher(reader)
+	}
+	defer func() { searcher.TopReaderContext().Reader().Close() }()
+	get := func(values *search.LiveFieldValues, id string) interface{} {
+		v, err := values.Get(id)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		return v
+	}
+
+	add("1", "a")
+	reopen()
+	values := search.NewLiveFieldValues(func() *search.IndexSearcher { return searcher },
+		"id", search.NewStoredFieldSource("value"))
+	It(t).Should("expect value of doc 1 from searcher, got %v", get(values, "1")).Assert(get(values, "1") == "a")
+
+	add("2", "b")
+	values.Add("2", "b")
+	values.Delete("1")
+	It(t).Should("expect fresh value of doc 2, got %v", get(values, "2")).Assert(get(values, "2") == "b")
+	It(t).Should("expect doc 1 to be deleted, got %v", get(values, "1")).Assert(get(values, "1") == nil)
+	It(t).Should("expect unknown doc 3, got %v", get(values, "3")).Assert(get(values, "3") == nil)
+
+	err = values.BeforeRefresh()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	add("3", "c")
+	values.Add("3", "c")
+	reopen()
+	It(t).Should("expect value of doc 2 while refreshing, got %v", get(values, "2")).Assert(get(values, "2") == "b")
+	err = values.AfterRefresh(true)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 1 tracked ID, got %v", values.Size()).Assert(values.Size() == 1)
+	It(t).Should("expect value of doc 2 from searcher, got %v", get(values, "2")).Assert(get(values, "2") == "b")
+	It(t).Should("expect value of doc 3, got %v", get(values, "3")).Assert(get(values, "3") == "c")
+}
+
+func TestPluginRegistry(t *testing.T) {
+	c, err := spi.LookupCodec("Lucene410")
+	It(t).Should("find linked in codec").Assert(err == nil && c.Name() == "Lucene410")
+	_, err = spi.LookupCodec("NoSuchCodec")
+	It(t).Should("not find unknown codec").Assert(err != nil)
+	pf, err := spi.LookupPostingsFormat("Lucene41")
+	It(t).Should("find linked in postings format").Assert(err == nil && pf.Name() == "Lucene41")
+
+	a, err := analysis.NewAnalyzerByName("standard")
+	It(t).Should("create registered analyzer").Assert(err == nil)
+	qp, err := queryparser.NewQueryParserByName("classic", "body", a)
+	It(t).Should("create registered query parser").Assert(err == nil)
+	q, err := qp.Parse("Quick fox")
+	It(t).Should("parse with classic syntax").Assert(err == nil && q.ToString("") == "body:quick body:fox")
+	_, err = queryparser.NewQueryParserByName("nosuchsyntax", "body", a)
+	It(t).Should("not find unknown query parser").Assert(err != nil)
+}
@@ -0,0 +1,121 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+/*
+Indexes 000 to 999 and x in a single document, so the terms
+dictionary splits the blocks of each leading digit into floor
+blocks, under a root block holding both sub-blocks and a term.
+*/
+func openFloorBlockTerms(t *testing.T) (words []string, terms model.Terms, closeAll func()) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	d := docu.NewDocument()
+	for i := 0; i < 1000; i++ {
+		words = append(words, fmt.Sprintf("%03d", i))
+		d.Add(docu.NewStringField("id", words[i], docu.STORE_NO))
+	}
+	words = append(words, "x")
+	d.Add(docu.NewStringField("id", "x", docu.STORE_NO))
+	err = writer.AddDocument(d.Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	terms = r.Leaves()[0].Reader().(index.AtomicReader).Fields().Terms("id")
+	return words, terms, func() {
+		r.Close()
+		directory.Close()
+		os.RemoveAll(path)
+	}
+}
+
+func TestTermsSeekExactPastBlockEnd(t *testing.T) {
+	_, terms, closeAll := openFloorBlockTerms(t)
+	defer closeAll()
+
+	// after the last entry of the root block, twice with the same
+	// enum, which then starts at the end of the block
+	te := terms.Iterator(nil)
+	for _, word := range []string{"y", "z"} {
+		ok, err := te.SeekExact([]byte(word))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect not to find %v", word).Assert(!ok)
+	}
+}
+
+func TestTermsNextOverFloorBlocks(t *testing.T) {
+	words, terms, closeAll := openFloorBlockTerms(t)
+	defer closeAll()
+
+	te := terms.Iterator(nil)
+	for i := 0; ; i++ {
+		term, err := te.Next()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		if term == nil {
+			It(t).Should("expect %v terms, but %v", len(words), i).Assert(i == len(words))
+			return
+		}
+		It(t).Should("expect term %v, but %v", words[i], string(term)).Assert(
+			i < len(words) && string(term) == words[i])
+	}
+}
+
+func TestTermsSeekExactFirstFloorBlocks(t *testing.T) {
+	_, terms, closeAll := openFloorBlockTerms(t)
+	defer closeAll()
+
+	for _, word := range []string{"000", "005", "100", "110"} {
+		ok, err := terms.Iterator(nil).SeekExact([]byte(word))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect to find %v", word).Assert(ok)
+	}
+}
+
+func TestTermsSeekExactOverFloorBlocks(t *testing.T) {
+	words, terms, closeAll := openFloorBlockTerms(t)
+	defer closeAll()
+
+	for _, word := range words {
+		ok, err := terms.Iterator(nil).SeekExact([]byte(word))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect to find %v", word).Assert(ok)
+	}
+	for _, word := range []string{"0000", "05", "1000", "099a", "999z"} {
+		ok, err := terms.Iterator(nil).SeekExact([]byte(word))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect not to find %v", word).Assert(!ok)
+	}
+}
+
+func TestTermsSeekExactInOrder(t *testing.T) {
+	words, terms, closeAll := openFloorBlockTerms(t)
+	defer closeAll()
+
+	te := terms.Iterator(nil)
+	for _, word := range words {
+		ok, err := te.SeekExact([]byte(word))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		It(t).Should("expect to find %v", word).Assert(ok)
+	}
+}
@@ -0,0 +1,51 @@
+package core_test
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	. "github.com/balzaczyy/gounit"
+	"testing"
+	"time"
+)
+
+func TestFlushByDocCount(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(2).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	// reaching the max buffered docs marks the writer flush pending
+	// while the flush control is already locked
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 7; i++ {
+			d := docu.NewDocument()
+			d.Add(docu.NewTextFieldFromString("foo", fmt.Sprintf("bar%v", i), docu.STORE_YES))
+			if err := writer.AddDocument(d.Fields()); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- writer.Close()
+	}()
+	select {
+	case err = <-done:
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	case <-time.After(10 * time.Second):
+		t.Fatal("adding documents did not finish")
+	}
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect 7 docs, but %v", reader.NumDocs()).Assert(reader.NumDocs() == 7)
+	n := len(reader.Leaves())
+	It(t).Should("expect several flushed segments, but %v", n).Assert(n > 1)
+}
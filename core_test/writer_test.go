@@ -0,0 +1,636 @@
+package core_test
+
+import (
+	"errors"
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/codec/spi"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/automaton"
+	. "github.com/balzaczyy/gounit"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommitOnClose(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	addDoc := func(writer *index.IndexWriter) {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("foo", "bar", docu.STORE_YES))
+		err := writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	numDocs := func() int {
+		reader, err := index.OpenDirectoryReader(directory)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		defer reader.Close()
+		return reader.NumDocs()
+	}
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(2).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	addDoc(writer)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 1 doc after commit on close").Assert(numDocs() == 1)
+
+	conf = index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetCommitOnClose(false).SetWaitForMergesOnClose(false)
+	writer, err = index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	addDoc(writer)
+	addDoc(writer)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect changes to be rolled back on close").Assert(numDocs() == 1)
+
+	// running merges are aborted, but the changes are still committed
+	conf = index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(2).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	conf.SetWaitForMergesOnClose(false)
+	writer, err = index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i := 0; i < 20; i++ {
+		addDoc(writer)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 21 docs after commit on close, got %v", numDocs()).Assert(numDocs() == 21)
+}
+
+func TestCommitRenamesPendingSegments(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	// a leftover of a commit interrupted by a crash
+	out, err := directory.CreateOutput("pending_segments_7", store.IO_CONTEXT_DEFAULT)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	out.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	d := docu.NewDocument()
+	d.Add(docu.NewTextFieldFromString("foo", "bar", docu.STORE_YES))
+	err = writer.AddDocument(d.Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	files, err := directory.ListAll()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var hasCommit bool
+	for _, name := range files {
+		It(t).Should("expect no pending segments file, but %v", name).Assert(
+			!strings.HasPrefix(name, "pending_segments"))
+		hasCommit = hasCommit || strings.HasPrefix(name, "segments_")
+	}
+	It(t).Should("expect a segments_N file in %v", files).Assert(hasCommit)
+}
+
+func TestIndexDiskUsage(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for _, text := range []string{"the quick brown fox", "jumps over the lazy dog"} {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_YES))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	usage, err := index.ComputeIndexDiskUsage(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect one segment, but %v", len(usage.Segments)).Assert(len(usage.Segments) == 1)
+	It(t).Should("expect 2 docs, but %v", usage.Segments[0].DocCount).Assert(usage.Segments[0].DocCount == 2)
+
+	var total, byExtension int64
+	files, err := directory.ListAll()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for _, name := range files {
+		if name != index.WRITE_LOCK_NAME {
+			size, err := directory.FileLength(name)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			total += size
+		}
+	}
+	for _, size := range usage.ByExtension {
+		byExtension += size
+	}
+	It(t).Should("expect total %v, but %v", total, usage.TotalSizeInBytes).Assert(usage.TotalSizeInBytes == total)
+	It(t).Should("expect extensions to sum up to segment size").Assert(byExtension == usage.Segments[0].SizeInBytes)
+	It(t).Should("expect segment info to be accounted").Assert(usage.ByExtension["si"] > 0)
+	It(t).Should("expect report to mention %v", usage.SegmentsFileName).Assert(
+		strings.Contains(usage.String(), usage.SegmentsFileName))
+}
+
+func TestFieldTermsStats(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for _, text := range []string{"quick brown fox", "jumps over lazy dog"} {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		err = writer.Commit() // one segment per doc
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer r.Close()
+
+	cache := index.NewTermsStatsCache()
+	stats := cache.FieldStats(r, "body")
+	It(t).Should("expect 2 segments, but %v", stats).Assert(stats.Segments == 2)
+	It(t).Should("expect 7 terms, but %v", stats).Assert(stats.TermCount == 7)
+	It(t).Should("expect max 4 terms per segment, but %v", stats).Assert(stats.MaxSegmentTermCount == 4)
+	It(t).Should("expect 2 docs, but %v", stats).Assert(stats.DocCount == 2)
+	It(t).Should("expect sumDocFreq 7, but %v", stats).Assert(stats.SumDocFreq == 7)
+	It(t).Should("expect min term 'brown', but %v", stats).Assert(string(stats.MinTerm) == "brown")
+	It(t).Should("expect max term 'quick', but %v", stats).Assert(string(stats.MaxTerm) == "quick")
+	It(t).Should("expect cached stats").Assert(cache.FieldStats(r, "body").TermCount == 7)
+
+	stats = cache.FieldStats(r, "missing")
+	It(t).Should("expect no segments, but %v", stats).Assert(stats.Segments == 0 && stats.DocCount == 0)
+}
+
+func TestTermsIntersect(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	// enough terms for the terms dictionary to have sub-blocks
+	var words []string
+	for _, prefix := range []string{"apple", "berry", "cherry"} {
+		for i := 0; i < 100; i++ {
+			words = append(words, fmt.Sprintf("%v%03d", prefix, i))
+		}
+	}
+	d := docu.NewDocument()
+	d.Add(docu.NewTextFieldFromString("body", strings.Join(words, " "), docu.STORE_NO))
+	err = writer.AddDocument(d.Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	r, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer r.Close()
+	terms := r.Leaves()[0].Reader().(index.AtomicReader).Fields().Terms("body")
+
+	intersect := func(re string, startTerm string) (ans []string) {
+		compiled := automaton.NewCompiledAutomaton(automaton.NewRegExp(re).ToAutomaton())
+		var start []byte
+		if startTerm != "" {
+			start = []byte(startTerm)
+		}
+		te := terms.Intersect(compiled, start)
+		for {
+			term, err := te.Next()
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			if term == nil {
+				return
+			}
+			ans = append(ans, string(term))
+		}
+	}
+
+	got := intersect("berry0[1-3]5", "")
+	It(t).Should("expect 3 berries, but %v", got).Assert(
+		strings.Join(got, " ") == "berry015 berry025 berry035")
+	got = intersect("(apple|cherry)09.", "")
+	It(t).Should("expect 20 terms, but %v", got).Assert(
+		len(got) == 20 && got[0] == "apple090" && got[19] == "cherry099")
+	got = intersect("(apple|cherry)09.", "apple095")
+	It(t).Should("expect 14 terms after apple095, but %v", got).Assert(
+		len(got) == 14 && got[0] == "apple096")
+	got = intersect(".*", "")
+	It(t).Should("expect all 300 terms, but %v", len(got)).Assert(len(got) == 300)
+	got = intersect("durian.*", "")
+	It(t).Should("expect no terms, but %v", got).Assert(len(got) == 0)
+
+	// the terms of several segments are merged
+	writer, err = index.NewIndexWriter(directory, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for _, text := range []string{"apple095 berry015 durian001", "berry015 berry016"} {
+		d = docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		err = writer.Commit()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	r2, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer r2.Close()
+	It(t).Should("expect 3 segments, but %v", len(r2.Leaves())).Assert(len(r2.Leaves()) == 3)
+	terms = index.GetMultiTerms(r2, "body")
+	got = intersect("(berry01.|durian.*)", "berry014")
+	It(t).Should("expect 6 terms, but %v", got).Assert(
+		strings.Join(got, " ") == "berry015 berry016 berry017 berry018 berry019 durian001")
+
+	te := terms.Intersect(automaton.NewCompiledAutomaton(automaton.NewRegExp("berry015").ToAutomaton()), nil)
+	term, err := te.Next()
+	It(t).Should("expect berry015, but %v, %v", term, err).Assert(string(term) == "berry015" && err == nil)
+	df, err := te.DocFreq()
+	It(t).Should("expect berry015 in 3 docs, but %v, %v", df, err).Assert(df == 3 && err == nil)
+	docs, err := te.Docs(nil, nil)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var got2 []int
+	for doc, err := docs.NextDoc(); doc != math.MaxInt32; doc, err = docs.NextDoc() {
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		got2 = append(got2, doc)
+	}
+	It(t).Should("expect docs [0 1 2], but %v", got2).Assert(fmt.Sprint(got2) == "[0 1 2]")
+}
+
+/* Collects the string fields of the wanted names, skipping others. */
+type wantedFieldsVisitor struct {
+	*docu.StoredFieldVisitorAdapter
+	wanted map[string]bool
+	values []string
+}
+
+func (v *wantedFieldsVisitor) StringField(fi *model.FieldInfo, value string) error {
+	v.values = append(v.values, fi.Name+"="+value)
+	return nil
+}
+
+func (v *wantedFieldsVisitor) NeedsField(fi *model.FieldInfo) (spi.StoredFieldVisitorStatus, error) {
+	if v.wanted[fi.Name] {
+		return spi.STORED_FIELD_VISITOR_STATUS_YES, nil
+	}
+	return spi.STORED_FIELD_VISITOR_STATUS_NO, nil
+}
+
+func TestBinaryStoredFields(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	directory, err := store.OpenFSDirectory(path)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer directory.Close()
+
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	d := docu.NewDocument()
+	d.Add(docu.NewStringField("id", "1", docu.STORE_YES))
+	d.Add(docu.NewStoredFieldFromBytes("data", []byte{0, 1, 2, 255}))
+	d.Add(docu.NewTextFieldFromString("body", "the quick brown fox", docu.STORE_YES))
+	d.Add(docu.NewStringField("tag", "last", docu.STORE_YES))
+	err = writer.AddDocument(d.Fields())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+
+	got, err := reader.Document(0)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	data := got.GetBinaryValue("data")
+	It(t).Should("expect binary value [0 1 2 255], but %v", data).Assert(
+		string(data) == string([]byte{0, 1, 2, 255}))
+	It(t).Should("expect binary field to have no string value").Assert(got.Get("data") == "")
+	It(t).Should("expect stored body, but %v", got.Get("body")).Assert(got.Get("body") == "the quick brown fox")
+
+	// skipped binary and string fields must not shift the later ones
+	visitor := &wantedFieldsVisitor{wanted: map[string]bool{"id": true, "tag": true}}
+	err = reader.VisitDocument(0, visitor)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect id and tag, but %v", visitor.values).Assert(
+		strings.Join(visitor.values, " ") == "id=1 tag=last")
+}
+
+func TestCompositeIndexWriter(t *testing.T) {
+	var writers []*index.IndexWriter
+	for i := 0; i < 3; i++ {
+		conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+		writer, err := index.NewIndexWriter(store.NewRAMDirectory(), conf)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		writers = append(writers, writer)
+	}
+	writer := index.NewCompositeIndexWriter(index.HashRouter("id"), writers...)
+	newDoc := func(id, body string) []model.IndexableField {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", id, docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		return d.Fields()
+	}
+	for i := 0; i < 10; i++ {
+		body := "common text"
+		if i == 3 {
+			body = "rare text"
+		}
+		err := writer.AddDocument(newDoc(fmt.Sprintf("doc%v", i), body))
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err := writer.AddDocument(newDoc("none", "no id")[1:])
+	It(t).Should("expect an error for a document without id").Assert(err != nil)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenCompositeReader(
+		writers[0].Directory(), writers[1].Directory(), writers[2].Directory())
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	It(t).Should("expect 10 docs, got %v", reader.NumDocs()).Assert(reader.NumDocs() == 10)
+	It(t).Should("expect 3 sub-indexes, got %v", len(reader.Leaves())).Assert(len(reader.Leaves()) == 3)
+
+	ss := search.NewIndexSearcher(reader)
+	docs, err := ss.SearchTop(search.NewTermQuery(index.NewTerm("body", "common")), 20)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	var ids []string
+	for _, hit := range docs.ScoreDocs {
+		d, err := reader.Document(hit.Doc)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		ids = append(ids, d.Get("id"))
+	}
+	sort.Strings(ids)
+	It(t).Should("expect all but doc3, got %v", ids).Assert(
+		fmt.Sprint(ids) == "[doc0 doc1 doc2 doc4 doc5 doc6 doc7 doc8 doc9]")
+	docs, err = ss.SearchTop(search.NewTermQuery(index.NewTerm("body", "rare")), 20)
+	It(t).Should("has no error: %v", err).Assert(err == nil && docs.TotalHits == 1)
+
+	router := index.TimeRouter("ts", time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), 24*time.Hour)
+	for _, c := range []struct {
+		ts   string
+		want int
+	}{
+		{"2013-12-31T23:00:00Z", 0},
+		{"2014-01-01T10:00:00Z", 0},
+		{"2014-01-02T00:00:00Z", 1},
+		{"2014-01-03T06:00:00+08:00", 1},
+		{"2014-02-01T00:00:00Z", 2},
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("ts", c.ts, docu.STORE_NO))
+		got, err := router(d.Fields(), 3)
+		It(t).Should("expect %v routed to %v, got %v (%v)", c.ts, c.want, got, err).Assert(
+			err == nil && got == c.want)
+	}
+}
+
+func TestRolloverManager(t *testing.T) {
+	path, err := ioutil.TempDir("", "gltest")
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer os.RemoveAll(path)
+
+	factory := &failingDirectoryFactory{IndexDirectoryFactory: index.NewFSDirectoryFactory(path)}
+	newConfig := func() *index.IndexWriterConfig {
+		return index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	}
+	policy := index.RolloverPolicy{MaxAge: 24 * time.Hour, MaxDocs: 3, Retention: 48 * time.Hour}
+	t0 := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := index.NewRolloverManager(factory, "logs", newConfig, policy, t0)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	addDoc := func() {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("msg", "hello", docu.STORE_NO))
+		err := m.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	maybeRollover := func(now time.Time, want bool) {
+		ok, err := m.MaybeRollover(now)
+		It(t).Should("expect rollover=%v at %v, got %v (%v)", want, now, ok, err).Assert(
+			err == nil && ok == want)
+	}
+	numDocs := func() int {
+		reader, err := m.OpenReader()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		defer reader.Close()
+		return reader.NumDocs()
+	}
+
+	addDoc()
+	addDoc()
+	maybeRollover(t0.Add(time.Hour), false)
+	addDoc()
+	maybeRollover(t0.Add(2*time.Hour), true) // max docs
+	maybeRollover(t0.Add(3*time.Hour), false)
+	maybeRollover(t0.Add(26*time.Hour), true) // max age
+	addDoc()
+	err = m.Commit()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect 4 docs, got %v", numDocs()).Assert(numDocs() == 4)
+	It(t).Should("expect 3 indexes, got %v", m.Indexes()).Assert(
+		fmt.Sprint(m.Indexes()) == "[logs-20140101T000000.000000000Z "+
+			"logs-20140101T020000.000000000Z logs-20140102T020000.000000000Z]")
+
+	// the first index holds documents up to 2h
+	dropped, err := m.ApplyRetention(t0.Add(49 * time.Hour))
+	It(t).Should("expect nothing dropped, got %v (%v)", dropped, err).Assert(err == nil && len(dropped) == 0)
+	// an open reader keeps the dropped index until it is closed
+	reader, err := m.OpenReader()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	dropped, err = m.ApplyRetention(t0.Add(50 * time.Hour))
+	It(t).Should("expect the first index dropped, got %v (%v)", dropped, err).Assert(
+		err == nil && fmt.Sprint(dropped) == "[logs-20140101T000000.000000000Z]")
+	It(t).Should("expect 1 doc, got %v", numDocs()).Assert(numDocs() == 1)
+	names, err := factory.List()
+	It(t).Should("expect 3 directories, got %v (%v)", names, err).Assert(err == nil && len(names) == 3)
+	It(t).Should("expect 4 docs, got %v", reader.NumDocs()).Assert(reader.NumDocs() == 4)
+	err = reader.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	names, err = factory.List()
+	It(t).Should("expect 2 directories, got %v (%v)", names, err).Assert(err == nil && len(names) == 2)
+
+	// a failed rollover keeps the active index
+	factory.fail = true
+	err = m.Rollover(t0.Add(50 * time.Hour))
+	It(t).Should("expect the rollover to fail").Assert(err != nil)
+	factory.fail = false
+	It(t).Should("expect 2 indexes, got %v", m.Indexes()).Assert(len(m.Indexes()) == 2)
+	addDoc()
+	err = m.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	// existing indexes are kept on restart
+	m, err = index.NewRolloverManager(factory, "logs", newConfig, policy, t0.Add(51*time.Hour))
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer m.Close()
+	It(t).Should("expect 3 indexes, got %v", m.Indexes()).Assert(len(m.Indexes()) == 3)
+	It(t).Should("expect 2 docs, got %v", numDocs()).Assert(numDocs() == 2)
+	names, err = factory.List()
+	It(t).Should("expect 3 directories, got %v (%v)", names, err).Assert(err == nil && len(names) == 3)
+}
+
+/* Fails to open new index directories when asked to. */
+type failingDirectoryFactory struct {
+	index.IndexDirectoryFactory
+	fail bool
+}
+
+func (f *failingDirectoryFactory) Open(name string) (store.Directory, error) {
+	if f.fail {
+		return nil, errors.New("simulated failure")
+	}
+	return f.IndexDirectoryFactory.Open(name)
+}
+
+func TestAccountable(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	It(t).Should("expect no buffered memory, got %v", writer.RamBytesUsed()).Assert(writer.RamBytesUsed() == 0)
+	for i := 0; i < 10; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", "the quick brown fox", docu.STORE_NO))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	used := writer.RamBytesUsed()
+	It(t).Should("expect buffered documents to use memory, got %v", used).Assert(used > 0)
+	buffered := util.ChildResources(writer)[0]
+	It(t).Should("expect %v bytes of buffered documents, got %v", used, buffered.RamBytesUsed()).Assert(
+		buffered.RamBytesUsed() == used)
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	a, ok := reader.(util.Accountable)
+	It(t).Should("expect the reader to be accountable").Assert(ok)
+	leaf := reader.Leaves()[0].Reader().(util.Accountable)
+	It(t).Should("expect the segment to use memory, got %v", leaf.RamBytesUsed()).Assert(
+		leaf.RamBytesUsed() > 0 && a.RamBytesUsed() == leaf.RamBytesUsed())
+	tree := util.AccountableToString(a)
+	It(t).Should("expect postings and stored fields, got %v", tree).Assert(
+		strings.Contains(tree, "|-- postings: ") && strings.Contains(tree, "|-- stored fields: "))
+}
+
+func TestMergeStoredFields(t *testing.T) {
+	directory := store.NewRAMDirectory()
+	defer directory.Close()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	conf.SetMaxBufferedDocs(3).SetRAMBufferSizeMB(index.DISABLE_AUTO_FLUSH)
+	writer, err := index.NewIndexWriter(directory, conf)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	for i := 0; i < 7; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", fmt.Sprint(i), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", strings.Repeat("the quick brown fox ", i+1), docu.STORE_YES))
+		err = writer.AddDocument(d.Fields())
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+	}
+	err = writer.Close()
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+
+	reader, err := index.OpenDirectoryReader(directory)
+	It(t).Should("has no error: %v", err).Assert(err == nil)
+	defer reader.Close()
+	leaves := reader.Leaves()
+	It(t).Should("expect 3 segments, got %v", len(leaves)).Assert(len(leaves) == 3)
+
+	merge := func(name string, liveDocs []util.Bits) []string {
+		state := &spi.MergeState{
+			FieldInfos: leaves[0].Reader().(*index.SegmentReader).FieldInfos(),
+			LiveDocs:   liveDocs,
+		}
+		for _, leaf := range leaves {
+			r := leaf.Reader().(*index.SegmentReader)
+			state.StoredFieldsReaders = append(state.StoredFieldsReaders, r.FieldsReader())
+			state.MaxDocs = append(state.MaxDocs, r.MaxDoc())
+		}
+		format := spi.DefaultCodec().StoredFieldsFormat()
+		si := model.NewSegmentInfo(directory, util.VERSION_LATEST, name, -1, false, spi.DefaultCodec(), nil)
+		w, err := format.FieldsWriter(directory, si, store.IO_CONTEXT_DEFAULT)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		docCount, err := spi.MergeStoredFields(w, state)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		err = w.Close()
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+
+		si.SetDocCount(docCount)
+		r, err := format.FieldsReader(directory, si, state.FieldInfos, store.IO_CONTEXT_DEFAULT)
+		It(t).Should("has no error: %v", err).Assert(err == nil)
+		defer r.Close()
+		var ids []string
+		for docID := 0; docID < docCount; docID++ {
+			visitor := docu.NewDocumentStoredFieldVisitor()
+			err = r.VisitDocument(docID, visitor)
+			It(t).Should("has no error: %v", err).Assert(err == nil)
+			d := visitor.Document()
+			ids = append(ids, d.Get("id"))
+			It(t).Should("expect body of doc %v to be kept, got %q", d.Get("id"), d.Get("body")).Assert(
+				d.Get("body") == strings.Repeat("the quick brown fox ", len(d.Get("body"))/20))
+		}
+		return ids
+	}
+
+	// no deletions: all chunks are copied as is
+	ids := merge("_merged1", make([]util.Bits, len(leaves)))
+	It(t).Should("expect all docs in order, got %v", ids).Assert(
+		strings.Join(ids, ",") == "0,1,2,3,4,5,6")
+
+	// deletions in the second segment: its docs are merged one by one
+	live := util.NewFixedBitSetOf(3)
+	live.Set(0)
+	live.Set(2)
+	ids = merge("_merged2", []util.Bits{nil, live, nil})
+	It(t).Should("expect live docs in order, got %v", ids).Assert(
+		strings.Join(ids, ",") == "0,1,2,3,5,6")
+}
@@ -0,0 +1,89 @@
+package facet
+
+import (
+	"bytes"
+	"github.com/balzaczyy/golucene/core/index"
+)
+
+// facet/FacetsConfig.java
+
+/* Default field to index the drill-down terms and ordinals in. */
+const DEFAULT_INDEX_FIELD_NAME = "$facets"
+
+const (
+	// Separates the components of an encoded path.
+	DELIM_CHAR = '\u001F'
+	// Escapes DELIM_CHAR and itself in the components of an encoded
+	// path.
+	ESCAPE_CHAR = '\u001E'
+)
+
+/*
+Turns a dimension plus path into an encoded string, e.g. the text of
+its drill-down term. The components are joined with DELIM_CHAR, and
+occurrences of DELIM_CHAR and ESCAPE_CHAR in them are escaped with
+ESCAPE_CHAR, so that StringToPath() can split it back.
+*/
+func PathToString(dim string, path ...string) string {
+	return pathToString(append([]string{dim}, path...))
+}
+
+func pathToString(path []string) string {
+	var buf bytes.Buffer
+	for i, s := range path {
+		assert2(s != "", "each path component must have length > 0 (got: \"\")")
+		if i > 0 {
+			buf.WriteRune(DELIM_CHAR)
+		}
+		for _, ch := range s {
+			if ch == DELIM_CHAR || ch == ESCAPE_CHAR {
+				buf.WriteRune(ESCAPE_CHAR)
+			}
+			buf.WriteRune(ch)
+		}
+	}
+	return buf.String()
+}
+
+/*
+Turns an encoded string, from a previous call to PathToString(), back
+into the original dimension and path.
+*/
+func StringToPath(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var parts []string
+	var buf bytes.Buffer
+	lastEscape := false
+	for _, ch := range s {
+		switch {
+		case lastEscape:
+			buf.WriteRune(ch)
+			lastEscape = false
+		case ch == ESCAPE_CHAR:
+			lastEscape = true
+		case ch == DELIM_CHAR:
+			parts = append(parts, buf.String())
+			buf.Reset()
+		default:
+			buf.WriteRune(ch)
+		}
+	}
+	parts = append(parts, buf.String())
+	assert2(!lastEscape, "dangling escape character in path: %q", s)
+	return parts
+}
+
+/*
+Returns the term to drill down on the dimension and path, i.e. to
+restrict a query to the documents of a category.
+*/
+func DrillDownTerm(indexFieldName, dim string, path ...string) *index.Term {
+	return index.NewTerm(indexFieldName, PathToString(dim, path...))
+}
+
+/* Returns the label of the dimension and path. */
+func LabelOf(dim string, path ...string) *FacetLabel {
+	return NewFacetLabel(append([]string{dim}, path...)...)
+}
@@ -0,0 +1,103 @@
+package facet
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFacetLabel(t *testing.T) {
+	a := NewFacetLabel("Date", "2010", "03")
+	if a.Length() != 3 || a.String() != "FacetLabel: [Date, 2010, 03]" {
+		t.Errorf("Unexpected label: %v", a)
+	}
+	for _, c := range []struct {
+		b    *FacetLabel
+		sign int
+	}{
+		{NewFacetLabel("Date", "2010", "03"), 0},
+		{NewFacetLabel("Date", "2010"), 1},
+		{NewFacetLabel("Date", "2011"), -1},
+		{NewFacetLabel("Author"), 1},
+	} {
+		if got := a.CompareTo(c.b); got*c.sign < 0 || (got == 0) != (c.sign == 0) {
+			t.Errorf("Expected %v compared to %v to have sign %v, got %v", a, c.b, c.sign, got)
+		}
+	}
+	if got := a.Subpath(1); !reflect.DeepEqual(got.Components, []string{"Date"}) {
+		t.Errorf("Expected dimension, got %v", got)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected empty component to be rejected")
+			}
+		}()
+		NewFacetLabel("Date", "")
+	}()
+}
+
+func TestPathToString(t *testing.T) {
+	for _, path := range [][]string{
+		{"Author", "Mark Twain"},
+		{"a\u001Fb", "\u001E", "c\u001E\u001F"},
+		{"dim"},
+	} {
+		s := PathToString(path[0], path[1:]...)
+		if got := StringToPath(s); !reflect.DeepEqual(got, path) {
+			t.Errorf("Expected %q back from %q, got %q", path, s, got)
+		}
+	}
+	if term := DrillDownTerm(DEFAULT_INDEX_FIELD_NAME, "Author", "Mark Twain"); term.Field != "$facets" ||
+		string(term.Bytes) != "Author\u001FMark Twain" {
+		t.Errorf("Unexpected drill-down term: %v", term)
+	}
+}
+
+func TestOrdinals(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var ords []int
+	for i := 0; i < 100; i++ {
+		ords = append(ords, r.Intn(1<<uint(r.Intn(31))))
+	}
+	ords = append(ords, ords[0], 0, 1<<31-1)
+	buf := EncodeOrdinals(ords)
+
+	var want []int
+	seen := map[int]bool{}
+	for _, ord := range ords {
+		if !seen[ord] {
+			want = append(want, ord)
+			seen[ord] = true
+		}
+	}
+	sort.Ints(want)
+	got, err := DecodeOrdinals(buf, make([]int, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, err = DecodeOrdinals(buf[:len(buf)-1], nil); err == nil {
+		t.Error("Expected truncated ordinals to be rejected")
+	}
+
+	values := NewOrdinalValues(10)
+	err = DecodeIntAssociations(EncodeIntAssociations([]int{3, 5, 3}, []int32{7, -2, 1 << 20}),
+		func(ord int, value int32) {
+			values.Add(ord, int64(value))
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values.Add(9, 7)
+	top := values.Top(2)
+	if !reflect.DeepEqual(top, []OrdinalAndValue{{3, 1<<20 + 7}, {9, 7}}) {
+		t.Errorf("Unexpected top ordinals: %v", top)
+	}
+	if values.Get(5) != -2 || len(values.Top(10)) != 3 {
+		t.Errorf("Expected negative value of ordinal 5, got %v", values.Top(10))
+	}
+}
@@ -0,0 +1,83 @@
+package facet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// facet/taxonomy/FacetLabel.java
+
+/*
+The maximum number of characters a FacetLabel can have, leaving room
+for the escaping and delimiter characters added when it is indexed.
+*/
+const MAX_CATEGORY_PATH_LENGTH = (32768 - 2) / 4
+
+/*
+Holds a sequence of string components, specifying the hierarchical
+name of a category, e.g. ["Author", "Mark Twain"] or
+["Date", "2010", "03"].
+*/
+type FacetLabel struct {
+	// The components of this label. Do not modify.
+	Components []string
+}
+
+/*
+Construct from the given path components, which must be non-empty,
+and whose total length must not exceed MAX_CATEGORY_PATH_LENGTH.
+*/
+func NewFacetLabel(components ...string) *FacetLabel {
+	length := 0
+	for _, c := range components {
+		assert2(c != "", "empty or null components not allowed: %q", components)
+		length += len(c)
+	}
+	assert2(length+len(components)-1 <= MAX_CATEGORY_PATH_LENGTH,
+		"category path exceeds maximum allowed path length: max=%v len=%v path=%v",
+		MAX_CATEGORY_PATH_LENGTH, length+len(components)-1,
+		strings.Join(components, "/"))
+	return &FacetLabel{components}
+}
+
+/* The number of components of this label. */
+func (l *FacetLabel) Length() int {
+	return len(l.Components)
+}
+
+/*
+Compares this label with another one, component by component, a
+prefix being before the longer labels it starts.
+*/
+func (l *FacetLabel) CompareTo(other *FacetLabel) int {
+	for i := 0; i < len(l.Components) && i < len(other.Components); i++ {
+		if a, b := l.Components[i], other.Components[i]; a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(l.Components) - len(other.Components)
+}
+
+/*
+Returns a sub-path of this label up to length components, e.g. its
+dimension for length 1.
+*/
+func (l *FacetLabel) Subpath(length int) *FacetLabel {
+	if length >= len(l.Components) || length < 0 {
+		return l
+	}
+	return &FacetLabel{l.Components[:length]}
+}
+
+func (l *FacetLabel) String() string {
+	return fmt.Sprintf("FacetLabel: [%v]", strings.Join(l.Components, ", "))
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
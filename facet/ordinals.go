@@ -0,0 +1,164 @@
+package facet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"sort"
+)
+
+// facet/FacetsConfig.java#dedupAndEncode
+
+/*
+Encodes the ordinals of the categories of a document, e.g. into the
+value of its binary doc values field: they are sorted, deduplicated,
+and written as deltas in variable-length bytes, most significant
+7 bits first, the last byte of each delta having its high bit clear.
+*/
+func EncodeOrdinals(ords []int) []byte {
+	sorted := append([]int(nil), ords...)
+	sort.Ints(sorted)
+	buf := make([]byte, 0, 5*len(sorted))
+	lastOrd := -1
+	for _, ord := range sorted {
+		assert2(ord >= 0, "ordinals must be non-negative (got: %v)", ord)
+		if ord == lastOrd {
+			continue
+		}
+		delta := ord
+		if lastOrd >= 0 {
+			delta -= lastOrd
+		}
+		for shift := 28; shift > 0; shift -= 7 {
+			if delta>>uint(shift) != 0 {
+				buf = append(buf, byte(0x80|(delta>>uint(shift))&0x7F))
+			}
+		}
+		buf = append(buf, byte(delta&0x7F))
+		lastOrd = ord
+	}
+	return buf
+}
+
+// facet/taxonomy/DocValuesOrdinalsReader.java#decode
+
+/*
+Decodes the ordinals encoded by EncodeOrdinals() into ords, which is
+returned grown as needed.
+*/
+func DecodeOrdinals(buf []byte, ords []int) ([]int, error) {
+	ords = ords[:0]
+	value, prev := 0, 0 // the first delta is relative to 0
+	for i, b := range buf {
+		value = value<<7 | int(b&0x7F)
+		if b&0x80 != 0 {
+			if i == len(buf)-1 {
+				return nil, errors.New(fmt.Sprintf("truncated ordinal at byte %v of %v", i, len(buf)))
+			}
+			continue
+		}
+		prev += value
+		ords = append(ords, prev)
+		value = 0
+	}
+	return ords, nil
+}
+
+// facet/taxonomy/IntAssociationFacetField.java
+
+/*
+Encodes the int values associated with the ordinals of the categories
+of a document, e.g. a weight or a confidence, as pairs of 4 bytes big
+endian ordinal and value.
+*/
+func EncodeIntAssociations(ords []int, values []int32) []byte {
+	assert2(len(ords) == len(values), "expected as many values as ordinals, got %v and %v",
+		len(values), len(ords))
+	buf := make([]byte, 8*len(ords))
+	for i, ord := range ords {
+		binary.BigEndian.PutUint32(buf[8*i:], uint32(ord))
+		binary.BigEndian.PutUint32(buf[8*i+4:], uint32(values[i]))
+	}
+	return buf
+}
+
+/* Calls fn with each ordinal and value encoded by EncodeIntAssociations(). */
+func DecodeIntAssociations(buf []byte, fn func(ord int, value int32)) error {
+	if len(buf)%8 != 0 {
+		return errors.New(fmt.Sprintf("expected pairs of 4 bytes ordinal and value, got %v bytes", len(buf)))
+	}
+	for i := 0; i < len(buf); i += 8 {
+		fn(int(binary.BigEndian.Uint32(buf[i:])), int32(binary.BigEndian.Uint32(buf[i+4:])))
+	}
+	return nil
+}
+
+// facet/taxonomy/IntTaxonomyFacets.java
+
+/*
+Per-ordinal int64 values, e.g. the counts or summed associations of
+a custom faceting aggregation, in a packed array whose bits per value
+grow with its largest value.
+*/
+type OrdinalValues struct {
+	values *packed.GrowableWriter
+}
+
+/* Creates values for ordinals below size, initially all 0. */
+func NewOrdinalValues(size int) *OrdinalValues {
+	return &OrdinalValues{packed.NewGrowableWriter(1, size, packed.PackedInts.DEFAULT)}
+}
+
+/* Returns the number of ordinals. */
+func (v *OrdinalValues) Size() int {
+	return v.values.Size()
+}
+
+func (v *OrdinalValues) Get(ord int) int64 {
+	return v.values.Get(ord)
+}
+
+func (v *OrdinalValues) Set(ord int, value int64) {
+	v.values.Set(ord, value)
+}
+
+/* Adds delta to the value of ord, e.g. 1 to count a document. */
+func (v *OrdinalValues) Add(ord int, delta int64) {
+	v.values.Set(ord, v.values.Get(ord)+delta)
+}
+
+/* An ordinal, and its value in OrdinalValues. */
+type OrdinalAndValue struct {
+	Ord   int
+	Value int64
+}
+
+/*
+Returns the n ordinals with the largest non-zero values, by
+decreasing value, then increasing ordinal.
+*/
+func (v *OrdinalValues) Top(n int) []OrdinalAndValue {
+	var ans []OrdinalAndValue
+	for ord := 0; ord < v.values.Size(); ord++ {
+		if value := v.values.Get(ord); value != 0 {
+			ans = append(ans, OrdinalAndValue{ord, value})
+		}
+	}
+	sort.Sort(byValue(ans))
+	if n < len(ans) {
+		ans = ans[:n]
+	}
+	return ans
+}
+
+type byValue []OrdinalAndValue
+
+func (a byValue) Len() int      { return len(a) }
+func (a byValue) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byValue) Less(i, j int) bool {
+	if a[i].Value != a[j].Value {
+		return a[i].Value > a[j].Value
+	}
+	return a[i].Ord < a[j].Ord
+}
@@ -0,0 +1,198 @@
+/*
+Package highlight provides the token streams needed to highlight the
+matches of a query in the text of a document.
+*/
+package highlight
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"sort"
+)
+
+// highlight/TokenSources.java
+
+/*
+Returns a token stream of the field of a document, with the offsets
+of its tokens in the text of the field, from the term vectors of the
+document if they have positions and offsets, else by re-analyzing the
+stored text of the field with analyzer.
+
+The field must be stored, or have term vectors with offsets. nil is
+returned if the document has no value for the field.
+*/
+func AnyTokenStream(r index.IndexReader, docId int, field string,
+	analyzer analysis.Analyzer) (analysis.TokenStream, error) {
+
+	ts, err := TokenStreamWithOffsets(r, docId, field)
+	if ts != nil || err != nil {
+		return ts, err
+	}
+	doc, err := r.Document(docId)
+	if err != nil {
+		return nil, err
+	}
+	var text string
+	var found bool
+	for _, f := range doc.Fields() {
+		if f.Name() == field {
+			text, found = f.StringValue(), true
+			break
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return TokenStreamFromText(field, text, analyzer)
+}
+
+/*
+Returns a token stream of the field of a document from its term
+vectors, or nil if the field has no term vectors with positions and
+offsets in the document.
+*/
+func TokenStreamWithOffsets(r index.IndexReader, docId int, field string) (analysis.TokenStream, error) {
+	leaves := r.Leaves()
+	leaf := leaves[index.SubIndex(docId, leaves)]
+	ar := leaf.Reader().(index.AtomicReader)
+	if fr, ok := ar.(interface {
+		FieldInfos() FieldInfos
+	}); ok {
+		if fi := fr.FieldInfos().FieldInfoByName(field); fi == nil || !fi.HasVectors() {
+			return nil, nil // don't ask the codec for vectors it has not written
+		}
+	}
+	tv, ok := ar.(interface {
+		TermVectors(docID int) (Fields, error)
+	})
+	if !ok {
+		return nil, nil
+	}
+	vectors, err := tv.TermVectors(docId - leaf.DocBase)
+	if vectors == nil || err != nil {
+		return nil, err
+	}
+	terms := vectors.Terms(field)
+	if terms == nil {
+		return nil, nil
+	}
+	return TokenStreamFromTermVector(terms)
+}
+
+/*
+Returns a token stream of the terms of a term vector, i.e. of a single
+document, in the order of their positions. nil is returned if the
+vector has no positions or offsets.
+*/
+func TokenStreamFromTermVector(vector Terms) (analysis.TokenStream, error) {
+	var tokens []*token
+	var dpe DocsAndPositionsEnum
+	te := vector.Iterator(nil)
+	for {
+		term, err := te.Next()
+		if err != nil {
+			return nil, err
+		}
+		if term == nil {
+			break
+		}
+		if dpe, err = te.DocsAndPositionsByFlags(nil, dpe, DOCS_POSITIONS_ENUM_FLAG_OFF_SETS); err != nil {
+			return nil, err
+		}
+		if dpe == nil {
+			return nil, nil // no positions
+		}
+		if _, err = dpe.NextDoc(); err != nil {
+			return nil, err
+		}
+		freq, err := dpe.Freq()
+		if err != nil {
+			return nil, err
+		}
+		text := string(term)
+		for i := 0; i < freq; i++ {
+			t := &token{term: text}
+			if t.position, err = dpe.NextPosition(); err != nil {
+				return nil, err
+			}
+			if t.startOffset, err = dpe.StartOffset(); err != nil {
+				return nil, err
+			}
+			if t.endOffset, err = dpe.EndOffset(); err != nil {
+				return nil, err
+			}
+			if t.position < 0 || t.startOffset < 0 {
+				return nil, nil // no offsets
+			}
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Stable(tokensByPosition(tokens))
+	return newStoredTokenStream(tokens), nil
+}
+
+/* Returns a token stream of text, analyzed as the value of field. */
+func TokenStreamFromText(field, text string, analyzer analysis.Analyzer) (analysis.TokenStream, error) {
+	return analyzer.TokenStreamForString(field, text)
+}
+
+type token struct {
+	term                   string
+	position               int
+	startOffset, endOffset int
+}
+
+type tokensByPosition []*token
+
+func (a tokensByPosition) Len() int      { return len(a) }
+func (a tokensByPosition) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a tokensByPosition) Less(i, j int) bool {
+	if a[i].position != a[j].position {
+		return a[i].position < a[j].position
+	}
+	return a[i].startOffset < a[j].startOffset
+}
+
+// highlight/TokenStreamFromTermPositionVector.java
+
+/* Replays the tokens read from a term vector. */
+type storedTokenStream struct {
+	*analysis.TokenStreamImpl
+	termAtt   ta.CharTermAttribute
+	posIncAtt ta.PositionIncrementAttribute
+	offsetAtt ta.OffsetAttribute
+	tokens    []*token
+	next      int
+}
+
+func newStoredTokenStream(tokens []*token) *storedTokenStream {
+	ans := &storedTokenStream{TokenStreamImpl: analysis.NewTokenStream(), tokens: tokens}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	ans.offsetAtt = ans.Attributes().Add("OffsetAttribute").(ta.OffsetAttribute)
+	return ans
+}
+
+func (ts *storedTokenStream) Reset() error {
+	ts.next = 0
+	return ts.TokenStreamImpl.Reset()
+}
+
+func (ts *storedTokenStream) IncrementToken() (bool, error) {
+	if ts.next == len(ts.tokens) {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	t := ts.tokens[ts.next]
+	prev := -1
+	if ts.next > 0 {
+		prev = ts.tokens[ts.next-1].position
+	}
+	ts.next++
+	ts.termAtt.AppendString(t.term)
+	ts.posIncAtt.SetPositionIncrement(t.position - prev)
+	ts.offsetAtt.SetOffset(t.startOffset, t.endOffset)
+	return true, nil
+}
@@ -0,0 +1,100 @@
+package highlight
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/store"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+/* Returns the tokens of ts as "term@position[start,end)". */
+func tokensOf(t *testing.T, ts analysis.TokenStream) []string {
+	termAtt := ts.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	posIncAtt := ts.Attributes().Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	offsetAtt := ts.Attributes().Add("OffsetAttribute").(ta.OffsetAttribute)
+	if err := ts.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	position := -1
+	for {
+		ok, err := ts.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		position += posIncAtt.PositionIncrement()
+		tokens = append(tokens, fmt.Sprintf("%v@%v[%v,%v)",
+			string(termAtt.Buffer()[:termAtt.Length()]), position,
+			offsetAtt.StartOffset(), offsetAtt.EndOffset()))
+	}
+	if err := ts.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+func TestAnyTokenStreamFromStoredText(t *testing.T) {
+	d, err := store.OpenFSDirectory("../core/search/testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	doc, err := r.Document(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	title := doc.Get("title")
+
+	// the title has no term vectors, and is re-analyzed
+	ts, err := AnyTokenStream(r, 0, "title", std.NewStandardAnalyzer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := tokensOf(t, ts)
+	if len(tokens) == 0 {
+		t.Fatalf("expect tokens of %q", title)
+	}
+	// "Caring for your fruit bat"
+	if tokens[0] != "caring@0[0,6)" {
+		t.Errorf("expect the first token of %q, got %v", title, tokens[0])
+	}
+	if last, expected := tokens[len(tokens)-1], fmt.Sprintf("bat@4[%v,%v)", len(title)-3, len(title)); last != expected {
+		t.Errorf("expect the last token %v of %q, got %v", expected, title, last)
+	}
+
+	if ts, err = AnyTokenStream(r, 0, "nonexistent", std.NewStandardAnalyzer()); ts != nil || err != nil {
+		t.Errorf("expect no token stream of a missing field, got %v (%v)", ts, err)
+	}
+}
+
+func TestStoredTokenStream(t *testing.T) {
+	tokens := []*token{
+		{"quick", 1, 4, 9},
+		{"the", 0, 0, 3},
+		{"fast", 1, 4, 9},
+		{"fox", 3, 16, 19},
+	}
+	// sorted as by TokenStreamFromTermVector()
+	sort.Stable(tokensByPosition(tokens))
+	ts := newStoredTokenStream(tokens)
+	expected := []string{"the@0[0,3)", "quick@1[4,9)", "fast@1[4,9)", "fox@3[16,19)"}
+	if got := tokensOf(t, ts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expect %v, got %v", expected, got)
+	}
+}
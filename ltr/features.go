@@ -0,0 +1,246 @@
+/*
+Package ltr supports learning to rank: it extracts features of the
+hits of a query, either to log them as training data, with a
+FeatureLoggingCollector, or to score the top hits with a model
+trained offline, with a reranking query of NewModelRerankQuery().
+*/
+package ltr
+
+import (
+	"context"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"sort"
+	"strconv"
+)
+
+// ltr/feature/Feature.java
+
+/*
+Values of a feature in a segment. doc is relative to the segment, and
+must not decrease from a call to the next one.
+*/
+type FeatureValues func(doc int) (float32, error)
+
+/* Returns the values of a feature in a segment. */
+type FeatureExtractor func(ctx *index.AtomicReaderContext) (FeatureValues, error)
+
+/* A feature of the documents, e.g. their score for a query. */
+type Feature interface {
+	Name() string
+	/* Prepares the extraction of the feature from the docs searched by ss. */
+	Extractor(ss *search.IndexSearcher) (FeatureExtractor, error)
+}
+
+// ltr/feature/SolrFeature.java
+
+type queryFeature struct {
+	name  string
+	query search.Query
+}
+
+/*
+Returns the feature of the score of query for a document, or 0 if the
+document does not match, e.g. the BM25 score of the title field.
+*/
+func NewQueryFeature(name string, query search.Query) Feature {
+	return &queryFeature{name, query}
+}
+
+func (f *queryFeature) Name() string { return f.name }
+
+func (f *queryFeature) Extractor(ss *search.IndexSearcher) (FeatureExtractor, error) {
+	weight, err := ss.CreateNormalizedWeight(context.Background(), f.query)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx *index.AtomicReaderContext) (FeatureValues, error) {
+		scorer, err := weight.Scorer(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return func(doc int) (float32, error) {
+			if scorer == nil {
+				return 0, nil
+			}
+			if scorer.DocId() < doc {
+				if _, err := scorer.Advance(doc); err != nil {
+					return 0, err
+				}
+			}
+			if scorer.DocId() != doc {
+				return 0, nil
+			}
+			return scorer.Score()
+		}, nil
+	}, nil
+}
+
+func (f *queryFeature) String() string {
+	return fmt.Sprintf("%v=%v", f.name, f.query.ToString(""))
+}
+
+// ltr/feature/FieldValueFeature.java
+
+type valueFeature struct {
+	name   string
+	source search.FieldValueSource
+}
+
+/*
+Returns the feature of a numeric value of the documents, e.g. their
+popularity, read from source. Documents without a value get 0.
+*/
+func NewValueFeature(name string, source search.FieldValueSource) Feature {
+	return &valueFeature{name, source}
+}
+
+func (f *valueFeature) Name() string { return f.name }
+
+func (f *valueFeature) Extractor(ss *search.IndexSearcher) (FeatureExtractor, error) {
+	return func(ctx *index.AtomicReaderContext) (FeatureValues, error) {
+		values, err := f.source.Values(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func(doc int) (float32, error) {
+			v, err := values(doc)
+			if v == nil || err != nil {
+				return 0, err
+			}
+			return toFloat32(v)
+		}, nil
+	}, nil
+}
+
+func (f *valueFeature) String() string {
+	return fmt.Sprintf("%v=%v", f.name, f.source)
+}
+
+func toFloat32(v interface{}) (float32, error) {
+	switch n := v.(type) {
+	case float64:
+		return float32(n), nil
+	case float32:
+		return n, nil
+	case int64:
+		return float32(n), nil
+	case int:
+		return float32(n), nil
+	case string:
+		f, err := strconv.ParseFloat(n, 32)
+		return float32(f), err
+	}
+	return 0, fmt.Errorf("not a number: %v", v)
+}
+
+// ltr/FeatureLogger.java
+
+/* The ordered features given to a model, or logged for its training. */
+type FeatureSet struct {
+	features []Feature
+}
+
+func NewFeatureSet(features ...Feature) *FeatureSet {
+	names := make(map[string]bool)
+	for _, f := range features {
+		assert2(!names[f.Name()], "duplicate feature: %v", f.Name())
+		names[f.Name()] = true
+	}
+	return &FeatureSet{features}
+}
+
+/* Returns the names of the features, in order. */
+func (fs *FeatureSet) Names() []string {
+	ans := make([]string, len(fs.features))
+	for i, f := range fs.features {
+		ans[i] = f.Name()
+	}
+	return ans
+}
+
+func (fs *FeatureSet) extractors(ss *search.IndexSearcher) ([]FeatureExtractor, error) {
+	ans := make([]FeatureExtractor, len(fs.features))
+	for i, f := range fs.features {
+		var err error
+		if ans[i], err = f.Extractor(ss); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func segmentValues(extractors []FeatureExtractor, ctx *index.AtomicReaderContext) ([]FeatureValues, error) {
+	ans := make([]FeatureValues, len(extractors))
+	for i, e := range extractors {
+		var err error
+		if ans[i], err = e(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func vectorOf(values []FeatureValues, doc int) ([]float32, error) {
+	ans := make([]float32, len(values))
+	for i, v := range values {
+		var err error
+		if ans[i], err = v(doc); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+/*
+Returns the feature vectors of the hits, which have top level doc ids,
+in the order of the hits.
+*/
+func (fs *FeatureSet) Extract(ss *search.IndexSearcher, hits []*search.ScoreDoc) ([][]float32, error) {
+	extractors, err := fs.extractors(ss)
+	if err != nil {
+		return nil, err
+	}
+	// by doc, so that each segment is read once and in order
+	order := make(hitOrder, len(hits))
+	for i, hit := range hits {
+		order[i] = [2]int{hit.Doc, i}
+	}
+	sort.Sort(order)
+
+	ans := make([][]float32, len(hits))
+	leaves := ss.TopReaderContext().Leaves()
+	var ctx *index.AtomicReaderContext
+	var values []FeatureValues
+	for _, hit := range order {
+		doc, i := hit[0], hit[1]
+		if ctx == nil || doc >= ctx.DocBase+ctx.Reader().MaxDoc() {
+			ctx = leaves[index.SubIndex(doc, leaves)]
+			if values, err = segmentValues(extractors, ctx); err != nil {
+				return nil, err
+			}
+		}
+		if ans[i], err = vectorOf(values, doc-ctx.DocBase); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+/* Indexes of hits, as {doc, index} pairs, by doc. */
+type hitOrder [][2]int
+
+func (a hitOrder) Len() int           { return len(a) }
+func (a hitOrder) Less(i, j int) bool { return a[i][0] < a[j][0] }
+func (a hitOrder) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+func (fs *FeatureSet) String() string {
+	return fmt.Sprintf("%v", fs.features)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,73 @@
+package ltr
+
+import (
+	_ "github.com/balzaczyy/golucene/core/codec/lucene42"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"testing"
+)
+
+func TestFeatureLoggingAndReranking(t *testing.T) {
+	d, err := store.OpenFSDirectory("../core/search/testdata/belfrysample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ss := search.NewIndexSearcher(r)
+
+	bat := search.NewTermQuery(index.NewTerm("content", "bat"))
+	fruit := search.NewTermQuery(index.NewTerm("content", "fruit"))
+	fruitDocs, err := ss.SearchTop(fruit, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fruitDocs.TotalHits == 0 || fruitDocs.TotalHits >= r.NumDocs() {
+		t.Fatalf("expect some docs about fruit, got %v", fruitDocs.TotalHits)
+	}
+	fruitScores := make(map[int]float32)
+	for _, hit := range fruitDocs.ScoreDocs {
+		fruitScores[hit.Doc] = hit.Score
+	}
+	features := NewFeatureSet(NewQueryFeature("bat", bat), NewQueryFeature("fruit", fruit))
+
+	c, err := NewFeatureLoggingCollector(ss, features, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ss.SearchCollector(bat, nil, c); err != nil {
+		t.Fatal(err)
+	}
+	vectors := c.FeatureVectors()
+	if len(vectors) != r.NumDocs() {
+		t.Fatalf("expect a feature vector of each of %v docs, got %v", r.NumDocs(), len(vectors))
+	}
+	for _, v := range vectors {
+		if v.Features[0] != v.Score || v.Features[1] != fruitScores[v.Doc] {
+			t.Errorf("expect features [%v %v] of doc %v, got %v",
+				v.Score, fruitScores[v.Doc], v.Doc, v.Features)
+		}
+	}
+
+	// ranked by the fruit feature only, docs without fruit last
+	q := NewModelRerankQuery(bat, 5, "fruitOnly", features, LinearModel(0, 1))
+	docs, err := ss.SearchTop(q, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.TotalHits != 5 {
+		t.Fatalf("expect the 5 reranked hits, got %v", docs.TotalHits)
+	}
+	for i, hit := range docs.ScoreDocs {
+		if hit.Score != fruitScores[hit.Doc] {
+			t.Errorf("expect score %v of doc %v, got %v", fruitScores[hit.Doc], hit.Doc, hit.Score)
+		}
+		if i > 0 && hit.Score > docs.ScoreDocs[i-1].Score {
+			t.Errorf("expect hits by decreasing score, got %v", docs.ScoreDocs)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package ltr
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+/* The features of a hit, with its score for the query searched. */
+type FeatureVector struct {
+	Doc      int // top level doc id
+	Score    float32
+	Features []float32 // in the order of the FeatureSet
+}
+
+/*
+Collector logging the feature vector of each collected hit, e.g. to
+be joined with relevance judgments as training data of a model.
+Hits are also passed on to another collector, if any, e.g. to find
+the top hits to be judged.
+
+Features are extracted for every matching document, so that a
+selective query should be searched.
+*/
+type FeatureLoggingCollector struct {
+	delegate   search.Collector
+	extractors []FeatureExtractor
+	vectors    []FeatureVector
+
+	docBase int
+	values  []FeatureValues
+	scorer  search.Scorer
+	err     error // of SetNextReader(), returned by Collect()
+}
+
+/* Logs the features of the docs searched by ss; delegate may be nil. */
+func NewFeatureLoggingCollector(ss *search.IndexSearcher, features *FeatureSet,
+	delegate search.Collector) (*FeatureLoggingCollector, error) {
+
+	extractors, err := features.extractors(ss)
+	if err != nil {
+		return nil, err
+	}
+	return &FeatureLoggingCollector{delegate: delegate, extractors: extractors}, nil
+}
+
+func (c *FeatureLoggingCollector) SetScorer(s search.Scorer) {
+	c.scorer = s
+	if c.delegate != nil {
+		c.delegate.SetScorer(s)
+	}
+}
+
+func (c *FeatureLoggingCollector) SetNextReader(ctx *index.AtomicReaderContext) {
+	c.docBase = ctx.DocBase
+	c.values, c.err = segmentValues(c.extractors, ctx)
+	if c.delegate != nil {
+		c.delegate.SetNextReader(ctx)
+	}
+}
+
+func (c *FeatureLoggingCollector) Collect(doc int) error {
+	if c.err != nil {
+		return c.err
+	}
+	score, err := c.scorer.Score()
+	if err != nil {
+		return err
+	}
+	features, err := vectorOf(c.values, doc)
+	if err != nil {
+		return err
+	}
+	c.vectors = append(c.vectors, FeatureVector{c.docBase + doc, score, features})
+	if c.delegate != nil {
+		return c.delegate.Collect(doc)
+	}
+	return nil
+}
+
+/* Features are extracted in doc order only. */
+func (c *FeatureLoggingCollector) AcceptsDocsOutOfOrder() bool {
+	return false
+}
+
+/* Returns the feature vectors of the hits, in doc order. */
+func (c *FeatureLoggingCollector) FeatureVectors() []FeatureVector {
+	return c.vectors
+}
+
+// ltr/model/LTRScoringModel.java
+
+/* Scores a document from its feature vector, e.g. a linear model. */
+type Model func(features []float32) float32
+
+/* Returns a linear model, the sum of the features times their weights. */
+func LinearModel(weights ...float32) Model {
+	return func(features []float32) (score float32) {
+		assert2(len(features) == len(weights), "expect %v features, got %v", len(weights), len(features))
+		for i, f := range features {
+			score += weights[i] * f
+		}
+		return
+	}
+}
+
+// ltr/LTRRescorer.java
+
+type modelRescorer struct {
+	name     string
+	features *FeatureSet
+	model    Model
+}
+
+func (r *modelRescorer) Rescore(ss *search.IndexSearcher, hits []*search.ScoreDoc) error {
+	vectors, err := r.features.Extract(ss, hits)
+	if err != nil {
+		return err
+	}
+	for i, hit := range hits {
+		hit.Score = r.model(vectors[i])
+	}
+	return nil
+}
+
+func (r *modelRescorer) String() string {
+	return fmt.Sprintf("%v%v", r.name, r.features)
+}
+
+/*
+Returns a query reranking the top window hits of query by the score
+of model, given the features of each hit; see search.RerankQuery.
+name identifies the model in the string of the query and in
+explanations.
+*/
+func NewModelRerankQuery(query search.Query, window int, name string,
+	features *FeatureSet, model Model) *search.RerankQuery {
+
+	return search.NewRerankQuery(query, &modelRescorer{name, features, model}, window)
+}
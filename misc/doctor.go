@@ -0,0 +1,319 @@
+package misc
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"sort"
+)
+
+/* Thresholds of the checks of IndexDoctor. */
+type DoctorThresholds struct {
+	// More segments are reported, as each search visits every segment
+	MaxSegments int
+	// A segment smaller than this fraction of the index is small
+	SmallSegmentRatio float64
+	// Deleted docs of a segment, or of the index, above this fraction
+	MaxDeletedRatio float64
+	// A field with at least this fraction of distinct terms per doc is
+	// an identifier, e.g. a primary key
+	IdCardinalityRatio float64
+	// Fields are only checked for cardinality with at least these docs
+	MinDocsForCardinality int
+}
+
+var DEFAULT_DOCTOR_THRESHOLDS = DoctorThresholds{
+	MaxSegments:           20,
+	SmallSegmentRatio:     0.1, // as the default noCFSRatio of merge policies
+	MaxDeletedRatio:       0.2,
+	IdCardinalityRatio:    0.9,
+	MinDocsForCardinality: 100,
+}
+
+/* A problem found by IndexDoctor, and how to fix it. */
+type Finding struct {
+	Check          string // e.g. "segments" or "deletes"
+	Problem        string
+	Recommendation string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("[%v] %v\n  -> %v", f.Check, f.Problem, f.Recommendation)
+}
+
+/* The size and deletes of a segment. */
+type SegmentSummary struct {
+	Name        string
+	MaxDoc      int
+	DelCount    int
+	SizeInBytes int64
+	Compound    bool
+}
+
+/* The report of IndexDoctor.Diagnose(). */
+type DoctorReport struct {
+	Segments []SegmentSummary
+	// Empty if no problem was found
+	Findings []*Finding
+}
+
+func (r *DoctorReport) String() string {
+	var buf bytes.Buffer
+	var maxDoc, delCount int
+	var size int64
+	for _, s := range r.Segments {
+		maxDoc, delCount, size = maxDoc+s.MaxDoc, delCount+s.DelCount, size+s.SizeInBytes
+	}
+	fmt.Fprintf(&buf, "%v segments, %v docs (%v deleted), %v bytes\n",
+		len(r.Segments), maxDoc, delCount, size)
+	if len(r.Findings) == 0 {
+		buf.WriteString("no problem found\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&buf, "%v\n", f)
+	}
+	return buf.String()
+}
+
+/*
+Runs a battery of static checks on an index, reporting problems with
+actionable recommendations:
+
+  - segments: too many segments, or many small ones;
+  - deletes: segments, or an index, with too many deleted docs;
+  - compound files: small segments not in compound files, each costing
+    several open files;
+  - norms and doc values: fields indexed with norms, or with doc values,
+    in some segments only;
+  - cardinality: identifier fields, with about a distinct term per doc,
+    indexed with norms or positions that they do not need.
+
+The index is only read. Checking cardinalities reads the terms
+dictionary of each indexed field, but no postings.
+*/
+type IndexDoctor struct {
+	thresholds DoctorThresholds
+}
+
+func NewIndexDoctor(thresholds DoctorThresholds) *IndexDoctor {
+	return &IndexDoctor{thresholds}
+}
+
+/* Checks the last commit of the index in dir. */
+func (d *IndexDoctor) Diagnose(dir store.Directory) (*DoctorReport, error) {
+	r, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return d.DiagnoseReader(r)
+}
+
+/* Checks the segments of r, which must be SegmentReaders. */
+func (d *IndexDoctor) DiagnoseReader(r index.IndexReader) (*DoctorReport, error) {
+	ans := new(DoctorReport)
+	var readers []*index.SegmentReader
+	for _, ctx := range r.Leaves() {
+		sr, ok := ctx.Reader().(*index.SegmentReader)
+		if !ok {
+			return nil, fmt.Errorf("not a segment reader: %v", ctx.Reader())
+		}
+		info := sr.SegmentInfos()
+		size, err := info.SizeInBytes()
+		if err != nil {
+			return nil, err
+		}
+		ans.Segments = append(ans.Segments, SegmentSummary{
+			Name:        info.Info.Name,
+			MaxDoc:      sr.MaxDoc(),
+			DelCount:    info.DelCount(),
+			SizeInBytes: size,
+			Compound:    info.Info.IsCompoundFile(),
+		})
+		readers = append(readers, sr)
+	}
+	d.checkSegments(ans)
+	d.checkDeletes(ans)
+	d.checkCompoundFiles(ans)
+	d.checkFieldInfos(ans, readers)
+	if err := d.checkCardinalities(ans, r, readers); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+func (d *IndexDoctor) report(r *DoctorReport, check, recommendation, problem string, args ...interface{}) {
+	r.Findings = append(r.Findings, &Finding{check, fmt.Sprintf(problem, args...), recommendation})
+}
+
+func totalSize(segments []SegmentSummary) (size int64) {
+	for _, s := range segments {
+		size += s.SizeInBytes
+	}
+	return
+}
+
+func (d *IndexDoctor) checkSegments(r *DoctorReport) {
+	if len(r.Segments) > d.thresholds.MaxSegments {
+		d.report(r, "segments", "force-merge the index, e.g. to a single segment if it is no longer updated",
+			"%v segments, more than %v", len(r.Segments), d.thresholds.MaxSegments)
+		return
+	}
+	total := totalSize(r.Segments)
+	var small []string
+	for _, s := range r.Segments {
+		if float64(s.SizeInBytes) < d.thresholds.SmallSegmentRatio*float64(total) {
+			small = append(small, s.Name)
+		}
+	}
+	// a merge policy tolerates a few small segments
+	if len(r.Segments) > 2 && len(small) > len(r.Segments)/2 {
+		d.report(r, "segments", "force-merge the small segments, or flush larger segments with a bigger RAM buffer",
+			"%v of %v segments are smaller than %v%% of the index: %v",
+			len(small), len(r.Segments), d.thresholds.SmallSegmentRatio*100, small)
+	}
+}
+
+func (d *IndexDoctor) checkDeletes(r *DoctorReport) {
+	var maxDoc, delCount int
+	for _, s := range r.Segments {
+		maxDoc, delCount = maxDoc+s.MaxDoc, delCount+s.DelCount
+		if s.MaxDoc > 0 && float64(s.DelCount) > d.thresholds.MaxDeletedRatio*float64(s.MaxDoc) {
+			d.report(r, "deletes", "expunge deletes, merging the segments with deleted docs",
+				"segment %v has %v deleted docs of %v", s.Name, s.DelCount, s.MaxDoc)
+		}
+	}
+	if maxDoc > 0 && float64(delCount) > d.thresholds.MaxDeletedRatio*float64(maxDoc) {
+		d.report(r, "deletes", "force-merge the index, as deleted docs still cost disk and search time",
+			"the index has %v deleted docs of %v", delCount, maxDoc)
+	}
+}
+
+func (d *IndexDoctor) checkCompoundFiles(r *DoctorReport) {
+	total := totalSize(r.Segments)
+	var notCompound []string
+	for _, s := range r.Segments {
+		if !s.Compound && len(r.Segments) > 1 &&
+			float64(s.SizeInBytes) < d.thresholds.SmallSegmentRatio*float64(total) {
+			notCompound = append(notCompound, s.Name)
+		}
+	}
+	if len(notCompound) > 0 {
+		d.report(r, "compound files", "enable compound files, with IndexWriterConfig.SetUseCompoundFile(true)",
+			"small segments are not compound files, each opening several files: %v", notCompound)
+	}
+}
+
+/* The segments of a field, by whether they have a property. */
+type fieldSegments struct {
+	with, without []string
+}
+
+func (d *IndexDoctor) checkFieldInfos(r *DoctorReport, readers []*index.SegmentReader) {
+	norms := make(map[string]*fieldSegments)
+	docValues := make(map[string]*fieldSegments)
+	docValuesTypes := make(map[string]map[DocValuesType]bool)
+	for _, sr := range readers {
+		for _, fi := range sr.FieldInfos().Values {
+			name := sr.SegmentName()
+			if fi.IsIndexed() {
+				addSegment(norms, fi.Name, !fi.OmitsNorms(), name)
+			}
+			addSegment(docValues, fi.Name, fi.HasDocValues(), name)
+			if fi.HasDocValues() {
+				if docValuesTypes[fi.Name] == nil {
+					docValuesTypes[fi.Name] = make(map[DocValuesType]bool)
+				}
+				docValuesTypes[fi.Name][fi.DocValuesType()] = true
+			}
+		}
+	}
+	for _, field := range sortedKeys(norms) {
+		if s := norms[field]; len(s.with) > 0 && len(s.without) > 0 {
+			d.report(r, "norms", "index the field with the same field type in all documents; "+
+				"norms are dropped once segments are merged",
+				"field %v has norms in segments %v, but omits them in %v", field, s.with, s.without)
+		}
+	}
+	for _, field := range sortedKeys(docValues) {
+		if s := docValues[field]; len(s.with) > 0 && len(s.without) > 0 {
+			d.report(r, "doc values", "add the doc values of the field to all documents, and reindex the others",
+				"field %v has doc values in segments %v, but not in %v", field, s.with, s.without)
+		}
+		if len(docValuesTypes[field]) > 1 {
+			d.report(r, "doc values", "reindex the field with a single doc values type",
+				"field %v has doc values of several types", field)
+		}
+	}
+}
+
+func addSegment(m map[string]*fieldSegments, field string, with bool, segment string) {
+	s := m[field]
+	if s == nil {
+		s = new(fieldSegments)
+		m[field] = s
+	}
+	if with {
+		s.with = append(s.with, segment)
+	} else {
+		s.without = append(s.without, segment)
+	}
+}
+
+func sortedKeys(m map[string]*fieldSegments) []string {
+	ans := make([]string, 0, len(m))
+	for k := range m {
+		ans = append(ans, k)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+func (d *IndexDoctor) checkCardinalities(r *DoctorReport, reader index.IndexReader, readers []*index.SegmentReader) error {
+	// whether a field has norms or positions in any segment
+	wasteful := make(map[string]bool)
+	var names []string
+	for _, sr := range readers {
+		for _, fi := range sr.FieldInfos().Values {
+			if !fi.IsIndexed() {
+				continue
+			}
+			if _, ok := wasteful[fi.Name]; !ok {
+				names = append(names, fi.Name)
+			}
+			wasteful[fi.Name] = wasteful[fi.Name] || !fi.OmitsNorms() ||
+				fi.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS
+		}
+	}
+	sort.Strings(names)
+	for _, field := range names {
+		var docCount int
+		var sumDocFreq int64
+		for _, sr := range readers {
+			if terms := sr.Terms(field); terms != nil && terms.DocCount() > 0 {
+				docCount += terms.DocCount()
+				sumDocFreq += terms.SumDocFreq()
+			}
+		}
+		// an identifier has a single term per doc, unlike a text
+		ratio := d.thresholds.IdCardinalityRatio
+		if docCount < d.thresholds.MinDocsForCardinality || ratio*float64(sumDocFreq) > float64(docCount) {
+			continue
+		}
+		var cardinality int
+		if err := ExportTerms(reader, field, false, func(TermStats) error {
+			cardinality++
+			return nil
+		}); err != nil {
+			return err
+		}
+		if wasteful[field] && float64(cardinality) >= ratio*float64(docCount) {
+			d.report(r, "cardinality", "index the field as a StringField, without norms nor positions",
+				"field %v looks like an identifier, with %v distinct terms in %v docs, "+
+					"but is indexed with norms or positions", field, cardinality, docCount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package misc
+
+import (
+	"fmt"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"strings"
+	"testing"
+)
+
+func findingsOf(r *DoctorReport, check string) (ans []*Finding) {
+	for _, f := range r.Findings {
+		if f.Check == check {
+			ans = append(ans, f)
+		}
+	}
+	return
+}
+
+func TestIndexDoctor(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	dir, closeDir := openTempDir(t)
+	defer closeDir()
+	w := newWriter(t, dir)
+	commit := func(docs ...*docu.Document) {
+		for _, d := range docs {
+			if err := w.AddDocument(d.Fields()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a large segment, with an identifier indexed as text
+	var docs []*docu.Document
+	for i := 0; i < 50; i++ {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("id", fmt.Sprintf("id%v", i), docu.STORE_NO))
+		d.Add(docu.NewTextFieldFromString("title", "title of a long document", docu.STORE_NO))
+		d.Add(docu.NewTextFieldFromString("body", "the quick brown fox jumps over the lazy dog", docu.STORE_NO))
+		docs = append(docs, d)
+	}
+	commit(docs...)
+	// small segments, one of them without the norms of title
+	noNorms := docu.NewFieldTypeFrom(docu.TEXT_FIELD_TYPE_NOT_STORED)
+	noNorms.SetOmitNorms(true)
+	d := docu.NewDocument()
+	d.Add(docu.NewFieldFromString("title", "short", noNorms))
+	commit(d)
+	d = docu.NewDocument()
+	d.Add(docu.NewTextFieldFromString("title", "short", docu.STORE_NO))
+	commit(d)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	thresholds := DEFAULT_DOCTOR_THRESHOLDS
+	// bulk packing of large norms is not supported yet
+	thresholds.MinDocsForCardinality = 20
+	// file headers are most of the size of a tiny segment
+	thresholds.SmallSegmentRatio = 0.25
+	report, err := NewIndexDoctor(thresholds).Diagnose(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Segments) != 3 {
+		t.Fatalf("Expected 3 segments, got %v", report)
+	}
+	if f := findingsOf(report, "segments"); len(f) != 1 {
+		t.Errorf("Expected small segments, got %v", report)
+	}
+	if f := findingsOf(report, "norms"); len(f) != 1 || !strings.Contains(f[0].Problem, "field title") {
+		t.Errorf("Expected inconsistent norms of title, got %v", report)
+	}
+	if f := findingsOf(report, "cardinality"); len(f) != 1 || !strings.Contains(f[0].Problem, "field id") {
+		t.Errorf("Expected id to be reported as an identifier, got %v", report)
+	}
+	if f := findingsOf(report, "deletes"); len(f) != 0 {
+		t.Errorf("Expected no deletes, got %v", report)
+	}
+	if f := findingsOf(report, "compound files"); len(f) != 0 {
+		t.Errorf("Expected compound files, got %v", report)
+	}
+
+	// deletes are not applied by IndexWriter yet, nor are compound files optional
+	report = &DoctorReport{Segments: []SegmentSummary{
+		{Name: "_0", MaxDoc: 100, DelCount: 10, SizeInBytes: 1000, Compound: true},
+		{Name: "_1", MaxDoc: 10, DelCount: 5, SizeInBytes: 100, Compound: true},
+	}}
+	NewIndexDoctor(DEFAULT_DOCTOR_THRESHOLDS).checkDeletes(report)
+	if f := findingsOf(report, "deletes"); len(f) != 1 || !strings.Contains(f[0].Problem, "segment _1") {
+		t.Errorf("Expected the deletes of _1, got %v", report)
+	}
+	report.Segments[1].Compound = false
+	NewIndexDoctor(DEFAULT_DOCTOR_THRESHOLDS).checkCompoundFiles(report)
+	if f := findingsOf(report, "compound files"); len(f) != 1 || !strings.Contains(f[0].Problem, "[_1]") {
+		t.Errorf("Expected _1 not to be a compound file, got %v", report)
+	}
+}
@@ -0,0 +1,127 @@
+package misc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+)
+
+// codecs/bloom/FuzzySet.java
+
+/*
+A bloom filter of terms: MayContain() is false for a term which was
+never added, and true for an added term, or for another term with
+about the false positive probability the set was created with.
+*/
+type FuzzySet struct {
+	bits      []int64
+	numBits   uint32
+	hashCount int
+}
+
+/*
+Creates a set sized for numItems terms with a false positive
+probability of fpp, in (0,1).
+*/
+func NewFuzzySet(numItems int, fpp float64) *FuzzySet {
+	if fpp <= 0 || fpp >= 1 {
+		panic(fmt.Sprintf("false positive probability must be in (0,1): %v", fpp))
+	}
+	if numItems < 1 {
+		numItems = 1
+	}
+	numBits := math.Ceil(-float64(numItems) * math.Log(fpp) / (math.Ln2 * math.Ln2))
+	numBits = math.Min(math.Max(numBits, 64), math.MaxInt32)
+	hashCount := int(math.Max(1, math.Round(numBits/float64(numItems)*math.Ln2)))
+	return newFuzzySet(uint32(numBits), hashCount)
+}
+
+func newFuzzySet(numBits uint32, hashCount int) *FuzzySet {
+	return &FuzzySet{make([]int64, (numBits+63)/64), numBits, hashCount}
+}
+
+/* Returns the bits of term, by double hashing of its murmur hash. */
+func (s *FuzzySet) positions(term []byte, fn func(pos uint32) bool) bool {
+	h1 := util.MurmurHash3_x86_32(term, 0)
+	h2 := util.MurmurHash3_x86_32(term, h1) | 1
+	for i := 0; i < s.hashCount; i++ {
+		if !fn((h1 + uint32(i)*h2) % s.numBits) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *FuzzySet) Add(term []byte) {
+	s.positions(term, func(pos uint32) bool {
+		s.bits[pos>>6] |= 1 << (pos & 63)
+		return true
+	})
+}
+
+/* Returns false if term was not added, true if it probably was. */
+func (s *FuzzySet) MayContain(term []byte) bool {
+	return s.positions(term, func(pos uint32) bool {
+		return s.bits[pos>>6]&(1<<(pos&63)) != 0
+	})
+}
+
+/* Returns the memory used by the bits of the set. */
+func (s *FuzzySet) RamBytesUsed() int64 {
+	return int64(len(s.bits)) * 8
+}
+
+const FUZZY_SET_VERSION = 1
+
+/* Writes the set, to be read back with LoadFuzzySet(). */
+func (s *FuzzySet) Save(out util.DataOutput) error {
+	if err := out.WriteInt(FUZZY_SET_VERSION); err != nil {
+		return err
+	}
+	if err := out.WriteVInt(int32(s.hashCount)); err != nil {
+		return err
+	}
+	if err := out.WriteInt(int32(s.numBits)); err != nil {
+		return err
+	}
+	for _, v := range s.bits {
+		if err := out.WriteLong(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Reads a set written by FuzzySet.Save(). */
+func LoadFuzzySet(in util.DataInput) (*FuzzySet, error) {
+	version, err := in.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+	if version != FUZZY_SET_VERSION {
+		return nil, errors.New(fmt.Sprintf("unknown FuzzySet version: %v", version))
+	}
+	hashCount, err := in.ReadVInt()
+	if err != nil {
+		return nil, err
+	}
+	numBits, err := in.ReadInt()
+	if err != nil {
+		return nil, err
+	}
+	if hashCount < 1 || numBits < 1 {
+		return nil, errors.New(fmt.Sprintf("corrupt FuzzySet: %v hashes of %v bits", hashCount, numBits))
+	}
+	ans := newFuzzySet(uint32(numBits), int(hashCount))
+	for i := range ans.bits {
+		if ans.bits[i], err = in.ReadLong(); err != nil {
+			return nil, err
+		}
+	}
+	return ans, nil
+}
+
+func (s *FuzzySet) String() string {
+	return fmt.Sprintf("FuzzySet(bits=%v, hashes=%v)", s.numBits, s.hashCount)
+}
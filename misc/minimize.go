@@ -0,0 +1,479 @@
+package misc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	. "github.com/balzaczyy/golucene/core/codec/spi"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+/* How a field is rewritten by IndexMinimizer. */
+type FieldRewrite struct {
+	// Remove the field entirely, both postings and stored values
+	Drop bool
+	// Keep the postings, but not the stored values
+	DropStored bool
+	// Index docs and freqs only
+	OmitPositions bool
+	OmitNorms     bool
+	// If > 0, keep only the K postings with the highest term frequency
+	// of each term (ties broken by doc ID). Implies OmitPositions.
+	TopK int
+}
+
+/*
+Rewrites a full (archival) index into a smaller serving index, by
+dropping fields, positions or norms of fields, or all but the top-K
+postings of each term.
+
+Postings are rebuilt by uninverting the source index, so positions
+cannot be recovered from them. A field which keeps its positions is
+instead re-analyzed from its stored value, with the analyzer given to
+NewIndexMinimizer, which hence must be the analyzer the source index
+was built with. Minimize() fails if such a field is not stored, or
+its stored value is binary.
+
+Rebuilt postings are written in term order with position increments of
+1, and norms are recomputed from the number of tokens, so index-time
+boosts are lost. Stored values and vectors are copied as is. Deleted
+documents are dropped; live documents keep their relative order.
+
+Segments are uninverted a window of documents at a time (see
+SetWindowSize()), which bounds the memory used, at the cost of
+enumerating the terms of the segment once per window.
+
+Doc values, term vectors and numeric stored values cannot be
+rewritten yet: Minimize() fails unless their fields are dropped.
+*/
+type IndexMinimizer struct {
+	analyzer   analysis.Analyzer
+	fields     map[string]FieldRewrite
+	windowSize int
+}
+
+/* Default number of documents uninverted at a time. */
+const DEFAULT_MINIMIZER_WINDOW_SIZE = 4096
+
+func NewIndexMinimizer(analyzer analysis.Analyzer) *IndexMinimizer {
+	return &IndexMinimizer{analyzer, make(map[string]FieldRewrite), DEFAULT_MINIMIZER_WINDOW_SIZE}
+}
+
+/* Sets how the field is rewritten. Fields without rewrite are copied. */
+func (m *IndexMinimizer) SetFieldRewrite(field string, rewrite FieldRewrite) *IndexMinimizer {
+	m.fields[field] = rewrite
+	return m
+}
+
+/*
+Sets the number of documents whose postings are uninverted at a time.
+Default is DEFAULT_MINIMIZER_WINDOW_SIZE.
+*/
+func (m *IndexMinimizer) SetWindowSize(windowSize int) *IndexMinimizer {
+	assert2(windowSize > 0, "windowSize must be positive, got %v", windowSize)
+	m.windowSize = windowSize
+	return m
+}
+
+type termFreq struct {
+	term string
+	freq int
+}
+
+type posting struct {
+	doc, freq int
+}
+
+/* Postings ordered by descending freq, then by doc ID */
+type byFreq []posting
+
+func (a byFreq) Len() int      { return len(a) }
+func (a byFreq) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byFreq) Less(i, j int) bool {
+	if a[i].freq != a[j].freq {
+		return a[i].freq > a[j].freq
+	}
+	return a[i].doc < a[j].doc
+}
+
+/*
+Adds the rewritten documents of the source reader to the writer. The
+caller commits and closes the writer.
+*/
+func (m *IndexMinimizer) Minimize(src index.IndexReader, dest *index.IndexWriter) error {
+	for _, ctx := range src.Leaves() {
+		if err := m.minimizeSegment(ctx.Reader().(index.AtomicReader), dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* How a field of one segment is rebuilt */
+type fieldPlan struct {
+	info      *FieldInfo
+	rewrite   FieldRewrite
+	reanalyze bool // from the stored value
+	ft        *docu.FieldType
+	// the last posting kept of each term, if pruned to the top-K
+	cutoffs map[string]posting
+}
+
+/* Returns true if the posting is among the top-K of its term. */
+func (p *fieldPlan) keep(term string, doc, freq int) bool {
+	if p.cutoffs == nil {
+		return true
+	}
+	cutoff, ok := p.cutoffs[term]
+	return !ok || freq > cutoff.freq || freq == cutoff.freq && doc <= cutoff.doc
+}
+
+/* The postings of the documents of a window, per doc and field. */
+type window struct {
+	start   int
+	fields  []map[string][]termFreq
+	present []map[string]bool // docs with postings of reanalyzed fields
+}
+
+func (m *IndexMinimizer) minimizeSegment(r index.AtomicReader, dest *index.IndexWriter) error {
+	sr, ok := r.(interface {
+		FieldInfos() FieldInfos
+	})
+	if !ok {
+		return errors.New(fmt.Sprintf("field infos not available from reader: %v", r))
+	}
+	liveDocs := r.LiveDocs()
+	maxDoc := r.MaxDoc()
+
+	plans := make(map[string]*fieldPlan)
+	vectors := make(map[string]VectorValues)
+	for _, fi := range sr.FieldInfos().Values {
+		rewrite := m.fields[fi.Name]
+		if rewrite.Drop {
+			continue
+		}
+		if fi.HasDocValues() {
+			return errors.New(fmt.Sprintf(
+				"cannot minimize doc values of field '%v' (not implemented yet); drop the field", fi.Name))
+		}
+		if fi.HasVectors() {
+			return errors.New(fmt.Sprintf(
+				"cannot minimize term vectors of field '%v' (not implemented yet); drop the field", fi.Name))
+		}
+		if fi.VectorDimension() > 0 {
+			values, err := r.VectorValues(fi.Name)
+			if err != nil {
+				return err
+			}
+			if values != nil {
+				vectors[fi.Name] = values
+			}
+		}
+		if !fi.IsIndexed() {
+			continue
+		}
+		plan := &fieldPlan{info: fi, rewrite: rewrite}
+		plans[fi.Name] = plan
+		plan.reanalyze = fi.IndexOptions() >= INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS &&
+			!rewrite.OmitPositions && rewrite.TopK <= 0
+		plan.ft = docu.NewFieldType()
+		plan.ft.SetIndexed(true)
+		plan.ft.SetOmitNorms(fi.OmitsNorms() || rewrite.OmitNorms)
+		if plan.reanalyze {
+			plan.ft.SetStored(!rewrite.DropStored)
+			plan.ft.SetIndexOptions(fi.IndexOptions())
+		} else if fi.IndexOptions() == INDEX_OPT_DOCS_ONLY {
+			plan.ft.SetIndexOptions(INDEX_OPT_DOCS_ONLY)
+		} else {
+			plan.ft.SetIndexOptions(INDEX_OPT_DOCS_AND_FREQS)
+		}
+		plan.ft.Freeze()
+		if rewrite.TopK > 0 {
+			if err := plan.computeCutoffs(r, liveDocs); err != nil {
+				return err
+			}
+		}
+	}
+
+	for start := 0; start < maxDoc; start += m.windowSize {
+		end := start + m.windowSize
+		if end > maxDoc {
+			end = maxDoc
+		}
+		w := &window{
+			start:   start,
+			fields:  make([]map[string][]termFreq, end-start),
+			present: make([]map[string]bool, end-start),
+		}
+		for _, plan := range plans {
+			if err := plan.uninvert(r, liveDocs, w); err != nil {
+				return err
+			}
+		}
+		for doc := start; doc < end; doc++ {
+			if liveDocs != nil && !liveDocs.At(doc) {
+				continue
+			}
+			fields, err := m.rewriteDocument(r, doc, plans, w)
+			if err != nil {
+				return err
+			}
+			for name, values := range vectors {
+				if v := vectorOf(values, doc); v != nil {
+					fields = append(fields, docu.NewVectorField(name, v, values.Similarity()))
+				}
+			}
+			if err = dest.AddDocumentWithAnalyzer(fields, m.analyzer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/* Returns the vector of the doc, or nil if it has none. */
+func vectorOf(values VectorValues, doc int) []float32 {
+	size := values.Size()
+	ord := sort.Search(size, func(i int) bool { return values.Doc(i) >= doc })
+	if ord < size && values.Doc(ord) == doc {
+		return values.VectorValue(ord)
+	}
+	return nil
+}
+
+/* Records the last posting of each term within its top-K. */
+func (p *fieldPlan) computeCutoffs(r index.AtomicReader, liveDocs util.Bits) error {
+	p.cutoffs = make(map[string]posting)
+	return p.forEachTerm(r, liveDocs, func(term []byte, docsEnum DocsEnum) error {
+		var list []posting
+		for {
+			doc, err := docsEnum.NextDoc()
+			if err != nil {
+				return err
+			}
+			if doc == NO_MORE_DOCS {
+				break
+			}
+			freq, err := p.freq(docsEnum)
+			if err != nil {
+				return err
+			}
+			list = append(list, posting{doc, freq})
+		}
+		if k := p.rewrite.TopK; len(list) > k {
+			sort.Sort(byFreq(list))
+			p.cutoffs[string(term)] = list[k-1]
+		}
+		return nil
+	})
+}
+
+/* Collects the kept postings of the documents of the window. */
+func (p *fieldPlan) uninvert(r index.AtomicReader, liveDocs util.Bits, w *window) error {
+	name := p.info.Name
+	end := w.start + len(w.fields)
+	return p.forEachTerm(r, liveDocs, func(term []byte, docsEnum DocsEnum) error {
+		text := string(term)
+		doc, err := docsEnum.Advance(w.start)
+		for ; err == nil && doc < end; doc, err = docsEnum.NextDoc() {
+			i := doc - w.start
+			if p.reanalyze { // only whether the field is present matters
+				if w.present[i] == nil {
+					w.present[i] = make(map[string]bool)
+				}
+				w.present[i][name] = true
+				continue
+			}
+			var freq int
+			if freq, err = p.freq(docsEnum); err != nil {
+				return err
+			}
+			if !p.keep(text, doc, freq) {
+				continue
+			}
+			if w.fields[i] == nil {
+				w.fields[i] = make(map[string][]termFreq)
+			}
+			w.fields[i][name] = append(w.fields[i][name], termFreq{text, freq})
+		}
+		return err
+	})
+}
+
+/* Calls f with the postings of each term of the field. */
+func (p *fieldPlan) forEachTerm(r index.AtomicReader, liveDocs util.Bits,
+	f func(term []byte, docsEnum DocsEnum) error) error {
+
+	terms := r.Terms(p.info.Name)
+	if terms == nil {
+		return nil
+	}
+	flags := 0
+	if !p.reanalyze && p.info.IndexOptions() != INDEX_OPT_DOCS_ONLY {
+		flags = DOCS_ENUM_FLAG_FREQS
+	}
+	termsEnum := terms.Iterator(nil)
+	var docsEnum DocsEnum
+	for {
+		term, err := termsEnum.Next()
+		if err != nil {
+			return err
+		}
+		if term == nil {
+			return nil
+		}
+		if docsEnum, err = termsEnum.DocsByFlags(liveDocs, docsEnum, flags); err != nil {
+			return err
+		}
+		if err = f(term, docsEnum); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *fieldPlan) freq(docsEnum DocsEnum) (int, error) {
+	if p.info.IndexOptions() == INDEX_OPT_DOCS_ONLY {
+		return 1, nil
+	}
+	return docsEnum.Freq()
+}
+
+/* Returns the rewritten fields of the document. */
+func (m *IndexMinimizer) rewriteDocument(r index.AtomicReader, doc int,
+	plans map[string]*fieldPlan, w *window) ([]IndexableField, error) {
+
+	loader := &storedFieldsLoader{fields: m.fields}
+	if err := r.VisitDocument(doc, loader); err != nil {
+		return nil, err
+	}
+	present := w.present[doc-w.start]
+	var fields []IndexableField
+	reanalyzed := make(map[string]bool)
+	for _, f := range loader.stored {
+		name := f.Name()
+		if plan, ok := plans[name]; ok && plan.reanalyze {
+			if f.BinaryValue() != nil {
+				return nil, errors.New(fmt.Sprintf(
+					"cannot re-analyze binary field '%v' of doc %v; omit its positions", name, doc))
+			}
+			if plan.ft.Stored() || present[name] {
+				fields = append(fields, docu.NewFieldFromString(name, f.StringValue(), plan.ft))
+			}
+			reanalyzed[name] = true
+		} else if !m.fields[name].DropStored {
+			fields = append(fields, f)
+		}
+	}
+	for name := range present {
+		if !reanalyzed[name] {
+			return nil, errors.New(fmt.Sprintf(
+				"cannot recover positions of field '%v' of doc %v as it is not stored; omit its positions", name, doc))
+		}
+	}
+	docFields := w.fields[doc-w.start]
+	var names []string
+	for name := range docFields {
+		names = append(names, name)
+	}
+	sort.Strings(names) // stable field order
+	for _, name := range names {
+		ts := newTermFreqTokenStream(docFields[name])
+		fields = append(fields, docu.NewFieldFromTokenStream(name, ts, plans[name].ft))
+	}
+	return fields, nil
+}
+
+/*
+Loads the stored values of the fields which are not dropped, as
+stored-only fields, keeping binary values binary.
+*/
+type storedFieldsLoader struct {
+	fields map[string]FieldRewrite
+	stored []*docu.Field
+}
+
+func (l *storedFieldsLoader) BinaryField(fi *FieldInfo, value []byte) error {
+	l.stored = append(l.stored, docu.NewFieldFromBytes(fi.Name, value, docu.STORED_FIELD_TYPE))
+	return nil
+}
+
+func (l *storedFieldsLoader) StringField(fi *FieldInfo, value string) error {
+	l.stored = append(l.stored, docu.NewFieldFromString(fi.Name, value, docu.STORED_FIELD_TYPE))
+	return nil
+}
+
+func (l *storedFieldsLoader) numericField(fi *FieldInfo) error {
+	return errors.New(fmt.Sprintf(
+		"cannot minimize numeric stored field '%v' (not implemented yet); drop the field", fi.Name))
+}
+
+func (l *storedFieldsLoader) IntField(fi *FieldInfo, value int) error {
+	return l.numericField(fi)
+}
+
+func (l *storedFieldsLoader) LongField(fi *FieldInfo, value int64) error {
+	return l.numericField(fi)
+}
+
+func (l *storedFieldsLoader) FloatField(fi *FieldInfo, value float32) error {
+	return l.numericField(fi)
+}
+
+func (l *storedFieldsLoader) DoubleField(fi *FieldInfo, value float64) error {
+	return l.numericField(fi)
+}
+
+func (l *storedFieldsLoader) NeedsField(fi *FieldInfo) (StoredFieldVisitorStatus, error) {
+	if l.fields[fi.Name].Drop {
+		return STORED_FIELD_VISITOR_STATUS_NO, nil
+	}
+	return STORED_FIELD_VISITOR_STATUS_YES, nil
+}
+
+/* Emits each term as many times as its frequency. */
+type termFreqTokenStream struct {
+	*analysis.TokenStreamImpl
+	termAtt   CharTermAttribute
+	posIncAtt PositionIncrementAttribute
+	terms     []termFreq
+	upto, n   int
+}
+
+func newTermFreqTokenStream(terms []termFreq) *termFreqTokenStream {
+	ans := &termFreqTokenStream{TokenStreamImpl: analysis.NewTokenStream(), terms: terms}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(PositionIncrementAttribute)
+	return ans
+}
+
+func (ts *termFreqTokenStream) Reset() error {
+	ts.upto, ts.n = 0, 0
+	return nil
+}
+
+func (ts *termFreqTokenStream) IncrementToken() (bool, error) {
+	for ts.upto < len(ts.terms) && ts.n >= ts.terms[ts.upto].freq {
+		ts.upto++
+		ts.n = 0
+	}
+	if ts.upto >= len(ts.terms) {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	ts.termAtt.AppendString(ts.terms[ts.upto].term)
+	ts.posIncAtt.SetPositionIncrement(1)
+	ts.n++
+	return true, nil
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
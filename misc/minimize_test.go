@@ -0,0 +1,162 @@
+package misc
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func openTempDir(t *testing.T) (store.Directory, func()) {
+	path, err := ioutil.TempDir("", "gltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := store.OpenFSDirectory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() {
+		dir.Close()
+		os.RemoveAll(path)
+	}
+}
+
+func newWriter(t *testing.T, dir store.Directory) *index.IndexWriter {
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	w, err := index.NewIndexWriter(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestIndexMinimizer(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	src, closeSrc := openTempDir(t)
+	defer closeSrc()
+	w := newWriter(t, src)
+	// same length in each doc, as norm tables are not supported yet
+	for i, text := range []string{
+		"fox fox fox jumps", "quick brown fox jumps", "lazy dog sleeps here",
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("id", string('a'+rune(i)), docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("title", "title "+text, docu.STORE_YES))
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		d.Add(docu.NewTextFieldFromString("tags", "archived", docu.STORE_YES))
+		d.Add(docu.NewStoredFieldFromBytes("raw", []byte{0, byte(i), 0xff}))
+		d.Add(docu.NewVectorField("vector", []float32{float32(i), 1}, VECTOR_SIMILARITY_COSINE))
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// positions of body cannot be recovered as it is not stored
+	dest, closeDest := openTempDir(t)
+	defer closeDest()
+	w = newWriter(t, dest)
+	if err = NewIndexMinimizer(std.NewStandardAnalyzer()).Minimize(r, w); err == nil {
+		t.Error("Expected error for unstored field with positions")
+	}
+	w.Rollback()
+
+	dest2, closeDest2 := openTempDir(t)
+	defer closeDest2()
+	w = newWriter(t, dest2)
+	err = NewIndexMinimizer(std.NewStandardAnalyzer()).
+		SetFieldRewrite("tags", FieldRewrite{Drop: true}).
+		SetFieldRewrite("body", FieldRewrite{TopK: 1, OmitNorms: true}).
+		SetWindowSize(2). // the top-K postings are selected across windows
+		Minimize(r, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := index.OpenDirectoryReader(dest2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r2.Close()
+	if r2.NumDocs() != 3 {
+		t.Fatalf("Expected 3 docs, but %v", r2.NumDocs())
+	}
+	docFreq := func(field, text string) int {
+		n, err := r2.DocFreq(index.NewTerm(field, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := docFreq("body", "fox"); n != 1 {
+		t.Errorf("Expected fox pruned to top-1 posting, but docFreq=%v", n)
+	}
+	if n := docFreq("title", "fox"); n != 2 {
+		t.Errorf("Expected title kept, but docFreq=%v", n)
+	}
+	if n := docFreq("id", "b"); n != 1 {
+		t.Errorf("Expected id kept, but docFreq=%v", n)
+	}
+	if n := docFreq("tags", "archived"); n != 0 {
+		t.Errorf("Expected tags dropped, but docFreq=%v", n)
+	}
+
+	infos := r2.Leaves()[0].Reader().(*index.SegmentReader).FieldInfos()
+	if fi := infos.FieldInfoByName("body"); fi == nil || fi.IndexOptions() != INDEX_OPT_DOCS_AND_FREQS || fi.HasNorms() {
+		t.Errorf("Expected body without positions and norms, but %v", fi)
+	}
+	if fi := infos.FieldInfoByName("title"); fi == nil || fi.IndexOptions() != INDEX_OPT_DOCS_AND_FREQS_AND_POSITIONS {
+		t.Errorf("Expected title with positions, but %v", fi)
+	}
+	if infos.FieldInfoByName("tags") != nil {
+		t.Error("Expected tags dropped")
+	}
+
+	d, err := r2.Document(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Get("id") != "a" || d.Get("title") != "title fox fox fox jumps" || d.Get("tags") != "" {
+		t.Errorf("Unexpected stored fields: %v", d.Fields())
+	}
+	if d, err = r2.Document(1); err != nil {
+		t.Fatal(err)
+	}
+	if raw := d.GetBinaryValue("raw"); string(raw) != string([]byte{0, 1, 0xff}) {
+		t.Errorf("Expected the binary value kept, but %v", raw)
+	}
+
+	values, err := r2.Leaves()[0].Reader().(index.AtomicReader).VectorValues("vector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values == nil || values.Size() != 3 {
+		t.Fatalf("Expected the 3 vectors kept, but %v", values)
+	}
+	for ord := 0; ord < 3; ord++ {
+		if v := values.VectorValue(ord); values.Doc(ord) != ord || v[0] != float32(ord) || v[1] != 1 {
+			t.Errorf("Expected [%v 1] for doc %v, but %v of doc %v", ord, ord, v, values.Doc(ord))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package misc
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/fst"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"io"
+	"math"
+)
+
+/* A term of the terms dictionary of a field. */
+type TermStats struct {
+	Term []byte
+	// Documents containing the term, deleted ones included; 0 if not
+	// exported.
+	DocFreq int
+	// Occurrences of the term, in deleted documents too; -1 if the
+	// field omits freqs, 0 if not exported.
+	TotalTermFreq int64
+}
+
+/* The terms of a field in a segment, and its current term. */
+type segmentTerms struct {
+	TermsEnum
+	term []byte
+}
+
+type segmentTermsQueue []*segmentTerms
+
+func (q segmentTermsQueue) Len() int      { return len(q) }
+func (q segmentTermsQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q segmentTermsQueue) Less(i, j int) bool {
+	return string(q[i].term) < string(q[j].term)
+}
+
+func (q *segmentTermsQueue) Push(x interface{}) { *q = append(*q, x.(*segmentTerms)) }
+func (q *segmentTermsQueue) Pop() interface{} {
+	old := *q
+	ans := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ans
+}
+
+/*
+Calls fn with each distinct term of field, in order, merging the terms
+dictionaries of the segments of r, without reading any posting. Term
+statistics are summed over the segments if withFreqs, e.g. for an
+autocorrect service to rank its suggestions by frequency.
+
+fn may keep the term, which is not reused. Exporting stops at the
+first error, which is returned.
+*/
+func ExportTerms(r index.IndexReader, field string, withFreqs bool, fn func(TermStats) error) error {
+	var queue segmentTermsQueue
+	for _, ctx := range r.Leaves() {
+		terms := ctx.Reader().(index.AtomicReader).Terms(field)
+		if terms == nil {
+			continue
+		}
+		st := &segmentTerms{TermsEnum: terms.Iterator(nil)}
+		var err error
+		if st.term, err = st.Next(); err != nil {
+			return err
+		} else if st.term != nil {
+			queue = append(queue, st)
+		}
+	}
+	heap.Init(&queue)
+	for len(queue) > 0 {
+		ans := TermStats{Term: append([]byte(nil), queue[0].term...)}
+		for len(queue) > 0 && string(queue[0].term) == string(ans.Term) {
+			top := queue[0]
+			if withFreqs {
+				if err := addStats(&ans, top); err != nil {
+					return err
+				}
+			}
+			var err error
+			if top.term, err = top.Next(); err != nil {
+				return err
+			} else if top.term != nil {
+				heap.Fix(&queue, 0)
+			} else {
+				heap.Pop(&queue)
+			}
+		}
+		if err := fn(ans); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addStats(stats *TermStats, te TermsEnum) error {
+	df, err := te.DocFreq()
+	if err != nil {
+		return err
+	}
+	stats.DocFreq += df
+	ttf, err := te.TotalTermFreq()
+	if err != nil {
+		return err
+	}
+	if ttf == -1 || stats.TotalTermFreq == -1 {
+		stats.TotalTermFreq = -1
+	} else {
+		stats.TotalTermFreq += ttf
+	}
+	return nil
+}
+
+/*
+Writes the terms of field to w, one per line, followed by their doc
+freq and total term freq if withFreqs, separated by tabs. Terms are
+written as is, and should not contain tabs nor new lines.
+*/
+func WriteTerms(w io.Writer, r index.IndexReader, field string, withFreqs bool) error {
+	return ExportTerms(r, field, withFreqs, func(t TermStats) (err error) {
+		if withFreqs {
+			_, err = fmt.Fprintf(w, "%s\t%v\t%v\n", t.Term, t.DocFreq, t.TotalTermFreq)
+		} else {
+			_, err = fmt.Fprintf(w, "%s\n", t.Term)
+		}
+		return
+	})
+}
+
+/*
+Builds the set of the terms of field as an FST without outputs, i.e.
+a minimal automaton, which is far smaller than the terms dictionary.
+A term is in the set if fst.GetFSTOutput() returns non-nil for it.
+The FST can be written with its Save() method, and read back with
+fst.LoadFST(in, fst.NO_OUTPUT).
+*/
+func BuildTermsFST(r index.IndexReader, field string) (*fst.FST, error) {
+	b := fst.NewBuilder(fst.INPUT_TYPE_BYTE1, 0, 0, true, true, math.MaxInt32,
+		fst.NO_OUTPUT, false, packed.PackedInts.COMPACT, true, 15)
+	scratch := util.NewIntsRefBuilder()
+	var count int
+	if err := ExportTerms(r, field, false, func(t TermStats) error {
+		count++
+		return b.Add(fst.ToIntsRef(t.Term, scratch), fst.NO_OUTPUT)
+	}); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil // an FST needs at least one input
+	}
+	return b.Finish()
+}
+
+/*
+Builds a FuzzySet of the terms of field, with the given false positive
+probability, e.g. for a service to skip looking up terms which are
+not in the index.
+*/
+func BuildTermsFuzzySet(r index.IndexReader, field string, fpp float64) (*FuzzySet, error) {
+	// the set is sized by a first pass over the terms
+	var size int
+	if err := ExportTerms(r, field, false, func(t TermStats) error {
+		size++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	ans := NewFuzzySet(size, fpp)
+	if err := ExportTerms(r, field, false, func(t TermStats) error {
+		ans.Add(t.Term)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
@@ -0,0 +1,97 @@
+package misc
+
+import (
+	"bytes"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util/fst"
+	"testing"
+)
+
+func TestExportTerms(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	dir, closeDir := openTempDir(t)
+	defer closeDir()
+	w := newWriter(t, dir)
+	// a segment per commit, so that terms are merged
+	for _, text := range []string{"fox fox jumps", "quick brown fox", "lazy dog"} {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		if err := w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if n := len(r.Leaves()); n != 3 {
+		t.Fatalf("Expected 3 segments, got %v", n)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteTerms(&buf, r, "body", true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "brown\t1\t1\ndog\t1\t1\nfox\t2\t3\njumps\t1\t1\nlazy\t1\t1\nquick\t1\t1\n"
+	if buf.String() != expected {
+		t.Errorf("Expected terms:\n%v\ngot:\n%v", expected, buf.String())
+	}
+
+	words := []string{"brown", "dog", "fox", "jumps", "lazy", "quick"}
+	others := []string{"cat", "fo", "foxes", "zebra", ""}
+	set, err := BuildTermsFST(r, "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contains := func(term string) bool {
+		out, err := fst.GetFSTOutput(set, []byte(term))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out != nil
+	}
+	for _, term := range words {
+		if !contains(term) {
+			t.Errorf("Expected %q in the FST", term)
+		}
+	}
+	for _, term := range others {
+		if contains(term) {
+			t.Errorf("Expected no %q in the FST", term)
+		}
+	}
+
+	fs, err := BuildTermsFuzzySet(r, "body", 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := store.NewRAMOutputStreamBuffer()
+	if err = fs.Save(out); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, out.FilePointer())
+	if err = out.WriteToBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	if fs, err = LoadFuzzySet(store.NewByteArrayDataInput(data)); err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range words {
+		if !fs.MayContain([]byte(term)) {
+			t.Errorf("Expected %q in %v", term, fs)
+		}
+	}
+}
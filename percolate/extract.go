@@ -0,0 +1,56 @@
+package percolate
+
+import (
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+)
+
+// percolator/QueryAnalyzer.java
+
+/*
+Returns terms such that any document matching the query contains at
+least one of them, or false if there are none, in which case the
+query must be verified against every document.
+
+A term query requires its term. A boolean query with required clauses
+requires the terms of one of them (the first one which has some),
+otherwise those of all its optional clauses, as long as each has
+some. Prohibited clauses are ignored. A filtered query requires the
+terms of its query, and an interval query those of its source.
+*/
+func ExtractTerms(q search.Query) ([]*index.Term, bool) {
+	switch v := q.(type) {
+	case *search.TermQuery:
+		return []*index.Term{v.Term()}, true
+	case *search.FilteredQuery:
+		return ExtractTerms(v.Query())
+	case *search.IntervalQuery:
+		terms := v.Terms()
+		return terms, len(terms) > 0
+	case *search.BooleanQuery:
+		var optional []*index.Term
+		hasRequired, allOptional := false, true
+		for _, clause := range v.Clauses() {
+			switch {
+			case clause.IsProhibited():
+				continue
+			case clause.IsRequired():
+				hasRequired = true
+				if terms, ok := ExtractTerms(clause.Query()); ok {
+					return terms, true
+				}
+			default:
+				if terms, ok := ExtractTerms(clause.Query()); ok {
+					optional = append(optional, terms...)
+				} else {
+					allOptional = false
+				}
+			}
+		}
+		if hasRequired || !allOptional || len(optional) == 0 {
+			return nil, false
+		}
+		return optional, true
+	}
+	return nil, false
+}
@@ -0,0 +1,130 @@
+package percolate
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"testing"
+)
+
+func TestExtractTerms(t *testing.T) {
+	term := func(text string) search.Query {
+		return search.NewTermQuery(index.NewTerm("body", text))
+	}
+	boolean := func(clauses ...interface{}) search.Query {
+		q := search.NewBooleanQuery()
+		for i := 0; i < len(clauses); i += 2 {
+			q.Add(clauses[i].(search.Query), clauses[i+1].(search.Occur))
+		}
+		return q
+	}
+	for i, c := range []struct {
+		q    search.Query
+		want string
+	}{
+		{term("fox"), "[body:fox] true"},
+		{boolean(term("quick"), search.SHOULD, term("fox"), search.SHOULD), "[body:quick body:fox] true"},
+		{boolean(term("quick"), search.SHOULD, term("fox"), search.MUST), "[body:fox] true"},
+		{boolean(term("quick"), search.MUST_NOT), "[] false"},
+		{boolean(term("quick"), search.SHOULD, boolean(term("fox"), search.MUST_NOT), search.SHOULD), "[] false"},
+		{search.NewIntervalQuery("body", search.IntervalPhrase("quick", "fox")), "[body:fox body:quick] true"},
+	} {
+		terms, ok := ExtractTerms(c.q)
+		if got := fmt.Sprint(terms, " ", ok); got != c.want {
+			t.Errorf("%v: ExtractTerms(%v) = %v, want %v", i, c.q, got, c.want)
+		}
+	}
+}
+
+func TestPercolate(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	p := NewPercolator(std.NewStandardAnalyzer())
+	p.Register("fox", search.NewTermQuery(index.NewTerm("body", "fox")))
+	p.Register("dog", search.NewTermQuery(index.NewTerm("body", "dog")))
+	both := search.NewBooleanQuery()
+	both.Add(search.NewTermQuery(index.NewTerm("body", "fox")), search.MUST)
+	both.Add(search.NewTermQuery(index.NewTerm("body", "dog")), search.MUST)
+	p.Register("both", both)
+	p.Register("phrase", search.NewIntervalQuery("body", search.IntervalPhrase("quick", "brown")))
+	p.Register("sport", search.NewTermQuery(index.NewTerm("category", "sport")))
+	notCat := search.NewBooleanQuery()
+	notCat.Add(search.NewTermQuery(index.NewTerm("category", "news")), search.MUST)
+	notCat.Add(search.NewTermQuery(index.NewTerm("body", "cat")), search.MUST_NOT)
+	p.Register("nocat", notCat)
+	// verified against every document
+	negative := search.NewBooleanQuery()
+	negative.Add(search.NewTermQuery(index.NewTerm("body", "cat")), search.MUST_NOT)
+	unfiltered := search.NewBooleanQuery()
+	unfiltered.Add(search.NewTermQuery(index.NewTerm("body", "lazy")), search.SHOULD)
+	unfiltered.Add(negative, search.SHOULD)
+	p.Register("lazy", unfiltered)
+	if !p.unfiltered["lazy"] || len(p.unfiltered) != 1 {
+		t.Errorf("expect lazy only to be unfiltered, got %v", p.unfiltered)
+	}
+
+	percolate := func(body, category string) string {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", body, docu.STORE_NO))
+		d.Add(docu.NewStringField("category", category, docu.STORE_NO))
+		ids, err := p.Percolate(d.Fields())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fmt.Sprint(ids)
+	}
+	for i, c := range []struct {
+		body, category, want string
+	}{
+		{"The quick brown fox", "news", "[fox nocat phrase]"},
+		{"The brown quick fox jumps over the lazy dog", "news", "[both dog fox lazy nocat]"},
+		{"A cat and a dog", "sport", "[dog sport]"},
+		{"Nothing here", "news", "[nocat]"},
+	} {
+		if got := percolate(c.body, c.category); got != c.want {
+			t.Errorf("%v: Percolate(%q) = %v, want %v", i, c.body, got, c.want)
+		}
+	}
+
+	// re-registering replaces the query, unregistering removes it
+	p.Register("fox", search.NewTermQuery(index.NewTerm("body", "cat")))
+	if !p.Unregister("nocat") || p.Unregister("nocat") {
+		t.Error("expect nocat to be unregistered once")
+	}
+	if got := percolate("A cat and a fox", "news"); got != "[fox]" {
+		t.Errorf("expect [fox], got %v", got)
+	}
+	if p.Size() != 6 {
+		t.Errorf("expect 6 queries, got %v", p.Size())
+	}
+	if len(p.terms["body"]["fox"]) != 1 || len(p.terms["category"]["news"]) != 0 {
+		t.Errorf("expect the replaced and removed queries to be unindexed: %v %v", p.terms, p.unfiltered)
+	}
+
+	// a query requiring the same term twice is indexed once
+	twice := search.NewBooleanQuery()
+	twice.Add(search.NewTermQuery(index.NewTerm("body", "dog")), search.SHOULD)
+	twice.Add(search.NewTermQuery(index.NewTerm("body", "dog")), search.SHOULD)
+	p.Register("twice", twice)
+	if got := fmt.Sprint(p.terms["body"]["dog"]); got != "[dog twice]" {
+		t.Errorf("expect twice to be indexed once, got %v", got)
+	}
+	if got := percolate("A dog", "news"); got != "[dog twice]" {
+		t.Errorf("expect [dog twice], got %v", got)
+	}
+	// a query modified after its registration is unindexed by the terms
+	// it was indexed by
+	twice.Add(search.NewTermQuery(index.NewTerm("body", "fox")), search.MUST)
+	p.Unregister("twice")
+	if got := fmt.Sprint(p.terms["body"]["dog"]); got != "[dog]" {
+		t.Errorf("expect twice to be unindexed, got %v", got)
+	}
+	if got := percolate("A dog", "news"); got != "[dog]" {
+		t.Errorf("expect [dog], got %v", got)
+	}
+}
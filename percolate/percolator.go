@@ -0,0 +1,214 @@
+package percolate
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+	"sync"
+)
+
+// percolator/PercolatorFieldMapper.java
+// memory/MemoryIndex.java
+
+/*
+Matches documents against a set of registered queries, e.g. to alert
+on, or classify, incoming documents, returning the IDs of the queries
+which match each document.
+
+Queries are indexed by the terms they require, as extracted by
+ExtractTerms() when they are registered, so that a document is only
+verified against the queries sharing at least one term with it.
+Queries whose terms cannot be extracted are verified against every
+document.
+
+A document is verified by indexing it alone in memory, with the
+analyzer given to NewPercolator, and searching the candidate queries
+against it. Percolator is safe for use by multiple goroutines.
+*/
+type Percolator struct {
+	sync.RWMutex
+	analyzer analysis.Analyzer
+	queries  map[string]search.Query
+	// field -> term -> IDs of the queries requiring one of their terms
+	terms map[string]map[string][]string
+	// ID -> the distinct terms the query was indexed by
+	queryTerms map[string][]*index.Term
+	// IDs of the queries verified against every document
+	unfiltered map[string]bool
+}
+
+func NewPercolator(analyzer analysis.Analyzer) *Percolator {
+	return &Percolator{
+		analyzer:   analyzer,
+		queries:    make(map[string]search.Query),
+		terms:      make(map[string]map[string][]string),
+		queryTerms: make(map[string][]*index.Term),
+		unfiltered: make(map[string]bool),
+	}
+}
+
+/* Registers the query under the given ID, replacing any query previously registered with it. */
+func (p *Percolator) Register(id string, q search.Query) {
+	assert2(q != nil, "query must not be nil")
+	p.Lock()
+	defer p.Unlock()
+	p.unregister(id)
+	p.queries[id] = q
+	terms, ok := ExtractTerms(q)
+	if !ok {
+		p.unfiltered[id] = true
+		return
+	}
+	// the terms are kept, as the query may be modified before it is
+	// unregistered
+	var distinct []*index.Term
+	for _, term := range terms {
+		byTerm, ok := p.terms[term.Field]
+		if !ok {
+			byTerm = make(map[string][]string)
+			p.terms[term.Field] = byTerm
+		}
+		text := string(term.Bytes)
+		// the query may require the same term several times
+		if ids := byTerm[text]; len(ids) == 0 || ids[len(ids)-1] != id {
+			byTerm[text] = append(ids, id)
+			distinct = append(distinct, term)
+		}
+	}
+	p.queryTerms[id] = distinct
+}
+
+/* Removes the query registered under the given ID, returning false if there is none. */
+func (p *Percolator) Unregister(id string) bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.unregister(id)
+}
+
+func (p *Percolator) unregister(id string) bool {
+	if _, ok := p.queries[id]; !ok {
+		return false
+	}
+	delete(p.queries, id)
+	if p.unfiltered[id] {
+		delete(p.unfiltered, id)
+		return true
+	}
+	terms := p.queryTerms[id]
+	delete(p.queryTerms, id)
+	for _, term := range terms {
+		byTerm := p.terms[term.Field]
+		text := string(term.Bytes)
+		ids := byTerm[text]
+		for i, v := range ids {
+			if v == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(byTerm, text)
+		} else {
+			byTerm[text] = ids
+		}
+		if len(byTerm) == 0 {
+			delete(p.terms, term.Field)
+		}
+	}
+	return true
+}
+
+/* Returns the number of registered queries. */
+func (p *Percolator) Size() int {
+	p.RLock()
+	defer p.RUnlock()
+	return len(p.queries)
+}
+
+/* Returns the IDs of the registered queries matching the document, sorted. */
+func (p *Percolator) Percolate(doc []IndexableField) (ids []string, err error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	dir := store.NewRAMDirectory()
+	defer dir.Close()
+	w, err := index.NewIndexWriter(dir, index.NewIndexWriterConfig(util.VERSION_LATEST, p.analyzer))
+	if err != nil {
+		return nil, err
+	}
+	if err = w.AddDocument(doc); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	reader, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	candidates, err := p.candidates(reader)
+	if err != nil {
+		return nil, err
+	}
+	ss := search.NewIndexSearcher(reader)
+	for id := range candidates {
+		q, ok := p.queries[id]
+		if !ok {
+			continue // not registered anymore
+		}
+		docs, err := ss.SearchTop(q, 1)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("percolating query %v: %v", id, err))
+		}
+		if docs.TotalHits > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+/* Returns the IDs of the queries which may match the indexed document. */
+func (p *Percolator) candidates(reader index.IndexReader) (map[string]bool, error) {
+	ans := make(map[string]bool)
+	for id := range p.unfiltered {
+		ans[id] = true
+	}
+	for _, ctx := range reader.Leaves() {
+		for field, byTerm := range p.terms {
+			terms := ctx.Reader().(index.AtomicReader).Terms(field)
+			if terms == nil {
+				continue
+			}
+			it := terms.Iterator(nil)
+			for {
+				term, err := it.Next()
+				if err != nil {
+					return nil, err
+				}
+				if term == nil {
+					break
+				}
+				for _, id := range byTerm[string(term)] {
+					ans[id] = true
+				}
+			}
+		}
+	}
+	return ans, nil
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
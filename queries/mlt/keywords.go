@@ -0,0 +1,280 @@
+package mlt
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/index"
+	. "github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"math"
+	"sort"
+)
+
+// queries/mlt/MoreLikeThis.java#retrieveInterestingTerms
+
+/* How the terms of a document are weighted by KeywordExtractor. */
+type Scoring int
+
+const (
+	// Raw term frequency times idf, as MoreLikeThis does.
+	SCORING_TF_IDF = Scoring(0)
+	// BM25: term frequency saturated by K1 and normalized by the
+	// length of the field in the document, times BM25 idf.
+	SCORING_BM25 = Scoring(1)
+)
+
+/* A term of a document, with its weight within the document. */
+type Keyword struct {
+	Field   string
+	Term    string
+	Freq    int // in the document
+	DocFreq int // in the index
+	Score   float64
+}
+
+/*
+Extracts the top weighted terms of a document, e.g. for tag
+suggestions or "related content" widgets, or to build a query finding
+documents like it with Query().
+
+Term frequencies are read from the term vectors of the document with
+KeywordsOf(), or from its stored fields, re-analyzed with the
+analyzer, with Keywords(). Document frequencies are read from the
+index.
+*/
+type KeywordExtractor struct {
+	reader   index.IndexReader
+	analyzer analysis.Analyzer
+	fields   []string
+
+	scoring     Scoring
+	k1, b       float64
+	minTermFreq int
+	minDocFreq  int
+	minWordLen  int
+	stopWords   map[string]bool
+}
+
+func NewKeywordExtractor(reader index.IndexReader, analyzer analysis.Analyzer,
+	fields ...string) *KeywordExtractor {
+	return &KeywordExtractor{
+		reader:      reader,
+		analyzer:    analyzer,
+		fields:      fields,
+		scoring:     SCORING_TF_IDF,
+		k1:          1.2,
+		b:           0.75,
+		minTermFreq: 1,
+		minDocFreq:  1,
+	}
+}
+
+/* Sets how terms are weighted, SCORING_TF_IDF by default. */
+func (e *KeywordExtractor) SetScoring(scoring Scoring) *KeywordExtractor {
+	e.scoring = scoring
+	return e
+}
+
+/* Sets the parameters of SCORING_BM25, 1.2 and 0.75 by default. */
+func (e *KeywordExtractor) SetBM25Params(k1, b float64) *KeywordExtractor {
+	e.k1, e.b = k1, b
+	return e
+}
+
+/* Ignores terms less frequent than this in the document, 1 by default. */
+func (e *KeywordExtractor) SetMinTermFreq(n int) *KeywordExtractor {
+	e.minTermFreq = n
+	return e
+}
+
+/* Ignores terms in less documents than this in the index, 1 by default. */
+func (e *KeywordExtractor) SetMinDocFreq(n int) *KeywordExtractor {
+	e.minDocFreq = n
+	return e
+}
+
+/* Ignores terms shorter than this many bytes, 0 by default. */
+func (e *KeywordExtractor) SetMinWordLen(n int) *KeywordExtractor {
+	e.minWordLen = n
+	return e
+}
+
+/* Ignores these terms. */
+func (e *KeywordExtractor) SetStopWords(words ...string) *KeywordExtractor {
+	e.stopWords = make(map[string]bool)
+	for _, w := range words {
+		e.stopWords[w] = true
+	}
+	return e
+}
+
+/*
+Returns the top n keywords of the stored fields of a document,
+analyzed with the analyzer of the extractor.
+*/
+func (e *KeywordExtractor) Keywords(docID, n int) ([]*Keyword, error) {
+	doc, err := e.reader.Document(docID)
+	if err != nil {
+		return nil, err
+	}
+	freqs := make(map[string]map[string]int)
+	for _, field := range e.fields {
+		for _, f := range doc.Fields() {
+			if f.Name() != field || f.StringValue() == "" {
+				continue
+			}
+			if err = e.addTermFreqs(freqs, field, f.StringValue()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e.top(freqs, n)
+}
+
+/* Returns the top n keywords of the term vectors of a document. */
+func (e *KeywordExtractor) KeywordsOf(vectors Fields, n int) ([]*Keyword, error) {
+	freqs := make(map[string]map[string]int)
+	for _, field := range e.fields {
+		terms := vectors.Terms(field)
+		if terms == nil {
+			continue
+		}
+		termFreqs := make(map[string]int)
+		it := terms.Iterator(nil)
+		term, err := it.Next()
+		for ; term != nil && err == nil; term, err = it.Next() {
+			var freq int64
+			if freq, err = it.TotalTermFreq(); err != nil {
+				return nil, err
+			}
+			termFreqs[string(term)] += int(freq)
+		}
+		if err != nil {
+			return nil, err
+		}
+		freqs[field] = termFreqs
+	}
+	return e.top(freqs, n)
+}
+
+func (e *KeywordExtractor) addTermFreqs(freqs map[string]map[string]int, field, text string) error {
+	ts, err := e.analyzer.TokenStreamForString(field, text)
+	if err != nil {
+		return err
+	}
+	defer util.CloseWhileSuppressingError(ts)
+
+	termAtt := ts.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	if err = ts.Reset(); err != nil {
+		return err
+	}
+	termFreqs := freqs[field]
+	if termFreqs == nil {
+		termFreqs = make(map[string]int)
+		freqs[field] = termFreqs
+	}
+	var ok bool
+	for ok, err = ts.IncrementToken(); ok && err == nil; ok, err = ts.IncrementToken() {
+		termFreqs[string(termAtt.Buffer()[:termAtt.Length()])]++
+	}
+	if err != nil {
+		return err
+	}
+	return ts.End()
+}
+
+/* Weights the terms of the document, and returns the top n of them. */
+func (e *KeywordExtractor) top(freqs map[string]map[string]int, n int) ([]*Keyword, error) {
+	numDocs := float64(e.reader.NumDocs())
+	var keywords []*Keyword
+	for _, field := range e.fields {
+		termFreqs := freqs[field]
+		if len(termFreqs) == 0 {
+			continue
+		}
+		norm := 1.0
+		if e.scoring == SCORING_BM25 {
+			docLen := 0
+			for _, freq := range termFreqs {
+				docLen += freq
+			}
+			if avgLen := e.avgFieldLength(field); avgLen > 0 {
+				norm = 1 - e.b + e.b*float64(docLen)/avgLen
+			}
+		}
+		for term, freq := range termFreqs {
+			if freq < e.minTermFreq || len(term) < e.minWordLen || e.stopWords[term] {
+				continue
+			}
+			docFreq, err := e.reader.DocFreq(index.NewTerm(field, term))
+			if err != nil {
+				return nil, err
+			}
+			if docFreq < e.minDocFreq || docFreq == 0 {
+				continue
+			}
+			var score float64
+			tf, df := float64(freq), float64(docFreq)
+			switch e.scoring {
+			case SCORING_BM25:
+				idf := math.Log(1 + (numDocs-df+0.5)/(df+0.5))
+				score = idf * tf * (e.k1 + 1) / (tf + e.k1*norm)
+			default:
+				idf := 1 + math.Log(numDocs/(df+1))
+				score = tf * idf
+			}
+			keywords = append(keywords, &Keyword{field, term, freq, docFreq, score})
+		}
+	}
+	sort.Sort(byScore(keywords))
+	if n < len(keywords) {
+		keywords = keywords[:n]
+	}
+	return keywords, nil
+}
+
+/* Returns the average number of tokens of the field per document. */
+func (e *KeywordExtractor) avgFieldLength(field string) float64 {
+	var sumTotalTermFreq int64
+	var docCount int
+	for _, leaf := range e.reader.Leaves() {
+		if terms := leaf.Reader().(index.AtomicReader).Terms(field); terms != nil {
+			sumTotalTermFreq += terms.SumTotalTermFreq()
+			docCount += terms.DocCount()
+		}
+	}
+	if sumTotalTermFreq <= 0 || docCount <= 0 {
+		return 0 // statistics not available
+	}
+	return float64(sumTotalTermFreq) / float64(docCount)
+}
+
+type byScore []*Keyword
+
+func (a byScore) Len() int      { return len(a) }
+func (a byScore) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byScore) Less(i, j int) bool {
+	if a[i].Score != a[j].Score {
+		return a[i].Score > a[j].Score
+	}
+	if a[i].Field != a[j].Field {
+		return a[i].Field < a[j].Field
+	}
+	return a[i].Term < a[j].Term
+}
+
+/*
+Returns a query finding documents like the one the keywords were
+extracted from: a disjunction of their terms, boosted by their score
+relative to the top one.
+*/
+func Query(keywords []*Keyword) search.Query {
+	q := search.NewBooleanQuery()
+	for _, k := range keywords {
+		tq := search.NewTermQuery(index.NewTerm(k.Field, k.Term))
+		tq.SetBoost(float32(k.Score / keywords[0].Score))
+		q.Add(tq, search.SHOULD)
+	}
+	return q
+}
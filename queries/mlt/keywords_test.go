@@ -0,0 +1,108 @@
+package mlt
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"testing"
+)
+
+func newReader(t *testing.T, texts ...string) index.IndexReader {
+	dir := store.NewRAMDirectory()
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer())
+	w, err := index.NewIndexWriter(dir, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range texts {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_YES))
+		if err = w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestKeywords(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	texts := []string{
+		"the quick brown fox jumps over the lazy dog",
+		"the lazy dog sleeps all day",
+		"a quick recipe for brown bread",
+		"golang search engines: golang indexes and golang queries for the web",
+		"the web is full of search engines",
+	}
+	r := newReader(t, texts...)
+	defer r.Close()
+
+	for _, scoring := range []Scoring{SCORING_TF_IDF, SCORING_BM25} {
+		e := NewKeywordExtractor(r, std.NewStandardAnalyzer(), "body").SetScoring(scoring)
+		keywords, err := e.Keywords(3, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keywords) != 3 {
+			t.Fatalf("Expected 3 keywords, got %v", len(keywords))
+		}
+		if k := keywords[0]; k.Term != "golang" || k.Freq != 3 || k.DocFreq != 1 {
+			t.Errorf("Expected golang to be the top keyword, got %+v", k)
+		}
+		for i := 1; i < len(keywords); i++ {
+			if keywords[i].Score > keywords[i-1].Score {
+				t.Errorf("Expected keywords by decreasing score, got %+v", keywords)
+			}
+		}
+
+		// the term vector of doc 3, as if read from a TermVectorsReader
+		tv := newReader(t, texts[3])
+		vectors := tv.Leaves()[0].Reader().(index.AtomicReader).Fields()
+		fromVectors, err := e.KeywordsOf(vectors, 3)
+		tv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, k := range fromVectors {
+			if *k != *keywords[i] {
+				t.Errorf("Expected %+v from term vectors, got %+v", keywords[i], k)
+			}
+		}
+	}
+
+	e := NewKeywordExtractor(r, std.NewStandardAnalyzer(), "body").
+		SetMinDocFreq(2).SetStopWords("engines")
+	keywords, err := e.Keywords(3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keywords) != 2 || keywords[0].Term != "search" || keywords[1].Term != "web" {
+		t.Fatalf("Expected search and web, got %+v", keywords)
+	}
+
+	// documents like doc 3: itself first, then the other one about search
+	keywords, err = NewKeywordExtractor(r, std.NewStandardAnalyzer(), "body").Keywords(3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, err := search.NewIndexSearcher(r).SearchTop(Query(keywords), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if top.TotalHits < 2 || top.ScoreDocs[0].Doc != 3 || top.ScoreDocs[1].Doc != 4 {
+		t.Errorf("Expected docs 3 and 4 first, got %v hits: %v", top.TotalHits, top.ScoreDocs)
+	}
+}
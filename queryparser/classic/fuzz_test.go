@@ -0,0 +1,37 @@
+package classic
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/util"
+	"testing"
+)
+
+/*
+Feeds arbitrary query strings to the parser, which must return a
+query or an error, but never panic, except on the syntax which is not
+ported yet.
+*/
+func FuzzQueryParser(f *testing.F) {
+	for _, seed := range []string{
+		"", "fox", "quick brown fox", "title:fox", "title:(quick fox)",
+		"\"quick fox\"", "fox^2", "+fox -dog", "fox AND dog", "fo*", "fox~",
+		"[a TO z]", "{a TO z}", "title:", "((fox", "fox)", "\\", "\"fox",
+	} {
+		f.Add(seed)
+	}
+	analyzer := std.NewStandardAnalyzer()
+	f.Fuzz(func(t *testing.T, query string) {
+		defer func() {
+			if e := recover(); e != nil {
+				if e != "not implemented yet" && e != "niy" {
+					panic(e)
+				}
+				t.Skipf("syntax of %q is not ported yet", query)
+			}
+		}()
+		q, err := NewQueryParser(util.VERSION_LATEST, "body", analyzer).Parse(query)
+		if err == nil && q == nil {
+			t.Errorf("Expected a query or an error for %q", query)
+		}
+	})
+}
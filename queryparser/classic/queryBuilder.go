@@ -1,16 +1,22 @@
 package classic
 
 import (
+	"errors"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/search"
 	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/graph"
 )
 
 type QueryBuilder struct {
 	analyzer                 analysis.Analyzer
 	enablePositionIncrements bool
+
+	hasPositions   func(field string) bool
+	degradePhrases bool
 }
 
 func newQueryBuilder() *QueryBuilder {
@@ -19,9 +25,33 @@ func newQueryBuilder() *QueryBuilder {
 	}
 }
 
+/*
+Sets how to tell whether positions of a field are indexed, e.g. with
+search.HasPositions(). Phrases on a field without positions then fail
+with search.PositionsNotIndexedError, or are degraded to a conjunction
+of their terms (see SetDegradePhrases()). nil, the default, assumes
+positions are indexed for all fields.
+*/
+func (qp *QueryBuilder) SetHasPositions(hasPositions func(field string) bool) {
+	qp.hasPositions = hasPositions
+}
+
+/*
+Whether phrases on a field indexed without positions are degraded to
+a conjunction (AND) of their terms instead of failing. Default is
+false.
+*/
+func (qp *QueryBuilder) SetDegradePhrases(degrade bool) {
+	qp.degradePhrases = degrade
+}
+
+func (qp *QueryBuilder) DegradePhrases() bool {
+	return qp.degradePhrases
+}
+
 // L193
 func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
-	operator search.Occur, field, queryText string, quoted bool, phraseSlop int) search.Query {
+	operator search.Occur, field, queryText string, quoted bool, phraseSlop int) (search.Query, error) {
 
 	assert(operator == search.SHOULD || operator == search.MUST)
 	assert(analyzer != nil)
@@ -30,9 +60,11 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 	var buffer *analysis.CachingTokenFilter
 	var termAtt ta.TermToBytesRefAttribute
 	var posIncrAtt ta.PositionIncrementAttribute
+	var posLenAtt ta.PositionLengthAttribute
 	var numTokens int
 	var positionCount int
 	var severalTokensAtSamePosition bool
+	var isGraph bool
 	if err := func() (err error) {
 		var source analysis.TokenStream
 		defer func() {
@@ -50,6 +82,9 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 
 		termAtt = buffer.Attributes().Get("TermToBytesRefAttribute").(ta.TermToBytesRefAttribute)
 		posIncrAtt = buffer.Attributes().Get("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+		if buffer.Attributes().Has("PositionLengthAttribute") {
+			posLenAtt = buffer.Attributes().Get("PositionLengthAttribute").(ta.PositionLengthAttribute)
+		}
 
 		if termAtt != nil {
 			hasMoreTokens, err := buffer.IncrementToken()
@@ -64,37 +99,70 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 				} else {
 					severalTokensAtSamePosition = true
 				}
+				if posLenAtt != nil && posLenAtt.PositionLength() > 1 {
+					isGraph = true
+				}
 				hasMoreTokens, err = buffer.IncrementToken()
 			} // ignore error
 		}
 		return nil
 	}(); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	// rewind the buffer stream
 	buffer.Reset()
 
+	if quoted && positionCount > 1 && qp.hasPositions != nil && !qp.hasPositions(field) {
+		if !qp.degradePhrases {
+			return nil, &search.PositionsNotIndexedError{Field: field, Query: "PhraseQuery"}
+		}
+		quoted, operator = false, search.MUST
+	}
+
+	if isGraph {
+		// graph token filters (e.g. multi-word synonyms) were used:
+		g, err := graph.NewGraphTokenStreamFiniteStrings(buffer)
+		if err != nil {
+			return nil, err
+		}
+		if quoted {
+			return qp.analyzeGraphPhrase(g, field, phraseSlop), nil
+		}
+		return qp.analyzeGraphBoolean(g, field, operator), nil
+	}
+
 	var bytes *util.BytesRef
 	if termAtt != nil {
 		bytes = termAtt.BytesRef()
 	}
 
 	if numTokens == 0 {
-		return nil
+		return nil, nil
 	} else if numTokens == 1 {
 		if hasNext, err := buffer.IncrementToken(); err == nil {
 			assert(hasNext)
 			termAtt.FillBytesRef()
 		} // safe to ignore error, because we know the number of tokens
-		return qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes()))
+		return qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())), nil
 	} else {
 		if severalTokensAtSamePosition || !quoted {
 			if positionCount == 1 || !quoted {
 				// no phrase query:
 
 				if positionCount == 1 {
-					panic("not implemented yet")
+					// simple case: only one position, with synonyms
+					q := qp.newBooleanQuery(true)
+					for i := 0; i < numTokens; i++ {
+						hasNext, err := buffer.IncrementToken()
+						if err != nil {
+							continue // safe to ignore error, because we know the number of tokens
+						}
+						assert(hasNext)
+						termAtt.FillBytesRef()
+						q.Add(qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())), search.SHOULD)
+					}
+					return q, nil
 				} else {
 					// multiple positions
 					q := qp.newBooleanQuery(false)
@@ -108,7 +176,13 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 						termAtt.FillBytesRef()
 
 						if posIncrAtt != nil && posIncrAtt.PositionIncrement() == 0 {
-							panic("not implemented yet")
+							bq, ok := currentQuery.(*search.BooleanQuery)
+							if !ok {
+								bq = qp.newBooleanQuery(true)
+								bq.Add(currentQuery, search.SHOULD)
+								currentQuery = bq
+							}
+							bq.Add(qp.newTermQuery(index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())), search.SHOULD)
 						} else {
 							if currentQuery != nil {
 								q.Add(currentQuery, operator)
@@ -117,16 +191,142 @@ func (qp *QueryBuilder) createFieldQuery(analyzer analysis.Analyzer,
 						}
 					}
 					q.Add(currentQuery, operator)
-					return q
+					return q, nil
 				}
 			} else {
-				panic("not implemented yet")
+				// phrase query with several terms at the same position,
+				// which needs a MultiPhraseQuery
+				return nil, errors.New(fmt.Sprintf(
+					"phrase with several terms at the same position is not supported: \"%v\"", queryText))
 			}
 		} else {
-			panic("not implemented yet")
+			pq := qp.newPhraseQuery()
+			pq.SetSlop(phraseSlop)
+			position := -1
+
+			for i := 0; i < numTokens; i++ {
+				positionIncrement := 1
+
+				hasNext, err := buffer.IncrementToken()
+				if err != nil {
+					continue // safe to ignore error, because we know the number of tokens
+				}
+				assert(hasNext)
+				termAtt.FillBytesRef()
+				if posIncrAtt != nil {
+					positionIncrement = posIncrAtt.PositionIncrement()
+				}
+
+				term := index.NewTermFromBytes(field, util.DeepCopyOf(bytes).ToBytes())
+				if qp.enablePositionIncrements {
+					position += positionIncrement
+					pq.AddAt(term, position)
+				} else {
+					pq.Add(term)
+				}
+			}
+			return pq, nil
+		}
+	}
+}
+
+/*
+Creates a boolean query from a graph token stream. The articulation
+points of the graph are visited in order and the queries created at
+each point are merged with the provided operator. Within a piece of
+the graph between two articulation points, each path becomes a
+conjunction of its terms and the alternatives are OR'ed, so tokens
+of different alternatives never match as if they were one sequence.
+*/
+func (qp *QueryBuilder) analyzeGraphBoolean(g *graph.GraphTokenStreamFiniteStrings,
+	field string, operator search.Occur) search.Query {
+
+	var clauses []search.Query
+	start := 0
+	for _, end := range append(g.ArticulationPoints(), g.End()) {
+		var alternatives []search.Query
+		for _, path := range g.FiniteStringsBetween(start, end) {
+			if len(path) == 0 {
+				continue // a gap
+			}
+			if len(path) == 1 {
+				alternatives = append(alternatives, qp.newTermQuery(index.NewTermFromBytes(field, path[0].Term)))
+				continue
+			}
+			q := qp.newBooleanQuery(false)
+			for _, token := range path {
+				q.Add(qp.newTermQuery(index.NewTermFromBytes(field, token.Term)), search.MUST)
+			}
+			alternatives = append(alternatives, q)
+		}
+		switch len(alternatives) {
+		case 0:
+		case 1:
+			clauses = append(clauses, alternatives[0])
+		default:
+			q := qp.newBooleanQuery(true)
+			for _, alternative := range alternatives {
+				q.Add(alternative, search.SHOULD)
+			}
+			clauses = append(clauses, q)
+		}
+		start = end
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		return clauses[0]
+	}
+	q := qp.newBooleanQuery(false)
+	for _, clause := range clauses {
+		q.Add(clause, operator)
+	}
+	return q
+}
+
+/*
+Creates a graph phrase query from the provided token stream graph:
+a phrase query for each path through the graph, with the given slop,
+OR'ed together. Holes left by removed tokens are kept in the phrases.
+*/
+func (qp *QueryBuilder) analyzeGraphPhrase(g *graph.GraphTokenStreamFiniteStrings,
+	field string, phraseSlop int) search.Query {
+
+	var queries []search.Query
+	for _, path := range g.FiniteStrings() {
+		switch len(path) {
+		case 0:
+			continue
+		case 1:
+			queries = append(queries, qp.newTermQuery(index.NewTermFromBytes(field, path[0].Term)))
+			continue
 		}
-		panic("should not be here")
+		pq := qp.newPhraseQuery()
+		pq.SetSlop(phraseSlop)
+		position := -1
+		for i, token := range path {
+			position++
+			if i > 0 && token.From > path[i-1].To {
+				position += token.From - path[i-1].To // a hole
+			}
+			pq.AddAt(index.NewTermFromBytes(field, token.Term), position)
+		}
+		queries = append(queries, pq)
+	}
+
+	switch len(queries) {
+	case 0:
+		return nil
+	case 1:
+		return queries[0]
+	}
+	q := qp.newBooleanQuery(true)
+	for _, query := range queries {
+		q.Add(query, search.SHOULD)
 	}
+	return q
 }
 
 // L379
@@ -134,6 +334,10 @@ func (qp *QueryBuilder) newBooleanQuery(disableCoord bool) *search.BooleanQuery
 	return search.NewBooleanQueryDisableCoord(disableCoord)
 }
 
+func (qp *QueryBuilder) newPhraseQuery() *search.PhraseQuery {
+	return search.NewPhraseQuery()
+}
+
 func (qp *QueryBuilder) newTermQuery(term *index.Term) search.Query {
 	return search.NewTermQuery(term)
 }
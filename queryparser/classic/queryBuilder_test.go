@@ -0,0 +1,119 @@
+package classic
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"io"
+	"strings"
+	"testing"
+)
+
+/*
+Splits its input on spaces, and emits the multi-word synonym "new
+york" along with each "ny", which spans both of its positions.
+*/
+type synonymGraphTokenizer struct {
+	*analysis.Tokenizer
+	termAtt   ta.CharTermAttribute
+	posIncAtt ta.PositionIncrementAttribute
+	posLenAtt ta.PositionLengthAttribute
+	pending   []string
+}
+
+func newSynonymGraphTokenizer(input io.RuneReader) *synonymGraphTokenizer {
+	ans := &synonymGraphTokenizer{Tokenizer: analysis.NewTokenizer(input)}
+	ans.termAtt = ans.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	ans.posIncAtt = ans.Attributes().Add("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	ans.posLenAtt = ans.Attributes().Add("PositionLengthAttribute").(ta.PositionLengthAttribute)
+	return ans
+}
+
+func (t *synonymGraphTokenizer) Reset() error {
+	if err := t.Tokenizer.Reset(); err != nil {
+		return err
+	}
+	var text []rune
+	for {
+		ch, _, err := t.Input.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		text = append(text, ch)
+	}
+	t.pending = nil
+	for _, word := range strings.Fields(string(text)) {
+		t.pending = append(t.pending, word)
+		if word == "ny" {
+			t.pending = append(t.pending, "new", "york")
+		}
+	}
+	return nil
+}
+
+func (t *synonymGraphTokenizer) IncrementToken() (bool, error) {
+	if len(t.pending) == 0 {
+		return false, nil
+	}
+	t.Attributes().Clear()
+	word := t.pending[0]
+	t.pending = t.pending[1:]
+	t.termAtt.AppendString(word)
+	switch word {
+	case "ny":
+		t.posLenAtt.SetPositionLength(2)
+	case "new":
+		t.posIncAtt.SetPositionIncrement(0)
+	}
+	return true, nil
+}
+
+type synonymGraphAnalyzer struct {
+	*analysis.AnalyzerImpl
+}
+
+func newSynonymGraphAnalyzer() *synonymGraphAnalyzer {
+	ans := &synonymGraphAnalyzer{analysis.NewAnalyzer()}
+	ans.Spi = ans
+	return ans
+}
+
+func (a *synonymGraphAnalyzer) CreateComponents(fieldName string, reader io.RuneReader) *analysis.TokenStreamComponents {
+	tokenizer := newSynonymGraphTokenizer(reader)
+	return analysis.NewTokenStreamComponents(tokenizer, tokenizer)
+}
+
+func TestGraphQueries(t *testing.T) {
+	qp := NewQueryParser(util.VERSION_LATEST, "body", newSynonymGraphAnalyzer())
+	for _, test := range []struct {
+		query, expected string
+	}{
+		{"fox", "body:fox"},
+		// each path of the graph is a conjunction, and the paths are OR'ed
+		{"ny", "body:ny (+body:new +body:york)"},
+		{"ny city", "(body:ny (+body:new +body:york)) body:city"},
+		// and each path of a quoted graph is a phrase
+		{`"ny city"`, `body:"ny city" body:"new york city"`},
+		{`"ny city"~2`, `body:"ny city"~2 body:"new york city"~2`},
+		{`"ny"`, `body:ny body:"new york"`},
+	} {
+		q, err := qp.Parse(test.query)
+		if err != nil {
+			t.Errorf("%v: %v", test.query, err)
+			continue
+		}
+		if s := q.ToString(""); s != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.query, test.expected, s)
+		}
+	}
+
+	q, err := qp.createFieldQuery(qp.analyzer, search.MUST, "body", "ny city", false, 0)
+	if err != nil {
+		t.Error(err)
+	} else if s := q.ToString(""); s != "+(body:ny (+body:new +body:york)) +body:city" {
+		t.Errorf("expected the pieces of the graph to be required, got %v", s)
+	}
+}
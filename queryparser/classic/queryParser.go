@@ -2,10 +2,11 @@ package classic
 
 import (
 	"errors"
-	// "fmt"
+	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/search"
 	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/queryparser"
 	"strings"
 )
 
@@ -33,6 +34,12 @@ type QueryParser struct {
 	jj_gc     int
 }
 
+func init() {
+	queryparser.RegisterQueryParser("classic", func(f string, a analysis.Analyzer) queryparser.QueryParser {
+		return NewQueryParser(util.VERSION_LATEST, f, a)
+	})
+}
+
 func NewQueryParser(matchVersion util.Version, f string, a analysis.Analyzer) *QueryParser {
 	qp := &QueryParser{
 		token_source: newTokenManager(newFastCharStream(strings.NewReader(""))),
@@ -194,7 +201,10 @@ func (qp *QueryParser) term(field string) (q search.Query, err error) {
 		}
 		switch qp.jj_ntk {
 		case FUZZY_SLOP:
-			panic("not implemented yet")
+			if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+				return nil, err
+			}
+			fuzzy = true
 		default:
 			qp.jj_la1[9] = qp.jj_gen
 		}
@@ -203,7 +213,24 @@ func (qp *QueryParser) term(field string) (q search.Query, err error) {
 		}
 		switch qp.jj_ntk {
 		case CARAT:
-			panic("not implemented yet")
+			if _, err = qp.jj_consume_token(CARAT); err != nil {
+				return nil, err
+			}
+			if boost, err = qp.jj_consume_token(NUMBER); err != nil {
+				return nil, err
+			}
+			if qp.jj_ntk == -1 {
+				qp.get_jj_ntk()
+			}
+			switch qp.jj_ntk {
+			case FUZZY_SLOP:
+				if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+					return nil, err
+				}
+				fuzzy = true
+			default:
+				qp.jj_la1[10] = qp.jj_gen
+			}
 		default:
 			qp.jj_la1[11] = qp.jj_gen
 		}
@@ -214,7 +241,37 @@ func (qp *QueryParser) term(field string) (q search.Query, err error) {
 	case RANGEIN_START, RANGEEX_START:
 		panic("not implemented yet")
 	case QUOTED:
-		panic("not implemented yet")
+		if term, err = qp.jj_consume_token(QUOTED); err != nil {
+			return nil, err
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case FUZZY_SLOP:
+			if fuzzySlop, err = qp.jj_consume_token(FUZZY_SLOP); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[19] = qp.jj_gen
+		}
+		if qp.jj_ntk == -1 {
+			qp.get_jj_ntk()
+		}
+		switch qp.jj_ntk {
+		case CARAT:
+			if _, err = qp.jj_consume_token(CARAT); err != nil {
+				return nil, err
+			}
+			if boost, err = qp.jj_consume_token(NUMBER); err != nil {
+				return nil, err
+			}
+		default:
+			qp.jj_la1[20] = qp.jj_gen
+		}
+		if q, err = qp.handleQuotedTerm(field, term, fuzzySlop); err != nil {
+			return nil, err
+		}
 	default:
 		panic("not implemented yet")
 	}
@@ -284,13 +341,30 @@ func (qp *QueryParser) jj_consume_token(kind int) (*Token, error) {
 	if qp.token.kind == kind {
 		qp.jj_gen++
 		if qp.jj_gc++; qp.jj_gc > 100 {
+			// forget the lookahead results of consumed tokens
 			qp.jj_gc = 0
-			panic("not implemented yet")
+			for _, c := range qp.jj_2_rtns {
+				for ; c != nil; c = c.next {
+					if c.gen < qp.jj_gen {
+						c.first = nil
+					}
+				}
+			}
 		}
 		return qp.token, nil
 	}
 	qp.token = oldToken
-	panic("not implemented yet")
+	return nil, qp.generateParseError()
+}
+
+/* Returns the error for the unexpected next token. */
+func (qp *QueryParser) generateParseError() error {
+	t := qp.token.next
+	if t.kind == EOF {
+		return errors.New("Encountered \"<EOF>\"")
+	}
+	return errors.New(fmt.Sprintf("Encountered \"%v\" at line %v, column %v.",
+		t.image, t.beginLine, t.beginColumn))
 }
 
 type LookAheadSuccess bool
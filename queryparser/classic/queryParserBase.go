@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/balzaczyy/golucene/core/analysis"
 	"github.com/balzaczyy/golucene/core/search"
+	"strconv"
 	"strings"
 )
 
@@ -36,6 +37,8 @@ type QueryParserBase struct {
 	phraseSlop int
 
 	autoGeneratePhraseQueries bool
+
+	fieldAliases *search.FieldAliases
 }
 
 func newQueryParserBase(spi QueryParserBaseSPI) *QueryParserBase {
@@ -48,6 +51,17 @@ func newQueryParserBase(spi QueryParserBaseSPI) *QueryParserBase {
 
 // L116
 func (qp *QueryParserBase) Parse(query string) (res search.Query, err error) {
+	defer func() {
+		// lexical errors are raised by the token manager, which cannot
+		// return an error; other panics are left to the caller
+		if e := recover(); e != nil {
+			tme, ok := e.(*TokenManagerError)
+			if !ok {
+				panic(e)
+			}
+			res, err = nil, errors.New(fmt.Sprintf("Cannot parse '%v': %v", query, tme))
+		}
+	}()
 	qp.spi.ReInit(newFastCharStream(strings.NewReader(query)))
 	if res, err = qp.spi.TopLevelQuery(qp.field); err != nil {
 		return nil, errors.New(fmt.Sprintf("Cannot parse '%v': %v", query, err))
@@ -58,6 +72,22 @@ func (qp *QueryParserBase) Parse(query string) (res search.Query, err error) {
 	return qp.newBooleanQuery(false), nil
 }
 
+/*
+Sets the field aliases resolved while parsing, so that queries on an
+alias are built on its concrete fields. No clause is built for the
+fields with a boost of 0, and a query on an alias whose fields are
+all excluded is dropped. As the aliases are resolved on each parse,
+they can be redefined without creating a new parser. nil disables
+aliasing.
+*/
+func (qp *QueryParserBase) SetFieldAliases(aliases *search.FieldAliases) {
+	qp.fieldAliases = aliases
+}
+
+func (qp *QueryParserBase) FieldAliases() *search.FieldAliases {
+	return qp.fieldAliases
+}
+
 // L408
 func (qp *QueryParserBase) addClause(clauses []*search.BooleanClause,
 	conj, mods int, q search.Query) []*search.BooleanClause {
@@ -100,12 +130,51 @@ func (qp *QueryParserBase) addClause(clauses []*search.BooleanClause,
 }
 
 // L461
-func (qp *QueryParserBase) fieldQuery(field, queryText string, quoted bool) search.Query {
+func (qp *QueryParserBase) fieldQuery(field, queryText string, quoted bool) (search.Query, error) {
+	if qp.fieldAliases != nil && qp.fieldAliases.IsAlias(field) {
+		var err error
+		q, err2 := qp.fieldAliases.Expand(field, func(field string) search.Query {
+			q, err3 := qp.newFieldQuery(qp.analyzer, field, queryText, quoted)
+			if err3 != nil && err == nil {
+				err = err3
+			}
+			return q
+		})
+		if err == nil {
+			err = err2
+		}
+		return q, err
+	}
 	return qp.newFieldQuery(qp.analyzer, field, queryText, quoted)
 }
 
+/*
+Delegates to fieldQuery(field, queryText, true), and sets the slop of
+the phrase query, or of the ones of the BooleanQuery built for an
+alias or a graph of tokens.
+*/
+func (qp *QueryParserBase) fieldQueryWithSlop(field, queryText string, slop int) (search.Query, error) {
+	q, err := qp.fieldQuery(field, queryText, true)
+	if err != nil {
+		return nil, err
+	}
+	setPhraseSlop(q, slop)
+	return q, nil
+}
+
+func setPhraseSlop(q search.Query, slop int) {
+	switch q := q.(type) {
+	case *search.PhraseQuery:
+		q.SetSlop(slop)
+	case *search.BooleanQuery:
+		for _, clause := range q.Clauses() {
+			setPhraseSlop(clause.Query(), slop)
+		}
+	}
+}
+
 func (qp *QueryParserBase) newFieldQuery(analyzer analysis.Analyzer,
-	field, queryText string, quoted bool) search.Query {
+	field, queryText string, quoted bool) (search.Query, error) {
 
 	var occur search.Occur
 	if qp.operator == OP_AND {
@@ -161,16 +230,39 @@ func (qp *QueryParserBase) handleBareTokenQuery(qField string,
 	} else if regexp {
 		panic("not implemented yet")
 	} else if fuzzy {
-		panic("not implemented yet")
+		return nil, errors.New(fmt.Sprintf("Fuzzy queries are not supported: %v%v",
+			termImage, fuzzySlop.image))
 	} else {
-		return qp.fieldQuery(qField, termImage, false), nil
+		return qp.fieldQuery(qField, termImage, false)
+	}
+}
+
+// L849
+func (qp *QueryParserBase) handleQuotedTerm(qField string, term, fuzzySlop *Token) (search.Query, error) {
+	s := qp.phraseSlop // default
+	if fuzzySlop != nil {
+		if f, err := strconv.ParseFloat(fuzzySlop.image[1:], 32); err == nil {
+			s = int(f)
+		}
+	}
+	text, err := qp.discardEscapeChar(term.image[1 : len(term.image)-1])
+	if err != nil {
+		return nil, err
 	}
+	return qp.fieldQueryWithSlop(qField, text, s)
 }
 
 // L876
 func (qp *QueryParserBase) handleBoost(q search.Query, boost *Token) search.Query {
 	if boost != nil {
-		panic("not implemented yet")
+		f := float32(1.0)
+		if v, err := strconv.ParseFloat(boost.image, 32); err == nil {
+			f = float32(v)
+		}
+		// avoid boosting null queries, such as those caused by stop words
+		if q != nil {
+			q.SetBoost(f)
+		}
 	}
 	return q
 }
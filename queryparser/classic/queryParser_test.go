@@ -0,0 +1,38 @@
+package classic
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	qp := NewQueryParser(util.VERSION_LATEST, "body", std.NewStandardAnalyzer())
+
+	// a lexical error is raised by the token manager, and returned
+	_, err := qp.Parse("\"fox")
+	if err == nil || !strings.Contains(err.Error(), "Lexical error at line 1, column 5.  Encountered: <EOF> after : \"\\\"fox\"") {
+		t.Errorf("Expected a lexical error, but %v", err)
+	}
+
+	// other panics are not hidden as parse errors
+	func() {
+		defer func() {
+			if e := recover(); e != "not implemented yet" {
+				t.Errorf("Expected unported syntax to panic, but %v", e)
+			}
+		}()
+		qp.Parse("fox)")
+	}()
+
+	// the lookahead calls are collected every 100 tokens
+	q, err := qp.Parse(strings.Repeat("fox ", 150))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bq, ok := q.(*search.BooleanQuery); !ok || len(bq.Clauses()) != 150 {
+		t.Errorf("Expected 150 clauses, but %v", q)
+	}
+}
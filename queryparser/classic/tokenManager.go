@@ -1,10 +1,16 @@
 package classic
 
 import (
-// "fmt"
+	// "fmt"
+	"strings"
+	"unicode/utf16"
 )
 
 var jjbitVec0 = []int64{1, 0, 0, 0}
+var jjbitVec1 = []uint64{
+	0xfffffffffffffffe, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff,
+}
+var jjbitVec3 = []uint64{0, 0, 0xffffffffffffffff, 0xffffffffffffffff}
 var jjbitVec4 = []uint64{
 	0xfffefffffffffffe, 0xffffffffffffffff, 0xffffffffffffffff, 0xffffffffffffffff,
 }
@@ -71,16 +77,121 @@ func (tm *TokenManager) jjMoveStringLiteralDfa0_2() int {
 	case 91:
 		panic("not implemented yet")
 	case 94:
-		panic("not implemented yet")
+		return tm.jjStopAtPos(0, 18)
 	case 123:
 		panic("not implemented yet")
+	case 126:
+		return tm.jjMoveFuzzySlop()
 	default:
 		return tm.jjMoveNfa_2(0, 0)
 	}
 }
 
+func (tm *TokenManager) jjStopAtPos(pos, kind int) int {
+	tm.jjmatchedKind = kind
+	tm.jjmatchedPos = pos
+	return pos + 1
+}
+
+/*
+Scans FUZZY_SLOP, i.e. "~" followed by term characters, e.g. "~2" or
+"~0.8". Unlike the generated lexer, which matches it with the NFA of
+the lexical state DEFAULT, it is scanned by hand.
+*/
+func (tm *TokenManager) jjMoveFuzzySlop() int {
+	tm.jjmatchedKind = FUZZY_SLOP
+	tm.jjmatchedPos = 0
+	curPos := 1
+	for {
+		c, err := tm.input_stream.readChar()
+		if err != nil {
+			return curPos
+		}
+		curPos++
+		if c == '\\' {
+			if _, err = tm.input_stream.readChar(); err != nil {
+				return curPos
+			}
+			curPos++
+		} else if !isTermChar(c) {
+			return curPos
+		}
+		tm.jjmatchedPos = curPos - 1
+	}
+}
+
+/* Whether c is a _TERM_CHAR which is not escaped. */
+func isTermChar(c rune) bool {
+	return c == '-' || c == '+' || !strings.ContainsRune(" \t\n\r\u3000!():^[]\"{}~*?\\/", c)
+}
+
 // L87
 
+func (tm *TokenManager) jjMoveStringLiteralDfa0_0() int {
+	return tm.jjMoveNfa_0(0, 0)
+}
+
+func (tm *TokenManager) jjMoveNfa_0(startState, curPos int) int {
+	startsAt := 0
+	tm.jjnewStateCnt = 3
+	i := 1
+	tm.jjstateSet[0] = startState
+	kind := 0x7fffffff
+	for {
+		if tm.jjround++; tm.jjround == 0x7fffffff {
+			tm.reInitRounds()
+		}
+		if tm.curChar < 64 {
+			l := int64(1) << uint(tm.curChar)
+			for {
+				i--
+				switch tm.jjstateSet[i] {
+				case 0:
+					if (0x3ff000000000000 & l) != 0 {
+						if kind > 27 {
+							kind = 27
+						}
+						tm.jjAddStates(31, 32)
+					}
+				case 1:
+					if tm.curChar == 46 {
+						tm.jjCheckNAdd(2)
+					}
+				case 2:
+					if (0x3ff000000000000 & l) != 0 {
+						if kind > 27 {
+							kind = 27
+						}
+						tm.jjCheckNAdd(2)
+					}
+				}
+				if i == startsAt {
+					break
+				}
+			}
+		} else {
+			// no state of NUMBER matches other chars
+			i = startsAt
+		}
+		if kind != 0x7fffffff {
+			tm.jjmatchedKind = kind
+			tm.jjmatchedPos = curPos
+			kind = 0x7fffffff
+		}
+		curPos++
+		i = tm.jjnewStateCnt
+		tm.jjnewStateCnt = startsAt
+		startsAt = 3 - tm.jjnewStateCnt
+		if i == startsAt {
+			return curPos
+		}
+		var err error
+		if tm.curChar, err = tm.input_stream.readChar(); err != nil {
+			return curPos
+		}
+	}
+}
+
 func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 	startsAt := 0
 	tm.jjnewStateCnt = 49
@@ -118,7 +229,7 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 					} else if tm.curChar == 47 {
 						panic("not implemented yet")
 					} else if tm.curChar == 34 {
-						panic("not implemented yet")
+						tm.jjCheckNAddStates(3, 5)
 					}
 					if (0x7bff50f8ffffd9ff & l) != 0 {
 						if kind > 20 {
@@ -147,11 +258,15 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 				case 16:
 					panic("not implemented yet")
 				case 17:
-					panic("not implemented yet")
+					if (0xfffffffbffffffff & uint64(l)) != 0 {
+						tm.jjCheckNAddStates(3, 5)
+					}
 				case 19:
-					panic("not implemented yet")
+					tm.jjCheckNAddStates(3, 5)
 				case 20:
-					panic("not implemented yet")
+					if tm.curChar == 34 && kind > 19 {
+						kind = 19
+					}
 				case 22:
 					panic("not implemented yet")
 				case 23:
@@ -257,11 +372,16 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 				case 12:
 					panic("niy")
 				case 17:
-					panic("niy")
+					if (0xffffffffefffffff & uint64(l)) != 0 {
+						tm.jjCheckNAddStates(3, 5)
+					}
 				case 18:
-					panic("niy")
+					if tm.curChar == 92 {
+						tm.jjstateSet[tm.jjnewStateCnt] = 19
+						tm.jjnewStateCnt++
+					}
 				case 19:
-					panic("niy")
+					tm.jjCheckNAddStates(3, 5)
 				case 21:
 					panic("niy")
 				case 25:
@@ -328,11 +448,17 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 				}
 			}
 		} else {
-			hiByte := int(tm.curChar >> 8)
+			curChar := tm.curChar
+			if curChar > 0xffff {
+				// the tables are for UTF-16 chars: classify the rune as
+				// its high surrogate, as in a Java string
+				curChar, _ = utf16.EncodeRune(curChar)
+			}
+			hiByte := int(curChar >> 8)
 			i1 := hiByte >> 6
 			l1 := int64(1 << (uint64(hiByte) & 077))
-			i2 := int((tm.curChar & 0xff) >> 6)
-			l2 := int64(1 << uint64(tm.curChar&077))
+			i2 := int((curChar & 0xff) >> 6)
+			l2 := int64(1 << uint64(curChar&077))
 			for {
 				i--
 				switch tm.jjstateSet[i] {
@@ -364,7 +490,9 @@ func (tm *TokenManager) jjMoveNfa_2(startState, curPos int) int {
 				case 15:
 					panic("not implemented yet")
 				case 17, 19:
-					panic("not implemented yet")
+					if jjCanMove_1(hiByte, i1, i2, l1, l2) {
+						tm.jjCheckNAddStates(3, 5)
+					}
 				case 25:
 					panic("not implemented yet")
 				case 27:
@@ -430,6 +558,14 @@ func jjCanMove_0(hiByte, i1, i2 int, l1, l2 int64) bool {
 	return false
 }
 
+func jjCanMove_1(hiByte, i1, i2 int, l1, l2 int64) bool {
+	switch hiByte {
+	case 0:
+		return (jjbitVec3[i2] & uint64(l2)) != 0
+	}
+	return (jjbitVec1[i1] & uint64(l1)) != 0
+}
+
 func jjCanMove_2(hiByte, i1, i2 int, l1, l2 int64) bool {
 	switch hiByte {
 	case 0:
@@ -490,7 +626,9 @@ func (tm *TokenManager) nextToken() (matchedToken *Token) {
 
 		switch tm.curLexState {
 		case 0:
-			panic("not implemented yet")
+			tm.jjmatchedKind = 0x7fffffff
+			tm.jjmatchedPos = 0
+			curPos = tm.jjMoveStringLiteralDfa0_0()
 		case 1:
 			panic("not implemented yet")
 		case 2:
@@ -505,8 +643,8 @@ func (tm *TokenManager) nextToken() (matchedToken *Token) {
 			}
 			if (jjtoToken[tm.jjmatchedKind>>6] & (int64(1) << uint(tm.jjmatchedKind&077))) != 0 {
 				matchedToken = tm.jjFillToken()
-				if jjnewLexState[tm.jjmatchedKind] != -1 {
-					panic("not implemented yet")
+				if n := jjnewLexState[tm.jjmatchedKind]; n != -1 {
+					tm.curLexState = n
 				}
 				return matchedToken
 			} else {
@@ -555,6 +693,13 @@ func (tm *TokenManager) jjCheckNAdd(state int) {
 
 // L1151
 
+func (tm *TokenManager) jjAddStates(start, end int) {
+	for ; start <= end; start++ {
+		tm.jjstateSet[tm.jjnewStateCnt] = jjnextStates[start]
+		tm.jjnewStateCnt++
+	}
+}
+
 func (tm *TokenManager) jjCheckNAddTwoStates(state1, state2 int) {
 	tm.jjCheckNAdd(state1)
 	tm.jjCheckNAdd(state2)
@@ -563,13 +708,9 @@ func (tm *TokenManager) jjCheckNAddTwoStates(state1, state2 int) {
 func (tm *TokenManager) jjCheckNAddStates(start, end int) {
 	assert(start < end)
 	assert(start >= 0)
-	assert(end <= len(jjnextStates))
-	for {
+	assert(end < len(jjnextStates))
+	for ; start <= end; start++ {
 		tm.jjCheckNAdd(jjnextStates[start])
-		start++
-		if start >= end {
-			break
-		}
 	}
 }
 
@@ -1,20 +1,87 @@
 package classic
 
+import (
+	"bytes"
+	"fmt"
+)
+
+// queryparser/classic/TokenMgrError.java
+
 const (
+	// Lexical error occurred.
 	LEXICAL_ERROR = iota
+	// An attempt was made to create a second instance of a static token manager.
 	STATIC_LEXER_ERROR
+	// Tried to change to an invalid lexical state.
 	INVALID_LEXICAL_STATE
+	// Detected (and bailed out of) an infinite loop in the token manager.
 	LOOP_DETECTED
 )
 
+/*
+Raised, as a panic, by the token manager on a lexical error. Parse()
+recovers it, and returns it as an error.
+*/
 type TokenManagerError struct {
+	message string
+	// One of the above 4 values.
+	ErrorCode int
 }
 
 func newTokenMgrError(eofSeen bool, lexState, errorLine, errorColumn int,
 	errorAfter string, curChar rune, reason int) *TokenManagerError {
-	panic("not implemented yet")
+	return &TokenManagerError{
+		lexicalError(eofSeen, lexState, errorLine, errorColumn, errorAfter, curChar),
+		reason,
+	}
+}
+
+/* Returns a detailed message for the error when it is raised by the token manager. */
+func lexicalError(eofSeen bool, lexState, errorLine, errorColumn int,
+	errorAfter string, curChar rune) string {
+	var encountered string
+	if eofSeen {
+		encountered = "<EOF> "
+	} else {
+		encountered = fmt.Sprintf("\"%v\" (%v), ", addEscapes(string(curChar)), int(curChar))
+	}
+	return fmt.Sprintf("Lexical error at line %v, column %v.  Encountered: %vafter : \"%v\"",
+		errorLine, errorColumn, encountered, addEscapes(errorAfter))
+}
+
+/* Replaces unprintable characters by their escaped (or unicode escaped) equivalents. */
+func addEscapes(str string) string {
+	var buf bytes.Buffer
+	for _, ch := range str {
+		switch ch {
+		case 0:
+		case '\b':
+			buf.WriteString("\\b")
+		case '\t':
+			buf.WriteString("\\t")
+		case '\n':
+			buf.WriteString("\\n")
+		case '\f':
+			buf.WriteString("\\f")
+		case '\r':
+			buf.WriteString("\\r")
+		case '"':
+			buf.WriteString("\\\"")
+		case '\'':
+			buf.WriteString("\\'")
+		case '\\':
+			buf.WriteString("\\\\")
+		default:
+			if ch < 0x20 || ch > 0x7e {
+				fmt.Fprintf(&buf, "\\u%04x", ch)
+			} else {
+				buf.WriteRune(ch)
+			}
+		}
+	}
+	return buf.String()
 }
 
 func (err *TokenManagerError) Error() string {
-	panic("not implemented yet")
+	return err.message
 }
@@ -0,0 +1,43 @@
+package queryparser
+
+import (
+	"github.com/balzaczyy/golucene/core/analysis"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+/* Parses query strings, in the syntax of its implementation, into queries. */
+type QueryParser interface {
+	Parse(query string) (search.Query, error)
+}
+
+/*
+Creates a parser of queries whose terms default to field, and whose
+text is analyzed with analyzer.
+*/
+type Factory func(field string, analyzer analysis.Analyzer) QueryParser
+
+var allQueryParsers = util.NewNamedSPILoader("QueryParser")
+
+/*
+Registers a query parser factory by name, e.g. in the init() function
+of the package implementing the syntax, so that the syntax named in a
+configuration can be resolved with NewQueryParserByName().
+*/
+func RegisterQueryParser(name string, factory Factory) {
+	allQueryParsers.Register(name, factory)
+}
+
+/* Creates a new parser of the syntax registered under name. */
+func NewQueryParserByName(name, field string, analyzer analysis.Analyzer) (QueryParser, error) {
+	factory, err := allQueryParsers.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory.(Factory)(field, analyzer), nil
+}
+
+/* Returns a list of all available query parser names. */
+func AvailableQueryParsers() []string {
+	return allQueryParsers.AvailableServices()
+}
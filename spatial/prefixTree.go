@@ -0,0 +1,118 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+)
+
+// spatial/prefix/tree/QuadPrefixTree.java
+
+/* Appended to the token of a cell which is entirely covered by the shape. */
+const LEAF_BYTE = '+'
+
+/* Maximum number of levels of a QuadPrefixTree. */
+const MAX_LEVELS_POSSIBLE = 50
+
+/*
+A prefix tree of quads: the world is split in 4 cells at level 1, each
+of which is split in 4 at the next level, and so on. The token of a
+cell is the token of its parent followed by one of 'A' (lower left),
+'B' (lower right), 'C' (upper left) and 'D' (upper right), so that the
+tokens of the cells within a cell all start with its token.
+*/
+type QuadPrefixTree struct {
+	maxLevels int
+	world     *Rectangle
+}
+
+func NewQuadPrefixTree(maxLevels int) *QuadPrefixTree {
+	assert2(maxLevels > 0 && maxLevels <= MAX_LEVELS_POSSIBLE,
+		"maxLevels must be in [1,%v], got: %v", MAX_LEVELS_POSSIBLE, maxLevels)
+	return &QuadPrefixTree{maxLevels, &Rectangle{-180, 180, -90, 90}}
+}
+
+func (t *QuadPrefixTree) MaxLevels() int { return t.maxLevels }
+
+/*
+Returns the level of the largest cells whose diagonal is at most dist
+degrees, or MaxLevels() if there is none.
+*/
+func (t *QuadPrefixTree) LevelForDistance(dist float64) int {
+	if dist <= 0 {
+		return t.maxLevels
+	}
+	diagonal := t.world.diagonal()
+	for level := 1; level < t.maxLevels; level++ {
+		if diagonal/math.Pow(2, float64(level)) <= dist {
+			return level
+		}
+	}
+	return t.maxLevels
+}
+
+/* Returns the cells of the first level. */
+func (t *QuadPrefixTree) roots() []*cell {
+	return (&cell{nil, t.world}).children()
+}
+
+func (t *QuadPrefixTree) String() string {
+	return fmt.Sprintf("QuadPrefixTree(maxLevels:%v)", t.maxLevels)
+}
+
+// spatial/prefix/tree/Cell.java
+
+type cell struct {
+	token []byte
+	rect  *Rectangle
+}
+
+func (c *cell) level() int { return len(c.token) }
+
+func (c *cell) children() []*cell {
+	midX, midY := (c.rect.MinX+c.rect.MaxX)/2, (c.rect.MinY+c.rect.MaxY)/2
+	return []*cell{
+		c.child('A', &Rectangle{c.rect.MinX, midX, c.rect.MinY, midY}),
+		c.child('B', &Rectangle{midX, c.rect.MaxX, c.rect.MinY, midY}),
+		c.child('C', &Rectangle{c.rect.MinX, midX, midY, c.rect.MaxY}),
+		c.child('D', &Rectangle{midX, c.rect.MaxX, midY, c.rect.MaxY}),
+	}
+}
+
+func (c *cell) child(b byte, rect *Rectangle) *cell {
+	token := make([]byte, len(c.token)+1)
+	copy(token, c.token)
+	token[len(c.token)] = b
+	return &cell{token, rect}
+}
+
+func (c *cell) leafToken() []byte {
+	return append(append(make([]byte, 0, len(c.token)+1), c.token...), LEAF_BYTE)
+}
+
+/*
+Returns the tokens of the cells intersecting the shape, down to
+detailLevel, with their ancestors. Cells within the shape are not
+split further, and their token is followed by their leaf token, as
+are the cells of detailLevel.
+*/
+func (t *QuadPrefixTree) tokens(shape Shape, detailLevel int) (ans [][]byte) {
+	var visit func(c *cell)
+	visit = func(c *cell) {
+		rel := shape.Relate(c.rect)
+		if rel == RELATION_DISJOINT {
+			return
+		}
+		ans = append(ans, c.token)
+		if rel == RELATION_CONTAINS || c.level() >= detailLevel {
+			ans = append(ans, c.leafToken())
+			return
+		}
+		for _, child := range c.children() {
+			visit(child)
+		}
+	}
+	for _, root := range t.roots() {
+		visit(root)
+	}
+	return
+}
@@ -0,0 +1,333 @@
+package spatial
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+/*
+Shapes are given in degrees, with x the longitude in [-180,180] and y
+the latitude in [-90,90]. Edges are straight lines in that plane, and
+shapes cannot cross the dateline.
+*/
+
+// spatial4j/shape/SpatialRelation.java
+
+/* Relation of a shape to another one. */
+type Relation int
+
+const (
+	// The shapes have no point in common.
+	RELATION_DISJOINT = Relation(iota)
+	// The shapes share some points, but neither is within the other.
+	RELATION_INTERSECTS
+	// The shape is within the other one.
+	RELATION_WITHIN
+	// The shape contains the other one.
+	RELATION_CONTAINS
+)
+
+func (r Relation) String() string {
+	switch r {
+	case RELATION_DISJOINT:
+		return "DISJOINT"
+	case RELATION_INTERSECTS:
+		return "INTERSECTS"
+	case RELATION_WITHIN:
+		return "WITHIN"
+	case RELATION_CONTAINS:
+		return "CONTAINS"
+	}
+	return fmt.Sprintf("Relation(%v)", int(r))
+}
+
+// spatial4j/shape/Shape.java
+
+type Shape interface {
+	// Returns the smallest rectangle containing the shape.
+	BoundingBox() *Rectangle
+	// Returns the relation of the shape to the rectangle, e.g.
+	// RELATION_WITHIN if the shape is within the rectangle.
+	Relate(r *Rectangle) Relation
+}
+
+// spatial4j/shape/Point.java
+
+type Point struct {
+	X, Y float64
+}
+
+/* Creates a point; note the longitude comes first. */
+func NewPoint(lon, lat float64) *Point {
+	assertInWorld(lon, lat)
+	return &Point{lon, lat}
+}
+
+func (p *Point) BoundingBox() *Rectangle {
+	return &Rectangle{p.X, p.X, p.Y, p.Y}
+}
+
+func (p *Point) Relate(r *Rectangle) Relation {
+	if r.contains(p.X, p.Y) {
+		return RELATION_WITHIN
+	}
+	return RELATION_DISJOINT
+}
+
+func (p *Point) String() string {
+	return fmt.Sprintf("Pt(x=%v,y=%v)", p.X, p.Y)
+}
+
+// spatial4j/shape/Rectangle.java
+
+type Rectangle struct {
+	MinX, MaxX, MinY, MaxY float64
+}
+
+func NewRectangle(minLon, maxLon, minLat, maxLat float64) *Rectangle {
+	assertInWorld(minLon, minLat)
+	assertInWorld(maxLon, maxLat)
+	assert2(minLon <= maxLon && minLat <= maxLat,
+		"invalid rectangle: [%v,%v] x [%v,%v]", minLon, maxLon, minLat, maxLat)
+	return &Rectangle{minLon, maxLon, minLat, maxLat}
+}
+
+func (r *Rectangle) BoundingBox() *Rectangle { return r }
+
+func (r *Rectangle) Relate(other *Rectangle) Relation {
+	switch {
+	case r.MinX > other.MaxX || r.MaxX < other.MinX ||
+		r.MinY > other.MaxY || r.MaxY < other.MinY:
+		return RELATION_DISJOINT
+	case other.MinX <= r.MinX && r.MaxX <= other.MaxX &&
+		other.MinY <= r.MinY && r.MaxY <= other.MaxY:
+		return RELATION_WITHIN
+	case r.MinX <= other.MinX && other.MaxX <= r.MaxX &&
+		r.MinY <= other.MinY && other.MaxY <= r.MaxY:
+		return RELATION_CONTAINS
+	}
+	return RELATION_INTERSECTS
+}
+
+func (r *Rectangle) contains(x, y float64) bool {
+	return r.MinX <= x && x <= r.MaxX && r.MinY <= y && y <= r.MaxY
+}
+
+/* Returns true if the segment from (x1,y1) to (x2,y2) has a point in the rectangle. */
+func (r *Rectangle) crossedBy(x1, y1, x2, y2 float64) bool {
+	if r.contains(x1, y1) || r.contains(x2, y2) {
+		return true
+	}
+	if math.Max(x1, x2) < r.MinX || math.Min(x1, x2) > r.MaxX ||
+		math.Max(y1, y2) < r.MinY || math.Min(y1, y2) > r.MaxY {
+		return false
+	}
+	// both ends are out, so the segment has to cross one of the sides
+	return segmentsCross(x1, y1, x2, y2, r.MinX, r.MinY, r.MaxX, r.MinY) ||
+		segmentsCross(x1, y1, x2, y2, r.MaxX, r.MinY, r.MaxX, r.MaxY) ||
+		segmentsCross(x1, y1, x2, y2, r.MaxX, r.MaxY, r.MinX, r.MaxY) ||
+		segmentsCross(x1, y1, x2, y2, r.MinX, r.MaxY, r.MinX, r.MinY)
+}
+
+/* Returns the length of the diagonal, in degrees. */
+func (r *Rectangle) diagonal() float64 {
+	return math.Hypot(r.MaxX-r.MinX, r.MaxY-r.MinY)
+}
+
+func (r *Rectangle) String() string {
+	return fmt.Sprintf("Rect(minX=%v,maxX=%v,minY=%v,maxY=%v)", r.MinX, r.MaxX, r.MinY, r.MaxY)
+}
+
+// spatial4j/shape/jts/JtsGeometry.java
+
+/*
+A line string, i.e. the segments between consecutive vertices. Lines
+have no area, so they never contain a rectangle.
+*/
+type Line struct {
+	Points []*Point
+	bbox   *Rectangle
+}
+
+func NewLine(points ...*Point) *Line {
+	assert2(len(points) >= 2, "a line needs at least 2 points, got: %v", len(points))
+	return &Line{points, boundingBoxOf(points)}
+}
+
+func (l *Line) BoundingBox() *Rectangle { return l.bbox }
+
+func (l *Line) Relate(r *Rectangle) Relation {
+	switch l.bbox.Relate(r) {
+	case RELATION_DISJOINT:
+		return RELATION_DISJOINT
+	case RELATION_WITHIN:
+		return RELATION_WITHIN
+	}
+	for i := 1; i < len(l.Points); i++ {
+		p1, p2 := l.Points[i-1], l.Points[i]
+		if r.crossedBy(p1.X, p1.Y, p2.X, p2.Y) {
+			return RELATION_INTERSECTS
+		}
+	}
+	return RELATION_DISJOINT
+}
+
+func (l *Line) String() string {
+	return "Line" + formatPoints(l.Points)
+}
+
+/*
+A polygon given by its exterior ring and optional holes. Rings are
+closed implicitly, i.e. the last vertex is connected to the first
+one, and must not intersect themselves or each other.
+*/
+type Polygon struct {
+	Exterior []*Point
+	Holes    [][]*Point
+	bbox     *Rectangle
+}
+
+func NewPolygon(exterior []*Point, holes ...[]*Point) *Polygon {
+	assert2(len(exterior) >= 3, "a polygon needs at least 3 points, got: %v", len(exterior))
+	for _, hole := range holes {
+		assert2(len(hole) >= 3, "a hole needs at least 3 points, got: %v", len(hole))
+	}
+	return &Polygon{exterior, holes, boundingBoxOf(exterior)}
+}
+
+func (p *Polygon) BoundingBox() *Rectangle { return p.bbox }
+
+func (p *Polygon) Relate(r *Rectangle) Relation {
+	switch p.bbox.Relate(r) {
+	case RELATION_DISJOINT:
+		return RELATION_DISJOINT
+	case RELATION_WITHIN:
+		return RELATION_WITHIN
+	}
+	if p.edgeCrosses(r) {
+		return RELATION_INTERSECTS
+	}
+	// no edge within the rectangle: either it is inside the polygon, or
+	// out of it (possibly in a hole)
+	if p.contains((r.MinX+r.MaxX)/2, (r.MinY+r.MaxY)/2) {
+		return RELATION_CONTAINS
+	}
+	return RELATION_DISJOINT
+}
+
+func (p *Polygon) edgeCrosses(r *Rectangle) bool {
+	for _, ring := range append([][]*Point{p.Exterior}, p.Holes...) {
+		for i, p2 := range ring {
+			p1 := ring[(i+len(ring)-1)%len(ring)]
+			if r.crossedBy(p1.X, p1.Y, p2.X, p2.Y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/* Returns true if the point is in the exterior ring, but not in a hole. */
+func (p *Polygon) contains(x, y float64) bool {
+	if !ringContains(p.Exterior, x, y) {
+		return false
+	}
+	for _, hole := range p.Holes {
+		if ringContains(hole, x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Polygon) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("Polygon")
+	buf.WriteString(formatPoints(p.Exterior))
+	for _, hole := range p.Holes {
+		buf.WriteString(" - ")
+		buf.WriteString(formatPoints(hole))
+	}
+	return buf.String()
+}
+
+/* Even-odd rule: a ray from the point crosses the ring an odd number of times. */
+func ringContains(ring []*Point, x, y float64) bool {
+	ans := false
+	for i, p2 := range ring {
+		p1 := ring[(i+len(ring)-1)%len(ring)]
+		if (p1.Y > y) != (p2.Y > y) &&
+			x < (p2.X-p1.X)*(y-p1.Y)/(p2.Y-p1.Y)+p1.X {
+			ans = !ans
+		}
+	}
+	return ans
+}
+
+/* Returns true if segments (x1,y1)-(x2,y2) and (x3,y3)-(x4,y4) have a common point. */
+func segmentsCross(x1, y1, x2, y2, x3, y3, x4, y4 float64) bool {
+	d1 := orientation(x3, y3, x4, y4, x1, y1)
+	d2 := orientation(x3, y3, x4, y4, x2, y2)
+	d3 := orientation(x1, y1, x2, y2, x3, y3)
+	d4 := orientation(x1, y1, x2, y2, x4, y4)
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+	// collinear cases
+	return d1 == 0 && onSegment(x3, y3, x4, y4, x1, y1) ||
+		d2 == 0 && onSegment(x3, y3, x4, y4, x2, y2) ||
+		d3 == 0 && onSegment(x1, y1, x2, y2, x3, y3) ||
+		d4 == 0 && onSegment(x1, y1, x2, y2, x4, y4)
+}
+
+/* Returns the sign of the cross product of (x2-x1,y2-y1) and (x3-x1,y3-y1). */
+func orientation(x1, y1, x2, y2, x3, y3 float64) float64 {
+	v := (x2-x1)*(y3-y1) - (y2-y1)*(x3-x1)
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+/* Returns true if (x,y), collinear with the segment, is on it. */
+func onSegment(x1, y1, x2, y2, x, y float64) bool {
+	return math.Min(x1, x2) <= x && x <= math.Max(x1, x2) &&
+		math.Min(y1, y2) <= y && y <= math.Max(y1, y2)
+}
+
+func boundingBoxOf(points []*Point) *Rectangle {
+	ans := &Rectangle{math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)}
+	for _, p := range points {
+		ans.MinX, ans.MaxX = math.Min(ans.MinX, p.X), math.Max(ans.MaxX, p.X)
+		ans.MinY, ans.MaxY = math.Min(ans.MinY, p.Y), math.Max(ans.MaxY, p.Y)
+	}
+	return ans
+}
+
+func formatPoints(points []*Point) string {
+	var buf bytes.Buffer
+	buf.WriteString("(")
+	for i, p := range points {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%v %v", p.X, p.Y)
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+func assertInWorld(lon, lat float64) {
+	assert2(lon >= -180 && lon <= 180, "invalid longitude: %v", lon)
+	assert2(lat >= -90 && lat <= 90, "invalid latitude: %v", lat)
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
@@ -0,0 +1,155 @@
+package spatial
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func square(minX, minY, size float64) []*Point {
+	return []*Point{
+		NewPoint(minX, minY), NewPoint(minX+size, minY),
+		NewPoint(minX+size, minY+size), NewPoint(minX, minY+size),
+	}
+}
+
+func TestRelate(t *testing.T) {
+	donut := NewPolygon(square(0, 0, 10), square(4, 4, 2))
+	line := NewLine(NewPoint(0, 0), NewPoint(10, 10))
+	for i, c := range []struct {
+		shape Shape
+		rect  *Rectangle
+		want  Relation
+	}{
+		{NewPoint(1, 2), NewRectangle(0, 1, 0, 2), RELATION_WITHIN},
+		{NewPoint(1, 2), NewRectangle(0, 1, 3, 4), RELATION_DISJOINT},
+		{NewRectangle(0, 2, 0, 2), NewRectangle(1, 3, 1, 3), RELATION_INTERSECTS},
+		{NewRectangle(0, 4, 0, 4), NewRectangle(1, 3, 1, 3), RELATION_CONTAINS},
+		{donut, NewRectangle(-1, 11, -1, 11), RELATION_WITHIN},
+		{donut, NewRectangle(1, 2, 1, 2), RELATION_CONTAINS},
+		{donut, NewRectangle(4.5, 5.5, 4.5, 5.5), RELATION_DISJOINT}, // in the hole
+		{donut, NewRectangle(3, 5, 3, 5), RELATION_INTERSECTS},
+		{donut, NewRectangle(20, 30, 0, 10), RELATION_DISJOINT},
+		{line, NewRectangle(4, 6, 4, 6), RELATION_INTERSECTS},
+		{line, NewRectangle(6, 8, 0, 2), RELATION_DISJOINT}, // within the bounding box only
+		{line, NewRectangle(0, 10, 0, 10), RELATION_WITHIN},
+	} {
+		if got := c.shape.Relate(c.rect); got != c.want {
+			t.Errorf("%v: %v relate %v = %v, want %v", i, c.shape, c.rect, got, c.want)
+		}
+	}
+}
+
+func TestPrefixTreeStrategy(t *testing.T) {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+
+	path, err := ioutil.TempDir("", "gltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	dir, err := store.OpenFSDirectory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+
+	strategy := NewPrefixTreeStrategy(NewQuadPrefixTree(12), "geo")
+	w, err := index.NewIndexWriter(dir, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		kind  string
+		shape Shape
+	}{
+		{"point", NewPoint(10, 10)},
+		{"point", NewPoint(-50, 40)},
+		{"polygon", NewPolygon(square(0, 0, 20))},
+		{"line", NewLine(NewPoint(30, -10), NewPoint(60, -10))},
+		{"polygon", NewPolygon([]*Point{NewPoint(100, 0), NewPoint(120, 0), NewPoint(110, 20)})},
+	} {
+		d := docu.NewDocument()
+		d.Add(docu.NewStringField("kind", v.kind, docu.STORE_NO))
+		d.Add(strategy.CreateField(v.shape))
+		if err = w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	ss := search.NewIndexSearcher(reader)
+	matches := func(op SpatialOperation, shape Shape) (ans []int) {
+		filter := strategy.MakeFilter(op, shape)
+		for _, ctx := range ss.TopReaderContext().Leaves() {
+			set, err := filter.DocIdSet(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if set == nil {
+				continue
+			}
+			it, err := set.Iterator()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for doc, err := it.NextDoc(); doc != NO_MORE_DOCS; doc, err = it.NextDoc() {
+				if err != nil {
+					t.Fatal(err)
+				}
+				ans = append(ans, ctx.DocBase+doc)
+			}
+		}
+		return
+	}
+
+	rect := NewRectangle(5, 35, -20, 15)
+	for i, c := range []struct {
+		op    SpatialOperation
+		shape Shape
+		want  string
+	}{
+		{SPATIAL_OPERATION_INTERSECTS, rect, "[0 2 3]"},
+		{SPATIAL_OPERATION_IS_WITHIN, rect, "[0]"},
+		{SPATIAL_OPERATION_IS_DISJOINT_TO, rect, "[1 4]"},
+		{SPATIAL_OPERATION_IS_WITHIN, NewRectangle(-10, 25, -10, 25), "[0 2]"},
+		{SPATIAL_OPERATION_INTERSECTS, NewPolygon(square(-60, 30, 20)), "[1]"},
+		// the point is in the hole
+		{SPATIAL_OPERATION_INTERSECTS, NewPolygon(square(-60, 30, 20), square(-52, 38, 4)), "[]"},
+		{SPATIAL_OPERATION_INTERSECTS, NewLine(NewPoint(90, 10), NewPoint(130, 10)), "[4]"},
+		{SPATIAL_OPERATION_INTERSECTS, NewPoint(110, 5), "[4]"},
+	} {
+		if got := fmt.Sprint(matches(c.op, c.shape)); got != c.want {
+			t.Errorf("%v: %v %v = %v, want %v", i, c.op, c.shape, got, c.want)
+		}
+	}
+
+	// filters combine with other queries
+	q := search.NewFilteredQuery(search.NewTermQuery(index.NewTerm("kind", "polygon")),
+		strategy.MakeFilter(SPATIAL_OPERATION_INTERSECTS, rect))
+	docs, err := ss.SearchTop(q, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs.ScoreDocs) != 1 || docs.ScoreDocs[0].Doc != 2 {
+		t.Errorf("expect doc 2 only, got %v", docs.ScoreDocs)
+	}
+}
@@ -0,0 +1,299 @@
+package spatial
+
+import (
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	. "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	. "github.com/balzaczyy/golucene/core/search/model"
+	"github.com/balzaczyy/golucene/core/util"
+)
+
+// spatial/query/SpatialOperation.java
+
+/* Relation that an indexed shape must have with the query shape to match. */
+type SpatialOperation int
+
+const (
+	// The indexed shape shares some points with the query shape.
+	SPATIAL_OPERATION_INTERSECTS = SpatialOperation(iota)
+	// The indexed shape is within the query shape.
+	SPATIAL_OPERATION_IS_WITHIN
+	// The indexed shape has no point in common with the query shape.
+	SPATIAL_OPERATION_IS_DISJOINT_TO
+)
+
+func (op SpatialOperation) String() string {
+	switch op {
+	case SPATIAL_OPERATION_INTERSECTS:
+		return "Intersects"
+	case SPATIAL_OPERATION_IS_WITHIN:
+		return "IsWithin"
+	case SPATIAL_OPERATION_IS_DISJOINT_TO:
+		return "IsDisjointTo"
+	}
+	return fmt.Sprintf("SpatialOperation(%v)", int(op))
+}
+
+// spatial/prefix/RecursivePrefixTreeStrategy.java
+
+/*
+Default precision of the non-point shapes, as a fraction of the
+distance from the center of their bounding box to its corners.
+*/
+const DEFAULT_DIST_ERR_PCT = 0.025
+
+/*
+Indexes shapes as the tokens of the cells of a QuadPrefixTree they
+intersect, and matches them against a query shape by walking down the
+indexed cells which intersect the query shape.
+
+Matching is exact at the precision of the cells: the cells of points
+are on the last level of the tree, while other shapes are split down
+to cells about DistErrPct() of their size, and the boundary cells of
+a shape are considered entirely covered by it. The same applies to
+the query shape.
+*/
+type PrefixTreeStrategy struct {
+	grid       *QuadPrefixTree
+	field      string
+	distErrPct float64
+}
+
+func NewPrefixTreeStrategy(grid *QuadPrefixTree, field string) *PrefixTreeStrategy {
+	return &PrefixTreeStrategy{grid, field, DEFAULT_DIST_ERR_PCT}
+}
+
+func (s *PrefixTreeStrategy) Field() string { return s.field }
+
+func (s *PrefixTreeStrategy) Grid() *QuadPrefixTree { return s.grid }
+
+func (s *PrefixTreeStrategy) DistErrPct() float64 { return s.distErrPct }
+
+/*
+Sets the precision of non-point shapes, in [0,0.5]; 0 means the last
+level of the tree, which can produce a lot of cells for large shapes.
+*/
+func (s *PrefixTreeStrategy) SetDistErrPct(distErrPct float64) {
+	assert2(distErrPct >= 0 && distErrPct <= 0.5, "distErrPct must be in [0,0.5], got: %v", distErrPct)
+	s.distErrPct = distErrPct
+}
+
+func (s *PrefixTreeStrategy) detailLevel(shape Shape) int {
+	if _, ok := shape.(*Point); ok {
+		return s.grid.maxLevels
+	}
+	return s.grid.LevelForDistance(s.distErrPct * shape.BoundingBox().diagonal() / 2)
+}
+
+/* Creates the field indexing the shape. */
+func (s *PrefixTreeStrategy) CreateField(shape Shape) *document.Field {
+	tokens := s.grid.tokens(shape, s.detailLevel(shape))
+	return document.NewFieldFromTokenStream(s.field, newCellTokenStream(tokens), CELL_FIELD_TYPE)
+}
+
+/* Creates a filter of the documents whose shape has the relation op with the query shape. */
+func (s *PrefixTreeStrategy) MakeFilter(op SpatialOperation, shape Shape) search.Filter {
+	return &prefixTreeFilter{s, op, shape, s.detailLevel(shape)}
+}
+
+/* Indexed, tokenized, omits norms, indexes DOCS_ONLY, not stored. */
+var CELL_FIELD_TYPE = func() *document.FieldType {
+	ft := document.NewFieldType()
+	ft.SetIndexed(true)
+	ft.SetTokenized(true)
+	ft.SetOmitNorms(true)
+	ft.SetIndexOptions(model.INDEX_OPT_DOCS_ONLY)
+	ft.Freeze()
+	return ft
+}()
+
+// spatial/prefix/CellTokenStream.java
+
+/* Returns the cell tokens, one per position. */
+type cellTokenStream struct {
+	*analysis.TokenStreamImpl
+	termAttribute CharTermAttribute
+	tokens        [][]byte
+	upto          int
+}
+
+func newCellTokenStream(tokens [][]byte) *cellTokenStream {
+	ans := &cellTokenStream{TokenStreamImpl: analysis.NewTokenStream(), tokens: tokens}
+	ans.termAttribute = ans.Attributes().Add("CharTermAttribute").(CharTermAttribute)
+	return ans
+}
+
+func (ts *cellTokenStream) Reset() error {
+	ts.upto = 0
+	return nil
+}
+
+func (ts *cellTokenStream) IncrementToken() (bool, error) {
+	if ts.upto >= len(ts.tokens) {
+		return false, nil
+	}
+	ts.Attributes().Clear()
+	ts.termAttribute.AppendString(string(ts.tokens[ts.upto]))
+	ts.upto++
+	return true, nil
+}
+
+// spatial/prefix/AbstractVisitingPrefixTreeFilter.java
+
+type prefixTreeFilter struct {
+	strategy    *PrefixTreeStrategy
+	op          SpatialOperation
+	shape       Shape
+	detailLevel int
+}
+
+func (f *prefixTreeFilter) DocIdSet(ctx *index.AtomicReaderContext, acceptDocs util.Bits) (search.DocIdSet, error) {
+	terms := ctx.Reader().(index.AtomicReader).Terms(f.strategy.field)
+	if terms == nil {
+		return nil, nil
+	}
+	v := &cellVisitor{
+		grid:        f.strategy.grid,
+		termsEnum:   terms.Iterator(nil),
+		acceptDocs:  acceptDocs,
+		maxDoc:      ctx.Reader().MaxDoc(),
+		shape:       f.shape,
+		detailLevel: f.detailLevel,
+	}
+	intersects, err := v.visit(v.intersects)
+	if err != nil {
+		return nil, err
+	}
+	switch f.op {
+	case SPATIAL_OPERATION_INTERSECTS:
+		return intersects, nil
+	case SPATIAL_OPERATION_IS_WITHIN:
+		outside, err := v.visit(v.outside)
+		if err != nil {
+			return nil, err
+		}
+		return andNot(intersects, outside), nil
+	case SPATIAL_OPERATION_IS_DISJOINT_TO:
+		all, err := v.visit(v.all)
+		if err != nil {
+			return nil, err
+		}
+		return andNot(all, intersects), nil
+	}
+	panic(fmt.Sprintf("unsupported operation: %v", f.op))
+}
+
+func (f *prefixTreeFilter) String() string {
+	return fmt.Sprintf("%v(%v,%v,detailLevel=%v)", f.op, f.strategy.field, f.shape, f.detailLevel)
+}
+
+/* Returns the docs of a which are not in b. */
+func andNot(a, b *util.FixedBitSet) *util.FixedBitSet {
+	ans := util.NewFixedBitSetOf(a.Length())
+	for doc := 0; doc < a.Length(); doc++ {
+		if a.At(doc) && !b.At(doc) {
+			ans.Set(doc)
+		}
+	}
+	return ans
+}
+
+/*
+Walks the cells of the segment, i.e. those whose token is indexed,
+from the top level down, and collects the documents of some of them,
+depending on their relation with the query shape.
+*/
+type cellVisitor struct {
+	grid        *QuadPrefixTree
+	termsEnum   model.TermsEnum
+	acceptDocs  util.Bits
+	maxDoc      int
+	shape       Shape
+	detailLevel int
+	bits        *util.FixedBitSet
+}
+
+/* Visits the cells with the given function, returning the collected documents. */
+func (v *cellVisitor) visit(f func(c *cell, rel Relation) (bool, error)) (*util.FixedBitSet, error) {
+	v.bits = util.NewFixedBitSetOf(v.maxDoc)
+	var visit func(c *cell) error
+	visit = func(c *cell) error {
+		if ok, err := v.termsEnum.SeekExact(c.token); !ok || err != nil {
+			return err // no document in this cell
+		}
+		descend, err := f(c, v.shape.Relate(c.rect))
+		if !descend || err != nil {
+			return err
+		}
+		for _, child := range c.children() {
+			if err = visit(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range v.grid.roots() {
+		if err := visit(root); err != nil {
+			return nil, err
+		}
+	}
+	return v.bits, nil
+}
+
+/* Collects the documents of the indexed shapes intersecting the query shape. */
+func (v *cellVisitor) intersects(c *cell, rel Relation) (bool, error) {
+	switch {
+	case rel == RELATION_DISJOINT:
+		return false, nil
+	case rel == RELATION_CONTAINS || c.level() >= v.detailLevel:
+		// every shape in this cell
+		return false, v.collect(c.token)
+	}
+	// shapes covering this cell, and maybe some in its children
+	return true, v.collect(c.leafToken())
+}
+
+/* Collects the documents of the indexed shapes going out of the query shape. */
+func (v *cellVisitor) outside(c *cell, rel Relation) (bool, error) {
+	switch {
+	case rel == RELATION_CONTAINS:
+		return false, nil
+	case rel == RELATION_DISJOINT:
+		// every shape in this cell
+		return false, v.collect(c.token)
+	case c.level() >= v.detailLevel:
+		// boundary of the query shape
+		return false, nil
+	}
+	// shapes covering this cell, and maybe some in its children
+	return true, v.collect(c.leafToken())
+}
+
+/* Collects the documents of all indexed shapes. */
+func (v *cellVisitor) all(c *cell, rel Relation) (bool, error) {
+	return false, v.collect(c.token)
+}
+
+func (v *cellVisitor) collect(token []byte) error {
+	if ok, err := v.termsEnum.SeekExact(token); !ok || err != nil {
+		return err
+	}
+	docs, err := v.termsEnum.Docs(v.acceptDocs, nil)
+	if err != nil {
+		return err
+	}
+	for {
+		doc, err := docs.NextDoc()
+		if err != nil {
+			return err
+		}
+		if doc == NO_MORE_DOCS {
+			return nil
+		}
+		v.bits.Set(doc)
+	}
+}
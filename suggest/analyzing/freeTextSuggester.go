@@ -0,0 +1,407 @@
+package analyzing
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/core/util/fst"
+	"github.com/balzaczyy/golucene/core/util/packed"
+	"github.com/balzaczyy/golucene/suggest"
+	"math"
+	"sort"
+	"strings"
+)
+
+// suggest/analyzing/FreeTextSuggester.java
+
+/*
+The default character used to join multiple tokens into a single
+ngram token. The input tokens produced by the analyzer must not
+contain this character.
+*/
+const DEFAULT_SEPARATOR = 0x1e
+
+/* By default we use a bigram model. */
+const DEFAULT_GRAMS = 2
+
+/* The constant used for backoff model. */
+const ALPHA = 0.4
+
+/*
+Builds an ngram model from the text sent to Build() and predicts based
+on the last grams-1 tokens in the request sent to Lookup(). This tries
+to handle the "long tail" of suggestions for when the incoming query
+is a never before seen query string.
+
+Likely this suggester would only be used as a fallback, when the
+primary suggester fails to find any suggestions.
+
+Note that the weight for each suggestion is unused, and the
+suggestions are the analyzed forms (so your analysis process should
+normally be very "light").
+
+This uses the stupid backoff language model to smooth scores across
+ngram models; see "Large language models in machine translation"
+(http://acl.ldc.upenn.edu/D/D07/D07-1090.pdf) for details.
+
+From Lookup(), the key of each result is the ngram token; the value
+is Long.MAX_VALUE * score (fixed point, cast to int64). Divide by
+math.MaxInt64 to get the score back, which ranges from 0.0 to 1.0.
+
+The grams of all orders are kept in a single FST, whose output is the
+count of the gram. The completions of a context and prefix are
+collected by walking the FST below the prefix, without following the
+separator, so only the grams of the same order are visited.
+*/
+type FreeTextSuggester struct {
+	// Analyzer that will be used for analyzing suggestions while
+	// building the index.
+	indexAnalyzer analysis.Analyzer
+	// Analyzer that will be used for analyzing suggestions at query
+	// time.
+	queryAnalyzer analysis.Analyzer
+	// How many ngrams we create.
+	grams int
+	// Character used to join tokens into a single gram.
+	separator string
+
+	// The grams of all orders, with their counts as outputs.
+	fst *fst.FST
+	// Total number of tokens seen while building.
+	totTokens int64
+	// Number of entries the suggester was built with.
+	count int64
+}
+
+/* Instantiate, using the provided analyzer for both indexing and lookup, using bigram model by default. */
+func NewFreeTextSuggester(analyzer analysis.Analyzer) *FreeTextSuggester {
+	return NewFreeTextSuggesterWith(analyzer, analyzer, DEFAULT_GRAMS, DEFAULT_SEPARATOR)
+}
+
+/*
+Instantiate, using the provided indexing and lookup analyzers, and
+specified model (2 = bigram, 3 = trigram, etc.). The separator is
+passed to join tokens into a single gram; none of the tokens produced
+by the analyzers may contain it.
+*/
+func NewFreeTextSuggesterWith(indexAnalyzer, queryAnalyzer analysis.Analyzer,
+	grams int, separator byte) *FreeTextSuggester {
+
+	assert2(grams >= 1, "grams must be >= 1")
+	return &FreeTextSuggester{
+		indexAnalyzer: indexAnalyzer,
+		queryAnalyzer: queryAnalyzer,
+		grams:         grams,
+		separator:     string([]byte{separator}),
+	}
+}
+
+func assert2(ok bool, msg string, args ...interface{}) {
+	if !ok {
+		panic(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (s *FreeTextSuggester) Count() int64 {
+	return s.count
+}
+
+/* Returns the ngram order of the model. */
+func (s *FreeTextSuggester) Grams() int {
+	return s.grams
+}
+
+func (s *FreeTextSuggester) Build(iterator suggest.InputIterator) error {
+	if iterator.HasPayloads() {
+		return errors.New("this suggester doesn't support payloads")
+	}
+
+	gramCounts := make(map[string]int64)
+	var totTokens, count int64
+	for {
+		surfaceForm, err := iterator.Next()
+		if err != nil {
+			return err
+		}
+		if surfaceForm == nil {
+			break
+		}
+		tokens, _, err := s.analyze(s.indexAnalyzer, string(surfaceForm))
+		if err != nil {
+			return err
+		}
+		for i := range tokens {
+			totTokens++
+			// record every gram ending at this token:
+			for order := 1; order <= s.grams && order <= i+1; order++ {
+				gram := s.join(tokens[i+1-order : i+1])
+				gramCounts[gram]++
+			}
+		}
+		count++
+	}
+
+	keys := make([]string, 0, len(gramCounts))
+	for k, _ := range gramCounts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var model *fst.FST
+	if len(keys) > 0 { // an FST needs at least one input
+		b := fst.NewBuilder(fst.INPUT_TYPE_BYTE1, 0, 0, true, true, math.MaxInt32,
+			fst.PositiveIntOutputsSingleton(), false, packed.PackedInts.COMPACT, true, 15)
+		scratch := util.NewIntsRefBuilder()
+		for _, k := range keys {
+			if err := b.Add(fst.ToIntsRef([]byte(k), scratch), gramCounts[k]); err != nil {
+				return err
+			}
+		}
+		var err error
+		if model, err = b.Finish(); err != nil {
+			return err
+		}
+	}
+	s.fst, s.totTokens, s.count = model, totTokens, count
+	return nil
+}
+
+/*
+Analyzes text into its tokens. Also reports whether the text ends
+with a token separator (e.g. a trailing space), in which case the
+last token is complete and the next token should be predicted.
+*/
+func (s *FreeTextSuggester) analyze(analyzer analysis.Analyzer, text string) (tokens []string, endsWithSep bool, err error) {
+	var ts analysis.TokenStream
+	if ts, err = analyzer.TokenStreamForString("", text); err != nil {
+		return nil, false, err
+	}
+	defer util.CloseWhileSuppressingError(ts)
+
+	termAtt := ts.Attributes().Add("CharTermAttribute").(ta.CharTermAttribute)
+	offsetAtt := ts.Attributes().Add("OffsetAttribute").(ta.OffsetAttribute)
+	if err = ts.Reset(); err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	lastEnd := 0
+	for ok, err = ts.IncrementToken(); ok && err == nil; ok, err = ts.IncrementToken() {
+		token := string(termAtt.Buffer()[:termAtt.Length()])
+		if strings.Contains(token, s.separator) {
+			return nil, false, errors.New(fmt.Sprintf(
+				"tokens must not contain separator byte; got token=%v", token))
+		}
+		tokens = append(tokens, token)
+		lastEnd = offsetAtt.EndOffset()
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if err = ts.End(); err != nil {
+		return nil, false, err
+	}
+	endsWithSep = offsetAtt.EndOffset() > lastEnd
+	return tokens, endsWithSep, nil
+}
+
+func (s *FreeTextSuggester) join(tokens []string) string {
+	return strings.Join(tokens, s.separator)
+}
+
+/* Returns the count of the given gram, or 0 if it was never seen. */
+func (s *FreeTextSuggester) gramCount(gram string) (int64, error) {
+	output, err := fst.GetFSTOutput(s.fst, []byte(gram))
+	if err != nil || output == nil {
+		return 0, err
+	}
+	return output.(int64), nil
+}
+
+func (s *FreeTextSuggester) Lookup(key string, onlyMorePopular bool, num int) ([]*suggest.LookupResult, error) {
+	if onlyMorePopular {
+		return nil, errors.New("this suggester only works with onlyMorePopular=false")
+	}
+	return s.LookupN(key, num)
+}
+
+/* Retrieve suggestions. */
+func (s *FreeTextSuggester) LookupN(key string, num int) ([]*suggest.LookupResult, error) {
+	assert2(num > 0, "num must be > 0")
+	if s.fst == nil {
+		return nil, nil
+	}
+
+	tokens, endsWithSep, err := s.analyze(s.queryAnalyzer, key)
+	if err != nil {
+		return nil, err
+	}
+	// The last token is the prefix to complete unless the key ends
+	// with a separator, in which case we predict the next token:
+	var prefix string
+	if !endsWithSep && len(tokens) > 0 {
+		prefix = tokens[len(tokens)-1]
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	// Don't consider grams longer than what we have context for:
+	maxOrder := s.grams
+	if len(tokens)+1 < maxOrder {
+		maxOrder = len(tokens) + 1
+	}
+
+	// Stupid backoff: try the longest context first, then back off
+	// to shorter contexts, discounting each step by ALPHA.
+	seen := make(map[string]bool)
+	var results []*suggest.LookupResult
+	backoff := 1.0
+	for order := maxOrder; order >= 1 && len(results) < num; order-- {
+		if order == 1 && prefix == "" {
+			break // don't make unigram predictions from an empty prefix
+		}
+		context := tokens[len(tokens)-(order-1):]
+		var contextCount int64
+		if order == 1 {
+			contextCount = s.totTokens
+		} else if contextCount, err = s.gramCount(s.join(context)); err != nil {
+			return nil, err
+		}
+		if contextCount > 0 {
+			gramPrefix := prefix
+			if len(context) > 0 {
+				gramPrefix = s.join(context) + s.separator + prefix
+			}
+			completions, err := s.topCompletions(gramPrefix, num-len(results), seen)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range completions {
+				score := backoff * float64(c.count) / float64(contextCount)
+				results = append(results, suggest.NewLookupResult(
+					s.surfaceForm(context, c.token), encodeWeight(score)))
+				seen[c.token] = true
+			}
+		}
+		backoff *= ALPHA
+	}
+
+	sort.Stable(byValueDesc(results))
+	if len(results) > num {
+		results = results[:num]
+	}
+	return results, nil
+}
+
+func (s *FreeTextSuggester) surfaceForm(context []string, token string) string {
+	if len(context) == 0 {
+		return token
+	}
+	return strings.Join(context, " ") + " " + token
+}
+
+func encodeWeight(score float64) int64 {
+	if score >= 1 {
+		return math.MaxInt64
+	}
+	return int64(score * math.MaxInt64)
+}
+
+type completion struct {
+	token string
+	count int64
+}
+
+/*
+Collects the top n completions of the grams starting with gramPrefix,
+and of the same order, skipping completions already seen by a higher
+order model.
+*/
+func (s *FreeTextSuggester) topCompletions(gramPrefix string, n int, seen map[string]bool) ([]completion, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	outputs := fst.PositiveIntOutputsSingleton()
+	in := s.fst.BytesReader()
+	arc := s.fst.FirstArc(&fst.Arc{})
+	output := outputs.NoOutput()
+	for _, b := range []byte(gramPrefix) {
+		if ret, err := s.fst.FindTargetArc(int(b), arc, arc, in); ret == nil || err != nil {
+			return nil, err // no gram starts with the prefix
+		}
+		output = outputs.Add(output, arc.Output)
+	}
+	// the last token of the gram starts right after its context:
+	tokenPrefix := gramPrefix[strings.LastIndex(gramPrefix, s.separator)+1:]
+
+	pq := &completionQueue{}
+	if err := s.collectCompletions(arc, output, in, []byte(tokenPrefix), n, seen, pq); err != nil {
+		return nil, err
+	}
+	ans := make([]completion, pq.Len())
+	for i := len(ans) - 1; i >= 0; i-- {
+		ans[i] = heap.Pop(pq).(completion)
+	}
+	return ans, nil
+}
+
+/*
+Walks the FST below the arc, whose path from the root has the given
+output and ends the token so far, adding the accepted tokens to the
+queue. The separator is not followed, as it leads to the higher order
+grams.
+*/
+func (s *FreeTextSuggester) collectCompletions(follow *fst.Arc, output interface{},
+	in fst.BytesReader, token []byte, n int, seen map[string]bool, pq *completionQueue) error {
+
+	outputs := fst.PositiveIntOutputsSingleton()
+	if follow.IsFinal() && !seen[string(token)] {
+		c := completion{string(token), outputs.Add(output, follow.NextFinalOutput).(int64)}
+		if pq.Len() < n {
+			heap.Push(pq, c)
+		} else if c.count > (*pq)[0].count {
+			(*pq)[0] = c
+			heap.Fix(pq, 0)
+		}
+	}
+	if !fst.TargetHasArcs(follow) {
+		return nil
+	}
+	arc, err := s.fst.ReadFirstTargetArc(follow, &fst.Arc{}, in)
+	for ; err == nil; arc, err = s.fst.ReadNextArc(arc, in) {
+		if arc.Label != fst.FST_END_LABEL && arc.Label != int(s.separator[0]) {
+			if err = s.collectCompletions(arc, outputs.Add(output, arc.Output), in,
+				append(token, byte(arc.Label)), n, seen, pq); err != nil {
+				return err
+			}
+		}
+		if arc.IsLast() {
+			return nil
+		}
+	}
+	return err
+}
+
+type completionQueue []completion
+
+func (q completionQueue) Len() int      { return len(q) }
+func (q completionQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q completionQueue) Less(i, j int) bool {
+	if q[i].count == q[j].count {
+		return q[i].token > q[j].token
+	}
+	return q[i].count < q[j].count
+}
+func (q *completionQueue) Push(x interface{}) { *q = append(*q, x.(completion)) }
+func (q *completionQueue) Pop() interface{} {
+	n := len(*q)
+	ans := (*q)[n-1]
+	*q = (*q)[:n-1]
+	return ans
+}
+
+type byValueDesc []*suggest.LookupResult
+
+func (r byValueDesc) Len() int           { return len(r) }
+func (r byValueDesc) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byValueDesc) Less(i, j int) bool { return r[i].Value > r[j].Value }
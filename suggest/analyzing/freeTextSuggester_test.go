@@ -0,0 +1,92 @@
+package analyzing
+
+import (
+	"fmt"
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	"github.com/balzaczyy/golucene/suggest"
+	"math"
+	"strings"
+	"testing"
+)
+
+func newInputs(texts ...string) suggest.InputIterator {
+	var inputs []suggest.Input
+	for _, text := range texts {
+		inputs = append(inputs, suggest.Input{Term: []byte(text), Weight: 1})
+	}
+	return suggest.NewInputArrayIterator(inputs...)
+}
+
+func lookupString(t *testing.T, s *FreeTextSuggester, key string) string {
+	results, err := s.LookupN(key, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	for _, r := range results {
+		parts = append(parts, fmt.Sprintf("%v/%.2f", r.Key, float64(r.Value)/math.MaxInt64))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestFreeTextSuggester(t *testing.T) {
+	s := NewFreeTextSuggester(std.NewStandardAnalyzer())
+	if err := s.Build(newInputs("foo bar baz blah", "boo foo bar foo bee")); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Count(); n != 2 {
+		t.Fatalf("expected 2 entries, but was %v", n)
+	}
+
+	for _, test := range []struct{ key, expected string }{
+		// uses the bigram model, then backs off to the unigrams
+		{"foo b", "foo bar/0.67 foo bee/0.33 baz/0.04 blah/0.04 boo/0.04"},
+		// uses only the bigram model
+		{"foo ", "foo bar/0.67 foo bee/0.33"},
+		// uses only the unigram model
+		{"foo", "foo/0.33"},
+		{"b", "bar/0.22 baz/0.11 bee/0.11 blah/0.11 boo/0.11"},
+		// never seen context, backs off to the unigrams
+		{"zzz b", "bar/0.09 baz/0.04 bee/0.04 blah/0.04 boo/0.04"},
+		{"x", ""},
+	} {
+		if got := lookupString(t, s, test.key); got != test.expected {
+			t.Errorf("expected suggestions of '%v' to be '%v', but was '%v'", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestFreeTextSuggesterTrigrams(t *testing.T) {
+	a := std.NewStandardAnalyzer()
+	s := NewFreeTextSuggesterWith(a, a, 3, DEFAULT_SEPARATOR)
+	if err := s.Build(newInputs("red fox jumps", "red fox sleeps", "blue fox jumps")); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ key, expected string }{
+		{"red fox ", "red fox jumps/0.50 red fox sleeps/0.50"},
+		// backs off to the bigrams of the last token
+		{"blue fox ", "blue fox jumps/1.00 fox sleeps/0.13"},
+		{"red fox j", "red fox jumps/0.50"},
+		// never seen context, discounted once
+		{"green fox ", "fox jumps/0.27 fox sleeps/0.13"},
+	} {
+		if got := lookupString(t, s, test.key); got != test.expected {
+			t.Errorf("expected suggestions of '%v' to be '%v', but was '%v'", test.key, test.expected, got)
+		}
+	}
+}
+
+func TestFreeTextSuggesterEmpty(t *testing.T) {
+	s := NewFreeTextSuggester(std.NewStandardAnalyzer())
+	if err := s.Build(newInputs()); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookupString(t, s, "foo"); got != "" {
+		t.Errorf("expected no suggestion, but was '%v'", got)
+	}
+	a := std.NewStandardAnalyzer()
+	s = NewFreeTextSuggesterWith(a, a, 2, 'o')
+	if err := s.Build(newInputs("foo bar")); err == nil {
+		t.Error("expected an error building with a token containing the separator")
+	}
+}
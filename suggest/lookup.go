@@ -0,0 +1,94 @@
+package suggest
+
+import (
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+)
+
+// suggest/Lookup.java
+
+/* Result of a lookup. */
+type LookupResult struct {
+	// the key's text
+	Key string
+	// the key's weight
+	Value int64
+	// the key's payload (nil if not present)
+	Payload []byte
+}
+
+func NewLookupResult(key string, value int64) *LookupResult {
+	return &LookupResult{Key: key, Value: value}
+}
+
+/* Simple Lookup interface for string suggestions. */
+type Lookup interface {
+	// Builds up a new internal Lookup representation based on the
+	// given InputIterator. The implementation might re-sort the data
+	// internally.
+	Build(InputIterator) error
+	// Look up a key and return possible completion for this key.
+	//
+	// If onlyMorePopular is true, return only more popular results.
+	// num is the maximum number of results to return.
+	Lookup(key string, onlyMorePopular bool, num int) ([]*LookupResult, error)
+	// Get the number of entries the lookup was built with.
+	Count() int64
+}
+
+// suggest/InputIterator.java
+
+/*
+Interface for enumerating term, weight, payload triples for suggester
+consumption; currently only AnalyzingSuggester and FreeTextSuggester
+support payloads.
+*/
+type InputIterator interface {
+	util.BytesRefIterator
+	// A term's weight, higher numbers mean better suggestions.
+	Weight() int64
+	// An arbitrary byte slice to record per suggestion. See
+	// LookupResult.Payload to retrieve the payload for each suggestion.
+	Payload() []byte
+	// Returns true if the iterator has payloads.
+	HasPayloads() bool
+}
+
+/* A term with a weight and an optional payload. */
+type Input struct {
+	Term    []byte
+	Weight  int64
+	Payload []byte
+}
+
+/* An InputIterator over a fixed slice of Inputs. */
+type InputArrayIterator struct {
+	inputs      []Input
+	current     int
+	hasPayloads bool
+}
+
+func NewInputArrayIterator(inputs ...Input) *InputArrayIterator {
+	ans := &InputArrayIterator{inputs: inputs, current: -1}
+	for _, in := range inputs {
+		if in.Payload != nil {
+			ans.hasPayloads = true
+			break
+		}
+	}
+	return ans
+}
+
+func (it *InputArrayIterator) Next() ([]byte, error) {
+	if it.current+1 >= len(it.inputs) {
+		it.current = len(it.inputs)
+		return nil, nil
+	}
+	it.current++
+	return it.inputs[it.current].Term, nil
+}
+
+func (it *InputArrayIterator) Comparator() sort.Interface { return nil }
+func (it *InputArrayIterator) Weight() int64              { return it.inputs[it.current].Weight }
+func (it *InputArrayIterator) Payload() []byte            { return it.inputs[it.current].Payload }
+func (it *InputArrayIterator) HasPayloads() bool          { return it.hasPayloads }
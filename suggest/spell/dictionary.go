@@ -0,0 +1,102 @@
+package spell
+
+import (
+	"bufio"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/util"
+	"github.com/balzaczyy/golucene/suggest"
+	"io"
+	"sort"
+	"strings"
+)
+
+// suggest/spell/Dictionary.java
+
+/*
+A simple interface representing a Dictionary. A Dictionary here is a
+list of entries, where every entry consists of term, weights and
+payload.
+*/
+type Dictionary interface {
+	// Returns an iterator over all the entries
+	EntryIterator() (suggest.InputIterator, error)
+}
+
+// suggest/spell/PlainTextDictionary.java
+
+/*
+Dictionary represented by a text file.
+
+Format allowed: 1 word per line:
+
+	word1
+	word2
+	word3
+*/
+type PlainTextDictionary struct {
+	in io.Reader
+}
+
+/* Creates a dictionary based on a reader. */
+func NewPlainTextDictionary(in io.Reader) *PlainTextDictionary {
+	return &PlainTextDictionary{in}
+}
+
+func (d *PlainTextDictionary) EntryIterator() (suggest.InputIterator, error) {
+	return newInputIteratorWrapper(&fileIterator{scanner: bufio.NewScanner(d.in)}), nil
+}
+
+type fileIterator struct {
+	scanner *bufio.Scanner
+	done    bool
+}
+
+func (it *fileIterator) Next() ([]byte, error) {
+	if it.done {
+		return nil, nil
+	}
+	if !it.scanner.Scan() {
+		it.done = true
+		return nil, it.scanner.Err()
+	}
+	return []byte(strings.TrimRight(it.scanner.Text(), "\r")), nil
+}
+
+func (it *fileIterator) Comparator() sort.Interface {
+	return nil
+}
+
+// suggest/spell/LuceneDictionary.java
+
+/* Lucene Dictionary: terms taken from the given field of a Lucene index. */
+type LuceneDictionary struct {
+	reader index.IndexReader
+	field  string
+}
+
+/* Creates a new Dictionary, pulling source terms from the specified field in the provided reader. */
+func NewLuceneDictionary(reader index.IndexReader, field string) *LuceneDictionary {
+	return &LuceneDictionary{reader, field}
+}
+
+func (d *LuceneDictionary) EntryIterator() (suggest.InputIterator, error) {
+	if terms := index.GetMultiTerms(d.reader, d.field); terms != nil {
+		return newInputIteratorWrapper(terms.Iterator(nil)), nil
+	}
+	return newInputIteratorWrapper(util.EMPTY_BYTES_REF_ITERATOR), nil
+}
+
+// suggest/InputIterator.java
+
+/* Wraps a BytesRefIterator as a suggester InputIterator, with all weights set to 1. */
+type inputIteratorWrapper struct {
+	util.BytesRefIterator
+}
+
+func newInputIteratorWrapper(wrapped util.BytesRefIterator) *inputIteratorWrapper {
+	return &inputIteratorWrapper{wrapped}
+}
+
+func (it *inputIteratorWrapper) Weight() int64     { return 1 }
+func (it *inputIteratorWrapper) Payload() []byte   { return nil }
+func (it *inputIteratorWrapper) HasPayloads() bool { return false }
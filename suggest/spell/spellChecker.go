@@ -0,0 +1,570 @@
+package spell
+
+import (
+	"errors"
+	"fmt"
+	"github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/index/model"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"sort"
+	"sync"
+)
+
+// suggest/spell/SuggestMode.java
+
+/* Set of strategies for suggesting related terms */
+type SuggestMode int
+
+const (
+	// Generate suggestions only for terms not in the index (default)
+	SUGGEST_WHEN_NOT_IN_INDEX = SuggestMode(1)
+	// Return only suggested words that are as frequent or more
+	// frequent than the searched word
+	SUGGEST_MORE_POPULAR = SuggestMode(2)
+	// Always attempt to offer suggestions (however, other parameters
+	// may limit suggestions. For example, see
+	// DirectSpellChecker.setMaxQueryFrequency).
+	SUGGEST_ALWAYS = SuggestMode(3)
+)
+
+// suggest/spell/SuggestWord.java
+
+/* SuggestWord, used in SuggestSimilar method in SpellChecker class. */
+type SuggestWord struct {
+	// the score of the word
+	Score float32
+	// The freq of the word
+	Freq int
+	// the suggested word
+	String string
+}
+
+// suggest/spell/SuggestWordScoreComparator.java
+
+/*
+Sorts SuggestWord instances first by score, then by frequency, and
+finally by the word itself.
+*/
+type suggestWordsByScore []*SuggestWord
+
+func (s suggestWordsByScore) Len() int      { return len(s) }
+func (s suggestWordsByScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s suggestWordsByScore) Less(i, j int) bool {
+	// first criteria: the distance
+	if s[i].Score != s[j].Score {
+		return s[i].Score > s[j].Score
+	}
+	// second criteria (if first criteria is equal): the popularity
+	if s[i].Freq != s[j].Freq {
+		return s[i].Freq > s[j].Freq
+	}
+	// third criteria: term text
+	return s[i].String < s[j].String
+}
+
+// suggest/spell/SpellChecker.java
+
+/* The default minimum score to use, if not specified by SetAccuracy(). */
+const DEFAULT_ACCURACY = 0.5
+
+/* Field name for each word in the ngram index. */
+const F_WORD = "word"
+
+/*
+Spell Checker class (Main class).
+(initially inspired by the David Spencer code).
+
+Example Usage:
+
+	spellChecker := spell.NewSpellChecker(spellDirectory)
+	// To index a field of a user index:
+	spellChecker.IndexDictionary(spell.NewLuceneDictionary(reader, field), config)
+	// To index a file containing words:
+	spellChecker.IndexDictionary(spell.NewPlainTextDictionary(file), config)
+	suggestions, err := spellChecker.SuggestSimilar("misspelt", 5)
+
+Unlike a direct spell checker, the words are indexed into an
+auxiliary index of their n-grams, and candidates are found by n-gram
+overlap before being re-ranked with the configured StringDistance.
+*/
+type SpellChecker struct {
+	// the spell index
+	spellIndex store.Directory
+	// Boost value for start and end grams
+	bStart, bEnd float32
+	// don't modify the directory directly - see swapSearcher()
+	searcher *search.IndexSearcher
+	reader   index.DirectoryReader
+	// this lock synchronizes all possible modifications to the current
+	// index directory. It should not be possible to try modifying the
+	// same index concurrently.
+	modifyCurrentIndexLock sync.Mutex
+	searcherLock           sync.RWMutex
+	closed                 bool
+	// minimum score for hits generated by the spell checker query
+	accuracy float32
+	sd       StringDistance
+}
+
+/*
+Use the given directory as a spell checker index with a
+LevensteinDistance as the default StringDistance. The directory is
+created if it doesn't exist yet.
+*/
+func NewSpellChecker(spellIndex store.Directory) (*SpellChecker, error) {
+	return NewSpellCheckerWith(spellIndex, NewLevensteinDistance())
+}
+
+/*
+Use the given directory as a spell checker index with the given
+StringDistance measure. The directory is created if it doesn't exist
+yet.
+*/
+func NewSpellCheckerWith(spellIndex store.Directory, sd StringDistance) (*SpellChecker, error) {
+	sc := &SpellChecker{
+		bStart:   2.0,
+		bEnd:     1.0,
+		accuracy: DEFAULT_ACCURACY,
+		sd:       sd,
+	}
+	if err := sc.SetSpellIndex(spellIndex); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+/*
+Use a different index as the spell checker index or re-open the
+existing index if spellIndex is the same value as given in the
+constructor.
+*/
+func (sc *SpellChecker) SetSpellIndex(spellIndex store.Directory) error {
+	// this could be the same directory as the current spellIndex
+	// modifications to the directory should be synchronized
+	sc.modifyCurrentIndexLock.Lock()
+	defer sc.modifyCurrentIndexLock.Unlock()
+
+	if err := sc.ensureOpen(); err != nil {
+		return err
+	}
+	ok, err := index.IsIndexExists(spellIndex)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		writer, err := index.NewIndexWriter(spellIndex,
+			index.NewIndexWriterConfig(util.VERSION_LATEST, nil))
+		if err != nil {
+			return err
+		}
+		if err = writer.Close(); err != nil {
+			return err
+		}
+	}
+	return sc.swapSearcher(spellIndex)
+}
+
+/* Sets the StringDistance implementation for this SpellChecker instance. */
+func (sc *SpellChecker) SetStringDistance(sd StringDistance) {
+	sc.sd = sd
+}
+
+/* Returns the StringDistance instance used by this SpellChecker instance. */
+func (sc *SpellChecker) StringDistance() StringDistance {
+	return sc.sd
+}
+
+/* Sets the accuracy 0 < accuracy < 1; default DEFAULT_ACCURACY */
+func (sc *SpellChecker) SetAccuracy(acc float32) {
+	sc.accuracy = acc
+}
+
+/* The accuracy (minimum score) to be used, unless overridden in SuggestSimilarWith(). */
+func (sc *SpellChecker) Accuracy() float32 {
+	return sc.accuracy
+}
+
+/*
+Suggest similar words.
+
+As the Lucene similarity that is used to fetch the most relevant
+n-grammed terms is not the same as the edit distance strategy used to
+calculate the best matching spell-checked word from the hits that
+Lucene found, one usually has to retrieve a couple of numSug's in
+order to get the true best match.
+
+I.e. if numSug == 1, don't count on that suggestion being the best
+one. Thus, you should set this value to at least 5 for a good
+suggestion.
+*/
+func (sc *SpellChecker) SuggestSimilar(word string, numSug int) ([]string, error) {
+	return sc.SuggestSimilarWith(word, numSug, nil, "", SUGGEST_WHEN_NOT_IN_INDEX, sc.accuracy)
+}
+
+/*
+Suggest similar words (optionally restricted to a field of an index).
+
+Suggestions are only returned for terms of the given field of ir
+when ir is not nil, and ranked by the configured StringDistance, then
+by the term's frequency in ir. If mode is SUGGEST_WHEN_NOT_IN_INDEX
+and the word exists in ir, the word itself is returned. Hits whose
+distance is below accuracy are dropped.
+*/
+func (sc *SpellChecker) SuggestSimilarWith(word string, numSug int,
+	ir index.IndexReader, field string, mode SuggestMode, accuracy float32) ([]string, error) {
+
+	searcher, err := sc.obtainSearcher()
+	if err != nil {
+		return nil, err
+	}
+	defer sc.releaseSearcher()
+
+	if ir == nil || field == "" {
+		mode = SUGGEST_ALWAYS
+	}
+	if mode == SUGGEST_ALWAYS {
+		ir, field = nil, ""
+	}
+
+	lengthWord := len([]rune(word))
+
+	freq := 0
+	if ir != nil {
+		if freq, err = ir.DocFreq(index.NewTerm(field, word)); err != nil {
+			return nil, err
+		}
+	}
+	goalFreq := 0
+	if mode == SUGGEST_MORE_POPULAR {
+		goalFreq = freq
+	}
+	// if the word exists in the real index and we don't care for word
+	// frequency, return the word itself
+	if mode == SUGGEST_WHEN_NOT_IN_INDEX && freq > 0 {
+		return []string{word}, nil
+	}
+
+	var clauses []search.Query
+	for ng := minGram(lengthWord); ng <= maxGram(lengthWord); ng++ {
+		key := fmt.Sprintf("gram%v", ng) // form key
+		grams := formGrams(word, ng)     // form word into ngrams (allow dups too)
+		if len(grams) == 0 {
+			continue // hmm
+		}
+		if sc.bStart > 0 { // should we boost prefixes?
+			// matches start of word
+			clauses = append(clauses, boostedTermQuery(fmt.Sprintf("start%v", ng), grams[0], sc.bStart))
+		}
+		if sc.bEnd > 0 { // should we boost suffixes
+			// matches end of word
+			clauses = append(clauses, boostedTermQuery(fmt.Sprintf("end%v", ng), grams[len(grams)-1], sc.bEnd))
+		}
+		for _, gram := range grams {
+			clauses = append(clauses, search.NewTermQuery(index.NewTerm(key, gram)))
+		}
+	}
+	if len(clauses) == 0 {
+		return nil, nil
+	}
+	query := clauses[0]
+	if len(clauses) > 1 {
+		bq := search.NewBooleanQuery()
+		for _, q := range clauses {
+			bq.Add(q, search.SHOULD)
+		}
+		query = bq
+	}
+
+	maxHits := 10 * numSug
+	res, err := searcher.Search(query, nil, maxHits)
+	if err != nil {
+		return nil, err
+	}
+
+	var sugWords []*SuggestWord
+	for _, hit := range res.ScoreDocs {
+		doc, err := sc.reader.Document(hit.Doc)
+		if err != nil {
+			return nil, err
+		}
+		sugWord := &SuggestWord{String: doc.Get(F_WORD)} // get orig word
+		// don't suggest a word for itself, that would be silly
+		if sugWord.String == word {
+			continue
+		}
+		// edit distance
+		if sugWord.Score = sc.sd.GetDistance(word, sugWord.String); sugWord.Score < accuracy {
+			continue
+		}
+		if ir != nil { // use the user index
+			// freq in the index
+			if sugWord.Freq, err = ir.DocFreq(index.NewTerm(field, sugWord.String)); err != nil {
+				return nil, err
+			}
+			// don't suggest a word that is not present in the field
+			if (mode == SUGGEST_MORE_POPULAR && goalFreq > sugWord.Freq) || sugWord.Freq < 1 {
+				continue
+			}
+		}
+		sugWords = append(sugWords, sugWord)
+	}
+
+	sort.Sort(suggestWordsByScore(sugWords))
+	if len(sugWords) > numSug {
+		sugWords = sugWords[:numSug]
+	}
+	ans := make([]string, len(sugWords))
+	for i, w := range sugWords {
+		ans[i] = w.String
+	}
+	return ans, nil
+}
+
+func boostedTermQuery(field, text string, boost float32) search.Query {
+	q := search.NewTermQuery(index.NewTerm(field, text))
+	q.SetBoost(boost)
+	return q
+}
+
+/* Form all ngrams for a given word. */
+func formGrams(text string, ng int) []string {
+	runes := []rune(text)
+	if len(runes) < ng {
+		return nil
+	}
+	res := make([]string, len(runes)-ng+1)
+	for i, _ := range res {
+		res[i] = string(runes[i : i+ng])
+	}
+	return res
+}
+
+/* Removes all terms from the spell check index. */
+func (sc *SpellChecker) ClearIndex() error {
+	sc.modifyCurrentIndexLock.Lock()
+	defer sc.modifyCurrentIndexLock.Unlock()
+
+	if err := sc.ensureOpen(); err != nil {
+		return err
+	}
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, nil)
+	conf.SetOpenMode(index.OPEN_MODE_CREATE)
+	writer, err := index.NewIndexWriter(sc.spellIndex, conf)
+	if err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return sc.swapSearcher(sc.spellIndex)
+}
+
+/* Check whether the word exists in the index. */
+func (sc *SpellChecker) Exist(word string) (bool, error) {
+	if _, err := sc.obtainSearcher(); err != nil {
+		return false, err
+	}
+	defer sc.releaseSearcher()
+	n, err := sc.reader.DocFreq(index.NewTerm(F_WORD, word))
+	return n > 0, err
+}
+
+/*
+Indexes the data from the given Dictionary. Words shorter than three
+characters and words already present in the spell index are skipped.
+*/
+func (sc *SpellChecker) IndexDictionary(dict Dictionary, config *index.IndexWriterConfig) error {
+	sc.modifyCurrentIndexLock.Lock()
+	defer sc.modifyCurrentIndexLock.Unlock()
+
+	if err := sc.ensureOpen(); err != nil {
+		return err
+	}
+	dir := sc.spellIndex
+	writer, err := index.NewIndexWriter(dir, config)
+	if err != nil {
+		return err
+	}
+	if err = sc.indexDictionary(writer, dict); err != nil {
+		writer.Rollback()
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	// also re-open the spell index to see our own changes when the
+	// next suggestion is fetched:
+	return sc.swapSearcher(dir)
+}
+
+func (sc *SpellChecker) indexDictionary(writer *index.IndexWriter, dict Dictionary) error {
+	if _, err := sc.obtainSearcher(); err != nil {
+		return err
+	}
+	defer sc.releaseSearcher()
+
+	var termsEnums []model.TermsEnum
+	if sc.reader.MaxDoc() > 0 {
+		for _, ctx := range sc.reader.Leaves() {
+			if terms := ctx.Reader().(index.AtomicReader).Terms(F_WORD); terms != nil {
+				termsEnums = append(termsEnums, terms.Iterator(nil))
+			}
+		}
+	}
+
+	iter, err := dict.EntryIterator()
+	if err != nil {
+		return err
+	}
+terms:
+	for {
+		currentTerm, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		if currentTerm == nil {
+			return nil
+		}
+		word := string(currentTerm)
+		length := len([]rune(word))
+		if length < 3 {
+			continue // too short we bail but "too long" is fine...
+		}
+		for _, te := range termsEnums {
+			ok, err := te.SeekExact(currentTerm)
+			if err != nil {
+				return err
+			}
+			if ok {
+				continue terms
+			}
+		}
+		// ok index the word
+		doc := createDocument(word, minGram(length), maxGram(length))
+		if err = writer.AddDocument(doc.Fields()); err != nil {
+			return err
+		}
+	}
+}
+
+func maxGram(l int) int {
+	if l > 5 {
+		return 4
+	}
+	if l == 5 {
+		return 3
+	}
+	return 2
+}
+
+func minGram(l int) int {
+	if l > 5 {
+		return 3
+	}
+	if l == 5 {
+		return 2
+	}
+	return 1
+}
+
+// spellchecker does not use positional queries, but we want freqs
+// for scoring these multivalued n-gram fields.
+var gramFieldType = func() *document.FieldType {
+	ft := document.NewFieldTypeFrom(document.STRING_FIELD_TYPE_NOT_STORED)
+	ft.SetIndexOptions(model.INDEX_OPT_DOCS_AND_FREQS)
+	return ft
+}()
+
+func createDocument(text string, ng1, ng2 int) *document.Document {
+	doc := document.NewDocument()
+	doc.Add(document.NewStringField(F_WORD, text, document.STORE_YES)) // orig term
+	addGram(text, doc, ng1, ng2)
+	return doc
+}
+
+func addGram(text string, doc *document.Document, ng1, ng2 int) {
+	for ng := ng1; ng <= ng2; ng++ {
+		grams := formGrams(text, ng)
+		if len(grams) == 0 {
+			continue // may not be present if len==ng1
+		}
+		key := fmt.Sprintf("gram%v", ng)
+		for _, gram := range grams {
+			doc.Add(document.NewFieldFromString(key, gram, gramFieldType))
+		}
+		// only one term possible in the startXX and endXX fields,
+		// TF/pos and norms aren't needed.
+		doc.Add(document.NewStringField(fmt.Sprintf("start%v", ng), grams[0], document.STORE_NO))
+		doc.Add(document.NewStringField(fmt.Sprintf("end%v", ng), grams[len(grams)-1], document.STORE_NO))
+	}
+}
+
+func (sc *SpellChecker) obtainSearcher() (*search.IndexSearcher, error) {
+	sc.searcherLock.RLock()
+	if err := sc.ensureOpen(); err != nil {
+		sc.searcherLock.RUnlock()
+		return nil, err
+	}
+	return sc.searcher, nil
+}
+
+func (sc *SpellChecker) releaseSearcher() {
+	sc.searcherLock.RUnlock()
+}
+
+func (sc *SpellChecker) ensureOpen() error {
+	if sc.closed {
+		return errors.New("Spellchecker has been closed")
+	}
+	return nil
+}
+
+/*
+Close the IndexSearcher used by this SpellChecker. Further calls
+return an error.
+*/
+func (sc *SpellChecker) Close() error {
+	sc.searcherLock.Lock()
+	defer sc.searcherLock.Unlock()
+
+	if err := sc.ensureOpen(); err != nil {
+		return err
+	}
+	sc.closed = true
+	if sc.reader != nil {
+		err := sc.reader.Close()
+		sc.searcher, sc.reader = nil, nil
+		return err
+	}
+	return nil
+}
+
+func (sc *SpellChecker) swapSearcher(dir store.Directory) error {
+	// opening a searcher is possibly very expensive. We rather close it
+	// again if the SpellChecker was closed during this operation than
+	// block access to the current searcher while opening.
+	reader, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		return err
+	}
+
+	sc.searcherLock.Lock()
+	defer sc.searcherLock.Unlock()
+	if sc.closed {
+		reader.Close()
+		return errors.New("Spellchecker has been closed")
+	}
+	if sc.reader != nil {
+		if err = sc.reader.Close(); err != nil {
+			reader.Close()
+			return err
+		}
+	}
+	// set the spellindex in the sync block - ensure consistency.
+	sc.reader, sc.searcher = reader, search.NewIndexSearcher(reader)
+	sc.spellIndex = dir
+	return nil
+}
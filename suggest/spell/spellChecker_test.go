@@ -0,0 +1,131 @@
+package spell
+
+import (
+	std "github.com/balzaczyy/golucene/analysis/standard"
+	_ "github.com/balzaczyy/golucene/core/codec/lucene410"
+	docu "github.com/balzaczyy/golucene/core/document"
+	"github.com/balzaczyy/golucene/core/index"
+	"github.com/balzaczyy/golucene/core/search"
+	"github.com/balzaczyy/golucene/core/store"
+	"github.com/balzaczyy/golucene/core/util"
+	"strings"
+	"testing"
+)
+
+func newSpellChecker(t *testing.T, words ...string) *SpellChecker {
+	index.DefaultSimilarity = func() index.Similarity {
+		return search.NewDefaultSimilarity()
+	}
+	sc, err := NewSpellChecker(store.NewRAMDirectory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := NewPlainTextDictionary(strings.NewReader(strings.Join(words, "\n")))
+	conf := index.NewIndexWriterConfig(util.VERSION_LATEST, nil)
+	if err = sc.IndexDictionary(dict, conf); err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+func assertSuggestions(t *testing.T, sc *SpellChecker, word string, expected ...string) {
+	similar, err := sc.SuggestSimilar(word, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(similar, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected suggestions of '%v' %v, but was %v", word, expected, similar)
+	}
+}
+
+func TestSuggestSimilar(t *testing.T) {
+	sc := newSpellChecker(t, "five", "fifty", "nine", "ninety", "seven", "seventy")
+	defer sc.Close()
+
+	assertSuggestions(t, sc, "fvie", "five")
+	assertSuggestions(t, sc, "fiv", "five")
+	// equally similar words are ordered by their text
+	assertSuggestions(t, sc, "ninty", "ninety", "fifty")
+	assertSuggestions(t, sc, "sevn", "seven")
+	assertSuggestions(t, sc, "tousand")
+
+	for _, word := range []string{"five", "ninety"} {
+		if ok, err := sc.Exist(word); err != nil || !ok {
+			t.Errorf("expected '%v' to exist (%v)", word, err)
+		}
+	}
+	if ok, err := sc.Exist("fvie"); err != nil || ok {
+		t.Errorf("expected 'fvie' not to exist (%v)", err)
+	}
+
+	// a higher accuracy drops the less similar words
+	sc.SetAccuracy(0.8)
+	assertSuggestions(t, sc, "ninty", "ninety")
+}
+
+func TestSuggestSimilarInField(t *testing.T) {
+	sc := newSpellChecker(t, "five", "fifty", "nine", "ninety")
+	defer sc.Close()
+
+	dir := store.NewRAMDirectory()
+	w, err := index.NewIndexWriter(dir, index.NewIndexWriterConfig(util.VERSION_LATEST, std.NewStandardAnalyzer()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"nine lives", "ninety days"} {
+		d := docu.NewDocument()
+		d.Add(docu.NewTextFieldFromString("body", text, docu.STORE_NO))
+		if err = w.AddDocument(d.Fields()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := index.OpenDirectoryReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// a word of the field is returned as is
+	similar, err := sc.SuggestSimilarWith("nine", 2, r, "body", SUGGEST_WHEN_NOT_IN_INDEX, 0.5)
+	if err != nil || len(similar) != 1 || similar[0] != "nine" {
+		t.Errorf("expected [nine], but was %v (%v)", similar, err)
+	}
+	// only the words of the field are suggested
+	similar, err = sc.SuggestSimilarWith("ninty", 2, r, "body", SUGGEST_MORE_POPULAR, 0.5)
+	if err != nil || strings.Join(similar, ",") != "ninety,nine" {
+		t.Errorf("expected [ninety nine], but was %v (%v)", similar, err)
+	}
+	similar, err = sc.SuggestSimilarWith("fvie", 2, r, "body", SUGGEST_MORE_POPULAR, 0.5)
+	if err != nil || len(similar) != 0 {
+		t.Errorf("expected no suggestion, but was %v (%v)", similar, err)
+	}
+}
+
+func TestClearIndex(t *testing.T) {
+	sc := newSpellChecker(t, "five", "fifty")
+	defer sc.Close()
+
+	if err := sc.ClearIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := sc.Exist("five"); err != nil || ok {
+		t.Errorf("expected 'five' not to exist after clearing the index (%v)", err)
+	}
+	assertSuggestions(t, sc, "fvie")
+}
+
+func TestSpellCheckerClosed(t *testing.T) {
+	sc := newSpellChecker(t, "five")
+	if err := sc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.SuggestSimilar("fvie", 2); err == nil {
+		t.Error("expected an error suggesting with a closed spell checker")
+	}
+	if err := sc.Close(); err == nil {
+		t.Error("expected an error closing the spell checker twice")
+	}
+}
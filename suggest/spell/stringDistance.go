@@ -0,0 +1,275 @@
+package spell
+
+import (
+	"fmt"
+	"math"
+)
+
+// suggest/spell/StringDistance.java
+
+/* Interface for string distances. */
+type StringDistance interface {
+	// Returns a float between 0 and 1 based on how similar the
+	// specified strings are to one another. Returning a value of 1
+	// means the specified strings are identical and 0 means the
+	// string are maximally different.
+	GetDistance(s1, s2 string) float32
+}
+
+// suggest/spell/LevensteinDistance.java
+
+/* Levenstein edit distance class. */
+type LevensteinDistance struct{}
+
+func NewLevensteinDistance() *LevensteinDistance {
+	return &LevensteinDistance{}
+}
+
+func (ld *LevensteinDistance) GetDistance(target, other string) float32 {
+	sa, ta := []rune(target), []rune(other)
+	n, m := len(sa), len(ta)
+	if n == 0 || m == 0 {
+		if n == m {
+			return 1
+		}
+		return 0
+	}
+
+	p := make([]int, n+1) // 'previous' cost array, horizontally
+	d := make([]int, n+1) // cost array, horizontally
+
+	for i := 0; i <= n; i++ {
+		p[i] = i
+	}
+	for j := 1; j <= m; j++ {
+		tj := ta[j-1]
+		d[0] = j
+		for i := 1; i <= n; i++ {
+			cost := 1
+			if sa[i-1] == tj {
+				cost = 0
+			}
+			// minimum of cell to the left+1, to the top+1, diagonally
+			// left and up +cost
+			d[i] = minInt(minInt(d[i-1]+1, p[i]+1), p[i-1]+cost)
+		}
+		// copy current distance counts to 'previous row' distance counts
+		p, d = d, p
+	}
+
+	// our last action in the above loop was to switch d and p, so p
+	// now actually has the most recent cost counts
+	return 1 - float32(p[n])/float32(maxInt(n, m))
+}
+
+func (ld *LevensteinDistance) String() string {
+	return "levenstein"
+}
+
+// suggest/spell/JaroWinklerDistance.java
+
+/* Similarity measure for short strings such as person names. */
+type JaroWinklerDistance struct {
+	threshold float32
+}
+
+func NewJaroWinklerDistance() *JaroWinklerDistance {
+	return &JaroWinklerDistance{threshold: 0.7}
+}
+
+func (jw *JaroWinklerDistance) matches(s1, s2 []rune) (matches, transpositions, prefix, maxLength int) {
+	max, min := s2, s1
+	if len(s1) > len(s2) {
+		max, min = s1, s2
+	}
+	r := maxInt(len(max)/2-1, 0)
+	matchIndexes := make([]int, len(min))
+	for i, _ := range matchIndexes {
+		matchIndexes[i] = -1
+	}
+	matchFlags := make([]bool, len(max))
+	for mi, c1 := range min {
+		for xi, xn := maxInt(mi-r, 0), minInt(mi+r+1, len(max)); xi < xn; xi++ {
+			if !matchFlags[xi] && c1 == max[xi] {
+				matchIndexes[mi] = xi
+				matchFlags[xi] = true
+				matches++
+				break
+			}
+		}
+	}
+	ms1 := make([]rune, 0, matches)
+	ms2 := make([]rune, 0, matches)
+	for i, c := range min {
+		if matchIndexes[i] != -1 {
+			ms1 = append(ms1, c)
+		}
+	}
+	for i, c := range max {
+		if matchFlags[i] {
+			ms2 = append(ms2, c)
+		}
+	}
+	for mi, c := range ms1 {
+		if c != ms2[mi] {
+			transpositions++
+		}
+	}
+	for mi := 0; mi < len(min); mi++ {
+		if s1[mi] != s2[mi] {
+			break
+		}
+		prefix++
+	}
+	return matches, transpositions / 2, prefix, len(max)
+}
+
+func (jw *JaroWinklerDistance) GetDistance(s1, s2 string) float32 {
+	r1, r2 := []rune(s1), []rune(s2)
+	matches, transpositions, prefix, maxLength := jw.matches(r1, r2)
+	m := float32(matches)
+	if m == 0 {
+		return 0
+	}
+	j := (m/float32(len(r1)) + m/float32(len(r2)) + (m-float32(transpositions))/m) / 3
+	if j < jw.threshold {
+		return j
+	}
+	return j + float32(math.Min(0.1, 1/float64(maxLength)))*float32(prefix)*(1-j)
+}
+
+/*
+Sets the threshold used to determine when Winkler bonus should be
+used. Set to a negative value to get the Jaro distance.
+*/
+func (jw *JaroWinklerDistance) SetThreshold(threshold float32) {
+	jw.threshold = threshold
+}
+
+/*
+Returns the current value of the threshold used for adding the Winkler
+bonus. The default value is 0.7.
+*/
+func (jw *JaroWinklerDistance) Threshold() float32 {
+	return jw.threshold
+}
+
+func (jw *JaroWinklerDistance) String() string {
+	return fmt.Sprintf("jarowinkler(%v)", jw.threshold)
+}
+
+// suggest/spell/NGramDistance.java
+
+/*
+N-Gram version of edit distance based on paper by Grzegorz Kondrak,
+"N-gram similarity and distance". Proceedings of the Twelfth
+International Conference on String Processing and Information
+Retrieval (SPIRE 2005), pp. 115-126, Buenos Aires, Argentina,
+November 2005.
+http://www.cs.ualberta.ca/~kondrak/papers/spire05.pdf
+
+This implementation uses the position-based optimization to compute
+partial matches of n-gram sub-strings and adds a null-character
+prefix of size n-1 so that the first character is contained in the
+same number of n-grams as a middle character. Null-character prefix
+matches are discounted so that strings with no matching characters
+will return a distance of 0.
+*/
+type NGramDistance struct {
+	n int
+}
+
+/* Creates an N-Gram distance measure using n-grams of the specified size. */
+func NewNGramDistance(size int) *NGramDistance {
+	return &NGramDistance{size}
+}
+
+/* Creates an N-Gram distance measure using n-grams of size 2. */
+func NewDefaultNGramDistance() *NGramDistance {
+	return NewNGramDistance(2)
+}
+
+func (nd *NGramDistance) GetDistance(source, target string) float32 {
+	s, t := []rune(source), []rune(target)
+	sl, tl, n := len(s), len(t), nd.n
+	if sl == 0 || tl == 0 {
+		if sl == tl {
+			return 1
+		}
+		return 0
+	}
+
+	if sl < n || tl < n {
+		cost := 0
+		for i, ni := 0, minInt(sl, tl); i < ni; i++ {
+			if s[i] == t[i] {
+				cost++
+			}
+		}
+		return float32(cost) / float32(maxInt(sl, tl))
+	}
+
+	// construct sa with prefix
+	sa := make([]rune, sl+n-1)
+	copy(sa[n-1:], s)
+
+	p := make([]float32, sl+1) // 'previous' cost array, horizontally
+	d := make([]float32, sl+1) // cost array, horizontally
+	tj := make([]rune, n)      // jth n-gram of t
+
+	for i := 0; i <= sl; i++ {
+		p[i] = float32(i)
+	}
+
+	for j := 1; j <= tl; j++ {
+		// construct tj n-gram
+		if j < n {
+			for ti := 0; ti < n-j; ti++ {
+				tj[ti] = 0 // add prefix
+			}
+			copy(tj[n-j:], t[:j])
+		} else {
+			copy(tj, t[j-n:j])
+		}
+		d[0] = float32(j)
+		for i := 1; i <= sl; i++ {
+			cost, tn := 0, n
+			// compare sa to tj
+			for ni := 0; ni < n; ni++ {
+				if sa[i-1+ni] != tj[ni] {
+					cost++
+				} else if sa[i-1+ni] == 0 { // discount matches on prefix
+					tn--
+				}
+			}
+			ec := float32(cost) / float32(tn)
+			// minimum of cell to the left+1, to the top+1, diagonally
+			// left and up +cost
+			d[i] = float32(math.Min(math.Min(float64(d[i-1]+1), float64(p[i]+1)), float64(p[i-1]+ec)))
+		}
+		// copy current distance counts to 'previous row' distance counts
+		p, d = d, p
+	}
+
+	// our last action in the above loop was to switch d and p, so p
+	// now actually has the most recent cost counts
+	return 1 - p[sl]/float32(maxInt(tl, sl))
+}
+
+func (nd *NGramDistance) String() string {
+	return fmt.Sprintf("ngram(%v)", nd.n)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
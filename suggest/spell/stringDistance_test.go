@@ -0,0 +1,59 @@
+package spell
+
+import (
+	"math"
+	"testing"
+)
+
+func assertDistance(t *testing.T, sd StringDistance, s1, s2 string, expected float32) {
+	if d := sd.GetDistance(s1, s2); math.Abs(float64(d-expected)) > 0.001 {
+		t.Errorf("%v: expected distance(%v, %v) = %v, but was %v", sd, s1, s2, expected, d)
+	}
+}
+
+func TestLevensteinDistance(t *testing.T) {
+	sd := NewLevensteinDistance()
+	assertDistance(t, sd, "al", "al", 1)
+	assertDistance(t, sd, "martha", "marhta", 0.6666)
+	assertDistance(t, sd, "jones", "johnson", 0.4285)
+	assertDistance(t, sd, "abcvwxyz", "cabvwxyz", 0.75)
+	assertDistance(t, sd, "dwayne", "duane", 0.6666)
+	assertDistance(t, sd, "dixon", "dicksonx", 0.5)
+	assertDistance(t, sd, "six", "ten", 0)
+	assertDistance(t, sd, "", "", 1)
+	assertDistance(t, sd, "", "al", 0)
+	// distance is symmetric
+	assertDistance(t, sd, "marhta", "martha", 0.6666)
+}
+
+func TestJaroWinklerDistance(t *testing.T) {
+	sd := NewJaroWinklerDistance()
+	assertDistance(t, sd, "al", "al", 1)
+	assertDistance(t, sd, "martha", "marhta", 0.9611)
+	assertDistance(t, sd, "jones", "johnson", 0.8323)
+	assertDistance(t, sd, "abcvwxyz", "cabvwxyz", 0.9583)
+	assertDistance(t, sd, "dwayne", "duane", 0.84)
+	assertDistance(t, sd, "dixon", "dicksonx", 0.8133)
+	assertDistance(t, sd, "fvie", "ten", 0)
+}
+
+func TestNGramDistance(t *testing.T) {
+	for n := 1; n <= 3; n++ {
+		sd := NewNGramDistance(n)
+		assertDistance(t, sd, "al", "al", 1)
+		assertDistance(t, sd, "a", "a", 1)
+		assertDistance(t, sd, "a", "b", 0)
+		assertDistance(t, sd, "", "", 1)
+		assertDistance(t, sd, "", "al", 0)
+	}
+
+	sd := NewNGramDistance(1)
+	assertDistance(t, sd, "martha", "marhta", 0.6666)
+	assertDistance(t, sd, "jones", "johnson", 0.4285)
+
+	// matching bigrams score higher than the single characters alone
+	sd = NewDefaultNGramDistance()
+	if d1, d2 := sd.GetDistance("spelling", "speling"), sd.GetDistance("spelling", "spelled"); d1 <= d2 {
+		t.Errorf("expected %v > %v", d1, d2)
+	}
+}
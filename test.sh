@@ -4,6 +4,8 @@ go test github.com/balzaczyy/golucene/core/util
 go test github.com/balzaczyy/golucene/core/util/automaton
 go test github.com/balzaczyy/golucene/core/util/fst
 go test github.com/balzaczyy/golucene/core/util/packed
+go test github.com/balzaczyy/golucene/core/util/hnsw
+go test github.com/balzaczyy/golucene/core/util/graph
 go test github.com/balzaczyy/golucene/core/analysis/tokenattributes
 go test github.com/balzaczyy/golucene/core/analysis
 go test github.com/balzaczyy/golucene/core/document
@@ -23,9 +25,42 @@ go test github.com/balzaczyy/golucene/core/codec/blocktree
 go test github.com/balzaczyy/golucene/core/codec/compressing
 go test github.com/balzaczyy/golucene/core/codec/perfield
 go test github.com/balzaczyy/golucene/core/index
+go test github.com/balzaczyy/golucene/core/index/translog
 go test github.com/balzaczyy/golucene/core/search
+go test github.com/balzaczyy/golucene/core/search/builder
 go test github.com/balzaczyy/golucene/analysis/core
 go test github.com/balzaczyy/golucene/analysis/standard
+go test github.com/balzaczyy/golucene/analysis/path
+go test github.com/balzaczyy/golucene/analysis/commongrams
+go test github.com/balzaczyy/golucene/analysis/fr
+go test github.com/balzaczyy/golucene/analysis/it
+go test github.com/balzaczyy/golucene/analysis/de
+go test github.com/balzaczyy/golucene/analysis/nl
+go test github.com/balzaczyy/golucene/analysis/miscellaneous
+go test github.com/balzaczyy/golucene/analysis/ja
+go test github.com/balzaczyy/golucene/analysis/cn/smart
+go test github.com/balzaczyy/golucene/analysis/ko
+go test github.com/balzaczyy/golucene/analysis/stempel
+go test github.com/balzaczyy/golucene/analysis/pl
+go test github.com/balzaczyy/golucene/analysis/opennlp
+go test github.com/balzaczyy/golucene/analysis/collation
+go test -tags xtext github.com/balzaczyy/golucene/analysis/collation
+go test github.com/balzaczyy/golucene/analysis/tr
+go test github.com/balzaczyy/golucene/analysis/el
+go test github.com/balzaczyy/golucene/analysis/ga
+go test github.com/balzaczyy/golucene/analysis/ar
+go test github.com/balzaczyy/golucene/analysis/fa
+go test github.com/balzaczyy/golucene/analysis/he
+go test github.com/balzaczyy/golucene/analysis/th
 go test github.com/balzaczyy/golucene/analysis/util
 go test github.com/balzaczyy/golucene/queryparser/classic
+go test github.com/balzaczyy/golucene/suggest/analyzing
+go test github.com/balzaczyy/golucene/suggest/spell
+go test github.com/balzaczyy/golucene/misc
+go test github.com/balzaczyy/golucene/spatial
+go test github.com/balzaczyy/golucene/percolate
+go test github.com/balzaczyy/golucene/queries/mlt
+go test github.com/balzaczyy/golucene/highlight
+go test github.com/balzaczyy/golucene/ltr
+go test github.com/balzaczyy/golucene/facet
 go test github.com/balzaczyy/golucene/core_test
@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	ca "github.com/balzaczyy/golucene/core/analysis"
+	ta "github.com/balzaczyy/golucene/core/analysis/tokenattributes"
+	"reflect"
+	"testing"
+)
+
+// analysis/BaseTokenStreamTestCase.java
+
+/* A token read by ConsumeTokens. */
+type Token struct {
+	Term           string
+	Type           string
+	PosInc, PosLen int
+}
+
+/*
+Consumes ts as an indexer does, i.e. resets, reads, ends and closes
+it, and returns its tokens. The type, position increment and position
+length of a token are only filled if ts has the attribute.
+*/
+func ConsumeTokens(t *testing.T, ts ca.TokenStream) []Token {
+	t.Helper()
+	termAtt := ts.Attributes().Get("CharTermAttribute").(ta.CharTermAttribute)
+	var typeAtt ta.TypeAttribute
+	if ts.Attributes().Has("TypeAttribute") {
+		typeAtt = ts.Attributes().Get("TypeAttribute").(ta.TypeAttribute)
+	}
+	var posIncAtt ta.PositionIncrementAttribute
+	if ts.Attributes().Has("PositionIncrementAttribute") {
+		posIncAtt = ts.Attributes().Get("PositionIncrementAttribute").(ta.PositionIncrementAttribute)
+	}
+	var posLenAtt ta.PositionLengthAttribute
+	if ts.Attributes().Has("PositionLengthAttribute") {
+		posLenAtt = ts.Attributes().Get("PositionLengthAttribute").(ta.PositionLengthAttribute)
+	}
+
+	if err := ts.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	var tokens []Token
+	for {
+		ok, err := ts.IncrementToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		token := Token{Term: string(termAtt.Buffer()[:termAtt.Length()])}
+		if typeAtt != nil {
+			token.Type = typeAtt.Type()
+		}
+		if posIncAtt != nil {
+			token.PosInc = posIncAtt.PositionIncrement()
+		}
+		if posLenAtt != nil {
+			token.PosLen = posLenAtt.PositionLength()
+		}
+		tokens = append(tokens, token)
+	}
+	if err := ts.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tokens
+}
+
+/* Consumes ts, and checks that it produces the expected terms. */
+func AssertTokenStreamContents(t *testing.T, ts ca.TokenStream, expected ...string) {
+	t.Helper()
+	if terms := terms(ConsumeTokens(t, ts)); !reflect.DeepEqual(terms, expected) {
+		t.Errorf("expected %q, but got %q", expected, terms)
+	}
+}
+
+/* Checks that the analyzer a turns input into the expected terms. */
+func AssertAnalyzesTo(t *testing.T, a ca.Analyzer, input string, expected ...string) {
+	t.Helper()
+	ts, err := a.TokenStreamForString("dummy", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms := terms(ConsumeTokens(t, ts)); !reflect.DeepEqual(terms, expected) {
+		t.Errorf("%q: expected %q, but got %q", input, expected, terms)
+	}
+}
+
+func terms(tokens []Token) []string {
+	var ans []string
+	for _, token := range tokens {
+		ans = append(ans, token.Term)
+	}
+	return ans
+}
@@ -65,6 +65,11 @@ func (p *MockRandomMergePolicy) FindForcedMerges(segmentsInfos *SegmentInfos,
 	panic("not implemented yet")
 }
 
+func (p *MockRandomMergePolicy) FindForcedDeletesMerges(segmentInfos *SegmentInfos,
+	writer *IndexWriter) (MergeSpecification, error) {
+	return p.FindMerges(MergeTrigger(0), segmentInfos, writer)
+}
+
 func (p *MockRandomMergePolicy) Close() error { return nil }
 
 func (p *MockRandomMergePolicy) UseCompoundFile(infos *SegmentInfos,
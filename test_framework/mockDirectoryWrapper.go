@@ -203,6 +203,35 @@ func (w *MockDirectoryWrapper) Sync(names []string) error {
 	return nil
 }
 
+func (w *MockDirectoryWrapper) RenameFile(source, dest string) error {
+	w.Lock() // synchronized
+	defer w.Unlock()
+	w.maybeYield()
+	err := w.maybeThrowDeterministicException()
+	if err != nil {
+		return err
+	}
+	if w.crashed {
+		return errors.New("cannot rename after crash")
+	}
+	if _, ok := w.openFiles[source]; ok {
+		return w._fillOpenTrace(errors.New(fmt.Sprintf(
+			"MockDirectoryWrapper: file '%v' is still open: cannot rename", source)), source, true)
+	}
+	if err = w.Directory.RenameFile(source, dest); err != nil {
+		return err
+	}
+	if _, ok := w.unSyncedFiles[source]; ok {
+		delete(w.unSyncedFiles, source)
+		w.unSyncedFiles[dest] = true
+	}
+	if _, ok := w.createdFiles[source]; ok {
+		delete(w.createdFiles, source)
+		w.createdFiles[dest] = true
+	}
+	return nil
+}
+
 func (w *MockDirectoryWrapper) String() string {
 	// NOTE: do not maybeYield here, since it consumes randomness and
 	// can thus (unexpectedly during debugging) change the behavior of
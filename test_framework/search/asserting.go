@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"fmt"
 	"github.com/balzaczyy/golucene/core/index"
 	"github.com/balzaczyy/golucene/core/search"
@@ -32,7 +33,7 @@ func NewAssertingIndexSearcherFromContext(random *rand.Rand, ctx index.IndexRead
 	}
 }
 
-func (ss *AssertingIndexSearcher) CreateNormalizedWeight(query search.Query) (search.Weight, error) {
+func (ss *AssertingIndexSearcher) CreateNormalizedWeight(ctx context.Context, query search.Query) (search.Weight, error) {
 	panic("not implemented yet")
 }
 
@@ -44,7 +45,7 @@ func (ss *AssertingIndexSearcher) WrapFilter(original search.Query, filter searc
 	panic("not implemented yet")
 }
 
-func (ss *AssertingIndexSearcher) SearchLWC(leaves []*index.AtomicReaderContext,
+func (ss *AssertingIndexSearcher) SearchLWC(ctx context.Context, leaves []*index.AtomicReaderContext,
 	weight search.Weight, collector search.Collector) error {
 	panic("not implemented yet")
 }
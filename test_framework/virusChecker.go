@@ -0,0 +1,65 @@
+package test_framework
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+)
+
+// mockfile/VirusCheckingFS.java
+
+/*
+Acts like a virus checker on Windows, where random programs may open
+the files you just wrote in an un-shared way, preventing deletion
+(e.g. IndexWriter's deletes) for some time. Install it on a
+FSDirectory with:
+
+	fsDir.SetRemoveFile(NewVirusChecker(random).Remove)
+*/
+type VirusChecker struct {
+	sync.Locker
+	random  *rand.Rand
+	enabled bool
+}
+
+func NewVirusChecker(random *rand.Rand) *VirusChecker {
+	return &VirusChecker{
+		Locker:  &sync.Mutex{},
+		random:  rand.New(rand.NewSource(random.Int63())),
+		enabled: true,
+	}
+}
+
+func (vc *VirusChecker) Enable() {
+	vc.Lock()
+	defer vc.Unlock()
+	vc.enabled = true
+}
+
+func (vc *VirusChecker) Disable() {
+	vc.Lock()
+	defer vc.Unlock()
+	vc.enabled = false
+}
+
+func (vc *VirusChecker) IsEnabled() bool {
+	vc.Lock()
+	defer vc.Unlock()
+	return vc.enabled
+}
+
+/* Removes the file, unless the virus checker randomly decides it is holding it open. */
+func (vc *VirusChecker) Remove(path string) error {
+	vc.Lock()
+	denied := vc.enabled && vc.random.Intn(5) == 1
+	vc.Unlock()
+
+	if denied {
+		if _, err := os.Stat(path); err == nil {
+			return &os.PathError{Op: "remove", Path: path,
+				Err: fmt.Errorf("virus checker is holding the file open; access denied")}
+		}
+	}
+	return os.Remove(path)
+}